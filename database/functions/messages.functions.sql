@@ -133,24 +133,6 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
--- Function to update message search index
-CREATE OR REPLACE FUNCTION messages.update_search_index()
-RETURNS TRIGGER AS $$
-BEGIN
-    INSERT INTO messages.search_index (
-        message_id, conversation_id, user_id, content_tsvector
-    ) VALUES (
-        NEW.id, NEW.conversation_id, NEW.sender_user_id,
-        to_tsvector('english', COALESCE(NEW.content, ''))
-    )
-    ON CONFLICT (message_id) DO UPDATE SET
-        content_tsvector = to_tsvector('english', COALESCE(NEW.content, '')),
-        updated_at = NOW();
-    
-    RETURN NEW;
-END;
-$$ LANGUAGE plpgsql;
-
 -- Function to clean up expired typing indicators
 CREATE OR REPLACE FUNCTION messages.cleanup_expired_typing_indicators()
 RETURNS INTEGER AS $$
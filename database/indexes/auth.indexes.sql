@@ -61,6 +61,7 @@ CREATE INDEX idx_auth_security_events_severity ON auth.security_events(severity)
 CREATE INDEX idx_auth_security_events_created ON auth.security_events(created_at);
 CREATE INDEX idx_auth_security_events_suspicious ON auth.security_events(is_suspicious) WHERE is_suspicious = TRUE;
 CREATE INDEX idx_auth_security_events_ip ON auth.security_events(ip_address);
+CREATE INDEX idx_auth_security_events_user_created ON auth.security_events(user_id, created_at DESC, id DESC);
 
 -- Login history indexes
 CREATE INDEX idx_auth_login_history_user ON auth.login_history(user_id);
@@ -71,6 +72,7 @@ CREATE INDEX idx_auth_login_history_created ON auth.login_history(created_at);
 CREATE INDEX idx_auth_login_history_ip ON auth.login_history(ip_address);
 CREATE INDEX idx_auth_login_history_device ON auth.login_history(device_id);
 CREATE INDEX idx_auth_login_history_new_device ON auth.login_history(user_id, is_new_device) WHERE is_new_device = TRUE;
+CREATE INDEX idx_auth_login_history_user_created ON auth.login_history(user_id, created_at DESC, id DESC);
 
 -- API keys indexes
 CREATE INDEX idx_auth_api_keys_user ON auth.api_keys(user_id);
@@ -639,7 +639,8 @@ CREATE TABLE analytics.webhook_deliveries (
     max_attempts INTEGER DEFAULT 3,
     next_retry_at TIMESTAMPTZ,
     
-    -- Payload
+    -- Payload (kept so failed deliveries can be retried)
+    payload JSONB,
     payload_size_bytes INTEGER,
     
     -- Error
@@ -649,6 +650,9 @@ CREATE TABLE analytics.webhook_deliveries (
     created_at TIMESTAMPTZ DEFAULT NOW()
 );
 
+CREATE INDEX idx_webhook_deliveries_due ON analytics.webhook_deliveries(next_retry_at)
+    WHERE status = 'retrying';
+
 -- User Feedback & Ratings
 CREATE TABLE analytics.user_feedback (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -165,6 +165,14 @@ CREATE TABLE messages.messages (
     metadata JSONB DEFAULT '{}'::JSONB
 );
 
+-- Per-user deletions ("delete for me"); the message stays visible to everyone else
+CREATE TABLE messages.message_deletions (
+    message_id UUID NOT NULL REFERENCES messages.messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    deleted_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (message_id, user_id)
+);
+
 -- Message Reactions
 CREATE TABLE messages.reactions (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -457,6 +457,16 @@ CREATE TABLE notifications.user_stats (
     updated_at TIMESTAMPTZ DEFAULT NOW()
 );
 
+-- Notification Deduplication
+-- One row per event a user was notified about, so redelivered and duplicate
+-- events (new_message and message.sent for the same message) notify once
+CREATE TABLE notifications.notification_dedup (
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    dedup_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY(user_id, dedup_key)
+);
+
 -- Notification Subscriptions (for topics/channels)
 CREATE TABLE notifications.subscriptions (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -51,13 +51,6 @@ CREATE TRIGGER trigger_messages_increment_mentions
     WHEN (NEW.mentions IS NOT NULL AND jsonb_array_length(NEW.mentions) > 0)
     EXECUTE FUNCTION messages.increment_mention_count();
 
--- Trigger to update search index
-CREATE TRIGGER trigger_messages_update_search_index
-    AFTER INSERT OR UPDATE ON messages.messages
-    FOR EACH ROW
-    WHEN (NEW.message_type = 'text' AND NEW.content IS NOT NULL AND NOT NEW.is_deleted)
-    EXECUTE FUNCTION messages.update_search_index();
-
 -- Trigger to update reaction counts
 CREATE TRIGGER trigger_reactions_update_count_insert
     AFTER INSERT ON messages.reactions
@@ -125,7 +118,7 @@ $$ LANGUAGE plpgsql;
 CREATE TRIGGER trigger_messages_set_edited
     BEFORE UPDATE ON messages.messages
     FOR EACH ROW
-    WHEN (OLD.content IS DISTINCT FROM NEW.content)
+    WHEN (OLD.content IS DISTINCT FROM NEW.content AND NOT NEW.is_deleted)
     EXECUTE FUNCTION messages.set_edited_timestamp();
 
 -- Trigger to update delivery status counts
@@ -350,7 +343,8 @@ BEGIN
     WHERE cs.conversation_id = NEW.conversation_id
     AND cs.disappearing_messages_enabled = TRUE;
     
-    IF v_expire_after IS NOT NULL THEN
+    -- Respect an expiry the application already computed at send time
+    IF v_expire_after IS NOT NULL AND NEW.expires_at IS NULL THEN
         NEW.expire_after_seconds = v_expire_after;
         NEW.expires_at = NOW() + (v_expire_after || ' seconds')::INTERVAL;
     END IF;
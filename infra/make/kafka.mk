@@ -43,6 +43,10 @@ kafka-create-topics:
 	@echo ""
 	@docker exec echo-kafka kafka-topics --create --if-not-exists --bootstrap-server localhost:9092 --topic messages --partitions 3 --replication-factor 1
 	@docker exec echo-kafka kafka-topics --create --if-not-exists --bootstrap-server localhost:9092 --topic notifications --partitions 3 --replication-factor 1
+	@docker exec echo-kafka kafka-topics --create --if-not-exists --bootstrap-server localhost:9092 --topic notifications.push --partitions 3 --replication-factor 1
+	@docker exec echo-kafka kafka-topics --create --if-not-exists --bootstrap-server localhost:9092 --topic notifications.email --partitions 3 --replication-factor 1
+	@docker exec echo-kafka kafka-topics --create --if-not-exists --bootstrap-server localhost:9092 --topic analytics.webhooks --partitions 3 --replication-factor 1
+	@docker exec echo-kafka kafka-topics --create --if-not-exists --bootstrap-server localhost:9092 --topic analytics.events --partitions 3 --replication-factor 1
 	@echo ""
 	@echo "$(BRIGHT_GREEN)$(CHECK) Topics created$(NC)"
 	@echo ""
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- =====================================================
+-- Rollback Per-user Message Deletions
+-- =====================================================
+
+DROP TABLE IF EXISTS messages.message_deletions;
+
+-- Remove migration tracking
+DELETE FROM schema_migrations WHERE version = 3;
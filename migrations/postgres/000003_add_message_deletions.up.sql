@@ -0,0 +1,18 @@
+-- =====================================================
+-- Per-user Message Deletions
+-- Description: Backs "delete for me". messages.deleted_for holds a single
+-- scope ('everyone' or 'sender'), so the users who hid a message are kept
+-- in their own table and history queries filter on it.
+-- =====================================================
+
+CREATE TABLE IF NOT EXISTS messages.message_deletions (
+    message_id UUID NOT NULL REFERENCES messages.messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    deleted_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (message_id, user_id)
+);
+
+-- Track migration
+INSERT INTO schema_migrations (version, description)
+VALUES (3, 'Add per-user message deletions')
+ON CONFLICT (version) DO NOTHING;
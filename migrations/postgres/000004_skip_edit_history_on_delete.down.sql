@@ -0,0 +1,14 @@
+-- =====================================================
+-- Rollback Skip Edit History on Delete
+-- =====================================================
+
+DROP TRIGGER IF EXISTS trigger_messages_set_edited ON messages.messages;
+
+CREATE TRIGGER trigger_messages_set_edited
+    BEFORE UPDATE ON messages.messages
+    FOR EACH ROW
+    WHEN (OLD.content IS DISTINCT FROM NEW.content)
+    EXECUTE FUNCTION messages.set_edited_timestamp();
+
+-- Remove migration tracking
+DELETE FROM schema_migrations WHERE version = 4;
@@ -0,0 +1,24 @@
+-- =====================================================
+-- Skip Edit History on Delete
+-- Description: Deleting or expiring a message blanks its content, which
+-- fired trigger_messages_set_edited and copied the old content back into
+-- edit_history. Only non-deleted rows are recorded as edits now.
+-- =====================================================
+
+DROP TRIGGER IF EXISTS trigger_messages_set_edited ON messages.messages;
+
+CREATE TRIGGER trigger_messages_set_edited
+    BEFORE UPDATE ON messages.messages
+    FOR EACH ROW
+    WHEN (OLD.content IS DISTINCT FROM NEW.content AND NOT NEW.is_deleted)
+    EXECUTE FUNCTION messages.set_edited_timestamp();
+
+-- Clear history already leaked into deleted messages
+UPDATE messages.messages
+SET edit_history = '[]'::JSONB
+WHERE is_deleted = TRUE AND edit_history <> '[]'::JSONB;
+
+-- Track migration
+INSERT INTO schema_migrations (version, description)
+VALUES (4, 'Skip edit history on delete')
+ON CONFLICT (version) DO NOTHING;
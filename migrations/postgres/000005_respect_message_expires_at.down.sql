@@ -0,0 +1,25 @@
+-- =====================================================
+-- Rollback Respect Message expires_at
+-- =====================================================
+
+CREATE OR REPLACE FUNCTION messages.set_message_expiration()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_expire_after INTEGER;
+BEGIN
+    SELECT cs.disappearing_messages_duration INTO v_expire_after
+    FROM messages.conversation_settings cs
+    WHERE cs.conversation_id = NEW.conversation_id
+    AND cs.disappearing_messages_enabled = TRUE;
+    
+    IF v_expire_after IS NOT NULL THEN
+        NEW.expire_after_seconds = v_expire_after;
+        NEW.expires_at = NOW() + (v_expire_after || ' seconds')::INTERVAL;
+    END IF;
+    
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Remove migration tracking
+DELETE FROM schema_migrations WHERE version = 5;
@@ -0,0 +1,31 @@
+-- =====================================================
+-- Respect Message expires_at
+-- Description: set_message_expiration overwrote the expiry the message
+-- service computes at send time with the conversation default. The
+-- conversation duration now only applies when expires_at is unset.
+-- =====================================================
+
+CREATE OR REPLACE FUNCTION messages.set_message_expiration()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_expire_after INTEGER;
+BEGIN
+    SELECT cs.disappearing_messages_duration INTO v_expire_after
+    FROM messages.conversation_settings cs
+    WHERE cs.conversation_id = NEW.conversation_id
+    AND cs.disappearing_messages_enabled = TRUE;
+    
+    -- Respect an expiry the application already computed at send time
+    IF v_expire_after IS NOT NULL AND NEW.expires_at IS NULL THEN
+        NEW.expire_after_seconds = v_expire_after;
+        NEW.expires_at = NOW() + (v_expire_after || ' seconds')::INTERVAL;
+    END IF;
+    
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Track migration
+INSERT INTO schema_migrations (version, description)
+VALUES (5, 'Respect message expires_at')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,29 @@
+-- =====================================================
+-- Rollback Drop Search Index Trigger
+-- =====================================================
+
+CREATE OR REPLACE FUNCTION messages.update_search_index()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO messages.search_index (
+        message_id, conversation_id, user_id, content_tsvector
+    ) VALUES (
+        NEW.id, NEW.conversation_id, NEW.sender_user_id,
+        to_tsvector('english', COALESCE(NEW.content, ''))
+    )
+    ON CONFLICT (message_id) DO UPDATE SET
+        content_tsvector = to_tsvector('english', COALESCE(NEW.content, '')),
+        updated_at = NOW();
+    
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_messages_update_search_index
+    AFTER INSERT OR UPDATE ON messages.messages
+    FOR EACH ROW
+    WHEN (NEW.message_type = 'text' AND NEW.content IS NOT NULL AND NOT NEW.is_deleted)
+    EXECUTE FUNCTION messages.update_search_index();
+
+-- Remove migration tracking
+DELETE FROM schema_migrations WHERE version = 6;
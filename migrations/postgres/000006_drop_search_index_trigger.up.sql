@@ -0,0 +1,16 @@
+-- =====================================================
+-- Drop Search Index Trigger
+-- Description: update_search_index always built 'english' vectors and
+-- raced the message service, which indexes with the configured
+-- search.language. The service is now the only writer of search_index.
+-- Rows indexed by the trigger keep their vectors until the message is
+-- edited.
+-- =====================================================
+
+DROP TRIGGER IF EXISTS trigger_messages_update_search_index ON messages.messages;
+DROP FUNCTION IF EXISTS messages.update_search_index();
+
+-- Track migration
+INSERT INTO schema_migrations (version, description)
+VALUES (6, 'Drop search index trigger')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,8 @@
+-- =====================================================
+-- Rollback Add Notification Dedup
+-- =====================================================
+
+DROP TABLE IF EXISTS notifications.notification_dedup;
+
+-- Remove migration tracking
+DELETE FROM schema_migrations WHERE version = 7;
@@ -0,0 +1,19 @@
+-- =====================================================
+-- Add Notification Dedup
+-- Description: Records which events each user was already notified
+-- about. The notification worker claims a row before notifying, so a
+-- retried batch and the new_message/message.sent pair for the same
+-- message notify a recipient once.
+-- =====================================================
+
+CREATE TABLE IF NOT EXISTS notifications.notification_dedup (
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    dedup_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY(user_id, dedup_key)
+);
+
+-- Track migration
+INSERT INTO schema_migrations (version, description)
+VALUES (7, 'Add notification dedup')
+ON CONFLICT (version) DO NOTHING;
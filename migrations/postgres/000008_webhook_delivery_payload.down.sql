@@ -0,0 +1,11 @@
+-- =====================================================
+-- Rollback Webhook Delivery Payload
+-- =====================================================
+
+DROP INDEX IF EXISTS analytics.idx_webhook_deliveries_due;
+
+ALTER TABLE analytics.webhook_deliveries
+    DROP COLUMN IF EXISTS payload;
+
+-- Remove migration tracking
+DELETE FROM schema_migrations WHERE version = 8;
@@ -0,0 +1,17 @@
+-- =====================================================
+-- Webhook Delivery Payload
+-- Description: Keeps each webhook delivery's payload so the analytics
+-- service can retry failed deliveries, and indexes the retries the
+-- sweeper looks up by next_retry_at.
+-- =====================================================
+
+ALTER TABLE analytics.webhook_deliveries
+    ADD COLUMN IF NOT EXISTS payload JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON analytics.webhook_deliveries(next_retry_at)
+    WHERE status = 'retrying';
+
+-- Track migration
+INSERT INTO schema_migrations (version, description)
+VALUES (8, 'Webhook delivery payload')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,9 @@
+-- =====================================================
+-- Rollback Add Auth History Cursor Indexes
+-- =====================================================
+
+DROP INDEX IF EXISTS auth.idx_auth_login_history_user_created;
+DROP INDEX IF EXISTS auth.idx_auth_security_events_user_created;
+
+-- Remove migration tracking
+DELETE FROM schema_migrations WHERE version = 9;
@@ -0,0 +1,17 @@
+-- =====================================================
+-- Add Auth History Cursor Indexes
+-- Description: Serves the cursor-paginated login history and security
+-- event endpoints, which page through a user's rows newest first by
+-- (created_at, id).
+-- =====================================================
+
+CREATE INDEX IF NOT EXISTS idx_auth_security_events_user_created
+    ON auth.security_events(user_id, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_auth_login_history_user_created
+    ON auth.login_history(user_id, created_at DESC, id DESC);
+
+-- Track migration
+INSERT INTO schema_migrations (version, description)
+VALUES (9, 'Add auth history cursor indexes')
+ON CONFLICT (version) DO NOTHING;
@@ -1 +1,460 @@
-package server
+package main
+
+import (
+	"analytics-service/internal/abtest"
+	"analytics-service/internal/config"
+	"analytics-service/internal/health"
+	healthCheckers "analytics-service/internal/health/checkers"
+	"analytics-service/internal/ingest"
+	"analytics-service/internal/repo"
+	"analytics-service/internal/rollup"
+	"analytics-service/internal/scheduler"
+	"analytics-service/internal/webhook"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"shared/pkg/cache"
+	"shared/pkg/cache/redis"
+	"shared/pkg/database"
+	"shared/pkg/database/postgres"
+	"shared/pkg/logger"
+	adapter "shared/pkg/logger/adapter"
+	"shared/pkg/messaging"
+	"shared/pkg/messaging/kafka"
+	env "shared/server/env"
+	"shared/server/middleware"
+	"shared/server/response"
+	"shared/server/router"
+	"shared/server/server"
+	"shared/server/shutdown"
+)
+
+func createLogger(name string) logger.Logger {
+	log, err := adapter.NewZap(logger.Config{
+		Level:   logger.GetLoggerLevel(),
+		Format:  logger.GetLoggerFormat(),
+		Output:  logger.GetLoggerOutput(),
+		File:    logger.GetLoggerFile(),
+		Service: name,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create logger: %v", err))
+	}
+	return log
+}
+
+func loadConfig() (*config.Config, error) {
+	configLogger := createLogger("config-loader")
+	defer configLogger.Sync()
+
+	appEnv := env.GetEnv("APP_ENV", "development")
+	configPath := env.GetEnv("CONFIG_PATH", "configs/config.yaml")
+	configLogger.Debug("Loading config from environment variables",
+		logger.String("configPath", configPath),
+		logger.String("environment", appEnv))
+
+	cfg, err := config.Load(configPath, appEnv)
+	if err != nil {
+		configLogger.Error("Failed to load config", logger.Error(err))
+		return nil, err
+	}
+
+	if err := config.ValidateAndSetDefaults(cfg); err != nil {
+		configLogger.Error("Invalid configuration", logger.Error(err))
+		return nil, err
+	}
+
+	configLogger.Debug("Config loaded successfully")
+	return cfg, nil
+}
+
+func createDBClient(cfg config.PostgresConfig, log logger.Logger) (database.Database, error) {
+	log.Debug("Creating database client")
+	dbClient, err := postgres.New(database.Config{
+		Host:            cfg.Host,
+		Port:            cfg.Port,
+		User:            cfg.User,
+		Password:        cfg.Password,
+		Database:        cfg.DBName,
+		SSLMode:         cfg.SSLMode,
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Database client created successfully")
+	return dbClient, nil
+}
+
+func createCacheClient(cfg config.CacheConfig, log logger.Logger) (cache.Cache, error) {
+	log.Debug("Creating cache client")
+	cacheClient, err := redis.New(cache.Config{
+		Host:         cfg.Host,
+		Port:         cfg.Port,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		MaxRetries:   cfg.MaxRetries,
+		PoolSize:     cfg.PoolSize,
+		MinIdleConns: cfg.MinIdleConns,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Cache client created successfully")
+	return cacheClient, nil
+}
+
+// createSweepLocker returns the Redis locker that keeps each background sweep
+// to one replica, or nil when no Redis cache is configured
+func createSweepLocker(cacheClient cache.Cache, log logger.Logger) *redis.Locker {
+	if cacheClient == nil {
+		log.Warn("Cache is disabled; background sweeps run without a distributed lock")
+		return nil
+	}
+	locker, err := redis.NewLockerFromCache(cacheClient)
+	if err != nil {
+		log.Warn("Background sweeps run without a distributed lock", logger.Error(err))
+		return nil
+	}
+	return locker
+}
+
+func createKafkaConsumer(cfg config.KafkaConfig, log logger.Logger) (messaging.Consumer, error) {
+	log.Debug("Creating Kafka consumer",
+		logger.String("brokers", fmt.Sprintf("%v", cfg.Brokers)),
+		logger.String("group_id", cfg.GroupID),
+	)
+	consumer, err := kafka.NewConsumer(messaging.ConsumerConfig{
+		Brokers:      cfg.Brokers,
+		ClientID:     cfg.ClientID,
+		GroupID:      cfg.GroupID,
+		MaxRetries:   cfg.MaxRetries,
+		RetryBackoff: int(cfg.RetryBackoff.Milliseconds()),
+		Logger:       log,
+
+		DeadLetterEnabled: cfg.DeadLetterEnabled,
+		DeadLetterSuffix:  cfg.DeadLetterSuffix,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Kafka consumer created successfully",
+		logger.String("group_id", cfg.GroupID),
+	)
+	return consumer, nil
+}
+
+func createKafkaProducer(cfg config.KafkaConfig, log logger.Logger) (messaging.Producer, error) {
+	log.Debug("Creating Kafka producer",
+		logger.String("brokers", fmt.Sprintf("%v", cfg.Brokers)),
+	)
+	producer, err := kafka.NewProducer(messaging.Config{
+		Brokers:    cfg.Brokers,
+		ClientID:   cfg.ClientID,
+		MaxRetries: cfg.MaxRetries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Kafka producer created successfully",
+		logger.String("brokers", fmt.Sprintf("%v", cfg.Brokers)),
+	)
+	return producer, nil
+}
+
+func createRouter(ingestHandler *ingest.Handler, abtestHandler *abtest.Handler, healthHandler *health.Handler, cfg *config.Config, log logger.Logger) *router.Router {
+	middlewares := []router.Middleware{
+		router.Middleware(middleware.RealIP(cfg.Server.TrustedProxies)),
+		router.Middleware(middleware.Timeout(30 * time.Second)),
+		router.Middleware(middleware.RequestReceivedLogger(log)),
+		// Telemetry arrives in bursts from every client, so the limit is
+		// per client IP across all endpoints
+		router.Middleware(middleware.RateLimit(middleware.RateLimitConfig{
+			RequestsPerWindow: cfg.Ingestion.RequestsPerWindow,
+			Window:            cfg.Ingestion.RateLimitWindow,
+			KeyFunc: func(remoteAddr string, path string) string {
+				return remoteAddr
+			},
+		})),
+	}
+	if cfg.Ingestion.GeoIPEndpoint != "" {
+		middlewares = append(middlewares, router.Middleware(middleware.GeoIP(
+			cfg.Ingestion.GeoIPEndpoint,
+			log,
+			middleware.WithGeoIPTimeout(cfg.Ingestion.GeoIPTimeout),
+		)))
+	}
+
+	builder := router.NewBuilder().
+		WithHealthEndpoint("/health", healthHandler.Health).
+		WithLogLevelEndpoint("/admin/loglevel", log, env.LogLevelEndpointEnabled()).
+		WithNotFoundHandler(func(w http.ResponseWriter, r *http.Request) {
+			response.RouteNotFoundError(r.Context(), r, w, log)
+		}).
+		WithMethodNotAllowedHandler(func(w http.ResponseWriter, r *http.Request) {
+			response.MethodNotAllowedError(r.Context(), r, w)
+		}).
+		WithBodyLimit(cfg.Ingestion.MaxBodySize).
+		WithEarlyMiddleware(middlewares...).
+		WithLateMiddleware(
+			router.Middleware(middleware.Recovery(log)),
+			router.Middleware(middleware.RequestCompletedLogger(log)),
+		).
+		WithRoutesGroup("/events", func(rg *router.RouteGroup) {
+			rg.Post("", ingestHandler.Track)            // Record one event
+			rg.Post("/batch", ingestHandler.TrackBatch) // Record many events at once
+		}).
+		WithRoutesGroup("/experiments", func(rg *router.RouteGroup) {
+			rg.Post("/{name}/assign", abtestHandler.Assign)                // Get or assign the user's variant
+			rg.Post("/{name}/conversions", abtestHandler.RecordConversion) // Record a conversion for the user's variant
+		})
+
+	return builder.Build()
+}
+
+func webhookTargets(cfg config.WebhooksConfig) []webhook.Target {
+	targets := make([]webhook.Target, 0, len(cfg.Targets))
+	for _, t := range cfg.Targets {
+		targets = append(targets, webhook.Target{
+			Name:        t.Name,
+			URL:         t.URL,
+			Secret:      t.Secret,
+			Timeout:     t.Timeout,
+			MaxAttempts: t.MaxAttempts,
+			Events:      t.Events,
+		})
+	}
+	return targets
+}
+
+func setupShutdownManager(srv *server.Server, consumer messaging.Consumer, producer messaging.Producer, workers []*scheduler.Worker, log logger.Logger, cfg *config.Config) *shutdown.Manager {
+	shutdownMgr := shutdown.New(
+		shutdown.WithTimeout(cfg.Shutdown.Timeout),
+		shutdown.WithLogger(log),
+	)
+
+	shutdownMgr.RegisterWithPriority(
+		"http-server",
+		shutdown.ServerShutdownHook(srv),
+		shutdown.PriorityHigh,
+	)
+
+	shutdownMgr.RegisterWithPriority(
+		"kafka-consumer",
+		shutdown.Hook(func(ctx context.Context) error {
+			log.Info("Draining Kafka consumer")
+			return consumer.Close()
+		}),
+		shutdown.PriorityHigh,
+	)
+
+	shutdownMgr.RegisterWithPriority(
+		"background-sweeps",
+		shutdown.Hook(func(ctx context.Context) error {
+			log.Info("Stopping background sweeps")
+			for _, worker := range workers {
+				if err := worker.Stop(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+		shutdown.PriorityHigh,
+	)
+
+	shutdownMgr.RegisterWithPriority(
+		"kafka-producer-flush",
+		shutdown.Hook(func(ctx context.Context) error {
+			log.Info("Flushing in-flight Kafka messages")
+			return producer.Flush(ctx)
+		}),
+		shutdown.PriorityNormal,
+	)
+
+	shutdownMgr.RegisterWithPriority(
+		"logger-sync",
+		shutdown.Hook(func(ctx context.Context) error {
+			log.Info("Syncing logger before shutdown")
+			return log.Sync()
+		}),
+		shutdown.PriorityLow,
+	)
+
+	return shutdownMgr
+}
+
+func main() {
+	env.LoadEnv()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load configuration: %v", err))
+	}
+
+	log := createLogger(cfg.Service.Name)
+	defer log.Sync()
+
+	log.Info("Starting Analytics Service",
+		logger.String("service", cfg.Service.Name),
+		logger.String("version", cfg.Service.Version),
+		logger.String("environment", cfg.Service.Environment),
+	)
+
+	dbClient, err := createDBClient(cfg.Database.Postgres, log)
+	if err != nil {
+		log.Fatal("Failed to create database client", logger.Error(err))
+	}
+	defer func() {
+		if dbClient != nil {
+			log.Info("Closing database connection")
+			if err := dbClient.Close(); err != nil {
+				log.Error("Failed to close database connection", logger.Error(err))
+			}
+		}
+	}()
+
+	var cacheClient cache.Cache
+	if cfg.Cache.Enabled {
+		cacheClient, err = createCacheClient(cfg.Cache, log)
+		if err != nil {
+			log.Fatal("Failed to create cache client", logger.Error(err))
+		}
+		defer func() {
+			if cacheClient != nil {
+				log.Info("Closing cache connection")
+				if err := cacheClient.Close(); err != nil {
+					log.Error("Failed to close cache connection", logger.Error(err))
+				}
+			}
+		}()
+	} else {
+		log.Info("Cache is disabled in configuration")
+	}
+
+	producer, err := createKafkaProducer(cfg.Kafka, log)
+	if err != nil {
+		log.Fatal("Failed to create Kafka producer", logger.Error(err))
+	}
+	defer func() {
+		if producer != nil {
+			log.Info("Closing Kafka producer")
+			if err := producer.Close(); err != nil {
+				log.Error("Failed to close Kafka producer", logger.Error(err))
+			}
+		}
+	}()
+
+	consumer, err := createKafkaConsumer(cfg.Kafka, log)
+	if err != nil {
+		log.Fatal("Failed to create Kafka consumer", logger.Error(err))
+	}
+
+	dispatcher := webhook.NewDispatcher(
+		repo.NewWebhookRepository(dbClient, log),
+		webhookTargets(cfg.Webhooks),
+		webhook.Backoff{Base: cfg.Webhooks.BackoffBase, Max: cfg.Webhooks.BackoffMax},
+		log,
+	)
+	if err := consumer.Subscribe(context.Background(), []string{cfg.Kafka.WebhookTopic}, dispatcher.HandleEvent); err != nil {
+		log.Fatal("Failed to subscribe to webhook events", logger.Error(err))
+	}
+
+	sweepLocker := createSweepLocker(cacheClient, log)
+	retryWorker := scheduler.NewWorker("webhook-retry", func(ctx context.Context) (int, error) {
+		return dispatcher.RetryDue(ctx, cfg.Webhooks.RetryBatchSize)
+	}, sweepLocker, scheduler.Config{
+		Interval: cfg.Webhooks.RetryInterval,
+		LockTTL:  cfg.Webhooks.LockTTL,
+	}, log)
+	retryWorker.Start()
+
+	rollupService := rollup.NewService(repo.NewRollupRepository(dbClient, log), rollup.Config{
+		LookbackDays: cfg.Rollup.LookbackDays,
+		ChurnDays:    cfg.Rollup.ChurnDays,
+	}, log)
+	rollupWorker := scheduler.NewWorker("daily-rollup", rollupService.RollupRecent, sweepLocker, scheduler.Config{
+		Interval: cfg.Rollup.Interval,
+		LockTTL:  cfg.Rollup.LockTTL,
+	}, log)
+	rollupWorker.Start()
+
+	ingestService := ingest.NewService(repo.NewEventRepository(dbClient, log), producer, ingest.Config{
+		Topic:             cfg.Kafka.EventsTopic,
+		MaxBatchSize:      cfg.Ingestion.MaxBatchSize,
+		MaxPropertiesSize: cfg.Ingestion.MaxPropertiesSize,
+		MaxFutureSkew:     cfg.Ingestion.MaxFutureSkew,
+		MaxEventAge:       cfg.Ingestion.MaxEventAge,
+	}, log)
+
+	healthMgr := health.NewManager(cfg.Service.Name, cfg.Service.Version)
+	healthMgr.SetCacheTTL(cfg.Server.HealthCacheTTL)
+	healthMgr.RegisterChecker(healthCheckers.NewDatabaseChecker(dbClient))
+	if cacheClient != nil {
+		healthMgr.RegisterChecker(healthCheckers.NewCacheChecker(cacheClient))
+	}
+
+	abtestService := abtest.NewService(repo.NewABTestRepository(dbClient, log), log)
+
+	routerInstance := createRouter(
+		ingest.NewHandler(ingestService, log),
+		abtest.NewHandler(abtestService, log),
+		health.NewHandler(healthMgr),
+		cfg,
+		log,
+	)
+
+	srv, err := server.New(&server.Config{
+		Port:            cfg.Server.Port,
+		Host:            cfg.Server.Host,
+		ReadTimeout:     cfg.Server.ReadTimeout,
+		WriteTimeout:    cfg.Server.WriteTimeout,
+		IdleTimeout:     cfg.Server.IdleTimeout,
+		ShutdownTimeout: cfg.Server.ShutdownTimeout,
+		MaxHeaderBytes:  cfg.Server.MaxHeaderBytes,
+		Handler:         routerInstance.Mux(),
+	}, log)
+	if err != nil {
+		log.Fatal("Failed to create server", logger.Error(err))
+	}
+
+	shutdownMgr := setupShutdownManager(srv, consumer, producer, []*scheduler.Worker{retryWorker, rollupWorker}, log, cfg)
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		log.Info("Analytics Service is running",
+			logger.String("address", srv.Address()),
+			logger.String("events_topic", cfg.Kafka.EventsTopic),
+			logger.String("webhook_topic", cfg.Kafka.WebhookTopic),
+			logger.Int("webhook_targets", len(cfg.Webhooks.Targets)),
+		)
+		serverErrors <- srv.Start()
+	}()
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- shutdownMgr.Wait()
+	}()
+
+	select {
+	case err := <-serverErrors:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server error", logger.Error(err))
+		}
+		log.Info("Server stopped")
+
+	case err := <-shutdownDone:
+		if err != nil {
+			log.Error("Shutdown completed with errors", logger.Error(err))
+		}
+		log.Info("Analytics Service stopped gracefully")
+	}
+}
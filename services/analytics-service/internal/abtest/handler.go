@@ -0,0 +1,112 @@
+package abtest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"shared/pkg/logger"
+	"shared/server/headers"
+	"shared/server/response"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// AssignResponse is the body of POST /experiments/{name}/assign. Variant is
+// nil when the user is not enrolled and should get the default behaviour.
+type AssignResponse struct {
+	Enrolled bool     `json:"enrolled"`
+	Variant  *Variant `json:"variant,omitempty"`
+}
+
+// ConversionRequest is the body of POST /experiments/{name}/conversions
+type ConversionRequest struct {
+	Value float64 `json:"value"`
+}
+
+// Handler serves the A/B test endpoints for the user the API gateway
+// authenticated
+type Handler struct {
+	service *Service
+	log     logger.Logger
+}
+
+// NewHandler creates the A/B test HTTP handler
+func NewHandler(service *Service, log logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		log:     log,
+	}
+}
+
+// Assign handles POST /experiments/{name}/assign, returning the user's
+// variant and assigning one on first use. Platform targeting reads the
+// X-Device-Platform header; country targeting reads the GeoIP middleware.
+func (h *Handler) Assign(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := WithAttributes(r.Context(), Attributes{Platform: r.Header.Get(headers.XDevicePlatform)})
+	variant, err := h.service.Assign(ctx, userID, mux.Vars(r)["name"])
+	if errors.Is(err, ErrNotEnrolled) {
+		response.JSONWithContext(r.Context(), r, w, http.StatusOK, AssignResponse{})
+		return
+	}
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	response.JSONWithContext(r.Context(), r, w, http.StatusOK, AssignResponse{Enrolled: true, Variant: variant})
+}
+
+// RecordConversion handles POST /experiments/{name}/conversions. The body is
+// optional; without one the conversion adds no value.
+func (h *Handler) RecordConversion(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	var req ConversionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequestError(r.Context(), r, w, "Invalid JSON body", err)
+		return
+	}
+
+	if err := h.service.RecordConversion(r.Context(), userID, mux.Vars(r)["name"], req.Value); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userID reads the user the API gateway authenticated, writing the error
+// response itself when there is none
+func (h *Handler) userID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id, err := uuid.Parse(r.Header.Get(headers.XUserID))
+	if err != nil {
+		response.UnauthorizedError(r.Context(), r, w, "Missing or invalid user ID", err)
+		return "", false
+	}
+	return id.String(), true
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrTestNotFound):
+		response.NotFoundError(r.Context(), r, w, "A/B test")
+	case errors.Is(err, ErrTestNotRunning):
+		response.ConflictError(r.Context(), r, w, err.Error(), err)
+	case errors.Is(err, ErrNotEnrolled):
+		response.UnprocessableEntityError(r.Context(), r, w, err.Error(), err)
+	default:
+		h.log.Error("Failed to serve A/B test request", logger.Error(err))
+		response.InternalServerError(r.Context(), r, w, "Failed to serve A/B test", err)
+	}
+}
@@ -0,0 +1,64 @@
+package abtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shared/pkg/logger"
+	"shared/server/headers"
+
+	"github.com/gorilla/mux"
+)
+
+func serveExperiment(h *Handler, handler http.HandlerFunc, name, user, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/experiments/"+name, strings.NewReader(body))
+	req.Header.Set(headers.XUserID, user)
+	req = mux.SetURLVars(req, map[string]string{"name": name})
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestHandler_AssignAndConvert(t *testing.T) {
+	r := newMemABTestRepo(newTest("checkout", `[{"name":"treatment","weight":1}]`))
+	h := NewHandler(newTestService(r), logger.NewNoop())
+
+	rec := serveExperiment(h, h.Assign, "checkout", userID(1), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("assign status = %d, body %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Data AssignResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode assign response: %v", err)
+	}
+	if assigned := body.Data; !assigned.Enrolled || assigned.Variant == nil || assigned.Variant.Name != "treatment" {
+		t.Fatalf("assign response = %+v, want enrolled in treatment", body.Data)
+	}
+
+	rec = serveExperiment(h, h.RecordConversion, "checkout", userID(1), `{"value":9.5}`)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("conversion status = %d, body %s", rec.Code, rec.Body)
+	}
+	if a := r.assignments["test-checkout/"+userID(1)]; !a.Converted || *a.ConversionValue != 9.5 {
+		t.Fatalf("conversion not recorded: %+v", a)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	h := NewHandler(newTestService(newMemABTestRepo(newTest("checkout", `[{"name":"a","weight":1}]`))), logger.NewNoop())
+
+	if rec := serveExperiment(h, h.Assign, "checkout", "not-a-uuid", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("assign without user = %d, want 401", rec.Code)
+	}
+	if rec := serveExperiment(h, h.Assign, "missing", userID(1), ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("assign to unknown test = %d, want 404", rec.Code)
+	}
+	if rec := serveExperiment(h, h.RecordConversion, "checkout", userID(2), ""); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("conversion without assignment = %d, want 422", rec.Code)
+	}
+}
@@ -0,0 +1,268 @@
+package abtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"analytics-service/internal/repo"
+
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+	"shared/server/middleware"
+)
+
+var (
+	ErrTestNotFound = errors.New("a/b test not found")
+	// ErrTestNotRunning is returned for tests that are not active or are
+	// outside their schedule
+	ErrTestNotRunning = errors.New("a/b test is not running")
+	// ErrNotEnrolled is returned when the user falls outside the test's
+	// targeting; callers should fall back to their default behaviour
+	ErrNotEnrolled     = errors.New("user is not enrolled in a/b test")
+	ErrInvalidVariants = errors.New("a/b test has no valid variants")
+)
+
+// bucketCount is the resolution of percentage targeting: users are hashed
+// into this many buckets, so targeting works to a hundredth of a percent
+const bucketCount = 10000
+
+// Variant is one arm of a test, as configured in its variants column
+type Variant struct {
+	Name   string          `json:"name"`
+	Weight int             `json:"weight"`
+	Config json.RawMessage `json:"config,omitempty"`
+}
+
+// Attributes describe the client a user is assigning from, for platform and
+// country targeting
+type Attributes struct {
+	Platform string
+	Country  string
+}
+
+type attributesKey struct{}
+
+// WithAttributes attaches the client's targeting attributes to ctx
+func WithAttributes(ctx context.Context, attrs Attributes) context.Context {
+	return context.WithValue(ctx, attributesKey{}, attrs)
+}
+
+// attributesFrom returns the attributes attached to ctx, taking the country
+// from the GeoIP middleware when the caller did not set one
+func attributesFrom(ctx context.Context) Attributes {
+	attrs, _ := ctx.Value(attributesKey{}).(Attributes)
+	if attrs.Country == "" {
+		if location := middleware.GetGeoLocation(ctx); location != nil {
+			attrs.Country = location.CountryCode
+		}
+	}
+	return attrs
+}
+
+// Service assigns users to A/B test variants. Assignments are sticky: once
+// stored, a user keeps their variant for the life of the test even if its
+// targeting or weights change. New assignments are deterministic, hashing the
+// user and test name, so a user lands in the same variant on every replica.
+type Service struct {
+	repo repo.ABTestRepository
+	log  logger.Logger
+	now  func() time.Time
+}
+
+// NewService creates an A/B test assignment service
+func NewService(r repo.ABTestRepository, log logger.Logger) *Service {
+	return &Service{
+		repo: r,
+		log:  log,
+		now:  time.Now,
+	}
+}
+
+// Assign returns the variant a user sees in testName, assigning one on first
+// use. Platform and country targeting read the attributes attached with
+// WithAttributes.
+func (s *Service) Assign(ctx context.Context, userID, testName string) (*Variant, error) {
+	test, err := s.runningTest(ctx, testName)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetAssignment(ctx, test.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return &Variant{Name: existing.VariantName, Config: existing.VariantConfig}, nil
+	}
+
+	eligible, err := s.eligible(ctx, test, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !eligible {
+		return nil, ErrNotEnrolled
+	}
+
+	variants, err := parseVariants(test.Variants)
+	if err != nil {
+		s.log.Error("A/B test has invalid variants",
+			logger.String("test_name", testName),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	variant := pickVariant(variants, hash(testName, "variant", userID))
+
+	assignment, err := s.repo.CreateAssignment(ctx, &models.ABTestAssignment{
+		TestID:        test.ID,
+		UserID:        userID,
+		VariantName:   variant.Name,
+		VariantConfig: variant.Config,
+		AssignedAt:    s.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Debug("Assigned A/B test variant",
+		logger.String("test_name", testName),
+		logger.String("user_id", userID),
+		logger.String("variant", assignment.VariantName),
+	)
+	return &Variant{Name: assignment.VariantName, Config: assignment.VariantConfig}, nil
+}
+
+// RecordConversion marks the user's assignment in testName as converted,
+// adding value to its conversion value. Users never assigned to the test
+// get ErrNotEnrolled.
+func (s *Service) RecordConversion(ctx context.Context, userID, testName string, value float64) error {
+	test, err := s.runningTest(ctx, testName)
+	if err != nil {
+		return err
+	}
+
+	recorded, err := s.repo.RecordConversion(ctx, test.ID, userID, value, s.now())
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return ErrNotEnrolled
+	}
+	return nil
+}
+
+// runningTest loads a test, failing unless it is active and inside its
+// schedule
+func (s *Service) runningTest(ctx context.Context, testName string) (*models.ABTest, error) {
+	test, err := s.repo.GetTestByName(ctx, testName)
+	if err != nil {
+		return nil, err
+	}
+	if test == nil {
+		return nil, ErrTestNotFound
+	}
+
+	now := s.now()
+	if test.Status != models.ABTestStatusActive {
+		return nil, ErrTestNotRunning
+	}
+	if test.StartsAt != nil && now.Before(*test.StartsAt) {
+		return nil, ErrTestNotRunning
+	}
+	if test.EndsAt != nil && !now.Before(*test.EndsAt) {
+		return nil, ErrTestNotRunning
+	}
+	return test, nil
+}
+
+// eligible applies the test's targeting to a user who has no assignment yet
+func (s *Service) eligible(ctx context.Context, test *models.ABTest, userID string) (bool, error) {
+	if hash(test.TestName, "target", userID)%bucketCount >= uint64(test.TargetPercentage)*bucketCount/100 {
+		return false, nil
+	}
+
+	attrs := attributesFrom(ctx)
+	if len(test.TargetPlatforms) > 0 && !containsFold(test.TargetPlatforms, attrs.Platform) {
+		return false, nil
+	}
+	if len(test.TargetCountries) > 0 && !containsFold(test.TargetCountries, attrs.Country) {
+		return false, nil
+	}
+
+	if len(test.TargetUserSegments) > 0 {
+		segments, err := s.repo.GetActiveSegments(ctx, userID, s.now())
+		if err != nil {
+			return false, err
+		}
+		for _, segment := range segments {
+			if containsFold(test.TargetUserSegments, segment) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func parseVariants(raw json.RawMessage) ([]Variant, error) {
+	var variants []Variant
+	if err := json.Unmarshal(raw, &variants); err != nil {
+		return nil, ErrInvalidVariants
+	}
+
+	total := 0
+	for _, v := range variants {
+		if v.Name == "" || v.Weight < 0 {
+			return nil, ErrInvalidVariants
+		}
+		total += v.Weight
+	}
+	if total == 0 {
+		return nil, ErrInvalidVariants
+	}
+	return variants, nil
+}
+
+// pickVariant chooses a variant in proportion to the weights, using h as the
+// random draw
+func pickVariant(variants []Variant, h uint64) Variant {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+
+	point := int(h % uint64(total))
+	for _, v := range variants {
+		if point < v.Weight {
+			return v
+		}
+		point -= v.Weight
+	}
+	return variants[len(variants)-1]
+}
+
+// hash maps a user into a test deterministically. Percentage targeting and
+// variant choice use different salts so that which users are included does
+// not skew which variant they get.
+func hash(testName, salt, userID string) uint64 {
+	sum := sha256.Sum256([]byte(testName + ":" + salt + ":" + userID))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+func containsFold(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package abtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+type memABTestRepo struct {
+	tests       map[string]*models.ABTest
+	assignments map[string]*models.ABTestAssignment
+	segments    map[string][]string
+	creates     int
+}
+
+func newMemABTestRepo(tests ...*models.ABTest) *memABTestRepo {
+	r := &memABTestRepo{
+		tests:       make(map[string]*models.ABTest),
+		assignments: make(map[string]*models.ABTestAssignment),
+		segments:    make(map[string][]string),
+	}
+	for _, t := range tests {
+		r.tests[t.TestName] = t
+	}
+	return r
+}
+
+func (r *memABTestRepo) GetTestByName(ctx context.Context, testName string) (*models.ABTest, error) {
+	return r.tests[testName], nil
+}
+
+func (r *memABTestRepo) GetAssignment(ctx context.Context, testID, userID string) (*models.ABTestAssignment, error) {
+	return r.assignments[testID+"/"+userID], nil
+}
+
+func (r *memABTestRepo) CreateAssignment(ctx context.Context, a *models.ABTestAssignment) (*models.ABTestAssignment, error) {
+	key := a.TestID + "/" + a.UserID
+	if existing, ok := r.assignments[key]; ok {
+		return existing, nil
+	}
+	r.creates++
+	r.assignments[key] = a
+	return a, nil
+}
+
+func (r *memABTestRepo) GetActiveSegments(ctx context.Context, userID string, now time.Time) ([]string, error) {
+	return r.segments[userID], nil
+}
+
+func (r *memABTestRepo) RecordConversion(ctx context.Context, testID, userID string, value float64, at time.Time) (bool, error) {
+	a, ok := r.assignments[testID+"/"+userID]
+	if !ok {
+		return false, nil
+	}
+	a.Converted = true
+	total := value
+	if a.ConversionValue != nil {
+		total += *a.ConversionValue
+	}
+	a.ConversionValue = &total
+	return true, nil
+}
+
+func newTest(name string, variants string) *models.ABTest {
+	return &models.ABTest{
+		ID:               "test-" + name,
+		TestName:         name,
+		Variants:         json.RawMessage(variants),
+		TargetPercentage: 100,
+		Status:           models.ABTestStatusActive,
+	}
+}
+
+func newTestService(r *memABTestRepo) *Service {
+	s := NewService(r, logger.NewNoop())
+	s.now = func() time.Time { return time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func userID(i int) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", i)
+}
+
+func TestAssign_IsSticky(t *testing.T) {
+	test := newTest("checkout", `[{"name":"control","weight":50},{"name":"treatment","weight":50,"config":{"color":"blue"}}]`)
+	r := newMemABTestRepo(test)
+	s := newTestService(r)
+	ctx := context.Background()
+
+	first, err := s.Assign(ctx, userID(1), "checkout")
+	if err != nil {
+		t.Fatalf("Assign failed: %v", err)
+	}
+
+	// Reweighting the test must not move users who were already assigned
+	test.Variants = json.RawMessage(`[{"name":"control","weight":0},{"name":"treatment","weight":0},{"name":"new","weight":1}]`)
+	for i := 0; i < 3; i++ {
+		again, err := s.Assign(ctx, userID(1), "checkout")
+		if err != nil {
+			t.Fatalf("repeat Assign failed: %v", err)
+		}
+		if again.Name != first.Name || string(again.Config) != string(first.Config) {
+			t.Fatalf("assignment moved from %s to %s", first.Name, again.Name)
+		}
+	}
+	if r.creates != 1 {
+		t.Fatalf("stored %d assignments, want 1", r.creates)
+	}
+}
+
+func TestAssign_IsDeterministicAndFollowsWeights(t *testing.T) {
+	variants := `[{"name":"control","weight":80},{"name":"treatment","weight":20}]`
+	counts := map[string]int{}
+
+	for i := 0; i < 2000; i++ {
+		// A fresh store each time: the same user must hash to the same
+		// variant on any replica, not just read back a stored row
+		a, err := newTestService(newMemABTestRepo(newTest("onboarding", variants))).Assign(context.Background(), userID(i), "onboarding")
+		if err != nil {
+			t.Fatalf("Assign failed: %v", err)
+		}
+		b, _ := newTestService(newMemABTestRepo(newTest("onboarding", variants))).Assign(context.Background(), userID(i), "onboarding")
+		if a.Name != b.Name {
+			t.Fatalf("user %d got %s then %s", i, a.Name, b.Name)
+		}
+		counts[a.Name]++
+	}
+
+	if share := float64(counts["treatment"]) / 2000; share < 0.15 || share > 0.25 {
+		t.Fatalf("treatment share = %.2f, want about 0.20", share)
+	}
+}
+
+func TestAssign_TargetingExclusion(t *testing.T) {
+	ctx := context.Background()
+	variants := `[{"name":"only","weight":1}]`
+
+	t.Run("percentage", func(t *testing.T) {
+		test := newTest("rollout", variants)
+		test.TargetPercentage = 10
+		s := newTestService(newMemABTestRepo(test))
+
+		enrolled := 0
+		for i := 0; i < 2000; i++ {
+			_, err := s.Assign(ctx, userID(i), "rollout")
+			switch {
+			case err == nil:
+				enrolled++
+			case !errors.Is(err, ErrNotEnrolled):
+				t.Fatalf("Assign failed: %v", err)
+			}
+		}
+		if share := float64(enrolled) / 2000; share < 0.07 || share > 0.13 {
+			t.Fatalf("enrolled share = %.2f, want about 0.10", share)
+		}
+	})
+
+	t.Run("zero percent", func(t *testing.T) {
+		test := newTest("off", variants)
+		test.TargetPercentage = 0
+		if _, err := newTestService(newMemABTestRepo(test)).Assign(ctx, userID(1), "off"); !errors.Is(err, ErrNotEnrolled) {
+			t.Fatalf("expected ErrNotEnrolled, got %v", err)
+		}
+	})
+
+	t.Run("platform and country", func(t *testing.T) {
+		test := newTest("ios-us", variants)
+		test.TargetPlatforms = pq.StringArray{"ios"}
+		test.TargetCountries = pq.StringArray{"US", "CA"}
+		s := newTestService(newMemABTestRepo(test))
+
+		cases := []struct {
+			attrs Attributes
+			want  error
+		}{
+			{Attributes{Platform: "iOS", Country: "us"}, nil},
+			{Attributes{Platform: "android", Country: "US"}, ErrNotEnrolled},
+			{Attributes{Platform: "ios", Country: "DE"}, ErrNotEnrolled},
+			{Attributes{}, ErrNotEnrolled},
+		}
+		for i, c := range cases {
+			_, err := s.Assign(WithAttributes(ctx, c.attrs), userID(100+i), "ios-us")
+			if !errors.Is(err, c.want) {
+				t.Fatalf("%+v: got %v, want %v", c.attrs, err, c.want)
+			}
+		}
+	})
+
+	t.Run("segments", func(t *testing.T) {
+		test := newTest("power", variants)
+		test.TargetUserSegments = pq.StringArray{"power_user"}
+		r := newMemABTestRepo(test)
+		r.segments[userID(1)] = []string{"casual_user", "power_user"}
+		r.segments[userID(2)] = []string{"casual_user"}
+		s := newTestService(r)
+
+		if _, err := s.Assign(ctx, userID(1), "power"); err != nil {
+			t.Fatalf("power user not enrolled: %v", err)
+		}
+		if _, err := s.Assign(ctx, userID(2), "power"); !errors.Is(err, ErrNotEnrolled) {
+			t.Fatalf("casual user enrolled: %v", err)
+		}
+	})
+}
+
+func TestAssign_RespectsStatusAndSchedule(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	cases := []struct {
+		name   string
+		modify func(t *models.ABTest)
+		want   error
+	}{
+		{"draft", func(t *models.ABTest) { t.Status = models.ABTestStatusDraft }, ErrTestNotRunning},
+		{"paused", func(t *models.ABTest) { t.Status = models.ABTestStatusPaused }, ErrTestNotRunning},
+		{"not started", func(t *models.ABTest) { t.StartsAt = &future }, ErrTestNotRunning},
+		{"ended", func(t *models.ABTest) { t.EndsAt = &past }, ErrTestNotRunning},
+		{"inside window", func(t *models.ABTest) { t.StartsAt, t.EndsAt = &past, &future }, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			test := newTest("scheduled", `[{"name":"only","weight":1}]`)
+			c.modify(test)
+			if _, err := newTestService(newMemABTestRepo(test)).Assign(ctx, userID(1), "scheduled"); !errors.Is(err, c.want) {
+				t.Fatalf("got %v, want %v", err, c.want)
+			}
+		})
+	}
+
+	if _, err := newTestService(newMemABTestRepo()).Assign(ctx, userID(1), "missing"); !errors.Is(err, ErrTestNotFound) {
+		t.Fatalf("missing test: got %v", err)
+	}
+}
+
+func TestRecordConversion(t *testing.T) {
+	r := newMemABTestRepo(newTest("pricing", `[{"name":"only","weight":1}]`))
+	s := newTestService(r)
+	ctx := context.Background()
+
+	if err := s.RecordConversion(ctx, userID(1), "pricing", 9.99); !errors.Is(err, ErrNotEnrolled) {
+		t.Fatalf("conversion without assignment: got %v", err)
+	}
+
+	if _, err := s.Assign(ctx, userID(1), "pricing"); err != nil {
+		t.Fatalf("Assign failed: %v", err)
+	}
+	if err := s.RecordConversion(ctx, userID(1), "pricing", 9.99); err != nil {
+		t.Fatalf("RecordConversion failed: %v", err)
+	}
+	if err := s.RecordConversion(ctx, userID(1), "pricing", 5); err != nil {
+		t.Fatalf("second RecordConversion failed: %v", err)
+	}
+
+	a := r.assignments["test-pricing/"+userID(1)]
+	if !a.Converted || a.ConversionValue == nil || *a.ConversionValue != 14.99 {
+		t.Fatalf("assignment = converted %v value %v, want true and 14.99", a.Converted, a.ConversionValue)
+	}
+}
@@ -0,0 +1,154 @@
+package config
+
+import "time"
+
+type Config struct {
+	Service   ServiceConfig   `yaml:"service" mapstructure:"service"`
+	Server    ServerConfig    `yaml:"server" mapstructure:"server"`
+	Database  DatabaseConfig  `yaml:"database" mapstructure:"database"`
+	Cache     CacheConfig     `yaml:"cache" mapstructure:"cache"`
+	Kafka     KafkaConfig     `yaml:"kafka" mapstructure:"kafka"`
+	Ingestion IngestionConfig `yaml:"ingestion" mapstructure:"ingestion"`
+	Rollup    RollupConfig    `yaml:"rollup" mapstructure:"rollup"`
+	Webhooks  WebhooksConfig  `yaml:"webhooks" mapstructure:"webhooks"`
+	Logging   LoggingConfig   `yaml:"logging" mapstructure:"logging"`
+	Shutdown  ShutdownConfig  `yaml:"shutdown" mapstructure:"shutdown"`
+}
+
+type ServiceConfig struct {
+	Name        string `yaml:"name" mapstructure:"name"`
+	Version     string `yaml:"version" mapstructure:"version"`
+	Environment string `yaml:"environment" mapstructure:"environment"`
+}
+
+type ServerConfig struct {
+	Host            string        `yaml:"host" mapstructure:"host"`
+	Port            int           `yaml:"port" mapstructure:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" mapstructure:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" mapstructure:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" mapstructure:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" mapstructure:"shutdown_timeout"`
+	MaxHeaderBytes  int           `yaml:"max_header_bytes" mapstructure:"max_header_bytes"`
+	HealthCacheTTL  time.Duration `yaml:"health_cache_ttl" mapstructure:"health_cache_ttl"`
+	TrustedProxies  []string      `yaml:"trusted_proxies" mapstructure:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	Postgres PostgresConfig `yaml:"postgres" mapstructure:"postgres"`
+}
+
+type PostgresConfig struct {
+	Host            string        `yaml:"host" mapstructure:"host"`
+	Port            int           `yaml:"port" mapstructure:"port"`
+	User            string        `yaml:"user" mapstructure:"user"`
+	Password        string        `yaml:"password" mapstructure:"password"`
+	DBName          string        `yaml:"db_name" mapstructure:"db_name"`
+	SSLMode         string        `yaml:"ssl_mode" mapstructure:"ssl_mode"`
+	MaxOpenConns    int           `yaml:"max_open_conns" mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" mapstructure:"conn_max_idle_time"`
+}
+
+type CacheConfig struct {
+	Enabled      bool          `yaml:"enabled" mapstructure:"enabled"`
+	Host         string        `yaml:"host" mapstructure:"host"`
+	Port         int           `yaml:"port" mapstructure:"port"`
+	Password     string        `yaml:"password" mapstructure:"password"`
+	DB           int           `yaml:"db" mapstructure:"db"`
+	MaxRetries   int           `yaml:"max_retries" mapstructure:"max_retries"`
+	PoolSize     int           `yaml:"pool_size" mapstructure:"pool_size"`
+	MinIdleConns int           `yaml:"min_idle_conns" mapstructure:"min_idle_conns"`
+	DialTimeout  time.Duration `yaml:"dial_timeout" mapstructure:"dial_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout" mapstructure:"write_timeout"`
+}
+
+type KafkaConfig struct {
+	Brokers  []string `yaml:"brokers" mapstructure:"brokers"`
+	ClientID string   `yaml:"client_id" mapstructure:"client_id"`
+	GroupID  string   `yaml:"group_id" mapstructure:"group_id"`
+	// WebhookTopic carries the events forwarded to webhook targets
+	WebhookTopic string `yaml:"webhook_topic" mapstructure:"webhook_topic"`
+	// EventsTopic receives every ingested event for downstream aggregation
+	EventsTopic  string        `yaml:"events_topic" mapstructure:"events_topic"`
+	MaxRetries   int           `yaml:"max_retries" mapstructure:"max_retries"`
+	RetryBackoff time.Duration `yaml:"retry_backoff" mapstructure:"retry_backoff"`
+	// DeadLetterEnabled moves messages that still fail after MaxRetries to
+	// <topic><DeadLetterSuffix> instead of redelivering them forever
+	DeadLetterEnabled bool   `yaml:"dead_letter_enabled" mapstructure:"dead_letter_enabled"`
+	DeadLetterSuffix  string `yaml:"dead_letter_suffix" mapstructure:"dead_letter_suffix"`
+}
+
+type IngestionConfig struct {
+	// MaxBatchSize caps how many events one batch request may carry
+	MaxBatchSize int `yaml:"max_batch_size" mapstructure:"max_batch_size"`
+	// MaxBodySize caps ingestion request bodies, in bytes
+	MaxBodySize int64 `yaml:"max_body_size" mapstructure:"max_body_size"`
+	// MaxPropertiesSize caps the encoded size of one event's properties
+	MaxPropertiesSize int `yaml:"max_properties_size" mapstructure:"max_properties_size"`
+	// MaxFutureSkew and MaxEventAge bound how far a client's event timestamp
+	// may sit from the server clock
+	MaxFutureSkew time.Duration `yaml:"max_future_skew" mapstructure:"max_future_skew"`
+	MaxEventAge   time.Duration `yaml:"max_event_age" mapstructure:"max_event_age"`
+	// RequestsPerWindow ingestion requests are allowed per client IP each
+	// RateLimitWindow
+	RequestsPerWindow int           `yaml:"requests_per_window" mapstructure:"requests_per_window"`
+	RateLimitWindow   time.Duration `yaml:"rate_limit_window" mapstructure:"rate_limit_window"`
+	// GeoIPEndpoint is the location-service lookup used to fill in location
+	// fields clients leave out; enrichment is off when it is empty
+	GeoIPEndpoint string        `yaml:"geoip_endpoint" mapstructure:"geoip_endpoint"`
+	GeoIPTimeout  time.Duration `yaml:"geoip_timeout" mapstructure:"geoip_timeout"`
+}
+
+type RollupConfig struct {
+	// Interval is how often the daily rollup runs
+	Interval time.Duration `yaml:"interval" mapstructure:"interval"`
+	// LookbackDays is how many days, ending yesterday, each run rolls up;
+	// raise it to backfill
+	LookbackDays int `yaml:"lookback_days" mapstructure:"lookback_days"`
+	// ChurnDays is how long a user must be inactive to count as churned
+	ChurnDays int `yaml:"churn_days" mapstructure:"churn_days"`
+	// LockTTL bounds how long a crashed replica can hold the rollup lock
+	LockTTL time.Duration `yaml:"lock_ttl" mapstructure:"lock_ttl"`
+}
+
+type WebhooksConfig struct {
+	Targets []WebhookTargetConfig `yaml:"targets" mapstructure:"targets"`
+	// Timeout and MaxAttempts apply to targets that do not set their own
+	Timeout     time.Duration `yaml:"timeout" mapstructure:"timeout"`
+	MaxAttempts int           `yaml:"max_attempts" mapstructure:"max_attempts"`
+	// BackoffBase is the delay before the first retry; each later retry
+	// waits twice as long as the one before, up to BackoffMax
+	BackoffBase time.Duration `yaml:"backoff_base" mapstructure:"backoff_base"`
+	BackoffMax  time.Duration `yaml:"backoff_max" mapstructure:"backoff_max"`
+	// RetryInterval is how often due retries are looked for
+	RetryInterval time.Duration `yaml:"retry_interval" mapstructure:"retry_interval"`
+	// RetryBatchSize caps how many retries one sweep attempts
+	RetryBatchSize int `yaml:"retry_batch_size" mapstructure:"retry_batch_size"`
+	// LockTTL bounds how long a crashed replica can hold the retry sweep lock
+	LockTTL time.Duration `yaml:"lock_ttl" mapstructure:"lock_ttl"`
+}
+
+type WebhookTargetConfig struct {
+	Name string `yaml:"name" mapstructure:"name"`
+	URL  string `yaml:"url" mapstructure:"url"`
+	// Secret signs every request to the target; requests are unsigned when
+	// it is empty
+	Secret      string        `yaml:"secret" mapstructure:"secret"`
+	Timeout     time.Duration `yaml:"timeout" mapstructure:"timeout"`
+	MaxAttempts int           `yaml:"max_attempts" mapstructure:"max_attempts"`
+	// Events lists the event types sent to the target; empty means all
+	Events []string `yaml:"events" mapstructure:"events"`
+}
+
+type LoggingConfig struct {
+	Level      string `yaml:"level" mapstructure:"level"`
+	Format     string `yaml:"format" mapstructure:"format"`
+	Output     string `yaml:"output" mapstructure:"output"`
+	TimeFormat string `yaml:"time_format" mapstructure:"time_format"`
+}
+
+type ShutdownConfig struct {
+	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout"`
+}
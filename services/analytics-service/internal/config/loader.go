@@ -0,0 +1,13 @@
+package config
+
+import (
+	"shared/server/config"
+)
+
+func Load(configPath string, env string) (*Config, error) {
+	return config.Load[Config](config.LoadOptions{
+		ConfigPath:  configPath,
+		ServiceName: "analytics-service",
+		Environment: env,
+	})
+}
@@ -0,0 +1,260 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+func ValidateAndSetDefaults(cfg *Config) error {
+	if cfg.Service.Name == "" {
+		cfg.Service.Name = "analytics-service"
+	}
+
+	if cfg.Server.Host == "" {
+		cfg.Server.Host = "0.0.0.0"
+	}
+	if cfg.Server.Port == 0 {
+		cfg.Server.Port = 8087
+	}
+	if cfg.Server.Port < 0 || cfg.Server.Port > 65535 {
+		return fmt.Errorf("invalid server port: %d", cfg.Server.Port)
+	}
+	if cfg.Server.ReadTimeout == 0 {
+		cfg.Server.ReadTimeout = 15 * time.Second
+	}
+	if cfg.Server.WriteTimeout == 0 {
+		cfg.Server.WriteTimeout = 15 * time.Second
+	}
+	if cfg.Server.IdleTimeout == 0 {
+		cfg.Server.IdleTimeout = 60 * time.Second
+	}
+	if cfg.Server.ShutdownTimeout == 0 {
+		cfg.Server.ShutdownTimeout = 30 * time.Second
+	}
+	if cfg.Server.MaxHeaderBytes == 0 {
+		cfg.Server.MaxHeaderBytes = 1 << 20
+	}
+	if cfg.Server.HealthCacheTTL <= 0 {
+		cfg.Server.HealthCacheTTL = 5 * time.Second
+	}
+
+	if cfg.Database.Postgres.Host == "" {
+		return errors.New("database host is required")
+	}
+
+	if cfg.Database.Postgres.Port == 0 {
+		cfg.Database.Postgres.Port = 5432
+	}
+
+	if cfg.Database.Postgres.User == "" {
+		return errors.New("database user is required")
+	}
+
+	if cfg.Database.Postgres.DBName == "" {
+		return errors.New("database name is required")
+	}
+
+	if err := validateCache(&cfg.Cache); err != nil {
+		return err
+	}
+
+	if len(cfg.Kafka.Brokers) == 0 {
+		return errors.New("kafka brokers are required")
+	}
+	if cfg.Kafka.ClientID == "" {
+		cfg.Kafka.ClientID = cfg.Service.Name
+	}
+	if cfg.Kafka.GroupID == "" {
+		cfg.Kafka.GroupID = "analytics-service-group"
+	}
+	if cfg.Kafka.WebhookTopic == "" {
+		cfg.Kafka.WebhookTopic = "analytics.webhooks"
+	}
+	if cfg.Kafka.EventsTopic == "" {
+		cfg.Kafka.EventsTopic = "analytics.events"
+	}
+	if cfg.Kafka.MaxRetries == 0 {
+		cfg.Kafka.MaxRetries = 3
+	}
+	if cfg.Kafka.RetryBackoff == 0 {
+		cfg.Kafka.RetryBackoff = 200 * time.Millisecond
+	}
+	if cfg.Kafka.DeadLetterSuffix == "" {
+		cfg.Kafka.DeadLetterSuffix = ".dlq"
+	}
+
+	if err := validateIngestion(&cfg.Ingestion); err != nil {
+		return err
+	}
+
+	if cfg.Rollup.Interval == 0 {
+		cfg.Rollup.Interval = time.Hour
+	}
+	if cfg.Rollup.LookbackDays == 0 {
+		cfg.Rollup.LookbackDays = 2
+	}
+	if cfg.Rollup.LookbackDays < 1 {
+		return fmt.Errorf("rollup lookback days must be at least 1")
+	}
+	if cfg.Rollup.ChurnDays == 0 {
+		cfg.Rollup.ChurnDays = 30
+	}
+	if cfg.Rollup.LockTTL == 0 {
+		cfg.Rollup.LockTTL = 10 * time.Minute
+	}
+
+	if err := validateWebhooks(&cfg.Webhooks); err != nil {
+		return err
+	}
+
+	if cfg.Logging.Level == "" {
+		cfg.Logging.Level = "info"
+	}
+
+	if cfg.Logging.Format == "" {
+		cfg.Logging.Format = "json"
+	}
+
+	if cfg.Shutdown.Timeout == 0 {
+		cfg.Shutdown.Timeout = 30 * time.Second
+	}
+
+	return nil
+}
+
+func validateCache(cache *CacheConfig) error {
+	if !cache.Enabled {
+		return nil
+	}
+
+	if cache.Host == "" {
+		return fmt.Errorf("cache host is required when cache is enabled")
+	}
+
+	if cache.Port <= 0 || cache.Port > 65535 {
+		return fmt.Errorf("invalid cache port: %d", cache.Port)
+	}
+
+	if cache.PoolSize == 0 {
+		cache.PoolSize = 10
+	}
+
+	if cache.MinIdleConns == 0 {
+		cache.MinIdleConns = 5
+	}
+
+	if cache.MaxRetries == 0 {
+		cache.MaxRetries = 3
+	}
+
+	if cache.DialTimeout == 0 {
+		cache.DialTimeout = 5 * time.Second
+	}
+
+	if cache.ReadTimeout == 0 {
+		cache.ReadTimeout = 3 * time.Second
+	}
+
+	if cache.WriteTimeout == 0 {
+		cache.WriteTimeout = 3 * time.Second
+	}
+
+	return nil
+}
+
+func validateIngestion(ingestion *IngestionConfig) error {
+	if ingestion.MaxBatchSize == 0 {
+		ingestion.MaxBatchSize = 100
+	}
+	if ingestion.MaxBatchSize < 1 {
+		return fmt.Errorf("ingestion max batch size must be at least 1")
+	}
+	if ingestion.MaxBodySize == 0 {
+		ingestion.MaxBodySize = 1 << 20
+	}
+	if ingestion.MaxPropertiesSize == 0 {
+		ingestion.MaxPropertiesSize = 8 << 10
+	}
+	if ingestion.MaxFutureSkew == 0 {
+		ingestion.MaxFutureSkew = 5 * time.Minute
+	}
+	if ingestion.MaxEventAge == 0 {
+		ingestion.MaxEventAge = 7 * 24 * time.Hour
+	}
+	if ingestion.RequestsPerWindow == 0 {
+		ingestion.RequestsPerWindow = 600
+	}
+	if ingestion.RateLimitWindow == 0 {
+		ingestion.RateLimitWindow = time.Minute
+	}
+	if ingestion.GeoIPTimeout == 0 {
+		ingestion.GeoIPTimeout = 200 * time.Millisecond
+	}
+	if ingestion.GeoIPEndpoint != "" {
+		u, err := url.Parse(ingestion.GeoIPEndpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid geoip endpoint: %q", ingestion.GeoIPEndpoint)
+		}
+	}
+
+	return nil
+}
+
+func validateWebhooks(webhooks *WebhooksConfig) error {
+	if webhooks.Timeout == 0 {
+		webhooks.Timeout = 10 * time.Second
+	}
+	if webhooks.MaxAttempts == 0 {
+		webhooks.MaxAttempts = 3
+	}
+	if webhooks.BackoffBase == 0 {
+		webhooks.BackoffBase = 30 * time.Second
+	}
+	if webhooks.BackoffMax == 0 {
+		webhooks.BackoffMax = time.Hour
+	}
+	if webhooks.BackoffMax < webhooks.BackoffBase {
+		return fmt.Errorf("webhook backoff max (%s) must not be less than backoff base (%s)", webhooks.BackoffMax, webhooks.BackoffBase)
+	}
+	if webhooks.RetryInterval == 0 {
+		webhooks.RetryInterval = 15 * time.Second
+	}
+	if webhooks.RetryBatchSize == 0 {
+		webhooks.RetryBatchSize = 100
+	}
+	if webhooks.LockTTL == 0 {
+		webhooks.LockTTL = 2 * time.Minute
+	}
+
+	seen := make(map[string]bool, len(webhooks.Targets))
+	for i := range webhooks.Targets {
+		target := &webhooks.Targets[i]
+
+		u, err := url.Parse(target.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook target %q has an invalid url: %q", target.Name, target.URL)
+		}
+		// Retries look targets up by URL, so each URL may only appear once
+		if seen[target.URL] {
+			return fmt.Errorf("webhook target url %q is configured more than once", target.URL)
+		}
+		seen[target.URL] = true
+
+		if target.Name == "" {
+			target.Name = u.Host
+		}
+		if target.Timeout == 0 {
+			target.Timeout = webhooks.Timeout
+		}
+		if target.MaxAttempts == 0 {
+			target.MaxAttempts = webhooks.MaxAttempts
+		}
+		if target.MaxAttempts < 1 {
+			return fmt.Errorf("webhook target %q max attempts must be at least 1", target.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package checkers
+
+import (
+	"analytics-service/internal/health"
+	"context"
+
+	"shared/pkg/cache"
+)
+
+type CacheChecker struct {
+	cache cache.Cache
+}
+
+func NewCacheChecker(cache cache.Cache) health.Checker {
+	return &CacheChecker{cache: cache}
+}
+
+func (c *CacheChecker) Name() string {
+	return "cache"
+}
+
+// IsCritical reports that the service keeps running, degraded, without its cache
+func (c *CacheChecker) IsCritical() bool {
+	return false
+}
+
+func (c *CacheChecker) Check(ctx context.Context) health.CheckResult {
+	if err := c.cache.Ping(ctx); err != nil {
+		return health.CheckResult{
+			Name:    c.Name(),
+			Status:  health.StatusUnhealthy,
+			Message: "Cache connection failed",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		}
+	}
+
+	result := health.CheckResult{
+		Name:    c.Name(),
+		Status:  health.StatusHealthy,
+		Message: "Cache is responsive",
+	}
+
+	if info, err := c.cache.Info(ctx); err == nil {
+		if version := serverVersion(info); version != "" {
+			result.Metadata = map[string]interface{}{
+				"server_version": version,
+			}
+		}
+	}
+	return result
+}
+
+// serverVersion finds redis_version in INFO output, whose keys may be
+// prefixed with their section name
+func serverVersion(info map[string]string) string {
+	if v, ok := info["redis_version"]; ok {
+		return v
+	}
+	return info["Server.redis_version"]
+}
@@ -0,0 +1,52 @@
+package checkers
+
+import (
+	"analytics-service/internal/health"
+	"context"
+
+	"shared/pkg/database"
+)
+
+type DatabaseChecker struct {
+	db database.Database
+}
+
+func NewDatabaseChecker(db database.Database) health.Checker {
+	return &DatabaseChecker{db: db}
+}
+
+func (c *DatabaseChecker) Name() string {
+	return "database"
+}
+
+// IsCritical reports that the service cannot serve requests without its database
+func (c *DatabaseChecker) IsCritical() bool {
+	return true
+}
+
+func (c *DatabaseChecker) Check(ctx context.Context) health.CheckResult {
+	if err := c.db.Ping(ctx); err != nil {
+		return health.CheckResult{
+			Name:    c.Name(),
+			Status:  health.StatusUnhealthy,
+			Message: "Database connection failed",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		}
+	}
+
+	result := health.CheckResult{
+		Name:    c.Name(),
+		Status:  health.StatusHealthy,
+		Message: "Database is responsive",
+	}
+
+	var version string
+	if err := c.db.QueryRow(ctx, "SHOW server_version").Scan(&version); err == nil {
+		result.Metadata = map[string]interface{}{
+			"server_version": version,
+		}
+	}
+	return result
+}
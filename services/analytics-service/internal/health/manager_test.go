@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"shared/server/env"
+)
+
+type countingChecker struct {
+	calls int
+}
+
+func (c *countingChecker) Name() string { return "database" }
+
+func (c *countingChecker) Check(ctx context.Context) CheckResult {
+	c.calls++
+	return CheckResult{
+		Name:     "database",
+		Status:   StatusHealthy,
+		Metadata: map[string]interface{}{"server_version": "16.2"},
+	}
+}
+
+func TestManager_ReportsTimingsAndCachesResults(t *testing.T) {
+	checker := &countingChecker{}
+	m := NewManager("analytics-service", "1.2.3")
+	m.RegisterChecker(checker)
+	ctx := context.Background()
+
+	first := m.Check(ctx)
+	if build, ok := first["build"].(env.BuildInfo); !ok || build.Version != "1.2.3" || build.GoVersion == "" {
+		t.Fatalf("build = %+v, want version 1.2.3 and a go version", first["build"])
+	}
+	check := first["checks"].([]CheckResult)[0]
+	if check.LastChecked == "" {
+		t.Fatalf("last_checked not set")
+	}
+	if check.DurationMS < 0 {
+		t.Fatalf("duration_ms = %v, want >= 0", check.DurationMS)
+	}
+	if check.Metadata["server_version"] != "16.2" {
+		t.Fatalf("metadata = %v, want server_version", check.Metadata)
+	}
+
+	second := m.Check(ctx)
+	if checker.calls != 1 {
+		t.Fatalf("checker ran %d times within the cache TTL, want 1", checker.calls)
+	}
+	if second["checks"].([]CheckResult)[0].LastChecked != check.LastChecked {
+		t.Fatalf("cached result changed last_checked")
+	}
+
+	m.SetCacheTTL(0)
+	m.Check(ctx)
+	if checker.calls != 2 {
+		t.Fatalf("checker ran %d times with caching disabled, want 2", checker.calls)
+	}
+
+	m.SetCacheTTL(time.Minute)
+	m.Check(ctx)
+	if checker.calls != 2 {
+		t.Fatalf("checker ran %d times after re-enabling the cache, want 2", checker.calls)
+	}
+}
@@ -0,0 +1,148 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"shared/pkg/logger"
+	"shared/server/headers"
+	"shared/server/response"
+
+	"github.com/google/uuid"
+)
+
+// BatchRequest is the body of POST /events/batch
+type BatchRequest struct {
+	Events []Event `json:"events"`
+}
+
+// BatchResponse reports the outcome of every event in a batch, in request
+// order
+type BatchResponse struct {
+	Accepted int      `json:"accepted"`
+	Rejected int      `json:"rejected"`
+	Results  []Result `json:"results"`
+}
+
+// Handler serves the event ingestion endpoints
+type Handler struct {
+	service *Service
+	log     logger.Logger
+}
+
+// NewHandler creates the event ingestion HTTP handler
+func NewHandler(service *Service, log logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		log:     log,
+	}
+}
+
+// Track handles POST /events, ingesting a single event
+func (h *Handler) Track(w http.ResponseWriter, r *http.Request) {
+	var event Event
+	if !h.decode(w, r, &event) {
+		return
+	}
+
+	results, err := h.service.Ingest(r.Context(), source(r), []Event{event})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	result := results[0]
+	if result.Status == ResultRejected {
+		response.ValidationError(r.Context(), r, w, []response.FieldError{{
+			Field:   result.Field,
+			Message: result.Field + " " + result.Reason,
+			Code:    "invalid",
+		}})
+		return
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusAccepted, "Event accepted", result)
+}
+
+// TrackBatch handles POST /events/batch. Valid events are stored even when
+// others in the batch are rejected; the response says which were which.
+func (h *Handler) TrackBatch(w http.ResponseWriter, r *http.Request) {
+	var batch BatchRequest
+	if !h.decode(w, r, &batch) {
+		return
+	}
+
+	results, err := h.service.Ingest(r.Context(), source(r), batch.Events)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp := BatchResponse{Results: results}
+	for _, result := range results {
+		if result.Status == ResultAccepted {
+			resp.Accepted++
+		} else {
+			resp.Rejected++
+		}
+	}
+
+	if resp.Accepted == 0 {
+		response.JSONWithMessage(r.Context(), r, w, http.StatusUnprocessableEntity, "No events accepted", resp)
+		return
+	}
+	response.JSONWithMessage(r.Context(), r, w, http.StatusAccepted, "Events accepted", resp)
+}
+
+// decode reads a JSON body, writing the error response itself when it can't.
+// Unknown fields are allowed so that older builds of the service keep
+// accepting events from newer clients.
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		response.RespondWithError(r.Context(), r, w, http.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, io.EOF):
+		response.BadRequestError(r.Context(), r, w, "Request body is empty", err)
+	default:
+		response.BadRequestError(r.Context(), r, w, "Invalid JSON body", err)
+	}
+	return false
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrEmptyBatch), errors.Is(err, ErrBatchTooLarge):
+		response.BadRequestError(r.Context(), r, w, err.Error(), err)
+	default:
+		h.log.Error("Failed to ingest analytics events", logger.Error(err))
+		response.InternalServerError(r.Context(), r, w, "Failed to store events", err)
+	}
+}
+
+// source reads the sender from the headers the API gateway sets and the
+// client IP RealIP left in RemoteAddr. Anonymous telemetry has no user or
+// session, and malformed IDs are dropped rather than failing the insert.
+func source(r *http.Request) Source {
+	var src Source
+	if id, err := uuid.Parse(r.Header.Get(headers.XUserID)); err == nil {
+		src.UserID = id.String()
+	}
+	if id, err := uuid.Parse(r.Header.Get(headers.XSessionID)); err == nil {
+		src.SessionID = id.String()
+	}
+
+	src.IP = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		src.IP = host
+	}
+	return src
+}
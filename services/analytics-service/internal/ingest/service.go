@@ -0,0 +1,403 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"analytics-service/internal/repo"
+
+	"shared/pkg/database/postgres/models"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+	"shared/pkg/messaging"
+	"shared/server/middleware"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEmptyBatch    = errors.New("batch contains no events")
+	ErrBatchTooLarge = errors.New("batch contains too many events")
+)
+
+const (
+	ResultAccepted = "accepted"
+	ResultRejected = "rejected"
+)
+
+// Publisher is the part of the Kafka producer ingestion publishes through
+type Publisher interface {
+	SendBatch(ctx context.Context, topic string, messages []*messaging.Message) pkgErrors.AppError
+}
+
+// Config bounds what ingestion accepts
+type Config struct {
+	// Topic receives every accepted event
+	Topic             string
+	MaxBatchSize      int
+	MaxPropertiesSize int
+	// MaxFutureSkew and MaxEventAge bound how far a client's event timestamp
+	// may sit from the server clock
+	MaxFutureSkew time.Duration
+	MaxEventAge   time.Duration
+}
+
+// Event is one event as a client reports it. Who sent it and from where come
+// from the request, never the body.
+type Event struct {
+	EventName     string   `json:"event_name"`
+	EventCategory *string  `json:"event_category,omitempty"`
+	EventAction   *string  `json:"event_action,omitempty"`
+	EventLabel    *string  `json:"event_label,omitempty"`
+	EventValue    *float64 `json:"event_value,omitempty"`
+
+	ScreenName     *string `json:"screen_name,omitempty"`
+	PreviousScreen *string `json:"previous_screen,omitempty"`
+	FlowName       *string `json:"flow_name,omitempty"`
+
+	DeviceID   *string `json:"device_id,omitempty"`
+	Platform   *string `json:"platform,omitempty"`
+	OSName     *string `json:"os_name,omitempty"`
+	OSVersion  *string `json:"os_version,omitempty"`
+	AppVersion *string `json:"app_version,omitempty"`
+	AppBuild   *string `json:"app_build,omitempty"`
+
+	Country   *string  `json:"country,omitempty"`
+	Region    *string  `json:"region,omitempty"`
+	City      *string  `json:"city,omitempty"`
+	Timezone  *string  `json:"timezone,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+
+	ConnectionType *string `json:"connection_type,omitempty"`
+	Carrier        *string `json:"carrier,omitempty"`
+
+	EventDurationMS *int `json:"event_duration_ms,omitempty"`
+	LoadTimeMS      *int `json:"load_time_ms,omitempty"`
+	TTFBMS          *int `json:"ttfb_ms,omitempty"`
+
+	Properties json.RawMessage `json:"properties,omitempty"`
+
+	UTMSource   *string `json:"utm_source,omitempty"`
+	UTMMedium   *string `json:"utm_medium,omitempty"`
+	UTMCampaign *string `json:"utm_campaign,omitempty"`
+	UTMTerm     *string `json:"utm_term,omitempty"`
+	UTMContent  *string `json:"utm_content,omitempty"`
+	Referrer    *string `json:"referrer,omitempty"`
+
+	// EventTimestamp is when the event happened on the client; it defaults
+	// to the time it was received
+	EventTimestamp *time.Time `json:"event_timestamp,omitempty"`
+}
+
+// Source identifies who sent a request. Empty fields are stored as NULL.
+type Source struct {
+	UserID    string
+	SessionID string
+	IP        string
+}
+
+// Result reports what happened to one event of a request
+type Result struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Field  string `json:"field,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ValidationError rejects one event for one field
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + " " + e.Reason
+}
+
+// Service validates client events, stores the valid ones and publishes them
+// for downstream aggregation
+type Service struct {
+	repo      repo.EventRepository
+	publisher Publisher
+	cfg       Config
+	log       logger.Logger
+	now       func() time.Time
+}
+
+// NewService creates an event ingestion service
+func NewService(r repo.EventRepository, publisher Publisher, cfg Config, log logger.Logger) *Service {
+	return &Service{
+		repo:      r,
+		publisher: publisher,
+		cfg:       cfg,
+		log:       log,
+		now:       time.Now,
+	}
+}
+
+// Ingest stores every valid event and reports, per event, whether it was
+// accepted. Invalid events never fail the others; an error is returned only
+// when the batch as a whole is unacceptable or could not be stored. Location
+// fields the client left out are filled in from the GeoIP middleware.
+func (s *Service) Ingest(ctx context.Context, src Source, events []Event) ([]Result, error) {
+	if len(events) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(events) > s.cfg.MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	now := s.now()
+	location := middleware.GetGeoLocation(ctx)
+
+	results := make([]Result, len(events))
+	accepted := make([]*models.Event, 0, len(events))
+	for i := range events {
+		results[i].Index = i
+		if err := s.validate(&events[i], now); err != nil {
+			var ve *ValidationError
+			errors.As(err, &ve)
+			results[i].Status = ResultRejected
+			results[i].Field = ve.Field
+			results[i].Reason = ve.Reason
+			continue
+		}
+
+		event := s.toModel(&events[i], src, location, now)
+		results[i].Status = ResultAccepted
+		results[i].ID = event.ID
+		accepted = append(accepted, event)
+	}
+
+	if len(accepted) == 0 {
+		return results, nil
+	}
+	if err := s.repo.CreateEvents(ctx, accepted); err != nil {
+		return nil, err
+	}
+	s.publish(ctx, accepted)
+
+	s.log.Debug("Ingested analytics events",
+		logger.Int("accepted", len(accepted)),
+		logger.Int("rejected", len(events)-len(accepted)),
+	)
+	return results, nil
+}
+
+// publish hands stored events to downstream aggregation. The events are
+// already stored, so a failure is logged rather than returned: failing the
+// request would only have the client send them again.
+func (s *Service) publish(ctx context.Context, events []*models.Event) {
+	messages := make([]*messaging.Message, 0, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			s.log.Error("Failed to encode analytics event",
+				logger.String("event_id", event.ID),
+				logger.Error(err),
+			)
+			continue
+		}
+		message := messaging.NewMessage(value)
+		message.Key = []byte(partitionKey(event))
+		messages = append(messages, message)
+	}
+
+	if err := s.publisher.SendBatch(ctx, s.cfg.Topic, messages); err != nil {
+		s.log.Error("Failed to publish analytics events",
+			logger.String("topic", s.cfg.Topic),
+			logger.Int("count", len(messages)),
+			logger.Error(err),
+		)
+	}
+}
+
+// partitionKey keeps each user's, or failing that each device's, events in
+// order on one partition
+func partitionKey(event *models.Event) string {
+	switch {
+	case event.UserID != nil:
+		return *event.UserID
+	case event.DeviceID != nil:
+		return *event.DeviceID
+	default:
+		return event.ID
+	}
+}
+
+func (s *Service) validate(e *Event, now time.Time) error {
+	e.EventName = strings.TrimSpace(e.EventName)
+	if e.EventName == "" {
+		return &ValidationError{Field: "event_name", Reason: "is required"}
+	}
+
+	// Limits match the column sizes in analytics.events
+	lengths := []struct {
+		field string
+		value *string
+		max   int
+	}{
+		{"event_name", &e.EventName, 255},
+		{"event_category", e.EventCategory, 100},
+		{"event_action", e.EventAction, 100},
+		{"event_label", e.EventLabel, 255},
+		{"screen_name", e.ScreenName, 255},
+		{"previous_screen", e.PreviousScreen, 255},
+		{"flow_name", e.FlowName, 100},
+		{"device_id", e.DeviceID, 255},
+		{"platform", e.Platform, 50},
+		{"os_name", e.OSName, 100},
+		{"os_version", e.OSVersion, 50},
+		{"app_version", e.AppVersion, 50},
+		{"app_build", e.AppBuild, 50},
+		{"country", e.Country, 100},
+		{"region", e.Region, 100},
+		{"city", e.City, 100},
+		{"timezone", e.Timezone, 100},
+		{"connection_type", e.ConnectionType, 50},
+		{"carrier", e.Carrier, 100},
+		{"utm_source", e.UTMSource, 255},
+		{"utm_medium", e.UTMMedium, 255},
+		{"utm_campaign", e.UTMCampaign, 255},
+		{"utm_term", e.UTMTerm, 255},
+		{"utm_content", e.UTMContent, 255},
+		{"referrer", e.Referrer, 2048},
+	}
+	for _, l := range lengths {
+		if l.value != nil && utf8.RuneCountInString(*l.value) > l.max {
+			return &ValidationError{Field: l.field, Reason: "is too long"}
+		}
+	}
+
+	// event_value is DECIMAL(10,2)
+	if e.EventValue != nil && (math.IsNaN(*e.EventValue) || math.Abs(*e.EventValue) >= 1e8) {
+		return &ValidationError{Field: "event_value", Reason: "is out of range"}
+	}
+	if e.Latitude != nil && (*e.Latitude < -90 || *e.Latitude > 90) {
+		return &ValidationError{Field: "latitude", Reason: "is out of range"}
+	}
+	if e.Longitude != nil && (*e.Longitude < -180 || *e.Longitude > 180) {
+		return &ValidationError{Field: "longitude", Reason: "is out of range"}
+	}
+
+	durations := []struct {
+		field string
+		value *int
+	}{
+		{"event_duration_ms", e.EventDurationMS},
+		{"load_time_ms", e.LoadTimeMS},
+		{"ttfb_ms", e.TTFBMS},
+	}
+	for _, d := range durations {
+		if d.value != nil && *d.value < 0 {
+			return &ValidationError{Field: d.field, Reason: "must not be negative"}
+		}
+	}
+
+	if len(e.Properties) > 0 && !bytes.Equal(e.Properties, []byte("null")) {
+		if len(e.Properties) > s.cfg.MaxPropertiesSize {
+			return &ValidationError{Field: "properties", Reason: "is too large"}
+		}
+		if !bytes.HasPrefix(bytes.TrimSpace(e.Properties), []byte("{")) {
+			return &ValidationError{Field: "properties", Reason: "must be an object"}
+		}
+	}
+
+	if e.EventTimestamp != nil {
+		if e.EventTimestamp.After(now.Add(s.cfg.MaxFutureSkew)) {
+			return &ValidationError{Field: "event_timestamp", Reason: "is in the future"}
+		}
+		if e.EventTimestamp.Before(now.Add(-s.cfg.MaxEventAge)) {
+			return &ValidationError{Field: "event_timestamp", Reason: "is too old"}
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) toModel(e *Event, src Source, location *middleware.GeoLocation, now time.Time) *models.Event {
+	event := &models.Event{
+		ID:              uuid.NewString(),
+		UserID:          optional(src.UserID),
+		SessionID:       optional(src.SessionID),
+		EventName:       e.EventName,
+		EventCategory:   e.EventCategory,
+		EventAction:     e.EventAction,
+		EventLabel:      e.EventLabel,
+		EventValue:      e.EventValue,
+		ScreenName:      e.ScreenName,
+		PreviousScreen:  e.PreviousScreen,
+		FlowName:        e.FlowName,
+		DeviceID:        e.DeviceID,
+		Platform:        e.Platform,
+		OSName:          e.OSName,
+		OSVersion:       e.OSVersion,
+		AppVersion:      e.AppVersion,
+		AppBuild:        e.AppBuild,
+		Country:         e.Country,
+		Region:          e.Region,
+		City:            e.City,
+		Timezone:        e.Timezone,
+		Latitude:        e.Latitude,
+		Longitude:       e.Longitude,
+		ConnectionType:  e.ConnectionType,
+		Carrier:         e.Carrier,
+		EventDurationMS: e.EventDurationMS,
+		LoadTimeMS:      e.LoadTimeMS,
+		TTFBMS:          e.TTFBMS,
+		Properties:      e.Properties,
+		UTMSource:       e.UTMSource,
+		UTMMedium:       e.UTMMedium,
+		UTMCampaign:     e.UTMCampaign,
+		UTMTerm:         e.UTMTerm,
+		UTMContent:      e.UTMContent,
+		Referrer:        e.Referrer,
+		CreatedAt:       now,
+		EventTimestamp:  now,
+	}
+	if e.EventTimestamp != nil {
+		event.EventTimestamp = e.EventTimestamp.UTC()
+	}
+	if len(event.Properties) == 0 || bytes.Equal(event.Properties, []byte("null")) {
+		event.Properties = json.RawMessage("{}")
+	}
+	if ip := net.ParseIP(src.IP); ip != nil {
+		addr := ip.String()
+		event.IPAddress = &addr
+	}
+
+	if location != nil {
+		fillMissing(&event.Country, location.CountryCode)
+		fillMissing(&event.Region, location.State)
+		fillMissing(&event.City, location.City)
+		fillMissing(&event.Timezone, location.Timezone)
+		if event.Latitude == nil && event.Longitude == nil && (location.Latitude != 0 || location.Longitude != 0) {
+			lat, long := location.Latitude, location.Longitude
+			event.Latitude, event.Longitude = &lat, &long
+		}
+	}
+
+	return event
+}
+
+func fillMissing(field **string, value string) {
+	if *field == nil && value != "" {
+		*field = &value
+	}
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
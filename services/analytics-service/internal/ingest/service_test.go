@@ -0,0 +1,206 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"shared/pkg/database/postgres/models"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+	"shared/pkg/messaging"
+	sContext "shared/server/context"
+	"shared/server/middleware"
+)
+
+type memEventRepo struct {
+	events []*models.Event
+}
+
+func (r *memEventRepo) CreateEvents(ctx context.Context, events []*models.Event) error {
+	r.events = append(r.events, events...)
+	return nil
+}
+
+type memPublisher struct {
+	topic    string
+	messages []*messaging.Message
+}
+
+func (p *memPublisher) SendBatch(ctx context.Context, topic string, messages []*messaging.Message) pkgErrors.AppError {
+	p.topic = topic
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
+var testNow = time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+func newTestService() (*Service, *memEventRepo, *memPublisher) {
+	r, p := &memEventRepo{}, &memPublisher{}
+	s := NewService(r, p, Config{
+		Topic:             "analytics.events",
+		MaxBatchSize:      3,
+		MaxPropertiesSize: 64,
+		MaxFutureSkew:     5 * time.Minute,
+		MaxEventAge:       24 * time.Hour,
+	}, logger.NewNoop())
+	s.now = func() time.Time { return testNow }
+	return s, r, p
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestValidate(t *testing.T) {
+	s, _, _ := newTestService()
+
+	cases := []struct {
+		name  string
+		event Event
+		field string
+	}{
+		{"valid", Event{EventName: "app_open", Properties: json.RawMessage(`{"a":1}`)}, ""},
+		{"missing name", Event{EventName: "  "}, "event_name"},
+		{"long category", Event{EventName: "x", EventCategory: ptr(strings.Repeat("c", 101))}, "event_category"},
+		{"value out of range", Event{EventName: "x", EventValue: ptr(1e9)}, "event_value"},
+		{"latitude out of range", Event{EventName: "x", Latitude: ptr(91.0)}, "latitude"},
+		{"negative duration", Event{EventName: "x", LoadTimeMS: ptr(-1)}, "load_time_ms"},
+		{"properties not an object", Event{EventName: "x", Properties: json.RawMessage(`[1,2]`)}, "properties"},
+		{"properties too large", Event{EventName: "x", Properties: json.RawMessage(`{"k":"` + strings.Repeat("v", 64) + `"}`)}, "properties"},
+		{"timestamp in future", Event{EventName: "x", EventTimestamp: ptr(testNow.Add(10 * time.Minute))}, "event_timestamp"},
+		{"timestamp too old", Event{EventName: "x", EventTimestamp: ptr(testNow.Add(-48 * time.Hour))}, "event_timestamp"},
+		{"small clock skew", Event{EventName: "x", EventTimestamp: ptr(testNow.Add(time.Minute))}, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := s.validate(&c.event, testNow)
+			if c.field == "" {
+				if err != nil {
+					t.Fatalf("expected valid, got %v", err)
+				}
+				return
+			}
+			ve, ok := err.(*ValidationError)
+			if !ok || ve.Field != c.field {
+				t.Fatalf("got %v, want error on %s", err, c.field)
+			}
+		})
+	}
+}
+
+func TestIngest_StampsServerTimeAndEnrichesLocation(t *testing.T) {
+	s, r, p := newTestService()
+	clientTime := testNow.Add(-time.Hour)
+	ctx := context.WithValue(context.Background(), sContext.GeoLocationKey, &middleware.GeoLocation{
+		CountryCode: "DE",
+		City:        "Berlin",
+		Timezone:    "Europe/Berlin",
+		Latitude:    52.5,
+		Longitude:   13.4,
+	})
+
+	results, err := s.Ingest(ctx, Source{UserID: "user-1", IP: "203.0.113.7"}, []Event{
+		{EventName: "app_open", EventTimestamp: &clientTime},
+		{EventName: "screen_view", City: ptr("Munich")},
+	})
+	if err != nil {
+		t.Fatalf("Ingest failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Status != ResultAccepted || results[0].ID == "" {
+		t.Fatalf("results = %+v", results)
+	}
+
+	first, second := r.events[0], r.events[1]
+	if !first.CreatedAt.Equal(testNow) || !first.EventTimestamp.Equal(clientTime) {
+		t.Fatalf("created_at %v event_timestamp %v; want server and client time", first.CreatedAt, first.EventTimestamp)
+	}
+	if !second.EventTimestamp.Equal(testNow) {
+		t.Fatalf("missing client timestamp not defaulted: %v", second.EventTimestamp)
+	}
+	if *first.Country != "DE" || *first.City != "Berlin" || *first.Latitude != 52.5 {
+		t.Fatalf("location not filled in: %v %v %v", *first.Country, *first.City, first.Latitude)
+	}
+	if *second.City != "Munich" {
+		t.Fatalf("client city overwritten with %s", *second.City)
+	}
+	if *first.UserID != "user-1" || *first.IPAddress != "203.0.113.7" || string(first.Properties) != "{}" {
+		t.Fatalf("user %v ip %v properties %s", first.UserID, first.IPAddress, first.Properties)
+	}
+
+	if p.topic != "analytics.events" || len(p.messages) != 2 || string(p.messages[0].Key) != "user-1" {
+		t.Fatalf("published %d messages to %q", len(p.messages), p.topic)
+	}
+}
+
+func TestTrackBatch_AcceptsValidEventsAndReportsRejected(t *testing.T) {
+	s, r, p := newTestService()
+	h := NewHandler(s, logger.NewNoop())
+
+	body := `{"events":[
+		{"event_name":"app_open"},
+		{"event_name":""},
+		{"event_name":"purchase","event_value":9.99,"unknown_field":true}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/events/batch", strings.NewReader(body))
+	req.RemoteAddr = "203.0.113.7:5555"
+	w := httptest.NewRecorder()
+	h.TrackBatch(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data BatchResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	got := resp.Data
+	if got.Accepted != 2 || got.Rejected != 1 || len(got.Results) != 3 {
+		t.Fatalf("response = %+v", got)
+	}
+	if got.Results[1].Status != ResultRejected || got.Results[1].Field != "event_name" {
+		t.Fatalf("second event result = %+v", got.Results[1])
+	}
+	if len(r.events) != 2 || len(p.messages) != 2 {
+		t.Fatalf("stored %d and published %d events, want 2", len(r.events), len(p.messages))
+	}
+	if *r.events[0].IPAddress != "203.0.113.7" {
+		t.Fatalf("ip = %s", *r.events[0].IPAddress)
+	}
+}
+
+func TestTrackBatch_RejectsOversizedAndInvalidBatches(t *testing.T) {
+	s, r, _ := newTestService()
+	h := NewHandler(s, logger.NewNoop())
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"too many events", `{"events":[{"event_name":"a"},{"event_name":"b"},{"event_name":"c"},{"event_name":"d"}]}`, http.StatusBadRequest},
+		{"empty batch", `{"events":[]}`, http.StatusBadRequest},
+		{"malformed json", `{"events":[`, http.StatusBadRequest},
+		{"nothing valid", `{"events":[{"event_name":""}]}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.TrackBatch(w, httptest.NewRequest(http.MethodPost, "/events/batch", strings.NewReader(c.body)))
+			if w.Code != c.want {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, c.want, w.Body.String())
+			}
+		})
+	}
+	if len(r.events) != 0 {
+		t.Fatalf("stored %d events from rejected batches", len(r.events))
+	}
+}
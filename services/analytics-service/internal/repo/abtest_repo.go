@@ -0,0 +1,233 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"shared/pkg/database"
+	"shared/pkg/database/postgres"
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// ABTestRepository reads A/B tests and records who is assigned to which
+// variant
+type ABTestRepository interface {
+	// GetTestByName returns a test, or nil when there is none by that name
+	GetTestByName(ctx context.Context, testName string) (*models.ABTest, error)
+
+	// GetAssignment returns a user's assignment to a test, or nil when they
+	// have none
+	GetAssignment(ctx context.Context, testID, userID string) (*models.ABTestAssignment, error)
+
+	// CreateAssignment stores an assignment and counts it in the test's
+	// sample size. If the user was assigned concurrently, the assignment
+	// that won is returned instead.
+	CreateAssignment(ctx context.Context, assignment *models.ABTestAssignment) (*models.ABTestAssignment, error)
+
+	// GetActiveSegments returns the segments a user belongs to at now
+	GetActiveSegments(ctx context.Context, userID string, now time.Time) ([]string, error)
+
+	// RecordConversion marks a user's assignment converted, adding value to
+	// its conversion value, and reports whether the user had an assignment
+	RecordConversion(ctx context.Context, testID, userID string, value float64, at time.Time) (bool, error)
+}
+
+type abTestRepository struct {
+	db  database.Database
+	log logger.Logger
+}
+
+// NewABTestRepository creates a new A/B test repository
+func NewABTestRepository(db database.Database, log logger.Logger) ABTestRepository {
+	return &abTestRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// GetTestByName returns a test, or nil when there is none by that name
+func (r *abTestRepository) GetTestByName(ctx context.Context, testName string) (*models.ABTest, error) {
+	query := `
+		SELECT id, test_name, variants, COALESCE(target_percentage, 100),
+		       target_platforms, target_countries, target_user_segments,
+		       status, starts_at, ends_at, COALESCE(sample_size, 0)
+		FROM analytics.ab_tests
+		WHERE test_name = $1
+	`
+
+	var test models.ABTest
+	var variants []byte
+	err := r.db.QueryRow(ctx, query, testName).Scan(
+		&test.ID,
+		&test.TestName,
+		&variants,
+		&test.TargetPercentage,
+		&test.TargetPlatforms,
+		&test.TargetCountries,
+		&test.TargetUserSegments,
+		&test.Status,
+		&test.StartsAt,
+		&test.EndsAt,
+		&test.SampleSize,
+	)
+	if err != nil {
+		if postgres.IsNoRowsError(err) {
+			return nil, nil
+		}
+		r.log.Error("Failed to get A/B test",
+			logger.String("test_name", testName),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	test.Variants = variants
+
+	return &test, nil
+}
+
+// GetAssignment returns a user's assignment to a test, or nil when they have
+// none
+func (r *abTestRepository) GetAssignment(ctx context.Context, testID, userID string) (*models.ABTestAssignment, error) {
+	query := `
+		SELECT id, test_id, user_id, variant_name, variant_config,
+		       converted, conversion_value, converted_at, assigned_at
+		FROM analytics.ab_test_assignments
+		WHERE test_id = $1 AND user_id = $2
+	`
+
+	var a models.ABTestAssignment
+	var config []byte
+	err := r.db.QueryRow(ctx, query, testID, userID).Scan(
+		&a.ID,
+		&a.TestID,
+		&a.UserID,
+		&a.VariantName,
+		&config,
+		&a.Converted,
+		&a.ConversionValue,
+		&a.ConvertedAt,
+		&a.AssignedAt,
+	)
+	if err != nil {
+		if postgres.IsNoRowsError(err) {
+			return nil, nil
+		}
+		r.log.Error("Failed to get A/B test assignment",
+			logger.String("test_id", testID),
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	a.VariantConfig = config
+
+	return &a, nil
+}
+
+// CreateAssignment stores an assignment and counts it in the test's sample
+// size. If the user was assigned concurrently, the assignment that won is
+// returned instead.
+func (r *abTestRepository) CreateAssignment(ctx context.Context, assignment *models.ABTestAssignment) (*models.ABTestAssignment, error) {
+	if assignment.ID == "" {
+		assignment.ID = uuid.NewString()
+	}
+
+	query := `
+		WITH inserted AS (
+			INSERT INTO analytics.ab_test_assignments (
+				id, test_id, user_id, variant_name, variant_config, assigned_at
+			) VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (test_id, user_id) DO NOTHING
+			RETURNING id
+		), counted AS (
+			UPDATE analytics.ab_tests
+			SET sample_size = sample_size + 1, updated_at = $6
+			WHERE id = $2 AND EXISTS (SELECT 1 FROM inserted)
+		)
+		SELECT id FROM inserted
+	`
+
+	var id string
+	err := r.db.QueryRow(ctx, query,
+		assignment.ID,
+		assignment.TestID,
+		assignment.UserID,
+		assignment.VariantName,
+		assignment.VariantConfig,
+		assignment.AssignedAt,
+	).Scan(&id)
+	if err == nil {
+		return assignment, nil
+	}
+	if !postgres.IsNoRowsError(err) {
+		r.log.Error("Failed to create A/B test assignment",
+			logger.String("test_id", assignment.TestID),
+			logger.String("user_id", assignment.UserID),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	// Another request assigned the user first; theirs stands
+	return r.GetAssignment(ctx, assignment.TestID, assignment.UserID)
+}
+
+// GetActiveSegments returns the segments a user belongs to at now
+func (r *abTestRepository) GetActiveSegments(ctx context.Context, userID string, now time.Time) ([]string, error) {
+	query := `
+		SELECT segment_name
+		FROM analytics.user_segments
+		WHERE user_id = $1
+		  AND is_active = TRUE
+		  AND (expires_at IS NULL OR expires_at > $2)
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, now)
+	if err != nil {
+		r.log.Error("Failed to get user segments",
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []string
+	for rows.Next() {
+		var segment string
+		if err := rows.Scan(&segment); err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+
+	return segments, rows.Err()
+}
+
+// RecordConversion marks a user's assignment converted, adding value to its
+// conversion value, and reports whether the user had an assignment
+func (r *abTestRepository) RecordConversion(ctx context.Context, testID, userID string, value float64, at time.Time) (bool, error) {
+	query := `
+		UPDATE analytics.ab_test_assignments
+		SET converted = TRUE,
+		    conversion_value = COALESCE(conversion_value, 0) + $3,
+		    converted_at = COALESCE(converted_at, $4)
+		WHERE test_id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.Exec(ctx, query, testID, userID, value, at)
+	if err != nil {
+		r.log.Error("Failed to record A/B test conversion",
+			logger.String("test_id", testID),
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+		return false, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"context"
+
+	"shared/pkg/database"
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// EventRepository stores ingested analytics events
+type EventRepository interface {
+	// CreateEvents inserts events in as few statements as possible, filling
+	// in the IDs of events that have none
+	CreateEvents(ctx context.Context, events []*models.Event) error
+}
+
+type eventRepository struct {
+	db  database.Database
+	log logger.Logger
+}
+
+// NewEventRepository creates a new analytics event repository
+func NewEventRepository(db database.Database, log logger.Logger) EventRepository {
+	return &eventRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// CreateEvents inserts events in as few statements as possible, filling in
+// the IDs of events that have none
+func (r *eventRepository) CreateEvents(ctx context.Context, events []*models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	rows := make([]database.Model, len(events))
+	for i, event := range events {
+		if event.ID == "" {
+			event.ID = uuid.NewString()
+		}
+		rows[i] = event
+	}
+
+	if _, err := r.db.CreateMany(ctx, rows); err != nil {
+		r.log.Error("Failed to create analytics events",
+			logger.Int("count", len(events)),
+			logger.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
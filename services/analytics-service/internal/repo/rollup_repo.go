@@ -0,0 +1,368 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"shared/pkg/database"
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+)
+
+// dateLayout formats DATE parameters, which are sent as text so the session
+// time zone can never shift them by a day
+const dateLayout = "2006-01-02"
+
+// rollupBatchSize caps the rows written per statement, well below the
+// Postgres parameter limit
+const rollupBatchSize = 1000
+
+// EventCount is how many events of one name a user sent from one flow and
+// platform during a day. Anonymous events have an empty UserID.
+type EventCount struct {
+	UserID     string
+	EventName  string
+	FlowName   string
+	Platform   string
+	Count      int
+	DurationMS int64
+}
+
+// SessionCount sums one user's sessions on one platform that started during
+// a day
+type SessionCount struct {
+	UserID           string
+	Platform         string
+	Sessions         int
+	TimedSessions    int
+	DurationSeconds  int64
+	MessagesSent     int
+	MessagesReceived int
+}
+
+// DayActivity is the raw activity a daily rollup aggregates
+type DayActivity struct {
+	Date     time.Time
+	Events   []EventCount
+	Sessions []SessionCount
+	// RegisteredAt holds the sign-up time of every user active that day
+	RegisteredAt map[string]time.Time
+	NewUsers     int
+	// ChurnCandidates were last active exactly the churn window before Date
+	ChurnCandidates []string
+}
+
+// DayRollup is everything a daily rollup writes
+type DayRollup struct {
+	ActiveUsers []*models.DailyActiveUser
+	Metric      *models.DailyMetric
+	Cohorts     []*models.UserCohort
+}
+
+// RollupRepository reads a day's raw activity and stores its aggregates
+type RollupRepository interface {
+	// RollupDay reads day's activity, passes it to aggregate and stores the
+	// result, all in one transaction. Rows for the day are upserted, so
+	// running a day again replaces its earlier results.
+	RollupDay(ctx context.Context, day time.Time, churnDays int, aggregate func(*DayActivity) *DayRollup) error
+}
+
+type rollupRepository struct {
+	db  database.Database
+	log logger.Logger
+}
+
+// NewRollupRepository creates a new daily rollup repository
+func NewRollupRepository(db database.Database, log logger.Logger) RollupRepository {
+	return &rollupRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// RollupDay reads day's activity, passes it to aggregate and stores the
+// result, all in one transaction
+func (r *rollupRepository) RollupDay(ctx context.Context, day time.Time, churnDays int, aggregate func(*DayActivity) *DayRollup) error {
+	date := day.Format(dateLayout)
+
+	dbErr := r.db.WithTransaction(ctx, func(tx database.Transaction) *database.DBError {
+		activity, err := readDayActivity(ctx, tx, day, churnDays)
+		if err != nil {
+			return database.WrapDBError(err, database.CodeDBInternal, "failed to read day activity")
+		}
+
+		rollup := aggregate(activity)
+		if err := upsertDailyActiveUsers(ctx, tx, date, rollup.ActiveUsers); err != nil {
+			return database.WrapDBError(err, database.CodeDBInternal, "failed to upsert daily active users")
+		}
+		if err := upsertDailyMetric(ctx, tx, date, rollup.Metric); err != nil {
+			return database.WrapDBError(err, database.CodeDBInternal, "failed to upsert daily metric")
+		}
+		if err := upsertCohorts(ctx, tx, rollup.Cohorts); err != nil {
+			return database.WrapDBError(err, database.CodeDBInternal, "failed to upsert user cohorts")
+		}
+		return nil
+	})
+	if dbErr != nil {
+		r.log.Error("Failed to roll up day",
+			logger.String("date", date),
+			logger.Error(dbErr),
+		)
+		return dbErr
+	}
+
+	return nil
+}
+
+func readDayActivity(ctx context.Context, tx database.Transaction, day time.Time, churnDays int) (*DayActivity, error) {
+	from, to := day, day.AddDate(0, 0, 1)
+	activity := &DayActivity{
+		Date:         day,
+		RegisteredAt: make(map[string]time.Time),
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT COALESCE(user_id::text, ''), event_name, COALESCE(flow_name, ''),
+		       COALESCE(platform, ''), COUNT(*), COALESCE(SUM(event_duration_ms), 0)
+		FROM analytics.events
+		WHERE event_timestamp >= $1 AND event_timestamp < $2
+		GROUP BY 1, 2, 3, 4
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var c EventCount
+		if err := rows.Scan(&c.UserID, &c.EventName, &c.FlowName, &c.Platform, &c.Count, &c.DurationMS); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		activity.Events = append(activity.Events, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT user_id::text, COALESCE(platform, ''), COUNT(*),
+		       COUNT(session_duration_seconds), COALESCE(SUM(session_duration_seconds), 0),
+		       COALESCE(SUM(messages_sent), 0), COALESCE(SUM(messages_received), 0)
+		FROM analytics.user_sessions
+		WHERE session_start >= $1 AND session_start < $2 AND user_id IS NOT NULL
+		GROUP BY 1, 2
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var c SessionCount
+		if err := rows.Scan(&c.UserID, &c.Platform, &c.Sessions, &c.TimedSessions, &c.DurationSeconds, &c.MessagesSent, &c.MessagesReceived); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		activity.Sessions = append(activity.Sessions, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT u.id::text, u.created_at
+		FROM auth.users u
+		WHERE u.id IN (
+			SELECT user_id FROM analytics.events
+			WHERE event_timestamp >= $1 AND event_timestamp < $2 AND user_id IS NOT NULL
+			UNION
+			SELECT user_id FROM analytics.user_sessions
+			WHERE session_start >= $1 AND session_start < $2 AND user_id IS NOT NULL
+		)
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var userID string
+		var createdAt time.Time
+		if err := rows.Scan(&userID, &createdAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		activity.RegisteredAt[userID] = createdAt
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM auth.users WHERE created_at >= $1 AND created_at < $2
+	`, from, to).Scan(&activity.NewUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT d.user_id::text
+		FROM analytics.daily_active_users d
+		WHERE d.date = $1::date - $2::int
+		  AND NOT EXISTS (
+			SELECT 1 FROM analytics.daily_active_users l
+			WHERE l.user_id = d.user_id AND l.date > d.date AND l.date < $1::date
+		  )
+	`, day.Format(dateLayout), churnDays)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		activity.ChurnCandidates = append(activity.ChurnCandidates, userID)
+	}
+	rows.Close()
+	return activity, rows.Err()
+}
+
+func upsertDailyActiveUsers(ctx context.Context, tx database.Transaction, date string, users []*models.DailyActiveUser) error {
+	for start := 0; start < len(users); start += rollupBatchSize {
+		end := min(start+rollupBatchSize, len(users))
+
+		args := []interface{}{date}
+		tuples := make([]string, 0, end-start)
+		for _, u := range users[start:end] {
+			args = append(args, u.UserID, u.SessionsCount, u.MessagesSent, u.MessagesReceived,
+				u.TimeSpentSeconds, u.FeaturesUsed, u.PlatformsUsed, u.CreatedAt)
+			n := len(args)
+			tuples = append(tuples, fmt.Sprintf("($1::date, $%d::uuid, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+				n-7, n-6, n-5, n-4, n-3, n-2, n-1, n))
+		}
+
+		_, err := tx.Exec(ctx, `
+			INSERT INTO analytics.daily_active_users (
+				date, user_id, sessions_count, messages_sent, messages_received,
+				time_spent_seconds, features_used, platforms_used, created_at
+			) VALUES `+strings.Join(tuples, ", ")+`
+			ON CONFLICT (date, user_id) DO UPDATE SET
+				sessions_count = EXCLUDED.sessions_count,
+				messages_sent = EXCLUDED.messages_sent,
+				messages_received = EXCLUDED.messages_received,
+				time_spent_seconds = EXCLUDED.time_spent_seconds,
+				features_used = EXCLUDED.features_used,
+				platforms_used = EXCLUDED.platforms_used
+		`, args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertDailyMetric writes the columns the rollup computes; revenue and
+// performance columns are left to whatever records them
+func upsertDailyMetric(ctx context.Context, tx database.Transaction, date string, m *models.DailyMetric) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO analytics.daily_metrics (
+			date, dau, new_users, churned_users,
+			total_messages_sent, total_sessions, avg_session_duration_seconds, avg_messages_per_user,
+			images_uploaded, videos_uploaded, voice_messages_sent,
+			new_conversations, new_groups_created, new_friendships,
+			voice_calls_total, video_calls_total, total_call_duration_minutes,
+			error_count, created_at
+		) VALUES ($1::date, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		ON CONFLICT (date) DO UPDATE SET
+			dau = EXCLUDED.dau,
+			new_users = EXCLUDED.new_users,
+			churned_users = EXCLUDED.churned_users,
+			total_messages_sent = EXCLUDED.total_messages_sent,
+			total_sessions = EXCLUDED.total_sessions,
+			avg_session_duration_seconds = EXCLUDED.avg_session_duration_seconds,
+			avg_messages_per_user = EXCLUDED.avg_messages_per_user,
+			images_uploaded = EXCLUDED.images_uploaded,
+			videos_uploaded = EXCLUDED.videos_uploaded,
+			voice_messages_sent = EXCLUDED.voice_messages_sent,
+			new_conversations = EXCLUDED.new_conversations,
+			new_groups_created = EXCLUDED.new_groups_created,
+			new_friendships = EXCLUDED.new_friendships,
+			voice_calls_total = EXCLUDED.voice_calls_total,
+			video_calls_total = EXCLUDED.video_calls_total,
+			total_call_duration_minutes = EXCLUDED.total_call_duration_minutes,
+			error_count = EXCLUDED.error_count
+	`,
+		date, m.DAU, m.NewUsers, m.ChurnedUsers,
+		m.TotalMessagesSent, m.TotalSessions, m.AvgSessionDurationSeconds, m.AvgMessagesPerUser,
+		m.ImagesUploaded, m.VideosUploaded, m.VoiceMessagesSent,
+		m.NewConversations, m.NewGroupsCreated, m.NewFriendships,
+		m.VoiceCallsTotal, m.VideoCallsTotal, m.TotalCallDurationMinutes,
+		m.ErrorCount, m.CreatedAt,
+	)
+	return err
+}
+
+// upsertCohorts sets the retention flags earned on the rolled up day, never
+// clearing ones set earlier, then recomputes each cohort's running totals
+// from daily_active_users so that re-running a day does not count it twice
+func upsertCohorts(ctx context.Context, tx database.Transaction, cohorts []*models.UserCohort) error {
+	if len(cohorts) == 0 {
+		return nil
+	}
+
+	for start := 0; start < len(cohorts); start += rollupBatchSize {
+		end := min(start+rollupBatchSize, len(cohorts))
+
+		var args []interface{}
+		tuples := make([]string, 0, end-start)
+		for _, c := range cohorts[start:end] {
+			args = append(args, c.UserID, c.CohortDate.Format(dateLayout), c.CohortWeek, c.CohortMonth,
+				c.Day1Active, c.Day7Active, c.Day14Active, c.Day30Active, c.Day60Active, c.Day90Active,
+				c.UpdatedAt)
+			n := len(args)
+			tuples = append(tuples, fmt.Sprintf("($%d::uuid, $%d::date, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+				n-10, n-9, n-8, n-7, n-6, n-5, n-4, n-3, n-2, n-1, n, n))
+		}
+
+		_, err := tx.Exec(ctx, `
+			INSERT INTO analytics.user_cohorts (
+				user_id, cohort_date, cohort_week, cohort_month,
+				day_1_active, day_7_active, day_14_active, day_30_active, day_60_active, day_90_active,
+				created_at, updated_at
+			) VALUES `+strings.Join(tuples, ", ")+`
+			ON CONFLICT (user_id) DO UPDATE SET
+				day_1_active = analytics.user_cohorts.day_1_active OR EXCLUDED.day_1_active,
+				day_7_active = analytics.user_cohorts.day_7_active OR EXCLUDED.day_7_active,
+				day_14_active = analytics.user_cohorts.day_14_active OR EXCLUDED.day_14_active,
+				day_30_active = analytics.user_cohorts.day_30_active OR EXCLUDED.day_30_active,
+				day_60_active = analytics.user_cohorts.day_60_active OR EXCLUDED.day_60_active,
+				day_90_active = analytics.user_cohorts.day_90_active OR EXCLUDED.day_90_active,
+				updated_at = EXCLUDED.updated_at
+		`, args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	userIDs := make([]string, len(cohorts))
+	for i, c := range cohorts {
+		userIDs[i] = c.UserID
+	}
+	_, err := tx.Exec(ctx, `
+		UPDATE analytics.user_cohorts c
+		SET messages_sent_total = t.messages_sent,
+		    days_active_count = t.days_active,
+		    last_active_date = t.last_active
+		FROM (
+			SELECT user_id, COALESCE(SUM(messages_sent), 0) AS messages_sent,
+			       COUNT(*) AS days_active, MAX(date) AS last_active
+			FROM analytics.daily_active_users
+			WHERE user_id = ANY($1::uuid[])
+			GROUP BY user_id
+		) t
+		WHERE c.user_id = t.user_id
+	`, userIDs)
+	return err
+}
@@ -0,0 +1,165 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"shared/pkg/database"
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// WebhookRepository records webhook deliveries and finds the ones due for
+// another attempt
+type WebhookRepository interface {
+	// CreateDelivery inserts a delivery, filling in its ID when it has none.
+	// It returns false without inserting when a delivery with that ID was
+	// already recorded.
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error)
+
+	// UpdateDelivery records the outcome of a delivery attempt
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	// GetDueRetries returns up to limit deliveries waiting to be retried at
+	// now, oldest first
+	GetDueRetries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db  database.Database
+	log logger.Logger
+}
+
+// NewWebhookRepository creates a new webhook delivery repository
+func NewWebhookRepository(db database.Database, log logger.Logger) WebhookRepository {
+	return &webhookRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// CreateDelivery inserts a delivery unless one with its ID exists, filling in
+// the ID when it has none
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	if delivery.ID == "" {
+		delivery.ID = uuid.NewString()
+	}
+
+	query := `
+		INSERT INTO analytics.webhook_deliveries (
+			id, webhook_url, event_type, status, attempt_number, max_attempts,
+			next_retry_at, payload, payload_size_bytes, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		delivery.ID,
+		delivery.WebhookURL,
+		delivery.EventType,
+		delivery.Status,
+		delivery.AttemptNumber,
+		delivery.MaxAttempts,
+		delivery.NextRetryAt,
+		delivery.Payload,
+		delivery.PayloadSizeBytes,
+		delivery.CreatedAt,
+	)
+	if err != nil {
+		r.log.Error("Failed to create webhook delivery",
+			logger.String("webhook_url", delivery.WebhookURL),
+			logger.String("event_type", delivery.EventType),
+			logger.Error(err),
+		)
+		return false, err
+	}
+
+	created, rowsErr := result.RowsAffected()
+	if rowsErr != nil {
+		return false, rowsErr
+	}
+	return created == 1, nil
+}
+
+// UpdateDelivery records the outcome of a delivery attempt
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		UPDATE analytics.webhook_deliveries
+		SET status = $2,
+		    http_status_code = $3,
+		    response_time_ms = $4,
+		    attempt_number = $5,
+		    next_retry_at = $6,
+		    error_message = $7,
+		    sent_at = $8
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		delivery.ID,
+		delivery.Status,
+		delivery.HTTPStatusCode,
+		delivery.ResponseTimeMS,
+		delivery.AttemptNumber,
+		delivery.NextRetryAt,
+		delivery.ErrorMessage,
+		delivery.SentAt,
+	)
+	if err != nil {
+		r.log.Error("Failed to update webhook delivery",
+			logger.String("delivery_id", delivery.ID),
+			logger.String("status", string(delivery.Status)),
+			logger.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// GetDueRetries returns up to limit deliveries waiting to be retried at now,
+// oldest first
+func (r *webhookRepository) GetDueRetries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+		SELECT id, webhook_url, event_type, status, attempt_number, max_attempts,
+		       next_retry_at, payload, payload_size_bytes, created_at
+		FROM analytics.webhook_deliveries
+		WHERE status = 'retrying'
+		  AND next_retry_at <= $1
+		ORDER BY next_retry_at
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		r.log.Error("Failed to get due webhook retries", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload []byte
+		if err := rows.Scan(
+			&d.ID,
+			&d.WebhookURL,
+			&d.EventType,
+			&d.Status,
+			&d.AttemptNumber,
+			&d.MaxAttempts,
+			&d.NextRetryAt,
+			&payload,
+			&d.PayloadSizeBytes,
+			&d.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		deliveries = append(deliveries, &d)
+	}
+
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,280 @@
+package rollup
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"analytics-service/internal/repo"
+
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+)
+
+// Event names the rollup counts. Clients report them through the ingestion
+// endpoints; any other name only makes its sender a daily active user.
+const (
+	EventMessageSent         = "message_sent"
+	EventMessageReceived     = "message_received"
+	EventImageUploaded       = "image_uploaded"
+	EventVideoUploaded       = "video_uploaded"
+	EventVoiceMessageSent    = "voice_message_sent"
+	EventConversationCreated = "conversation_created"
+	EventGroupCreated        = "group_created"
+	EventFriendshipCreated   = "friendship_created"
+	// Call events carry the call length in event_duration_ms
+	EventVoiceCall = "voice_call"
+	EventVideoCall = "video_call"
+	EventError     = "error"
+)
+
+// retentionDays are the days after sign-up that user_cohorts tracks
+var retentionDays = []int{1, 7, 14, 30, 60, 90}
+
+type Config struct {
+	// LookbackDays is how many days, ending yesterday, each sweep rolls up.
+	// More than one picks up events that arrive late; raise it to backfill.
+	LookbackDays int
+	// ChurnDays is how long a user must be inactive to count as churned
+	ChurnDays int
+}
+
+// Service aggregates each day's raw events and sessions into
+// daily_active_users, daily_metrics and the user_cohorts retention flags.
+// Days are UTC calendar days.
+type Service struct {
+	repo repo.RollupRepository
+	cfg  Config
+	log  logger.Logger
+	now  func() time.Time
+}
+
+// NewService creates a daily rollup service
+func NewService(r repo.RollupRepository, cfg Config, log logger.Logger) *Service {
+	return &Service{
+		repo: r,
+		cfg:  cfg,
+		log:  log,
+		now:  time.Now,
+	}
+}
+
+// RollupRecent rolls up the configured number of days ending yesterday,
+// oldest first, and returns how many it rolled up. It is the scheduled job.
+func (s *Service) RollupRecent(ctx context.Context) (int, error) {
+	today := startOfDay(s.now())
+	return s.RollupRange(ctx, today.AddDate(0, 0, -s.cfg.LookbackDays), today.AddDate(0, 0, -1))
+}
+
+// RollupRange rolls up every day from from to to inclusive, oldest first, for
+// backfills. It stops at the first day that fails.
+func (s *Service) RollupRange(ctx context.Context, from, to time.Time) (int, error) {
+	rolled := 0
+	for day := startOfDay(from); !day.After(startOfDay(to)); day = day.AddDate(0, 0, 1) {
+		if err := s.RollupDay(ctx, day); err != nil {
+			return rolled, err
+		}
+		rolled++
+	}
+	return rolled, nil
+}
+
+// RollupDay aggregates one day. Its results replace any earlier run's, so a
+// day can be rolled up again safely.
+func (s *Service) RollupDay(ctx context.Context, day time.Time) error {
+	day = startOfDay(day)
+	now := s.now()
+
+	var dau int
+	err := s.repo.RollupDay(ctx, day, s.cfg.ChurnDays, func(activity *repo.DayActivity) *repo.DayRollup {
+		rollup := aggregate(activity, now)
+		dau = rollup.Metric.DAU
+		return rollup
+	})
+	if err != nil {
+		return err
+	}
+
+	s.log.Info("Rolled up daily analytics",
+		logger.String("date", day.Format("2006-01-02")),
+		logger.Int("dau", dau),
+	)
+	return nil
+}
+
+// aggregate computes a day's rollup from its raw activity
+func aggregate(activity *repo.DayActivity, now time.Time) *repo.DayRollup {
+	users := make(map[string]*userDay)
+	user := func(id string) *userDay {
+		u, ok := users[id]
+		if !ok {
+			u = &userDay{features: make(map[string]bool), platforms: make(map[string]bool)}
+			users[id] = u
+		}
+		return u
+	}
+
+	metric := &models.DailyMetric{Date: activity.Date, CreatedAt: now}
+	var callDurationMS int64
+
+	for _, e := range activity.Events {
+		switch e.EventName {
+		case EventImageUploaded:
+			metric.ImagesUploaded += e.Count
+		case EventVideoUploaded:
+			metric.VideosUploaded += e.Count
+		case EventVoiceMessageSent:
+			metric.VoiceMessagesSent += e.Count
+		case EventConversationCreated:
+			metric.NewConversations += e.Count
+		case EventGroupCreated:
+			metric.NewGroupsCreated += e.Count
+		case EventFriendshipCreated:
+			metric.NewFriendships += e.Count
+		case EventVoiceCall:
+			metric.VoiceCallsTotal += e.Count
+			callDurationMS += e.DurationMS
+		case EventVideoCall:
+			metric.VideoCallsTotal += e.Count
+			callDurationMS += e.DurationMS
+		case EventError:
+			metric.ErrorCount += e.Count
+		}
+
+		if e.UserID == "" {
+			continue
+		}
+		u := user(e.UserID)
+		switch e.EventName {
+		case EventMessageSent:
+			u.messagesSentEvents += e.Count
+		case EventMessageReceived:
+			u.messagesReceivedEvents += e.Count
+		}
+		if e.FlowName != "" {
+			u.features[e.FlowName] = true
+		}
+		if e.Platform != "" {
+			u.platforms[e.Platform] = true
+		}
+	}
+
+	var sessionSeconds int64
+	var timedSessions int
+	for _, c := range activity.Sessions {
+		u := user(c.UserID)
+		u.sessions += c.Sessions
+		u.timeSpent += c.DurationSeconds
+		u.messagesSentSessions += c.MessagesSent
+		u.messagesReceivedSessions += c.MessagesReceived
+		if c.Platform != "" {
+			u.platforms[c.Platform] = true
+		}
+		sessionSeconds += c.DurationSeconds
+		timedSessions += c.TimedSessions
+	}
+
+	rollup := &repo.DayRollup{Metric: metric}
+	for _, id := range sortedKeys(users) {
+		u := users[id]
+		dau := &models.DailyActiveUser{
+			Date:             activity.Date,
+			UserID:           id,
+			SessionsCount:    u.sessions,
+			MessagesSent:     u.messagesSent(),
+			MessagesReceived: u.messagesReceived(),
+			TimeSpentSeconds: int(u.timeSpent),
+			FeaturesUsed:     sortedKeys(u.features),
+			PlatformsUsed:    sortedKeys(u.platforms),
+			CreatedAt:        now,
+		}
+		rollup.ActiveUsers = append(rollup.ActiveUsers, dau)
+
+		metric.TotalMessagesSent += int64(dau.MessagesSent)
+		metric.TotalSessions += int64(dau.SessionsCount)
+
+		if registeredAt, ok := activity.RegisteredAt[id]; ok {
+			rollup.Cohorts = append(rollup.Cohorts, cohortFor(id, startOfDay(registeredAt), activity.Date, now))
+		}
+	}
+
+	metric.DAU = len(users)
+	metric.NewUsers = activity.NewUsers
+	for _, id := range activity.ChurnCandidates {
+		if _, active := users[id]; !active {
+			metric.ChurnedUsers++
+		}
+	}
+	if timedSessions > 0 {
+		metric.AvgSessionDurationSeconds = int(sessionSeconds / int64(timedSessions))
+	}
+	if metric.DAU > 0 {
+		metric.AvgMessagesPerUser = math.Round(float64(metric.TotalMessagesSent)/float64(metric.DAU)*100) / 100
+	}
+	metric.TotalCallDurationMinutes = callDurationMS / int64(time.Minute/time.Millisecond)
+
+	return rollup
+}
+
+// cohortFor returns the retention flags a user earns by being active on day
+func cohortFor(userID string, cohortDate, day, now time.Time) *models.UserCohort {
+	_, week := cohortDate.ISOWeek()
+	month := int(cohortDate.Month())
+	cohort := &models.UserCohort{
+		UserID:      userID,
+		CohortDate:  cohortDate,
+		CohortWeek:  &week,
+		CohortMonth: &month,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	age := int(day.Sub(cohortDate).Hours() / 24)
+	flags := []*bool{&cohort.Day1Active, &cohort.Day7Active, &cohort.Day14Active, &cohort.Day30Active, &cohort.Day60Active, &cohort.Day90Active}
+	for i, d := range retentionDays {
+		if age == d {
+			*flags[i] = true
+		}
+	}
+	return cohort
+}
+
+// userDay accumulates one user's activity over a day
+type userDay struct {
+	sessions                 int
+	timeSpent                int64
+	messagesSentEvents       int
+	messagesSentSessions     int
+	messagesReceivedEvents   int
+	messagesReceivedSessions int
+	features                 map[string]bool
+	platforms                map[string]bool
+}
+
+// Message counts come from both message events and session totals; whichever
+// pipeline saw more of them is taken, since both count the same messages
+func (u *userDay) messagesSent() int {
+	return max(u.messagesSentEvents, u.messagesSentSessions)
+}
+
+func (u *userDay) messagesReceived() int {
+	return max(u.messagesReceivedEvents, u.messagesReceivedSessions)
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
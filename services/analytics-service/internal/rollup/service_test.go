@@ -0,0 +1,192 @@
+package rollup
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"analytics-service/internal/repo"
+
+	"shared/pkg/logger"
+)
+
+type memRollupRepo struct {
+	activity map[string]*repo.DayActivity
+	rollups  map[string]*repo.DayRollup
+	order    []string
+}
+
+func newMemRollupRepo() *memRollupRepo {
+	return &memRollupRepo{
+		activity: make(map[string]*repo.DayActivity),
+		rollups:  make(map[string]*repo.DayRollup),
+	}
+}
+
+func (r *memRollupRepo) RollupDay(ctx context.Context, day time.Time, churnDays int, aggregate func(*repo.DayActivity) *repo.DayRollup) error {
+	key := day.Format("2006-01-02")
+	activity, ok := r.activity[key]
+	if !ok {
+		activity = &repo.DayActivity{Date: day}
+	}
+	r.rollups[key] = aggregate(activity)
+	r.order = append(r.order, key)
+	return nil
+}
+
+var (
+	day   = time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	alice = "00000000-0000-4000-8000-000000000001"
+	bob   = "00000000-0000-4000-8000-000000000002"
+	carol = "00000000-0000-4000-8000-000000000003"
+	dave  = "00000000-0000-4000-8000-000000000004"
+)
+
+func seededActivity() *repo.DayActivity {
+	return &repo.DayActivity{
+		Date: day,
+		Events: []repo.EventCount{
+			{UserID: alice, EventName: EventMessageSent, FlowName: "messaging", Platform: "ios", Count: 12},
+			{UserID: alice, EventName: EventImageUploaded, FlowName: "messaging", Platform: "ios", Count: 2},
+			{UserID: alice, EventName: EventVoiceCall, Platform: "ios", Count: 1, DurationMS: 5 * 60 * 1000},
+			{UserID: bob, EventName: EventMessageSent, FlowName: "messaging", Platform: "web", Count: 3},
+			{UserID: bob, EventName: EventVideoCall, Platform: "web", Count: 2, DurationMS: 7 * 60 * 1000},
+			{UserID: bob, EventName: "screen_view", FlowName: "settings", Platform: "web", Count: 4},
+			{UserID: "", EventName: EventError, Platform: "android", Count: 5},
+		},
+		Sessions: []repo.SessionCount{
+			{UserID: alice, Platform: "ios", Sessions: 2, TimedSessions: 2, DurationSeconds: 600, MessagesSent: 10},
+			{UserID: alice, Platform: "android", Sessions: 1, TimedSessions: 1, DurationSeconds: 300},
+			{UserID: carol, Platform: "android", Sessions: 1, TimedSessions: 0, MessagesSent: 4, MessagesReceived: 6},
+		},
+		RegisteredAt: map[string]time.Time{
+			alice: day.AddDate(0, 0, -7).Add(15 * time.Hour),
+			bob:   day.AddDate(0, 0, -1).Add(23 * time.Hour),
+			carol: day.Add(9 * time.Hour),
+		},
+		NewUsers:        1,
+		ChurnCandidates: []string{bob, dave},
+	}
+}
+
+func newTestService(r *memRollupRepo) *Service {
+	s := NewService(r, Config{LookbackDays: 2, ChurnDays: 30}, logger.NewNoop())
+	s.now = func() time.Time { return day.AddDate(0, 0, 1).Add(3 * time.Hour) }
+	return s
+}
+
+func TestRollupDay_ComputesAggregates(t *testing.T) {
+	r := newMemRollupRepo()
+	r.activity["2026-03-04"] = seededActivity()
+
+	if err := newTestService(r).RollupDay(context.Background(), day.Add(13*time.Hour)); err != nil {
+		t.Fatalf("RollupDay failed: %v", err)
+	}
+	rollup := r.rollups["2026-03-04"]
+
+	m := rollup.Metric
+	if m.DAU != 3 || m.NewUsers != 1 {
+		t.Fatalf("dau = %d, new users = %d; want 3 and 1", m.DAU, m.NewUsers)
+	}
+	// Bob was a churn candidate but came back; Dave did not
+	if m.ChurnedUsers != 1 {
+		t.Fatalf("churned = %d, want 1", m.ChurnedUsers)
+	}
+	// Alice 12 (events beat sessions' 10), Bob 3, Carol 4 from sessions
+	if m.TotalMessagesSent != 19 || m.AvgMessagesPerUser != 6.33 {
+		t.Fatalf("messages = %d avg %.2f; want 19 and 6.33", m.TotalMessagesSent, m.AvgMessagesPerUser)
+	}
+	// Carol's untimed session counts towards sessions but not the average
+	if m.TotalSessions != 4 || m.AvgSessionDurationSeconds != 300 {
+		t.Fatalf("sessions = %d avg %ds; want 4 and 300s", m.TotalSessions, m.AvgSessionDurationSeconds)
+	}
+	if m.ImagesUploaded != 2 || m.VoiceCallsTotal != 1 || m.VideoCallsTotal != 2 || m.TotalCallDurationMinutes != 12 || m.ErrorCount != 5 {
+		t.Fatalf("metric = %+v", m)
+	}
+
+	if len(rollup.ActiveUsers) != 3 {
+		t.Fatalf("active users = %d, want 3", len(rollup.ActiveUsers))
+	}
+	a := rollup.ActiveUsers[0]
+	if a.UserID != alice || a.SessionsCount != 3 || a.MessagesSent != 12 || a.TimeSpentSeconds != 900 {
+		t.Fatalf("alice = %+v", a)
+	}
+	if !reflect.DeepEqual([]string(a.PlatformsUsed), []string{"android", "ios"}) || !reflect.DeepEqual([]string(a.FeaturesUsed), []string{"messaging"}) {
+		t.Fatalf("alice platforms %v features %v", a.PlatformsUsed, a.FeaturesUsed)
+	}
+	if c := rollup.ActiveUsers[2]; c.UserID != carol || c.MessagesReceived != 6 || c.FeaturesUsed != nil {
+		t.Fatalf("carol = %+v", c)
+	}
+}
+
+func TestRollupDay_SetsRetentionFlags(t *testing.T) {
+	r := newMemRollupRepo()
+	r.activity["2026-03-04"] = seededActivity()
+
+	if err := newTestService(r).RollupDay(context.Background(), day); err != nil {
+		t.Fatalf("RollupDay failed: %v", err)
+	}
+
+	cohorts := map[string]bool{}
+	for _, c := range r.rollups["2026-03-04"].Cohorts {
+		flags := []bool{c.Day1Active, c.Day7Active, c.Day14Active, c.Day30Active, c.Day60Active, c.Day90Active}
+		var want []bool
+		switch c.UserID {
+		case alice:
+			want = []bool{false, true, false, false, false, false}
+		case bob:
+			want = []bool{true, false, false, false, false, false}
+		case carol:
+			want = []bool{false, false, false, false, false, false}
+		}
+		if !reflect.DeepEqual(flags, want) {
+			t.Fatalf("%s flags = %v, want %v", c.UserID, flags, want)
+		}
+		cohorts[c.UserID] = true
+	}
+	if len(cohorts) != 3 {
+		t.Fatalf("cohorts for %v, want all three users", cohorts)
+	}
+}
+
+func TestRollupDay_IsRepeatable(t *testing.T) {
+	r := newMemRollupRepo()
+	r.activity["2026-03-04"] = seededActivity()
+	s := newTestService(r)
+
+	if err := s.RollupDay(context.Background(), day); err != nil {
+		t.Fatalf("RollupDay failed: %v", err)
+	}
+	first := r.rollups["2026-03-04"]
+	if err := s.RollupDay(context.Background(), day); err != nil {
+		t.Fatalf("second RollupDay failed: %v", err)
+	}
+	if !reflect.DeepEqual(first, r.rollups["2026-03-04"]) {
+		t.Fatalf("re-running a day changed its rollup")
+	}
+}
+
+func TestRollupRecent_CoversLookbackOldestFirst(t *testing.T) {
+	r := newMemRollupRepo()
+	s := newTestService(r)
+
+	rolled, err := s.RollupRecent(context.Background())
+	if err != nil || rolled != 2 {
+		t.Fatalf("RollupRecent = %d, %v; want 2", rolled, err)
+	}
+	if want := []string{"2026-03-03", "2026-03-04"}; !reflect.DeepEqual(r.order, want) {
+		t.Fatalf("rolled up %v, want %v", r.order, want)
+	}
+	if m := r.rollups["2026-03-03"].Metric; m.DAU != 0 || m.AvgMessagesPerUser != 0 {
+		t.Fatalf("empty day metric = %+v", m)
+	}
+
+	r.order = nil
+	if rolled, _ := s.RollupRange(context.Background(), day.AddDate(0, 0, -9), day.AddDate(0, 0, -7)); rolled != 3 {
+		t.Fatalf("backfill rolled %d days, want 3", rolled)
+	}
+	if r.order[0] != "2026-02-23" || r.order[2] != "2026-02-25" {
+		t.Fatalf("backfill rolled %v", r.order)
+	}
+}
@@ -0,0 +1,303 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"analytics-service/internal/repo"
+
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+)
+
+// Headers sent with every webhook request. The signature is an HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the target's secret, so receivers can
+// reject replayed or forged requests.
+const (
+	HeaderEvent     = "X-Echo-Event"
+	HeaderDelivery  = "X-Echo-Delivery"
+	HeaderTimestamp = "X-Echo-Timestamp"
+	HeaderSignature = "X-Echo-Signature"
+)
+
+// maxErrorBodyBytes caps how much of a failed response is kept as the error
+const maxErrorBodyBytes = 512
+
+var ErrInvalidPayload = errors.New("webhook payload must be valid JSON")
+
+// Target is an endpoint that receives webhook events
+type Target struct {
+	Name        string
+	URL         string
+	Secret      string
+	Timeout     time.Duration
+	MaxAttempts int
+	// Events lists the event types the target receives; empty means all
+	Events []string
+}
+
+// Accepts reports whether the target subscribes to eventType
+func (t *Target) Accepts(eventType string) bool {
+	if len(t.Events) == 0 {
+		return true
+	}
+	for _, e := range t.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff spaces out retries: the first waits Base, each later one twice as
+// long as the one before, capped at Max
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns how long to wait after the given failed attempt (1-based)
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Base
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
+
+// Dispatcher POSTs events to webhook targets, recording every attempt as a
+// WebhookDelivery and retrying failures with exponential backoff until the
+// target's max attempts are used up
+type Dispatcher struct {
+	repo    repo.WebhookRepository
+	client  *http.Client
+	targets map[string]*Target
+	backoff Backoff
+	log     logger.Logger
+	now     func() time.Time
+}
+
+// NewDispatcher creates a dispatcher for the given targets. Targets are
+// looked up by URL when a delivery is retried.
+func NewDispatcher(r repo.WebhookRepository, targets []Target, backoff Backoff, log logger.Logger) *Dispatcher {
+	byURL := make(map[string]*Target, len(targets))
+	for i := range targets {
+		byURL[targets[i].URL] = &targets[i]
+	}
+	return &Dispatcher{
+		repo:    r,
+		client:  &http.Client{},
+		targets: byURL,
+		backoff: backoff,
+		log:     log,
+		now:     time.Now,
+	}
+}
+
+// Targets returns the targets that receive eventType
+func (d *Dispatcher) Targets(eventType string) []*Target {
+	var targets []*Target
+	for _, t := range d.targets {
+		if t.Accepts(eventType) {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// Dispatch records a delivery of payload to target and makes the first
+// attempt. A failed attempt is not an error: the delivery is scheduled for
+// retry, or marked failed once it has no attempts left.
+//
+// The delivery is recorded as already retrying, due when a failed first
+// attempt would have been retried, so the sweeper picks it up if the
+// process dies before the attempt's outcome is saved.
+func (d *Dispatcher) Dispatch(ctx context.Context, target *Target, eventType string, payload []byte) (*models.WebhookDelivery, error) {
+	return d.dispatch(ctx, "", target, eventType, payload)
+}
+
+// dispatch is Dispatch for a delivery with a known ID. It returns nil without
+// sending anything when that delivery was already recorded, since it has
+// been sent or is waiting on the sweeper.
+func (d *Dispatcher) dispatch(ctx context.Context, deliveryID string, target *Target, eventType string, payload []byte) (*models.WebhookDelivery, error) {
+	if !json.Valid(payload) {
+		return nil, ErrInvalidPayload
+	}
+
+	now := d.now()
+	retryAt := now.Add(d.backoff.Delay(1))
+	if timeout := now.Add(target.Timeout); timeout.After(retryAt) {
+		retryAt = timeout
+	}
+	size := len(payload)
+	delivery := &models.WebhookDelivery{
+		ID:               deliveryID,
+		WebhookURL:       target.URL,
+		EventType:        eventType,
+		Status:           models.WebhookDeliveryStatusRetrying,
+		AttemptNumber:    1,
+		MaxAttempts:      target.MaxAttempts,
+		NextRetryAt:      &retryAt,
+		Payload:          payload,
+		PayloadSizeBytes: &size,
+		CreatedAt:        now,
+	}
+	created, err := d.repo.CreateDelivery(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		d.log.Debug("Skipping webhook delivery already recorded",
+			logger.String("delivery_id", delivery.ID),
+			logger.String("target", target.Name),
+		)
+		return nil, nil
+	}
+
+	d.attempt(ctx, target, delivery)
+	if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+// RetryDue makes the next attempt for every delivery whose retry is due and
+// returns how many it attempted
+func (d *Dispatcher) RetryDue(ctx context.Context, limit int) (int, error) {
+	deliveries, err := d.repo.GetDueRetries(ctx, d.now(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	attempted := 0
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return attempted, ctx.Err()
+		}
+
+		target, ok := d.targets[delivery.WebhookURL]
+		if !ok {
+			// The target was removed from the configuration since the
+			// first attempt; there is nowhere left to send it
+			d.giveUp(delivery, "webhook target is no longer configured")
+		} else {
+			delivery.AttemptNumber++
+			d.attempt(ctx, target, delivery)
+			attempted++
+		}
+
+		if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+			return attempted, err
+		}
+	}
+	return attempted, nil
+}
+
+// attempt sends the delivery once and records the outcome on it
+func (d *Dispatcher) attempt(ctx context.Context, target *Target, delivery *models.WebhookDelivery) {
+	start := time.Now()
+	status, err := d.post(ctx, target, delivery)
+	elapsed := int(time.Since(start).Milliseconds())
+	delivery.ResponseTimeMS = &elapsed
+	delivery.HTTPStatusCode = nil
+	if status != 0 {
+		delivery.HTTPStatusCode = &status
+	}
+
+	if err == nil {
+		now := d.now()
+		delivery.Status = models.WebhookDeliveryStatusSent
+		delivery.SentAt = &now
+		delivery.NextRetryAt = nil
+		delivery.ErrorMessage = nil
+		return
+	}
+
+	if delivery.AttemptNumber >= delivery.MaxAttempts {
+		d.log.Warn("Giving up on webhook delivery",
+			logger.String("delivery_id", delivery.ID),
+			logger.String("target", target.Name),
+			logger.Int("attempts", delivery.AttemptNumber),
+			logger.Error(err),
+		)
+		d.giveUp(delivery, err.Error())
+		return
+	}
+
+	next := d.now().Add(d.backoff.Delay(delivery.AttemptNumber))
+	message := err.Error()
+	delivery.Status = models.WebhookDeliveryStatusRetrying
+	delivery.NextRetryAt = &next
+	delivery.ErrorMessage = &message
+}
+
+func (d *Dispatcher) giveUp(delivery *models.WebhookDelivery, reason string) {
+	delivery.Status = models.WebhookDeliveryStatusFailed
+	delivery.NextRetryAt = nil
+	delivery.ErrorMessage = &reason
+}
+
+// post sends one request and returns the response status, or 0 when no
+// response arrived. Any status outside 2xx is an error.
+func (d *Dispatcher) post(ctx context.Context, target *Target, delivery *models.WebhookDelivery) (int, error) {
+	if target.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, target.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "echo-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	if target.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(target.Secret, timestamp, delivery.Payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	if len(body) == 0 {
+		return resp.StatusCode, fmt.Errorf("webhook target responded %s", resp.Status)
+	}
+	return resp.StatusCode, fmt.Errorf("webhook target responded %s: %s", resp.Status, bytes.TrimSpace(body))
+}
+
+// Sign returns the signature header value for a request body sent at
+// timestamp (unix seconds)
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,274 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+)
+
+type memWebhookRepo struct {
+	deliveries map[string]*models.WebhookDelivery
+	order      []string
+	// failUpdates makes UpdateDelivery fail, as if the process died after
+	// an attempt but before recording it
+	failUpdates bool
+	// failCreateFor makes CreateDelivery fail for deliveries to that URL
+	failCreateFor string
+}
+
+func newMemWebhookRepo() *memWebhookRepo {
+	return &memWebhookRepo{deliveries: make(map[string]*models.WebhookDelivery)}
+}
+
+func (r *memWebhookRepo) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	if delivery.WebhookURL == r.failCreateFor {
+		return false, errors.New("insert failed")
+	}
+	if delivery.ID == "" {
+		delivery.ID = "delivery-" + strconv.Itoa(len(r.order)+1)
+	}
+	if _, ok := r.deliveries[delivery.ID]; ok {
+		return false, nil
+	}
+	stored := *delivery
+	r.deliveries[delivery.ID] = &stored
+	r.order = append(r.order, delivery.ID)
+	return true, nil
+}
+
+func (r *memWebhookRepo) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if r.failUpdates {
+		return errors.New("update failed")
+	}
+	stored := *delivery
+	r.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (r *memWebhookRepo) GetDueRetries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	var due []*models.WebhookDelivery
+	for _, id := range r.order {
+		d := r.deliveries[id]
+		if d.Status == models.WebhookDeliveryStatusRetrying && !d.NextRetryAt.After(now) {
+			copied := *d
+			due = append(due, &copied)
+		}
+	}
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+type dispatcherFixture struct {
+	dispatcher *Dispatcher
+	repo       *memWebhookRepo
+	target     *Target
+	now        time.Time
+}
+
+func newDispatcherFixture(t *testing.T, handler http.HandlerFunc) *dispatcherFixture {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	f := &dispatcherFixture{
+		repo: newMemWebhookRepo(),
+		now:  time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC),
+	}
+	f.dispatcher = NewDispatcher(f.repo, []Target{{
+		Name:        "test",
+		URL:         srv.URL,
+		Secret:      "s3cret",
+		Timeout:     time.Second,
+		MaxAttempts: 3,
+	}}, Backoff{Base: 30 * time.Second, Max: time.Hour}, logger.NewNoop())
+	f.dispatcher.now = func() time.Time { return f.now }
+	f.target = f.dispatcher.Targets("user.signed_up")[0]
+	return f
+}
+
+func TestDispatch_SignsAndRecordsSuccess(t *testing.T) {
+	payload := []byte(`{"user_id":"u1"}`)
+	var gotSignature, gotTimestamp, gotEvent string
+	var gotBody []byte
+
+	f := newDispatcherFixture(t, func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(HeaderSignature)
+		gotTimestamp = r.Header.Get(HeaderTimestamp)
+		gotEvent = r.Header.Get(HeaderEvent)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	delivery, err := f.dispatcher.Dispatch(context.Background(), f.target, "user.signed_up", payload)
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+
+	if string(gotBody) != string(payload) || gotEvent != "user.signed_up" {
+		t.Fatalf("target received event %q body %s", gotEvent, gotBody)
+	}
+	if gotTimestamp != strconv.FormatInt(f.now.Unix(), 10) {
+		t.Fatalf("timestamp header = %q", gotTimestamp)
+	}
+	if want := Sign("s3cret", f.now.Unix(), payload); gotSignature != want {
+		t.Fatalf("signature = %q, want %q", gotSignature, want)
+	}
+
+	stored := f.repo.deliveries[delivery.ID]
+	if stored.Status != models.WebhookDeliveryStatusSent || stored.SentAt == nil {
+		t.Fatalf("delivery status = %s, sent_at = %v; want sent", stored.Status, stored.SentAt)
+	}
+	if stored.HTTPStatusCode == nil || *stored.HTTPStatusCode != http.StatusNoContent {
+		t.Fatalf("http status not recorded: %v", stored.HTTPStatusCode)
+	}
+	if stored.ResponseTimeMS == nil || stored.NextRetryAt != nil {
+		t.Fatalf("response time %v, next retry %v", stored.ResponseTimeMS, stored.NextRetryAt)
+	}
+}
+
+func TestDispatch_SchedulesRetryWithBackoff(t *testing.T) {
+	var calls atomic.Int32
+	f := newDispatcherFixture(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	ctx := context.Background()
+
+	delivery, err := f.dispatcher.Dispatch(ctx, f.target, "user.signed_up", []byte(`{}`))
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	stored := f.repo.deliveries[delivery.ID]
+	if stored.Status != models.WebhookDeliveryStatusRetrying {
+		t.Fatalf("status after failure = %s, want retrying", stored.Status)
+	}
+	if want := f.now.Add(30 * time.Second); stored.NextRetryAt == nil || !stored.NextRetryAt.Equal(want) {
+		t.Fatalf("next_retry_at = %v, want %v", stored.NextRetryAt, want)
+	}
+	if stored.HTTPStatusCode == nil || *stored.HTTPStatusCode != http.StatusServiceUnavailable || stored.ErrorMessage == nil {
+		t.Fatalf("failure not recorded: status %v, error %v", stored.HTTPStatusCode, stored.ErrorMessage)
+	}
+
+	// Not due yet
+	f.now = f.now.Add(29 * time.Second)
+	if attempted, _ := f.dispatcher.RetryDue(ctx, 10); attempted != 0 {
+		t.Fatalf("retried %d deliveries before they were due", attempted)
+	}
+
+	f.now = f.now.Add(time.Second)
+	if attempted, err := f.dispatcher.RetryDue(ctx, 10); err != nil || attempted != 1 {
+		t.Fatalf("RetryDue = %d, %v; want 1", attempted, err)
+	}
+	stored = f.repo.deliveries[delivery.ID]
+	if stored.Status != models.WebhookDeliveryStatusSent || stored.AttemptNumber != 2 {
+		t.Fatalf("after retry status = %s attempt = %d, want sent on attempt 2", stored.Status, stored.AttemptNumber)
+	}
+}
+
+func TestDispatch_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	f := newDispatcherFixture(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	ctx := context.Background()
+
+	delivery, err := f.dispatcher.Dispatch(ctx, f.target, "user.signed_up", []byte(`{}`))
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+
+	// Retries wait 30s, then 60s
+	for _, wait := range []time.Duration{30 * time.Second, 60 * time.Second} {
+		f.now = f.now.Add(wait)
+		if attempted, err := f.dispatcher.RetryDue(ctx, 10); err != nil || attempted != 1 {
+			t.Fatalf("RetryDue after %s = %d, %v; want 1", wait, attempted, err)
+		}
+	}
+
+	stored := f.repo.deliveries[delivery.ID]
+	if stored.Status != models.WebhookDeliveryStatusFailed || stored.NextRetryAt != nil {
+		t.Fatalf("status = %s next_retry_at = %v, want failed with no retry", stored.Status, stored.NextRetryAt)
+	}
+	if stored.AttemptNumber != 3 || calls.Load() != 3 {
+		t.Fatalf("attempts = %d, requests = %d; want 3 of each", stored.AttemptNumber, calls.Load())
+	}
+
+	f.now = f.now.Add(24 * time.Hour)
+	if attempted, _ := f.dispatcher.RetryDue(ctx, 10); attempted != 0 {
+		t.Fatalf("retried a delivery that was given up on")
+	}
+}
+
+func TestDispatch_UnrecordedAttemptIsRetried(t *testing.T) {
+	var calls atomic.Int32
+	f := newDispatcherFixture(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	})
+	ctx := context.Background()
+
+	f.repo.failUpdates = true
+	if _, err := f.dispatcher.Dispatch(ctx, f.target, "user.signed_up", []byte(`{}`)); err == nil {
+		t.Fatalf("expected the failed update to be returned")
+	}
+	f.repo.failUpdates = false
+
+	stored := f.repo.deliveries[f.repo.order[0]]
+	if want := f.now.Add(30 * time.Second); stored.Status != models.WebhookDeliveryStatusRetrying || !stored.NextRetryAt.Equal(want) {
+		t.Fatalf("unrecorded delivery status = %s next_retry_at = %v, want retrying at %v", stored.Status, stored.NextRetryAt, want)
+	}
+
+	f.now = f.now.Add(30 * time.Second)
+	if attempted, err := f.dispatcher.RetryDue(ctx, 10); err != nil || attempted != 1 {
+		t.Fatalf("RetryDue = %d, %v; want 1", attempted, err)
+	}
+	if stored := f.repo.deliveries[f.repo.order[0]]; stored.Status != models.WebhookDeliveryStatusSent || calls.Load() != 2 {
+		t.Fatalf("status = %s after %d requests, want sent after 2", stored.Status, calls.Load())
+	}
+}
+
+func TestDispatch_TimesOutSlowTargets(t *testing.T) {
+	release := make(chan struct{})
+	f := newDispatcherFixture(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer close(release)
+	f.target.Timeout = 50 * time.Millisecond
+
+	delivery, err := f.dispatcher.Dispatch(context.Background(), f.target, "user.signed_up", []byte(`{}`))
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	stored := f.repo.deliveries[delivery.ID]
+	if stored.Status != models.WebhookDeliveryStatusRetrying || stored.HTTPStatusCode != nil {
+		t.Fatalf("timed out delivery status = %s code = %v, want retrying with no code", stored.Status, stored.HTTPStatusCode)
+	}
+}
+
+func TestBackoff_DoublesUpToMax(t *testing.T) {
+	b := Backoff{Base: 30 * time.Second, Max: 5 * time.Minute}
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, w := range want {
+		if got := b.Delay(i + 1); got != w {
+			t.Fatalf("Delay(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"shared/pkg/logger"
+	"shared/pkg/messaging"
+
+	"github.com/google/uuid"
+)
+
+// Event is the envelope published on the webhook topic. Payload is sent to
+// each subscribed target as the request body.
+type Event struct {
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// HandleEvent delivers an event from the webhook topic to every target that
+// subscribes to it. Failed deliveries are retried by the sweeper rather than
+// by redelivering the message, so only failing to record a delivery is
+// returned as an error.
+//
+// Each target's delivery ID is derived from the message, so when the message
+// is redelivered after an error the targets it already reached are skipped.
+func (d *Dispatcher) HandleEvent(ctx context.Context, msg *messaging.Message) error {
+	var event Event
+	if err := json.Unmarshal(msg.Value, &event); err != nil || event.EventType == "" || len(event.Payload) == 0 {
+		d.log.Warn("Skipping malformed webhook event",
+			logger.String("topic", msg.Topic),
+			logger.Int64("offset", msg.Offset),
+		)
+		return nil
+	}
+
+	for _, target := range d.Targets(event.EventType) {
+		if _, err := d.dispatch(ctx, deliveryID(msg, target), target, event.EventType, event.Payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliveryID names the delivery of msg to target. Messages are told apart by
+// their dedup key when the producer set one, and by their position in the
+// topic otherwise.
+func deliveryID(msg *messaging.Message, target *Target) string {
+	event := msg.DedupKey()
+	if event == "" {
+		event = fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(target.URL+"#"+event)).String()
+}
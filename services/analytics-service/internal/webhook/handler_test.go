@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"shared/pkg/logger"
+	"shared/pkg/messaging"
+)
+
+func TestHandleEvent_RedeliverySkipsTargetsAlreadyReached(t *testing.T) {
+	var first, second atomic.Int32
+	firstSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		first.Add(1)
+	}))
+	t.Cleanup(firstSrv.Close)
+	secondSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		second.Add(1)
+	}))
+	t.Cleanup(secondSrv.Close)
+
+	repo := newMemWebhookRepo()
+	d := NewDispatcher(repo, []Target{
+		{Name: "first", URL: firstSrv.URL, Timeout: time.Second, MaxAttempts: 3},
+		{Name: "second", URL: secondSrv.URL, Timeout: time.Second, MaxAttempts: 3},
+	}, Backoff{Base: 30 * time.Second, Max: time.Hour}, logger.NewNoop())
+
+	msg := messaging.NewMessage([]byte(`{"event_type":"user.signed_up","payload":{"user_id":"u1"}}`))
+	msg.Topic, msg.Partition, msg.Offset = "webhooks", 0, 42
+
+	repo.failCreateFor = secondSrv.URL
+	if err := d.HandleEvent(context.Background(), msg); err == nil {
+		t.Fatalf("expected the failed insert to be returned for redelivery")
+	}
+
+	repo.failCreateFor = ""
+	if err := d.HandleEvent(context.Background(), msg); err != nil {
+		t.Fatalf("redelivery: %v", err)
+	}
+	if first.Load() != 1 || second.Load() != 1 {
+		t.Fatalf("targets received %d and %d requests, want 1 each", first.Load(), second.Load())
+	}
+	if len(repo.order) != 2 {
+		t.Fatalf("recorded %d deliveries, want 2", len(repo.order))
+	}
+}
@@ -0,0 +1,48 @@
+package dto
+
+import (
+	serviceModels "auth-service/internal/service/models"
+	"slices"
+)
+
+type DeviceResponse struct {
+	DeviceID     string            `json:"device_id"`
+	Name         string            `json:"name,omitempty"`
+	Type         string            `json:"type,omitempty"`
+	OS           string            `json:"os,omitempty"`
+	OSVersion    string            `json:"os_version,omitempty"`
+	Model        string            `json:"model,omitempty"`
+	Browser      string            `json:"browser,omitempty"`
+	UserAgent    string            `json:"user_agent,omitempty"`
+	IPAddress    string            `json:"ip_address,omitempty"`
+	Location     *ActivityLocation `json:"location,omitempty"`
+	IsMobile     bool              `json:"is_mobile"`
+	IsTrusted    bool              `json:"is_trusted"`
+	IsCurrent    bool              `json:"is_current"`
+	SessionCount int               `json:"session_count"`
+	FirstSeenAt  int64             `json:"first_seen_at"`
+	LastActiveAt int64             `json:"last_active_at"`
+}
+
+// NewDeviceResponse describes d to its owner; currentSessionID marks the
+// device the request came from
+func NewDeviceResponse(d *serviceModels.Device, currentSessionID string) DeviceResponse {
+	return DeviceResponse{
+		DeviceID:     d.DeviceID,
+		Name:         d.Name,
+		Type:         d.Type,
+		OS:           d.OS,
+		OSVersion:    d.OSVersion,
+		Model:        d.Model,
+		Browser:      d.Browser,
+		UserAgent:    d.UserAgent,
+		IPAddress:    d.IPAddress,
+		Location:     newActivityLocation(&d.City, &d.Country),
+		IsMobile:     d.IsMobile,
+		IsTrusted:    d.IsTrusted,
+		IsCurrent:    currentSessionID != "" && slices.Contains(d.SessionIDs, currentSessionID),
+		SessionCount: len(d.SessionIDs),
+		FirstSeenAt:  d.FirstSeenAt.Unix(),
+		LastActiveAt: d.LastActiveAt.Unix(),
+	}
+}
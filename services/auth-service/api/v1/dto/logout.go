@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"shared/server/request"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type LogoutRequest struct {
+	SessionID string `json:"session_id" validate:"required,uuid"`
+}
+
+func NewLogoutRequest() *LogoutRequest {
+	return &LogoutRequest{}
+}
+
+func (lr *LogoutRequest) GetValue() interface{} {
+	return lr
+}
+
+func (lr *LogoutRequest) ValidateErrors(ve validator.ValidationErrors) ([]request.ValidationErrorDetail, error) {
+	var errors []request.ValidationErrorDetail
+	for _, err := range ve {
+		switch err.Field() {
+		case "SessionID":
+			if err.Tag() == "required" {
+				errors = append(errors, request.ValidationErrorDetail{
+					Msg:  "Session ID is required",
+					Code: request.REQUIRED_FIELD,
+				})
+			} else {
+				errors = append(errors, request.ValidationErrorDetail{
+					Msg:  "Session ID must be a valid UUID",
+					Code: request.INVALID_FORMAT,
+				})
+			}
+		}
+	}
+	return errors, nil
+}
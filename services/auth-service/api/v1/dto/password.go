@@ -0,0 +1,80 @@
+package dto
+
+import (
+	"shared/server/request"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type ForgotPasswordRequest struct {
+	Email       string `json:"email,omitempty" validate:"required_without=PhoneNumber,omitempty,email"`
+	PhoneNumber string `json:"phone_number,omitempty" validate:"required_without=Email,omitempty,e164"`
+}
+
+func NewForgotPasswordRequest() *ForgotPasswordRequest {
+	return &ForgotPasswordRequest{}
+}
+
+func (r *ForgotPasswordRequest) GetValue() interface{} {
+	return r
+}
+
+func (r *ForgotPasswordRequest) ValidateErrors(ve validator.ValidationErrors) ([]request.ValidationErrorDetail, error) {
+	var msgs []request.ValidationErrorDetail
+	for _, err := range ve {
+		switch err.Field() {
+		case "Email":
+			if err.Tag() == "required_without" {
+				msgs = append(msgs, request.ValidationErrorDetail{
+					Msg:  "Email or phone number is required",
+					Code: request.REQUIRED_FIELD,
+				})
+			} else {
+				msgs = append(msgs, request.ValidationErrorDetail{
+					Msg:  "Email must be a valid email address",
+					Code: request.INVALID_FORMAT,
+				})
+			}
+		case "PhoneNumber":
+			if err.Tag() == "e164" {
+				msgs = append(msgs, request.ValidationErrorDetail{
+					Msg:  "Phone number must be in valid E.164 format",
+					Code: request.INVALID_FORMAT,
+				})
+			}
+		}
+	}
+	return msgs, nil
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+func NewResetPasswordRequest() *ResetPasswordRequest {
+	return &ResetPasswordRequest{}
+}
+
+func (r *ResetPasswordRequest) GetValue() interface{} {
+	return r
+}
+
+func (r *ResetPasswordRequest) ValidateErrors(ve validator.ValidationErrors) ([]request.ValidationErrorDetail, error) {
+	var msgs []request.ValidationErrorDetail
+	for _, err := range ve {
+		switch err.Field() {
+		case "Token":
+			msgs = append(msgs, request.ValidationErrorDetail{
+				Msg:  "Reset token is required",
+				Code: request.REQUIRED_FIELD,
+			})
+		case "NewPassword":
+			msgs = append(msgs, request.ValidationErrorDetail{
+				Msg:  "New password is required",
+				Code: request.REQUIRED_FIELD,
+			})
+		}
+	}
+	return msgs, nil
+}
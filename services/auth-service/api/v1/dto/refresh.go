@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"shared/server/request"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func NewRefreshRequest() *RefreshRequest {
+	return &RefreshRequest{}
+}
+
+func (rr *RefreshRequest) GetValue() interface{} {
+	return rr
+}
+
+func (rr *RefreshRequest) ValidateErrors(ve validator.ValidationErrors) ([]request.ValidationErrorDetail, error) {
+	var errors []request.ValidationErrorDetail
+	for _, err := range ve {
+		switch err.Field() {
+		case "RefreshToken":
+			errors = append(errors, request.ValidationErrorDetail{
+				Msg:  "Refresh token is required",
+				Code: request.REQUIRED_FIELD,
+			})
+		}
+	}
+	return errors, nil
+}
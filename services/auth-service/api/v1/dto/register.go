@@ -8,7 +8,7 @@ import (
 
 type RegisterRequest struct {
 	Email            string `json:"email" validate:"required,email"`
-	Password         string `json:"password" validate:"required,min=8,max=128"`
+	Password         string `json:"password" validate:"required"`
 	PhoneNumber      string `json:"phone_number,omitempty" validate:"omitempty,e164"`
 	PhoneCountryCode string `json:"phone_country_code,omitempty"`
 	AcceptTerms      bool   `json:"accept_terms" validate:"required"`
@@ -40,17 +40,10 @@ func (r *RegisterRequest) ValidateErrors(ve validator.ValidationErrors) ([]reque
 				})
 			}
 		case "Password":
-			if err.Tag() == "required" {
-				msgs = append(msgs, request.ValidationErrorDetail{
-					Msg:  "Password is required",
-					Code: request.REQUIRED_FIELD,
-				})
-			} else if err.Tag() == "min" || err.Tag() == "max" {
-				msgs = append(msgs, request.ValidationErrorDetail{
-					Msg:  "Password must be between 8 and 128 characters",
-					Code: request.TOO_SHORT,
-				})
-			}
+			msgs = append(msgs, request.ValidationErrorDetail{
+				Msg:  "Password is required",
+				Code: request.REQUIRED_FIELD,
+			})
 		case "PhoneNumber":
 			if err.Tag() == "e164" {
 				msgs = append(msgs, request.ValidationErrorDetail{
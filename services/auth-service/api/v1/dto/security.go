@@ -0,0 +1,87 @@
+package dto
+
+import dbModel "shared/pkg/database/postgres/models"
+
+type ActivityLocation struct {
+	City    string `json:"city,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+type LoginHistoryEntry struct {
+	ID            string            `json:"id"`
+	Status        string            `json:"status"`
+	Success       bool              `json:"success"`
+	FailureReason string            `json:"failure_reason,omitempty"`
+	LoginMethod   string            `json:"login_method,omitempty"`
+	IPAddress     string            `json:"ip_address,omitempty"`
+	UserAgent     string            `json:"user_agent,omitempty"`
+	DeviceID      string            `json:"device_id,omitempty"`
+	Location      *ActivityLocation `json:"location,omitempty"`
+	IsNewDevice   bool              `json:"is_new_device"`
+	IsNewLocation bool              `json:"is_new_location"`
+	CreatedAt     int64             `json:"created_at"`
+}
+
+type SecurityEventEntry struct {
+	ID           string                    `json:"id"`
+	EventType    dbModel.SecurityEventType `json:"event_type"`
+	Category     string                    `json:"category,omitempty"`
+	Severity     dbModel.SecuritySeverity  `json:"severity"`
+	Status       string                    `json:"status,omitempty"`
+	Description  string                    `json:"description,omitempty"`
+	IPAddress    string                    `json:"ip_address,omitempty"`
+	UserAgent    string                    `json:"user_agent,omitempty"`
+	DeviceID     string                    `json:"device_id,omitempty"`
+	Location     *ActivityLocation         `json:"location,omitempty"`
+	IsSuspicious bool                      `json:"is_suspicious"`
+	CreatedAt    int64                     `json:"created_at"`
+}
+
+func NewLoginHistoryEntry(h *dbModel.LoginHistory) LoginHistoryEntry {
+	status := deref(h.Status)
+	return LoginHistoryEntry{
+		ID:            h.ID,
+		Status:        status,
+		Success:       status == "success",
+		FailureReason: deref(h.FailureReason),
+		LoginMethod:   deref(h.LoginMethod),
+		IPAddress:     deref(h.IPAddress),
+		UserAgent:     deref(h.UserAgent),
+		DeviceID:      deref(h.DeviceID),
+		Location:      newActivityLocation(h.LocationCity, h.LocationCountry),
+		IsNewDevice:   h.IsNewDevice,
+		IsNewLocation: h.IsNewLocation,
+		CreatedAt:     h.CreatedAt.Unix(),
+	}
+}
+
+func NewSecurityEventEntry(e *dbModel.SecurityEvent) SecurityEventEntry {
+	return SecurityEventEntry{
+		ID:           e.ID,
+		EventType:    e.EventType,
+		Category:     deref(e.EventCategory),
+		Severity:     e.Severity,
+		Status:       deref(e.Status),
+		Description:  deref(e.Description),
+		IPAddress:    deref(e.IPAddress),
+		UserAgent:    deref(e.UserAgent),
+		DeviceID:     deref(e.DeviceID),
+		Location:     newActivityLocation(e.LocationCity, e.LocationCountry),
+		IsSuspicious: e.IsSuspicious,
+		CreatedAt:    e.CreatedAt.Unix(),
+	}
+}
+
+func newActivityLocation(city, country *string) *ActivityLocation {
+	if deref(city) == "" && deref(country) == "" {
+		return nil
+	}
+	return &ActivityLocation{City: deref(city), Country: deref(country)}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"shared/pkg/database"
+	"shared/server/response"
+)
+
+// writeDBError answers a database failure wrapped anywhere in err with
+// response.FromDBError. It reports false when err carries no database error
+// so the caller can answer it instead.
+func writeDBError(w http.ResponseWriter, r *http.Request, err error) bool {
+	var dbErr *database.DBError
+	if !errors.As(err, &dbErr) {
+		return false
+	}
+	response.FromDBError(r.Context(), r, w, dbErr)
+	return true
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"auth-service/api/v1/dto"
+	authErrors "auth-service/internal/errors"
+	serviceModels "auth-service/internal/service/models"
+	"net/http"
+	"shared/pkg/logger"
+	"shared/server/middleware"
+	"shared/server/request"
+	"shared/server/response"
+)
+
+// ListDevices lists the devices the caller has active sessions on
+func (h *AuthHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	handler := request.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+	userID := middleware.GetUserID(r.Context())
+
+	h.log.Info("List devices request received",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("request_id", requestID),
+		logger.String("user_id", userID),
+	)
+
+	devices, err := h.sessionService.ListDevices(r.Context(), userID)
+	if err != nil {
+		h.log.Error("Failed to list devices",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("request_id", requestID),
+			logger.Error(err),
+		)
+		if writeDBError(w, r, err) {
+			return
+		}
+		response.InternalServerError(r.Context(), r, w, "Failed to list devices", err)
+		return
+	}
+
+	currentSessionID := middleware.GetSessionID(r.Context())
+	result := make([]dto.DeviceResponse, 0, len(devices))
+	for _, device := range devices {
+		result = append(result, dto.NewDeviceResponse(device, currentSessionID))
+	}
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Devices retrieved successfully", result)
+}
+
+// RevokeDevice signs the caller out of every session on one of their devices
+func (h *AuthHandler) RevokeDevice(w http.ResponseWriter, r *http.Request) {
+	handler := request.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+	userID := middleware.GetUserID(r.Context())
+	deviceID := handler.PathParam("device_id")
+
+	h.log.Info("Revoke device request received",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("request_id", requestID),
+		logger.String("user_id", userID),
+		logger.String("device_id", deviceID),
+	)
+
+	if deviceID == "" {
+		response.BadRequestError(r.Context(), r, w, "Device ID is required", nil)
+		return
+	}
+
+	revoked, err := h.sessionService.RevokeDevice(r.Context(), serviceModels.RevokeDeviceInput{
+		UserID:    userID,
+		DeviceID:  deviceID,
+		IPAddress: handler.GetClientIP(),
+		UserAgent: handler.GetUserAgent(),
+	})
+	if err != nil {
+		if err.Code() == authErrors.CodeDeviceNotFound {
+			response.NotFoundError(r.Context(), r, w, "device")
+			return
+		}
+		h.log.Error("Failed to revoke device",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("request_id", requestID),
+			logger.String("device_id", deviceID),
+			logger.Error(err),
+		)
+		if writeDBError(w, r, err) {
+			return
+		}
+		response.InternalServerError(r.Context(), r, w, "Failed to revoke device", err)
+		return
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Device signed out", map[string]any{
+		"device_id":        deviceID,
+		"revoked_sessions": revoked,
+	})
+}
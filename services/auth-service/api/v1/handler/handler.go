@@ -1,22 +1,40 @@
 package handler
 
 import (
+	"auth-service/internal/password"
 	"auth-service/internal/service"
 	"shared/pkg/logger"
+	"shared/server/response"
 )
 
 type AuthHandler struct {
 	service         *service.AuthService
 	sessionService  *service.SessionService
 	locationService *service.LocationService
+	securityService *service.SecurityService
+	passwordPolicy  *password.Policy
 	log             logger.Logger
 }
 
-func NewAuthHandler(service *service.AuthService, sessionService *service.SessionService, locationService *service.LocationService, log logger.Logger) *AuthHandler {
+func NewAuthHandler(service *service.AuthService, sessionService *service.SessionService, locationService *service.LocationService, securityService *service.SecurityService, passwordPolicy *password.Policy, log logger.Logger) *AuthHandler {
 	return &AuthHandler{
 		service:         service,
 		sessionService:  sessionService,
 		locationService: locationService,
+		securityService: securityService,
+		passwordPolicy:  passwordPolicy,
 		log:             log,
 	}
 }
+
+func passwordFieldErrors(violations []password.Violation) []response.FieldError {
+	fieldErrors := make([]response.FieldError, 0, len(violations))
+	for _, v := range violations {
+		fieldErrors = append(fieldErrors, response.FieldError{
+			Field:   "password",
+			Message: v.Message,
+			Code:    string(v.Rule),
+		})
+	}
+	return fieldErrors
+}
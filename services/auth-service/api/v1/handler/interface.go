@@ -13,6 +13,21 @@ type AuthHandlerInterface interface {
 	// Authentication endpoints
 	Register(w http.ResponseWriter, r *http.Request)
 	Login(w http.ResponseWriter, r *http.Request)
+	Refresh(w http.ResponseWriter, r *http.Request)
+	Logout(w http.ResponseWriter, r *http.Request)
+	LogoutAll(w http.ResponseWriter, r *http.Request)
+
+	// Password reset endpoints
+	ForgotPassword(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+
+	// Device endpoints
+	ListDevices(w http.ResponseWriter, r *http.Request)
+	RevokeDevice(w http.ResponseWriter, r *http.Request)
+
+	// Security activity endpoints
+	LoginHistory(w http.ResponseWriter, r *http.Request)
+	SecurityEvents(w http.ResponseWriter, r *http.Request)
 }
 
 // Compile-time interface compliance check
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"shared/server/common/token"
+)
+
+// JWKS serves the public signing keys in the bare RFC 7517 format, without
+// the usual response envelope, so standard JWT libraries can consume it.
+func JWKS(keys *token.RSAKeySet) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(keys.JWKS())
+	}
+}
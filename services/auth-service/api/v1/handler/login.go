@@ -74,7 +74,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		logger.String("browser", browserInfo.Name),
 	)
 
-	locationInfo, err := h.locationService.Lookup(clientIP)
+	locationInfo, err := h.locationService.Lookup(r.Context(), clientIP)
 	if err != nil {
 		h.log.Error("Failed to lookup location",
 			logger.String("service", authErrors.ServiceName),
@@ -87,6 +87,9 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	user, authErr := h.service.GetUserByEmail(r.Context(), loginRequest.Email)
 	if authErr != nil {
 		h.log.Error("Failed to fetch user during login", logger.Error(authErr))
+		if writeDBError(w, r, authErr) {
+			return
+		}
 		response.InternalServerError(r.Context(), r, w, "Failed to process login", authErr)
 		return
 	}
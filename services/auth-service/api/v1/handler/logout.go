@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"auth-service/api/v1/dto"
+	authErrors "auth-service/internal/errors"
+	"net/http"
+	"shared/pkg/logger"
+	"shared/server/middleware"
+	"shared/server/request"
+	"shared/server/response"
+
+	"github.com/google/uuid"
+)
+
+// Logout revokes the caller's current session. Tokens that carry no session_id
+// must name the session in the request body.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	handler := request.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+	userID := middleware.GetUserID(r.Context())
+
+	h.log.Info("Logout request received",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("request_id", requestID),
+		logger.String("user_id", userID),
+	)
+
+	sessionID := middleware.GetSessionID(r.Context())
+	if sessionID == "" {
+		req := dto.NewLogoutRequest()
+		if !handler.ParseValidateAndSend(req) {
+			return
+		}
+		sessionID = req.SessionID
+	}
+
+	session, err := h.sessionService.GetSessionByID(r.Context(), sessionID)
+	if err != nil {
+		h.log.Error("Failed to fetch session during logout", logger.Error(err))
+		if writeDBError(w, r, err) {
+			return
+		}
+		response.InternalServerError(r.Context(), r, w, "Failed to process logout", err)
+		return
+	}
+	if session == nil || session.UserID != userID {
+		response.NotFoundError(r.Context(), r, w, "session")
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(r.Context(), sessionID); err != nil {
+		h.log.Error("Failed to revoke session",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("request_id", requestID),
+			logger.String("session_id", sessionID),
+			logger.Error(err),
+		)
+		if writeDBError(w, r, err) {
+			return
+		}
+		response.InternalServerError(r.Context(), r, w, "Failed to process logout", err)
+		return
+	}
+	h.sessionService.RevokeAccessToken(r.Context(), middleware.GetTokenClaims(r.Context()))
+
+	h.log.Info("Logout successful",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("request_id", requestID),
+		logger.String("session_id", sessionID),
+	)
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Logged out successfully", map[string]any{
+		"session_id": sessionID,
+	})
+}
+
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	handler := request.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Logout-all request received",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("request_id", requestID),
+	)
+
+	userID, parseErr := uuid.Parse(middleware.GetUserID(r.Context()))
+	if parseErr != nil {
+		response.UnauthorizedError(r.Context(), r, w, "Invalid token", parseErr)
+		return
+	}
+
+	if err := h.sessionService.RevokeAllUserSessions(r.Context(), userID); err != nil {
+		h.log.Error("Failed to revoke user sessions",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("request_id", requestID),
+			logger.String("user_id", userID.String()),
+			logger.Error(err),
+		)
+		if writeDBError(w, r, err) {
+			return
+		}
+		response.InternalServerError(r.Context(), r, w, "Failed to process logout", err)
+		return
+	}
+	h.sessionService.RevokeAccessToken(r.Context(), middleware.GetTokenClaims(r.Context()))
+
+	h.log.Info("Logged out of all sessions",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("request_id", requestID),
+		logger.String("user_id", userID.String()),
+	)
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Logged out of all sessions", nil)
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"auth-service/api/v1/dto"
+	authErrors "auth-service/internal/errors"
+	serviceModels "auth-service/internal/service/models"
+	"context"
+	"net/http"
+	dbModels "shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+	"shared/server/request"
+	"shared/server/response"
+
+	"github.com/google/uuid"
+)
+
+const forgotPasswordMessage = "If an account exists for the provided details, password reset instructions have been sent"
+
+func (h *AuthHandler) recordPasswordResetEvent(ctx context.Context, handler *request.RequestHandler, userID, status, description string) {
+	category := "password"
+	ip := handler.GetClientIP()
+	userAgent := handler.GetUserAgent()
+	err := h.sessionService.RecordSecurityEvent(ctx, &dbModels.SecurityEvent{
+		UserID:        &userID,
+		EventType:     dbModels.SecurityEventPasswordReset,
+		EventCategory: &category,
+		Severity:      dbModels.SecuritySeverityMedium,
+		Status:        &status,
+		Description:   &description,
+		IPAddress:     &ip,
+		UserAgent:     &userAgent,
+	})
+	if err != nil {
+		h.log.Error("Failed to record password reset security event",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+	}
+}
+
+// ForgotPassword always answers 200 so the response never reveals whether an
+// account exists.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	handler := request.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Forgot password request received",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("request_id", requestID),
+		logger.String("client_ip", handler.GetClientIP()),
+	)
+
+	req := dto.NewForgotPasswordRequest()
+	if !handler.ParseValidateAndSend(req) {
+		return
+	}
+
+	output, err := h.service.RequestPasswordReset(r.Context(), serviceModels.PasswordResetRequestInput{
+		Email:       req.Email,
+		PhoneNumber: req.PhoneNumber,
+	})
+	if err != nil {
+		h.log.Error("Failed to process password reset request",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("request_id", requestID),
+			logger.Error(err),
+		)
+	} else if output != nil {
+		h.recordPasswordResetEvent(r.Context(), handler, output.UserID, "requested", "Password reset requested")
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, forgotPasswordMessage, nil)
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	handler := request.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Reset password request received",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("request_id", requestID),
+		logger.String("client_ip", handler.GetClientIP()),
+	)
+
+	req := dto.NewResetPasswordRequest()
+	if !handler.ParseValidateAndSend(req) {
+		return
+	}
+
+	if violations := h.passwordPolicy.Validate(req.NewPassword); len(violations) > 0 {
+		response.BadRequestFieldsError(r.Context(), r, w, "Password does not meet the requirements", passwordFieldErrors(violations))
+		return
+	}
+
+	userID, err := h.service.ResetPassword(r.Context(), req.Token, req.NewPassword)
+	if err != nil {
+		if err.Code() == authErrors.CodeInvalidResetToken {
+			response.BadRequestError(r.Context(), r, w, err.Message(), err)
+			return
+		}
+		h.log.Error("Failed to reset password",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("request_id", requestID),
+			logger.Error(err),
+		)
+		response.InternalServerError(r.Context(), r, w, "Failed to reset password", err)
+		return
+	}
+
+	if parsed, parseErr := uuid.Parse(userID); parseErr == nil {
+		if revokeErr := h.sessionService.RevokeAllUserSessions(r.Context(), parsed); revokeErr != nil {
+			h.log.Error("Failed to revoke sessions after password reset",
+				logger.String("service", authErrors.ServiceName),
+				logger.String("request_id", requestID),
+				logger.String("user_id", userID),
+				logger.Error(revokeErr),
+			)
+		}
+	}
+	h.recordPasswordResetEvent(r.Context(), handler, userID, "completed", "Password reset completed")
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Password has been reset. Please log in again.", nil)
+}
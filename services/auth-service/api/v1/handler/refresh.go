@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"auth-service/api/v1/dto"
+	authErrors "auth-service/internal/errors"
+	"net/http"
+	"shared/pkg/logger"
+	"shared/server/request"
+	"shared/server/response"
+)
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	handler := request.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Token refresh request received",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("request_id", requestID),
+		logger.String("client_ip", handler.GetClientIP()),
+	)
+
+	req := dto.NewRefreshRequest()
+	if !handler.ParseValidateAndSend(req) {
+		h.log.Warn("Token refresh request validation failed",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("request_id", requestID),
+		)
+		return
+	}
+
+	pair, err := h.sessionService.RefreshSession(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch err.Code() {
+		case authErrors.CodeInvalidRefreshToken, authErrors.CodeRefreshTokenExpired, authErrors.CodeSuspiciousActivity:
+			h.log.Warn("Token refresh rejected",
+				logger.String("service", authErrors.ServiceName),
+				logger.String("request_id", requestID),
+				logger.String("code", err.Code()),
+			)
+			response.UnauthorizedError(r.Context(), r, w, err.Message(), err)
+		default:
+			h.log.Error("Failed to refresh session",
+				logger.String("service", authErrors.ServiceName),
+				logger.String("request_id", requestID),
+				logger.Error(err),
+			)
+			response.InternalServerError(r.Context(), r, w, "Failed to refresh session", err)
+		}
+		return
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Token refreshed successfully",
+		map[string]any{
+			"access_token":  pair.AccessToken.Token,
+			"expires_at":    pair.AccessToken.Claims.ExpiresAt.Unix(),
+			"refresh_token": pair.RefreshToken.Token,
+			"token_type":    "Bearer",
+		},
+	)
+}
@@ -31,6 +31,16 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if violations := h.passwordPolicy.Validate(req.Password); len(violations) > 0 {
+		h.log.Warn("Registration rejected by password policy",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("request_id", requestID),
+			logger.Int("violations", len(violations)),
+		)
+		response.BadRequestFieldsError(r.Context(), r, w, "Password does not meet the requirements", passwordFieldErrors(violations))
+		return
+	}
+
 	clientIp := handler.GetClientIP()
 
 	h.log.Debug("Checking if email is already registered",
@@ -47,6 +57,9 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 			logger.String("email", req.Email),
 			logger.Error(dbErr),
 		)
+		if writeDBError(w, r, dbErr) {
+			return
+		}
 		response.InternalServerError(r.Context(), r, w, "Failed to process registration", dbErr)
 		return
 	}
@@ -72,9 +85,12 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	if authErr != nil {
 		if authErr.Code() == authErrors.CodePasswordTooWeak || authErr.Code() == authErrors.CodeInvalidEmail {
 			response.BadRequestError(r.Context(), r, w, authErr.Message(), nil)
-		} else {
-			response.InternalServerError(r.Context(), r, w, authErr.Message(), authErr)
+			return
+		}
+		if writeDBError(w, r, authErr) {
+			return
 		}
+		response.InternalServerError(r.Context(), r, w, authErr.Message(), authErr)
 		return
 	}
 
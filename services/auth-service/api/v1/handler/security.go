@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"auth-service/api/v1/dto"
+	authErrors "auth-service/internal/errors"
+	"net/http"
+	"shared/pkg/database"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+	"shared/server/middleware"
+	"shared/server/request"
+	"shared/server/response"
+)
+
+// LoginHistory lists the caller's login attempts newest first.
+// Query parameters: cursor (from the previous page) and limit.
+func (h *AuthHandler) LoginHistory(w http.ResponseWriter, r *http.Request) {
+	handler := request.NewHandler(r, w)
+	userID := middleware.GetUserID(r.Context())
+
+	h.log.Info("Login history request received",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("request_id", handler.GetRequestID()),
+		logger.String("user_id", userID),
+	)
+
+	cursor, limit, ok := activityPageParams(handler, w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.securityService.ListLoginHistory(r.Context(), userID, cursor, limit)
+	if err != nil {
+		h.writeActivityError(w, r, err, "Failed to fetch login history")
+		return
+	}
+
+	entries := make([]dto.LoginHistoryEntry, 0, len(page.Items))
+	for _, item := range page.Items {
+		entries = append(entries, dto.NewLoginHistoryEntry(item))
+	}
+	response.Paginated(r.Context(), r, w, entries,
+		response.NewCursorPagination(page.Limit, len(entries), page.NextCursor != nil, cursor != "", page.NextCursor, nil))
+}
+
+// SecurityEvents lists the caller's security events newest first.
+// Query parameters: cursor (from the previous page) and limit.
+func (h *AuthHandler) SecurityEvents(w http.ResponseWriter, r *http.Request) {
+	handler := request.NewHandler(r, w)
+	userID := middleware.GetUserID(r.Context())
+
+	h.log.Info("Security events request received",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("request_id", handler.GetRequestID()),
+		logger.String("user_id", userID),
+	)
+
+	cursor, limit, ok := activityPageParams(handler, w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.securityService.ListSecurityEvents(r.Context(), userID, cursor, limit)
+	if err != nil {
+		h.writeActivityError(w, r, err, "Failed to fetch security events")
+		return
+	}
+
+	entries := make([]dto.SecurityEventEntry, 0, len(page.Items))
+	for _, item := range page.Items {
+		entries = append(entries, dto.NewSecurityEventEntry(item))
+	}
+	response.Paginated(r.Context(), r, w, entries,
+		response.NewCursorPagination(page.Limit, len(entries), page.NextCursor != nil, cursor != "", page.NextCursor, nil))
+}
+
+func activityPageParams(handler *request.RequestHandler, w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	limit, err := handler.QueryParamInt("limit", database.DefaultPageLimit)
+	if err != nil || limit < 1 {
+		response.BadRequestError(r.Context(), r, w, "Limit must be a positive integer", err)
+		return "", 0, false
+	}
+	return handler.QueryParam("cursor"), limit, true
+}
+
+func (h *AuthHandler) writeActivityError(w http.ResponseWriter, r *http.Request, err pkgErrors.AppError, message string) {
+	if err.Code() == pkgErrors.CodeInvalidArgument {
+		response.BadRequestError(r.Context(), r, w, "Invalid cursor", err)
+		return
+	}
+
+	h.log.Error(message,
+		logger.String("service", authErrors.ServiceName),
+		logger.Error(err),
+	)
+	if writeDBError(w, r, err) {
+		return
+	}
+	response.InternalServerError(r.Context(), r, w, message, err)
+}
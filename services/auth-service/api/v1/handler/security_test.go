@@ -0,0 +1,86 @@
+package handler
+
+import (
+	repoModels "auth-service/internal/repo/models"
+	"auth-service/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"shared/pkg/database/postgres/models"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+	sContext "shared/server/context"
+)
+
+type ownedEventsRepo struct {
+	events []*models.SecurityEvent
+}
+
+func (o *ownedEventsRepo) ListLoginHistory(ctx context.Context, userID string, after *repoModels.ActivityCursor, limit int) ([]*models.LoginHistory, pkgErrors.AppError) {
+	return nil, nil
+}
+
+func (o *ownedEventsRepo) ListSecurityEvents(ctx context.Context, userID string, after *repoModels.ActivityCursor, limit int) ([]*models.SecurityEvent, pkgErrors.AppError) {
+	var rows []*models.SecurityEvent
+	for _, e := range o.events {
+		if e.UserID != nil && *e.UserID == userID {
+			rows = append(rows, e)
+		}
+	}
+	return rows, nil
+}
+
+func TestSecurityEvents_ReturnsOnlyCallersEvents(t *testing.T) {
+	alice, bob := "user-a", "user-b"
+	city := "Lisbon"
+	repo := &ownedEventsRepo{events: []*models.SecurityEvent{
+		{ID: "e1", UserID: &alice, EventType: models.SecurityEventType("login_failed"), LocationCity: &city, CreatedAt: time.Now()},
+		{ID: "e2", UserID: &bob, EventType: models.SecurityEventType("login_failed"), CreatedAt: time.Now()},
+	}}
+	h := &AuthHandler{securityService: service.NewSecurityService(repo, logger.NewNoop()), log: logger.NewNoop()}
+
+	// A spoofed header must not widen the result; only the token's user counts
+	req := httptest.NewRequest(http.MethodGet, "/security/events", nil)
+	req.Header.Set("X-User-ID", bob)
+	req = req.WithContext(context.WithValue(req.Context(), sContext.UserIDKey, alice))
+	w := httptest.NewRecorder()
+	h.SecurityEvents(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data []struct {
+			ID       string `json:"id"`
+			Location *struct {
+				City string `json:"city"`
+			} `json:"location"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != "e1" {
+		t.Fatalf("data = %+v, want only alice's event", body.Data)
+	}
+	if body.Data[0].Location == nil || body.Data[0].Location.City != "Lisbon" {
+		t.Fatalf("location = %+v, want Lisbon", body.Data[0].Location)
+	}
+}
+
+func TestLoginHistory_RejectsInvalidCursor(t *testing.T) {
+	h := &AuthHandler{securityService: service.NewSecurityService(&ownedEventsRepo{}, logger.NewNoop()), log: logger.NewNoop()}
+
+	req := httptest.NewRequest(http.MethodGet, "/security/login-history?cursor=bm9wZQ", nil)
+	req = req.WithContext(context.WithValue(req.Context(), sContext.UserIDKey, "user-a"))
+	w := httptest.NewRecorder()
+	h.LoginHistory(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,537 @@
+package main
+
+import (
+	"auth-service/api/v1/handler"
+	"auth-service/internal/config"
+	"auth-service/internal/health"
+	"auth-service/internal/health/checkers"
+	"auth-service/internal/password"
+	repository "auth-service/internal/repo"
+	"auth-service/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"shared/pkg/cache"
+	"shared/pkg/cache/redis"
+	"shared/pkg/database"
+	"shared/pkg/database/postgres"
+	"shared/pkg/logger"
+	adapter "shared/pkg/logger/adapter"
+	"shared/pkg/monitoring/tracing"
+	"shared/server/common/hashing"
+	"shared/server/common/token"
+
+	env "shared/server/env"
+	coreMiddleware "shared/server/middleware"
+	"shared/server/response"
+	"shared/server/router"
+	"shared/server/server"
+	"shared/server/shutdown"
+)
+
+func createLogger(name string) logger.Logger {
+	log, err := adapter.NewZap(logger.Config{
+		Level:      logger.GetLoggerLevel(),
+		Format:     logger.GetLoggerFormat(),
+		Output:     logger.GetLoggerOutput(),
+		File:       logger.GetLoggerFile(),
+		TimeFormat: logger.GetLoggerTimeFormat(),
+		Service:    name,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create logger: %v", err))
+	}
+	return log
+}
+
+func loadConfig() (*config.Config, error) {
+	log := createLogger("config-loader")
+	defer log.Sync()
+
+	configPath := env.GetEnv("CONFIG_PATH")
+	env := env.GetEnv("APP_ENV")
+
+	var cfg *config.Config
+	var err error
+	log.Debug("Loading config from file",
+		logger.String("configPath", configPath),
+		logger.String("environment", env),
+	)
+	cfg, err = config.Load(configPath, env)
+	if err != nil {
+		log.Error("Failed to load config", logger.Error(err))
+		return nil, err
+	}
+	log.Debug("Config loaded successfully")
+	return cfg, nil
+}
+
+func createDBClient(dbConfig config.DatabaseConfig, log logger.Logger) (database.Database, error) {
+	log.Debug("Creating Postgres client - configuration",
+		logger.String("host", dbConfig.Postgres.Host),
+		logger.Int("port", dbConfig.Postgres.Port),
+		logger.String("user", dbConfig.Postgres.User),
+		logger.String("database", dbConfig.Postgres.DBName),
+	)
+	dbClient, err := postgres.New(database.Config{
+		Host:            dbConfig.Postgres.Host,
+		Port:            dbConfig.Postgres.Port,
+		User:            dbConfig.Postgres.User,
+		Password:        dbConfig.Postgres.Password,
+		Database:        dbConfig.Postgres.DBName,
+		SSLMode:         dbConfig.Postgres.SSLMode,
+		MaxOpenConns:    dbConfig.Postgres.MaxOpenConns,
+		MaxIdleConns:    dbConfig.Postgres.MaxIdleConns,
+		ConnMaxLifetime: dbConfig.Postgres.ConnMaxLifetime,
+		ConnMaxIdleTime: dbConfig.Postgres.ConnMaxIdleTime,
+	})
+	if err != nil {
+		log.Error("Failed to create Postgres client", logger.Error(err))
+		return nil, err
+	}
+	log.Info("Postgres client created successfully")
+	return dbClient, nil
+}
+
+func createCacheClient(cacheConfig config.CacheConfig, log logger.Logger) (cache.Cache, error) {
+	log.Debug("Creating Redis cache client - configuration",
+		logger.String("host", cacheConfig.RedisConfig.RedisHost),
+		logger.Int("port", cacheConfig.RedisConfig.RedisPort),
+		logger.Int("db", cacheConfig.RedisConfig.RedisDB),
+	)
+	cacheClient, err := redis.New(cache.Config{
+		Host:         cacheConfig.RedisConfig.RedisHost,
+		Port:         cacheConfig.RedisConfig.RedisPort,
+		Password:     cacheConfig.RedisConfig.RedisPassword,
+		DB:           cacheConfig.RedisConfig.RedisDB,
+		DialTimeout:  cacheConfig.RedisConfig.RedisDialTimeout,
+		PoolSize:     cacheConfig.RedisConfig.RedisPoolSize,
+		MinIdleConns: cacheConfig.RedisConfig.RedisMinIdleConns,
+	})
+	if err != nil {
+		log.Error("Failed to create Redis client", logger.Error(err))
+		return nil, err
+	}
+	log.Info("Redis client created successfully")
+	return cacheClient, nil
+}
+
+func createTracer(cfg *config.Config, log logger.Logger) *tracing.Tracer {
+	tracingCfg := cfg.Observability.Tracing
+	tracer, err := tracing.New(tracing.Config{
+		ServiceName:    cfg.Service.Name,
+		ServiceVersion: cfg.Service.Version,
+		Environment:    cfg.Service.Environment,
+		JaegerEndpoint: tracingCfg.Endpoint,
+		SamplingRate:   tracingCfg.SampleRate,
+		Enabled:        tracingCfg.Enabled,
+	})
+	if err != nil {
+		log.Fatal("Failed to create tracer", logger.Error(err))
+	}
+
+	if tracingCfg.Enabled {
+		log.Info("Tracing enabled",
+			logger.String("endpoint", tracingCfg.Endpoint),
+			logger.Float64("sample_rate", tracingCfg.SampleRate),
+		)
+	}
+	return tracer
+}
+
+func setupHealthChecks(dbClient database.Database, cacheClient cache.Cache, cfg *config.Config) *health.Manager {
+	healthMgr := health.NewManager(cfg.Service.Name, cfg.Service.Version)
+	healthMgr.SetCacheTTL(cfg.Observability.Health.CacheTTL)
+
+	// Register database health checker
+	if dbClient != nil {
+		healthMgr.RegisterChecker(checkers.NewDatabaseChecker(dbClient))
+	}
+
+	// Register cache health checker
+	if cacheClient != nil && cfg.Cache.Enabled {
+		healthMgr.RegisterChecker(checkers.NewCacheChecker(cacheClient))
+		healthMgr.RegisterChecker(checkers.NewCachePerformanceChecker(cacheClient))
+	}
+
+	return healthMgr
+}
+
+func setupRoutes(builder *router.Builder, h *handler.AuthHandler, authMiddleware coreMiddleware.Handler, rsaKeys *token.RSAKeySet, log logger.Logger) *router.Builder {
+	log.Debug("Registering auth routes")
+	builder = builder.WithRoutes(func(r *router.Router) {
+		if rsaKeys != nil {
+			r.Get("/.well-known/jwks.json", handler.JWKS(rsaKeys))
+		}
+		r.Post("/register", h.Register)
+		r.Post("/login", h.Login)
+		r.Post("/refresh", h.Refresh)
+		r.Post("/logout", authMiddleware(http.HandlerFunc(h.Logout)).ServeHTTP)
+		r.Post("/logout/all", authMiddleware(http.HandlerFunc(h.LogoutAll)).ServeHTTP)
+		r.Post("/password/forgot", h.ForgotPassword)
+		r.Post("/password/reset", h.ResetPassword)
+		r.Get("/devices", authMiddleware(http.HandlerFunc(h.ListDevices)).ServeHTTP)
+		r.Delete("/devices/{device_id}", authMiddleware(http.HandlerFunc(h.RevokeDevice)).ServeHTTP)
+		r.Get("/security/login-history", authMiddleware(http.HandlerFunc(h.LoginHistory)).ServeHTTP)
+		r.Get("/security/events", authMiddleware(http.HandlerFunc(h.SecurityEvents)).ServeHTTP)
+	})
+	log.Debug("Auth routes registered successfully")
+	return builder
+}
+
+func createRouter(h *handler.AuthHandler, healthHandler *health.Handler, authMiddleware coreMiddleware.Handler, rsaKeys *token.RSAKeySet, inFlight *shutdown.InFlight, cfg *config.Config, log logger.Logger) (*router.Router, error) {
+	builder := router.NewBuilder()
+	if cfg.Observability.Tracing.Enabled {
+		builder = builder.WithEarlyMiddleware(router.Middleware(coreMiddleware.Tracing(cfg.Service.Name)))
+	}
+
+	builder = builder.
+		WithHealthEndpoint("/health", healthHandler.Health).
+		WithLogLevelEndpoint("/admin/loglevel", log, env.LogLevelEndpointEnabled()).
+		WithNotFoundHandler(func(w http.ResponseWriter, r *http.Request) {
+			response.RouteNotFoundError(r.Context(), r, w, log)
+		}).
+		WithMethodNotAllowedHandler(func(w http.ResponseWriter, r *http.Request) {
+			response.MethodNotAllowedError(r.Context(), r, w)
+		}).
+		WithEarlyMiddleware(
+			router.Middleware(inFlight.Middleware),
+			router.Middleware(coreMiddleware.RequestReceivedLogger(log)),
+		).
+		WithLateMiddleware(
+			router.Middleware(coreMiddleware.Recovery(log)),
+			router.Middleware(coreMiddleware.RequestCompletedLogger(log)),
+			router.Middleware(coreMiddleware.BodyLogger(coreMiddleware.BodyLoggerConfig{Enabled: env.BodyLoggingEnabled(), Logger: log})),
+		)
+
+	builder = builder.WithRoutes(func(r *router.Router) {
+		r.Get("/live", healthHandler.Liveness)
+		r.Get("/ready", healthHandler.Readiness)
+		r.Get("/health/liveness", healthHandler.Liveness)
+		r.Get("/health/readiness", healthHandler.Readiness)
+	})
+
+	builder = setupRoutes(builder, h, authMiddleware, rsaKeys, log)
+	r := builder.Build()
+	return r, nil
+}
+
+func setupShutdownManager(srv *server.Server, inFlight *shutdown.InFlight, tracer *tracing.Tracer, log logger.Logger, cfg *config.Config) *shutdown.Manager {
+	shutdownMgr := shutdown.New(
+		shutdown.WithTimeout(cfg.Server.ShutdownTimeout),
+		shutdown.WithLogger(log),
+	)
+
+	// Draining runs inside the server hook, after the listeners close, so it
+	// sees the requests the server is still handling
+	serverHook := shutdown.ServerShutdownHook(srv)
+	if cfg.Shutdown.WaitForConnections && cfg.Shutdown.DrainTimeout > 0 {
+		serverHook = shutdown.ServerDrainHook(srv, inFlight, cfg.Shutdown.DrainTimeout, log)
+	}
+	shutdownMgr.RegisterWithPriority(
+		"http-server",
+		serverHook,
+		shutdown.PriorityHigh,
+	)
+
+	shutdownMgr.RegisterWithPriority(
+		"tracer-shutdown",
+		shutdown.Hook(tracer.Shutdown),
+		shutdown.PriorityNormal,
+	)
+
+	shutdownMgr.RegisterWithPriority(
+		"logger-sync",
+		shutdown.Hook(func(ctx context.Context) error {
+			log.Info("Syncing logger before shutdown")
+			return log.Sync()
+		}),
+		shutdown.PriorityLow,
+	)
+
+	return shutdownMgr
+}
+
+func waitForShutdown(shutdownMgr *shutdown.Manager) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := shutdownMgr.Wait(); err != nil {
+		}
+	}()
+	return done
+}
+
+func createTokenManager(cfg config.Config, log logger.Logger) (*token.JWTTokenService, *token.RSAKeySet) {
+	log.Debug("Creating Token service", logger.String("algorithm", cfg.Auth.JWT.Algorithm))
+	var keySet token.KeySet
+	var rsaKeys *token.RSAKeySet
+	if cfg.Auth.JWT.Algorithm == token.AlgorithmRS256 {
+		rsaKeys = createRSAKeySet(cfg, log)
+		keySet = rsaKeys
+	} else {
+		key, err := token.NewStaticKeySet([]byte(cfg.Auth.JWT.SecretKey))
+		if err != nil {
+			log.Fatal("Failed to create Token KeySet", logger.Error(err))
+		}
+		keySet = key
+	}
+	tokenService, err := token.NewJWTTokenService(token.Config{
+		KeySet:          keySet,
+		Issuer:          cfg.Auth.JWT.Issuer,
+		Audience:        []string{cfg.Auth.JWT.Audience},
+		AccessTokenTTL:  cfg.Auth.JWT.AccessTokenTTL,
+		RefreshTokenTTL: cfg.Auth.JWT.RefreshTokenTTL,
+		Leeway:          cfg.Auth.JWT.Leeway,
+	})
+	if err != nil {
+		log.Fatal("Failed to create Token service", logger.Error(err))
+	}
+	log.Info("Token Service created successfully")
+	return tokenService, rsaKeys
+}
+
+func createRSAKeySet(cfg config.Config, log logger.Logger) *token.RSAKeySet {
+	current, err := token.LoadRSAPrivateKey(cfg.Auth.JWT.PrivateKeyPath)
+	if err != nil {
+		log.Fatal("Failed to load JWT signing key", logger.Error(err))
+	}
+	// Keys rotated out keep validating until every token they signed has expired.
+	expiresAt := time.Now().Add(cfg.Auth.JWT.PreviousKeyTTL)
+	previous := make([]token.Key, 0, len(cfg.Auth.JWT.PreviousPublicKeyPaths))
+	for _, path := range cfg.Auth.JWT.PreviousPublicKeyPaths {
+		key, err := token.LoadRSAPublicKey(path, expiresAt)
+		if err != nil {
+			log.Fatal("Failed to load previous JWT key", logger.String("path", path), logger.Error(err))
+		}
+		previous = append(previous, key)
+	}
+	keySet, err := token.NewRSAKeySet(current, previous...)
+	if err != nil {
+		log.Fatal("Failed to create RSA KeySet", logger.Error(err))
+	}
+	log.Info("RSA signing keys loaded",
+		logger.String("kid", current.ID),
+		logger.Int("previous_keys", len(previous)),
+	)
+	return keySet
+}
+
+// watchSigningKey re-reads the RS256 private key every interval and rotates
+// to it when the file holds a new key. The replaced key keeps verifying for
+// PreviousKeyTTL. Closing the returned channel stops the watcher.
+func watchSigningKey(keySet *token.RSAKeySet, cfg config.Config, log logger.Logger) chan<- struct{} {
+	stop := make(chan struct{})
+	interval := cfg.Auth.JWT.KeyReloadInterval
+	if keySet == nil || interval <= 0 {
+		return stop
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				rotated, err := keySet.ReloadPrivateKey(cfg.Auth.JWT.PrivateKeyPath, cfg.Auth.JWT.PreviousKeyTTL)
+				if err != nil {
+					log.Warn("Failed to reload JWT signing key",
+						logger.String("path", cfg.Auth.JWT.PrivateKeyPath),
+						logger.Error(err),
+					)
+					continue
+				}
+				if rotated {
+					current, _ := keySet.Current(context.Background())
+					log.Info("JWT signing key rotated",
+						logger.String("kid", current.ID),
+						logger.Duration("previous_key_ttl", cfg.Auth.JWT.PreviousKeyTTL),
+					)
+				}
+			}
+		}
+	}()
+	return stop
+}
+
+func createPasswordPolicy(cfg config.Config, log logger.Logger) *password.Policy {
+	log.Debug("Creating password policy",
+		logger.Int("min_length", cfg.Auth.Password.MinLength),
+		logger.Int("max_length", cfg.Auth.Password.MaxLength),
+		logger.String("blocklist_path", cfg.Auth.Password.BlocklistPath),
+	)
+	policy, err := password.NewPolicy(password.Config{
+		MinLength:        cfg.Auth.Password.MinLength,
+		MaxLength:        cfg.Auth.Password.MaxLength,
+		RequireUppercase: cfg.Auth.Password.RequireUppercase,
+		RequireLowercase: cfg.Auth.Password.RequireLowercase,
+		RequireNumber:    cfg.Auth.Password.RequireNumber,
+		RequireSpecial:   cfg.Auth.Password.RequireSpecial,
+		BlocklistPath:    cfg.Auth.Password.BlocklistPath,
+	})
+	if err != nil {
+		log.Fatal("Failed to create password policy", logger.Error(err))
+	}
+	log.Info("Password policy created successfully")
+	return policy
+}
+
+func createHashingService(cfg config.Config, log logger.Logger) *hashing.HashingService {
+	log.Debug("Creating Hashing service")
+	hashingService, err := hashing.NewService(hashing.Config{
+		Default: hashing.Algorithm(cfg.Auth.Hash.Default),
+		Argon2: hashing.Argon2Config{
+			SaltLength: uint32(cfg.Auth.Hash.SaltLength),
+			Time:       uint32(cfg.Auth.Hash.Iterations),
+			Memory:     uint32(64 * 1024), // 64 MB
+			Threads:    uint8(4),
+			KeyLength:  uint32(cfg.Auth.Hash.KeyLength),
+		},
+		Bcrypt: hashing.BcryptConfig{
+			Cost: cfg.Auth.Hash.Cost,
+		},
+		Scrypt: hashing.ScryptConfig{
+			SaltLength: cfg.Auth.Hash.SaltLength,
+			N:          1 << uint8(cfg.Auth.Hash.Iterations),
+			R:          8,
+			P:          1,
+			KeyLength:  cfg.Auth.Hash.KeyLength,
+		},
+	})
+	if err != nil {
+		log.Fatal("Failed to create Hashing service", logger.Error(err))
+	}
+	log.Info("Hashing Service created successfully")
+	return hashingService
+}
+
+func main() {
+	env.LoadEnv()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load configuration: %v", err))
+	}
+
+	log := createLogger(cfg.Service.Name)
+	defer log.Sync()
+	response.SetLogger(log)
+
+	tracer := createTracer(cfg, log)
+
+	dbClient, err := createDBClient(cfg.Database, log)
+	if err != nil {
+		log.Fatal("Failed to create database client", logger.Error(err))
+	}
+	defer func() {
+		if dbClient != nil {
+			log.Info("Closing database connection")
+			if err := dbClient.Close(); err != nil {
+				log.Error("Failed to close database connection", logger.Error(err))
+			}
+		}
+	}()
+
+	var cacheClient cache.Cache
+	if cfg.Cache.Enabled {
+		cacheClient, err = createCacheClient(cfg.Cache, log)
+		if err != nil {
+			log.Fatal("Failed to create cache client", logger.Error(err))
+		}
+		defer func() {
+			if cacheClient != nil {
+				log.Info("Closing cache connection")
+				if err := cacheClient.Close(); err != nil {
+					log.Error("Failed to close cache connection", logger.Error(err))
+				}
+			}
+		}()
+	} else {
+		log.Info("Cache is disabled in configuration")
+	}
+
+	tokenService, rsaKeys := createTokenManager(*cfg, log)
+	stopKeyWatch := watchSigningKey(rsaKeys, *cfg, log)
+	defer close(stopKeyWatch)
+	hashingService := createHashingService(*cfg, log)
+	passwordPolicy := createPasswordPolicy(*cfg, log)
+
+	locationService := service.NewLocationService(cfg.LocationService.Endpoint, log)
+
+	loginHistoryRepo := repository.NewLoginHistoryRepo(dbClient, log)
+
+	sessionRepo := repository.NewSessionRepo(dbClient, log)
+	sessionService := service.NewSessionService(sessionRepo, cacheClient, *tokenService, log, cfg.Cache)
+
+	securityService := service.NewSecurityService(repository.NewSecurityActivityRepo(dbClient, log), log)
+
+	authRepo := repository.NewAuthRepository(dbClient, log)
+	authService := service.NewAuthServiceBuilder().
+		WithRepo(authRepo).
+		WithLoginHistoryRepo(loginHistoryRepo).
+		WithTokenService(*tokenService).
+		WithHashingService(*hashingService).
+		WithCache(cacheClient).
+		WithConfig(&cfg.Auth).
+		WithLogger(log).
+		Build()
+
+	authHandler := handler.NewAuthHandler(authService, sessionService, locationService, securityService, passwordPolicy, log)
+
+	healthMgr := setupHealthChecks(dbClient, cacheClient, cfg)
+	healthHandler := health.NewHandler(healthMgr)
+
+	var authOptions []coreMiddleware.AuthOption
+	if denylist := sessionService.Denylist(); denylist != nil {
+		authOptions = append(authOptions, coreMiddleware.WithDenylist(denylist))
+	}
+	authMiddleware := coreMiddleware.JWTAuth(tokenService, authOptions...)
+
+	inFlight := shutdown.NewInFlight()
+	routerInstance, err := createRouter(authHandler, healthHandler, authMiddleware, rsaKeys, inFlight, cfg, log)
+	if err != nil {
+		log.Fatal("Failed to create router", logger.Error(err))
+	}
+
+	serverCfg := server.Config{
+		Host:           cfg.Server.Host,
+		Port:           cfg.Server.Port,
+		ReadTimeout:    cfg.Server.ReadTimeout,
+		WriteTimeout:   cfg.Server.WriteTimeout,
+		IdleTimeout:    cfg.Server.IdleTimeout,
+		MaxHeaderBytes: cfg.Server.MaxHeaderBytes,
+		Handler:        routerInstance.Mux(),
+	}
+
+	srv, err := server.New(&serverCfg, log)
+	if err != nil {
+		log.Fatal("Failed to create server", logger.Error(err))
+	}
+
+	shutdownMgr := setupShutdownManager(srv, inFlight, tracer, log, cfg)
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		log.Info("Starting Auth Service server",
+			logger.String("host", cfg.Server.Host),
+			logger.Int("port", cfg.Server.Port),
+		)
+		serverErrors <- srv.Start()
+	}()
+
+	select {
+	case err := <-serverErrors:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server error", logger.Error(err))
+		}
+		log.Info("Server stopped")
+
+	case <-waitForShutdown(shutdownMgr):
+		log.Info("Auth Service stopped gracefully")
+	}
+}
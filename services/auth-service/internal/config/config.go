@@ -103,16 +103,27 @@ type JWTConfig struct {
 	Issuer          string        `yaml:"issuer" mapstructure:"issuer"`
 	Audience        string        `yaml:"audience" mapstructure:"audience"`
 	Leeway          time.Duration `yaml:"leeway" mapstructure:"leeway"`
+	// Algorithm selects HS256 (shared SecretKey) or RS256 (PrivateKeyPath,
+	// published via /.well-known/jwks.json).
+	Algorithm              string        `yaml:"algorithm" mapstructure:"algorithm"`
+	PrivateKeyPath         string        `yaml:"private_key_path" mapstructure:"private_key_path"`
+	PreviousPublicKeyPaths []string      `yaml:"previous_public_key_paths" mapstructure:"previous_public_key_paths"`
+	PreviousKeyTTL         time.Duration `yaml:"previous_key_ttl" mapstructure:"previous_key_ttl"`
+	// KeyReloadInterval is how often PrivateKeyPath is re-read for a rotated
+	// key; zero disables reloading.
+	KeyReloadInterval time.Duration `yaml:"key_reload_interval" mapstructure:"key_reload_interval"`
 }
 
 // PasswordConfig contains password policy configuration
 type PasswordConfig struct {
-	MinLength        int  `yaml:"min_length" mapstructure:"min_length"`
-	RequireUppercase bool `yaml:"require_uppercase" mapstructure:"require_uppercase"`
-	RequireLowercase bool `yaml:"require_lowercase" mapstructure:"require_lowercase"`
-	RequireNumber    bool `yaml:"require_number" mapstructure:"require_number"`
-	RequireSpecial   bool `yaml:"require_special" mapstructure:"require_special"`
-	BcryptCost       int  `yaml:"bcrypt_cost" mapstructure:"bcrypt_cost"`
+	MinLength        int    `yaml:"min_length" mapstructure:"min_length"`
+	MaxLength        int    `yaml:"max_length" mapstructure:"max_length"`
+	RequireUppercase bool   `yaml:"require_uppercase" mapstructure:"require_uppercase"`
+	RequireLowercase bool   `yaml:"require_lowercase" mapstructure:"require_lowercase"`
+	RequireNumber    bool   `yaml:"require_number" mapstructure:"require_number"`
+	RequireSpecial   bool   `yaml:"require_special" mapstructure:"require_special"`
+	BlocklistPath    string `yaml:"blocklist_path" mapstructure:"blocklist_path"`
+	BcryptCost       int    `yaml:"bcrypt_cost" mapstructure:"bcrypt_cost"`
 }
 
 // EmailVerificationConfig contains email verification configuration
@@ -266,8 +277,9 @@ type TracingConfig struct {
 
 // HealthConfig contains health check configuration
 type HealthConfig struct {
-	Enabled  bool   `yaml:"enabled" mapstructure:"enabled"`
-	Endpoint string `yaml:"endpoint" mapstructure:"endpoint"`
+	Enabled  bool          `yaml:"enabled" mapstructure:"enabled"`
+	Endpoint string        `yaml:"endpoint" mapstructure:"endpoint"`
+	CacheTTL time.Duration `yaml:"cache_ttl" mapstructure:"cache_ttl"`
 }
 
 // ShutdownConfig contains graceful shutdown configuration
@@ -172,12 +172,25 @@ func validateCache(cfg *Config) error {
 
 func validateAuth(cfg *Config) error {
 	// Validate JWT
-	if cfg.Auth.JWT.SecretKey == "" {
-		return fmt.Errorf("auth.jwt.secret_key is required")
+	if cfg.Auth.JWT.Algorithm == "" {
+		cfg.Auth.JWT.Algorithm = "HS256"
 	}
 
-	if len(cfg.Auth.JWT.SecretKey) < 32 {
-		return fmt.Errorf("auth.jwt.secret_key must be at least 32 characters long")
+	switch cfg.Auth.JWT.Algorithm {
+	case "HS256":
+		if cfg.Auth.JWT.SecretKey == "" {
+			return fmt.Errorf("auth.jwt.secret_key is required")
+		}
+
+		if len(cfg.Auth.JWT.SecretKey) < 32 {
+			return fmt.Errorf("auth.jwt.secret_key must be at least 32 characters long")
+		}
+	case "RS256":
+		if cfg.Auth.JWT.PrivateKeyPath == "" {
+			return fmt.Errorf("auth.jwt.private_key_path is required for RS256")
+		}
+	default:
+		return fmt.Errorf("auth.jwt.algorithm must be HS256 or RS256, got %q", cfg.Auth.JWT.Algorithm)
 	}
 
 	if cfg.Auth.JWT.AccessTokenTTL <= 0 {
@@ -188,6 +201,10 @@ func validateAuth(cfg *Config) error {
 		cfg.Auth.JWT.RefreshTokenTTL = 168 * time.Hour // 7 days
 	}
 
+	if cfg.Auth.JWT.PreviousKeyTTL <= 0 {
+		cfg.Auth.JWT.PreviousKeyTTL = cfg.Auth.JWT.RefreshTokenTTL
+	}
+
 	if cfg.Auth.JWT.Issuer == "" {
 		cfg.Auth.JWT.Issuer = "auth-service"
 	}
@@ -201,6 +218,13 @@ func validateAuth(cfg *Config) error {
 		return fmt.Errorf("auth.password.min_length must be at least 8")
 	}
 
+	if cfg.Auth.Password.MaxLength <= 0 {
+		cfg.Auth.Password.MaxLength = 72
+	}
+	if cfg.Auth.Password.MaxLength < cfg.Auth.Password.MinLength {
+		return fmt.Errorf("auth.password.max_length (%d) must not be less than min_length (%d)", cfg.Auth.Password.MaxLength, cfg.Auth.Password.MinLength)
+	}
+
 	if cfg.Auth.Password.BcryptCost < 10 || cfg.Auth.Password.BcryptCost > 31 {
 		return fmt.Errorf("auth.password.bcrypt_cost must be between 10 and 31, got %d", cfg.Auth.Password.BcryptCost)
 	}
@@ -218,7 +242,7 @@ func validateAuth(cfg *Config) error {
 	// Validate Password Reset
 	if cfg.Auth.PasswordReset.Enabled {
 		if cfg.Auth.PasswordReset.TokenTTL <= 0 {
-			cfg.Auth.PasswordReset.TokenTTL = 1 * time.Hour
+			cfg.Auth.PasswordReset.TokenTTL = 30 * time.Minute
 		}
 	}
 
@@ -313,7 +337,7 @@ func validateLogging(cfg *Config) error {
 		return fmt.Errorf("logging.format must be one of: %s", strings.Join(validFormats, ", "))
 	}
 
-	validOutputs := []string{"stdout", "stderr", "file"}
+	validOutputs := []string{"stdout", "stderr", "file", "both"}
 	if !contains(validOutputs, cfg.Logging.Output) {
 		return fmt.Errorf("logging.output must be one of: %s", strings.Join(validOutputs, ", "))
 	}
@@ -324,9 +348,9 @@ func validateLogging(cfg *Config) error {
 	}
 
 	// Validate file logging settings
-	if cfg.Logging.Output == "file" {
+	if cfg.Logging.Output == "file" || cfg.Logging.Output == "both" {
 		if cfg.Logging.File.Path == "" {
-			return fmt.Errorf("logging.file.path is required when output is 'file'")
+			return fmt.Errorf("logging.file.path is required when output is '%s'", cfg.Logging.Output)
 		}
 
 		if cfg.Logging.File.MaxSize <= 0 {
@@ -425,6 +449,9 @@ func validateObservability(cfg *Config) error {
 		if cfg.Observability.Health.Endpoint == "" {
 			cfg.Observability.Health.Endpoint = "/health"
 		}
+		if cfg.Observability.Health.CacheTTL <= 0 {
+			cfg.Observability.Health.CacheTTL = 5 * time.Second
+		}
 	}
 
 	return nil
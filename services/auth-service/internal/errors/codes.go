@@ -34,11 +34,16 @@ const (
 	CodeInvalidRefreshToken   = "AUTH_INVALID_REFRESH_TOKEN"
 	CodeRefreshTokenExpired   = "AUTH_REFRESH_TOKEN_EXPIRED"
 
+	// Password Reset Errors
+	CodeInvalidResetToken   = "AUTH_INVALID_RESET_TOKEN"
+	CodePasswordResetFailed = "AUTH_PASSWORD_RESET_FAILED"
+
 	// Security Errors
 	CodeTooManyFailedAttempts = "AUTH_TOO_MANY_FAILED_ATTEMPTS"
 	CodeSuspiciousActivity    = "AUTH_SUSPICIOUS_ACTIVITY"
 	CodeIPBlocked             = "AUTH_IP_BLOCKED"
 	CodeDeviceNotTrusted      = "AUTH_DEVICE_NOT_TRUSTED"
+	CodeDeviceNotFound        = "AUTH_DEVICE_NOT_FOUND"
 )
 
 // ============================================================================
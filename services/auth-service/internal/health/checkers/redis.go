@@ -22,6 +22,11 @@ func (c *CacheChecker) Name() string {
 	return "cache"
 }
 
+// IsCritical reports that the service keeps running, degraded, without its cache
+func (c *CacheChecker) IsCritical() bool {
+	return false
+}
+
 func (c *CacheChecker) Check(ctx context.Context) health.CheckResult {
 	start := time.Now()
 	result := health.CheckResult{
@@ -95,6 +100,18 @@ func (c *CacheChecker) Check(ctx context.Context) health.CheckResult {
 		}
 	}
 
+	// Report the server version when the backend exposes one
+	infoCtx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	if info, err := c.cache.Info(infoCtx); err == nil {
+		if version := serverVersion(info); version != "" {
+			result.Metadata = map[string]interface{}{
+				"server_version": version,
+			}
+		}
+	}
+
 	result.ResponseTime = float64(time.Since(start).Milliseconds())
 	result.Details = map[string]interface{}{
 		"cache":             details,
@@ -104,6 +121,15 @@ func (c *CacheChecker) Check(ctx context.Context) health.CheckResult {
 	return result
 }
 
+// serverVersion finds redis_version in INFO output, whose keys may be
+// prefixed with their section name
+func serverVersion(info map[string]string) string {
+	if v, ok := info["redis_version"]; ok {
+		return v
+	}
+	return info["Server.redis_version"]
+}
+
 // CachePerformanceChecker checks cache performance metrics
 type CachePerformanceChecker struct {
 	cache cache.Cache
@@ -119,6 +145,11 @@ func (c *CachePerformanceChecker) Name() string {
 	return "cache_performance"
 }
 
+// IsCritical reports that the service keeps running, degraded, without its cache
+func (c *CachePerformanceChecker) IsCritical() bool {
+	return false
+}
+
 func (c *CachePerformanceChecker) Check(ctx context.Context) health.CheckResult {
 	start := time.Now()
 	result := health.CheckResult{
@@ -0,0 +1,141 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// BcryptMaxBytes is the input length beyond which bcrypt silently ignores
+// the remaining bytes.
+const BcryptMaxBytes = 72
+
+type Rule string
+
+const (
+	RuleMinLength Rule = "min_length"
+	RuleMaxLength Rule = "max_length"
+	RuleUppercase Rule = "uppercase"
+	RuleLowercase Rule = "lowercase"
+	RuleNumber    Rule = "number"
+	RuleSpecial   Rule = "special"
+	RuleCommon    Rule = "common_password"
+)
+
+type Violation struct {
+	Rule    Rule   `json:"rule"`
+	Message string `json:"message"`
+}
+
+type Config struct {
+	MinLength        int
+	MaxLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireNumber    bool
+	RequireSpecial   bool
+	BlocklistPath    string
+}
+
+type Policy struct {
+	cfg       Config
+	blocklist map[string]struct{}
+}
+
+// NewPolicy builds a policy, loading the common-password blocklist when a path
+// is configured. MaxLength defaults to BcryptMaxBytes.
+func NewPolicy(cfg Config) (*Policy, error) {
+	if cfg.MaxLength <= 0 {
+		cfg.MaxLength = BcryptMaxBytes
+	}
+	if cfg.MinLength > cfg.MaxLength {
+		return nil, fmt.Errorf("password: min length %d exceeds max length %d", cfg.MinLength, cfg.MaxLength)
+	}
+
+	policy := &Policy{cfg: cfg, blocklist: make(map[string]struct{})}
+	if cfg.BlocklistPath != "" {
+		blocklist, err := LoadBlocklist(cfg.BlocklistPath)
+		if err != nil {
+			return nil, err
+		}
+		policy.blocklist = blocklist
+	}
+	return policy, nil
+}
+
+// LoadBlocklist reads one password per line, ignoring blank lines and lines
+// starting with '#'. Entries are matched case-insensitively.
+func LoadBlocklist(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("password: open blocklist: %w", err)
+	}
+	defer file.Close()
+
+	blocklist := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blocklist[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("password: read blocklist: %w", err)
+	}
+	return blocklist, nil
+}
+
+// Validate returns every rule the password breaks; an empty result means the
+// password is acceptable.
+func (p *Policy) Validate(password string) []Violation {
+	var violations []Violation
+
+	if length := len([]rune(password)); length < p.cfg.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("Password must be at least %d characters long", p.cfg.MinLength),
+		})
+	}
+	if len(password) > p.cfg.MaxLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("Password must be at most %d bytes long", p.cfg.MaxLength),
+		})
+	}
+
+	var hasUpper, hasLower, hasNumber, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasNumber = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+	if p.cfg.RequireUppercase && !hasUpper {
+		violations = append(violations, Violation{Rule: RuleUppercase, Message: "Password must contain an uppercase letter"})
+	}
+	if p.cfg.RequireLowercase && !hasLower {
+		violations = append(violations, Violation{Rule: RuleLowercase, Message: "Password must contain a lowercase letter"})
+	}
+	if p.cfg.RequireNumber && !hasNumber {
+		violations = append(violations, Violation{Rule: RuleNumber, Message: "Password must contain a number"})
+	}
+	if p.cfg.RequireSpecial && !hasSpecial {
+		violations = append(violations, Violation{Rule: RuleSpecial, Message: "Password must contain a special character"})
+	}
+
+	if _, blocked := p.blocklist[strings.ToLower(password)]; blocked {
+		violations = append(violations, Violation{Rule: RuleCommon, Message: "Password is too common"})
+	}
+
+	return violations
+}
@@ -0,0 +1,114 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mustPolicy(t *testing.T, cfg Config) *Policy {
+	t.Helper()
+	policy, err := NewPolicy(cfg)
+	if err != nil {
+		t.Fatalf("NewPolicy: %v", err)
+	}
+	return policy
+}
+
+func hasRule(violations []Violation, rule Rule) bool {
+	for _, v := range violations {
+		if v.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPolicy_MinLength(t *testing.T) {
+	policy := mustPolicy(t, Config{MinLength: 10})
+	if !hasRule(policy.Validate("short"), RuleMinLength) {
+		t.Fatalf("expected min_length violation")
+	}
+	if hasRule(policy.Validate("long-enough-pw"), RuleMinLength) {
+		t.Fatalf("unexpected min_length violation")
+	}
+}
+
+func TestPolicy_MaxLengthDefaultsToBcryptLimit(t *testing.T) {
+	policy := mustPolicy(t, Config{MinLength: 8})
+	if hasRule(policy.Validate(strings.Repeat("a", BcryptMaxBytes)), RuleMaxLength) {
+		t.Fatalf("unexpected max_length violation at the limit")
+	}
+	if !hasRule(policy.Validate(strings.Repeat("a", BcryptMaxBytes+1)), RuleMaxLength) {
+		t.Fatalf("expected max_length violation past the bcrypt limit")
+	}
+	// Multi-byte characters count by bytes, not runes.
+	if !hasRule(policy.Validate(strings.Repeat("é", 40)), RuleMaxLength) {
+		t.Fatalf("expected max_length violation for 80-byte password")
+	}
+}
+
+func TestPolicy_CharacterClasses(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		pw   string
+		rule Rule
+	}{
+		{"uppercase", Config{RequireUppercase: true}, "lowercase1!", RuleUppercase},
+		{"lowercase", Config{RequireLowercase: true}, "UPPERCASE1!", RuleLowercase},
+		{"number", Config{RequireNumber: true}, "NoDigits!!", RuleNumber},
+		{"special", Config{RequireSpecial: true}, "NoSpecial1", RuleSpecial},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := mustPolicy(t, tt.cfg)
+			violations := policy.Validate(tt.pw)
+			if len(violations) != 1 || violations[0].Rule != tt.rule {
+				t.Fatalf("expected only %s violation, got %+v", tt.rule, violations)
+			}
+			if got := policy.Validate("Valid1Pass!"); len(got) != 0 {
+				t.Fatalf("expected compliant password to pass, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestPolicy_Blocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "common.txt")
+	if err := os.WriteFile(path, []byte("# comment\nPassword123\n\nletmein\n"), 0o600); err != nil {
+		t.Fatalf("write blocklist: %v", err)
+	}
+	policy := mustPolicy(t, Config{BlocklistPath: path})
+
+	if !hasRule(policy.Validate("password123"), RuleCommon) {
+		t.Fatalf("expected blocklisted password to be rejected case-insensitively")
+	}
+	if hasRule(policy.Validate("# comment"), RuleCommon) {
+		t.Fatalf("comment lines must not be loaded")
+	}
+	if hasRule(policy.Validate("a-rare-passphrase"), RuleCommon) {
+		t.Fatalf("unexpected common_password violation")
+	}
+
+	if _, err := NewPolicy(Config{BlocklistPath: filepath.Join(t.TempDir(), "missing.txt")}); err == nil {
+		t.Fatalf("expected error for missing blocklist file")
+	}
+}
+
+func TestPolicy_ReportsAllViolations(t *testing.T) {
+	policy := mustPolicy(t, Config{MinLength: 12, RequireUppercase: true, RequireNumber: true, RequireSpecial: true})
+	violations := policy.Validate("abc")
+	for _, rule := range []Rule{RuleMinLength, RuleUppercase, RuleNumber, RuleSpecial} {
+		if !hasRule(violations, rule) {
+			t.Fatalf("expected %s in %+v", rule, violations)
+		}
+	}
+}
+
+func TestNewPolicy_RejectsMinAboveMax(t *testing.T) {
+	if _, err := NewPolicy(Config{MinLength: 80, MaxLength: 72}); err == nil {
+		t.Fatalf("expected error when min length exceeds max length")
+	}
+}
@@ -215,6 +215,78 @@ func (r *AuthRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 	return &user, nil
 }
 
+func (r *AuthRepository) GetUserByPhone(ctx context.Context, phoneNumber string) (*models.AuthUser, pkgErrors.AppError) {
+	r.log.Debug("Fetching user by phone number",
+		logger.String("service", authErrors.ServiceName),
+	)
+
+	query := `SELECT * FROM auth.users WHERE phone_number = $1 AND deleted_at IS NULL LIMIT 1`
+	row := r.db.QueryRow(ctx, query, phoneNumber)
+	var user models.AuthUser
+	err := row.ScanOne(&user)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to get user by phone number")
+	}
+	return &user, nil
+}
+
+// ============================================================================
+// Password Management
+// ============================================================================
+
+type UpdatePasswordParams struct {
+	UserID            string
+	PasswordHash      string
+	PasswordSalt      string
+	PasswordAlgorithm string
+}
+
+func (r *AuthRepository) UpdatePassword(ctx context.Context, params UpdatePasswordParams) pkgErrors.AppError {
+	r.log.Info("Updating user password",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("user_id", params.UserID),
+		logger.String("password_algorithm", params.PasswordAlgorithm),
+	)
+
+	historyEntry, marshalErr := json.Marshal([]map[string]string{{
+		"hash":       params.PasswordHash,
+		"salt":       params.PasswordSalt,
+		"algorithm":  params.PasswordAlgorithm,
+		"changed_at": time.Now().Format(time.RFC3339),
+	}})
+	if marshalErr != nil {
+		return pkgErrors.FromError(marshalErr, pkgErrors.CodeInternal, "failed to encode password history").
+			WithDetail("user_id", params.UserID)
+	}
+
+	query := `UPDATE auth.users
+		SET password_hash = $1,
+		    password_salt = $2,
+		    password_algorithm = $3,
+		    password_last_changed_at = NOW(),
+		    password_history = COALESCE(password_history, '[]'::jsonb) || $4::jsonb,
+		    requires_password_change = FALSE,
+		    failed_login_attempts = 0,
+		    account_locked_until = NULL,
+		    updated_at = NOW()
+		WHERE id = $5`
+	result, err := r.db.Exec(ctx, query, params.PasswordHash, params.PasswordSalt, params.PasswordAlgorithm, string(historyEntry), params.UserID)
+	if err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to update password").
+			WithDetail("user_id", params.UserID)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return pkgErrors.New(authErrors.CodeUserNotFound, "user not found").
+			WithDetail("user_id", params.UserID)
+	}
+	return nil
+}
+
 // ============================================================================
 // Login Tracking
 // ============================================================================
@@ -3,6 +3,7 @@ package repository
 import (
 	repoModels "auth-service/internal/repo/models"
 	"context"
+	"time"
 
 	"shared/pkg/database/postgres/models"
 	pkgErrors "shared/pkg/errors"
@@ -40,11 +41,31 @@ type SessionRepositoryInterface interface {
 	CreateSession(ctx context.Context, session *models.AuthSession) pkgErrors.AppError
 	GetSessionByUserId(ctx context.Context, userID string) (*models.AuthSession, pkgErrors.AppError)
 	DeleteSessionByID(ctx context.Context, sessionID string) pkgErrors.AppError
+	GetSessionByID(ctx context.Context, sessionID string) (*models.AuthSession, pkgErrors.AppError)
+	GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*models.AuthSession, pkgErrors.AppError)
+	RotateRefreshToken(ctx context.Context, sessionID, oldToken, newToken string, expiresAt time.Time) (bool, pkgErrors.AppError)
+	RevokeSession(ctx context.Context, sessionID string, reason string) pkgErrors.AppError
+	RevokeAllUserSessions(ctx context.Context, userID string, reason string) ([]*models.AuthSession, pkgErrors.AppError)
+
+	// Devices
+	ListActiveSessions(ctx context.Context, userID string) ([]*models.AuthSession, pkgErrors.AppError)
+	RevokeDeviceSessions(ctx context.Context, userID, deviceID, reason string) ([]*models.AuthSession, pkgErrors.AppError)
+
+	// Security events
+	CreateSecurityEvent(ctx context.Context, event *models.SecurityEvent) pkgErrors.AppError
+}
+
+// SecurityActivityRepositoryInterface defines the contract for reading a user's security activity
+type SecurityActivityRepositoryInterface interface {
+	// Cursor-paginated listings, newest first
+	ListLoginHistory(ctx context.Context, userID string, after *repoModels.ActivityCursor, limit int) ([]*models.LoginHistory, pkgErrors.AppError)
+	ListSecurityEvents(ctx context.Context, userID string, after *repoModels.ActivityCursor, limit int) ([]*models.SecurityEvent, pkgErrors.AppError)
 }
 
 // Compile-time interface compliance checks
 var (
-	_ AuthRepositoryInterface         = (*AuthRepository)(nil)
-	_ LoginHistoryRepositoryInterface = (*LoginHistoryRepo)(nil)
-	_ SessionRepositoryInterface      = (*SessionRepo)(nil)
+	_ AuthRepositoryInterface             = (*AuthRepository)(nil)
+	_ LoginHistoryRepositoryInterface     = (*LoginHistoryRepo)(nil)
+	_ SessionRepositoryInterface          = (*SessionRepo)(nil)
+	_ SecurityActivityRepositoryInterface = (*SecurityActivityRepo)(nil)
 )
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// ActivityCursor marks the last row of a page of login history or security
+// events; the next page starts strictly after it in (created_at, id) order.
+type ActivityCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	repoModels "auth-service/internal/repo/models"
+	"context"
+	"fmt"
+	"shared/pkg/database"
+	"shared/pkg/database/postgres/models"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+)
+
+// ============================================================================
+// Repository Definition
+// ============================================================================
+
+// SecurityActivityRepo reads a user's own login history and security events
+// newest first, one keyset page at a time
+type SecurityActivityRepo struct {
+	db  database.Database
+	log logger.Logger
+}
+
+func NewSecurityActivityRepo(db database.Database, log logger.Logger) *SecurityActivityRepo {
+	return &SecurityActivityRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// ============================================================================
+// Security Activity Operations
+// ============================================================================
+
+func (r *SecurityActivityRepo) ListLoginHistory(ctx context.Context, userID string, after *repoModels.ActivityCursor, limit int) ([]*models.LoginHistory, pkgErrors.AppError) {
+	r.log.Debug("Listing login history",
+		logger.String("user_id", userID),
+		logger.Int("limit", limit),
+	)
+	var histories []*models.LoginHistory
+	query, args := activityPageQuery(`SELECT id, user_id, session_id, login_method, status, failure_reason,
+		ip_address, user_agent, device_id, device_fingerprint, location_country,
+		location_city, latitude, longitude, is_new_device, is_new_location, created_at
+		FROM auth.login_history`, userID, after, limit)
+	if err := r.db.FindMany(ctx, &histories, query, args...); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to list login history").
+			WithDetail("user_id", userID)
+	}
+	return histories, nil
+}
+
+func (r *SecurityActivityRepo) ListSecurityEvents(ctx context.Context, userID string, after *repoModels.ActivityCursor, limit int) ([]*models.SecurityEvent, pkgErrors.AppError) {
+	r.log.Debug("Listing security events",
+		logger.String("user_id", userID),
+		logger.Int("limit", limit),
+	)
+	var events []*models.SecurityEvent
+	query, args := activityPageQuery(`SELECT id, user_id, session_id, event_type, event_category, severity,
+		status, description, ip_address, user_agent, device_id, location_country,
+		location_city, risk_score, is_suspicious, blocked_reason, created_at
+		FROM auth.security_events`, userID, after, limit)
+	if err := r.db.FindMany(ctx, &events, query, args...); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to list security events").
+			WithDetail("user_id", userID)
+	}
+	return events, nil
+}
+
+// ============================================================================
+// Helper Functions
+// ============================================================================
+
+// activityPageQuery scopes a listing to one user and continues strictly after
+// the cursor; the row comparison matches the (user_id, created_at DESC, id DESC)
+// indexes so each page is an index range scan.
+func activityPageQuery(selectFrom, userID string, after *repoModels.ActivityCursor, limit int) (string, []interface{}) {
+	args := []interface{}{userID}
+	query := selectFrom + ` WHERE user_id = $1`
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		query += ` AND (created_at, id) < ($2::timestamptz, $3::uuid)`
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args))
+	return query, args
+}
@@ -7,6 +7,7 @@ import (
 	"shared/pkg/database/postgres/models"
 	pkgErrors "shared/pkg/errors"
 	"shared/pkg/logger"
+	"time"
 )
 
 // ============================================================================
@@ -82,3 +83,180 @@ func (r *SessionRepo) DeleteSessionByID(ctx context.Context, sessionID string) p
 	)
 	return nil
 }
+
+func (r *SessionRepo) GetSessionByID(ctx context.Context, sessionID string) (*models.AuthSession, pkgErrors.AppError) {
+	r.log.Debug("Fetching session by ID",
+		logger.String("session_id", sessionID),
+	)
+	var session models.AuthSession
+	query := "SELECT * FROM auth.sessions WHERE id = $1 LIMIT 1"
+	err := r.db.QueryRow(ctx, query, sessionID).ScanOne(&session)
+	if err != nil {
+		if postgres.IsNoRowsError(err) {
+			return nil, nil
+		}
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to get session by ID").
+			WithDetail("session_id", sessionID)
+	}
+	return &session, nil
+}
+
+func (r *SessionRepo) GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*models.AuthSession, pkgErrors.AppError) {
+	r.log.Debug("Fetching session by refresh token")
+	var session models.AuthSession
+	query := "SELECT * FROM auth.sessions WHERE refresh_token = $1 AND revoked_at IS NULL LIMIT 1"
+	err := r.db.QueryRow(ctx, query, refreshToken).ScanOne(&session)
+	if err != nil {
+		if postgres.IsNoRowsError(err) {
+			r.log.Debug("No active session found for refresh token")
+			return nil, nil
+		}
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to get session by refresh token")
+	}
+	return &session, nil
+}
+
+// RotateRefreshToken swaps the session's refresh token only if it still holds
+// oldToken, so concurrent rotations of the same token cannot both succeed.
+func (r *SessionRepo) RotateRefreshToken(ctx context.Context, sessionID, oldToken, newToken string, expiresAt time.Time) (bool, pkgErrors.AppError) {
+	r.log.Debug("Rotating refresh token",
+		logger.String("session_id", sessionID),
+	)
+	query := `UPDATE auth.sessions
+		SET refresh_token = $1, expires_at = $2, last_refresh_at = NOW(), last_activity_at = NOW()
+		WHERE id = $3 AND refresh_token = $4 AND revoked_at IS NULL`
+	result, err := r.db.Exec(ctx, query, newToken, expiresAt, sessionID, oldToken)
+	if err != nil {
+		return false, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to rotate refresh token").
+			WithDetail("session_id", sessionID)
+	}
+	affected, rowsErr := result.RowsAffected()
+	if rowsErr != nil {
+		return false, pkgErrors.FromError(rowsErr, pkgErrors.CodeDatabaseError, "failed to read rotated rows").
+			WithDetail("session_id", sessionID)
+	}
+	return affected == 1, nil
+}
+
+func (r *SessionRepo) RevokeSession(ctx context.Context, sessionID string, reason string) pkgErrors.AppError {
+	r.log.Debug("Revoking session",
+		logger.String("session_id", sessionID),
+		logger.String("reason", reason),
+	)
+	query := `UPDATE auth.sessions SET revoked_at = NOW(), revoked_reason = $1 WHERE id = $2 AND revoked_at IS NULL`
+	_, err := r.db.Exec(ctx, query, reason, sessionID)
+	if err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to revoke session").
+			WithDetail("session_id", sessionID)
+	}
+	return nil
+}
+
+// RevokeAllUserSessions marks every active session of the user revoked and
+// returns the sessions it touched.
+func (r *SessionRepo) RevokeAllUserSessions(ctx context.Context, userID string, reason string) ([]*models.AuthSession, pkgErrors.AppError) {
+	r.log.Debug("Revoking all sessions for user",
+		logger.String("user_id", userID),
+		logger.String("reason", reason),
+	)
+	query := `UPDATE auth.sessions SET revoked_at = NOW(), revoked_reason = $1
+		WHERE user_id = $2 AND revoked_at IS NULL
+		RETURNING id, expires_at`
+	rows, err := r.db.Query(ctx, query, reason, userID)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to revoke user sessions").
+			WithDetail("user_id", userID)
+	}
+	defer rows.Close()
+
+	var sessions []*models.AuthSession
+	for rows.Next() {
+		session := models.AuthSession{UserID: userID}
+		if scanErr := rows.Scan(&session.ID, &session.ExpiresAt); scanErr != nil {
+			return nil, pkgErrors.FromError(scanErr, pkgErrors.CodeDatabaseError, "failed to scan revoked session").
+				WithDetail("user_id", userID)
+		}
+		sessions = append(sessions, &session)
+	}
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, pkgErrors.FromError(rowsErr, pkgErrors.CodeDatabaseError, "failed to read revoked sessions").
+			WithDetail("user_id", userID)
+	}
+	return sessions, nil
+}
+
+// ListActiveSessions returns the user's unrevoked, unexpired sessions, most
+// recently active first
+func (r *SessionRepo) ListActiveSessions(ctx context.Context, userID string) ([]*models.AuthSession, pkgErrors.AppError) {
+	r.log.Debug("Listing active sessions",
+		logger.String("user_id", userID),
+	)
+	var sessions []*models.AuthSession
+	query := `SELECT id, user_id, device_id, device_name, device_type, device_os, device_os_version,
+		device_model, device_manufacturer, browser_name, browser_version, user_agent, ip_address,
+		ip_country, ip_region, ip_city, is_mobile, is_trusted_device, session_type, expires_at,
+		last_activity_at, created_at
+		FROM auth.sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_activity_at DESC, created_at DESC`
+	if err := r.db.FindMany(ctx, &sessions, query, userID); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to list active sessions").
+			WithDetail("user_id", userID)
+	}
+	return sessions, nil
+}
+
+// RevokeDeviceSessions marks every active session the user holds on deviceID
+// revoked and returns the sessions it touched. Sessions of other users are
+// never matched, so an unknown or foreign device revokes nothing.
+func (r *SessionRepo) RevokeDeviceSessions(ctx context.Context, userID, deviceID, reason string) ([]*models.AuthSession, pkgErrors.AppError) {
+	r.log.Debug("Revoking device sessions",
+		logger.String("user_id", userID),
+		logger.String("device_id", deviceID),
+		logger.String("reason", reason),
+	)
+	query := `UPDATE auth.sessions SET revoked_at = NOW(), revoked_reason = $1
+		WHERE user_id = $2 AND device_id = $3 AND revoked_at IS NULL
+		RETURNING id, session_token, expires_at`
+	rows, err := r.db.Query(ctx, query, reason, userID, deviceID)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to revoke device sessions").
+			WithDetail("user_id", userID).
+			WithDetail("device_id", deviceID)
+	}
+	defer rows.Close()
+
+	var sessions []*models.AuthSession
+	for rows.Next() {
+		session := models.AuthSession{UserID: userID, DeviceID: &deviceID}
+		if scanErr := rows.Scan(&session.ID, &session.SessionToken, &session.ExpiresAt); scanErr != nil {
+			return nil, pkgErrors.FromError(scanErr, pkgErrors.CodeDatabaseError, "failed to scan revoked session").
+				WithDetail("user_id", userID).
+				WithDetail("device_id", deviceID)
+		}
+		sessions = append(sessions, &session)
+	}
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, pkgErrors.FromError(rowsErr, pkgErrors.CodeDatabaseError, "failed to read revoked sessions").
+			WithDetail("user_id", userID).
+			WithDetail("device_id", deviceID)
+	}
+	return sessions, nil
+}
+
+// ============================================================================
+// Security Events
+// ============================================================================
+
+func (r *SessionRepo) CreateSecurityEvent(ctx context.Context, event *models.SecurityEvent) pkgErrors.AppError {
+	r.log.Debug("Creating security event",
+		logger.String("event_type", string(event.EventType)),
+		logger.String("severity", string(event.Severity)),
+	)
+	_, err := r.db.Insert(ctx, event)
+	if err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to create security event").
+			WithDetail("event_type", string(event.EventType))
+	}
+	return nil
+}
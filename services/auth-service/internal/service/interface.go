@@ -11,6 +11,8 @@ import (
 	"shared/server/common/hashing"
 	"shared/server/common/token"
 	"shared/server/request"
+
+	"github.com/google/uuid"
 )
 
 // ============================================================================
@@ -26,6 +28,10 @@ type AuthServiceInterface interface {
 	RegisterUser(ctx context.Context, input serviceModels.RegisterUserInput) (*serviceModels.RegisterUserOutput, pkgErrors.AppError)
 	Login(ctx context.Context, email, password string) (*dto.LoginResponse, pkgErrors.AppError)
 
+	// Password reset
+	RequestPasswordReset(ctx context.Context, input serviceModels.PasswordResetRequestInput) (*serviceModels.PasswordResetRequestOutput, pkgErrors.AppError)
+	ResetPassword(ctx context.Context, resetToken, newPassword string) (string, pkgErrors.AppError)
+
 	// Service accessors
 	TokenService() token.JWTTokenService
 	HashingService() hashing.HashingService
@@ -36,13 +42,31 @@ type SessionServiceInterface interface {
 	// Session management
 	CreateSession(ctx context.Context, input serviceModels.CreateSessionInput) (*serviceModels.CreateSessionOutput, pkgErrors.AppError)
 	GetSessionByUserId(ctx context.Context, userID string) (*models.AuthSession, pkgErrors.AppError)
+	GetSessionByID(ctx context.Context, sessionID string) (*models.AuthSession, pkgErrors.AppError)
 	DeleteSessionByID(ctx context.Context, sessionID string) pkgErrors.AppError
+	RefreshSession(ctx context.Context, refreshToken string) (*token.TokenPair, pkgErrors.AppError)
+	RevokeSession(ctx context.Context, sessionID string) pkgErrors.AppError
+	RevokeAllUserSessions(ctx context.Context, userID uuid.UUID) pkgErrors.AppError
+
+	// Devices
+	ListDevices(ctx context.Context, userID string) ([]*serviceModels.Device, pkgErrors.AppError)
+	RevokeDevice(ctx context.Context, input serviceModels.RevokeDeviceInput) (int, pkgErrors.AppError)
+
+	// Security events
+	RecordSecurityEvent(ctx context.Context, event *models.SecurityEvent) pkgErrors.AppError
 }
 
 // LocationServiceInterface defines the contract for location service operations
 type LocationServiceInterface interface {
 	// IP lookup
-	Lookup(ip string) (*request.IpAddressInfo, pkgErrors.AppError)
+	Lookup(ctx context.Context, ip string) (*request.IpAddressInfo, pkgErrors.AppError)
+}
+
+// SecurityServiceInterface defines the contract for security activity operations
+type SecurityServiceInterface interface {
+	// Cursor-paginated listings of the caller's own records
+	ListLoginHistory(ctx context.Context, userID, cursor string, limit int) (*serviceModels.LoginHistoryPage, pkgErrors.AppError)
+	ListSecurityEvents(ctx context.Context, userID, cursor string, limit int) (*serviceModels.SecurityEventPage, pkgErrors.AppError)
 }
 
 // Compile-time interface compliance checks
@@ -50,4 +74,5 @@ var (
 	_ AuthServiceInterface     = (*AuthService)(nil)
 	_ SessionServiceInterface  = (*SessionService)(nil)
 	_ LocationServiceInterface = (*LocationService)(nil)
+	_ SecurityServiceInterface = (*SecurityService)(nil)
 )
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type RegisterUserInput struct {
 	Email            string
 	Password         string
@@ -16,3 +18,21 @@ type RegisterUserOutput struct {
 	EmailVerificationSent bool
 	VerificationToken     string
 }
+
+type PasswordResetRequestInput struct {
+	Email       string
+	PhoneNumber string
+}
+
+type PasswordResetRequestOutput struct {
+	UserID    string
+	ExpiresAt time.Time
+}
+
+type PasswordResetNotification struct {
+	UserID      string
+	Email       string
+	PhoneNumber string
+	ResetToken  string
+	ExpiresAt   time.Time
+}
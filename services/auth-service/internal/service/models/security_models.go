@@ -0,0 +1,15 @@
+package models
+
+import dbModels "shared/pkg/database/postgres/models"
+
+// ActivityPage is one page of a user's security activity. NextCursor is nil
+// on the last page.
+type ActivityPage[T any] struct {
+	Items      []T
+	Limit      int
+	NextCursor *string
+}
+
+type LoginHistoryPage = ActivityPage[*dbModels.LoginHistory]
+
+type SecurityEventPage = ActivityPage[*dbModels.SecurityEvent]
@@ -28,3 +28,31 @@ type CreateSessionOutput struct {
 	SessionId    string
 	SessionToken string
 }
+
+// Device groups the active sessions a user holds on one device. Descriptive
+// fields come from the device's most recently active session.
+type Device struct {
+	DeviceID     string
+	Name         string
+	Type         string
+	OS           string
+	OSVersion    string
+	Model        string
+	Browser      string
+	UserAgent    string
+	IPAddress    string
+	City         string
+	Country      string
+	IsMobile     bool
+	IsTrusted    bool
+	SessionIDs   []string
+	FirstSeenAt  time.Time
+	LastActiveAt time.Time
+}
+
+type RevokeDeviceInput struct {
+	UserID    string
+	DeviceID  string
+	IPAddress string
+	UserAgent string
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	authErrors "auth-service/internal/errors"
+	repository "auth-service/internal/repo"
+	serviceModels "auth-service/internal/service/models"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"shared/pkg/cache"
+	"shared/pkg/database/postgres/models"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+)
+
+const passwordResetKeyPrefix = "password_reset:"
+
+var errInvalidResetToken = errors.New("password reset token is invalid or already used")
+
+// PasswordResetNotifier delivers a freshly issued reset token to the user.
+type PasswordResetNotifier interface {
+	SendPasswordReset(ctx context.Context, notification serviceModels.PasswordResetNotification) error
+}
+
+type logPasswordResetNotifier struct {
+	log logger.Logger
+}
+
+// NewLogPasswordResetNotifier records that a reset was requested without
+// delivering it. Used until an email/SMS channel is configured.
+func NewLogPasswordResetNotifier(log logger.Logger) PasswordResetNotifier {
+	return &logPasswordResetNotifier{log: log}
+}
+
+func (n *logPasswordResetNotifier) SendPasswordReset(ctx context.Context, notification serviceModels.PasswordResetNotification) error {
+	n.log.Info("Password reset token issued (no delivery channel configured)",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("user_id", notification.UserID),
+		logger.Time("expires_at", notification.ExpiresAt),
+	)
+	return nil
+}
+
+// passwordResetStore keeps only the SHA-256 of each reset token in the cache,
+// so a cache dump cannot be replayed against /password/reset.
+type passwordResetStore struct {
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+func hashResetToken(resetToken string) string {
+	sum := sha256.Sum256([]byte(resetToken))
+	return hex.EncodeToString(sum[:])
+}
+
+func (p *passwordResetStore) issue(ctx context.Context, userID string) (string, time.Time, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, err
+	}
+	resetToken := base64.RawURLEncoding.EncodeToString(raw)
+	expiresAt := time.Now().Add(p.ttl)
+	if err := p.cache.Set(ctx, passwordResetKeyPrefix+hashResetToken(resetToken), []byte(userID), p.ttl); err != nil {
+		return "", time.Time{}, err
+	}
+	return resetToken, expiresAt, nil
+}
+
+// consume returns the user the token was issued for. The atomic counter makes
+// the token single-use even when two resets race.
+func (p *passwordResetStore) consume(ctx context.Context, resetToken string) (string, error) {
+	key := passwordResetKeyPrefix + hashResetToken(resetToken)
+	value, err := p.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return "", errInvalidResetToken
+		}
+		return "", err
+	}
+	if len(value) == 0 {
+		return "", errInvalidResetToken
+	}
+
+	uses, err := p.cache.Increment(ctx, key+":used", 1)
+	if err != nil {
+		return "", err
+	}
+	if uses != 1 {
+		return "", errInvalidResetToken
+	}
+	_ = p.cache.Expire(ctx, key+":used", p.ttl)
+	_ = p.cache.Delete(ctx, key)
+	return string(value), nil
+}
+
+func (s *AuthService) passwordResetStore() (*passwordResetStore, pkgErrors.AppError) {
+	if !s.cfg.PasswordReset.Enabled {
+		return nil, pkgErrors.New(pkgErrors.CodeForbidden, "password reset is disabled").
+			WithService(authErrors.ServiceName)
+	}
+	if s.cache == nil {
+		return nil, pkgErrors.New(authErrors.CodePasswordResetFailed, "password reset requires a cache").
+			WithService(authErrors.ServiceName)
+	}
+	return &passwordResetStore{cache: s.cache, ttl: s.cfg.PasswordReset.TokenTTL}, nil
+}
+
+// RequestPasswordReset issues a reset token for the account matching the email
+// or phone number. It returns nil output when no account matches so callers
+// can respond identically either way.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, input serviceModels.PasswordResetRequestInput) (*serviceModels.PasswordResetRequestOutput, pkgErrors.AppError) {
+	store, appErr := s.passwordResetStore()
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	var user *models.AuthUser
+	var err pkgErrors.AppError
+	if input.Email != "" {
+		user, err = s.repo.GetUserByEmail(ctx, normalizeEmail(input.Email))
+	} else {
+		user, err = s.repo.GetUserByPhone(ctx, input.PhoneNumber)
+	}
+	if err != nil {
+		return nil, err.WithService(authErrors.ServiceName)
+	}
+	if user == nil || user.DeletedAt != nil {
+		s.log.Info("Password reset requested for unknown account",
+			logger.String("service", authErrors.ServiceName),
+		)
+		return nil, nil
+	}
+
+	resetToken, expiresAt, issueErr := store.issue(ctx, user.ID)
+	if issueErr != nil {
+		return nil, pkgErrors.FromError(issueErr, authErrors.CodePasswordResetFailed, "failed to store password reset token").
+			WithService(authErrors.ServiceName).
+			WithDetail("user_id", user.ID)
+	}
+
+	notification := serviceModels.PasswordResetNotification{
+		UserID:     user.ID,
+		Email:      user.Email,
+		ResetToken: resetToken,
+		ExpiresAt:  expiresAt,
+	}
+	if user.PhoneNumber != nil {
+		notification.PhoneNumber = *user.PhoneNumber
+	}
+	if notifyErr := s.resetNotifier.SendPasswordReset(ctx, notification); notifyErr != nil {
+		return nil, pkgErrors.FromError(notifyErr, authErrors.CodePasswordResetFailed, "failed to enqueue password reset").
+			WithService(authErrors.ServiceName).
+			WithDetail("user_id", user.ID)
+	}
+
+	s.log.Info("Password reset token issued",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("user_id", user.ID),
+	)
+
+	return &serviceModels.PasswordResetRequestOutput{
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ResetPassword consumes a reset token and stores the new password hash,
+// returning the ID of the user whose password changed.
+func (s *AuthService) ResetPassword(ctx context.Context, resetToken, newPassword string) (string, pkgErrors.AppError) {
+	store, appErr := s.passwordResetStore()
+	if appErr != nil {
+		return "", appErr
+	}
+
+	userID, consumeErr := store.consume(ctx, resetToken)
+	if consumeErr != nil {
+		if errors.Is(consumeErr, errInvalidResetToken) {
+			return "", pkgErrors.New(authErrors.CodeInvalidResetToken, "Reset token is invalid or has expired").
+				WithService(authErrors.ServiceName)
+		}
+		return "", pkgErrors.FromError(consumeErr, authErrors.CodePasswordResetFailed, "failed to verify reset token").
+			WithService(authErrors.ServiceName)
+	}
+
+	result, hashErr := s.hashingService.HashPassword(ctx, newPassword)
+	if hashErr != nil {
+		return "", pkgErrors.FromError(hashErr, authErrors.CodePasswordHashingFailed, "failed to hash password").
+			WithService(authErrors.ServiceName).
+			WithDetail("user_id", userID)
+	}
+
+	err := s.repo.UpdatePassword(ctx, repository.UpdatePasswordParams{
+		UserID:            userID,
+		PasswordHash:      result.Encoded,
+		PasswordSalt:      base64.StdEncoding.EncodeToString(result.Salt),
+		PasswordAlgorithm: string(result.Algorithm),
+	})
+	if err != nil {
+		return "", err.WithService(authErrors.ServiceName)
+	}
+
+	s.log.Info("Password reset completed",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("user_id", userID),
+	)
+	return userID, nil
+}
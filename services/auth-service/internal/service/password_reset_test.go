@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	pkgErrors "shared/pkg/errors"
+)
+
+func (f *fakeCache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	current, _ := strconv.ParseInt(string(f.items[key]), 10, 64)
+	current += delta
+	f.items[key] = []byte(strconv.FormatInt(current, 10))
+	return current, nil
+}
+
+func (f *fakeCache) Expire(ctx context.Context, key string, ttl time.Duration) pkgErrors.AppError {
+	return nil
+}
+
+func TestPasswordResetStore_SingleUse(t *testing.T) {
+	c := newFakeCache()
+	store := &passwordResetStore{cache: c, ttl: 30 * time.Minute}
+	ctx := context.Background()
+
+	resetToken, expiresAt, err := store.issue(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+	if time.Until(expiresAt) <= 29*time.Minute {
+		t.Fatalf("unexpected expiry %v", expiresAt)
+	}
+	for key := range c.items {
+		if strings.Contains(key, resetToken) {
+			t.Fatalf("raw reset token must not be stored, found key %q", key)
+		}
+	}
+
+	userID, err := store.consume(ctx, resetToken)
+	if err != nil || userID != "user-1" {
+		t.Fatalf("expected user-1, got %q (err %v)", userID, err)
+	}
+	if _, err := store.consume(ctx, resetToken); !errors.Is(err, errInvalidResetToken) {
+		t.Fatalf("expected reused token to be rejected, got %v", err)
+	}
+}
+
+func TestPasswordResetStore_UnknownToken(t *testing.T) {
+	store := &passwordResetStore{cache: newFakeCache(), ttl: time.Minute}
+	if _, err := store.consume(context.Background(), "not-a-token"); !errors.Is(err, errInvalidResetToken) {
+		t.Fatalf("expected invalid token error, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	authErrors "auth-service/internal/errors"
+	repository "auth-service/internal/repo"
+	repoModels "auth-service/internal/repo/models"
+	serviceModels "auth-service/internal/service/models"
+	"context"
+	"time"
+
+	"shared/pkg/database"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+	"shared/server/pagination"
+)
+
+// SecurityService serves a user's own login history and security events
+type SecurityService struct {
+	repo repository.SecurityActivityRepositoryInterface
+	log  logger.Logger
+}
+
+func NewSecurityService(repo repository.SecurityActivityRepositoryInterface, log logger.Logger) *SecurityService {
+	if repo == nil {
+		panic("SecurityActivityRepo is required")
+	}
+	if log == nil {
+		panic("Logger is required")
+	}
+
+	log.Info("Initializing SecurityService",
+		logger.String("service", authErrors.ServiceName),
+	)
+
+	return &SecurityService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// ListLoginHistory returns userID's login attempts newest first, starting
+// after cursor when one is given
+func (s *SecurityService) ListLoginHistory(ctx context.Context, userID, cursor string, limit int) (*serviceModels.LoginHistoryPage, pkgErrors.AppError) {
+	after, limit, err := pageArgs(cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra row tells whether another page follows
+	rows, err := s.repo.ListLoginHistory(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &serviceModels.LoginHistoryPage{Items: rows, Limit: limit}
+	if len(rows) > limit {
+		page.Items = rows[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = nextCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// ListSecurityEvents returns userID's security events newest first, starting
+// after cursor when one is given
+func (s *SecurityService) ListSecurityEvents(ctx context.Context, userID, cursor string, limit int) (*serviceModels.SecurityEventPage, pkgErrors.AppError) {
+	after, limit, err := pageArgs(cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListSecurityEvents(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &serviceModels.SecurityEventPage{Items: rows, Limit: limit}
+	if len(rows) > limit {
+		page.Items = rows[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = nextCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+func pageArgs(cursor string, limit int) (*repoModels.ActivityCursor, int, pkgErrors.AppError) {
+	limit = database.ClampLimit(limit)
+	if cursor == "" {
+		return nil, limit, nil
+	}
+
+	createdAt, id, err := pagination.DecodeTimeCursor(cursor)
+	if err != nil {
+		return nil, 0, pkgErrors.FromError(err, pkgErrors.CodeInvalidArgument, "invalid cursor").
+			WithService(authErrors.ServiceName)
+	}
+	return &repoModels.ActivityCursor{CreatedAt: createdAt, ID: id}, limit, nil
+}
+
+func nextCursor(createdAt time.Time, id string) *string {
+	cursor := pagination.EncodeTimeCursor(createdAt, id)
+	return &cursor
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	repoModels "auth-service/internal/repo/models"
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"shared/pkg/database/postgres/models"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+)
+
+// memActivityRepo applies the same keyset ordering as the SQL listing
+type memActivityRepo struct {
+	history []*models.LoginHistory
+}
+
+func (m *memActivityRepo) ListLoginHistory(ctx context.Context, userID string, after *repoModels.ActivityCursor, limit int) ([]*models.LoginHistory, pkgErrors.AppError) {
+	var rows []*models.LoginHistory
+	for _, h := range m.history {
+		if h.UserID != userID {
+			continue
+		}
+		if after != nil && !activityBefore(h.CreatedAt, h.ID, after) {
+			continue
+		}
+		rows = append(rows, h)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return activityBefore(rows[j].CreatedAt, rows[j].ID, &repoModels.ActivityCursor{CreatedAt: rows[i].CreatedAt, ID: rows[i].ID})
+	})
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+func (m *memActivityRepo) ListSecurityEvents(ctx context.Context, userID string, after *repoModels.ActivityCursor, limit int) ([]*models.SecurityEvent, pkgErrors.AppError) {
+	return nil, nil
+}
+
+// activityBefore reports whether (createdAt, id) sorts strictly below c
+func activityBefore(createdAt time.Time, id string, c *repoModels.ActivityCursor) bool {
+	if !createdAt.Equal(c.CreatedAt) {
+		return createdAt.Before(c.CreatedAt)
+	}
+	return id < c.ID
+}
+
+func TestListLoginHistory_PagesWithoutGapsOrRepeats(t *testing.T) {
+	base := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := &memActivityRepo{}
+	// Pairs of rows share a timestamp so the id tie-break is exercised at
+	// every page boundary
+	for i := 0; i < 11; i++ {
+		repo.history = append(repo.history, &models.LoginHistory{
+			ID:        fmt.Sprintf("00000000-0000-4000-8000-%012d", i),
+			UserID:    "user-a",
+			CreatedAt: base.Add(time.Duration(i/2) * time.Minute),
+		})
+	}
+	svc := NewSecurityService(repo, logger.NewNoop())
+
+	seen := map[string]bool{}
+	cursor := ""
+	pages := 0
+	for {
+		page, err := svc.ListLoginHistory(context.Background(), "user-a", cursor, 3)
+		if err != nil {
+			t.Fatalf("ListLoginHistory failed: %v", err)
+		}
+		pages++
+		for _, h := range page.Items {
+			if seen[h.ID] {
+				t.Fatalf("row %s returned twice", h.ID)
+			}
+			seen[h.ID] = true
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		cursor = *page.NextCursor
+	}
+
+	if len(seen) != 11 || pages != 4 {
+		t.Fatalf("saw %d rows over %d pages, want 11 over 4", len(seen), pages)
+	}
+}
+
+func TestListLoginHistory_RejectsBadCursorAndCapsLimit(t *testing.T) {
+	svc := NewSecurityService(&memActivityRepo{}, logger.NewNoop())
+
+	if _, err := svc.ListLoginHistory(context.Background(), "user-a", "not-a-cursor", 10); err == nil || err.Code() != pkgErrors.CodeInvalidArgument {
+		t.Fatalf("bad cursor err = %v, want %s", err, pkgErrors.CodeInvalidArgument)
+	}
+
+	page, err := svc.ListLoginHistory(context.Background(), "user-a", "", 10000)
+	if err != nil || page.Limit != 100 {
+		t.Fatalf("limit = %d, err %v; want capped at 100", page.Limit, err)
+	}
+}
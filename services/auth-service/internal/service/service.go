@@ -14,6 +14,7 @@ type AuthService struct {
 	tokenService   token.JWTTokenService
 	hashingService hashing.HashingService
 	cache          cache.Cache
+	resetNotifier  PasswordResetNotifier
 	cfg            *config.AuthConfig
 	log            logger.Logger
 	*repository.LoginHistoryRepo
@@ -29,6 +30,7 @@ type AuthServiceBuilder struct {
 	tokenService     token.JWTTokenService
 	hashingService   hashing.HashingService
 	cache            cache.Cache
+	resetNotifier    PasswordResetNotifier
 	cfg              *config.AuthConfig
 	log              logger.Logger
 }
@@ -58,6 +60,11 @@ func (b *AuthServiceBuilder) WithCache(cache cache.Cache) *AuthServiceBuilder {
 	return b
 }
 
+func (b *AuthServiceBuilder) WithPasswordResetNotifier(notifier PasswordResetNotifier) *AuthServiceBuilder {
+	b.resetNotifier = notifier
+	return b
+}
+
 func (b *AuthServiceBuilder) WithConfig(cfg *config.AuthConfig) *AuthServiceBuilder {
 	b.cfg = cfg
 	return b
@@ -82,6 +89,10 @@ func (b *AuthServiceBuilder) Build() *AuthService {
 		panic("Logger is required")
 	}
 
+	if b.resetNotifier == nil {
+		b.resetNotifier = NewLogPasswordResetNotifier(b.log)
+	}
+
 	b.log.Info("Building AuthService",
 		logger.String("service", "auth-service"),
 	)
@@ -92,6 +103,7 @@ func (b *AuthServiceBuilder) Build() *AuthService {
 		tokenService:     b.tokenService,
 		hashingService:   b.hashingService,
 		cache:            b.cache,
+		resetNotifier:    b.resetNotifier,
 		cfg:              b.cfg,
 		log:              b.log,
 	}
@@ -10,25 +10,28 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"shared/pkg/cache"
 	"shared/pkg/database/postgres/models"
 	pkgErrors "shared/pkg/errors"
 	"shared/pkg/logger"
 	"shared/server/common/token"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type SessionService struct {
-	repo         *repository.SessionRepo
+	repo         repository.SessionRepositoryInterface
 	tokenService token.JWTTokenService
+	denylist     *token.Denylist
 	cfg          config.CacheConfig
 	cache        cache.Cache
 	log          logger.Logger
 }
 
-func NewSessionService(repo *repository.SessionRepo, cache cache.Cache, token token.JWTTokenService, log logger.Logger, cfg config.CacheConfig) *SessionService {
+func NewSessionService(repo repository.SessionRepositoryInterface, cache cache.Cache, tokenService token.JWTTokenService, log logger.Logger, cfg config.CacheConfig) *SessionService {
 	if repo == nil {
 		panic("SessionRepo is required")
 	}
@@ -40,13 +43,23 @@ func NewSessionService(repo *repository.SessionRepo, cache cache.Cache, token to
 		logger.String("service", authErrors.ServiceName),
 	)
 
-	return &SessionService{
+	svc := &SessionService{
 		repo:         repo,
 		cache:        cache,
-		tokenService: token,
+		tokenService: tokenService,
 		log:          log,
 		cfg:          cfg,
 	}
+	if cache != nil {
+		svc.denylist = token.NewDenylist(cache)
+	}
+	return svc
+}
+
+// Denylist returns the revocation list shared with the JWT middleware, or nil
+// when caching is disabled.
+func (s *SessionService) Denylist() *token.Denylist {
+	return s.denylist
 }
 
 func (s *SessionService) generateSessionToken(userID string) (string, pkgErrors.AppError) {
@@ -214,6 +227,14 @@ func (s *SessionService) GetSessionByUserId(ctx context.Context, userID string)
 	return session, nil
 }
 
+func (s *SessionService) GetSessionByID(ctx context.Context, sessionID string) (*models.AuthSession, pkgErrors.AppError) {
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, err.WithService(authErrors.ServiceName)
+	}
+	return session, nil
+}
+
 func (s *SessionService) DeleteSessionByID(ctx context.Context, sessionID string) pkgErrors.AppError {
 	s.log.Info("Deleting session",
 		logger.String("service", authErrors.ServiceName),
@@ -232,3 +253,435 @@ func (s *SessionService) DeleteSessionByID(ctx context.Context, sessionID string
 
 	return nil
 }
+
+func rotatedRefreshTokenKey(jti string) string {
+	return fmt.Sprintf("refresh_token:rotated:%s", jti)
+}
+
+// RefreshSession exchanges a refresh token for a new access/refresh pair and
+// invalidates the presented token. Presenting a token that was already rotated
+// is treated as theft: the whole session is revoked.
+func (s *SessionService) RefreshSession(ctx context.Context, refreshToken string) (*token.TokenPair, pkgErrors.AppError) {
+	s.log.Debug("Refreshing session",
+		logger.String("service", authErrors.ServiceName),
+	)
+
+	claims, tokenErr := s.tokenService.Validate(ctx, refreshToken, token.TokenTypeRefresh)
+	if tokenErr != nil {
+		if errors.Is(tokenErr, token.ErrExpiredToken) {
+			return nil, pkgErrors.New(authErrors.CodeRefreshTokenExpired, "refresh token has expired").
+				WithService(authErrors.ServiceName)
+		}
+		return nil, pkgErrors.FromError(tokenErr, authErrors.CodeInvalidRefreshToken, "invalid refresh token").
+			WithService(authErrors.ServiceName)
+	}
+
+	if sessionID, rotated := s.rotatedSessionID(ctx, claims.ID); rotated {
+		return nil, s.handleRefreshTokenReuse(ctx, sessionID, claims)
+	}
+
+	session, err := s.repo.GetSessionByRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err.WithService(authErrors.ServiceName)
+	}
+	if session == nil {
+		s.log.Warn("Refresh token does not belong to an active session",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("user_id", claims.Subject),
+		)
+		return nil, pkgErrors.New(authErrors.CodeInvalidRefreshToken, "invalid refresh token").
+			WithService(authErrors.ServiceName)
+	}
+
+	pair, issueErr := s.tokenService.IssuePair(ctx, session.UserID, token.IssueOptions{
+		Metadata: map[string]interface{}{
+			"user_id":    session.UserID,
+			"session_id": session.ID,
+		},
+		Audience: claims.Audience,
+	})
+	if issueErr != nil {
+		return nil, pkgErrors.FromError(issueErr, authErrors.CodeTokenGenerationFailed, "failed to issue token pair").
+			WithService(authErrors.ServiceName).
+			WithDetail("session_id", session.ID)
+	}
+
+	rotated, err := s.repo.RotateRefreshToken(ctx, session.ID, refreshToken, pair.RefreshToken.Token, pair.RefreshToken.Claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, authErrors.CodeSessionUpdateFailed, "failed to rotate refresh token").
+			WithService(authErrors.ServiceName).
+			WithDetail("session_id", session.ID)
+	}
+	if !rotated {
+		// Another request rotated this token between our read and write.
+		return nil, s.handleRefreshTokenReuse(ctx, session.ID, claims)
+	}
+
+	s.markRefreshTokenRotated(ctx, session.ID, claims)
+
+	s.log.Info("Session refreshed",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("session_id", session.ID),
+		logger.String("user_id", session.UserID),
+	)
+
+	return &pair, nil
+}
+
+func (s *SessionService) rotatedSessionID(ctx context.Context, jti string) (string, bool) {
+	if s.cache == nil || jti == "" {
+		return "", false
+	}
+	value, err := s.cache.Get(ctx, rotatedRefreshTokenKey(jti))
+	if err != nil || value == nil {
+		return "", false
+	}
+	return string(value), true
+}
+
+func (s *SessionService) markRefreshTokenRotated(ctx context.Context, sessionID string, claims *token.Claims) {
+	if s.cache == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return
+	}
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return
+	}
+	if err := s.cache.Set(ctx, rotatedRefreshTokenKey(claims.ID), []byte(sessionID), ttl); err != nil {
+		s.log.Warn("Failed to record rotated refresh token (non-critical)",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("session_id", sessionID),
+			logger.Error(err),
+		)
+	}
+}
+
+func (s *SessionService) handleRefreshTokenReuse(ctx context.Context, sessionID string, claims *token.Claims) pkgErrors.AppError {
+	s.log.Warn("Refresh token reuse detected, revoking session",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("session_id", sessionID),
+		logger.String("user_id", claims.Subject),
+		logger.String("jti", claims.ID),
+	)
+
+	if err := s.revokeSession(ctx, sessionID, "refresh_token_reuse"); err != nil {
+		s.log.Error("Failed to revoke session after refresh token reuse",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("session_id", sessionID),
+			logger.Error(err),
+		)
+	}
+
+	category := "session"
+	description := "Previously rotated refresh token was presented again"
+	event := &models.SecurityEvent{
+		ID:            uuid.NewString(),
+		SessionID:     &sessionID,
+		EventType:     models.SecurityEventSuspiciousActivity,
+		EventCategory: &category,
+		Severity:      models.SecuritySeverityHigh,
+		Description:   &description,
+		IsSuspicious:  true,
+		CreatedAt:     time.Now(),
+	}
+	if claims.Subject != "" {
+		event.UserID = &claims.Subject
+	}
+	if err := s.repo.CreateSecurityEvent(ctx, event); err != nil {
+		s.log.Error("Failed to record security event",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("session_id", sessionID),
+			logger.Error(err),
+		)
+	}
+
+	return pkgErrors.New(authErrors.CodeSuspiciousActivity, "refresh token reuse detected, session revoked").
+		WithService(authErrors.ServiceName).
+		WithDetail("session_id", sessionID)
+}
+
+// RevokeSession marks the session revoked and denylists it until its tokens
+// would have expired anyway.
+func (s *SessionService) RevokeSession(ctx context.Context, sessionID string) pkgErrors.AppError {
+	return s.revokeSession(ctx, sessionID, "logout")
+}
+
+func (s *SessionService) revokeSession(ctx context.Context, sessionID string, reason string) pkgErrors.AppError {
+	s.log.Info("Revoking session",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("session_id", sessionID),
+		logger.String("reason", reason),
+	)
+
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return err.WithService(authErrors.ServiceName)
+	}
+	if session == nil {
+		return pkgErrors.New(authErrors.CodeSessionNotFound, "session not found").
+			WithService(authErrors.ServiceName).
+			WithDetail("session_id", sessionID)
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+
+	if err := s.repo.RevokeSession(ctx, sessionID, reason); err != nil {
+		return pkgErrors.FromError(err, authErrors.CodeSessionUpdateFailed, "failed to revoke session").
+			WithService(authErrors.ServiceName).
+			WithDetail("session_id", sessionID)
+	}
+
+	s.denylistSession(ctx, session)
+
+	s.log.Info("Session revoked",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("session_id", sessionID),
+		logger.String("user_id", session.UserID),
+	)
+	return nil
+}
+
+// RevokeAllUserSessions revokes every active session of the user and rejects
+// any token issued to them before now.
+func (s *SessionService) RevokeAllUserSessions(ctx context.Context, userID uuid.UUID) pkgErrors.AppError {
+	s.log.Info("Revoking all sessions for user",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("user_id", userID.String()),
+	)
+
+	sessions, err := s.repo.RevokeAllUserSessions(ctx, userID.String(), "logout_all")
+	if err != nil {
+		return pkgErrors.FromError(err, authErrors.CodeSessionUpdateFailed, "failed to revoke user sessions").
+			WithService(authErrors.ServiceName).
+			WithDetail("user_id", userID.String())
+	}
+
+	var longest time.Duration
+	for _, session := range sessions {
+		s.denylistSession(ctx, session)
+		if remaining := time.Until(session.ExpiresAt); remaining > longest {
+			longest = remaining
+		}
+	}
+	if s.denylist != nil && longest > 0 {
+		if err := s.denylist.RevokeUserTokensBefore(ctx, userID.String(), time.Now(), longest); err != nil {
+			s.log.Warn("Failed to denylist user tokens",
+				logger.String("service", authErrors.ServiceName),
+				logger.String("user_id", userID.String()),
+				logger.Error(err),
+			)
+		}
+	}
+
+	s.log.Info("All user sessions revoked",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("user_id", userID.String()),
+		logger.Int("session_count", len(sessions)),
+	)
+	return nil
+}
+
+// ListDevices groups the user's active sessions by device, most recently
+// active device first. Sessions created without a device ID are left out;
+// they can only be ended by logging out.
+func (s *SessionService) ListDevices(ctx context.Context, userID string) ([]*serviceModels.Device, pkgErrors.AppError) {
+	sessions, err := s.repo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err.WithService(authErrors.ServiceName)
+	}
+
+	var devices []*serviceModels.Device
+	byID := make(map[string]*serviceModels.Device)
+	for _, session := range sessions {
+		deviceID := derefString(session.DeviceID)
+		if deviceID == "" {
+			continue
+		}
+		device, ok := byID[deviceID]
+		if !ok {
+			// Sessions arrive most recently active first, so the first one
+			// seen describes the device
+			device = &serviceModels.Device{
+				DeviceID:     deviceID,
+				Name:         derefString(session.DeviceName),
+				Type:         derefString(session.DeviceType),
+				OS:           derefString(session.DeviceOS),
+				OSVersion:    derefString(session.DeviceOSVersion),
+				Model:        derefString(session.DeviceModel),
+				Browser:      derefString(session.BrowserName),
+				UserAgent:    derefString(session.UserAgent),
+				IPAddress:    session.IPAddress,
+				City:         derefString(session.IPCity),
+				Country:      derefString(session.IPCountry),
+				IsMobile:     session.IsMobile,
+				FirstSeenAt:  session.CreatedAt,
+				LastActiveAt: session.LastActivityAt,
+			}
+			byID[deviceID] = device
+			devices = append(devices, device)
+		}
+		device.SessionIDs = append(device.SessionIDs, session.ID)
+		device.IsTrusted = device.IsTrusted || session.IsTrustedDevice
+		if session.CreatedAt.Before(device.FirstSeenAt) {
+			device.FirstSeenAt = session.CreatedAt
+		}
+	}
+	return devices, nil
+}
+
+// RevokeDevice revokes every session the user holds on a device, denylists
+// the tokens carrying those sessions and announces the revocation so live
+// connections from the device are dropped. A device the user holds no active
+// session on is reported as not found, whoever it belongs to.
+func (s *SessionService) RevokeDevice(ctx context.Context, input serviceModels.RevokeDeviceInput) (int, pkgErrors.AppError) {
+	s.log.Info("Revoking device",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("user_id", input.UserID),
+		logger.String("device_id", input.DeviceID),
+	)
+
+	sessions, err := s.repo.RevokeDeviceSessions(ctx, input.UserID, input.DeviceID, "device_revoked")
+	if err != nil {
+		return 0, pkgErrors.FromError(err, authErrors.CodeSessionUpdateFailed, "failed to revoke device sessions").
+			WithService(authErrors.ServiceName).
+			WithDetail("device_id", input.DeviceID)
+	}
+	if len(sessions) == 0 {
+		return 0, pkgErrors.New(authErrors.CodeDeviceNotFound, "device not found").
+			WithService(authErrors.ServiceName).
+			WithDetail("device_id", input.DeviceID)
+	}
+
+	sessionIDs := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		s.denylistSession(ctx, session)
+		sessionIDs = append(sessionIDs, session.ID)
+	}
+
+	s.recordDeviceRevoked(ctx, input, sessionIDs)
+	s.announceDeviceRevoked(ctx, input, sessionIDs)
+
+	s.log.Info("Device revoked",
+		logger.String("service", authErrors.ServiceName),
+		logger.String("user_id", input.UserID),
+		logger.String("device_id", input.DeviceID),
+		logger.Int("session_count", len(sessions)),
+	)
+	return len(sessions), nil
+}
+
+func (s *SessionService) recordDeviceRevoked(ctx context.Context, input serviceModels.RevokeDeviceInput, sessionIDs []string) {
+	category := "device"
+	status := "revoked"
+	description := "Device signed out by the account owner"
+	event := &models.SecurityEvent{
+		UserID:        &input.UserID,
+		EventType:     models.SecurityEventSessionRevoked,
+		EventCategory: &category,
+		Severity:      models.SecuritySeverityMedium,
+		Status:        &status,
+		Description:   &description,
+		DeviceID:      &input.DeviceID,
+	}
+	if input.IPAddress != "" {
+		event.IPAddress = &input.IPAddress
+	}
+	if input.UserAgent != "" {
+		event.UserAgent = &input.UserAgent
+	}
+	if b, err := json.Marshal(map[string]any{"session_ids": sessionIDs}); err == nil {
+		metadata := json.RawMessage(b)
+		event.Metadata = &metadata
+	}
+	if err := s.RecordSecurityEvent(ctx, event); err != nil {
+		s.log.Error("Failed to record security event",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("device_id", input.DeviceID),
+			logger.Error(err),
+		)
+	}
+}
+
+// announceDeviceRevoked publishes the revocation for services holding live
+// connections. It is best effort: the denylist already rejects the device's
+// tokens on auth-service requests and ws-service upgrades, so a missed
+// announcement leaves a socket open only until the device next reconnects.
+func (s *SessionService) announceDeviceRevoked(ctx context.Context, input serviceModels.RevokeDeviceInput, sessionIDs []string) {
+	if s.cache == nil {
+		return
+	}
+	payload, err := json.Marshal(token.DeviceRevocation{
+		UserID:     input.UserID,
+		DeviceID:   input.DeviceID,
+		SessionIDs: sessionIDs,
+		Reason:     "device_revoked",
+		RevokedAt:  time.Now().UTC(),
+	})
+	if err == nil {
+		err = s.cache.Publish(ctx, token.DeviceRevokedChannel, payload)
+	}
+	if err != nil {
+		s.log.Warn("Failed to announce device revocation",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("device_id", input.DeviceID),
+			logger.Error(err),
+		)
+	}
+}
+
+func (s *SessionService) RecordSecurityEvent(ctx context.Context, event *models.SecurityEvent) pkgErrors.AppError {
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	if err := s.repo.CreateSecurityEvent(ctx, event); err != nil {
+		return err.WithService(authErrors.ServiceName)
+	}
+	return nil
+}
+
+// RevokeAccessToken denylists a single access token for the rest of its lifetime.
+func (s *SessionService) RevokeAccessToken(ctx context.Context, claims *token.Claims) {
+	if s.denylist == nil || claims == nil || claims.ExpiresAt == nil {
+		return
+	}
+	if err := s.denylist.RevokeToken(ctx, claims.ID, time.Until(claims.ExpiresAt.Time)); err != nil {
+		s.log.Warn("Failed to denylist access token",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("jti", claims.ID),
+			logger.Error(err),
+		)
+	}
+}
+
+func (s *SessionService) denylistSession(ctx context.Context, session *models.AuthSession) {
+	if s.denylist == nil {
+		return
+	}
+	if err := s.denylist.RevokeSession(ctx, session.ID, time.Until(session.ExpiresAt)); err != nil {
+		s.log.Warn("Failed to denylist session",
+			logger.String("service", authErrors.ServiceName),
+			logger.String("session_id", session.ID),
+			logger.Error(err),
+		)
+	}
+	if session.SessionToken != "" {
+		if err := s.cache.Delete(ctx, fmt.Sprintf("session_token:%s", session.SessionToken)); err != nil {
+			s.log.Debug("Failed to drop cached session token",
+				logger.String("service", authErrors.ServiceName),
+				logger.String("session_id", session.ID),
+				logger.Error(err),
+			)
+		}
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,445 @@
+package service
+
+import (
+	"auth-service/internal/config"
+	authErrors "auth-service/internal/errors"
+	repository "auth-service/internal/repo"
+	serviceModels "auth-service/internal/service/models"
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"shared/pkg/cache"
+	"shared/pkg/database/postgres/models"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+	"shared/server/common/token"
+
+	"github.com/google/uuid"
+)
+
+type fakeSessionRepo struct {
+	repository.SessionRepositoryInterface
+	mu       sync.Mutex
+	sessions map[string]*models.AuthSession
+	events   []*models.SecurityEvent
+}
+
+func newFakeSessionRepo(sessions ...*models.AuthSession) *fakeSessionRepo {
+	repo := &fakeSessionRepo{sessions: make(map[string]*models.AuthSession)}
+	for _, s := range sessions {
+		repo.sessions[s.ID] = s
+	}
+	return repo
+}
+
+func (f *fakeSessionRepo) GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*models.AuthSession, pkgErrors.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.sessions {
+		if s.RevokedAt == nil && s.RefreshToken != nil && *s.RefreshToken == refreshToken {
+			copied := *s
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeSessionRepo) GetSessionByID(ctx context.Context, sessionID string) (*models.AuthSession, pkgErrors.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.sessions[sessionID]; ok {
+		copied := *s
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeSessionRepo) RevokeAllUserSessions(ctx context.Context, userID string, reason string) ([]*models.AuthSession, pkgErrors.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var revoked []*models.AuthSession
+	now := time.Now()
+	for _, s := range f.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			s.RevokedAt = &now
+			s.RevokedReason = &reason
+			copied := *s
+			revoked = append(revoked, &copied)
+		}
+	}
+	return revoked, nil
+}
+
+func (f *fakeSessionRepo) RotateRefreshToken(ctx context.Context, sessionID, oldToken, newToken string, expiresAt time.Time) (bool, pkgErrors.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.sessions[sessionID]
+	if !ok || s.RevokedAt != nil || s.RefreshToken == nil || *s.RefreshToken != oldToken {
+		return false, nil
+	}
+	s.RefreshToken = &newToken
+	s.ExpiresAt = expiresAt
+	return true, nil
+}
+
+func (f *fakeSessionRepo) RevokeSession(ctx context.Context, sessionID string, reason string) pkgErrors.AppError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.sessions[sessionID]; ok {
+		now := time.Now()
+		s.RevokedAt = &now
+		s.RevokedReason = &reason
+	}
+	return nil
+}
+
+func (f *fakeSessionRepo) ListActiveSessions(ctx context.Context, userID string) ([]*models.AuthSession, pkgErrors.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var active []*models.AuthSession
+	for _, s := range f.sessions {
+		if s.UserID == userID && s.RevokedAt == nil && s.ExpiresAt.After(time.Now()) {
+			copied := *s
+			active = append(active, &copied)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].LastActivityAt.After(active[j].LastActivityAt) })
+	return active, nil
+}
+
+func (f *fakeSessionRepo) RevokeDeviceSessions(ctx context.Context, userID, deviceID, reason string) ([]*models.AuthSession, pkgErrors.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var revoked []*models.AuthSession
+	now := time.Now()
+	for _, s := range f.sessions {
+		if s.UserID == userID && s.DeviceID != nil && *s.DeviceID == deviceID && s.RevokedAt == nil {
+			s.RevokedAt = &now
+			s.RevokedReason = &reason
+			copied := *s
+			revoked = append(revoked, &copied)
+		}
+	}
+	return revoked, nil
+}
+
+func (f *fakeSessionRepo) CreateSecurityEvent(ctx context.Context, event *models.SecurityEvent) pkgErrors.AppError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, event)
+	return nil
+}
+
+type fakeCache struct {
+	cache.Cache
+	mu        sync.Mutex
+	items     map[string][]byte
+	published map[string][][]byte
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{items: make(map[string][]byte)}
+}
+
+func (f *fakeCache) Get(ctx context.Context, key string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.items[key]
+	if !ok {
+		return nil, cache.ErrNotFound
+	}
+	return v, nil
+}
+
+func (f *fakeCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) pkgErrors.AppError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.items[key] = value
+	return nil
+}
+
+func (f *fakeCache) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	found := make(map[string][]byte)
+	for _, key := range keys {
+		if v, ok := f.items[key]; ok {
+			found[key] = v
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeCache) Delete(ctx context.Context, key string) pkgErrors.AppError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.items, key)
+	return nil
+}
+
+func (f *fakeCache) Publish(ctx context.Context, channel string, payload []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.published == nil {
+		f.published = make(map[string][][]byte)
+	}
+	f.published[channel] = append(f.published[channel], payload)
+	return nil
+}
+
+func newTestTokenService(t *testing.T, clock func() time.Time) token.JWTTokenService {
+	t.Helper()
+	ks, err := token.NewStaticKeySet([]byte("test-secret-key-which-is-long-enough"))
+	if err != nil {
+		t.Fatalf("failed to create key set: %v", err)
+	}
+	svc, err := token.NewJWTTokenService(token.Config{
+		KeySet:          ks,
+		Issuer:          "auth-test",
+		Audience:        []string{"echo_users"},
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+		Clock:           clock,
+	})
+	if err != nil {
+		t.Fatalf("failed to create token service: %v", err)
+	}
+	return *svc
+}
+
+func newSessionWithRefreshToken(t *testing.T, tokens token.JWTTokenService) (*models.AuthSession, string) {
+	t.Helper()
+	refresh, err := tokens.IssueRefreshToken(context.Background(), "user-1", token.IssueOptions{})
+	if err != nil {
+		t.Fatalf("failed to issue refresh token: %v", err)
+	}
+	return &models.AuthSession{
+		ID:           "session-1",
+		UserID:       "user-1",
+		RefreshToken: &refresh.Token,
+		ExpiresAt:    refresh.Claims.ExpiresAt.Time,
+	}, refresh.Token
+}
+
+func TestRefreshSession_RotatesToken(t *testing.T) {
+	tokens := newTestTokenService(t, nil)
+	session, oldToken := newSessionWithRefreshToken(t, tokens)
+	repo := newFakeSessionRepo(session)
+	svc := NewSessionService(repo, newFakeCache(), tokens, logger.NewNoop(), config.CacheConfig{})
+
+	pair, err := svc.RefreshSession(context.Background(), oldToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if pair.RefreshToken.Token == oldToken {
+		t.Fatalf("expected a new refresh token")
+	}
+	if got := *repo.sessions["session-1"].RefreshToken; got != pair.RefreshToken.Token {
+		t.Fatalf("session not updated with rotated token")
+	}
+	if sid, _ := pair.AccessToken.Claims.Metadata["session_id"].(string); sid != "session-1" {
+		t.Fatalf("expected session_id metadata, got %q", sid)
+	}
+
+	// The rotated token must still be usable.
+	if _, err := svc.RefreshSession(context.Background(), pair.RefreshToken.Token); err != nil {
+		t.Fatalf("refresh with rotated token failed: %v", err)
+	}
+}
+
+func TestRefreshSession_ReuseRevokesSession(t *testing.T) {
+	tokens := newTestTokenService(t, nil)
+	session, oldToken := newSessionWithRefreshToken(t, tokens)
+	repo := newFakeSessionRepo(session)
+	svc := NewSessionService(repo, newFakeCache(), tokens, logger.NewNoop(), config.CacheConfig{})
+
+	pair, err := svc.RefreshSession(context.Background(), oldToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	_, err = svc.RefreshSession(context.Background(), oldToken)
+	if err == nil || err.Code() != authErrors.CodeSuspiciousActivity {
+		t.Fatalf("expected suspicious activity error, got %v", err)
+	}
+	if repo.sessions["session-1"].RevokedAt == nil {
+		t.Fatalf("expected session to be revoked")
+	}
+	if len(repo.events) != 1 || repo.events[0].EventType != models.SecurityEventSuspiciousActivity {
+		t.Fatalf("expected one suspicious activity event, got %d", len(repo.events))
+	}
+
+	// The legitimate holder's new token dies with the session.
+	_, err = svc.RefreshSession(context.Background(), pair.RefreshToken.Token)
+	if err == nil || err.Code() != authErrors.CodeInvalidRefreshToken {
+		t.Fatalf("expected invalid refresh token after revocation, got %v", err)
+	}
+}
+
+func TestRefreshSession_ExpiredToken(t *testing.T) {
+	past := func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	session, oldToken := newSessionWithRefreshToken(t, newTestTokenService(t, past))
+	repo := newFakeSessionRepo(session)
+	svc := NewSessionService(repo, newFakeCache(), newTestTokenService(t, nil), logger.NewNoop(), config.CacheConfig{})
+
+	_, err := svc.RefreshSession(context.Background(), oldToken)
+	if err == nil || err.Code() != authErrors.CodeRefreshTokenExpired {
+		t.Fatalf("expected expired refresh token error, got %v", err)
+	}
+	if *repo.sessions["session-1"].RefreshToken != oldToken {
+		t.Fatalf("expired token must not rotate the session")
+	}
+}
+
+func TestRevokeSession_DenylistsSessionTokens(t *testing.T) {
+	tokens := newTestTokenService(t, nil)
+	session, _ := newSessionWithRefreshToken(t, tokens)
+	repo := newFakeSessionRepo(session)
+	svc := NewSessionService(repo, newFakeCache(), tokens, logger.NewNoop(), config.CacheConfig{})
+
+	access, issueErr := tokens.IssueAccessToken(context.Background(), "user-1", token.IssueOptions{
+		Metadata: map[string]any{"session_id": "session-1"},
+	})
+	if issueErr != nil {
+		t.Fatalf("failed to issue access token: %v", issueErr)
+	}
+
+	if err := svc.RevokeSession(context.Background(), "session-1"); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if repo.sessions["session-1"].RevokedAt == nil {
+		t.Fatalf("expected session row to be revoked")
+	}
+	if revoked, _ := svc.Denylist().IsRevoked(context.Background(), access.Claims); !revoked {
+		t.Fatalf("expected session tokens to be denylisted")
+	}
+
+	if err := svc.RevokeSession(context.Background(), "missing"); err == nil || err.Code() != authErrors.CodeSessionNotFound {
+		t.Fatalf("expected session not found, got %v", err)
+	}
+}
+
+func TestRevokeAllUserSessions(t *testing.T) {
+	userID := uuid.New()
+	tokens := newTestTokenService(t, func() time.Time { return time.Now().Add(-time.Minute) })
+	expires := time.Now().Add(time.Hour)
+	repo := newFakeSessionRepo(
+		&models.AuthSession{ID: "a", UserID: userID.String(), ExpiresAt: expires},
+		&models.AuthSession{ID: "b", UserID: userID.String(), ExpiresAt: expires},
+		&models.AuthSession{ID: "c", UserID: "someone-else", ExpiresAt: expires},
+	)
+	svc := NewSessionService(repo, newFakeCache(), tokens, logger.NewNoop(), config.CacheConfig{})
+
+	access, issueErr := tokens.IssueAccessToken(context.Background(), userID.String(), token.IssueOptions{})
+	if issueErr != nil {
+		t.Fatalf("failed to issue access token: %v", issueErr)
+	}
+
+	if err := svc.RevokeAllUserSessions(context.Background(), userID); err != nil {
+		t.Fatalf("revoke all failed: %v", err)
+	}
+	if repo.sessions["a"].RevokedAt == nil || repo.sessions["b"].RevokedAt == nil {
+		t.Fatalf("expected all user sessions to be revoked")
+	}
+	if repo.sessions["c"].RevokedAt != nil {
+		t.Fatalf("other users' sessions must not be revoked")
+	}
+	if revoked, _ := svc.Denylist().IsRevoked(context.Background(), access.Claims); !revoked {
+		t.Fatalf("expected tokens issued before logout to be denylisted")
+	}
+}
+
+func deviceSession(id, userID, deviceID string, lastActive time.Time) *models.AuthSession {
+	city := "Porto"
+	return &models.AuthSession{
+		ID:             id,
+		UserID:         userID,
+		DeviceID:       &deviceID,
+		IPCity:         &city,
+		ExpiresAt:      time.Now().Add(time.Hour),
+		LastActivityAt: lastActive,
+		CreatedAt:      lastActive.Add(-time.Hour),
+	}
+}
+
+func TestListDevices_GroupsSessionsByDevice(t *testing.T) {
+	now := time.Now()
+	repo := newFakeSessionRepo(
+		deviceSession("s1", "user-1", "phone", now.Add(-time.Minute)),
+		deviceSession("s2", "user-1", "laptop", now.Add(-time.Hour)),
+		deviceSession("s3", "user-1", "phone", now.Add(-2*time.Hour)),
+		deviceSession("s4", "user-2", "tablet", now),
+	)
+	svc := NewSessionService(repo, newFakeCache(), newTestTokenService(t, nil), logger.NewNoop(), config.CacheConfig{})
+
+	devices, err := svc.ListDevices(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("list devices failed: %v", err)
+	}
+	if len(devices) != 2 || devices[0].DeviceID != "phone" || devices[1].DeviceID != "laptop" {
+		t.Fatalf("devices = %+v, want phone then laptop", devices)
+	}
+	phone := devices[0]
+	if len(phone.SessionIDs) != 2 || !phone.LastActiveAt.Equal(now.Add(-time.Minute)) || phone.City != "Porto" {
+		t.Fatalf("phone = %+v", phone)
+	}
+	if !phone.FirstSeenAt.Equal(now.Add(-3 * time.Hour)) {
+		t.Fatalf("phone first seen %v, want its oldest session", phone.FirstSeenAt)
+	}
+}
+
+func TestRevokeDevice_InvalidatesDeviceTokens(t *testing.T) {
+	tokens := newTestTokenService(t, nil)
+	repo := newFakeSessionRepo(
+		deviceSession("s1", "user-1", "phone", time.Now()),
+		deviceSession("s2", "user-1", "laptop", time.Now()),
+	)
+	cache := newFakeCache()
+	svc := NewSessionService(repo, cache, tokens, logger.NewNoop(), config.CacheConfig{})
+
+	issue := func(sessionID string) *token.Claims {
+		access, err := tokens.IssueAccessToken(context.Background(), "user-1", token.IssueOptions{
+			Metadata: map[string]any{"session_id": sessionID},
+		})
+		if err != nil {
+			t.Fatalf("failed to issue access token: %v", err)
+		}
+		return access.Claims
+	}
+	phoneToken, laptopToken := issue("s1"), issue("s2")
+
+	// Another user cannot revoke the device, and learns nothing about it
+	if _, err := svc.RevokeDevice(context.Background(), serviceModels.RevokeDeviceInput{UserID: "user-2", DeviceID: "phone"}); err == nil || err.Code() != authErrors.CodeDeviceNotFound {
+		t.Fatalf("expected device not found for another user, got %v", err)
+	}
+	if repo.sessions["s1"].RevokedAt != nil {
+		t.Fatalf("another user's request revoked the session")
+	}
+
+	revoked, err := svc.RevokeDevice(context.Background(), serviceModels.RevokeDeviceInput{UserID: "user-1", DeviceID: "phone", IPAddress: "203.0.113.7"})
+	if err != nil || revoked != 1 {
+		t.Fatalf("revoke device = %d, %v; want 1 session", revoked, err)
+	}
+	if isRevoked, _ := svc.Denylist().IsRevoked(context.Background(), phoneToken); !isRevoked {
+		t.Fatalf("expected the phone's tokens to be rejected after revocation")
+	}
+	if isRevoked, _ := svc.Denylist().IsRevoked(context.Background(), laptopToken); isRevoked {
+		t.Fatalf("the laptop's tokens must keep working")
+	}
+
+	if len(repo.events) != 1 || repo.events[0].EventType != models.SecurityEventSessionRevoked || *repo.events[0].DeviceID != "phone" {
+		t.Fatalf("expected one session_revoked event for the phone, got %d", len(repo.events))
+	}
+	notices := cache.published[token.DeviceRevokedChannel]
+	if len(notices) != 1 {
+		t.Fatalf("expected one revocation notice, got %d", len(notices))
+	}
+	var notice token.DeviceRevocation
+	if err := json.Unmarshal(notices[0], &notice); err != nil || notice.DeviceID != "phone" || notice.UserID != "user-1" {
+		t.Fatalf("notice = %+v, err %v", notice, err)
+	}
+}
@@ -8,14 +8,23 @@ import (
 	"location-service/service"
 	"net"
 	"net/http"
+	"runtime"
 	"shared/pkg/database"
+	pkgErrors "shared/pkg/errors"
 	"shared/pkg/logger"
 	"shared/pkg/logger/adapter"
 	"shared/pkg/utils"
 	"shared/server/env"
+	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	maxBatchLookupSize      = 500
+	maxBatchLookupBodyBytes = 1 << 20
+)
+
 type Server struct {
 	locationService *service.LocationService
 	host            string
@@ -28,8 +37,9 @@ type ErrorResponse struct {
 }
 
 type HealthResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Cache   *service.CacheStats `json:"cache,omitempty"`
 }
 
 func NewServer(svc *service.LocationService, host string, port string, log *logger.Logger) *Server {
@@ -50,6 +60,9 @@ func (s *Server) Start() error {
 
 	mux.HandleFunc("/health", s.handleHealth)
 	mux.HandleFunc("/lookup", s.handleLookup)
+	mux.HandleFunc("/lookup/batch", s.handleBatchLookup)
+	mux.HandleFunc("/timezone", s.handleTimezone)
+	mux.HandleFunc("/distance", s.handleDistance)
 
 	handler := loggingMiddleware(corsMiddleware(mux), s.log)
 
@@ -81,6 +94,9 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		Status:  "healthy",
 		Message: "Location service is running",
 	}
+	if stats, ok := s.locationService.CacheStats(); ok {
+		response.Cache = &stats
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -129,8 +145,7 @@ func (s *Server) handleLookup(w http.ResponseWriter, r *http.Request) {
 			logger.String("ip", ipStr),
 			logger.Error(err),
 		)
-		statusCode := locErrors.HTTPStatus(locErrors.CodeLookupFailed)
-		respondError(w, fmt.Sprintf("Lookup failed: %v", err), statusCode)
+		respondError(w, lookupErrorMessage(err), lookupErrorStatus(err))
 		return
 	}
 
@@ -139,7 +154,185 @@ func (s *Server) handleLookup(w http.ResponseWriter, r *http.Request) {
 		logger.String("ip", ipStr),
 	)
 
-	var response *model.LookupResult = &model.LookupResult{}
+	response := buildLookupResult(ipStr, result)
+	response.IsHosting = s.locationService.IsHostingOrg(response.ISP)
+
+	s.log.Info("Location lookup completed successfully",
+		logger.String("service", locErrors.ServiceName),
+		logger.String("ip", ipStr),
+		logger.String("city", response.City),
+		logger.String("country", response.Country),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleTimezone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ipStr := r.URL.Query().Get("ip")
+	if ipStr == "" {
+		respondError(w, "IP parameter is required", http.StatusBadRequest)
+		return
+	}
+	if net.ParseIP(ipStr) == nil {
+		s.log.Warn("Invalid IP address format",
+			logger.String("service", locErrors.ServiceName),
+			logger.String("ip", ipStr),
+			logger.String("error_code", locErrors.CodeInvalidIP),
+		)
+		respondError(w, "Invalid IP address format", http.StatusBadRequest)
+		return
+	}
+
+	timezone, err := s.locationService.LookupTimezone(ipStr)
+	if err != nil {
+		s.log.Warn("Timezone lookup failed",
+			logger.String("service", locErrors.ServiceName),
+			logger.String("ip", ipStr),
+			logger.Error(err),
+		)
+		statusCode := locErrors.HTTPStatus(locErrors.CodeLookupFailed)
+		message := "Timezone lookup failed"
+		if appErr, ok := err.(pkgErrors.AppError); ok {
+			statusCode = locErrors.HTTPStatus(appErr.Code())
+			message = appErr.Message()
+		}
+		respondError(w, message, statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(model.TimezoneResult{IP: ipStr, Timezone: timezone})
+}
+
+func (s *Server) handleDistance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	points := make(map[string]*model.LookupResult, 2)
+	for _, param := range []string{"from", "to"} {
+		ipStr := query.Get(param)
+		if ipStr == "" {
+			respondError(w, fmt.Sprintf("%s parameter is required", param), http.StatusBadRequest)
+			return
+		}
+		if net.ParseIP(ipStr) == nil {
+			respondError(w, fmt.Sprintf("Invalid IP address format for %s", param), http.StatusBadRequest)
+			return
+		}
+
+		result, err := s.locationService.Lookup(ipStr)
+		if err != nil {
+			s.log.Error("Location lookup failed",
+				logger.String("service", locErrors.ServiceName),
+				logger.String("ip", ipStr),
+				logger.Error(err),
+			)
+			respondError(w, fmt.Sprintf("%s: %s", param, lookupErrorMessage(err)), lookupErrorStatus(err))
+			return
+		}
+		if result.City == nil || result.City.Location == nil {
+			respondError(w, fmt.Sprintf("No location data for %s IP %s", param, ipStr), http.StatusUnprocessableEntity)
+			return
+		}
+		points[param] = buildLookupResult(ipStr, result)
+	}
+
+	from, to := points["from"], points["to"]
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(model.DistanceResult{
+		FromIP:     from.IP,
+		ToIP:       to.IP,
+		FromCity:   from.City,
+		ToCity:     to.City,
+		DistanceKm: service.HaversineKm(from.Latitude, from.Longitude, to.Latitude, to.Longitude),
+	})
+}
+
+func (s *Server) handleBatchLookup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req model.BatchLookupRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchLookupBodyBytes)).Decode(&req); err != nil {
+		s.log.Warn("Invalid batch lookup body",
+			logger.String("service", locErrors.ServiceName),
+			logger.Error(err),
+		)
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.IPs) == 0 {
+		respondError(w, "ips must not be empty", http.StatusBadRequest)
+		return
+	}
+	if len(req.IPs) > maxBatchLookupSize {
+		respondError(w, fmt.Sprintf("at most %d ips are allowed per request", maxBatchLookupSize), http.StatusBadRequest)
+		return
+	}
+
+	s.log.Info("Batch location lookup request received",
+		logger.String("service", locErrors.ServiceName),
+		logger.Int("count", len(req.IPs)),
+	)
+
+	results := make([]model.LookupResult, len(req.IPs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := runtime.NumCPU()
+	if workers > len(req.IPs) {
+		workers = len(req.IPs)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = s.lookupOne(strings.TrimSpace(req.IPs[idx]))
+			}
+		}()
+	}
+	for idx := range req.IPs {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (s *Server) lookupOne(ipStr string) model.LookupResult {
+	if net.ParseIP(ipStr) == nil {
+		return model.LookupResult{IP: ipStr, Error: "Invalid IP address format"}
+	}
+	result, err := s.locationService.Lookup(ipStr)
+	if err != nil {
+		s.log.Warn("Batch location lookup failed for IP",
+			logger.String("service", locErrors.ServiceName),
+			logger.String("ip", ipStr),
+			logger.Error(err),
+		)
+		return model.LookupResult{IP: ipStr, Error: lookupErrorMessage(err)}
+	}
+	lookup := buildLookupResult(ipStr, result)
+	lookup.IsHosting = s.locationService.IsHostingOrg(lookup.ISP)
+	return *lookup
+}
+
+func buildLookupResult(ipStr string, result *model.LocationResult) *model.LookupResult {
+	response := &model.LookupResult{}
 	if result.City != nil {
 		response.City = result.City.GetCityName("en")
 		response.State = result.City.GetSubdivisionName("en")
@@ -161,16 +354,24 @@ func (s *Server) handleLookup(w http.ResponseWriter, r *http.Request) {
 		response.ISP = result.ASN.AutonomousSystemOrganization
 	}
 	response.IP = ipStr
+	return response
+}
 
-	s.log.Info("Location lookup completed successfully",
-		logger.String("service", locErrors.ServiceName),
-		logger.String("ip", ipStr),
-		logger.String("city", response.City),
-		logger.String("country", response.Country),
-	)
+// lookupErrorStatus maps a lookup error to the status of its error code;
+// errors without one are treated as lookup failures
+func lookupErrorStatus(err error) int {
+	if appErr, ok := err.(pkgErrors.AppError); ok {
+		return locErrors.HTTPStatus(appErr.Code())
+	}
+	return locErrors.HTTPStatus(locErrors.CodeLookupFailed)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// lookupErrorMessage is the client-facing message for a lookup error
+func lookupErrorMessage(err error) string {
+	if appErr, ok := err.(pkgErrors.AppError); ok {
+		return appErr.Message()
+	}
+	return fmt.Sprintf("Lookup failed: %v", err)
 }
 
 func respondError(w http.ResponseWriter, message string, statusCode int) {
@@ -202,7 +403,7 @@ func loggingMiddleware(next http.Handler, log logger.Logger) http.Handler {
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 		if r.Method == "OPTIONS" {
@@ -219,6 +420,7 @@ func main() {
 	log, err := adapter.NewZap(logger.Config{
 		Level:      logger.GetLoggerLevel(),
 		Output:     logger.GetLoggerOutput(),
+		File:       logger.GetLoggerFile(),
 		Format:     logger.GetLoggerFormat(),
 		TimeFormat: logger.GetLoggerTimeFormat(),
 		Service:    "location-service",
@@ -241,11 +443,26 @@ func main() {
 		logger.String("port", port),
 	)
 
+	cacheTTL, err := time.ParseDuration(env.GetEnv("LOOKUP_CACHE_TTL", "1h"))
+	if err != nil {
+		log.Fatal("Invalid LOOKUP_CACHE_TTL:", logger.Error(err))
+	}
+
+	// Negative disables reloading the GeoIP databases
+	geoIPReloadInterval, err := time.ParseDuration(env.GetEnv("GEOIP_RELOAD_INTERVAL", "5m"))
+	if err != nil {
+		log.Fatal("Invalid GEOIP_RELOAD_INTERVAL:", logger.Error(err))
+	}
+
 	cfg := service.Config{
-		CityDBPath:    cityDBPath,
-		ASNDBPath:     asnDBPath,
-		CountryDBPath: countryDBPath,
-		Logger:        log,
+		CityDBPath:          cityDBPath,
+		ASNDBPath:           asnDBPath,
+		CountryDBPath:       countryDBPath,
+		Logger:              log,
+		GeoIPReloadInterval: geoIPReloadInterval,
+		CacheSize:           utils.StringToMustInt(env.GetEnv("LOOKUP_CACHE_SIZE", "10000")),
+		CacheTTL:            cacheTTL,
+		HostingListPath:     env.GetEnv("HOSTING_ASN_LIST_PATH", ""),
 	}
 
 	svc, err := service.NewLocationService(cfg, database.Config{
@@ -16,6 +16,25 @@ type LookupResult struct {
 	Timezone      string  `json:"timezone,omitempty"`
 	ISP           string  `json:"isp,omitempty"`
 	IP            string  `json:"ip,omitempty"`
+	IsHosting     bool    `json:"is_hosting"`
+	Error         string  `json:"error,omitempty"`
+}
+
+type TimezoneResult struct {
+	IP       string `json:"ip"`
+	Timezone string `json:"timezone"`
+}
+
+type DistanceResult struct {
+	FromIP     string  `json:"from_ip"`
+	ToIP       string  `json:"to_ip"`
+	FromCity   string  `json:"from_city"`
+	ToCity     string  `json:"to_city"`
+	DistanceKm float64 `json:"distance_km"`
+}
+
+type BatchLookupRequest struct {
+	IPs []string `json:"ips"`
 }
 
 type City struct {
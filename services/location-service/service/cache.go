@@ -0,0 +1,123 @@
+package service
+
+import (
+	"container/list"
+	"location-service/model"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============================================================================
+// Lookup Cache
+// ============================================================================
+
+type CacheStats struct {
+	Size   int    `json:"size"`
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+type lookupCacheEntry struct {
+	ip        string
+	result    *model.LocationResult
+	expiresAt time.Time
+}
+
+// lookupCache is a fixed-size LRU of lookup results keyed by IP string.
+// purge bumps its generation so results computed before a purge, from a
+// GeoIP database that has since been replaced, are not stored afterwards.
+type lookupCache struct {
+	mu         sync.Mutex
+	capacity   int
+	ttl        time.Duration
+	order      *list.List
+	items      map[string]*list.Element
+	generation uint64
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+}
+
+func newLookupCache(capacity int, ttl time.Duration) *lookupCache {
+	return &lookupCache{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+func (c *lookupCache) get(ip string) (*model.LocationResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[ip]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	entry := elem.Value.(*lookupCacheEntry)
+	if c.ttl > 0 && time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.items, ip)
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	c.hits.Add(1)
+	return entry.result, true
+}
+
+// current returns the generation to pass to set for a lookup starting now
+func (c *lookupCache) current() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// set stores result unless the cache was purged since generation was read
+func (c *lookupCache) set(ip string, result *model.LocationResult, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	expiresAt := time.Now().Add(c.ttl)
+	if elem, ok := c.items[ip]; ok {
+		entry := elem.Value.(*lookupCacheEntry)
+		entry.result = result
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[ip] = c.order.PushFront(&lookupCacheEntry{ip: ip, result: result, expiresAt: expiresAt})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lookupCacheEntry).ip)
+	}
+}
+
+// purge drops every entry, leaving hit and miss counts as they are
+func (c *lookupCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element, c.capacity)
+	c.generation++
+}
+
+func (c *lookupCache) stats() CacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+	return CacheStats{
+		Size:   size,
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"location-service/model"
+	"shared/pkg/database"
+	"shared/pkg/logger"
+)
+
+// slowDB stands in for the lookup table with a fixed round-trip cost.
+type slowDB struct {
+	database.Database
+	delay   time.Duration
+	queries int
+}
+
+func (d *slowDB) FindOne(ctx context.Context, model database.Model, query string, args ...interface{}) *database.DBError {
+	d.queries++
+	time.Sleep(d.delay)
+	return database.NotFoundError("ip_addresses", "ip_address")
+}
+
+func (d *slowDB) Insert(ctx context.Context, model database.Model) (*string, *database.DBError) {
+	return nil, nil
+}
+
+func newTestService(db database.Database, cacheSize int) *LocationService {
+	svc := &LocationService{db: db, log: logger.NewNoop()}
+	if cacheSize > 0 {
+		svc.cache = newLookupCache(cacheSize, time.Minute)
+	}
+	return svc
+}
+
+func TestLookupCache(t *testing.T) {
+	db := &slowDB{}
+	svc := newTestService(db, 2)
+
+	for i := 0; i < 3; i++ {
+		if _, err := svc.Lookup("8.8.8.8"); err != nil {
+			t.Fatalf("Lookup: %v", err)
+		}
+	}
+	if db.queries != 1 {
+		t.Fatalf("expected 1 backend query, got %d", db.queries)
+	}
+
+	if _, err := svc.Lookup("not-an-ip"); err == nil {
+		t.Fatalf("expected invalid IP error")
+	}
+	svc.Lookup("1.1.1.1")
+	svc.Lookup("9.9.9.9")
+
+	stats, ok := svc.CacheStats()
+	if !ok {
+		t.Fatalf("expected cache stats")
+	}
+	if stats.Size != 2 || stats.Hits != 2 || stats.Misses != 3 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if _, ok := svc.cache.get("not-an-ip"); ok {
+		t.Fatalf("invalid IP must not be cached")
+	}
+	if _, ok := svc.cache.get("8.8.8.8"); ok {
+		t.Fatalf("expected least recently used entry to be evicted")
+	}
+}
+
+func TestLookupCachePurgeDropsStaleResults(t *testing.T) {
+	cache := newLookupCache(4, 0)
+	cache.set("8.8.8.8", &model.LocationResult{IP: "8.8.8.8"}, cache.current())
+
+	// A lookup that started before the purge must not repopulate the cache
+	stale := cache.current()
+	cache.purge()
+	if _, ok := cache.get("8.8.8.8"); ok {
+		t.Fatalf("expected purge to drop cached results")
+	}
+	cache.set("1.1.1.1", &model.LocationResult{IP: "1.1.1.1"}, stale)
+	if _, ok := cache.get("1.1.1.1"); ok {
+		t.Fatalf("result from before the purge was cached")
+	}
+
+	cache.set("1.1.1.1", &model.LocationResult{IP: "1.1.1.1"}, cache.current())
+	if _, ok := cache.get("1.1.1.1"); !ok {
+		t.Fatalf("expected result from after the purge to be cached")
+	}
+}
+
+func BenchmarkLookupHotKey(b *testing.B) {
+	for _, bc := range []struct {
+		name      string
+		cacheSize int
+	}{
+		{"uncached", 0},
+		{"cached", 1024},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			svc := newTestService(&slowDB{delay: 50 * time.Microsecond}, bc.cacheSize)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := svc.Lookup("8.8.8.8"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
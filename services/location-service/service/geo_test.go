@@ -0,0 +1,27 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHaversineKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"London to Paris", 51.5074, -0.1278, 48.8566, 2.3522, 343.5},
+		{"New York to Los Angeles", 40.7128, -74.0060, 34.0522, -118.2437, 3935.7},
+		{"Sydney to Tokyo", -33.8688, 151.2093, 35.6762, 139.6503, 7823.0},
+		{"same point", 12.34, 56.78, 12.34, 56.78, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HaversineKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.want) > tt.want*0.005+0.1 {
+				t.Fatalf("expected ~%.1f km, got %.1f km", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"errors"
+	"io/fs"
+	locErrors "location-service/errors"
+	"net"
+	"os"
+	"shared/pkg/logger"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// ============================================================================
+// GeoIP Databases
+// ============================================================================
+
+const (
+	DefaultGeoIPReloadInterval = 5 * time.Minute
+
+	// geoIPOpenAttempts bounds how often a changed file is reopened before the
+	// reload is abandoned until the next check
+	geoIPOpenAttempts = 3
+)
+
+// geoIPOpenRetryDelay is the pause between attempts to open a changed file,
+// giving a copy that is still being written time to finish
+var geoIPOpenRetryDelay = 2 * time.Second
+
+// geoIPDB is a MaxMind database that is reopened when its file changes.
+// Lookups hold a read lock, so a reload waits for them before swapping in the
+// new reader and closing the old one; onReload then runs so callers can drop
+// anything derived from the old data. Replace files by renaming a complete
+// copy over them: the reader maps the file, so writing it in place corrupts
+// lookups still served by the old reader.
+type geoIPDB struct {
+	name     string
+	path     string
+	onReload func()
+	log      logger.Logger
+	mu       sync.RWMutex
+	reader   *maxminddb.Reader
+	modTime  time.Time
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// openGeoIPDB opens the database at path and checks it for changes every
+// interval; zero uses DefaultGeoIPReloadInterval and a negative interval
+// disables reloading. onReload, when set, is called after every reload that
+// replaces the reader, but not for the initial open.
+func openGeoIPDB(name, path string, interval time.Duration, onReload func(), log logger.Logger) (*geoIPDB, error) {
+	d := &geoIPDB{
+		name:     name,
+		path:     path,
+		onReload: onReload,
+		log:      log,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if _, err := d.reload(); err != nil {
+		return nil, err
+	}
+	if interval < 0 {
+		close(d.done)
+		return d, nil
+	}
+	if interval == 0 {
+		interval = DefaultGeoIPReloadInterval
+	}
+	go d.watch(interval)
+	return d, nil
+}
+
+func (d *geoIPDB) Lookup(ip net.IP, result any) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.reader.Lookup(ip, result)
+}
+
+// reload reopens the database if its modification time changed. The current
+// reader keeps serving until the new file opens cleanly.
+func (d *geoIPDB) reload() (bool, error) {
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return false, err
+	}
+
+	d.mu.RLock()
+	unchanged := info.ModTime().Equal(d.modTime)
+	d.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	reader, err := d.open()
+	if err != nil {
+		return false, err
+	}
+
+	d.mu.Lock()
+	old := d.reader
+	d.reader = reader
+	d.modTime = info.ModTime()
+	d.mu.Unlock()
+
+	if old != nil {
+		if err := old.Close(); err != nil {
+			d.log.Warn("Failed to close replaced GeoIP database",
+				logger.String("service", locErrors.ServiceName),
+				logger.String("database", d.name),
+				logger.Error(err),
+			)
+		}
+		if d.onReload != nil {
+			d.onReload()
+		}
+	}
+
+	d.log.Info("GeoIP database loaded",
+		logger.String("service", locErrors.ServiceName),
+		logger.String("database", d.name),
+		logger.String("path", d.path),
+		logger.String("type", reader.Metadata.DatabaseType),
+		logger.Time("build_date", time.Unix(int64(reader.Metadata.BuildEpoch), 0).UTC()),
+	)
+	return true, nil
+}
+
+// open reads the file, retrying when it fails to parse in case it is still
+// being written
+func (d *geoIPDB) open() (*maxminddb.Reader, error) {
+	for attempt := 1; ; attempt++ {
+		reader, err := maxminddb.Open(d.path)
+		if err == nil {
+			return reader, nil
+		}
+		if errors.Is(err, fs.ErrNotExist) || attempt == geoIPOpenAttempts {
+			return nil, err
+		}
+
+		d.log.Debug("GeoIP database not readable yet, retrying",
+			logger.String("service", locErrors.ServiceName),
+			logger.String("database", d.name),
+			logger.Int("attempt", attempt),
+			logger.Error(err),
+		)
+		select {
+		case <-d.stop:
+			return nil, err
+		case <-time.After(geoIPOpenRetryDelay):
+		}
+	}
+}
+
+func (d *geoIPDB) watch(interval time.Duration) {
+	defer close(d.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			if _, err := d.reload(); err != nil {
+				d.log.Warn("Failed to reload GeoIP database",
+					logger.String("service", locErrors.ServiceName),
+					logger.String("database", d.name),
+					logger.String("path", d.path),
+					logger.String("error_code", locErrors.CodeDatabaseLoadFailed),
+					logger.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// Close stops reloading and closes the current reader
+func (d *geoIPDB) Close() error {
+	close(d.stop)
+	<-d.done
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.reader.Close()
+}
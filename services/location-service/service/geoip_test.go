@@ -0,0 +1,263 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"location-service/model"
+	"shared/pkg/logger"
+)
+
+// writeTestMMDB writes an IPv6 MaxMind DB whose every address maps to record.
+// Values may be maps, strings, uint16/uint32/uint64 and float64.
+func writeTestMMDB(t *testing.T, path string, record map[string]any) {
+	t.Helper()
+	writeFamilyMMDB(t, path, record, record)
+}
+
+// writeFamilyMMDB writes an IPv6 MaxMind DB that maps the IPv4 space
+// (::/96) to ipv4 and every other address to ipv6
+func writeFamilyMMDB(t *testing.T, path string, ipv4, ipv6 map[string]any) {
+	t.Helper()
+
+	v4Data, v6Data := encodeMMDB(ipv4), encodeMMDB(ipv6)
+
+	// A chain of 96 nodes follows the zero bits of ::/96. Every right branch
+	// leads to the ipv6 record; the last left branch to the ipv4 one. Data
+	// pointers are node count + 16 byte separator + offset in the data section.
+	const nodeCount = 96
+	v4Pointer := uint32(nodeCount + 16)
+	v6Pointer := v4Pointer + uint32(len(v4Data))
+
+	var db bytes.Buffer
+	for i := 0; i < nodeCount; i++ {
+		left := uint32(i + 1)
+		if i == nodeCount-1 {
+			left = v4Pointer
+		}
+		writeRecord24(&db, left)
+		writeRecord24(&db, v6Pointer)
+	}
+	db.Write(make([]byte, 16))
+	db.Write(v4Data)
+	db.Write(v6Data)
+	db.WriteString("\xab\xcd\xefMaxMind.com")
+	db.Write(encodeMMDB(map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(time.Now().Unix()),
+		"database_type":               "Test-City",
+		"description":                 map[string]any{"en": "test"},
+		"ip_version":                  uint16(6),
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+	}))
+
+	// Rename a complete copy into place, as operators are told to
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, db.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeRecord24(buf *bytes.Buffer, v uint32) {
+	buf.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+}
+
+func encodeMMDB(v any) []byte {
+	var buf bytes.Buffer
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte(7<<5 | byte(len(v)))
+		for _, k := range keys {
+			buf.Write(encodeMMDB(k))
+			buf.Write(encodeMMDB(v[k]))
+		}
+	case string:
+		buf.WriteByte(2<<5 | byte(len(v)))
+		buf.WriteString(v)
+	case float64:
+		buf.WriteByte(3<<5 | 8)
+		binary.Write(&buf, binary.BigEndian, math.Float64bits(v))
+	case uint16:
+		buf.WriteByte(5<<5 | 2)
+		binary.Write(&buf, binary.BigEndian, v)
+	case uint32:
+		buf.WriteByte(6<<5 | 4)
+		binary.Write(&buf, binary.BigEndian, v)
+	case uint64:
+		// Extended type 9
+		buf.WriteByte(8)
+		buf.WriteByte(9 - 7)
+		binary.Write(&buf, binary.BigEndian, v)
+	default:
+		panic("encodeMMDB: unsupported value")
+	}
+	return buf.Bytes()
+}
+
+func cityRecord(name string) map[string]any {
+	return map[string]any{
+		"city": map[string]any{"names": map[string]any{"en": name}},
+	}
+}
+
+func bumpModTime(t *testing.T, path string) {
+	t.Helper()
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func lookupCityName(t *testing.T, svc *LocationService, ip string) string {
+	t.Helper()
+	city, err := svc.LookupCity(ip)
+	if err != nil {
+		t.Fatalf("LookupCity(%s): %v", ip, err)
+	}
+	return city.City.Names["en"]
+}
+
+func TestGeoIPDBReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeTestMMDB(t, path, cityRecord("Oldtown"))
+
+	db, err := openGeoIPDB("city", path, -1, nil, logger.NewNoop())
+	if err != nil {
+		t.Fatalf("openGeoIPDB: %v", err)
+	}
+	defer db.Close()
+	svc := &LocationService{cityDB: db, log: logger.NewNoop()}
+
+	if got := lookupCityName(t, svc, "8.8.8.8"); got != "Oldtown" {
+		t.Fatalf("city = %q, want Oldtown", got)
+	}
+
+	if reloaded, err := db.reload(); err != nil || reloaded {
+		t.Fatalf("unchanged file reloaded=%v err=%v, want no reload", reloaded, err)
+	}
+
+	writeTestMMDB(t, path, cityRecord("Newtown"))
+	bumpModTime(t, path)
+	if reloaded, err := db.reload(); err != nil || !reloaded {
+		t.Fatalf("expected reload, got reloaded=%v err=%v", reloaded, err)
+	}
+	if got := lookupCityName(t, svc, "2001:4860:4860::8888"); got != "Newtown" {
+		t.Fatalf("city after reload = %q, want Newtown", got)
+	}
+}
+
+func TestGeoIPDBKeepsServingOnHalfWrittenFile(t *testing.T) {
+	delay := geoIPOpenRetryDelay
+	geoIPOpenRetryDelay = time.Millisecond
+	defer func() { geoIPOpenRetryDelay = delay }()
+
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeTestMMDB(t, path, cityRecord("Oldtown"))
+
+	db, err := openGeoIPDB("city", path, -1, nil, logger.NewNoop())
+	if err != nil {
+		t.Fatalf("openGeoIPDB: %v", err)
+	}
+	defer db.Close()
+
+	// A copy cut off before its metadata section
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".partial", data[:len(data)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path+".partial", path); err != nil {
+		t.Fatal(err)
+	}
+	bumpModTime(t, path)
+
+	if _, err := db.reload(); err == nil {
+		t.Fatalf("expected a truncated file to fail to load")
+	}
+	var city model.CityRecord
+	if err := db.Lookup(net.ParseIP("8.8.8.8"), &city); err != nil || city.City.Names["en"] != "Oldtown" {
+		t.Fatalf("old reader stopped serving: city=%q err=%v", city.City.Names["en"], err)
+	}
+
+	writeTestMMDB(t, path, cityRecord("Newtown"))
+	bumpModTime(t, path)
+	if reloaded, err := db.reload(); err != nil || !reloaded {
+		t.Fatalf("expected the completed file to load, got reloaded=%v err=%v", reloaded, err)
+	}
+}
+
+func TestGeoIPDBWatcherPicksUpNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeTestMMDB(t, path, cityRecord("Oldtown"))
+
+	db, err := openGeoIPDB("city", path, 5*time.Millisecond, nil, logger.NewNoop())
+	if err != nil {
+		t.Fatalf("openGeoIPDB: %v", err)
+	}
+	defer db.Close()
+	svc := &LocationService{cityDB: db, log: logger.NewNoop()}
+
+	writeTestMMDB(t, path, cityRecord("Newtown"))
+	bumpModTime(t, path)
+
+	deadline := time.Now().Add(time.Second)
+	for lookupCityName(t, svc, "8.8.8.8") != "Newtown" {
+		if time.Now().After(deadline) {
+			t.Fatalf("watcher did not reload the database")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestGeoIPDBReloadPurgesLookupCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeTestMMDB(t, path, cityRecord("Oldtown"))
+
+	svc := newTestService(&slowDB{}, 16)
+	db, err := openGeoIPDB("city", path, -1, svc.purgeCache, logger.NewNoop())
+	if err != nil {
+		t.Fatalf("openGeoIPDB: %v", err)
+	}
+	defer db.Close()
+	svc.cityDB = db
+
+	lookup := func() string {
+		t.Helper()
+		result, err := svc.Lookup("8.8.8.8")
+		if err != nil {
+			t.Fatalf("Lookup: %v", err)
+		}
+		return result.City.City.Names["en"]
+	}
+	if got := lookup(); got != "Oldtown" {
+		t.Fatalf("city = %q, want Oldtown", got)
+	}
+
+	writeTestMMDB(t, path, cityRecord("Newtown"))
+	bumpModTime(t, path)
+	if reloaded, err := db.reload(); err != nil || !reloaded {
+		t.Fatalf("expected reload, got reloaded=%v err=%v", reloaded, err)
+	}
+	if got := lookup(); got != "Newtown" {
+		t.Fatalf("cached city after reload = %q, want Newtown", got)
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"bufio"
+	locErrors "location-service/errors"
+	"os"
+	"shared/pkg/logger"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Hosting / Anonymizer Detection
+// ============================================================================
+
+const DefaultHostingListReloadInterval = 30 * time.Second
+
+// hostingMatcher flags ASN organizations that contain any of the configured
+// patterns (case-insensitive). The pattern file is re-read when it changes.
+type hostingMatcher struct {
+	path     string
+	log      logger.Logger
+	mu       sync.RWMutex
+	patterns []string
+	modTime  time.Time
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func newHostingMatcher(path string, interval time.Duration, log logger.Logger) (*hostingMatcher, error) {
+	m := &hostingMatcher{
+		path: path,
+		log:  log,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if _, err := m.reload(); err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = DefaultHostingListReloadInterval
+	}
+	go m.watch(interval)
+	return m, nil
+}
+
+func (m *hostingMatcher) matches(org string) bool {
+	if org == "" {
+		return false
+	}
+	org = strings.ToLower(org)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, pattern := range m.patterns {
+		if strings.Contains(org, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// reload re-reads the pattern file if its modification time changed.
+func (m *hostingMatcher) reload() (bool, error) {
+	info, err := os.Stat(m.path)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.RLock()
+	unchanged := info.ModTime().Equal(m.modTime)
+	m.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	patterns, err := readHostingPatterns(m.path)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	m.patterns = patterns
+	m.modTime = info.ModTime()
+	m.mu.Unlock()
+	return true, nil
+}
+
+func (m *hostingMatcher) watch(interval time.Duration) {
+	defer close(m.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			reloaded, err := m.reload()
+			if err != nil {
+				m.log.Warn("Failed to reload hosting ASN list",
+					logger.String("service", locErrors.ServiceName),
+					logger.String("path", m.path),
+					logger.Error(err),
+				)
+				continue
+			}
+			if reloaded {
+				m.log.Info("Hosting ASN list reloaded",
+					logger.String("service", locErrors.ServiceName),
+					logger.String("path", m.path),
+				)
+			}
+		}
+	}
+}
+
+func (m *hostingMatcher) close() {
+	close(m.stop)
+	<-m.done
+}
+
+// readHostingPatterns reads one organization pattern per line, skipping blank
+// lines and lines starting with '#'.
+func readHostingPatterns(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, strings.ToLower(line))
+	}
+	return patterns, scanner.Err()
+}
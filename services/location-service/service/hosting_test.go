@@ -0,0 +1,46 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shared/pkg/logger"
+)
+
+func TestHostingMatcherReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosting.txt")
+	if err := os.WriteFile(path, []byte("# cloud providers\nAmazon\n\ndigitalocean\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := newHostingMatcher(path, time.Hour, logger.NewNoop())
+	if err != nil {
+		t.Fatalf("newHostingMatcher: %v", err)
+	}
+	defer m.close()
+
+	if !m.matches("AMAZON-02") || !m.matches("DigitalOcean, LLC") {
+		t.Fatalf("expected case-insensitive substring matches")
+	}
+	if m.matches("Deutsche Telekom AG") || m.matches("") {
+		t.Fatalf("unexpected match")
+	}
+
+	if err := os.WriteFile(path, []byte("ovh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := m.reload()
+	if err != nil || !reloaded {
+		t.Fatalf("expected reload, got reloaded=%v err=%v", reloaded, err)
+	}
+	if !m.matches("OVH SAS") || m.matches("AMAZON-02") {
+		t.Fatalf("expected reloaded patterns to replace the old list")
+	}
+}
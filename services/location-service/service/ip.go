@@ -0,0 +1,70 @@
+package service
+
+import (
+	locErrors "location-service/errors"
+	"net"
+	"net/netip"
+)
+
+// ============================================================================
+// IP Normalization
+// ============================================================================
+
+// nat64Prefix is the well-known NAT64 prefix (RFC 6052). Addresses in it
+// carry the IPv4 address an IPv6-only client was translated to reach.
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// reservedPrefixes are special-purpose ranges (RFC 6890) that net.IP's
+// IsPrivate, IsLoopback and friends don't cover and that no GeoIP database
+// has a location for.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// normalizeIP parses ipStr into the address to look up and its canonical
+// string form. IPv4-mapped IPv6 (::ffff:1.2.3.4) and NAT64 addresses become
+// their IPv4 address, so every spelling of an address shares one cache entry
+// and database row. Private, loopback, link-local and other reserved
+// addresses are rejected with CodePrivateIPAddress since they have no
+// location.
+func normalizeIP(ipStr string) (net.IP, string, error) {
+	addr, err := netip.ParseAddr(ipStr)
+	if err != nil || addr.Zone() != "" {
+		return nil, "", locErrors.NewLocationError(locErrors.CodeInvalidIP, "Invalid IP address format")
+	}
+
+	addr = addr.Unmap()
+	if nat64Prefix.Contains(addr) {
+		v6 := addr.As16()
+		addr = netip.AddrFrom4([4]byte(v6[12:]))
+	}
+
+	if !isPublicAddr(addr) {
+		return nil, "", locErrors.NewLocationError(locErrors.CodePrivateIPAddress,
+			"IP address "+addr.String()+" is private or reserved and has no location")
+	}
+	return net.IP(addr.AsSlice()), addr.String(), nil
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	locErrors "location-service/errors"
+	"shared/pkg/database"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+)
+
+// recordingDB remembers which addresses the lookup table was queried for.
+type recordingDB struct {
+	database.Database
+	queried []string
+}
+
+func (d *recordingDB) FindOne(ctx context.Context, model database.Model, query string, args ...interface{}) *database.DBError {
+	d.queried = append(d.queried, args[0].(string))
+	return database.NotFoundError("ip_addresses", "ip_address")
+}
+
+func (d *recordingDB) Insert(ctx context.Context, model database.Model) (*string, *database.DBError) {
+	return nil, nil
+}
+
+func TestNormalizeIP(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"8.8.8.8", "8.8.8.8"},
+		{"::ffff:8.8.8.8", "8.8.8.8"},
+		{"::FFFF:808:808", "8.8.8.8"},
+		{"64:ff9b::8.8.8.8", "8.8.8.8"},
+		{"2001:4860:4860::8888", "2001:4860:4860::8888"},
+		{"2001:4860:4860:0:0:0:0:8888", "2001:4860:4860::8888"},
+		{"2A00:1450:4001:0829::200E", "2a00:1450:4001:829::200e"},
+	}
+	for _, tt := range tests {
+		ip, got, err := normalizeIP(tt.in)
+		if err != nil {
+			t.Fatalf("normalizeIP(%q): %v", tt.in, err)
+		}
+		if got != tt.want || ip.String() != tt.want {
+			t.Fatalf("normalizeIP(%q) = %s (%s), want %s", tt.in, got, ip, tt.want)
+		}
+	}
+}
+
+func TestNormalizeIPRejectsNonPublicAddresses(t *testing.T) {
+	for _, in := range []string{
+		"10.1.2.3", "172.16.0.1", "192.168.1.1", "127.0.0.1", "169.254.1.1",
+		"100.64.0.1", "192.0.2.10", "0.0.0.0", "224.0.0.1", "255.255.255.255",
+		"::ffff:192.168.1.1", "64:ff9b::10.0.0.1",
+		"::1", "::", "fe80::1", "fd12:3456::1", "ff02::1", "2001:db8::1",
+	} {
+		_, _, err := normalizeIP(in)
+		appErr, ok := err.(pkgErrors.AppError)
+		if !ok || appErr.Code() != locErrors.CodePrivateIPAddress {
+			t.Fatalf("normalizeIP(%q) error = %v, want %s", in, err, locErrors.CodePrivateIPAddress)
+		}
+	}
+
+	for _, in := range []string{"", "not-an-ip", "1.2.3", "fe80::1%eth0", "01.2.3.4"} {
+		_, _, err := normalizeIP(in)
+		appErr, ok := err.(pkgErrors.AppError)
+		if !ok || appErr.Code() != locErrors.CodeInvalidIP {
+			t.Fatalf("normalizeIP(%q) error = %v, want %s", in, err, locErrors.CodeInvalidIP)
+		}
+	}
+}
+
+func TestLookupNormalizesIPv6(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeFamilyMMDB(t, path, cityRecord("Four"), cityRecord("Six"))
+	cityDB, err := openGeoIPDB("city", path, -1, nil, logger.NewNoop())
+	if err != nil {
+		t.Fatalf("openGeoIPDB: %v", err)
+	}
+	defer cityDB.Close()
+
+	db := &recordingDB{}
+	svc := &LocationService{db: db, cityDB: cityDB, log: logger.NewNoop(), cache: newLookupCache(16, 0)}
+
+	tests := []struct {
+		in, wantIP, wantCity string
+	}{
+		{"2001:4860:4860:0::8888", "2001:4860:4860::8888", "Six"},
+		{"::ffff:8.8.8.8", "8.8.8.8", "Four"},
+		{"8.8.8.8", "8.8.8.8", "Four"},
+		{"64:ff9b::808:808", "8.8.8.8", "Four"},
+	}
+	for _, tt := range tests {
+		result, err := svc.Lookup(tt.in)
+		if err != nil {
+			t.Fatalf("Lookup(%q): %v", tt.in, err)
+		}
+		if result.IP != tt.wantIP {
+			t.Fatalf("Lookup(%q).IP = %q, want %q", tt.in, result.IP, tt.wantIP)
+		}
+		if result.City == nil || result.City.City.Names["en"] != tt.wantCity {
+			t.Fatalf("Lookup(%q) city = %+v, want %s", tt.in, result.City, tt.wantCity)
+		}
+	}
+
+	// Every spelling of 8.8.8.8 after the first is served from the cache
+	if len(db.queried) != 2 || db.queried[0] != "2001:4860:4860::8888" || db.queried[1] != "8.8.8.8" {
+		t.Fatalf("lookup table queried for %v, want the two canonical addresses", db.queried)
+	}
+
+	if _, err := svc.Lookup("::ffff:10.0.0.1"); err == nil {
+		t.Fatalf("expected private IPv4-mapped address to be rejected")
+	}
+	if _, err := svc.LookupCity("fe80::1"); err == nil {
+		t.Fatalf("expected link-local address to be rejected")
+	}
+	if len(db.queried) != 2 {
+		t.Fatalf("rejected addresses reached the lookup table")
+	}
+}
@@ -10,8 +10,7 @@ import (
 	dbModels "shared/pkg/database/postgres/models"
 	"shared/pkg/logger"
 	"sync"
-
-	"github.com/oschwald/maxminddb-golang"
+	"time"
 )
 
 // ============================================================================
@@ -19,10 +18,12 @@ import (
 // ============================================================================
 
 type LocationService struct {
-	cityDB    *maxminddb.Reader
-	asnDB     *maxminddb.Reader
-	countryDB *maxminddb.Reader
+	cityDB    *geoIPDB
+	asnDB     *geoIPDB
+	countryDB *geoIPDB
 	db        database.Database
+	cache     *lookupCache
+	hosting   *hostingMatcher
 	mu        sync.RWMutex
 	log       logger.Logger
 }
@@ -32,6 +33,22 @@ type Config struct {
 	ASNDBPath     string
 	CountryDBPath string
 	Logger        logger.Logger
+
+	// GeoIPReloadInterval is how often the database files are checked for
+	// changes and reopened. Zero uses DefaultGeoIPReloadInterval; negative
+	// disables reloading.
+	GeoIPReloadInterval time.Duration
+
+	// CacheSize enables an in-memory LRU of lookup results when positive.
+	CacheSize int
+	// CacheTTL bounds how long a cached result is served; zero means no expiry.
+	CacheTTL time.Duration
+
+	// HostingListPath points to a file of hosting/VPN ASN organization
+	// patterns, one per line. It is polled for changes every
+	// HostingListReloadInterval.
+	HostingListPath           string
+	HostingListReloadInterval time.Duration
 }
 
 func NewLocationService(cfg Config, dbConfig database.Config) (*LocationService, error) {
@@ -70,6 +87,14 @@ func NewLocationService(cfg Config, dbConfig database.Config) (*LocationService,
 		db:  db,
 		log: cfg.Logger,
 	}
+	if cfg.CacheSize > 0 {
+		svc.cache = newLookupCache(cfg.CacheSize, cfg.CacheTTL)
+		cfg.Logger.Info("Lookup cache enabled",
+			logger.String("service", locErrors.ServiceName),
+			logger.Int("size", cfg.CacheSize),
+			logger.Duration("ttl", cfg.CacheTTL),
+		)
+	}
 	var err error
 
 	if cfg.CityDBPath != "" {
@@ -77,7 +102,7 @@ func NewLocationService(cfg Config, dbConfig database.Config) (*LocationService,
 			logger.String("service", locErrors.ServiceName),
 			logger.String("path", cfg.CityDBPath),
 		)
-		svc.cityDB, err = maxminddb.Open(cfg.CityDBPath)
+		svc.cityDB, err = openGeoIPDB("city", cfg.CityDBPath, cfg.GeoIPReloadInterval, svc.purgeCache, cfg.Logger)
 		if err != nil {
 			cfg.Logger.Error("Failed to open city database",
 				logger.String("service", locErrors.ServiceName),
@@ -97,7 +122,7 @@ func NewLocationService(cfg Config, dbConfig database.Config) (*LocationService,
 			logger.String("service", locErrors.ServiceName),
 			logger.String("path", cfg.ASNDBPath),
 		)
-		svc.asnDB, err = maxminddb.Open(cfg.ASNDBPath)
+		svc.asnDB, err = openGeoIPDB("asn", cfg.ASNDBPath, cfg.GeoIPReloadInterval, svc.purgeCache, cfg.Logger)
 		if err != nil {
 			cfg.Logger.Error("Failed to open ASN database",
 				logger.String("service", locErrors.ServiceName),
@@ -118,7 +143,7 @@ func NewLocationService(cfg Config, dbConfig database.Config) (*LocationService,
 			logger.String("service", locErrors.ServiceName),
 			logger.String("path", cfg.CountryDBPath),
 		)
-		svc.countryDB, err = maxminddb.Open(cfg.CountryDBPath)
+		svc.countryDB, err = openGeoIPDB("country", cfg.CountryDBPath, cfg.GeoIPReloadInterval, svc.purgeCache, cfg.Logger)
 		if err != nil {
 			cfg.Logger.Error("Failed to open country database",
 				logger.String("service", locErrors.ServiceName),
@@ -134,6 +159,22 @@ func NewLocationService(cfg Config, dbConfig database.Config) (*LocationService,
 		)
 	}
 
+	if cfg.HostingListPath != "" {
+		svc.hosting, err = newHostingMatcher(cfg.HostingListPath, cfg.HostingListReloadInterval, cfg.Logger)
+		if err != nil {
+			cfg.Logger.Error("Failed to load hosting ASN list",
+				logger.String("service", locErrors.ServiceName),
+				logger.String("path", cfg.HostingListPath),
+				logger.Error(err),
+			)
+			svc.Close()
+			return nil, err
+		}
+		cfg.Logger.Info("Hosting ASN list loaded successfully",
+			logger.String("service", locErrors.ServiceName),
+		)
+	}
+
 	cfg.Logger.Info("LocationService initialized successfully",
 		logger.String("service", locErrors.ServiceName),
 	)
@@ -151,16 +192,64 @@ func (s *LocationService) Lookup(ipStr string) (*model.LocationResult, error) {
 		logger.String("ip", ipStr),
 	)
 
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		s.log.Warn("Invalid IP address provided",
+	ip, normalized, err := normalizeIP(ipStr)
+	if err != nil {
+		s.log.Warn("IP address rejected for lookup",
 			logger.String("service", locErrors.ServiceName),
 			logger.String("ip", ipStr),
-			logger.String("error_code", locErrors.CodeInvalidIP),
+			logger.Error(err),
 		)
-		return nil, locErrors.NewLocationError(locErrors.CodeInvalidIP, "Invalid IP address format")
+		return nil, err
 	}
+	ipStr = normalized
 
+	var generation uint64
+	if s.cache != nil {
+		if cached, ok := s.cache.get(ipStr); ok {
+			return cached, nil
+		}
+		generation = s.cache.current()
+	}
+
+	result, err := s.lookup(ip, ipStr)
+	if err != nil {
+		return nil, err
+	}
+	if s.cache != nil {
+		s.cache.set(ipStr, result, generation)
+	}
+	return result, nil
+}
+
+// IsHostingOrg reports whether an ASN organization matches the hosting/VPN list.
+func (s *LocationService) IsHostingOrg(org string) bool {
+	if s.hosting == nil {
+		return false
+	}
+	return s.hosting.matches(org)
+}
+
+// CacheStats reports lookup cache usage; ok is false when caching is disabled.
+func (s *LocationService) CacheStats() (CacheStats, bool) {
+	if s.cache == nil {
+		return CacheStats{}, false
+	}
+	return s.cache.stats(), true
+}
+
+// purgeCache drops cached lookups after a GeoIP database is replaced, so
+// they are answered from the new data
+func (s *LocationService) purgeCache() {
+	if s.cache == nil {
+		return
+	}
+	s.cache.purge()
+	s.log.Info("Lookup cache purged after GeoIP database reload",
+		logger.String("service", locErrors.ServiceName),
+	)
+}
+
+func (s *LocationService) lookup(ip net.IP, ipStr string) (*model.LocationResult, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -272,15 +361,16 @@ func (s *LocationService) LookupCity(ipStr string) (*model.CityRecord, error) {
 		return nil, locErrors.NewLocationError(locErrors.CodeDatabaseNotFound, "City database not loaded")
 	}
 
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		s.log.Warn("Invalid IP address for city lookup",
+	ip, normalized, err := normalizeIP(ipStr)
+	if err != nil {
+		s.log.Warn("IP address rejected for city lookup",
 			logger.String("service", locErrors.ServiceName),
 			logger.String("ip", ipStr),
-			logger.String("error_code", locErrors.CodeInvalidIP),
+			logger.Error(err),
 		)
-		return nil, locErrors.NewLocationError(locErrors.CodeInvalidIP, "Invalid IP address format")
+		return nil, err
 	}
+	ipStr = normalized
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -304,6 +394,57 @@ func (s *LocationService) LookupCity(ipStr string) (*model.CityRecord, error) {
 	return &city, nil
 }
 
+// LookupTimezone reads only the city database's location.time_zone field.
+func (s *LocationService) LookupTimezone(ipStr string) (string, error) {
+	s.log.Debug("Performing timezone lookup",
+		logger.String("service", locErrors.ServiceName),
+		logger.String("ip", ipStr),
+	)
+
+	if s.cityDB == nil {
+		s.log.Error("City database not loaded",
+			logger.String("service", locErrors.ServiceName),
+			logger.String("error_code", locErrors.CodeDatabaseNotFound),
+		)
+		return "", locErrors.NewLocationError(locErrors.CodeDatabaseNotFound, "City database not loaded")
+	}
+
+	ip, normalized, err := normalizeIP(ipStr)
+	if err != nil {
+		s.log.Warn("IP address rejected for timezone lookup",
+			logger.String("service", locErrors.ServiceName),
+			logger.String("ip", ipStr),
+			logger.Error(err),
+		)
+		return "", err
+	}
+	ipStr = normalized
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var record struct {
+		Location struct {
+			TimeZone string `maxminddb:"time_zone"`
+		} `maxminddb:"location"`
+	}
+	if err := s.cityDB.Lookup(ip, &record); err != nil {
+		s.log.Error("Timezone lookup failed",
+			logger.String("service", locErrors.ServiceName),
+			logger.String("ip", ipStr),
+			logger.String("error_code", locErrors.CodeLookupFailed),
+			logger.Error(err),
+		)
+		return "", locErrors.NewLocationError(locErrors.CodeLookupFailed, "Timezone lookup failed")
+	}
+
+	if record.Location.TimeZone == "" {
+		return "", locErrors.NewLocationError(locErrors.CodeIPNotFound, "No timezone found for IP address")
+	}
+
+	return record.Location.TimeZone, nil
+}
+
 func (s *LocationService) LookupASN(ipStr string) (*model.ASNRecord, error) {
 	s.log.Debug("Performing ASN lookup",
 		logger.String("service", locErrors.ServiceName),
@@ -318,15 +459,16 @@ func (s *LocationService) LookupASN(ipStr string) (*model.ASNRecord, error) {
 		return nil, locErrors.NewLocationError(locErrors.CodeDatabaseNotFound, "ASN database not loaded")
 	}
 
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		s.log.Warn("Invalid IP address for ASN lookup",
+	ip, normalized, err := normalizeIP(ipStr)
+	if err != nil {
+		s.log.Warn("IP address rejected for ASN lookup",
 			logger.String("service", locErrors.ServiceName),
 			logger.String("ip", ipStr),
-			logger.String("error_code", locErrors.CodeInvalidIP),
+			logger.Error(err),
 		)
-		return nil, locErrors.NewLocationError(locErrors.CodeInvalidIP, "Invalid IP address format")
+		return nil, err
 	}
+	ipStr = normalized
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -364,15 +506,16 @@ func (s *LocationService) LookupCountry(ipStr string) (*model.CountryRecord, err
 		return nil, locErrors.NewLocationError(locErrors.CodeDatabaseNotFound, "Country database not loaded")
 	}
 
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		s.log.Warn("Invalid IP address for country lookup",
+	ip, normalized, err := normalizeIP(ipStr)
+	if err != nil {
+		s.log.Warn("IP address rejected for country lookup",
 			logger.String("service", locErrors.ServiceName),
 			logger.String("ip", ipStr),
-			logger.String("error_code", locErrors.CodeInvalidIP),
+			logger.Error(err),
 		)
-		return nil, locErrors.NewLocationError(locErrors.CodeInvalidIP, "Invalid IP address format")
+		return nil, err
 	}
+	ipStr = normalized
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -410,6 +553,11 @@ func (s *LocationService) Close() error {
 
 	var errs []error
 
+	if s.hosting != nil {
+		s.hosting.close()
+		s.hosting = nil
+	}
+
 	if s.cityDB != nil {
 		s.log.Debug("Closing city database",
 			logger.String("service", locErrors.ServiceName),
@@ -35,6 +35,7 @@ func createLogger(name string) logger.Logger {
 		Level:      logger.GetLoggerLevel(),
 		Format:     logger.GetLoggerFormat(),
 		Output:     logger.GetLoggerOutput(),
+		File:       logger.GetLoggerFile(),
 		TimeFormat: logger.GetLoggerTimeFormat(),
 		Service:    name,
 	})
@@ -145,6 +146,7 @@ func createStorageProvider(cfg *config.Config, log logger.Logger) (service.Stora
 }
 func setupHealthChecks(dbClient database.Database, cacheClient cache.Cache, cfg *config.Config) *health.Manager {
 	healthMgr := health.NewManager(cfg.Service.Name, cfg.Service.Version)
+	healthMgr.SetCacheTTL(cfg.Observability.Health.CacheTTL)
 
 	// Register database health checker
 	if dbClient != nil {
@@ -195,10 +197,11 @@ func setupRoutes(builder *router.Builder, h *handler.Handler, cfg *config.Config
 	return builder
 }
 
-func createRouter(h *handler.Handler, healthHandler *health.Handler, cfg *config.Config, log logger.Logger) (*router.Router, error) {
+func createRouter(h *handler.Handler, healthHandler *health.Handler, inFlight *shutdown.InFlight, cfg *config.Config, log logger.Logger) (*router.Router, error) {
 
 	builder := router.NewBuilder().
 		WithHealthEndpoint("/health", healthHandler.Health).
+		WithLogLevelEndpoint("/admin/loglevel", log, env.LogLevelEndpointEnabled()).
 		WithNotFoundHandler(func(w http.ResponseWriter, r *http.Request) {
 			response.RouteNotFoundError(r.Context(), r, w, log)
 		}).
@@ -206,6 +209,7 @@ func createRouter(h *handler.Handler, healthHandler *health.Handler, cfg *config
 			response.MethodNotAllowedError(r.Context(), r, w)
 		}).
 		WithEarlyMiddleware(
+			router.Middleware(inFlight.Middleware),
 			router.Middleware(coreMiddleware.RequestReceivedLogger(log)),
 			router.Middleware(coreMiddleware.InterceptUserId()),
 			// BodyLimit removed - FileOnlyMultipart middleware handles size validation for file uploads
@@ -213,6 +217,7 @@ func createRouter(h *handler.Handler, healthHandler *health.Handler, cfg *config
 		WithLateMiddleware(
 			router.Middleware(coreMiddleware.Recovery(log)),
 			router.Middleware(coreMiddleware.RequestCompletedLogger(log)),
+			router.Middleware(coreMiddleware.BodyLogger(coreMiddleware.BodyLoggerConfig{Enabled: env.BodyLoggingEnabled(), Logger: log})),
 		)
 
 	// Health endpoints without authentication
@@ -228,27 +233,24 @@ func createRouter(h *handler.Handler, healthHandler *health.Handler, cfg *config
 	return routerInstance, nil
 }
 
-func setupShutdownManager(srv *server.Server, log logger.Logger, cfg *config.Config) *shutdown.Manager {
+func setupShutdownManager(srv *server.Server, inFlight *shutdown.InFlight, log logger.Logger, cfg *config.Config) *shutdown.Manager {
 	shutdownMgr := shutdown.New(
 		shutdown.WithTimeout(cfg.Server.ShutdownTimeout),
 		shutdown.WithLogger(log),
 	)
 
+	// Draining runs inside the server hook, after the listeners close, so it
+	// sees the requests the server is still handling
+	serverHook := shutdown.ServerShutdownHook(srv)
+	if cfg.Shutdown.WaitForConnections && cfg.Shutdown.DrainTimeout > 0 {
+		serverHook = shutdown.ServerDrainHook(srv, inFlight, cfg.Shutdown.DrainTimeout, log)
+	}
 	shutdownMgr.RegisterWithPriority(
 		"http-server",
-		shutdown.ServerShutdownHook(srv),
+		serverHook,
 		shutdown.PriorityHigh,
 	)
 
-	if cfg.Shutdown.WaitForConnections && cfg.Shutdown.DrainTimeout > 0 {
-		shutdownMgr.RegisterWithOptions(
-			"drain-connections",
-			shutdown.DelayHook(cfg.Shutdown.DrainTimeout),
-			shutdown.PriorityHigh,
-			cfg.Shutdown.DrainTimeout,
-		)
-	}
-
 	shutdownMgr.RegisterWithPriority(
 		"logger-sync",
 		shutdown.Hook(func(ctx context.Context) error {
@@ -341,7 +343,8 @@ func main() {
 	healthHandler := health.NewHandler(healthMgr)
 
 	// Create router
-	routerInstance, err := createRouter(mediaHandler, healthHandler, cfg, log)
+	inFlight := shutdown.NewInFlight()
+	routerInstance, err := createRouter(mediaHandler, healthHandler, inFlight, cfg, log)
 	if err != nil {
 		log.Fatal("Failed to create router", logger.Error(err))
 	}
@@ -362,7 +365,7 @@ func main() {
 		log.Fatal("Failed to create server", logger.Error(err))
 	}
 
-	shutdownMgr := setupShutdownManager(srv, log, cfg)
+	shutdownMgr := setupShutdownManager(srv, inFlight, log, cfg)
 
 	serverErrors := make(chan error, 1)
 	go func() {
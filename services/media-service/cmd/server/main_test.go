@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"media-service/internal/config"
+
+	"shared/pkg/logger"
+	"shared/server/server"
+	"shared/server/shutdown"
+)
+
+// startServer serves handler behind the in-flight counter on a local port
+func startServer(t *testing.T, handler http.HandlerFunc) (*server.Server, *shutdown.InFlight, string) {
+	t.Helper()
+	inFlight := shutdown.NewInFlight()
+	srv, err := server.New(&server.Config{
+		Host:    "127.0.0.1",
+		Port:    8080,
+		Handler: inFlight.Middleware(handler),
+	}, logger.NewNoop())
+	if err != nil {
+		t.Fatalf("server: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.StartWithListener(ln)
+	return srv, inFlight, "http://" + ln.Addr().String()
+}
+
+func shutdownConfig(drainTimeout time.Duration) *config.Config {
+	cfg := &config.Config{}
+	cfg.Server.ShutdownTimeout = 5 * time.Second
+	cfg.Shutdown.WaitForConnections = true
+	cfg.Shutdown.DrainTimeout = drainTimeout
+	return cfg
+}
+
+func TestSetupShutdownManager_DrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	srv, inFlight, url := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	mgr := setupShutdownManager(srv, inFlight, logger.NewNoop(), shutdownConfig(2*time.Second))
+	if err := mgr.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if n := inFlight.Count(); n != 0 {
+		t.Fatalf("in-flight count after shutdown = %d, want 0", n)
+	}
+	if got := <-status; got != http.StatusOK {
+		t.Fatalf("in-flight request status = %d, want 200", got)
+	}
+}
+
+func TestSetupShutdownManager_StopsWaitingAtDrainTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	srv, inFlight, url := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	go http.Get(url)
+	<-started
+
+	mgr := setupShutdownManager(srv, inFlight, logger.NewNoop(), shutdownConfig(50*time.Millisecond))
+	start := time.Now()
+	if err := mgr.Shutdown(context.Background()); err == nil {
+		t.Fatalf("shutdown succeeded with a request still in flight")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown took %v, want it to give up at the 50ms drain timeout", elapsed)
+	}
+}
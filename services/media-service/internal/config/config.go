@@ -183,8 +183,9 @@ type TracingConfig struct {
 
 // HealthConfig contains health check configuration
 type HealthConfig struct {
-	Enabled  bool   `yaml:"enabled" mapstructure:"enabled"`
-	Endpoint string `yaml:"endpoint" mapstructure:"endpoint"`
+	Enabled  bool          `yaml:"enabled" mapstructure:"enabled"`
+	Endpoint string        `yaml:"endpoint" mapstructure:"endpoint"`
+	CacheTTL time.Duration `yaml:"cache_ttl" mapstructure:"cache_ttl"`
 }
 
 // ShutdownConfig contains graceful shutdown configuration
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 )
 
 // Validate validates the configuration
@@ -32,6 +33,10 @@ func Validate(cfg *Config) error {
 		return fmt.Errorf("processing config: %w", err)
 	}
 
+	if cfg.Observability.Health.CacheTTL <= 0 {
+		cfg.Observability.Health.CacheTTL = 5 * time.Second
+	}
+
 	return nil
 }
 
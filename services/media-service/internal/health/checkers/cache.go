@@ -26,12 +26,18 @@ func (c *CacheChecker) Name() string {
 	return "cache"
 }
 
+// IsCritical reports that the service keeps running, degraded, without its cache
+func (c *CacheChecker) IsCritical() bool {
+	return false
+}
+
 // Check performs the cache health check
 func (c *CacheChecker) Check(ctx context.Context) health.CheckResult {
 	start := time.Now()
 
 	// Try to get cache info
-	if _, err := c.cache.Info(ctx); err != nil {
+	info, err := c.cache.Info(ctx)
+	if err != nil {
 		return health.CheckResult{
 			Status:    health.StatusUnhealthy,
 			Timestamp: time.Now(),
@@ -42,13 +48,28 @@ func (c *CacheChecker) Check(ctx context.Context) health.CheckResult {
 		}
 	}
 
-	return health.CheckResult{
+	result := health.CheckResult{
 		Status:    health.StatusHealthy,
 		Timestamp: time.Now(),
 		Details: map[string]interface{}{
 			"response_time_ms": time.Since(start).Milliseconds(),
 		},
 	}
+	if version := serverVersion(info); version != "" {
+		result.Metadata = map[string]interface{}{
+			"server_version": version,
+		}
+	}
+	return result
+}
+
+// serverVersion finds redis_version in INFO output, whose keys may be
+// prefixed with their section name
+func serverVersion(info map[string]string) string {
+	if v, ok := info["redis_version"]; ok {
+		return v
+	}
+	return info["Server.redis_version"]
 }
 
 // CachePerformanceChecker checks cache performance
@@ -68,6 +89,11 @@ func (c *CachePerformanceChecker) Name() string {
 	return "cache_performance"
 }
 
+// IsCritical reports that the service keeps running, degraded, without its cache
+func (c *CachePerformanceChecker) IsCritical() bool {
+	return false
+}
+
 // Check performs the cache performance check
 func (c *CachePerformanceChecker) Check(ctx context.Context) health.CheckResult {
 	start := time.Now()
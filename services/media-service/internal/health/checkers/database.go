@@ -26,6 +26,11 @@ func (c *DatabaseChecker) Name() string {
 	return "database"
 }
 
+// IsCritical reports that the service cannot serve requests without its database
+func (c *DatabaseChecker) IsCritical() bool {
+	return true
+}
+
 // Check performs the database health check
 func (c *DatabaseChecker) Check(ctx context.Context) health.CheckResult {
 	start := time.Now()
@@ -43,7 +48,7 @@ func (c *DatabaseChecker) Check(ctx context.Context) health.CheckResult {
 
 	stats := c.db.Stats()
 
-	return health.CheckResult{
+	result := health.CheckResult{
 		Status:    health.StatusHealthy,
 		Timestamp: time.Now(),
 		Details: map[string]interface{}{
@@ -54,4 +59,12 @@ func (c *DatabaseChecker) Check(ctx context.Context) health.CheckResult {
 			"max_open_connections": stats.MaxOpenConnections,
 		},
 	}
+
+	var version string
+	if err := c.db.QueryRow(ctx, "SHOW server_version").Scan(&version); err == nil {
+		result.Metadata = map[string]interface{}{
+			"server_version": version,
+		}
+	}
+	return result
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"shared/server/env"
 )
 
 // Status represents health check status
@@ -17,10 +19,15 @@ const (
 
 // CheckResult represents the result of a health check
 type CheckResult struct {
-	Status    Status                 `json:"status"`
-	Timestamp time.Time              `json:"timestamp"`
-	Details   map[string]interface{} `json:"details,omitempty"`
-	Error     string                 `json:"error,omitempty"`
+	Status      Status                 `json:"status"`
+	Timestamp   time.Time              `json:"timestamp"`
+	DurationMS  float64                `json:"duration_ms"`
+	LastChecked string                 `json:"last_checked,omitempty"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	// Metadata carries non-sensitive facts about the dependency, such as its
+	// server version
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // Checker interface for health checks
@@ -29,12 +36,47 @@ type Checker interface {
 	Check(ctx context.Context) CheckResult
 }
 
+// CriticalityChecker is implemented by checkers that declare whether the
+// service can run without their dependency. Checkers that don't implement it
+// are treated as critical.
+type CriticalityChecker interface {
+	IsCritical() bool
+}
+
+func isCritical(checker Checker) bool {
+	if c, ok := checker.(CriticalityChecker); ok {
+		return c.IsCritical()
+	}
+	return true
+}
+
+// combineStatus folds one checker's status into the overall status: an
+// unhealthy critical checker makes the service unhealthy, while an unhealthy
+// optional checker or any degraded checker only degrades it
+func combineStatus(overall, status Status, critical bool) Status {
+	switch {
+	case overall == StatusUnhealthy || status == StatusHealthy:
+		return overall
+	case status == StatusUnhealthy && critical:
+		return StatusUnhealthy
+	default:
+		return StatusDegraded
+	}
+}
+
 // Manager manages health checks
 type Manager struct {
 	serviceName string
 	version     string
 	checkers    []Checker
 	mu          sync.RWMutex
+	cache       map[string]cachedResult
+	cacheTTL    time.Duration
+}
+
+type cachedResult struct {
+	result    CheckResult
+	timestamp time.Time
 }
 
 // NewManager creates a new health check manager
@@ -43,9 +85,19 @@ func NewManager(serviceName, version string) *Manager {
 		serviceName: serviceName,
 		version:     version,
 		checkers:    make([]Checker, 0),
+		cache:       make(map[string]cachedResult),
+		cacheTTL:    5 * time.Second,
 	}
 }
 
+// SetCacheTTL sets how long a check result is reused before the checker runs
+// again; zero runs every checker on every request
+func (m *Manager) SetCacheTTL(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheTTL = ttl
+}
+
 // RegisterChecker registers a health checker
 func (m *Manager) RegisterChecker(checker Checker) {
 	m.mu.Lock()
@@ -64,31 +116,52 @@ func (m *Manager) CheckHealth(ctx context.Context) HealthResponse {
 	overallStatus := StatusHealthy
 
 	for _, checker := range checkers {
-		result := checker.Check(ctx)
+		result := m.check(ctx, checker)
 		results[checker.Name()] = result
 
-		// Determine overall status
-		if result.Status == StatusUnhealthy {
-			overallStatus = StatusUnhealthy
-		} else if result.Status == StatusDegraded && overallStatus != StatusUnhealthy {
-			overallStatus = StatusDegraded
-		}
+		overallStatus = combineStatus(overallStatus, result.Status, isCritical(checker))
 	}
 
 	return HealthResponse{
 		Status:    string(overallStatus),
 		Service:   m.serviceName,
 		Version:   m.version,
+		Build:     env.Build(m.version),
 		Timestamp: time.Now(),
 		Checks:    results,
 	}
 }
 
+// check runs a checker, or returns its last result while that is younger
+// than the cache TTL
+func (m *Manager) check(ctx context.Context, checker Checker) CheckResult {
+	name := checker.Name()
+
+	m.mu.RLock()
+	cached, ok := m.cache[name]
+	ttl := m.cacheTTL
+	m.mu.RUnlock()
+	if ok && time.Since(cached.timestamp) < ttl {
+		return cached.result
+	}
+
+	start := time.Now()
+	result := checker.Check(ctx)
+	result.DurationMS = float64(time.Since(start).Microseconds()) / 1000.0
+	result.LastChecked = start.UTC().Format(time.RFC3339)
+
+	m.mu.Lock()
+	m.cache[name] = cachedResult{result: result, timestamp: start}
+	m.mu.Unlock()
+	return result
+}
+
 // HealthResponse represents the overall health response
 type HealthResponse struct {
 	Status    string                 `json:"status"`
 	Service   string                 `json:"service"`
 	Version   string                 `json:"version"`
+	Build     env.BuildInfo          `json:"build"`
 	Timestamp time.Time              `json:"timestamp"`
 	Checks    map[string]CheckResult `json:"checks"`
 }
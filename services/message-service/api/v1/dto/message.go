@@ -2,7 +2,10 @@ package dto
 
 import (
 	"echo-backend/services/message-service/internal/models"
+	"encoding/json"
 	"shared/server/request"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -81,6 +84,8 @@ type SendMessageRequest struct {
 	ParentMessageID *string                `json:"parent_message_id,omitempty" validate:"omitempty,uuid4"`
 	Mentions        []models.Mention       `json:"mentions,omitempty"`
 	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	// ScheduledAt holds the message back until the given RFC 3339 time
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
 }
 
 func NewSendMessageRequest() *SendMessageRequest {
@@ -159,6 +164,7 @@ type SendMessageResponse struct {
 	ParentMessageID *string                `json:"parent_message_id,omitempty"`
 	Mentions        []models.Mention       `json:"mentions,omitempty"`
 	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	ScheduledAt     *int64                 `json:"scheduled_at,omitempty"`
 	CreatedAt       int64                  `json:"created_at"`
 	UpdatedAt       int64                  `json:"updated_at"`
 }
@@ -170,6 +176,12 @@ func NewSendMessageResponse(msg *models.Message) *SendMessageResponse {
 		parentMsgID = &id
 	}
 
+	var scheduledAt *int64
+	if msg.IsScheduled && msg.ScheduledAt != nil {
+		at := msg.ScheduledAt.Unix()
+		scheduledAt = &at
+	}
+
 	return &SendMessageResponse{
 		ID:              msg.ID.String(),
 		ConversationID:  msg.ConversationID.String(),
@@ -178,6 +190,7 @@ func NewSendMessageResponse(msg *models.Message) *SendMessageResponse {
 		MessageType:     msg.MessageType,
 		Status:          msg.Status,
 		ParentMessageID: parentMsgID,
+		ScheduledAt:     scheduledAt,
 		CreatedAt:       msg.CreatedAt.Unix(),
 		UpdatedAt:       msg.UpdatedAt.Unix(),
 	}
@@ -221,6 +234,46 @@ func (r *EditMessageRequest) ValidateErrors(ve validator.ValidationErrors) ([]re
 	return errors, nil
 }
 
+// MessageStateResponse is the state of a message after an edit or delete
+type MessageStateResponse struct {
+	ID             string                    `json:"id"`
+	ConversationID string                    `json:"conversation_id"`
+	SenderUserID   string                    `json:"sender_user_id"`
+	Content        string                    `json:"content"`
+	IsEdited       bool                      `json:"is_edited"`
+	IsDeleted      bool                      `json:"is_deleted"`
+	DeletedForMe   bool                      `json:"deleted_for_me,omitempty"`
+	EditHistory    []models.EditHistoryEntry `json:"edit_history,omitempty"`
+	EditedAt       *int64                    `json:"edited_at,omitempty"`
+	DeletedAt      *int64                    `json:"deleted_at,omitempty"`
+	UpdatedAt      int64                     `json:"updated_at"`
+}
+
+func NewMessageStateResponse(msg *models.Message) *MessageStateResponse {
+	resp := &MessageStateResponse{
+		ID:             msg.ID.String(),
+		ConversationID: msg.ConversationID.String(),
+		SenderUserID:   msg.SenderUserID.String(),
+		Content:        msg.Content,
+		IsEdited:       msg.IsEdited,
+		IsDeleted:      msg.IsDeleted,
+		DeletedForMe:   msg.DeletedForMe,
+		UpdatedAt:      msg.UpdatedAt.Unix(),
+	}
+	if len(msg.EditHistory) > 0 {
+		_ = json.Unmarshal(msg.EditHistory, &resp.EditHistory)
+	}
+	if msg.EditedAt.Valid {
+		editedAt := msg.EditedAt.Time.Unix()
+		resp.EditedAt = &editedAt
+	}
+	if msg.DeletedAt.Valid {
+		deletedAt := msg.DeletedAt.Time.Unix()
+		resp.DeletedAt = &deletedAt
+	}
+	return resp
+}
+
 // MarkAsReadRequest represents the request to mark a message as read
 type MarkAsReadRequest struct {
 	MessageID string `json:"message_id" validate:"required,uuid4"`
@@ -287,3 +340,102 @@ func (r *TypingIndicatorRequest) ValidateErrors(ve validator.ValidationErrors) (
 	}
 	return errors, nil
 }
+
+// AddReactionRequest represents the request to react to a message
+type AddReactionRequest struct {
+	ReactionType string  `json:"reaction_type" validate:"required,max=100"`
+	Emoji        *string `json:"emoji,omitempty" validate:"omitempty,max=100"`
+	SkinTone     *string `json:"skin_tone,omitempty" validate:"omitempty,max=50"`
+}
+
+func NewAddReactionRequest() *AddReactionRequest {
+	return &AddReactionRequest{}
+}
+
+func (r *AddReactionRequest) GetValue() interface{} {
+	return r
+}
+
+func (r *AddReactionRequest) ValidateErrors(ve validator.ValidationErrors) ([]request.ValidationErrorDetail, error) {
+	var errors []request.ValidationErrorDetail
+	for _, fieldErr := range ve {
+		switch fieldErr.Field() {
+		case "ReactionType":
+			if fieldErr.Tag() == "required" {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.REQUIRED_FIELD,
+					Msg:  "Reaction type is required",
+				})
+			} else if fieldErr.Tag() == "max" {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.TOO_LONG,
+					Msg:  "Reaction type must be at most 100 characters",
+				})
+			}
+		case "Emoji":
+			errors = append(errors, request.ValidationErrorDetail{
+				Code: request.TOO_LONG,
+				Msg:  "Emoji must be at most 100 characters",
+			})
+		case "SkinTone":
+			errors = append(errors, request.ValidationErrorDetail{
+				Code: request.TOO_LONG,
+				Msg:  "Skin tone must be at most 50 characters",
+			})
+		}
+	}
+	return errors, nil
+}
+
+// ForwardMessageRequest represents the request to forward a message into
+// other conversations
+type ForwardMessageRequest struct {
+	ConversationIDs []string `json:"conversation_ids" validate:"required,min=1,max=5,dive,uuid4"`
+}
+
+func NewForwardMessageRequest() *ForwardMessageRequest {
+	return &ForwardMessageRequest{}
+}
+
+func (r *ForwardMessageRequest) GetValue() interface{} {
+	return r
+}
+
+func (r *ForwardMessageRequest) ValidateErrors(ve validator.ValidationErrors) ([]request.ValidationErrorDetail, error) {
+	var errors []request.ValidationErrorDetail
+	for _, fieldErr := range ve {
+		switch {
+		case fieldErr.Field() == "ConversationIDs":
+			if fieldErr.Tag() == "max" {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.TOO_LONG,
+					Msg:  "A message can be forwarded to at most 5 conversations at once",
+				})
+			} else {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.REQUIRED_FIELD,
+					Msg:  "At least one conversation ID is required",
+				})
+			}
+		case strings.HasPrefix(fieldErr.Field(), "ConversationIDs["):
+			errors = append(errors, request.ValidationErrorDetail{
+				Code: request.INVALID_FORMAT,
+				Msg:  "Conversation IDs must be valid UUIDs",
+			})
+		}
+	}
+	return errors, nil
+}
+
+// ForwardMessageResponse lists the created messages in request order
+type ForwardMessageResponse struct {
+	MessageIDs []string `json:"message_ids"`
+}
+
+func NewForwardMessageResponse(messages []*models.Message) *ForwardMessageResponse {
+	ids := make([]string, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID.String())
+	}
+	return &ForwardMessageResponse{MessageIDs: ids}
+}
@@ -0,0 +1,124 @@
+package dto
+
+import (
+	"shared/server/request"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// CreatePollRequest represents the request to post a poll in a conversation.
+// For quizzes CorrectOptionID is the index of the right answer in Options.
+type CreatePollRequest struct {
+	Question        string     `json:"question" validate:"required,max=500"`
+	Options         []string   `json:"options" validate:"required,min=2,max=10,dive,required,max=200"`
+	AllowMultiple   bool       `json:"allow_multiple_answers"`
+	IsAnonymous     bool       `json:"is_anonymous"`
+	IsQuiz          bool       `json:"is_quiz"`
+	CorrectOptionID *int       `json:"correct_option_id,omitempty" validate:"omitempty,min=0"`
+	Explanation     *string    `json:"explanation,omitempty" validate:"omitempty,max=1000"`
+	ClosesAt        *time.Time `json:"closes_at,omitempty"`
+}
+
+func NewCreatePollRequest() *CreatePollRequest {
+	return &CreatePollRequest{}
+}
+
+func (r *CreatePollRequest) GetValue() interface{} {
+	return r
+}
+
+func (r *CreatePollRequest) ValidateErrors(ve validator.ValidationErrors) ([]request.ValidationErrorDetail, error) {
+	var errors []request.ValidationErrorDetail
+	for _, fieldErr := range ve {
+		switch {
+		case fieldErr.Field() == "Question":
+			if fieldErr.Tag() == "required" {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.REQUIRED_FIELD,
+					Msg:  "Question is required",
+				})
+			} else if fieldErr.Tag() == "max" {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.TOO_LONG,
+					Msg:  "Question must be at most 500 characters",
+				})
+			}
+		case fieldErr.Field() == "Options":
+			if fieldErr.Tag() == "max" {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.TOO_LONG,
+					Msg:  "A poll can have at most 10 options",
+				})
+			} else {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.TOO_SHORT,
+					Msg:  "A poll needs at least 2 options",
+				})
+			}
+		case strings.HasPrefix(fieldErr.Field(), "Options["):
+			if fieldErr.Tag() == "required" {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.REQUIRED_FIELD,
+					Msg:  "Poll options cannot be empty",
+				})
+			} else if fieldErr.Tag() == "max" {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.TOO_LONG,
+					Msg:  "Poll options must be at most 200 characters",
+				})
+			}
+		case fieldErr.Field() == "CorrectOptionID":
+			errors = append(errors, request.ValidationErrorDetail{
+				Code: request.INVALID_FORMAT,
+				Msg:  "Correct option ID must be the index of an option",
+			})
+		case fieldErr.Field() == "Explanation":
+			errors = append(errors, request.ValidationErrorDetail{
+				Code: request.TOO_LONG,
+				Msg:  "Explanation must be at most 1000 characters",
+			})
+		}
+	}
+	return errors, nil
+}
+
+// VotePollRequest represents the request to answer a poll
+type VotePollRequest struct {
+	OptionIDs []string `json:"option_ids" validate:"required,min=1,max=10,dive,uuid4"`
+}
+
+func NewVotePollRequest() *VotePollRequest {
+	return &VotePollRequest{}
+}
+
+func (r *VotePollRequest) GetValue() interface{} {
+	return r
+}
+
+func (r *VotePollRequest) ValidateErrors(ve validator.ValidationErrors) ([]request.ValidationErrorDetail, error) {
+	var errors []request.ValidationErrorDetail
+	for _, fieldErr := range ve {
+		switch {
+		case fieldErr.Field() == "OptionIDs":
+			if fieldErr.Tag() == "max" {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.TOO_LONG,
+					Msg:  "At most 10 options can be selected",
+				})
+			} else {
+				errors = append(errors, request.ValidationErrorDetail{
+					Code: request.REQUIRED_FIELD,
+					Msg:  "At least one option is required",
+				})
+			}
+		case strings.HasPrefix(fieldErr.Field(), "OptionIDs["):
+			errors = append(errors, request.ValidationErrorDetail{
+				Code: request.INVALID_FORMAT,
+				Msg:  "Option IDs must be valid UUIDs",
+			})
+		}
+	}
+	return errors, nil
+}
@@ -4,9 +4,11 @@ import (
 	"echo-backend/services/message-service/api/v1/dto"
 	"echo-backend/services/message-service/internal/models"
 	"net/http"
+	pkgErrors "shared/pkg/errors"
 	"shared/pkg/logger"
 	req "shared/server/request"
 	"shared/server/response"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -85,6 +87,7 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		ParentMessageID: parentMessageID,
 		Mentions:        request.Mentions,
 		Metadata:        metadata,
+		ScheduledAt:     request.ScheduledAt,
 	})
 
 	if err != nil {
@@ -93,7 +96,7 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 			logger.String("conversation_id", request.ConversationID),
 			logger.Error(err),
 		)
-		response.InternalServerError(r.Context(), r, w, "Failed to send message", err)
+		writeMessageError(r, w, err, "Failed to send message")
 		return
 	}
 
@@ -103,8 +106,13 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		logger.String("conversation_id", message.ConversationID.String()),
 	)
 
+	statusMessage := "Message sent successfully"
+	if message.IsScheduled {
+		statusMessage = "Message scheduled successfully"
+	}
+
 	// Send response
-	response.JSONWithMessage(r.Context(), r, w, http.StatusCreated, "Message sent successfully",
+	response.JSONWithMessage(r.Context(), r, w, http.StatusCreated, statusMessage,
 		dto.NewSendMessageResponse(message),
 	)
 }
@@ -131,8 +139,10 @@ func (h *MessageHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	viewerID := uuid.MustParse(userID)
 	messages, err := h.service.GetMessages(r.Context(), uuid.MustParse(request.ConversationID), &models.PaginationParams{
-		Limit: request.Limit,
+		Limit:    request.Limit,
+		ViewerID: &viewerID,
 	})
 	if err != nil {
 		h.log.Error("Failed to get messages",
@@ -151,6 +161,125 @@ func (h *MessageHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Messages retrieved successfully", responseDTO)
 }
 
+// GetConversationMessages handles paging through a conversation's history.
+// Query parameters: before or after (message ID cursor) and limit.
+func (h *MessageHandler) GetConversationMessages(w http.ResponseWriter, r *http.Request) {
+	handler := req.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Get conversation messages request received",
+		logger.String("service", "message-service"),
+		logger.String("request_id", requestID),
+	)
+
+	userID, ok := req.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.UnauthorizedError(r.Context(), r, w, "User not authenticated", nil)
+		return
+	}
+
+	conversationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.BadRequestError(r.Context(), r, w, "Invalid conversation ID", err)
+		return
+	}
+
+	query := r.URL.Query()
+	params := &models.PaginationParams{}
+	if limit := query.Get("limit"); limit != "" {
+		params.Limit, err = strconv.Atoi(limit)
+		if err != nil || params.Limit < 1 {
+			response.BadRequestError(r.Context(), r, w, "Limit must be a positive integer", err)
+			return
+		}
+	}
+	for name, target := range map[string]**uuid.UUID{"before": &params.BeforeID, "after": &params.AfterID} {
+		if raw := query.Get(name); raw != "" {
+			cursor, err := uuid.Parse(raw)
+			if err != nil {
+				response.BadRequestError(r.Context(), r, w, "Invalid "+name+" cursor", err)
+				return
+			}
+			*target = &cursor
+		}
+	}
+	if params.BeforeID != nil && params.AfterID != nil {
+		response.BadRequestError(r.Context(), r, w, "Use either before or after, not both", nil)
+		return
+	}
+
+	messages, err := h.service.GetConversationHistory(r.Context(), conversationID, uuid.MustParse(userID), params)
+	if err != nil {
+		h.log.Error("Failed to get conversation messages",
+			logger.String("conversation_id", conversationID.String()),
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+		writeMessageError(r, w, err, "Failed to get messages")
+		return
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Messages retrieved successfully", messages)
+}
+
+// SearchMessages handles full-text search within a conversation. Query
+// parameters: q (websearch syntax, "quoted phrases" supported), limit, offset.
+func (h *MessageHandler) SearchMessages(w http.ResponseWriter, r *http.Request) {
+	handler := req.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Search messages request received",
+		logger.String("service", "message-service"),
+		logger.String("request_id", requestID),
+	)
+
+	userID, ok := req.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.UnauthorizedError(r.Context(), r, w, "User not authenticated", nil)
+		return
+	}
+
+	conversationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.BadRequestError(r.Context(), r, w, "Invalid conversation ID", err)
+		return
+	}
+
+	query := r.URL.Query()
+	text := query.Get("q")
+	if text == "" {
+		response.BadRequestError(r.Context(), r, w, "Search query is required", nil)
+		return
+	}
+
+	var limit, offset int
+	if raw := query.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 {
+			response.BadRequestError(r.Context(), r, w, "Limit must be a positive integer", err)
+			return
+		}
+	}
+	if raw := query.Get("offset"); raw != "" {
+		if offset, err = strconv.Atoi(raw); err != nil || offset < 0 {
+			response.BadRequestError(r.Context(), r, w, "Offset must be a non-negative integer", err)
+			return
+		}
+	}
+
+	results, err := h.service.SearchMessages(r.Context(), conversationID, uuid.MustParse(userID), text, limit, offset)
+	if err != nil {
+		h.log.Error("Failed to search messages",
+			logger.String("conversation_id", conversationID.String()),
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+		writeMessageError(r, w, err, "Failed to search messages")
+		return
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Search completed successfully", results)
+}
+
 // EditMessage handles editing an existing message
 func (h *MessageHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
 	handler := req.NewHandler(r, w)
@@ -170,9 +299,9 @@ func (h *MessageHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
 
 	// Get message ID from path
 	vars := mux.Vars(r)
-	messageID := vars["id"]
-	if messageID == "" {
-		response.BadRequestError(r.Context(), r, w, "Message ID is required", nil)
+	messageID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		response.BadRequestError(r.Context(), r, w, "Invalid message ID", err)
 		return
 	}
 
@@ -183,23 +312,41 @@ func (h *MessageHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call service layer
-	err := h.service.EditMessage(r.Context(), uuid.MustParse(messageID), uuid.MustParse(userID), request.Content)
+	message, err := h.service.EditMessage(r.Context(), messageID, uuid.MustParse(userID), request.Content)
 	if err != nil {
 		h.log.Error("Failed to edit message",
 			logger.String("user_id", userID),
-			logger.String("message_id", messageID),
+			logger.String("message_id", messageID.String()),
 			logger.Error(err),
 		)
-		response.InternalServerError(r.Context(), r, w, "Failed to edit message", err)
+		writeMessageError(r, w, err, "Failed to edit message")
 		return
 	}
 
 	h.log.Info("Message edited successfully",
 		logger.String("user_id", userID),
-		logger.String("message_id", messageID),
+		logger.String("message_id", messageID.String()),
 	)
 
-	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Message edited successfully", nil)
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Message edited successfully", dto.NewMessageStateResponse(message))
+}
+
+// writeMessageError maps service error codes onto HTTP responses
+func writeMessageError(r *http.Request, w http.ResponseWriter, err error, fallback string) {
+	switch pkgErrors.GetCode(err) {
+	case pkgErrors.CodeInvalidArgument:
+		response.BadRequestError(r.Context(), r, w, err.Error(), err)
+	case pkgErrors.CodeNotFound:
+		response.NotFoundError(r.Context(), r, w, "Message")
+	case pkgErrors.CodeForbidden:
+		response.ForbiddenError(r.Context(), r, w, err.Error(), err)
+	case pkgErrors.CodeConflict:
+		response.ConflictError(r.Context(), r, w, err.Error(), err)
+	case pkgErrors.CodeGone:
+		response.RespondWithError(r.Context(), r, w, http.StatusGone, err)
+	default:
+		response.InternalServerError(r.Context(), r, w, fallback, err)
+	}
 }
 
 // DeleteMessage handles deleting a message
@@ -221,30 +368,88 @@ func (h *MessageHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
 
 	// Get message ID from path
 	vars := mux.Vars(r)
-	messageID := vars["id"]
-	if messageID == "" {
-		response.BadRequestError(r.Context(), r, w, "Message ID is required", nil)
+	messageID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		response.BadRequestError(r.Context(), r, w, "Invalid message ID", err)
+		return
+	}
+
+	// Deleting for everyone stays the default for clients that send no scope
+	scope := r.URL.Query().Get("scope")
+	if scope == "" {
+		scope = models.DeleteScopeEveryone
+	}
+	if scope != models.DeleteScopeMe && scope != models.DeleteScopeEveryone {
+		response.BadRequestError(r.Context(), r, w, "Scope must be one of: me, everyone", nil)
 		return
 	}
 
 	// Call service layer
-	err := h.service.DeleteMessage(r.Context(), uuid.MustParse(messageID), uuid.MustParse(userID))
+	message, err := h.service.DeleteMessage(r.Context(), messageID, uuid.MustParse(userID), scope)
 	if err != nil {
 		h.log.Error("Failed to delete message",
 			logger.String("user_id", userID),
-			logger.String("message_id", messageID),
+			logger.String("message_id", messageID.String()),
+			logger.String("scope", scope),
 			logger.Error(err),
 		)
-		response.InternalServerError(r.Context(), r, w, "Failed to delete message", err)
+		writeMessageError(r, w, err, "Failed to delete message")
 		return
 	}
 
 	h.log.Info("Message deleted successfully",
 		logger.String("user_id", userID),
-		logger.String("message_id", messageID),
+		logger.String("message_id", messageID.String()),
+		logger.String("scope", scope),
 	)
 
-	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Message deleted successfully", nil)
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Message deleted successfully", dto.NewMessageStateResponse(message))
+}
+
+// ForwardMessage handles copying a message into other conversations
+func (h *MessageHandler) ForwardMessage(w http.ResponseWriter, r *http.Request) {
+	handler := req.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Forward message request received",
+		logger.String("service", "message-service"),
+		logger.String("request_id", requestID),
+	)
+
+	userID, ok := req.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.UnauthorizedError(r.Context(), r, w, "User not authenticated", nil)
+		return
+	}
+
+	messageID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.BadRequestError(r.Context(), r, w, "Invalid message ID", err)
+		return
+	}
+
+	request := dto.NewForwardMessageRequest()
+	if !handler.ParseValidateAndSend(request) {
+		return
+	}
+
+	targetIDs := make([]uuid.UUID, 0, len(request.ConversationIDs))
+	for _, id := range request.ConversationIDs {
+		targetIDs = append(targetIDs, uuid.MustParse(id))
+	}
+
+	messages, err := h.service.ForwardMessage(r.Context(), messageID, uuid.MustParse(userID), targetIDs)
+	if err != nil {
+		h.log.Error("Failed to forward message",
+			logger.String("user_id", userID),
+			logger.String("message_id", messageID.String()),
+			logger.Error(err),
+		)
+		writeMessageError(r, w, err, "Failed to forward message")
+		return
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusCreated, "Message forwarded successfully", dto.NewForwardMessageResponse(messages))
 }
 
 // MarkAsRead handles marking a message as read
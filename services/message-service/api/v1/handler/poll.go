@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"echo-backend/services/message-service/api/v1/dto"
+	"echo-backend/services/message-service/internal/models"
+	"net/http"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+	req "shared/server/request"
+	"shared/server/response"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CreatePoll handles posting a poll to a conversation
+func (h *MessageHandler) CreatePoll(w http.ResponseWriter, r *http.Request) {
+	handler := req.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Create poll request received",
+		logger.String("service", "message-service"),
+		logger.String("request_id", requestID),
+	)
+
+	userID, ok := req.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.UnauthorizedError(r.Context(), r, w, "User not authenticated", nil)
+		return
+	}
+
+	conversationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.BadRequestError(r.Context(), r, w, "Invalid conversation ID", err)
+		return
+	}
+
+	request := dto.NewCreatePollRequest()
+	if !handler.ParseValidateAndSend(request) {
+		return
+	}
+
+	poll, err := h.service.CreatePoll(r.Context(), &models.CreatePollRequest{
+		ConversationID: conversationID,
+		CreatorUserID:  uuid.MustParse(userID),
+		Question:       request.Question,
+		Options:        request.Options,
+		AllowMultiple:  request.AllowMultiple,
+		IsAnonymous:    request.IsAnonymous,
+		IsQuiz:         request.IsQuiz,
+		CorrectOption:  request.CorrectOptionID,
+		Explanation:    request.Explanation,
+		ClosesAt:       request.ClosesAt,
+	})
+	if err != nil {
+		h.log.Error("Failed to create poll",
+			logger.String("user_id", userID),
+			logger.String("conversation_id", conversationID.String()),
+			logger.Error(err),
+		)
+		writePollError(r, w, err, "Failed to create poll")
+		return
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusCreated, "Poll created successfully", poll)
+}
+
+// VotePoll handles answering a poll; the selection replaces any earlier vote
+func (h *MessageHandler) VotePoll(w http.ResponseWriter, r *http.Request) {
+	handler := req.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Vote poll request received",
+		logger.String("service", "message-service"),
+		logger.String("request_id", requestID),
+	)
+
+	userID, ok := req.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.UnauthorizedError(r.Context(), r, w, "User not authenticated", nil)
+		return
+	}
+
+	pollID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.BadRequestError(r.Context(), r, w, "Invalid poll ID", err)
+		return
+	}
+
+	request := dto.NewVotePollRequest()
+	if !handler.ParseValidateAndSend(request) {
+		return
+	}
+
+	optionIDs := make([]uuid.UUID, 0, len(request.OptionIDs))
+	for _, id := range request.OptionIDs {
+		optionIDs = append(optionIDs, uuid.MustParse(id))
+	}
+
+	poll, err := h.service.VotePoll(r.Context(), pollID, uuid.MustParse(userID), optionIDs)
+	if err != nil {
+		h.log.Error("Failed to vote on poll",
+			logger.String("user_id", userID),
+			logger.String("poll_id", pollID.String()),
+			logger.Error(err),
+		)
+		writePollError(r, w, err, "Failed to vote on poll")
+		return
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Vote recorded successfully", poll)
+}
+
+// ClosePoll handles closing a poll to further votes
+func (h *MessageHandler) ClosePoll(w http.ResponseWriter, r *http.Request) {
+	handler := req.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Close poll request received",
+		logger.String("service", "message-service"),
+		logger.String("request_id", requestID),
+	)
+
+	userID, ok := req.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.UnauthorizedError(r.Context(), r, w, "User not authenticated", nil)
+		return
+	}
+
+	pollID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.BadRequestError(r.Context(), r, w, "Invalid poll ID", err)
+		return
+	}
+
+	poll, err := h.service.ClosePoll(r.Context(), pollID, uuid.MustParse(userID))
+	if err != nil {
+		h.log.Error("Failed to close poll",
+			logger.String("user_id", userID),
+			logger.String("poll_id", pollID.String()),
+			logger.Error(err),
+		)
+		writePollError(r, w, err, "Failed to close poll")
+		return
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Poll closed successfully", poll)
+}
+
+func writePollError(r *http.Request, w http.ResponseWriter, err error, fallback string) {
+	if pkgErrors.GetCode(err) == pkgErrors.CodeNotFound {
+		response.NotFoundError(r.Context(), r, w, "Poll")
+		return
+	}
+	writeMessageError(r, w, err, fallback)
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"echo-backend/services/message-service/api/v1/dto"
+	"net/http"
+	"shared/pkg/logger"
+	req "shared/server/request"
+	"shared/server/response"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// AddReaction handles reacting to a message
+func (h *MessageHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	handler := req.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Add reaction request received",
+		logger.String("service", "message-service"),
+		logger.String("request_id", requestID),
+	)
+
+	userID, ok := req.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.UnauthorizedError(r.Context(), r, w, "User not authenticated", nil)
+		return
+	}
+
+	messageID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.BadRequestError(r.Context(), r, w, "Invalid message ID", err)
+		return
+	}
+
+	request := dto.NewAddReactionRequest()
+	if !handler.ParseValidateAndSend(request) {
+		return
+	}
+
+	summary, err := h.service.AddReaction(r.Context(), messageID, uuid.MustParse(userID), request.ReactionType, request.Emoji, request.SkinTone)
+	if err != nil {
+		h.log.Error("Failed to add reaction",
+			logger.String("user_id", userID),
+			logger.String("message_id", messageID.String()),
+			logger.Error(err),
+		)
+		writeMessageError(r, w, err, "Failed to add reaction")
+		return
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Reaction added successfully", summary)
+}
+
+// RemoveReaction handles withdrawing a reaction; the type comes from the
+// reaction_type query parameter
+func (h *MessageHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	handler := req.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Remove reaction request received",
+		logger.String("service", "message-service"),
+		logger.String("request_id", requestID),
+	)
+
+	userID, ok := req.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.UnauthorizedError(r.Context(), r, w, "User not authenticated", nil)
+		return
+	}
+
+	messageID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.BadRequestError(r.Context(), r, w, "Invalid message ID", err)
+		return
+	}
+
+	reactionType := r.URL.Query().Get("reaction_type")
+	if reactionType == "" {
+		response.BadRequestError(r.Context(), r, w, "Reaction type is required", nil)
+		return
+	}
+
+	summary, err := h.service.RemoveReaction(r.Context(), messageID, uuid.MustParse(userID), reactionType)
+	if err != nil {
+		h.log.Error("Failed to remove reaction",
+			logger.String("user_id", userID),
+			logger.String("message_id", messageID.String()),
+			logger.Error(err),
+		)
+		writeMessageError(r, w, err, "Failed to remove reaction")
+		return
+	}
+
+	response.JSONWithMessage(r.Context(), r, w, http.StatusOK, "Reaction removed successfully", summary)
+}
@@ -12,6 +12,7 @@ import (
 	"echo-backend/services/message-service/internal/health"
 	healthCheckers "echo-backend/services/message-service/internal/health/checkers"
 	"echo-backend/services/message-service/internal/repo"
+	"echo-backend/services/message-service/internal/scheduler"
 	"echo-backend/services/message-service/internal/service"
 	"echo-backend/services/message-service/internal/websocket"
 
@@ -35,6 +36,8 @@ func createLogger(name string) logger.Logger {
 	log, err := adapter.NewZap(logger.Config{
 		Level:   logger.GetLoggerLevel(),
 		Format:  logger.GetLoggerFormat(),
+		Output:  logger.GetLoggerOutput(),
+		File:    logger.GetLoggerFile(),
 		Service: name,
 	})
 	if err != nil {
@@ -139,19 +142,32 @@ func setupAPIRoutes(
 
 	// Message endpoints (root level - API Gateway routes /api/v1/messages to this service)
 	builder = builder.WithRoutes(func(r *router.Router) {
-		r.Get("/ws", wsHandler.HandleConnection)             // WebSocket connection
-		r.Post("/", messageHandler.SendMessage)              // Send a new message
-		r.Get("/", messageHandler.GetMessages)               // Get messages (with query params)
-		r.Put("/{id}", messageHandler.EditMessage)           // Edit a message
-		r.Delete("/{id}", messageHandler.DeleteMessage)      // Delete a message
-		r.Post("/read", messageHandler.MarkAsRead)           // Mark message as read
-		r.Post("/typing", messageHandler.SetTypingIndicator) // Set typing indicator
+		r.Get("/ws", wsHandler.HandleConnection)                   // WebSocket connection
+		r.Post("/", messageHandler.SendMessage)                    // Send a new message
+		r.Get("/", messageHandler.GetMessages)                     // Get messages (with query params)
+		r.Patch("/{id}", messageHandler.EditMessage)               // Edit a message
+		r.Put("/{id}", messageHandler.EditMessage)                 // Edit a message (legacy)
+		r.Delete("/{id}", messageHandler.DeleteMessage)            // Delete a message
+		r.Post("/{id}/reactions", messageHandler.AddReaction)      // React to a message
+		r.Delete("/{id}/reactions", messageHandler.RemoveReaction) // Remove a reaction
+		r.Post("/{id}/forward", messageHandler.ForwardMessage)     // Forward a message to other conversations
+		r.Post("/read", messageHandler.MarkAsRead)                 // Mark message as read
+		r.Post("/typing", messageHandler.SetTypingIndicator)       // Set typing indicator
 	})
 
 	// Conversation endpoints
 	builder = builder.WithRoutesGroup("/conversations", func(rg *router.RouteGroup) {
-		rg.Post("", conversationHandler.CreateConversation) // Create new conversation
-		rg.Get("", conversationHandler.GetConversations)    // Get user's conversations
+		rg.Post("", conversationHandler.CreateConversation)              // Create new conversation
+		rg.Get("", conversationHandler.GetConversations)                 // Get user's conversations
+		rg.Get("/{id}/messages", messageHandler.GetConversationMessages) // Page through a conversation's messages
+		rg.Get("/{id}/search", messageHandler.SearchMessages)            // Full-text search within a conversation
+		rg.Post("/{id}/polls", messageHandler.CreatePoll)                // Post a poll to a conversation
+	})
+
+	// Poll endpoints
+	builder = builder.WithRoutesGroup("/polls", func(rg *router.RouteGroup) {
+		rg.Post("/{id}/vote", messageHandler.VotePoll)   // Answer a poll
+		rg.Post("/{id}/close", messageHandler.ClosePoll) // Stop a poll from taking votes
 	})
 
 	log.Debug("API routes registered successfully")
@@ -163,12 +179,14 @@ func createRouter(
 	conversationHandler *handler.ConversationHandler,
 	wsHandler *websocket.Handler,
 	healthHandler *health.Handler,
+	inFlight *shutdown.InFlight,
 	cfg *config.Config,
 	log logger.Logger,
 ) (*router.Router, error) {
 
 	builder := router.NewBuilder().
 		WithHealthEndpoint("/health", healthHandler.Health).
+		WithLogLevelEndpoint("/admin/loglevel", log, env.LogLevelEndpointEnabled()).
 		WithNotFoundHandler(func(w http.ResponseWriter, r *http.Request) {
 			response.RouteNotFoundError(r.Context(), r, w, log)
 		}).
@@ -176,20 +194,25 @@ func createRouter(
 			response.MethodNotAllowedError(r.Context(), r, w)
 		}).
 		WithEarlyMiddleware(
+			router.Middleware(inFlight.Middleware),
 			router.Middleware(middleware.Timeout(30*time.Second)),
 			router.Middleware(middleware.BodyLimit(10*1024*1024)),
 			router.Middleware(middleware.RequestReceivedLogger(log)),
+			router.Middleware(middleware.InterceptUserId()),
+			// Every request arrives from the gateway, so limit per user; this
+			// needs InterceptUserId above to have set the user ID
 			router.Middleware(middleware.RateLimit(middleware.RateLimitConfig{
 				RequestsPerWindow: 100,
 				Window:            time.Minute,
+				KeyByUser:         true,
 			})),
-			router.Middleware(middleware.InterceptUserId()),
 			router.Middleware(middleware.InterceptSessionId()),
 			router.Middleware(middleware.InterceptSessionToken()),
 		).
 		WithLateMiddleware(
 			router.Middleware(middleware.Recovery(log)),
 			router.Middleware(middleware.RequestCompletedLogger(log)),
+			router.Middleware(middleware.BodyLogger(middleware.BodyLoggerConfig{Enabled: env.BodyLoggingEnabled(), Logger: log})),
 		)
 
 	builder = setupAPIRoutes(builder, messageHandler, conversationHandler, wsHandler, log)
@@ -198,15 +221,36 @@ func createRouter(
 	return r, nil
 }
 
-func setupShutdownManager(srv *server.Server, hub *websocket.Hub, log logger.Logger, cfg *config.Config) *shutdown.Manager {
+// createSweepLocker returns the Redis locker that keeps each background sweep
+// to one replica, or nil when no Redis cache is configured
+func createSweepLocker(cacheClient cache.Cache, log logger.Logger) *redis.Locker {
+	if cacheClient == nil {
+		log.Warn("Cache is disabled; background sweeps run without a distributed lock")
+		return nil
+	}
+	locker, err := redis.NewLockerFromCache(cacheClient)
+	if err != nil {
+		log.Warn("Background sweeps run without a distributed lock", logger.Error(err))
+		return nil
+	}
+	return locker
+}
+
+func setupShutdownManager(srv *server.Server, inFlight *shutdown.InFlight, hub *websocket.Hub, workers []*scheduler.Worker, kafkaProducer messaging.Producer, log logger.Logger, cfg *config.Config) *shutdown.Manager {
 	shutdownMgr := shutdown.New(
 		shutdown.WithTimeout(cfg.Server.ShutdownTimeout),
 		shutdown.WithLogger(log),
 	)
 
+	// Draining runs inside the server hook, after the listeners close, so it
+	// sees the requests the server is still handling
+	serverHook := shutdown.ServerShutdownHook(srv)
+	if cfg.Shutdown.WaitForConnections && cfg.Shutdown.DrainTimeout > 0 {
+		serverHook = shutdown.ServerDrainHook(srv, inFlight, cfg.Shutdown.DrainTimeout, log)
+	}
 	shutdownMgr.RegisterWithPriority(
 		"http-server",
-		shutdown.ServerShutdownHook(srv),
+		serverHook,
 		shutdown.PriorityHigh,
 	)
 
@@ -220,14 +264,28 @@ func setupShutdownManager(srv *server.Server, hub *websocket.Hub, log logger.Log
 		shutdown.PriorityHigh,
 	)
 
-	if cfg.Shutdown.WaitForConnections && cfg.Shutdown.DrainTimeout > 0 {
-		shutdownMgr.RegisterWithOptions(
-			"drain-connections",
-			shutdown.DelayHook(cfg.Shutdown.DrainTimeout),
-			shutdown.PriorityHigh,
-			cfg.Shutdown.DrainTimeout,
-		)
-	}
+	shutdownMgr.RegisterWithPriority(
+		"background-sweeps",
+		shutdown.Hook(func(ctx context.Context) error {
+			log.Info("Stopping background sweeps")
+			for _, worker := range workers {
+				if err := worker.Stop(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+		shutdown.PriorityHigh,
+	)
+
+	shutdownMgr.RegisterWithPriority(
+		"kafka-producer-flush",
+		shutdown.Hook(func(ctx context.Context) error {
+			log.Info("Flushing in-flight Kafka messages")
+			return kafkaProducer.Flush(ctx)
+		}),
+		shutdown.PriorityNormal,
+	)
 
 	shutdownMgr.RegisterWithPriority(
 		"logger-sync",
@@ -317,10 +375,12 @@ func main() {
 	log.Info("WebSocket hub started")
 
 	healthMgr := health.NewManager(cfg.Service.Name, cfg.Service.Version)
+	healthMgr.SetCacheTTL(cfg.Monitoring.HealthCacheTTL)
 	healthMgr.RegisterChecker(healthCheckers.NewDatabaseChecker(dbClient))
 	if cfg.Cache.Enabled && cacheClient != nil {
 		healthMgr.RegisterChecker(healthCheckers.NewCacheChecker(cacheClient))
 	}
+	healthMgr.RegisterChecker(healthCheckers.NewKafkaChecker(kafkaProducer, cfg.Kafka.HealthTimeout))
 	log.Info("Health checks registered")
 
 	// Initialize repositories
@@ -328,16 +388,36 @@ func main() {
 	conversationRepo := repo.NewConversationRepository(dbClient)
 
 	// Initialize services
-	messageService := service.NewMessageService(messageRepo, hub, kafkaProducer, log)
+	messageService := service.NewMessageService(messageRepo, hub, kafkaProducer, service.Config{
+		EventsTopic:     cfg.Kafka.Topic,
+		EditWindow:      cfg.Limits.EditWindow,
+		DeleteWindow:    cfg.Limits.DeleteWindow,
+		SearchLanguage:  cfg.Search.Language,
+		ExpiryBatchSize: cfg.Scheduler.ExpiryBatchSize,
+	}, log)
 	conversationService := service.NewConversationService(conversationRepo, log)
 
+	sweepLocker := createSweepLocker(cacheClient, log)
+	deliveryWorker := scheduler.NewWorker("scheduled-delivery", messageService.DeliverScheduledMessages, sweepLocker, scheduler.Config{
+		Interval: cfg.Scheduler.PollInterval,
+		LockTTL:  cfg.Scheduler.LockTTL,
+	}, log)
+	deliveryWorker.Start()
+
+	expiryWorker := scheduler.NewWorker("message-expiry", messageService.ExpireMessages, sweepLocker, scheduler.Config{
+		Interval: cfg.Scheduler.ExpiryInterval,
+		LockTTL:  cfg.Scheduler.LockTTL,
+	}, log)
+	expiryWorker.Start()
+
 	// Initialize handlers
 	messageHandler := handler.NewMessageHandler(messageService, log)
 	conversationHandler := handler.NewConversationHandler(conversationService, log)
 	wsHandler := websocket.NewHandler(hub, log)
 	healthHandler := health.NewHandler(healthMgr)
 
-	routerInstance, err := createRouter(messageHandler, conversationHandler, wsHandler, healthHandler, cfg, log)
+	inFlight := shutdown.NewInFlight()
+	routerInstance, err := createRouter(messageHandler, conversationHandler, wsHandler, healthHandler, inFlight, cfg, log)
 	if err != nil {
 		log.Fatal("Failed to create router", logger.Error(err))
 	}
@@ -358,7 +438,7 @@ func main() {
 		log.Fatal("Failed to create server", logger.Error(err))
 	}
 
-	shutdownMgr := setupShutdownManager(srv, hub, log, cfg)
+	shutdownMgr := setupShutdownManager(srv, inFlight, hub, []*scheduler.Worker{deliveryWorker, expiryWorker}, kafkaProducer, log, cfg)
 
 	serverErrors := make(chan error, 1)
 	go func() {
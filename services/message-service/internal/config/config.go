@@ -17,6 +17,8 @@ type Config struct {
 	Security   SecurityConfig   `yaml:"security" mapstructure:"security"`
 	Features   FeaturesConfig   `yaml:"features" mapstructure:"features"`
 	Limits     LimitsConfig     `yaml:"limits" mapstructure:"limits"`
+	Search     SearchConfig     `yaml:"search" mapstructure:"search"`
+	Scheduler  SchedulerConfig  `yaml:"scheduler" mapstructure:"scheduler"`
 }
 
 type ServiceConfig struct {
@@ -64,6 +66,8 @@ type KafkaConfig struct {
 	Acks              string   `yaml:"acks" mapstructure:"acks"`
 	EnableIdempotence bool     `yaml:"enable_idempotence" mapstructure:"enable_idempotence"`
 	MaxInFlight       int      `yaml:"max_in_flight" mapstructure:"max_in_flight"`
+	// HealthTimeout caps the broker check run by the readiness probe
+	HealthTimeout time.Duration `yaml:"health_timeout" mapstructure:"health_timeout"`
 }
 
 type CacheConfig struct {
@@ -133,13 +137,14 @@ type ShutdownConfig struct {
 }
 
 type MonitoringConfig struct {
-	Enabled           bool    `yaml:"enabled" mapstructure:"enabled"`
-	MetricsEnabled    bool    `yaml:"metrics_enabled" mapstructure:"metrics_enabled"`
-	MetricsPath       string  `yaml:"metrics_path" mapstructure:"metrics_path"`
-	HealthPath        string  `yaml:"health_path" mapstructure:"health_path"`
-	TracingEnabled    bool    `yaml:"tracing_enabled" mapstructure:"tracing_enabled"`
-	TracingEndpoint   string  `yaml:"tracing_endpoint" mapstructure:"tracing_endpoint"`
-	TracingSampleRate float64 `yaml:"tracing_sample_rate" mapstructure:"tracing_sample_rate"`
+	Enabled           bool          `yaml:"enabled" mapstructure:"enabled"`
+	MetricsEnabled    bool          `yaml:"metrics_enabled" mapstructure:"metrics_enabled"`
+	MetricsPath       string        `yaml:"metrics_path" mapstructure:"metrics_path"`
+	HealthPath        string        `yaml:"health_path" mapstructure:"health_path"`
+	HealthCacheTTL    time.Duration `yaml:"health_cache_ttl" mapstructure:"health_cache_ttl"`
+	TracingEnabled    bool          `yaml:"tracing_enabled" mapstructure:"tracing_enabled"`
+	TracingEndpoint   string        `yaml:"tracing_endpoint" mapstructure:"tracing_endpoint"`
+	TracingSampleRate float64       `yaml:"tracing_sample_rate" mapstructure:"tracing_sample_rate"`
 }
 
 type SecurityConfig struct {
@@ -174,9 +179,30 @@ type FeaturesConfig struct {
 }
 
 type LimitsConfig struct {
-	MaxMessageLength         int `yaml:"max_message_length" mapstructure:"max_message_length"`
-	MaxAttachmentsPerMessage int `yaml:"max_attachments_per_message" mapstructure:"max_attachments_per_message"`
-	MaxMessagesPerRequest    int `yaml:"max_messages_per_request" mapstructure:"max_messages_per_request"`
-	ConversationHistoryDays  int `yaml:"conversation_history_days" mapstructure:"conversation_history_days"`
-	UserConversationsLimit   int `yaml:"user_conversations_limit" mapstructure:"user_conversations_limit"`
+	MaxMessageLength         int           `yaml:"max_message_length" mapstructure:"max_message_length"`
+	MaxAttachmentsPerMessage int           `yaml:"max_attachments_per_message" mapstructure:"max_attachments_per_message"`
+	MaxMessagesPerRequest    int           `yaml:"max_messages_per_request" mapstructure:"max_messages_per_request"`
+	ConversationHistoryDays  int           `yaml:"conversation_history_days" mapstructure:"conversation_history_days"`
+	UserConversationsLimit   int           `yaml:"user_conversations_limit" mapstructure:"user_conversations_limit"`
+	EditWindow               time.Duration `yaml:"edit_window" mapstructure:"edit_window"`
+	DeleteWindow             time.Duration `yaml:"delete_window" mapstructure:"delete_window"`
+}
+
+type SearchConfig struct {
+	// Language is the Postgres text search configuration used to index and
+	// query messages
+	Language string `yaml:"language" mapstructure:"language"`
+}
+
+type SchedulerConfig struct {
+	// PollInterval is how often scheduled messages that have come due are
+	// delivered
+	PollInterval time.Duration `yaml:"poll_interval" mapstructure:"poll_interval"`
+	// ExpiryInterval is how often expired disappearing messages are purged
+	ExpiryInterval time.Duration `yaml:"expiry_interval" mapstructure:"expiry_interval"`
+	// ExpiryBatchSize caps how many messages one purge transaction covers
+	ExpiryBatchSize int `yaml:"expiry_batch_size" mapstructure:"expiry_batch_size"`
+	// LockTTL is how long the sweep lock outlives a replica that died
+	// holding it
+	LockTTL time.Duration `yaml:"lock_ttl" mapstructure:"lock_ttl"`
 }
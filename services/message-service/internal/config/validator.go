@@ -54,6 +54,14 @@ func ValidateAndSetDefaults(cfg *Config) error {
 		return err
 	}
 
+	if err := validateSearch(&cfg.Search); err != nil {
+		return err
+	}
+
+	if err := validateScheduler(&cfg.Scheduler); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -178,6 +186,10 @@ func validateKafka(kafka *KafkaConfig) error {
 		kafka.MaxInFlight = 5
 	}
 
+	if kafka.HealthTimeout <= 0 {
+		kafka.HealthTimeout = 2 * time.Second
+	}
+
 	return nil
 }
 
@@ -326,6 +338,10 @@ func validateMonitoring(monitoring *MonitoringConfig) error {
 		monitoring.HealthPath = "/health"
 	}
 
+	if monitoring.HealthCacheTTL <= 0 {
+		monitoring.HealthCacheTTL = 5 * time.Second
+	}
+
 	if monitoring.TracingSampleRate <= 0 {
 		monitoring.TracingSampleRate = 0.1
 	}
@@ -382,5 +398,65 @@ func validateLimits(limits *LimitsConfig) error {
 		limits.UserConversationsLimit = 1000
 	}
 
+	if limits.EditWindow == 0 {
+		limits.EditWindow = 15 * time.Minute
+	}
+
+	if limits.DeleteWindow == 0 {
+		limits.DeleteWindow = 1 * time.Hour
+	}
+
+	return nil
+}
+
+// searchLanguages are the text search configurations shipped with Postgres
+var searchLanguages = map[string]bool{
+	"simple": true, "arabic": true, "danish": true, "dutch": true, "english": true,
+	"finnish": true, "french": true, "german": true, "hungarian": true, "indonesian": true,
+	"italian": true, "norwegian": true, "portuguese": true, "romanian": true, "russian": true,
+	"spanish": true, "swedish": true, "turkish": true,
+}
+
+func validateSearch(search *SearchConfig) error {
+	if search.Language == "" {
+		search.Language = "english"
+	}
+
+	if !searchLanguages[search.Language] {
+		return fmt.Errorf("unsupported search language: %s", search.Language)
+	}
+
+	return nil
+}
+
+func validateScheduler(scheduler *SchedulerConfig) error {
+	if scheduler.PollInterval == 0 {
+		scheduler.PollInterval = 5 * time.Second
+	}
+
+	if scheduler.ExpiryInterval == 0 {
+		scheduler.ExpiryInterval = 30 * time.Second
+	}
+
+	if scheduler.ExpiryBatchSize == 0 {
+		scheduler.ExpiryBatchSize = 500
+	}
+
+	if scheduler.LockTTL == 0 {
+		scheduler.LockTTL = 30 * time.Second
+	}
+
+	if scheduler.PollInterval < 0 {
+		return fmt.Errorf("scheduler poll interval must be positive")
+	}
+
+	if scheduler.ExpiryInterval < 0 {
+		return fmt.Errorf("scheduler expiry interval must be positive")
+	}
+
+	if scheduler.ExpiryBatchSize < 0 {
+		return fmt.Errorf("scheduler expiry batch size must be positive")
+	}
+
 	return nil
 }
@@ -23,6 +23,11 @@ func (c *DatabaseChecker) Name() string {
 	return "database"
 }
 
+// IsCritical reports that the service cannot serve requests without its database
+func (c *DatabaseChecker) IsCritical() bool {
+	return true
+}
+
 func (c *DatabaseChecker) Check(ctx context.Context) health.CheckResult {
 	start := time.Now()
 	result := health.CheckResult{
@@ -64,11 +69,15 @@ func (c *DatabaseChecker) Check(ctx context.Context) health.CheckResult {
 	queryCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	row := c.db.QueryRow(queryCtx, "SELECT version()")
+	row := c.db.QueryRow(queryCtx, "SHOW server_version")
 	if err := row.Scan(&dbVersion); err != nil {
 		result.Status = health.StatusDegraded
 		result.Error = fmt.Sprintf("Query test failed: %v", err)
 		result.Message = "Database is connected but queries are failing"
+	} else {
+		result.Metadata = map[string]interface{}{
+			"server_version": dbVersion,
+		}
 	}
 
 	result.ResponseTime = float64(time.Since(start).Milliseconds())
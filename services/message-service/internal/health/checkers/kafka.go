@@ -0,0 +1,101 @@
+package checkers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"echo-backend/services/message-service/internal/health"
+	"shared/pkg/messaging"
+)
+
+const defaultKafkaCheckTimeout = 2 * time.Second
+
+type KafkaChecker struct {
+	name    string
+	pinger  messaging.Pinger
+	timeout time.Duration
+}
+
+// NewKafkaChecker checks the brokers behind a producer. The check gives up
+// after timeout so a hanging broker cannot stall the readiness probe.
+func NewKafkaChecker(producer messaging.Producer, timeout time.Duration) *KafkaChecker {
+	return newKafkaChecker("kafka", producer, timeout)
+}
+
+// NewKafkaConsumerChecker checks the brokers behind a consumer group
+func NewKafkaConsumerChecker(consumer messaging.Consumer, timeout time.Duration) *KafkaChecker {
+	return newKafkaChecker("kafka_consumer", consumer, timeout)
+}
+
+// newKafkaChecker keeps client's Pinger, if it has one; clients without it
+// report degraded since connectivity cannot be verified
+func newKafkaChecker(name string, client any, timeout time.Duration) *KafkaChecker {
+	if timeout <= 0 {
+		timeout = defaultKafkaCheckTimeout
+	}
+	pinger, _ := client.(messaging.Pinger)
+	return &KafkaChecker{
+		name:    name,
+		pinger:  pinger,
+		timeout: timeout,
+	}
+}
+
+func (c *KafkaChecker) Name() string {
+	return c.name
+}
+
+func (c *KafkaChecker) Check(ctx context.Context) health.CheckResult {
+	start := time.Now()
+	result := health.CheckResult{
+		Status:      health.StatusHealthy,
+		LastChecked: time.Now().Format(time.RFC3339),
+	}
+	details := health.KafkaDetails{}
+
+	if c.pinger == nil {
+		result.Status = health.StatusDegraded
+		result.Message = "Kafka client does not support connectivity checks"
+		result.Details = map[string]interface{}{
+			"kafka": details,
+		}
+		return result
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	status, err := c.pinger.Ping(pingCtx)
+	result.ResponseTime = float64(time.Since(start).Milliseconds())
+	if err != nil {
+		result.Status = health.StatusUnhealthy
+		result.Error = fmt.Sprintf("Broker metadata request failed: %v", err)
+		result.Message = "Unable to reach any Kafka broker"
+		result.Details = map[string]interface{}{
+			"kafka": details,
+		}
+		return result
+	}
+
+	details.Connected = status.Reachable > 0
+	details.Brokers = status.Brokers
+	details.Topics = status.Topics
+
+	switch {
+	case status.Reachable == 0:
+		result.Status = health.StatusUnhealthy
+		result.Message = "Unable to reach any Kafka broker"
+	case status.Reachable < status.Brokers:
+		result.Status = health.StatusDegraded
+		result.Message = fmt.Sprintf("%d of %d Kafka brokers reachable", status.Reachable, status.Brokers)
+		details.Message = result.Message
+	default:
+		result.Message = "Kafka brokers are healthy"
+	}
+
+	result.Details = map[string]interface{}{
+		"kafka": details,
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"echo-backend/services/message-service/internal/health"
+	"shared/pkg/messaging"
+)
+
+type fakeProducer struct {
+	messaging.Producer
+	status messaging.BrokerStatus
+	err    error
+	block  bool
+}
+
+func (p *fakeProducer) Ping(ctx context.Context) (messaging.BrokerStatus, error) {
+	if p.block {
+		<-ctx.Done()
+		return messaging.BrokerStatus{}, ctx.Err()
+	}
+	return p.status, p.err
+}
+
+func TestKafkaChecker(t *testing.T) {
+	cases := []struct {
+		name     string
+		producer *fakeProducer
+		want     health.Status
+	}{
+		{"all brokers", &fakeProducer{status: messaging.BrokerStatus{Brokers: 3, Reachable: 3}}, health.StatusHealthy},
+		{"some brokers", &fakeProducer{status: messaging.BrokerStatus{Brokers: 3, Reachable: 2}}, health.StatusDegraded},
+		{"metadata failure", &fakeProducer{err: errors.New("no brokers")}, health.StatusUnhealthy},
+		{"hanging broker", &fakeProducer{block: true}, health.StatusUnhealthy},
+	}
+	for _, tc := range cases {
+		checker := NewKafkaChecker(tc.producer, 50*time.Millisecond)
+		start := time.Now()
+		result := checker.Check(context.Background())
+		if result.Status != tc.want {
+			t.Fatalf("%s: expected %s, got %s (%s)", tc.name, tc.want, result.Status, result.Message)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("%s: check ignored its timeout, took %s", tc.name, elapsed)
+		}
+	}
+}
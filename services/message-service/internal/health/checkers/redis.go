@@ -23,6 +23,11 @@ func (c *CacheChecker) Name() string {
 	return "cache"
 }
 
+// IsCritical reports that the service keeps running, degraded, without its cache
+func (c *CacheChecker) IsCritical() bool {
+	return false
+}
+
 func (c *CacheChecker) Check(ctx context.Context) health.CheckResult {
 	start := time.Now()
 	result := health.CheckResult{
@@ -79,6 +84,18 @@ func (c *CacheChecker) Check(ctx context.Context) health.CheckResult {
 	defer cancel()
 	c.cache.Delete(delCtx, testKey)
 
+	// Report the server version when the backend exposes one
+	infoCtx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	if info, err := c.cache.Info(infoCtx); err == nil {
+		if version := serverVersion(info); version != "" {
+			result.Metadata = map[string]interface{}{
+				"server_version": version,
+			}
+		}
+	}
+
 	result.ResponseTime = float64(time.Since(start).Milliseconds())
 	result.Details = map[string]interface{}{
 		"cache": details,
@@ -86,3 +103,12 @@ func (c *CacheChecker) Check(ctx context.Context) health.CheckResult {
 
 	return result
 }
+
+// serverVersion finds redis_version in INFO output, whose keys may be
+// prefixed with their section name
+func serverVersion(info map[string]string) string {
+	if v, ok := info["redis_version"]; ok {
+		return v
+	}
+	return info["Server.redis_version"]
+}
@@ -3,6 +3,7 @@ package health
 import (
 	"context"
 	"net/http"
+	"shared/server/env"
 	"sync"
 	"time"
 )
@@ -12,6 +13,13 @@ type Checker interface {
 	Check(ctx context.Context) CheckResult
 }
 
+// CriticalityChecker is implemented by checkers that declare whether the
+// service can run without their dependency. Checkers that don't implement it
+// are treated as critical.
+type CriticalityChecker interface {
+	IsCritical() bool
+}
+
 type Manager struct {
 	serviceName string
 	version     string
@@ -38,6 +46,14 @@ func NewManager(serviceName, version string) *Manager {
 	}
 }
 
+// SetCacheTTL sets how long a check result is reused before the checker runs
+// again; zero runs every checker on every request
+func (m *Manager) SetCacheTTL(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheTTL = ttl
+}
+
 func (m *Manager) RegisterChecker(checker Checker) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -51,23 +67,18 @@ func (m *Manager) Health(ctx context.Context, includeChecks bool) Response {
 		Service:   m.serviceName,
 		Version:   m.version,
 		Uptime:    time.Since(m.startTime).String(),
+		Build:     env.Build(m.version),
 	}
 
 	if includeChecks {
 		resp.Checks = m.runChecks(ctx)
-		for _, check := range resp.Checks {
-			if check.Status == StatusUnhealthy {
-				resp.Status = StatusUnhealthy
-				break
-			} else if check.Status == StatusDegraded && resp.Status == StatusHealthy {
-				resp.Status = StatusDegraded
-			}
-		}
+		resp.Status = m.overallStatus(resp.Checks)
 	}
 
 	return resp
 }
 
+// Liveness reports whether the process is up; it never runs dependency checks
 func (m *Manager) Liveness(ctx context.Context) Response {
 	return Response{
 		Status:    StatusHealthy,
@@ -75,21 +86,50 @@ func (m *Manager) Liveness(ctx context.Context) Response {
 		Service:   m.serviceName,
 		Version:   m.version,
 		Uptime:    time.Since(m.startTime).String(),
+		Build:     env.Build(m.version),
 	}
 }
 
+// Readiness reports whether the service should receive traffic. Only a
+// failing critical checker makes it unhealthy; a degraded service stays ready.
 func (m *Manager) Readiness(ctx context.Context) Response {
-	resp := m.Health(ctx, true)
-	if resp.Status == StatusUnhealthy {
-		resp.Status = StatusUnhealthy
-	}
-	return resp
+	return m.Health(ctx, true)
 }
 
 func (m *Manager) Detailed(ctx context.Context) Response {
 	return m.Health(ctx, true)
 }
 
+// overallStatus folds check results into the service status: unhealthy if a
+// critical checker is unhealthy, degraded if an optional checker is unhealthy
+// or any checker is degraded, healthy otherwise
+func (m *Manager) overallStatus(checks map[string]CheckResult) Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := StatusHealthy
+	for name, check := range checks {
+		switch check.Status {
+		case StatusHealthy:
+		case StatusUnhealthy:
+			if isCritical(m.checkers[name]) {
+				return StatusUnhealthy
+			}
+			status = StatusDegraded
+		default:
+			status = StatusDegraded
+		}
+	}
+	return status
+}
+
+func isCritical(checker Checker) bool {
+	if c, ok := checker.(CriticalityChecker); ok {
+		return c.IsCritical()
+	}
+	return true
+}
+
 func (m *Manager) runChecks(ctx context.Context) map[string]CheckResult {
 	m.mu.RLock()
 	checkers := make(map[string]Checker, len(m.checkers))
@@ -99,25 +139,27 @@ func (m *Manager) runChecks(ctx context.Context) map[string]CheckResult {
 	m.mu.RUnlock()
 
 	results := make(map[string]CheckResult)
-	now := time.Now()
 
 	for name, checker := range checkers {
 		m.mu.RLock()
-		if cached, ok := m.cache[name]; ok && now.Sub(cached.timestamp) < m.cacheTTL {
+		if cached, ok := m.cache[name]; ok && time.Since(cached.timestamp) < m.cacheTTL {
 			results[name] = cached.result
 			m.mu.RUnlock()
 			continue
 		}
 		m.mu.RUnlock()
 
+		start := time.Now()
 		checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		result := checker.Check(checkCtx)
 		cancel()
+		result.DurationMS = float64(time.Since(start).Microseconds()) / 1000.0
+		result.LastChecked = start.UTC().Format(time.RFC3339)
 
 		m.mu.Lock()
 		m.cache[name] = cachedResult{
 			result:    result,
-			timestamp: now,
+			timestamp: start,
 		}
 		m.mu.Unlock()
 
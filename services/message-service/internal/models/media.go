@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MessageMedia is a media attachment of a message
+type MessageMedia struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	MessageID    uuid.UUID `json:"message_id" db:"message_id"`
+	MediaID      uuid.UUID `json:"media_id" db:"media_id"`
+	MediaType    string    `json:"media_type" db:"media_type"` // image, video, audio, document, voice, sticker
+	DisplayOrder int       `json:"display_order" db:"display_order"`
+	Caption      *string   `json:"caption,omitempty" db:"caption"`
+	ThumbnailURL *string   `json:"thumbnail_url,omitempty" db:"thumbnail_url"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
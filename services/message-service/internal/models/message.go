@@ -25,11 +25,62 @@ type Message struct {
 	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at"`
 	DeletedAt       sql.NullTime    `json:"deleted_at,omitempty" db:"deleted_at"`
 	EditedAt        sql.NullTime    `json:"edited_at,omitempty" db:"edited_at"`
+	EditHistory     json.RawMessage `json:"edit_history,omitempty" db:"edit_history"`
+	ExpiresAt       sql.NullTime    `json:"expires_at,omitempty" db:"expires_at"`
+	ExpireAfter     *int            `json:"expire_after_seconds,omitempty" db:"expire_after_seconds"`
+	DeletedFor      *string         `json:"deleted_for,omitempty" db:"deleted_for"` // everyone
+	ScheduledAt     *time.Time      `json:"scheduled_at,omitempty" db:"scheduled_at"`
+	IsScheduled     bool            `json:"is_scheduled" db:"is_scheduled"`
+
+	IsForwarded            bool       `json:"is_forwarded" db:"is_forwarded"`
+	ForwardedFromMessageID *uuid.UUID `json:"forwarded_from_message_id,omitempty" db:"forwarded_from_message_id"`
+	ForwardCount           int        `json:"forward_count" db:"forward_count"`
 
 	// Joined fields (not in DB)
 	SenderName   string `json:"sender_name,omitempty" db:"-"`
 	SenderAvatar string `json:"sender_avatar,omitempty" db:"-"`
 	ReadCount    int    `json:"read_count,omitempty" db:"-"`
+	DeletedForMe bool   `json:"deleted_for_me,omitempty" db:"-"`
+
+	Media     []MessageMedia  `json:"media,omitempty" db:"-"`
+	Reactions []ReactionCount `json:"reactions,omitempty" db:"-"`
+	Poll      *Poll           `json:"poll,omitempty" db:"-"`
+}
+
+// Delete scopes: "me" hides the message for the requesting user only,
+// "everyone" replaces it with a tombstone for all participants
+const (
+	DeleteScopeMe       = "me"
+	DeleteScopeEveryone = "everyone"
+)
+
+// MessageStatusScheduled marks a message held back until its scheduled_at;
+// the delivery worker moves it to "sent"
+const MessageStatusScheduled = "scheduled"
+
+// MaxForwardTargets caps how many conversations one forward request may
+// copy a message into
+const MaxForwardTargets = 5
+
+// EditHistoryEntry is one previous revision of an edited message
+type EditHistoryEntry struct {
+	Content  string    `json:"content"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+// UnmarshalJSON reads the entries messages.set_edited_timestamp appends to
+// edit_history, which keep the replaced text under previous_content
+func (e *EditHistoryEntry) UnmarshalJSON(data []byte) error {
+	var stored struct {
+		PreviousContent string    `json:"previous_content"`
+		EditedAt        time.Time `json:"edited_at"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	e.Content = stored.PreviousContent
+	e.EditedAt = stored.EditedAt
+	return nil
 }
 
 // SendMessageRequest represents the request to send a message
@@ -41,6 +92,7 @@ type SendMessageRequest struct {
 	ParentMessageID *uuid.UUID `json:"parent_message_id,omitempty" validate:"omitempty"`
 	Mentions        []Mention  `json:"mentions,omitempty"`
 	Metadata        Metadata   `json:"metadata,omitempty"`
+	ScheduledAt     *time.Time `json:"scheduled_at,omitempty"`
 }
 
 // Mention represents a user mention in a message
@@ -91,12 +143,15 @@ type WebSocketMessage struct {
 
 // MessageEvent represents different message events
 type MessageEvent struct {
-	Type      string    `json:"type"`
-	Message   *Message  `json:"message,omitempty"`
-	MessageID uuid.UUID `json:"message_id,omitempty"`
-	UserID    uuid.UUID `json:"user_id,omitempty"`
-	Status    string    `json:"status,omitempty"`
-	Timestamp time.Time `json:"timestamp"`
+	Type           string    `json:"type"`
+	Message        *Message  `json:"message,omitempty"`
+	MessageID      uuid.UUID `json:"message_id,omitempty"`
+	ConversationID uuid.UUID `json:"conversation_id,omitempty"`
+	UserID         uuid.UUID `json:"user_id,omitempty"`
+	Status         string    `json:"status,omitempty"`
+	Reaction       *Reaction `json:"reaction,omitempty"`
+	Poll           *Poll     `json:"poll,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
 }
 
 // ReadReceipt represents a message read receipt
@@ -113,6 +168,7 @@ type ConversationParticipant struct {
 	UserID            uuid.UUID    `json:"user_id" db:"user_id"`
 	Role              string       `json:"role" db:"role"`
 	CanSendMessages   bool         `json:"can_send_messages" db:"can_send_messages"`
+	CanDeleteMessages bool         `json:"can_delete_messages" db:"can_delete_messages"`
 	LastReadMessageID *uuid.UUID   `json:"last_read_message_id,omitempty" db:"last_read_message_id"`
 	LastReadAt        sql.NullTime `json:"last_read_at,omitempty" db:"last_read_at"`
 	UnreadCount       int          `json:"unread_count" db:"unread_count"`
@@ -125,11 +181,13 @@ type PaginationParams struct {
 	Limit    int        `json:"limit" validate:"min=1,max=100"`
 	BeforeID *uuid.UUID `json:"before_id,omitempty"`
 	AfterID  *uuid.UUID `json:"after_id,omitempty"`
+	ViewerID *uuid.UUID `json:"-"` // hides messages the viewer deleted for themselves
 }
 
 // MessagesResponse represents a paginated messages response
 type MessagesResponse struct {
-	Messages []Message `json:"messages"`
-	HasMore  bool      `json:"has_more"`
-	Total    int64     `json:"total,omitempty"`
+	Messages   []Message `json:"messages"`
+	HasMore    bool      `json:"has_more"`
+	Total      int64     `json:"total,omitempty"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
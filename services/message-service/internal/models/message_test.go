@@ -0,0 +1,28 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEditHistoryEntry_DecodesTriggerShape(t *testing.T) {
+	stored := `[{"edited_at": "2026-10-16T15:04:05.123456+00:00", "previous_content": "first draft"}]`
+
+	var history []EditHistoryEntry
+	if err := json.Unmarshal([]byte(stored), &history); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if len(history) != 1 || history[0].Content != "first draft" || history[0].EditedAt.IsZero() {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+
+	out, err := json.Marshal(history[0])
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(out, &fields)
+	if fields["content"] != "first draft" {
+		t.Fatalf("API entry should expose the revision as content, got %s", out)
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Poll limits
+const (
+	MinPollOptions = 2
+	MaxPollOptions = 10
+)
+
+// Poll is attached to a message of type "poll". For quizzes CorrectOptionID
+// is the OptionOrder of the right answer.
+type Poll struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	MessageID       uuid.UUID  `json:"message_id" db:"message_id"`
+	ConversationID  uuid.UUID  `json:"conversation_id" db:"-"`
+	CreatorUserID   uuid.UUID  `json:"creator_user_id" db:"-"`
+	Question        string     `json:"question" db:"question"`
+	AllowMultiple   bool       `json:"allow_multiple_answers" db:"allow_multiple_answers"`
+	IsAnonymous     bool       `json:"is_anonymous" db:"is_anonymous"`
+	IsQuiz          bool       `json:"is_quiz" db:"is_quiz"`
+	CorrectOptionID *int       `json:"correct_option_id,omitempty" db:"correct_option_id"`
+	Explanation     *string    `json:"explanation,omitempty" db:"explanation"`
+	ClosesAt        *time.Time `json:"closes_at,omitempty" db:"closes_at"`
+	IsClosed        bool       `json:"is_closed" db:"is_closed"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+	TotalVotes      int        `json:"total_votes" db:"total_votes"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+
+	Options []PollOption `json:"options" db:"-"`
+
+	// Viewer-specific fields
+	MyVotes   []uuid.UUID `json:"my_votes,omitempty" db:"-"`
+	IsCorrect *bool       `json:"is_correct,omitempty" db:"-"`
+}
+
+// PollOption is one answer of a poll with its running tally
+type PollOption struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	PollID         uuid.UUID `json:"poll_id" db:"poll_id"`
+	OptionText     string    `json:"option_text" db:"option_text"`
+	OptionOrder    int       `json:"option_order" db:"option_order"`
+	VoteCount      int       `json:"vote_count" db:"vote_count"`
+	VotePercentage float64   `json:"vote_percentage" db:"vote_percentage"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// PollVote is one user's choice of one option
+type PollVote struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	PollID       uuid.UUID `json:"poll_id" db:"poll_id"`
+	PollOptionID uuid.UUID `json:"poll_option_id" db:"poll_option_id"`
+	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	VotedAt      time.Time `json:"voted_at" db:"voted_at"`
+}
+
+// IsOpen reports whether the poll still accepts votes at the given time
+func (p *Poll) IsOpen(now time.Time) bool {
+	return !p.IsClosed && (p.ClosesAt == nil || now.Before(*p.ClosesAt))
+}
+
+// CreatePollRequest represents the request to post a poll in a conversation
+type CreatePollRequest struct {
+	ConversationID uuid.UUID
+	CreatorUserID  uuid.UUID
+	Question       string
+	Options        []string
+	AllowMultiple  bool
+	IsAnonymous    bool
+	IsQuiz         bool
+	CorrectOption  *int
+	Explanation    *string
+	ClosesAt       *time.Time
+}
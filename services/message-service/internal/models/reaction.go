@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reaction is one user's reaction of a given type to a message
+type Reaction struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	MessageID        uuid.UUID `json:"message_id" db:"message_id"`
+	UserID           uuid.UUID `json:"user_id" db:"user_id"`
+	ReactionType     string    `json:"reaction_type" db:"reaction_type"`
+	ReactionEmoji    *string   `json:"reaction_emoji,omitempty" db:"reaction_emoji"`
+	ReactionSkinTone *string   `json:"reaction_skin_tone,omitempty" db:"reaction_skin_tone"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// ReactionCount aggregates the reactions of one type on a message
+type ReactionCount struct {
+	ReactionType string  `json:"reaction_type"`
+	Emoji        *string `json:"emoji,omitempty"`
+	Count        int     `json:"count"`
+	ReactedByMe  bool    `json:"reacted_by_me"`
+}
+
+// ReactionSummary is the reaction state of a message as seen by one user
+type ReactionSummary struct {
+	MessageID uuid.UUID       `json:"message_id"`
+	Total     int             `json:"total"`
+	Reactions []ReactionCount `json:"reactions"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SearchResult is a message matching a full-text query. Snippet holds the
+// matching fragments with the hits wrapped in <mark> tags.
+type SearchResult struct {
+	MessageID      uuid.UUID `json:"message_id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderUserID   uuid.UUID `json:"sender_user_id"`
+	Content        string    `json:"content"`
+	MessageType    string    `json:"message_type"`
+	CreatedAt      time.Time `json:"created_at"`
+	Rank           float64   `json:"rank"`
+	Snippet        string    `json:"snippet"`
+}
+
+// SearchResponse is one page of search results
+type SearchResponse struct {
+	Results    []SearchResult `json:"results"`
+	HasMore    bool           `json:"has_more"`
+	NextOffset int            `json:"next_offset,omitempty"`
+}
@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"echo-backend/services/message-service/internal/models"
+	"time"
+
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// GetDisappearingMessagesDuration returns how long new messages in the
+// conversation live, or nil when disappearing messages are off
+func (r *messageRepository) GetDisappearingMessagesDuration(ctx context.Context, conversationID uuid.UUID) (*time.Duration, pkgErrors.AppError) {
+	query := `
+		SELECT disappearing_messages_duration
+		FROM messages.conversation_settings
+		WHERE conversation_id = $1
+		  AND disappearing_messages_enabled = TRUE
+		  AND disappearing_messages_duration > 0
+	`
+
+	var seconds int
+	err := r.db.QueryRow(ctx, query, conversationID).Scan(&seconds)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to get conversation settings").
+			WithDetail("conversation_id", conversationID.String())
+	}
+
+	duration := time.Duration(seconds) * time.Second
+	return &duration, nil
+}
+
+// ExpireMessages turns up to limit messages whose expires_at is at or before
+// now into tombstones and drops their media, reactions and search entries,
+// all in one transaction. Scheduled messages are skipped until delivered.
+// Returns the expired messages with their IDs and conversations filled in.
+func (r *messageRepository) ExpireMessages(ctx context.Context, now time.Time, limit int) ([]models.Message, pkgErrors.AppError) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		WITH due AS (
+			SELECT id FROM messages.messages
+			WHERE expires_at <= $1 AND is_deleted = FALSE AND is_scheduled = FALSE
+			ORDER BY expires_at ASC
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE messages.messages m
+		SET is_deleted = TRUE, deleted_at = $1, deleted_for = 'everyone',
+		    content = '', edit_history = '[]'::JSONB, reaction_count = 0, updated_at = $1
+		FROM due
+		WHERE m.id = due.id
+		RETURNING m.id, m.conversation_id, m.sender_user_id, m.expires_at
+	`
+
+	rows, dbErr := tx.Query(ctx, query, now, limit)
+	if dbErr != nil {
+		return nil, pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to expire messages").
+			WithDetail("limit", limit)
+	}
+
+	expired := make([]models.Message, 0, limit)
+	ids := make([]uuid.UUID, 0, limit)
+	for rows.Next() {
+		var msg models.Message
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.SenderUserID, &msg.ExpiresAt); err != nil {
+			rows.Close()
+			return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan expired message")
+		}
+		msg.IsDeleted = true
+		expired = append(expired, msg)
+		ids = append(ids, msg.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to read expired messages")
+	}
+
+	if len(ids) == 0 {
+		return expired, nil
+	}
+
+	cleanup := []struct {
+		table string
+		query string
+	}{
+		{"message_media", `DELETE FROM messages.message_media WHERE message_id = ANY($1)`},
+		{"reactions", `DELETE FROM messages.reactions WHERE message_id = ANY($1)`},
+		{"search_index", `DELETE FROM messages.search_index WHERE message_id = ANY($1)`},
+	}
+	for _, step := range cleanup {
+		if _, err := tx.Exec(ctx, step.query, pq.Array(ids)); err != nil {
+			return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to clean up expired messages").
+				WithDetail("table", step.table).
+				WithDetail("count", len(ids))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to commit transaction").
+			WithDetail("count", len(ids))
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExpireMessages_ClearsEditHistory(t *testing.T) {
+	db := newIntegrationDB(t)
+	r := NewMessageRepository(db)
+	ctx := context.Background()
+	messageID := createTestMessage(t, db, "first draft")
+
+	if _, err := r.UpdateMessage(ctx, messageID, "second draft"); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	now := time.Now()
+	if _, err := db.Exec(ctx,
+		`UPDATE messages.messages SET expires_at = $1 WHERE id = $2`,
+		now.Add(-time.Minute), messageID,
+	); err != nil {
+		t.Fatalf("failed to backdate expires_at: %v", err)
+	}
+
+	expired, err := r.ExpireMessages(ctx, now, 1000)
+	if err != nil {
+		t.Fatalf("expire failed: %v", err)
+	}
+	found := false
+	for _, msg := range expired {
+		found = found || msg.ID == messageID
+	}
+	if !found {
+		t.Fatalf("message %s was not expired", messageID)
+	}
+
+	var content, history string
+	if err := db.QueryRow(ctx,
+		`SELECT content, edit_history::TEXT FROM messages.messages WHERE id = $1`, messageID,
+	).Scan(&content, &history); err != nil {
+		t.Fatalf("failed to reload message: %v", err)
+	}
+	if content != "" || history != "[]" {
+		t.Fatalf("content=%q edit_history=%s after expiry, want empty content and []", content, history)
+	}
+}
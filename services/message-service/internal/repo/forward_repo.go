@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"echo-backend/services/message-service/internal/models"
+
+	pkgErrors "shared/pkg/errors"
+)
+
+// ForwardMessage stores a forwarded copy of a message and copies the
+// source's attachments onto it in one transaction. The source's
+// forward_count is bumped by the messages insert trigger.
+func (r *messageRepository) ForwardMessage(ctx context.Context, msg *models.Message) pkgErrors.AppError {
+	if msg.ForwardedFromMessageID == nil {
+		return pkgErrors.New(pkgErrors.CodeInvalidArgument, "forwarded message has no source").
+			WithDetail("message_id", msg.ID.String())
+	}
+	sourceID := *msg.ForwardedFromMessageID
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to begin transaction").
+			WithDetail("conversation_id", msg.ConversationID.String())
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if appErr := insertMessage(ctx, tx, msg); appErr != nil {
+		return appErr
+	}
+
+	mediaQuery := `
+		INSERT INTO messages.message_media (
+			message_id, media_id, media_type, display_order, caption, thumbnail_url
+		)
+		SELECT $1, media_id, media_type, display_order, caption, thumbnail_url
+		FROM messages.message_media
+		WHERE message_id = $2
+		ORDER BY display_order ASC
+		RETURNING id, message_id, media_id, media_type, display_order, caption, thumbnail_url, created_at
+	`
+	rows, queryErr := tx.Query(ctx, mediaQuery, msg.ID, sourceID)
+	if queryErr != nil {
+		return pkgErrors.FromError(queryErr, pkgErrors.CodeDatabaseError, "failed to copy message media").
+			WithDetail("message_id", msg.ID.String()).
+			WithDetail("source_message_id", sourceID.String())
+	}
+
+	msg.Media = nil
+	for rows.Next() {
+		var m models.MessageMedia
+		if err := rows.Scan(&m.ID, &m.MessageID, &m.MediaID, &m.MediaType, &m.DisplayOrder, &m.Caption, &m.ThumbnailURL, &m.CreatedAt); err != nil {
+			rows.Close()
+			return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan message media").
+				WithDetail("message_id", msg.ID.String())
+		}
+		msg.Media = append(msg.Media, m)
+	}
+	rowsErr := rows.Err()
+	rows.Close()
+	if rowsErr != nil {
+		return pkgErrors.FromError(rowsErr, pkgErrors.CodeDatabaseError, "failed to copy message media").
+			WithDetail("message_id", msg.ID.String())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to commit transaction").
+			WithDetail("message_id", msg.ID.String())
+	}
+
+	return nil
+}
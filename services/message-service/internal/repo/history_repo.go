@@ -0,0 +1,170 @@
+package repo
+
+import (
+	"context"
+	"echo-backend/services/message-service/internal/models"
+	"fmt"
+
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// GetConversationHistory returns one page of a conversation as seen by
+// viewerID: messages they deleted for themselves, expired messages and other
+// people's undelivered scheduled messages are left out, while messages
+// deleted for everyone come back as tombstones.
+// Pages are keyed on (created_at, id) so messages sharing a timestamp are
+// neither skipped nor repeated. Without AfterID the page runs newest first.
+func (r *messageRepository) GetConversationHistory(ctx context.Context, conversationID, viewerID uuid.UUID, params *models.PaginationParams) ([]models.Message, bool, pkgErrors.AppError) {
+	query := `
+		SELECT m.id, m.conversation_id, m.sender_user_id, m.parent_message_id,
+		       m.content, m.message_type, m.status, m.is_edited, m.is_deleted,
+		       m.mentions, m.metadata, m.created_at, m.updated_at, m.deleted_at, m.edited_at,
+		       m.edit_history, m.expires_at, m.deleted_for,
+		       (SELECT COUNT(*) FROM messages.delivery_status ds
+		        WHERE ds.message_id = m.id AND ds.status = 'read') AS read_count
+		FROM messages.messages m
+		WHERE m.conversation_id = $1
+		  AND (m.expires_at IS NULL OR m.expires_at > NOW())
+		  AND (m.is_scheduled = FALSE OR m.sender_user_id = $2)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM messages.message_deletions md
+		      WHERE md.message_id = m.id AND md.user_id = $2
+		  )
+	`
+	args := []interface{}{conversationID, viewerID}
+
+	order := "DESC"
+	switch {
+	case params.AfterID != nil:
+		query += ` AND (m.created_at, m.id) > (SELECT created_at, id FROM messages.messages WHERE id = $3)`
+		args = append(args, *params.AfterID)
+		order = "ASC"
+	case params.BeforeID != nil:
+		query += ` AND (m.created_at, m.id) < (SELECT created_at, id FROM messages.messages WHERE id = $3)`
+		args = append(args, *params.BeforeID)
+	}
+
+	// One extra row tells us whether another page follows
+	query += fmt.Sprintf(` ORDER BY m.created_at %s, m.id %s LIMIT $%d`, order, order, len(args)+1)
+	args = append(args, params.Limit+1)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, false, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to query conversation history").
+			WithDetail("conversation_id", conversationID.String()).
+			WithDetail("limit", params.Limit)
+	}
+	defer rows.Close()
+
+	messages := make([]models.Message, 0, params.Limit)
+	for rows.Next() {
+		var msg models.Message
+		err := rows.Scan(
+			&msg.ID,
+			&msg.ConversationID,
+			&msg.SenderUserID,
+			&msg.ParentMessageID,
+			&msg.Content,
+			&msg.MessageType,
+			&msg.Status,
+			&msg.IsEdited,
+			&msg.IsDeleted,
+			&msg.Mentions,
+			&msg.Metadata,
+			&msg.CreatedAt,
+			&msg.UpdatedAt,
+			&msg.DeletedAt,
+			&msg.EditedAt,
+			&msg.EditHistory,
+			&msg.ExpiresAt,
+			&msg.DeletedFor,
+			&msg.ReadCount,
+		)
+		if err != nil {
+			return nil, false, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan message").
+				WithDetail("conversation_id", conversationID.String())
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to read conversation history").
+			WithDetail("conversation_id", conversationID.String())
+	}
+
+	hasMore := len(messages) > params.Limit
+	if hasMore {
+		messages = messages[:params.Limit]
+	}
+	return messages, hasMore, nil
+}
+
+// GetMediaForMessages loads the attachments of several messages at once,
+// keyed by message ID
+func (r *messageRepository) GetMediaForMessages(ctx context.Context, messageIDs []uuid.UUID) (map[uuid.UUID][]models.MessageMedia, pkgErrors.AppError) {
+	media := make(map[uuid.UUID][]models.MessageMedia)
+	if len(messageIDs) == 0 {
+		return media, nil
+	}
+
+	query := `
+		SELECT id, message_id, media_id, media_type, display_order, caption, thumbnail_url, created_at
+		FROM messages.message_media
+		WHERE message_id = ANY($1)
+		ORDER BY message_id, display_order ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, pq.Array(messageIDs))
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to query message media").
+			WithDetail("message_count", len(messageIDs))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.MessageMedia
+		if err := rows.Scan(&m.ID, &m.MessageID, &m.MediaID, &m.MediaType, &m.DisplayOrder, &m.Caption, &m.ThumbnailURL, &m.CreatedAt); err != nil {
+			return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan message media")
+		}
+		media[m.MessageID] = append(media[m.MessageID], m)
+	}
+
+	return media, nil
+}
+
+// GetReactionSummaries is GetReactionSummary for several messages at once,
+// keyed by message ID
+func (r *messageRepository) GetReactionSummaries(ctx context.Context, messageIDs []uuid.UUID, viewerID uuid.UUID) (map[uuid.UUID][]models.ReactionCount, pkgErrors.AppError) {
+	summaries := make(map[uuid.UUID][]models.ReactionCount)
+	if len(messageIDs) == 0 {
+		return summaries, nil
+	}
+
+	query := `
+		SELECT message_id, reaction_type, MIN(reaction_emoji), COUNT(*), BOOL_OR(user_id = $2)
+		FROM messages.reactions
+		WHERE message_id = ANY($1)
+		GROUP BY message_id, reaction_type
+		ORDER BY message_id, COUNT(*) DESC, reaction_type ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, pq.Array(messageIDs), viewerID)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to query reactions").
+			WithDetail("message_count", len(messageIDs))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID uuid.UUID
+		var rc models.ReactionCount
+		if err := rows.Scan(&messageID, &rc.ReactionType, &rc.Emoji, &rc.Count, &rc.ReactedByMe); err != nil {
+			return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan reaction")
+		}
+		summaries[messageID] = append(summaries[messageID], rc)
+	}
+
+	return summaries, nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"echo-backend/services/message-service/internal/models"
 	"fmt"
+	"time"
 
 	"shared/pkg/database"
 	pkgErrors "shared/pkg/errors"
@@ -16,10 +17,40 @@ import (
 type MessageRepository interface {
 	// Core message operations
 	CreateMessage(ctx context.Context, msg *models.Message) pkgErrors.AppError
+	ForwardMessage(ctx context.Context, msg *models.Message) pkgErrors.AppError
 	GetMessageByID(ctx context.Context, messageID uuid.UUID) (*models.Message, pkgErrors.AppError)
+	GetMessageIncludingDeleted(ctx context.Context, messageID uuid.UUID) (*models.Message, pkgErrors.AppError)
 	GetMessages(ctx context.Context, conversationID uuid.UUID, params *models.PaginationParams) ([]models.Message, pkgErrors.AppError)
-	UpdateMessage(ctx context.Context, messageID uuid.UUID, content string) pkgErrors.AppError
-	DeleteMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID) pkgErrors.AppError
+	GetConversationHistory(ctx context.Context, conversationID, viewerID uuid.UUID, params *models.PaginationParams) ([]models.Message, bool, pkgErrors.AppError)
+	GetMediaForMessages(ctx context.Context, messageIDs []uuid.UUID) (map[uuid.UUID][]models.MessageMedia, pkgErrors.AppError)
+
+	// Search
+	IndexMessage(ctx context.Context, msg *models.Message, language string) pkgErrors.AppError
+	RemoveFromIndex(ctx context.Context, messageID uuid.UUID) pkgErrors.AppError
+	SearchMessages(ctx context.Context, conversationID, viewerID uuid.UUID, text, language string, limit, offset int) ([]models.SearchResult, bool, pkgErrors.AppError)
+	UpdateMessage(ctx context.Context, messageID uuid.UUID, content string) (*models.Message, pkgErrors.AppError)
+	DeleteMessage(ctx context.Context, messageID uuid.UUID) (*models.Message, pkgErrors.AppError)
+	HideMessageForUser(ctx context.Context, messageID, userID uuid.UUID) pkgErrors.AppError
+
+	// Scheduled delivery
+	DeliverDueScheduledMessages(ctx context.Context, now time.Time, limit int, publish func([]models.Message) error) ([]models.Message, pkgErrors.AppError)
+
+	// Disappearing messages
+	GetDisappearingMessagesDuration(ctx context.Context, conversationID uuid.UUID) (*time.Duration, pkgErrors.AppError)
+	ExpireMessages(ctx context.Context, now time.Time, limit int) ([]models.Message, pkgErrors.AppError)
+
+	// Polls
+	CreatePoll(ctx context.Context, msg *models.Message, poll *models.Poll) pkgErrors.AppError
+	GetPoll(ctx context.Context, pollID uuid.UUID) (*models.Poll, pkgErrors.AppError)
+	GetPollVotes(ctx context.Context, pollID, userID uuid.UUID) ([]uuid.UUID, pkgErrors.AppError)
+	CastPollVote(ctx context.Context, pollID, userID uuid.UUID, optionIDs []uuid.UUID) (*models.Poll, pkgErrors.AppError)
+	ClosePoll(ctx context.Context, pollID uuid.UUID) (*models.Poll, pkgErrors.AppError)
+
+	// Reactions
+	AddReaction(ctx context.Context, reaction *models.Reaction) (bool, pkgErrors.AppError)
+	RemoveReaction(ctx context.Context, messageID, userID uuid.UUID, reactionType string) (bool, pkgErrors.AppError)
+	GetReactionSummary(ctx context.Context, messageID, viewerID uuid.UUID) (*models.ReactionSummary, pkgErrors.AppError)
+	GetReactionSummaries(ctx context.Context, messageIDs []uuid.UUID, viewerID uuid.UUID) (map[uuid.UUID][]models.ReactionCount, pkgErrors.AppError)
 
 	// Delivery tracking
 	CreateDeliveryStatus(ctx context.Context, messageID uuid.UUID, userIDs []uuid.UUID) pkgErrors.AppError
@@ -31,6 +62,7 @@ type MessageRepository interface {
 	// Conversation operations
 	GetConversationParticipants(ctx context.Context, conversationID uuid.UUID) ([]models.ConversationParticipant, pkgErrors.AppError)
 	GetParticipantUserIDs(ctx context.Context, conversationID uuid.UUID) ([]uuid.UUID, pkgErrors.AppError)
+	GetParticipant(ctx context.Context, conversationID, userID uuid.UUID) (*models.ConversationParticipant, pkgErrors.AppError)
 	ValidateParticipant(ctx context.Context, conversationID, userID uuid.UUID) (bool, pkgErrors.AppError)
 	UpdateConversationLastMessage(ctx context.Context, conversationID, messageID uuid.UUID) pkgErrors.AppError
 	UpdateParticipantUnreadCount(ctx context.Context, conversationID, userID uuid.UUID, increment bool) pkgErrors.AppError
@@ -51,16 +83,27 @@ func NewMessageRepository(db database.Database) MessageRepository {
 
 // CreateMessage creates a new message in the database
 func (r *messageRepository) CreateMessage(ctx context.Context, msg *models.Message) pkgErrors.AppError {
+	return insertMessage(ctx, r.db, msg)
+}
+
+// rowQuerier is satisfied by both the database and a transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, query string, args ...interface{}) database.Row
+}
+
+func insertMessage(ctx context.Context, q rowQuerier, msg *models.Message) pkgErrors.AppError {
 	query := `
 		INSERT INTO messages.messages (
 			id, conversation_id, sender_user_id, parent_message_id,
-			content, message_type, status, mentions, metadata, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			content, message_type, status, mentions, metadata, created_at, updated_at,
+			scheduled_at, is_scheduled, expires_at, expire_after_seconds,
+			is_forwarded, forwarded_from_message_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 		RETURNING id, created_at, updated_at
 	`
 
 	// Mentions and Metadata are already json.RawMessage from the service layer
-	row := r.db.QueryRow(ctx, query,
+	row := q.QueryRow(ctx, query,
 		msg.ID,
 		msg.ConversationID,
 		msg.SenderUserID,
@@ -72,6 +115,12 @@ func (r *messageRepository) CreateMessage(ctx context.Context, msg *models.Messa
 		msg.Metadata,
 		msg.CreatedAt,
 		msg.UpdatedAt,
+		msg.ScheduledAt,
+		msg.IsScheduled,
+		msg.ExpiresAt,
+		msg.ExpireAfter,
+		msg.IsForwarded,
+		msg.ForwardedFromMessageID,
 	)
 	err := row.Scan(&msg.ID, &msg.CreatedAt, &msg.UpdatedAt)
 
@@ -89,7 +138,8 @@ func (r *messageRepository) GetMessageByID(ctx context.Context, messageID uuid.U
 	query := `
 		SELECT id, conversation_id, sender_user_id, parent_message_id,
 		       content, message_type, status, is_edited, is_deleted,
-		       mentions, metadata, created_at, updated_at, deleted_at, edited_at
+		       mentions, metadata, created_at, updated_at, deleted_at, edited_at,
+		       is_scheduled, is_forwarded, forwarded_from_message_id, forward_count
 		FROM messages.messages
 		WHERE id = $1 AND is_deleted = FALSE
 	`
@@ -111,6 +161,56 @@ func (r *messageRepository) GetMessageByID(ctx context.Context, messageID uuid.U
 		&msg.UpdatedAt,
 		&msg.DeletedAt,
 		&msg.EditedAt,
+		&msg.IsScheduled,
+		&msg.IsForwarded,
+		&msg.ForwardedFromMessageID,
+		&msg.ForwardCount,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, pkgErrors.New(pkgErrors.CodeNotFound, "message not found").
+			WithDetail("message_id", messageID.String())
+	}
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to get message").
+			WithDetail("message_id", messageID.String())
+	}
+
+	return msg, nil
+}
+
+// GetMessageIncludingDeleted retrieves a message whatever its deletion or
+// expiry state, for operations that must tell those cases apart
+func (r *messageRepository) GetMessageIncludingDeleted(ctx context.Context, messageID uuid.UUID) (*models.Message, pkgErrors.AppError) {
+	query := `
+		SELECT id, conversation_id, sender_user_id, parent_message_id,
+		       content, message_type, status, is_edited, is_deleted,
+		       mentions, metadata, created_at, updated_at, deleted_at, edited_at,
+		       edit_history, expires_at, deleted_for
+		FROM messages.messages
+		WHERE id = $1
+	`
+
+	msg := &models.Message{}
+	err := r.db.QueryRow(ctx, query, messageID).Scan(
+		&msg.ID,
+		&msg.ConversationID,
+		&msg.SenderUserID,
+		&msg.ParentMessageID,
+		&msg.Content,
+		&msg.MessageType,
+		&msg.Status,
+		&msg.IsEdited,
+		&msg.IsDeleted,
+		&msg.Mentions,
+		&msg.Metadata,
+		&msg.CreatedAt,
+		&msg.UpdatedAt,
+		&msg.DeletedAt,
+		&msg.EditedAt,
+		&msg.EditHistory,
+		&msg.ExpiresAt,
+		&msg.DeletedFor,
 	)
 
 	if err == sql.ErrNoRows {
@@ -178,6 +278,16 @@ func (r *messageRepository) GetMessages(ctx context.Context, conversationID uuid
 		argIdx++
 	}
 
+	// Scheduled messages stay hidden until delivered, except from their sender
+	if params.ViewerID != nil {
+		query += fmt.Sprintf(` AND NOT EXISTS (SELECT 1 FROM messages.message_deletions md WHERE md.message_id = m.id AND md.user_id = $%d)`, argIdx)
+		query += fmt.Sprintf(` AND (m.is_scheduled = FALSE OR m.sender_user_id = $%d)`, argIdx)
+		args = append(args, *params.ViewerID)
+		argIdx++
+	} else {
+		query += ` AND m.is_scheduled = FALSE`
+	}
+
 	query += `
 		GROUP BY m.id
 		ORDER BY m.created_at DESC
@@ -229,57 +339,113 @@ func (r *messageRepository) GetMessages(ctx context.Context, conversationID uuid
 	return messages, nil
 }
 
-// UpdateMessage updates message content
-func (r *messageRepository) UpdateMessage(ctx context.Context, messageID uuid.UUID, content string) pkgErrors.AppError {
+// UpdateMessage replaces the content of a live message. The
+// messages.set_edited_timestamp trigger appends the previous content to its
+// edit history.
+func (r *messageRepository) UpdateMessage(ctx context.Context, messageID uuid.UUID, content string) (*models.Message, pkgErrors.AppError) {
 	query := `
 		UPDATE messages.messages
 		SET content = $1, is_edited = TRUE, edited_at = NOW(), updated_at = NOW()
 		WHERE id = $2 AND is_deleted = FALSE
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING id, conversation_id, sender_user_id, parent_message_id,
+		          content, message_type, status, is_edited, is_deleted,
+		          mentions, metadata, created_at, updated_at, deleted_at, edited_at,
+		          edit_history, expires_at
 	`
 
-	result, err := r.db.Exec(ctx, query, content, messageID)
-	if err != nil {
-		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to update message").
-			WithDetail("message_id", messageID.String())
-	}
+	msg := &models.Message{}
+	err := r.db.QueryRow(ctx, query, content, messageID).Scan(
+		&msg.ID,
+		&msg.ConversationID,
+		&msg.SenderUserID,
+		&msg.ParentMessageID,
+		&msg.Content,
+		&msg.MessageType,
+		&msg.Status,
+		&msg.IsEdited,
+		&msg.IsDeleted,
+		&msg.Mentions,
+		&msg.Metadata,
+		&msg.CreatedAt,
+		&msg.UpdatedAt,
+		&msg.DeletedAt,
+		&msg.EditedAt,
+		&msg.EditHistory,
+		&msg.ExpiresAt,
+	)
 
-	rows, dbErr := result.RowsAffected()
-	if dbErr != nil {
-		return pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to get affected rows").
+	if err == sql.ErrNoRows {
+		return nil, pkgErrors.New(pkgErrors.CodeNotFound, "message not found, deleted or expired").
 			WithDetail("message_id", messageID.String())
 	}
-
-	if rows == 0 {
-		return pkgErrors.New(pkgErrors.CodeNotFound, "message not found or already deleted").
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to update message").
 			WithDetail("message_id", messageID.String())
 	}
 
-	return nil
+	return msg, nil
 }
 
-// DeleteMessage soft deletes a message
-func (r *messageRepository) DeleteMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID) pkgErrors.AppError {
+// DeleteMessage soft deletes a message for everyone, blanking its content
+// and edit history so only a tombstone remains
+func (r *messageRepository) DeleteMessage(ctx context.Context, messageID uuid.UUID) (*models.Message, pkgErrors.AppError) {
 	query := `
 		UPDATE messages.messages
-		SET is_deleted = TRUE, deleted_at = NOW(), updated_at = NOW()
-		WHERE id = $1 AND sender_user_id = $2 AND is_deleted = FALSE
+		SET is_deleted = TRUE, deleted_at = NOW(), deleted_for = 'everyone',
+		    content = '', edit_history = '[]'::JSONB, updated_at = NOW()
+		WHERE id = $1 AND is_deleted = FALSE
+		RETURNING id, conversation_id, sender_user_id, parent_message_id,
+		          content, message_type, status, is_edited, is_deleted,
+		          mentions, metadata, created_at, updated_at, deleted_at, edited_at,
+		          edit_history, expires_at, deleted_for
 	`
 
-	result, err := r.db.Exec(ctx, query, messageID, userID)
-	if err != nil {
-		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to delete message").
-			WithDetail("message_id", messageID.String()).
-			WithDetail("user_id", userID.String())
-	}
+	msg := &models.Message{}
+	err := r.db.QueryRow(ctx, query, messageID).Scan(
+		&msg.ID,
+		&msg.ConversationID,
+		&msg.SenderUserID,
+		&msg.ParentMessageID,
+		&msg.Content,
+		&msg.MessageType,
+		&msg.Status,
+		&msg.IsEdited,
+		&msg.IsDeleted,
+		&msg.Mentions,
+		&msg.Metadata,
+		&msg.CreatedAt,
+		&msg.UpdatedAt,
+		&msg.DeletedAt,
+		&msg.EditedAt,
+		&msg.EditHistory,
+		&msg.ExpiresAt,
+		&msg.DeletedFor,
+	)
 
-	rows, dbErr := result.RowsAffected()
-	if dbErr != nil {
-		return pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to get affected rows").
+	if err == sql.ErrNoRows {
+		return nil, pkgErrors.New(pkgErrors.CodeNotFound, "message not found or already deleted").
+			WithDetail("message_id", messageID.String())
+	}
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to delete message").
 			WithDetail("message_id", messageID.String())
 	}
 
-	if rows == 0 {
-		return pkgErrors.New(pkgErrors.CodeNotFound, "message not found or unauthorized").
+	return msg, nil
+}
+
+// HideMessageForUser records a "delete for me" so the message is filtered
+// out of that user's history
+func (r *messageRepository) HideMessageForUser(ctx context.Context, messageID, userID uuid.UUID) pkgErrors.AppError {
+	query := `
+		INSERT INTO messages.message_deletions (message_id, user_id, deleted_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (message_id, user_id) DO NOTHING
+	`
+
+	if _, err := r.db.Exec(ctx, query, messageID, userID); err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to delete message for user").
 			WithDetail("message_id", messageID.String()).
 			WithDetail("user_id", userID.String())
 	}
@@ -381,7 +547,7 @@ func (r *messageRepository) GetDeliveryStatus(ctx context.Context, messageID uui
 // GetConversationParticipants gets all participants in a conversation
 func (r *messageRepository) GetConversationParticipants(ctx context.Context, conversationID uuid.UUID) ([]models.ConversationParticipant, pkgErrors.AppError) {
 	query := `
-		SELECT id, conversation_id, user_id, role, can_send_messages,
+		SELECT id, conversation_id, user_id, role, can_send_messages, can_delete_messages,
 		       last_read_message_id, last_read_at, unread_count, joined_at, left_at
 		FROM messages.conversation_participants
 		WHERE conversation_id = $1 AND left_at IS NULL
@@ -404,6 +570,7 @@ func (r *messageRepository) GetConversationParticipants(ctx context.Context, con
 			&p.UserID,
 			&p.Role,
 			&p.CanSendMessages,
+			&p.CanDeleteMessages,
 			&p.LastReadMessageID,
 			&p.LastReadAt,
 			&p.UnreadCount,
@@ -447,6 +614,44 @@ func (r *messageRepository) GetParticipantUserIDs(ctx context.Context, conversat
 	return userIDs, nil
 }
 
+// GetParticipant gets a user's active membership in a conversation
+func (r *messageRepository) GetParticipant(ctx context.Context, conversationID, userID uuid.UUID) (*models.ConversationParticipant, pkgErrors.AppError) {
+	query := `
+		SELECT id, conversation_id, user_id, role, can_send_messages, can_delete_messages,
+		       last_read_message_id, last_read_at, unread_count, joined_at, left_at
+		FROM messages.conversation_participants
+		WHERE conversation_id = $1 AND user_id = $2 AND left_at IS NULL
+	`
+
+	p := &models.ConversationParticipant{}
+	err := r.db.QueryRow(ctx, query, conversationID, userID).Scan(
+		&p.ID,
+		&p.ConversationID,
+		&p.UserID,
+		&p.Role,
+		&p.CanSendMessages,
+		&p.CanDeleteMessages,
+		&p.LastReadMessageID,
+		&p.LastReadAt,
+		&p.UnreadCount,
+		&p.JoinedAt,
+		&p.LeftAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, pkgErrors.New(pkgErrors.CodeNotFound, "participant not found").
+			WithDetail("conversation_id", conversationID.String()).
+			WithDetail("user_id", userID.String())
+	}
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to get participant").
+			WithDetail("conversation_id", conversationID.String()).
+			WithDetail("user_id", userID.String())
+	}
+
+	return p, nil
+}
+
 // ValidateParticipant checks if a user is a participant in a conversation
 func (r *messageRepository) ValidateParticipant(ctx context.Context, conversationID, userID uuid.UUID) (bool, pkgErrors.AppError) {
 	query := `
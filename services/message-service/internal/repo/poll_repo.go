@@ -0,0 +1,281 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"echo-backend/services/message-service/internal/models"
+
+	"shared/pkg/database"
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// CreatePoll stores a poll message together with the poll and its options
+// in one transaction, filling in the generated IDs
+func (r *messageRepository) CreatePoll(ctx context.Context, msg *models.Message, poll *models.Poll) pkgErrors.AppError {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to begin transaction").
+			WithDetail("conversation_id", msg.ConversationID.String())
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if appErr := insertMessage(ctx, tx, msg); appErr != nil {
+		return appErr
+	}
+
+	pollQuery := `
+		INSERT INTO messages.polls (
+			id, message_id, question, allow_multiple_answers, is_anonymous,
+			is_quiz, correct_option_id, explanation, closes_at
+		) VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, is_closed, total_votes, created_at
+	`
+	dbErr := tx.QueryRow(ctx, pollQuery,
+		msg.ID,
+		poll.Question,
+		poll.AllowMultiple,
+		poll.IsAnonymous,
+		poll.IsQuiz,
+		poll.CorrectOptionID,
+		poll.Explanation,
+		poll.ClosesAt,
+	).Scan(&poll.ID, &poll.IsClosed, &poll.TotalVotes, &poll.CreatedAt)
+	if dbErr != nil {
+		return pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to create poll").
+			WithDetail("message_id", msg.ID.String())
+	}
+	poll.MessageID = msg.ID
+
+	optionQuery := `
+		INSERT INTO messages.poll_options (id, poll_id, option_text, option_order)
+		VALUES (gen_random_uuid(), $1, $2, $3)
+		RETURNING id, vote_count, vote_percentage, created_at
+	`
+	for i := range poll.Options {
+		option := &poll.Options[i]
+		option.PollID = poll.ID
+		err := tx.QueryRow(ctx, optionQuery, poll.ID, option.OptionText, option.OptionOrder).
+			Scan(&option.ID, &option.VoteCount, &option.VotePercentage, &option.CreatedAt)
+		if err != nil {
+			return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to create poll option").
+				WithDetail("poll_id", poll.ID.String()).
+				WithDetail("option_order", option.OptionOrder)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to commit transaction").
+			WithDetail("poll_id", poll.ID.String())
+	}
+
+	return nil
+}
+
+// GetPoll loads a poll of a live message with its options in display order
+func (r *messageRepository) GetPoll(ctx context.Context, pollID uuid.UUID) (*models.Poll, pkgErrors.AppError) {
+	query := `
+		SELECT p.id, p.message_id, m.conversation_id, m.sender_user_id, p.question,
+		       p.allow_multiple_answers, p.is_anonymous, p.is_quiz, p.correct_option_id,
+		       p.explanation, p.closes_at, p.is_closed, p.closed_at, p.total_votes, p.created_at
+		FROM messages.polls p
+		JOIN messages.messages m ON m.id = p.message_id
+		WHERE p.id = $1 AND m.is_deleted = FALSE
+	`
+
+	poll := &models.Poll{}
+	err := r.db.QueryRow(ctx, query, pollID).Scan(
+		&poll.ID,
+		&poll.MessageID,
+		&poll.ConversationID,
+		&poll.CreatorUserID,
+		&poll.Question,
+		&poll.AllowMultiple,
+		&poll.IsAnonymous,
+		&poll.IsQuiz,
+		&poll.CorrectOptionID,
+		&poll.Explanation,
+		&poll.ClosesAt,
+		&poll.IsClosed,
+		&poll.ClosedAt,
+		&poll.TotalVotes,
+		&poll.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, pkgErrors.New(pkgErrors.CodeNotFound, "poll not found").
+			WithDetail("poll_id", pollID.String())
+	}
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to get poll").
+			WithDetail("poll_id", pollID.String())
+	}
+
+	optionQuery := `
+		SELECT id, poll_id, option_text, option_order, vote_count, vote_percentage, created_at
+		FROM messages.poll_options
+		WHERE poll_id = $1
+		ORDER BY option_order ASC
+	`
+	rows, dbErr := r.db.Query(ctx, optionQuery, pollID)
+	if dbErr != nil {
+		return nil, pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to query poll options").
+			WithDetail("poll_id", pollID.String())
+	}
+	defer rows.Close()
+
+	poll.Options = make([]models.PollOption, 0, models.MaxPollOptions)
+	for rows.Next() {
+		var option models.PollOption
+		if err := rows.Scan(&option.ID, &option.PollID, &option.OptionText, &option.OptionOrder,
+			&option.VoteCount, &option.VotePercentage, &option.CreatedAt); err != nil {
+			return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan poll option").
+				WithDetail("poll_id", pollID.String())
+		}
+		poll.Options = append(poll.Options, option)
+	}
+
+	return poll, nil
+}
+
+// GetPollVotes returns the options a user currently has selected
+func (r *messageRepository) GetPollVotes(ctx context.Context, pollID, userID uuid.UUID) ([]uuid.UUID, pkgErrors.AppError) {
+	rows, err := r.db.Query(ctx, `SELECT poll_option_id FROM messages.poll_votes WHERE poll_id = $1 AND user_id = $2`, pollID, userID)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to query poll votes").
+			WithDetail("poll_id", pollID.String())
+	}
+	defer rows.Close()
+
+	optionIDs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan poll vote").
+				WithDetail("poll_id", pollID.String())
+		}
+		optionIDs = append(optionIDs, id)
+	}
+
+	return optionIDs, nil
+}
+
+// CastPollVote makes optionIDs the user's selection, replacing any earlier
+// vote, and recomputes the tallies. The poll row is locked for the duration
+// so a vote cannot slip in after the poll closes, and a re-vote never leaves
+// the user with both the old and the new choice.
+func (r *messageRepository) CastPollVote(ctx context.Context, pollID, userID uuid.UUID, optionIDs []uuid.UUID) (*models.Poll, pkgErrors.AppError) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to begin transaction").
+			WithDetail("poll_id", pollID.String())
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var open bool
+	lockQuery := `
+		SELECT NOT is_closed AND (closes_at IS NULL OR closes_at > NOW())
+		FROM messages.polls
+		WHERE id = $1
+		FOR UPDATE
+	`
+	dbErr := tx.QueryRow(ctx, lockQuery, pollID).Scan(&open)
+	if dbErr == sql.ErrNoRows {
+		return nil, pkgErrors.New(pkgErrors.CodeNotFound, "poll not found").
+			WithDetail("poll_id", pollID.String())
+	}
+	if dbErr != nil {
+		return nil, pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to lock poll").
+			WithDetail("poll_id", pollID.String())
+	}
+	if !open {
+		return nil, pkgErrors.New(pkgErrors.CodeConflict, "poll is closed").
+			WithDetail("poll_id", pollID.String())
+	}
+
+	var matched int
+	dbErr = tx.QueryRow(ctx, `SELECT COUNT(*) FROM messages.poll_options WHERE poll_id = $1 AND id = ANY($2)`,
+		pollID, pq.Array(optionIDs)).Scan(&matched)
+	if dbErr != nil {
+		return nil, pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to check poll options").
+			WithDetail("poll_id", pollID.String())
+	}
+	if matched != len(optionIDs) {
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "option does not belong to this poll").
+			WithDetail("poll_id", pollID.String())
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM messages.poll_votes WHERE poll_id = $1 AND user_id = $2`, pollID, userID); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to clear previous vote").
+			WithDetail("poll_id", pollID.String())
+	}
+
+	insertQuery := `
+		INSERT INTO messages.poll_votes (id, poll_id, poll_option_id, user_id, voted_at)
+		SELECT gen_random_uuid(), $1, option_id, $3, NOW()
+		FROM UNNEST($2::uuid[]) AS option_id
+	`
+	if _, err := tx.Exec(ctx, insertQuery, pollID, pq.Array(optionIDs), userID); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to record vote").
+			WithDetail("poll_id", pollID.String())
+	}
+
+	if appErr := refreshPollCounts(ctx, tx, pollID); appErr != nil {
+		return nil, appErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to commit transaction").
+			WithDetail("poll_id", pollID.String())
+	}
+
+	return r.GetPoll(ctx, pollID)
+}
+
+// ClosePoll stops a poll from taking further votes
+func (r *messageRepository) ClosePoll(ctx context.Context, pollID uuid.UUID) (*models.Poll, pkgErrors.AppError) {
+	result, err := r.db.Exec(ctx, `UPDATE messages.polls SET is_closed = TRUE, closed_at = NOW() WHERE id = $1 AND is_closed = FALSE`, pollID)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to close poll").
+			WithDetail("poll_id", pollID.String())
+	}
+
+	rows, rowsErr := result.RowsAffected()
+	if rowsErr != nil {
+		return nil, pkgErrors.FromError(rowsErr, pkgErrors.CodeDatabaseError, "failed to get affected rows").
+			WithDetail("poll_id", pollID.String())
+	}
+	if rows == 0 {
+		return nil, pkgErrors.New(pkgErrors.CodeConflict, "poll is already closed").
+			WithDetail("poll_id", pollID.String())
+	}
+
+	return r.GetPoll(ctx, pollID)
+}
+
+// refreshPollCounts recomputes per-option counts, the poll total and the
+// option percentages from the votes table
+func refreshPollCounts(ctx context.Context, tx database.Transaction, pollID uuid.UUID) pkgErrors.AppError {
+	queries := []string{
+		`UPDATE messages.poll_options po
+		 SET vote_count = (SELECT COUNT(*) FROM messages.poll_votes pv WHERE pv.poll_option_id = po.id)
+		 WHERE po.poll_id = $1`,
+		`UPDATE messages.polls
+		 SET total_votes = (SELECT COUNT(*) FROM messages.poll_votes WHERE poll_id = $1)
+		 WHERE id = $1`,
+		`UPDATE messages.poll_options po
+		 SET vote_percentage = CASE WHEN p.total_votes > 0
+		                            THEN ROUND(po.vote_count * 100.0 / p.total_votes, 2)
+		                            ELSE 0 END
+		 FROM messages.polls p
+		 WHERE po.poll_id = p.id AND p.id = $1`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query, pollID); err != nil {
+			return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to update poll counts").
+				WithDetail("poll_id", pollID.String())
+		}
+	}
+	return nil
+}
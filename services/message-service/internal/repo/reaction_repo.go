@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"echo-backend/services/message-service/internal/models"
+
+	"shared/pkg/database"
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// AddReaction inserts a reaction and refreshes the message's reaction_count in
+// one transaction. A repeated reaction of the same type is a no-op and
+// reports added=false.
+func (r *messageRepository) AddReaction(ctx context.Context, reaction *models.Reaction) (bool, pkgErrors.AppError) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to begin transaction").
+			WithDetail("message_id", reaction.MessageID.String())
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if appErr := lockLiveMessage(ctx, tx, reaction.MessageID); appErr != nil {
+		return false, appErr
+	}
+
+	query := `
+		INSERT INTO messages.reactions (id, message_id, user_id, reaction_type, reaction_emoji, reaction_skin_tone, created_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (message_id, user_id, reaction_type) DO NOTHING
+	`
+	result, dbErr := tx.Exec(ctx, query,
+		reaction.MessageID,
+		reaction.UserID,
+		reaction.ReactionType,
+		reaction.ReactionEmoji,
+		reaction.ReactionSkinTone,
+	)
+	if dbErr != nil {
+		return false, pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to add reaction").
+			WithDetail("message_id", reaction.MessageID.String()).
+			WithDetail("reaction_type", reaction.ReactionType)
+	}
+
+	rows, dbErr := result.RowsAffected()
+	if dbErr != nil {
+		return false, pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to get affected rows").
+			WithDetail("message_id", reaction.MessageID.String())
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if appErr := refreshReactionCount(ctx, tx, reaction.MessageID); appErr != nil {
+		return false, appErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to commit transaction").
+			WithDetail("message_id", reaction.MessageID.String())
+	}
+
+	return true, nil
+}
+
+// RemoveReaction deletes a user's reaction of the given type, reporting
+// removed=false when there was none
+func (r *messageRepository) RemoveReaction(ctx context.Context, messageID, userID uuid.UUID, reactionType string) (bool, pkgErrors.AppError) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to begin transaction").
+			WithDetail("message_id", messageID.String())
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if appErr := lockLiveMessage(ctx, tx, messageID); appErr != nil {
+		return false, appErr
+	}
+
+	query := `
+		DELETE FROM messages.reactions
+		WHERE message_id = $1 AND user_id = $2 AND reaction_type = $3
+	`
+	result, dbErr := tx.Exec(ctx, query, messageID, userID, reactionType)
+	if dbErr != nil {
+		return false, pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to remove reaction").
+			WithDetail("message_id", messageID.String()).
+			WithDetail("reaction_type", reactionType)
+	}
+
+	rows, dbErr := result.RowsAffected()
+	if dbErr != nil {
+		return false, pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to get affected rows").
+			WithDetail("message_id", messageID.String())
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if appErr := refreshReactionCount(ctx, tx, messageID); appErr != nil {
+		return false, appErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to commit transaction").
+			WithDetail("message_id", messageID.String())
+	}
+
+	return true, nil
+}
+
+// GetReactionSummary counts a message's reactions per type and flags the
+// types the viewer used
+func (r *messageRepository) GetReactionSummary(ctx context.Context, messageID, viewerID uuid.UUID) (*models.ReactionSummary, pkgErrors.AppError) {
+	query := `
+		SELECT reaction_type, MIN(reaction_emoji), COUNT(*), BOOL_OR(user_id = $2)
+		FROM messages.reactions
+		WHERE message_id = $1
+		GROUP BY reaction_type
+		ORDER BY COUNT(*) DESC, reaction_type ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, messageID, viewerID)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to query reactions").
+			WithDetail("message_id", messageID.String())
+	}
+	defer rows.Close()
+
+	summary := &models.ReactionSummary{MessageID: messageID, Reactions: []models.ReactionCount{}}
+	for rows.Next() {
+		var rc models.ReactionCount
+		if err := rows.Scan(&rc.ReactionType, &rc.Emoji, &rc.Count, &rc.ReactedByMe); err != nil {
+			return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan reaction").
+				WithDetail("message_id", messageID.String())
+		}
+		summary.Total += rc.Count
+		summary.Reactions = append(summary.Reactions, rc)
+	}
+
+	return summary, nil
+}
+
+// lockLiveMessage row-locks the message so concurrent reactions serialize
+// their reaction_count updates
+func lockLiveMessage(ctx context.Context, tx database.Transaction, messageID uuid.UUID) pkgErrors.AppError {
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `SELECT id FROM messages.messages WHERE id = $1 AND is_deleted = FALSE FOR UPDATE`, messageID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return pkgErrors.New(pkgErrors.CodeNotFound, "message not found").
+			WithDetail("message_id", messageID.String())
+	}
+	if err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to lock message").
+			WithDetail("message_id", messageID.String())
+	}
+	return nil
+}
+
+func refreshReactionCount(ctx context.Context, tx database.Transaction, messageID uuid.UUID) pkgErrors.AppError {
+	query := `
+		UPDATE messages.messages
+		SET reaction_count = (SELECT COUNT(*) FROM messages.reactions WHERE message_id = $1)
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, query, messageID); err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to update reaction count").
+			WithDetail("message_id", messageID.String())
+	}
+	return nil
+}
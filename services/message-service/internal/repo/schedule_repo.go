@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"context"
+	"echo-backend/services/message-service/internal/models"
+	"time"
+
+	pkgErrors "shared/pkg/errors"
+)
+
+// DeliverDueScheduledMessages moves up to limit scheduled messages whose
+// scheduled_at is at or before now to "sent", stamping them with now and
+// restarting any disappearing-messages timer from delivery, and hands them to
+// publish before committing. Rows are claimed FOR UPDATE SKIP LOCKED so
+// overlapping sweeps never pick the same message, and an error from publish
+// rolls the batch back so the next sweep retries it instead of the messages
+// being marked sent without their event.
+func (r *messageRepository) DeliverDueScheduledMessages(ctx context.Context, now time.Time, limit int, publish func([]models.Message) error) ([]models.Message, pkgErrors.AppError) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		WITH due AS (
+			SELECT id FROM messages.messages
+			WHERE is_scheduled = TRUE AND scheduled_at <= $1 AND is_deleted = FALSE
+			ORDER BY scheduled_at ASC, id ASC
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE messages.messages m
+		SET is_scheduled = FALSE, status = 'sent', created_at = $1, updated_at = $1,
+		    expires_at = CASE WHEN m.expire_after_seconds IS NULL THEN m.expires_at
+		                      ELSE $1 + m.expire_after_seconds * INTERVAL '1 second' END
+		FROM due
+		WHERE m.id = due.id
+		RETURNING m.id, m.conversation_id, m.sender_user_id, m.parent_message_id,
+		          m.content, m.message_type, m.status, m.mentions, m.metadata,
+		          m.created_at, m.updated_at, m.scheduled_at, m.expires_at
+	`
+
+	rows, dbErr := tx.Query(ctx, query, now, limit)
+	if dbErr != nil {
+		return nil, pkgErrors.FromError(dbErr, pkgErrors.CodeDatabaseError, "failed to claim scheduled messages").
+			WithDetail("limit", limit)
+	}
+
+	messages := make([]models.Message, 0, limit)
+	for rows.Next() {
+		var msg models.Message
+		err := rows.Scan(
+			&msg.ID,
+			&msg.ConversationID,
+			&msg.SenderUserID,
+			&msg.ParentMessageID,
+			&msg.Content,
+			&msg.MessageType,
+			&msg.Status,
+			&msg.Mentions,
+			&msg.Metadata,
+			&msg.CreatedAt,
+			&msg.UpdatedAt,
+			&msg.ScheduledAt,
+			&msg.ExpiresAt,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan scheduled message")
+		}
+		messages = append(messages, msg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to read scheduled messages")
+	}
+
+	if len(messages) == 0 {
+		return messages, nil
+	}
+
+	if err := publish(messages); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeUnavailable, "failed to publish scheduled messages").
+			WithDetail("count", len(messages))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to commit transaction").
+			WithDetail("count", len(messages))
+	}
+
+	return messages, nil
+}
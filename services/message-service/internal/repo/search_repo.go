@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"context"
+	"echo-backend/services/message-service/internal/models"
+
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// headlineOptions bound ts_headline to a couple of short fragments with the
+// matched terms wrapped in <mark>
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=\" … \""
+
+// IndexMessage creates or refreshes the search_index row of a message using
+// the given text search configuration (e.g. "english", "simple")
+func (r *messageRepository) IndexMessage(ctx context.Context, msg *models.Message, language string) pkgErrors.AppError {
+	query := `
+		INSERT INTO messages.search_index (id, message_id, conversation_id, user_id, content_tsvector, created_at, updated_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, to_tsvector($4::regconfig, COALESCE($5, '')), NOW(), NOW())
+		ON CONFLICT (message_id) DO UPDATE SET
+			content_tsvector = EXCLUDED.content_tsvector,
+			updated_at = NOW()
+	`
+
+	if _, err := r.db.Exec(ctx, query, msg.ID, msg.ConversationID, msg.SenderUserID, language, msg.Content); err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to index message").
+			WithDetail("message_id", msg.ID.String()).
+			WithDetail("language", language)
+	}
+	return nil
+}
+
+// RemoveFromIndex drops a message from search, e.g. once it is deleted
+func (r *messageRepository) RemoveFromIndex(ctx context.Context, messageID uuid.UUID) pkgErrors.AppError {
+	if _, err := r.db.Exec(ctx, `DELETE FROM messages.search_index WHERE message_id = $1`, messageID); err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to remove message from index").
+			WithDetail("message_id", messageID.String())
+	}
+	return nil
+}
+
+// SearchMessages runs a full-text query over one conversation, best match
+// first. The query uses websearch syntax, so "quoted phrases", OR and -term
+// work as users expect. Messages the viewer deleted for themselves, deleted
+// for everyone or expired are left out.
+func (r *messageRepository) SearchMessages(ctx context.Context, conversationID, viewerID uuid.UUID, text, language string, limit, offset int) ([]models.SearchResult, bool, pkgErrors.AppError) {
+	query := `
+		SELECT m.id, m.conversation_id, m.sender_user_id, m.content, m.message_type, m.created_at,
+		       ts_rank(si.content_tsvector, q) AS rank,
+		       ts_headline($3::regconfig, m.content, q, $4) AS snippet
+		FROM messages.search_index si
+		JOIN messages.messages m ON m.id = si.message_id
+		CROSS JOIN websearch_to_tsquery($3::regconfig, $5) q
+		WHERE si.conversation_id = $1
+		  AND si.content_tsvector @@ q
+		  AND m.is_deleted = FALSE
+		  AND (m.expires_at IS NULL OR m.expires_at > NOW())
+		  AND NOT EXISTS (
+		      SELECT 1 FROM messages.message_deletions md
+		      WHERE md.message_id = m.id AND md.user_id = $2
+		  )
+		ORDER BY rank DESC, m.created_at DESC
+		LIMIT $6 OFFSET $7
+	`
+
+	// One extra row tells us whether another page follows
+	rows, err := r.db.Query(ctx, query, conversationID, viewerID, language, headlineOptions, text, limit+1, offset)
+	if err != nil {
+		return nil, false, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to search messages").
+			WithDetail("conversation_id", conversationID.String()).
+			WithDetail("language", language)
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0, limit)
+	for rows.Next() {
+		var res models.SearchResult
+		err := rows.Scan(
+			&res.MessageID,
+			&res.ConversationID,
+			&res.SenderUserID,
+			&res.Content,
+			&res.MessageType,
+			&res.CreatedAt,
+			&res.Rank,
+			&res.Snippet,
+		)
+		if err != nil {
+			return nil, false, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan search result").
+				WithDetail("conversation_id", conversationID.String())
+		}
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to read search results").
+			WithDetail("conversation_id", conversationID.String())
+	}
+
+	hasMore := len(results) > limit
+	if hasMore {
+		results = results[:limit]
+	}
+	return results, hasMore, nil
+}
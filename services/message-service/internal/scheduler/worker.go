@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"shared/pkg/cache/redis"
+	"shared/pkg/logger"
+)
+
+// lockKeyPrefix namespaces the per-job sweep locks
+const lockKeyPrefix = "message-service:sweep:"
+
+// Job is one sweep of a background task, returning how many items it handled
+type Job func(ctx context.Context) (int, error)
+
+type Config struct {
+	// Interval is how often the job runs
+	Interval time.Duration
+	// LockTTL bounds how long a crashed replica can hold the sweep lock
+	LockTTL time.Duration
+}
+
+// Worker runs a job periodically, such as delivering scheduled messages or
+// purging expired ones. With a locker it takes a Redis lock around each
+// sweep so that only one replica runs it; without one it assumes it is the
+// only replica.
+type Worker struct {
+	name   string
+	job    Job
+	locker *redis.Locker
+	cfg    Config
+	log    logger.Logger
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewWorker(name string, job Job, locker *redis.Locker, cfg Config, log logger.Logger) *Worker {
+	return &Worker{
+		name:   name,
+		job:    job,
+		locker: locker,
+		cfg:    cfg,
+		log:    log,
+	}
+}
+
+// Start runs a sweep every poll interval until Stop is called
+func (w *Worker) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	go w.run(w.stop, w.done)
+
+	w.log.Info("Background sweep started",
+		logger.String("job", w.name),
+		logger.Duration("interval", w.cfg.Interval),
+		logger.Bool("distributed_lock", w.locker != nil),
+	)
+}
+
+// Stop ends the loop and waits for an in-flight sweep to finish or ctx to
+// expire
+func (w *Worker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	w.mu.Unlock()
+
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) run(stop, done chan struct{}) {
+	defer close(done)
+
+	// Cancelling on stop cuts an in-flight sweep short at its next query
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := w.Sweep(ctx); err != nil && ctx.Err() == nil {
+				w.log.Error("Background sweep failed",
+					logger.String("job", w.name),
+					logger.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// Sweep runs the job once. It returns zero without error when another
+// replica holds the lock.
+func (w *Worker) Sweep(ctx context.Context) (int, error) {
+	if w.locker == nil {
+		return w.job(ctx)
+	}
+
+	lock, err := w.locker.TryAcquire(ctx, lockKeyPrefix+w.name, w.cfg.LockTTL)
+	if errors.Is(err, redis.ErrLockNotAcquired) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := lock.Release(context.Background()); err != nil && !errors.Is(err, redis.ErrLockNotHeld) {
+			w.log.Warn("Failed to release sweep lock",
+				logger.String("job", w.name),
+				logger.Error(err),
+			)
+		}
+	}()
+
+	// Stop early if the lock is lost so two replicas never sweep together
+	lock.AutoRenew(0)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-lock.Lost():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return w.job(ctx)
+}
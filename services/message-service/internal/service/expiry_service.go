@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+
+	"echo-backend/services/message-service/internal/models"
+
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// defaultExpiryBatchSize applies when the configured batch size is unset
+const defaultExpiryBatchSize = 500
+
+// ExpireMessages purges every disappearing message whose time is up and
+// tells each affected conversation which messages went away. Returns how
+// many messages expired.
+func (s *messageService) ExpireMessages(ctx context.Context) (int, error) {
+	now := s.clock()
+	limit := s.cfg.ExpiryBatchSize
+	if limit <= 0 {
+		limit = defaultExpiryBatchSize
+	}
+
+	total := 0
+	for {
+		expired, err := s.repo.ExpireMessages(ctx, now, limit)
+		if err != nil {
+			return total, err.WithService("message-service")
+		}
+
+		s.broadcastExpired(ctx, expired)
+		total += len(expired)
+
+		if len(expired) < limit {
+			break
+		}
+	}
+
+	if total > 0 {
+		s.logger.Info("Expired disappearing messages", logger.Int("count", total))
+	}
+	return total, nil
+}
+
+func (s *messageService) broadcastExpired(ctx context.Context, expired []models.Message) {
+	byConversation := make(map[uuid.UUID][]models.Message)
+	for _, message := range expired {
+		byConversation[message.ConversationID] = append(byConversation[message.ConversationID], message)
+	}
+
+	for conversationID, messages := range byConversation {
+		participantIDs, err := s.repo.GetParticipantUserIDs(ctx, conversationID)
+		if err != nil {
+			s.logger.Warn("Failed to get participants for expired messages",
+				logger.String("conversation_id", conversationID.String()),
+				logger.Error(err),
+			)
+			continue
+		}
+
+		for _, message := range messages {
+			event := models.MessageEvent{
+				Type:           "message.expired",
+				MessageID:      message.ID,
+				ConversationID: conversationID,
+				Timestamp:      message.ExpiresAt.Time,
+			}
+			if err := s.hub.SendToUsers(participantIDs, event, nil); err != nil {
+				s.logger.Debug("Failed to broadcast expired message",
+					logger.String("message_id", message.ID.String()),
+					logger.Error(err),
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExpireMessages_PurgesOnceTimerRunsOut(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	ttl := 10 * time.Minute
+	r := &fakeRepo{expireAfter: &ttl}
+	svc, _ := newTestService(r, Config{})
+	svc.clock = func() time.Time { return now }
+
+	msg := scheduleMessage(t, svc, now.Add(time.Hour))
+	if !msg.ExpiresAt.Valid || !msg.ExpiresAt.Time.Equal(now.Add(time.Hour+ttl)) {
+		t.Fatalf("expected expiry counted from the scheduled time, got %v", msg.ExpiresAt)
+	}
+	if msg.ExpireAfter == nil || *msg.ExpireAfter != 600 {
+		t.Fatalf("expected expire_after_seconds 600, got %v", msg.ExpireAfter)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := svc.DeliverScheduledMessages(context.Background()); err != nil {
+		t.Fatalf("DeliverScheduledMessages returned error: %v", err)
+	}
+
+	now = now.Add(5 * time.Minute)
+	if n, err := svc.ExpireMessages(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected nothing expired yet, got %d (err %v)", n, err)
+	}
+
+	now = now.Add(5 * time.Minute)
+	if n, err := svc.ExpireMessages(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected one expired message, got %d (err %v)", n, err)
+	}
+	if stored := r.messages[0]; !stored.IsDeleted || stored.Content != "" {
+		t.Fatalf("expected content purged, got is_deleted=%v content=%q", stored.IsDeleted, stored.Content)
+	}
+
+	if n, err := svc.ExpireMessages(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected a second sweep to find nothing, got %d (err %v)", n, err)
+	}
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"echo-backend/services/message-service/internal/models"
+	"echo-backend/services/message-service/internal/repo"
+	"echo-backend/services/message-service/internal/websocket"
+
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+	"shared/pkg/messaging"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepo is the in-memory MessageRepository behind every service test.
+// Tests fill in the fields their feature reads and inspect the ones it writes.
+type fakeRepo struct {
+	repo.MessageRepository
+
+	// message answers single-message lookups, whatever ID is asked for
+	message *models.Message
+	// participant is the only member of every conversation; nil admits anyone
+	participant *models.ConversationParticipant
+	// readOnly lists conversations the caller cannot send to
+	readOnly    map[uuid.UUID]bool
+	expireAfter *time.Duration
+
+	// messages holds what CreateMessage stored, for scheduling and expiry
+	messages  []*models.Message
+	forwarded []*models.Message
+	updated   bool
+	deleted   bool
+	hiddenFor []uuid.UUID
+
+	page      []models.Message
+	lastLimit int
+
+	reactions map[string]bool
+
+	poll  *models.Poll
+	votes map[uuid.UUID][]uuid.UUID
+
+	language string
+	// indexed maps each indexed message to its text search configuration
+	indexed map[uuid.UUID]string
+}
+
+func (r *fakeRepo) GetMessageByID(ctx context.Context, messageID uuid.UUID) (*models.Message, pkgErrors.AppError) {
+	if r.message == nil || r.message.ID != messageID {
+		return nil, pkgErrors.New(pkgErrors.CodeNotFound, "message not found")
+	}
+	copied := *r.message
+	return &copied, nil
+}
+
+func (r *fakeRepo) GetMessageIncludingDeleted(ctx context.Context, messageID uuid.UUID) (*models.Message, pkgErrors.AppError) {
+	copied := *r.message
+	return &copied, nil
+}
+
+func (r *fakeRepo) GetParticipant(ctx context.Context, conversationID, userID uuid.UUID) (*models.ConversationParticipant, pkgErrors.AppError) {
+	if r.participant == nil {
+		return &models.ConversationParticipant{ConversationID: conversationID, UserID: userID}, nil
+	}
+	if r.participant.UserID != userID {
+		return nil, pkgErrors.New(pkgErrors.CodeNotFound, "participant not found")
+	}
+	return r.participant, nil
+}
+
+func (r *fakeRepo) GetParticipantUserIDs(ctx context.Context, conversationID uuid.UUID) ([]uuid.UUID, pkgErrors.AppError) {
+	return nil, nil
+}
+
+func (r *fakeRepo) ValidateParticipant(ctx context.Context, conversationID, userID uuid.UUID) (bool, pkgErrors.AppError) {
+	return !r.readOnly[conversationID], nil
+}
+
+func (r *fakeRepo) GetDisappearingMessagesDuration(ctx context.Context, conversationID uuid.UUID) (*time.Duration, pkgErrors.AppError) {
+	return r.expireAfter, nil
+}
+
+func (r *fakeRepo) CreateMessage(ctx context.Context, msg *models.Message) pkgErrors.AppError {
+	msg.CreatedAt = msg.UpdatedAt
+	stored := *msg
+	r.messages = append(r.messages, &stored)
+	return nil
+}
+
+func (r *fakeRepo) ForwardMessage(ctx context.Context, msg *models.Message) pkgErrors.AppError {
+	r.forwarded = append(r.forwarded, msg)
+	return nil
+}
+
+func (r *fakeRepo) UpdateConversationLastMessage(ctx context.Context, conversationID, messageID uuid.UUID) pkgErrors.AppError {
+	return nil
+}
+
+func (r *fakeRepo) UpdateMessage(ctx context.Context, messageID uuid.UUID, content string) (*models.Message, pkgErrors.AppError) {
+	r.updated = true
+	// The shape messages.set_edited_timestamp appends
+	history, _ := json.Marshal([]map[string]interface{}{{"previous_content": r.message.Content, "edited_at": time.Now()}})
+	updated := *r.message
+	updated.Content = content
+	updated.IsEdited = true
+	updated.EditedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	updated.EditHistory = history
+	return &updated, nil
+}
+
+func (r *fakeRepo) DeleteMessage(ctx context.Context, messageID uuid.UUID) (*models.Message, pkgErrors.AppError) {
+	r.deleted = true
+	everyone := models.DeleteScopeEveryone
+	deleted := *r.message
+	deleted.Content = ""
+	deleted.IsDeleted = true
+	deleted.DeletedFor = &everyone
+	deleted.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return &deleted, nil
+}
+
+func (r *fakeRepo) HideMessageForUser(ctx context.Context, messageID, userID uuid.UUID) pkgErrors.AppError {
+	r.hiddenFor = append(r.hiddenFor, userID)
+	return nil
+}
+
+func (r *fakeRepo) IndexMessage(ctx context.Context, msg *models.Message, language string) pkgErrors.AppError {
+	if r.indexed == nil {
+		r.indexed = make(map[uuid.UUID]string)
+	}
+	r.indexed[msg.ID] = language
+	return nil
+}
+
+func (r *fakeRepo) RemoveFromIndex(ctx context.Context, messageID uuid.UUID) pkgErrors.AppError {
+	return nil
+}
+
+func (r *fakeRepo) SearchMessages(ctx context.Context, conversationID, viewerID uuid.UUID, text, language string, limit, offset int) ([]models.SearchResult, bool, pkgErrors.AppError) {
+	r.language = language
+	return make([]models.SearchResult, limit), true, nil
+}
+
+func (r *fakeRepo) GetConversationHistory(ctx context.Context, conversationID, viewerID uuid.UUID, params *models.PaginationParams) ([]models.Message, bool, pkgErrors.AppError) {
+	r.lastLimit = params.Limit
+	if len(r.page) > params.Limit {
+		return r.page[:params.Limit], true, nil
+	}
+	return r.page, false, nil
+}
+
+func (r *fakeRepo) GetMediaForMessages(ctx context.Context, messageIDs []uuid.UUID) (map[uuid.UUID][]models.MessageMedia, pkgErrors.AppError) {
+	return map[uuid.UUID][]models.MessageMedia{messageIDs[0]: {{MessageID: messageIDs[0], MediaType: "image"}}}, nil
+}
+
+func (r *fakeRepo) GetReactionSummaries(ctx context.Context, messageIDs []uuid.UUID, viewerID uuid.UUID) (map[uuid.UUID][]models.ReactionCount, pkgErrors.AppError) {
+	return map[uuid.UUID][]models.ReactionCount{messageIDs[0]: {{ReactionType: "heart", Count: 2}}}, nil
+}
+
+func (r *fakeRepo) AddReaction(ctx context.Context, reaction *models.Reaction) (bool, pkgErrors.AppError) {
+	if r.reactions == nil {
+		r.reactions = make(map[string]bool)
+	}
+	key := reaction.UserID.String() + ":" + reaction.ReactionType
+	if r.reactions[key] {
+		return false, nil
+	}
+	r.reactions[key] = true
+	return true, nil
+}
+
+func (r *fakeRepo) GetReactionSummary(ctx context.Context, messageID, viewerID uuid.UUID) (*models.ReactionSummary, pkgErrors.AppError) {
+	summary := &models.ReactionSummary{MessageID: messageID}
+	for key := range r.reactions {
+		summary.Total++
+		summary.Reactions = append(summary.Reactions, models.ReactionCount{
+			ReactionType: key[len(viewerID.String())+1:],
+			Count:        1,
+			ReactedByMe:  key[:len(viewerID.String())] == viewerID.String(),
+		})
+	}
+	return summary, nil
+}
+
+func (r *fakeRepo) GetPoll(ctx context.Context, pollID uuid.UUID) (*models.Poll, pkgErrors.AppError) {
+	copied := *r.poll
+	copied.Options = append([]models.PollOption(nil), r.poll.Options...)
+	return &copied, nil
+}
+
+func (r *fakeRepo) GetPollVotes(ctx context.Context, pollID, userID uuid.UUID) ([]uuid.UUID, pkgErrors.AppError) {
+	return r.votes[userID], nil
+}
+
+func (r *fakeRepo) CastPollVote(ctx context.Context, pollID, userID uuid.UUID, optionIDs []uuid.UUID) (*models.Poll, pkgErrors.AppError) {
+	if r.votes == nil {
+		r.votes = make(map[uuid.UUID][]uuid.UUID)
+	}
+	r.votes[userID] = optionIDs
+
+	r.poll.TotalVotes = 0
+	for i := range r.poll.Options {
+		option := &r.poll.Options[i]
+		option.VoteCount = 0
+		for _, selected := range r.votes {
+			for _, id := range selected {
+				if id == option.ID {
+					option.VoteCount++
+					r.poll.TotalVotes++
+				}
+			}
+		}
+	}
+	return r.GetPoll(ctx, pollID)
+}
+
+// DeliverDueScheduledMessages mirrors the transactional contract of the real
+// query: nothing changes unless publish succeeds
+func (r *fakeRepo) DeliverDueScheduledMessages(ctx context.Context, now time.Time, limit int, publish func([]models.Message) error) ([]models.Message, pkgErrors.AppError) {
+	var due []*models.Message
+	for _, msg := range r.messages {
+		if msg.IsScheduled && !msg.ScheduledAt.After(now) && len(due) < limit {
+			due = append(due, msg)
+		}
+	}
+
+	sent := make([]models.Message, 0, len(due))
+	for _, msg := range due {
+		copied := *msg
+		copied.IsScheduled = false
+		copied.Status = "sent"
+		copied.CreatedAt = now
+		if copied.ExpireAfter != nil {
+			copied.ExpiresAt.Time = now.Add(time.Duration(*copied.ExpireAfter) * time.Second)
+		}
+		sent = append(sent, copied)
+	}
+	if len(sent) == 0 {
+		return sent, nil
+	}
+
+	if err := publish(sent); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeUnavailable, "failed to publish scheduled messages")
+	}
+	for i, msg := range due {
+		*msg = sent[i]
+	}
+	return sent, nil
+}
+
+func (r *fakeRepo) ExpireMessages(ctx context.Context, now time.Time, limit int) ([]models.Message, pkgErrors.AppError) {
+	expired := make([]models.Message, 0)
+	for _, msg := range r.messages {
+		if len(expired) == limit {
+			break
+		}
+		if msg.IsDeleted || msg.IsScheduled || !msg.ExpiresAt.Valid || msg.ExpiresAt.Time.After(now) {
+			continue
+		}
+		msg.IsDeleted = true
+		msg.Content = ""
+		expired = append(expired, *msg)
+	}
+	return expired, nil
+}
+
+type producedEvent struct {
+	topic string
+	key   string
+	value []byte
+}
+
+type fakeProducer struct {
+	messaging.Producer
+	produced chan producedEvent
+	err      pkgErrors.AppError
+}
+
+func (p *fakeProducer) ProduceWithKey(ctx context.Context, topic, key string, value []byte, opts ...messaging.ProduceOption) pkgErrors.AppError {
+	if p.err != nil {
+		return p.err
+	}
+	p.produced <- producedEvent{topic: topic, key: key, value: value}
+	return nil
+}
+
+// newTestService builds a messageService over r that publishes to the
+// "messages" topic through the returned producer
+func newTestService(r *fakeRepo, cfg Config) (*messageService, *fakeProducer) {
+	cfg.EventsTopic = "messages"
+	p := &fakeProducer{produced: make(chan producedEvent, 8)}
+	log := logger.NewNoop()
+	svc := NewMessageService(r, websocket.NewHub(log), p, cfg, log).(*messageService)
+	return svc, p
+}
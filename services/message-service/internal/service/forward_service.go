@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"echo-backend/services/message-service/internal/models"
+
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// ForwardMessage copies a message, with its attachments, into each target
+// conversation and returns the new messages in target order. Every target
+// is checked before anything is written; each copy is then stored in its
+// own transaction, so a failure part-way leaves earlier copies in place.
+func (s *messageService) ForwardMessage(ctx context.Context, messageID, userID uuid.UUID, targetIDs []uuid.UUID) ([]*models.Message, error) {
+	targetIDs = uniqueIDs(targetIDs)
+	if len(targetIDs) == 0 {
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "at least one target conversation is required").
+			WithService("message-service")
+	}
+	if len(targetIDs) > models.MaxForwardTargets {
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "too many target conversations").
+			WithService("message-service").
+			WithDetail("max_targets", models.MaxForwardTargets)
+	}
+
+	source, err := s.repo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+	if source.IsScheduled && source.SenderUserID != userID {
+		return nil, pkgErrors.New(pkgErrors.CodeNotFound, "message not found").
+			WithService("message-service").
+			WithDetail("message_id", messageID.String())
+	}
+	if source.IsScheduled || source.MessageType == "poll" {
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "message cannot be forwarded").
+			WithService("message-service").
+			WithDetail("message_id", messageID.String())
+	}
+	if _, err := s.requireParticipant(ctx, source.ConversationID, userID); err != nil {
+		return nil, err
+	}
+
+	for _, targetID := range targetIDs {
+		canSend, err := s.repo.ValidateParticipant(ctx, targetID, userID)
+		if err != nil {
+			return nil, err.WithService("message-service")
+		}
+		if !canSend {
+			return nil, pkgErrors.New(pkgErrors.CodeForbidden, "user cannot send messages in the target conversation").
+				WithService("message-service").
+				WithDetail("conversation_id", targetID.String()).
+				WithDetail("user_id", userID.String())
+		}
+	}
+
+	forwarded := make([]*models.Message, 0, len(targetIDs))
+	for _, targetID := range targetIDs {
+		now := s.clock()
+		message := &models.Message{
+			ID:                     uuid.New(),
+			ConversationID:         targetID,
+			SenderUserID:           userID,
+			Content:                source.Content,
+			MessageType:            source.MessageType,
+			Status:                 "sent",
+			Mentions:               json.RawMessage("[]"), // mentions point at members of the source conversation
+			Metadata:               source.Metadata,
+			CreatedAt:              now,
+			UpdatedAt:              now,
+			IsForwarded:            true,
+			ForwardedFromMessageID: &source.ID,
+		}
+		if len(message.Metadata) == 0 {
+			message.Metadata = json.RawMessage("{}")
+		}
+		if err := s.startDisappearingTimer(ctx, message, now); err != nil {
+			return nil, err
+		}
+
+		if err := s.repo.ForwardMessage(ctx, message); err != nil {
+			s.logger.Error("Failed to forward message",
+				logger.String("message_id", messageID.String()),
+				logger.String("conversation_id", targetID.String()),
+				logger.Error(err),
+			)
+			return nil, err.WithService("message-service").
+				WithDetail("forwarded", len(forwarded))
+		}
+
+		s.fanOutMessage(ctx, message)
+		forwarded = append(forwarded, message)
+	}
+
+	s.logger.Info("Message forwarded",
+		logger.String("message_id", messageID.String()),
+		logger.String("user_id", userID.String()),
+		logger.Int("targets", len(forwarded)),
+	)
+
+	return forwarded, nil
+}
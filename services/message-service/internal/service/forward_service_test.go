@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"echo-backend/services/message-service/internal/models"
+
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// forwardSource is a message with a mention, which forwarded copies drop
+func forwardSource() *models.Message {
+	return &models.Message{
+		ID:             uuid.New(),
+		ConversationID: uuid.New(),
+		SenderUserID:   uuid.New(),
+		Content:        "meet at noon",
+		MessageType:    "text",
+		Mentions:       json.RawMessage(`[{"user_id":"` + uuid.NewString() + `","offset":0,"length":4}]`),
+	}
+}
+
+func TestForwardMessage_CopiesIntoEachTarget(t *testing.T) {
+	r := &fakeRepo{message: forwardSource()}
+	svc, _ := newTestService(r, Config{})
+	forwarder := uuid.New()
+	targets := []uuid.UUID{uuid.New(), uuid.New()}
+
+	messages, err := svc.ForwardMessage(context.Background(), r.message.ID, forwarder, append(targets, targets[0]))
+	if err != nil {
+		t.Fatalf("ForwardMessage returned error: %v", err)
+	}
+	if len(messages) != len(targets) {
+		t.Fatalf("expected one copy per distinct target, got %d", len(messages))
+	}
+
+	for i, message := range messages {
+		if message.ConversationID != targets[i] || message.SenderUserID != forwarder {
+			t.Fatalf("copy %d landed in %s from %s", i, message.ConversationID, message.SenderUserID)
+		}
+		if !message.IsForwarded || message.ForwardedFromMessageID == nil || *message.ForwardedFromMessageID != r.message.ID {
+			t.Fatalf("copy %d is not marked as forwarded from the source", i)
+		}
+		if message.Content != r.message.Content || string(message.Mentions) != "[]" {
+			t.Fatalf("copy %d has content %q and mentions %s", i, message.Content, message.Mentions)
+		}
+	}
+}
+
+func TestForwardMessage_ChecksEveryTargetBeforeWriting(t *testing.T) {
+	r := &fakeRepo{message: forwardSource()}
+	svc, _ := newTestService(r, Config{})
+	readOnly := uuid.New()
+	r.readOnly = map[uuid.UUID]bool{readOnly: true}
+
+	_, err := svc.ForwardMessage(context.Background(), r.message.ID, uuid.New(), []uuid.UUID{uuid.New(), readOnly})
+	if pkgErrors.GetCode(err) != pkgErrors.CodeForbidden {
+		t.Fatalf("expected forbidden for a conversation the user cannot write to, got %v", err)
+	}
+	if len(r.forwarded) != 0 {
+		t.Fatalf("expected nothing forwarded, got %d copies", len(r.forwarded))
+	}
+}
+
+func TestForwardMessage_CapsTargets(t *testing.T) {
+	r := &fakeRepo{message: forwardSource()}
+	svc, _ := newTestService(r, Config{})
+
+	targets := make([]uuid.UUID, models.MaxForwardTargets+1)
+	for i := range targets {
+		targets[i] = uuid.New()
+	}
+
+	_, err := svc.ForwardMessage(context.Background(), r.message.ID, uuid.New(), targets)
+	if pkgErrors.GetCode(err) != pkgErrors.CodeInvalidArgument {
+		t.Fatalf("expected invalid argument above the target cap, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"echo-backend/services/message-service/internal/models"
+
+	"shared/pkg/database"
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+func TestGetConversationHistory_PageAndCursor(t *testing.T) {
+	conversationID, member := uuid.New(), uuid.New()
+	page := []models.Message{{ID: uuid.New()}, {ID: uuid.New()}, {ID: uuid.New()}}
+	r := &fakeRepo{
+		message:     &models.Message{ID: uuid.New(), ConversationID: conversationID},
+		participant: &models.ConversationParticipant{UserID: member},
+		page:        page,
+	}
+	svc, _ := newTestService(r, Config{})
+
+	result, err := svc.GetConversationHistory(context.Background(), conversationID, member, &models.PaginationParams{Limit: 2})
+	if err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	if !result.HasMore || result.NextCursor != page[1].ID.String() {
+		t.Fatalf("expected next cursor %s, got %+v", page[1].ID, result)
+	}
+	if len(result.Messages[0].Media) != 1 || len(result.Messages[0].Reactions) != 1 {
+		t.Fatalf("media and reactions were not attached: %+v", result.Messages[0])
+	}
+
+	if _, err := svc.GetConversationHistory(context.Background(), conversationID, member, &models.PaginationParams{Limit: 1000}); err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	if r.lastLimit != database.MaxPageLimit {
+		t.Fatalf("limit should be capped at %d, got %d", database.MaxPageLimit, r.lastLimit)
+	}
+}
+
+func TestGetConversationHistory_Rejections(t *testing.T) {
+	conversationID, member := uuid.New(), uuid.New()
+	svc, _ := newTestService(&fakeRepo{
+		message:     &models.Message{ID: uuid.New(), ConversationID: conversationID},
+		participant: &models.ConversationParticipant{UserID: member},
+	}, Config{})
+
+	_, err := svc.GetConversationHistory(context.Background(), conversationID, uuid.New(), &models.PaginationParams{})
+	if got := pkgErrors.GetCode(err); got != pkgErrors.CodeForbidden {
+		t.Fatalf("non-participant: expected %s, got %s", pkgErrors.CodeForbidden, got)
+	}
+
+	// The fake resolves every cursor to a message of another conversation
+	foreign := uuid.New()
+	_, err = svc.GetConversationHistory(context.Background(), uuid.New(), member, &models.PaginationParams{BeforeID: &foreign})
+	if got := pkgErrors.GetCode(err); got != pkgErrors.CodeInvalidArgument {
+		t.Fatalf("foreign cursor: expected %s, got %s", pkgErrors.CodeInvalidArgument, got)
+	}
+}
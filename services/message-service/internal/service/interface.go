@@ -13,8 +13,20 @@ type MessageServiceInterface interface {
 	SendMessage(ctx context.Context, req *models.SendMessageRequest) (*models.Message, error)
 	GetMessage(ctx context.Context, messageID uuid.UUID) (*models.Message, error)
 	GetMessages(ctx context.Context, conversationID uuid.UUID, params *models.PaginationParams) (*models.MessagesResponse, error)
-	EditMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID, newContent string) error
-	DeleteMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID) error
+	GetConversationHistory(ctx context.Context, conversationID, userID uuid.UUID, params *models.PaginationParams) (*models.MessagesResponse, error)
+	SearchMessages(ctx context.Context, conversationID, userID uuid.UUID, query string, limit, offset int) (*models.SearchResponse, error)
+	EditMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID, newContent string) (*models.Message, error)
+	DeleteMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID, scope string) (*models.Message, error)
+	ForwardMessage(ctx context.Context, messageID, userID uuid.UUID, targetIDs []uuid.UUID) ([]*models.Message, error)
+
+	// Reactions
+	AddReaction(ctx context.Context, messageID, userID uuid.UUID, reactionType string, emoji, skinTone *string) (*models.ReactionSummary, error)
+	RemoveReaction(ctx context.Context, messageID, userID uuid.UUID, reactionType string) (*models.ReactionSummary, error)
+
+	// Polls
+	CreatePoll(ctx context.Context, req *models.CreatePollRequest) (*models.Poll, error)
+	VotePoll(ctx context.Context, pollID, userID uuid.UUID, optionIDs []uuid.UUID) (*models.Poll, error)
+	ClosePoll(ctx context.Context, pollID, userID uuid.UUID) (*models.Poll, error)
 
 	// Delivery and read receipts
 	MarkAsDelivered(ctx context.Context, messageID, userID uuid.UUID) error
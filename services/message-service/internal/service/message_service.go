@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"echo-backend/services/message-service/internal/repo"
 	"echo-backend/services/message-service/internal/websocket"
 
+	"shared/pkg/database"
 	pkgErrors "shared/pkg/errors"
 	"shared/pkg/logger"
 	"shared/pkg/messaging"
@@ -20,34 +22,67 @@ import (
 type MessageService interface {
 	SendMessage(ctx context.Context, req *models.SendMessageRequest) (*models.Message, error)
 	GetMessages(ctx context.Context, conversationID uuid.UUID, params *models.PaginationParams) (*models.MessagesResponse, error)
+	GetConversationHistory(ctx context.Context, conversationID, userID uuid.UUID, params *models.PaginationParams) (*models.MessagesResponse, error)
+	SearchMessages(ctx context.Context, conversationID, userID uuid.UUID, query string, limit, offset int) (*models.SearchResponse, error)
 	GetMessage(ctx context.Context, messageID uuid.UUID) (*models.Message, error)
-	EditMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID, newContent string) error
-	DeleteMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID) error
+	EditMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID, newContent string) (*models.Message, error)
+	DeleteMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID, scope string) (*models.Message, error)
+	AddReaction(ctx context.Context, messageID, userID uuid.UUID, reactionType string, emoji, skinTone *string) (*models.ReactionSummary, error)
+	RemoveReaction(ctx context.Context, messageID, userID uuid.UUID, reactionType string) (*models.ReactionSummary, error)
+	CreatePoll(ctx context.Context, req *models.CreatePollRequest) (*models.Poll, error)
+	VotePoll(ctx context.Context, pollID, userID uuid.UUID, optionIDs []uuid.UUID) (*models.Poll, error)
+	ClosePoll(ctx context.Context, pollID, userID uuid.UUID) (*models.Poll, error)
+	ForwardMessage(ctx context.Context, messageID, userID uuid.UUID, targetIDs []uuid.UUID) ([]*models.Message, error)
 	MarkAsDelivered(ctx context.Context, messageID, userID uuid.UUID) error
 	MarkAsRead(ctx context.Context, messageID, userID uuid.UUID) error
 	HandleReadReceipt(ctx context.Context, userID, messageID uuid.UUID) error
 	SetTypingIndicator(ctx context.Context, conversationID, userID uuid.UUID, isTyping bool) error
 	MarkConversationAsRead(ctx context.Context, conversationID, userID uuid.UUID) error
+	DeliverScheduledMessages(ctx context.Context) (int, error)
+	ExpireMessages(ctx context.Context) (int, error)
+}
+
+// Config carries the settings the message service reads from the service
+// configuration
+type Config struct {
+	// EventsTopic receives message lifecycle events such as message.edited
+	EventsTopic string
+	// EditWindow is how long after sending the sender may still edit
+	EditWindow time.Duration
+	// DeleteWindow is how long the sender may delete for everyone; conversation
+	// admins with can_delete_messages are not bound by it
+	DeleteWindow time.Duration
+	// SearchLanguage is the Postgres text search configuration for indexing
+	// and querying message content
+	SearchLanguage string
+	// ExpiryBatchSize caps how many expired messages are purged per
+	// transaction
+	ExpiryBatchSize int
 }
 
 type messageService struct {
 	repo   repo.MessageRepository
 	hub    *websocket.Hub
 	kafka  messaging.Producer
+	cfg    Config
 	logger logger.Logger
+	clock  func() time.Time
 }
 
 func NewMessageService(
 	repo repo.MessageRepository,
 	hub *websocket.Hub,
 	kafka messaging.Producer,
+	cfg Config,
 	log logger.Logger,
 ) MessageService {
 	return &messageService{
 		repo:   repo,
 		hub:    hub,
 		kafka:  kafka,
+		cfg:    cfg,
 		logger: log,
+		clock:  time.Now,
 	}
 }
 
@@ -78,7 +113,7 @@ func (s *messageService) SendMessage(ctx context.Context, req *models.SendMessag
 			WithDetail("user_id", req.SenderUserID.String())
 	}
 
-	now := time.Now()
+	now := s.clock()
 	message := &models.Message{
 		ID:              uuid.New(),
 		ConversationID:  req.ConversationID,
@@ -89,6 +124,7 @@ func (s *messageService) SendMessage(ctx context.Context, req *models.SendMessag
 		Status:          "sent",
 		IsEdited:        false,
 		IsDeleted:       false,
+		CreatedAt:       now,
 		UpdatedAt:       now,
 	}
 
@@ -118,6 +154,27 @@ func (s *messageService) SendMessage(ctx context.Context, req *models.SendMessag
 		message.Metadata = json.RawMessage("{}") // Empty object
 	}
 
+	// Scheduled messages are stored now and sent by the delivery worker
+	if req.ScheduledAt != nil {
+		if !req.ScheduledAt.After(now) {
+			return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "scheduled_at must be in the future").
+				WithService("message-service").
+				WithDetail("scheduled_at", req.ScheduledAt.Format(time.RFC3339))
+		}
+		scheduledAt := req.ScheduledAt.UTC()
+		message.ScheduledAt = &scheduledAt
+		message.IsScheduled = true
+		message.Status = models.MessageStatusScheduled
+	}
+
+	sentAt := now
+	if message.ScheduledAt != nil {
+		sentAt = *message.ScheduledAt
+	}
+	if appErr := s.startDisappearingTimer(ctx, message, sentAt); appErr != nil {
+		return nil, appErr
+	}
+
 	err = s.repo.CreateMessage(ctx, message)
 
 	if err != nil {
@@ -135,12 +192,42 @@ func (s *messageService) SendMessage(ctx context.Context, req *models.SendMessag
 		logger.String("sender_id", message.SenderUserID.String()),
 	)
 
-	var participantIDs []uuid.UUID
-	participantIDs, err = s.repo.GetParticipantUserIDs(ctx, req.ConversationID)
+	if message.IsScheduled {
+		s.logger.Info("Message scheduled",
+			logger.String("message_id", message.ID.String()),
+			logger.Time("scheduled_at", *message.ScheduledAt),
+		)
+		return message, nil
+	}
+
+	s.fanOutMessage(ctx, message)
+
+	return message, nil
+}
+
+// startDisappearingTimer sets the expiry of a message when its conversation
+// has disappearing messages on; the timer starts when the message goes out
+func (s *messageService) startDisappearingTimer(ctx context.Context, message *models.Message, sentAt time.Time) pkgErrors.AppError {
+	expireAfter, err := s.repo.GetDisappearingMessagesDuration(ctx, message.ConversationID)
+	if err != nil {
+		return err.WithService("message-service")
+	}
+	if expireAfter != nil {
+		seconds := int(expireAfter.Seconds())
+		message.ExpireAfter = &seconds
+		message.ExpiresAt = sql.NullTime{Time: sentAt.Add(*expireAfter), Valid: true}
+	}
+	return nil
+}
 
+// fanOutMessage runs everything that follows a message becoming visible:
+// conversation bookkeeping, delivery tracking, broadcast, indexing and
+// unread counts
+func (s *messageService) fanOutMessage(ctx context.Context, message *models.Message) {
+	participantIDs, err := s.repo.GetParticipantUserIDs(ctx, message.ConversationID)
 	if err != nil {
 		s.logger.Error("Failed to get participants",
-			logger.String("conversation_id", req.ConversationID.String()),
+			logger.String("conversation_id", message.ConversationID.String()),
 			logger.Error(err),
 		)
 		participantIDs = []uuid.UUID{}
@@ -148,12 +235,12 @@ func (s *messageService) SendMessage(ctx context.Context, req *models.SendMessag
 
 	go func() {
 		bgCtx := context.Background()
-		s.repo.UpdateConversationLastMessage(bgCtx, req.ConversationID, message.ID)
+		s.repo.UpdateConversationLastMessage(bgCtx, message.ConversationID, message.ID)
 	}()
 
 	recipientIDs := make([]uuid.UUID, 0)
 	for _, participantID := range participantIDs {
-		if participantID != req.SenderUserID {
+		if participantID != message.SenderUserID {
 			recipientIDs = append(recipientIDs, participantID)
 		}
 	}
@@ -165,14 +252,15 @@ func (s *messageService) SendMessage(ctx context.Context, req *models.SendMessag
 		}()
 	}
 
-	// Step 7: Broadcast message to all participants
-	go s.broadcastMessage(message, participantIDs, req.SenderUserID)
+	// Step 7: Index the message, then broadcast it to all participants
+	s.indexMessage(ctx, message)
+	go s.broadcastMessage(message, participantIDs, message.SenderUserID)
 
 	// Step 8: Update unread counts for all recipients
 	go func() {
 		bgCtx := context.Background()
 		for _, recipientID := range recipientIDs {
-			if err := s.repo.UpdateParticipantUnreadCount(bgCtx, req.ConversationID, recipientID, true); err != nil {
+			if err := s.repo.UpdateParticipantUnreadCount(bgCtx, message.ConversationID, recipientID, true); err != nil {
 				s.logger.Warn("Failed to update unread count",
 					logger.String("user_id", recipientID.String()),
 					logger.Error(err),
@@ -180,8 +268,6 @@ func (s *messageService) SendMessage(ctx context.Context, req *models.SendMessag
 			}
 		}
 	}()
-
-	return message, nil
 }
 
 // broadcastMessage handles the intelligent broadcasting of messages
@@ -258,11 +344,13 @@ func (s *messageService) sendPushNotification(message *models.Message, recipient
 		return
 	}
 
-	// Publish to Kafka topic for notification service
+	// Publish to Kafka topic for notification service. The dedup key lets the
+	// consumer drop a notification the service published twice.
 	kafkaMsg := messaging.NewMessage(notifJSON).
 		WithKey([]byte(recipientID.String())).
 		WithHeader("type", "notification").
-		WithHeader("message_id", message.ID.String())
+		WithHeader("message_id", message.ID.String()).
+		WithDedupKey(message.ID.String() + ":" + recipientID.String())
 
 	if err := s.kafka.Send(context.Background(), "notifications", kafkaMsg); err != nil {
 		s.logger.Error("Failed to publish notification",
@@ -295,6 +383,68 @@ func (s *messageService) GetMessages(ctx context.Context, conversationID uuid.UU
 	}, nil
 }
 
+// GetConversationHistory pages through a conversation for one of its
+// participants, attaching media and reaction summaries to each message
+func (s *messageService) GetConversationHistory(ctx context.Context, conversationID, userID uuid.UUID, params *models.PaginationParams) (*models.MessagesResponse, error) {
+	if _, err := s.repo.GetParticipant(ctx, conversationID, userID); err != nil {
+		if err.Code() == pkgErrors.CodeNotFound {
+			return nil, pkgErrors.New(pkgErrors.CodeForbidden, "user is not a participant of this conversation").
+				WithService("message-service").
+				WithDetail("conversation_id", conversationID.String()).
+				WithDetail("user_id", userID.String())
+		}
+		return nil, err.WithService("message-service")
+	}
+
+	for _, cursor := range []*uuid.UUID{params.BeforeID, params.AfterID} {
+		if cursor == nil {
+			continue
+		}
+		anchor, err := s.repo.GetMessageIncludingDeleted(ctx, *cursor)
+		if err != nil || anchor.ConversationID != conversationID {
+			return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "cursor does not belong to this conversation").
+				WithService("message-service").
+				WithDetail("conversation_id", conversationID.String()).
+				WithDetail("cursor", cursor.String())
+		}
+	}
+
+	params.Limit = database.ClampLimit(params.Limit)
+	messages, hasMore, err := s.repo.GetConversationHistory(ctx, conversationID, userID, params)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+
+	messageIDs := make([]uuid.UUID, len(messages))
+	for i := range messages {
+		messageIDs[i] = messages[i].ID
+	}
+
+	media, err := s.repo.GetMediaForMessages(ctx, messageIDs)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+	reactions, err := s.repo.GetReactionSummaries(ctx, messageIDs, userID)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+	for i := range messages {
+		messages[i].Media = media[messages[i].ID]
+		messages[i].Reactions = reactions[messages[i].ID]
+	}
+
+	result := &models.MessagesResponse{
+		Messages: messages,
+		HasMore:  hasMore,
+	}
+	// The cursor continues in the direction of this page: pass it back as
+	// after when paging forward, as before otherwise
+	if hasMore && len(messages) > 0 {
+		result.NextCursor = messages[len(messages)-1].ID.String()
+	}
+	return result, nil
+}
+
 // GetMessage retrieves a single message
 func (s *messageService) GetMessage(ctx context.Context, messageID uuid.UUID) (*models.Message, error) {
 	message, err := s.repo.GetMessageByID(ctx, messageID)
@@ -305,31 +455,61 @@ func (s *messageService) GetMessage(ctx context.Context, messageID uuid.UUID) (*
 	return message, nil
 }
 
-// EditMessage edits an existing message
-func (s *messageService) EditMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID, newContent string) error {
-	// Get original message to verify ownership
-	message, err := s.repo.GetMessageByID(ctx, messageID)
+// EditMessage replaces the content of a message. Only the sender may edit,
+// and only within the configured edit window.
+func (s *messageService) EditMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID, newContent string) (*models.Message, error) {
+	message, err := s.repo.GetMessageIncludingDeleted(ctx, messageID)
 	if err != nil {
-		return fmt.Errorf("message not found: %w", err)
+		return nil, err.WithService("message-service")
 	}
 
 	if message.SenderUserID != userID {
-		return fmt.Errorf("unauthorized: only message sender can edit")
+		return nil, pkgErrors.New(pkgErrors.CodeForbidden, "only the message sender can edit").
+			WithService("message-service").
+			WithDetail("message_id", messageID.String()).
+			WithDetail("user_id", userID.String())
 	}
 
-	// Update message
-	if err := s.repo.UpdateMessage(ctx, messageID, newContent); err != nil {
+	now := time.Now()
+	if message.IsDeleted {
+		return nil, pkgErrors.New(pkgErrors.CodeGone, "message has been deleted").
+			WithService("message-service").
+			WithDetail("message_id", messageID.String())
+	}
+	if message.ExpiresAt.Valid && !message.ExpiresAt.Time.After(now) {
+		return nil, pkgErrors.New(pkgErrors.CodeGone, "message has expired").
+			WithService("message-service").
+			WithDetail("message_id", messageID.String())
+	}
+	if s.cfg.EditWindow > 0 && now.Sub(message.CreatedAt) > s.cfg.EditWindow {
+		return nil, pkgErrors.New(pkgErrors.CodeForbidden, "edit window has passed").
+			WithService("message-service").
+			WithDetail("message_id", messageID.String()).
+			WithDetail("edit_window", s.cfg.EditWindow.String())
+	}
+
+	updated, err := s.repo.UpdateMessage(ctx, messageID, newContent)
+	if err != nil {
 		s.logger.Error("Failed to edit message",
 			logger.String("message_id", messageID.String()),
 			logger.Error(err),
 		)
-		return fmt.Errorf("failed to edit message: %w", err)
+		return nil, err.WithService("message-service")
 	}
 
-	// Broadcast edit event to all participants
+	s.logger.Info("Message edited",
+		logger.String("message_id", messageID.String()),
+		logger.String("conversation_id", updated.ConversationID.String()),
+		logger.String("user_id", userID.String()),
+	)
+
+	s.indexMessage(ctx, updated)
+	go s.publishMessageEdited(updated)
+
+	// Broadcast to every participant, including the editor's other devices
 	go func() {
 		bgCtx := context.Background()
-		participantIDs, err := s.repo.GetParticipantUserIDs(bgCtx, message.ConversationID)
+		participantIDs, err := s.repo.GetParticipantUserIDs(bgCtx, updated.ConversationID)
 		if err != nil {
 			return
 		}
@@ -337,53 +517,186 @@ func (s *messageService) EditMessage(ctx context.Context, messageID uuid.UUID, u
 		editEvent := models.MessageEvent{
 			Type:      "message_edited",
 			MessageID: messageID,
-			Message: &models.Message{
-				ID:      messageID,
-				Content: newContent,
-			},
-			Timestamp: time.Now(),
+			Message:   updated,
+			UserID:    userID,
+			Timestamp: updated.EditedAt.Time,
 		}
 
-		_ = s.hub.SendToUsers(participantIDs, editEvent, []uuid.UUID{userID})
+		_ = s.hub.SendToUsers(participantIDs, editEvent, nil)
 	}()
 
-	return nil
+	return updated, nil
 }
 
-// DeleteMessage deletes a message
-func (s *messageService) DeleteMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID) error {
-	if err := s.repo.DeleteMessage(ctx, messageID, userID); err != nil {
-		s.logger.Error("Failed to delete message",
-			logger.String("message_id", messageID.String()),
+// publishMessageEdited emits message.edited keyed by conversation, so
+// consumers see the edits of one conversation in order
+func (s *messageService) publishMessageEdited(message *models.Message) {
+	event := map[string]interface{}{
+		"type":            "message.edited",
+		"message_id":      message.ID.String(),
+		"conversation_id": message.ConversationID.String(),
+		"sender_id":       message.SenderUserID.String(),
+		"content":         message.Content,
+		"edited_at":       message.EditedAt.Time,
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("Failed to marshal message.edited event",
+			logger.String("message_id", message.ID.String()),
 			logger.Error(err),
 		)
-		return fmt.Errorf("failed to delete message: %w", err)
+		return
 	}
 
-	// Broadcast delete event
-	go func() {
-		bgCtx := context.Background()
-		message, err := s.repo.GetMessageByID(bgCtx, messageID)
-		if err != nil {
-			return
+	dedupKey := fmt.Sprintf("%s:edited:%d", message.ID, message.EditedAt.Time.UnixNano())
+	if err := s.kafka.ProduceWithKey(context.Background(), s.cfg.EventsTopic, message.ConversationID.String(), eventJSON,
+		messaging.WithDedupKey(dedupKey)); err != nil {
+		s.logger.Error("Failed to publish message.edited event",
+			logger.String("message_id", message.ID.String()),
+			logger.Error(err),
+		)
+	}
+}
+
+// DeleteMessage deletes a message for the requesting user only (scope "me")
+// or replaces it with a tombstone for every participant (scope "everyone")
+func (s *messageService) DeleteMessage(ctx context.Context, messageID uuid.UUID, userID uuid.UUID, scope string) (*models.Message, error) {
+	message, err := s.repo.GetMessageIncludingDeleted(ctx, messageID)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+
+	participant, err := s.repo.GetParticipant(ctx, message.ConversationID, userID)
+	if err != nil {
+		if err.Code() == pkgErrors.CodeNotFound {
+			return nil, pkgErrors.New(pkgErrors.CodeForbidden, "user is not a participant of this conversation").
+				WithService("message-service").
+				WithDetail("message_id", messageID.String()).
+				WithDetail("user_id", userID.String())
 		}
+		return nil, err.WithService("message-service")
+	}
 
-		participantIDs, err := s.repo.GetParticipantUserIDs(bgCtx, message.ConversationID)
-		if err != nil {
-			return
+	switch scope {
+	case models.DeleteScopeMe:
+		if err := s.repo.HideMessageForUser(ctx, messageID, userID); err != nil {
+			return nil, err.WithService("message-service")
 		}
+		message.DeletedForMe = true
 
-		deleteEvent := models.MessageEvent{
-			Type:      "message_deleted",
-			MessageID: messageID,
-			UserID:    userID,
-			Timestamp: time.Now(),
+		// Sync the user's other devices; nobody else sees a change
+		go func() {
+			_ = s.hub.SendToUser(userID, models.MessageEvent{
+				Type:      "message_deleted",
+				MessageID: messageID,
+				UserID:    userID,
+				Status:    models.DeleteScopeMe,
+				Timestamp: time.Now(),
+			})
+		}()
+
+	case models.DeleteScopeEveryone:
+		if message.IsDeleted {
+			return nil, pkgErrors.New(pkgErrors.CodeGone, "message has already been deleted").
+				WithService("message-service").
+				WithDetail("message_id", messageID.String())
 		}
 
-		_ = s.hub.SendToUsers(participantIDs, deleteEvent, []uuid.UUID{userID})
-	}()
+		isSender := message.SenderUserID == userID
+		if !isSender && !participant.CanDeleteMessages {
+			return nil, pkgErrors.New(pkgErrors.CodeForbidden, "only the sender or a conversation admin can delete for everyone").
+				WithService("message-service").
+				WithDetail("message_id", messageID.String()).
+				WithDetail("user_id", userID.String())
+		}
+		if isSender && !participant.CanDeleteMessages && s.cfg.DeleteWindow > 0 && time.Since(message.CreatedAt) > s.cfg.DeleteWindow {
+			return nil, pkgErrors.New(pkgErrors.CodeForbidden, "delete window has passed").
+				WithService("message-service").
+				WithDetail("message_id", messageID.String()).
+				WithDetail("delete_window", s.cfg.DeleteWindow.String())
+		}
 
-	return nil
+		message, err = s.repo.DeleteMessage(ctx, messageID)
+		if err != nil {
+			s.logger.Error("Failed to delete message",
+				logger.String("message_id", messageID.String()),
+				logger.Error(err),
+			)
+			return nil, err.WithService("message-service")
+		}
+
+		go s.removeFromIndex(messageID)
+
+		// Broadcast the tombstone to all participants
+		go func() {
+			bgCtx := context.Background()
+			participantIDs, err := s.repo.GetParticipantUserIDs(bgCtx, message.ConversationID)
+			if err != nil {
+				return
+			}
+
+			deleteEvent := models.MessageEvent{
+				Type:      "message_deleted",
+				MessageID: messageID,
+				Message:   message,
+				UserID:    userID,
+				Status:    models.DeleteScopeEveryone,
+				Timestamp: message.DeletedAt.Time,
+			}
+
+			_ = s.hub.SendToUsers(participantIDs, deleteEvent, nil)
+		}()
+
+	default:
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "delete scope must be me or everyone").
+			WithService("message-service").
+			WithDetail("scope", scope)
+	}
+
+	s.logger.Info("Message deleted",
+		logger.String("message_id", messageID.String()),
+		logger.String("user_id", userID.String()),
+		logger.String("scope", scope),
+	)
+
+	go s.publishMessageDeleted(message, userID, scope)
+
+	return message, nil
+}
+
+// publishMessageDeleted emits message.deleted with the scope, keyed by
+// conversation like the other lifecycle events
+func (s *messageService) publishMessageDeleted(message *models.Message, userID uuid.UUID, scope string) {
+	event := map[string]interface{}{
+		"type":            "message.deleted",
+		"scope":           scope,
+		"message_id":      message.ID.String(),
+		"conversation_id": message.ConversationID.String(),
+		"deleted_by":      userID.String(),
+		"deleted_at":      time.Now(),
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("Failed to marshal message.deleted event",
+			logger.String("message_id", message.ID.String()),
+			logger.Error(err),
+		)
+		return
+	}
+
+	dedupKey := fmt.Sprintf("%s:deleted:%s", message.ID, scope)
+	if scope == models.DeleteScopeMe {
+		dedupKey += ":" + userID.String()
+	}
+	if err := s.kafka.ProduceWithKey(context.Background(), s.cfg.EventsTopic, message.ConversationID.String(), eventJSON,
+		messaging.WithDedupKey(dedupKey)); err != nil {
+		s.logger.Error("Failed to publish message.deleted event",
+			logger.String("message_id", message.ID.String()),
+			logger.Error(err),
+		)
+	}
 }
 
 // MarkAsDelivered marks a message as delivered
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"echo-backend/services/message-service/internal/models"
+
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// messageTestConfig gives edits and deletes their production windows
+var messageTestConfig = Config{EditWindow: 15 * time.Minute, DeleteWindow: time.Hour}
+
+func TestEditMessage_PublishesEditedEvent(t *testing.T) {
+	sender := uuid.New()
+	msg := &models.Message{ID: uuid.New(), ConversationID: uuid.New(), SenderUserID: sender, Content: "helo", CreatedAt: time.Now()}
+	svc, producer := newTestService(&fakeRepo{message: msg}, messageTestConfig)
+
+	updated, err := svc.EditMessage(context.Background(), msg.ID, sender, "hello")
+	if err != nil {
+		t.Fatalf("edit failed: %v", err)
+	}
+	if !updated.IsEdited || updated.Content != "hello" {
+		t.Fatalf("unexpected message state: %+v", updated)
+	}
+
+	select {
+	case event := <-producer.produced:
+		if event.topic != "messages" || event.key != msg.ConversationID.String() {
+			t.Fatalf("event published to %s with key %s", event.topic, event.key)
+		}
+		var payload map[string]interface{}
+		if err := json.Unmarshal(event.value, &payload); err != nil || payload["type"] != "message.edited" {
+			t.Fatalf("unexpected event payload %s", event.value)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("message.edited was not published")
+	}
+}
+
+func TestEditMessage_Rejections(t *testing.T) {
+	sender := uuid.New()
+	cases := []struct {
+		name   string
+		msg    models.Message
+		editor uuid.UUID
+		code   string
+	}{
+		{"not sender", models.Message{SenderUserID: sender, CreatedAt: time.Now()}, uuid.New(), pkgErrors.CodeForbidden},
+		{"window passed", models.Message{SenderUserID: sender, CreatedAt: time.Now().Add(-time.Hour)}, sender, pkgErrors.CodeForbidden},
+		{"deleted", models.Message{SenderUserID: sender, CreatedAt: time.Now(), IsDeleted: true}, sender, pkgErrors.CodeGone},
+		{"expired", models.Message{SenderUserID: sender, CreatedAt: time.Now(),
+			ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true}}, sender, pkgErrors.CodeGone},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := tc.msg
+			msg.ID = uuid.New()
+			r := &fakeRepo{message: &msg}
+			svc, _ := newTestService(r, messageTestConfig)
+
+			_, err := svc.EditMessage(context.Background(), msg.ID, tc.editor, "new content")
+			if got := pkgErrors.GetCode(err); got != tc.code {
+				t.Fatalf("expected %s, got %s (%v)", tc.code, got, err)
+			}
+			if r.updated {
+				t.Fatalf("rejected edit must not reach the repository")
+			}
+		})
+	}
+}
+
+func TestDeleteMessage_ForEveryone(t *testing.T) {
+	sender, admin, member := uuid.New(), uuid.New(), uuid.New()
+	cases := []struct {
+		name        string
+		createdAt   time.Time
+		participant models.ConversationParticipant
+		code        string
+	}{
+		{"sender within window", time.Now(), models.ConversationParticipant{UserID: sender}, ""},
+		{"sender after window", time.Now().Add(-2 * time.Hour), models.ConversationParticipant{UserID: sender}, pkgErrors.CodeForbidden},
+		{"admin after window", time.Now().Add(-2 * time.Hour), models.ConversationParticipant{UserID: admin, CanDeleteMessages: true}, ""},
+		{"other member", time.Now(), models.ConversationParticipant{UserID: member}, pkgErrors.CodeForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := &models.Message{ID: uuid.New(), ConversationID: uuid.New(), SenderUserID: sender, Content: "secret", CreatedAt: tc.createdAt}
+			r := &fakeRepo{message: msg, participant: &tc.participant}
+			svc, producer := newTestService(r, messageTestConfig)
+
+			deleted, err := svc.DeleteMessage(context.Background(), msg.ID, tc.participant.UserID, models.DeleteScopeEveryone)
+			if tc.code != "" {
+				if got := pkgErrors.GetCode(err); got != tc.code {
+					t.Fatalf("expected %s, got %s (%v)", tc.code, got, err)
+				}
+				if r.deleted {
+					t.Fatalf("rejected delete must not reach the repository")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("delete failed: %v", err)
+			}
+			if !deleted.IsDeleted || deleted.Content != "" {
+				t.Fatalf("expected a blank tombstone, got %+v", deleted)
+			}
+			select {
+			case event := <-producer.produced:
+				var payload map[string]interface{}
+				if err := json.Unmarshal(event.value, &payload); err != nil || payload["scope"] != models.DeleteScopeEveryone {
+					t.Fatalf("unexpected event payload %s", event.value)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("message.deleted was not published")
+			}
+		})
+	}
+}
+
+func TestDeleteMessage_ForMeHidesOnlyForUser(t *testing.T) {
+	sender, member := uuid.New(), uuid.New()
+	msg := &models.Message{ID: uuid.New(), ConversationID: uuid.New(), SenderUserID: sender, Content: "hi", CreatedAt: time.Now().Add(-48 * time.Hour)}
+	r := &fakeRepo{message: msg, participant: &models.ConversationParticipant{UserID: member}}
+	svc, _ := newTestService(r, messageTestConfig)
+
+	result, err := svc.DeleteMessage(context.Background(), msg.ID, member, models.DeleteScopeMe)
+	if err != nil {
+		t.Fatalf("delete for me failed: %v", err)
+	}
+	if !result.DeletedForMe || result.IsDeleted || result.Content != "hi" {
+		t.Fatalf("message should only be hidden for the user: %+v", result)
+	}
+	if r.deleted || len(r.hiddenFor) != 1 || r.hiddenFor[0] != member {
+		t.Fatalf("expected a per-user deletion, got deleted=%v hidden=%v", r.deleted, r.hiddenFor)
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"echo-backend/services/message-service/internal/models"
+
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// CreatePoll posts a poll message to a conversation the creator belongs to
+func (s *messageService) CreatePoll(ctx context.Context, req *models.CreatePollRequest) (*models.Poll, error) {
+	if _, err := s.requireParticipant(ctx, req.ConversationID, req.CreatorUserID); err != nil {
+		return nil, err
+	}
+
+	now := s.clock()
+	poll, err := newPoll(req, now)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+
+	message := &models.Message{
+		ID:             uuid.New(),
+		ConversationID: req.ConversationID,
+		SenderUserID:   req.CreatorUserID,
+		Content:        poll.Question,
+		MessageType:    "poll",
+		Status:         "sent",
+		Mentions:       json.RawMessage("[]"),
+		Metadata:       json.RawMessage("{}"),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.startDisappearingTimer(ctx, message, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreatePoll(ctx, message, poll); err != nil {
+		s.logger.Error("Failed to create poll",
+			logger.String("conversation_id", req.ConversationID.String()),
+			logger.String("user_id", req.CreatorUserID.String()),
+			logger.Error(err),
+		)
+		return nil, err.WithService("message-service")
+	}
+	poll.ConversationID = message.ConversationID
+	poll.CreatorUserID = message.SenderUserID
+
+	// Recipients get the poll without a quiz's answer
+	message.Poll = pollView(poll, uuid.Nil, nil, now)
+	s.fanOutMessage(ctx, message)
+
+	return pollView(poll, req.CreatorUserID, nil, now), nil
+}
+
+// VotePoll makes optionIDs the user's answer to a poll. A poll without
+// multiple answers takes exactly one option, and a new vote replaces the
+// previous one; quiz answers are final.
+func (s *messageService) VotePoll(ctx context.Context, pollID, userID uuid.UUID, optionIDs []uuid.UUID) (*models.Poll, error) {
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+	if _, err := s.requireParticipant(ctx, poll.ConversationID, userID); err != nil {
+		return nil, err
+	}
+
+	if !poll.IsOpen(s.clock()) {
+		return nil, pkgErrors.New(pkgErrors.CodeConflict, "poll is closed").
+			WithService("message-service").
+			WithDetail("poll_id", pollID.String())
+	}
+
+	optionIDs = uniqueIDs(optionIDs)
+	if len(optionIDs) == 0 {
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "at least one option is required").
+			WithService("message-service")
+	}
+	if !poll.AllowMultiple && len(optionIDs) > 1 {
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "poll allows a single answer").
+			WithService("message-service").
+			WithDetail("poll_id", pollID.String())
+	}
+
+	if poll.IsQuiz {
+		previous, err := s.repo.GetPollVotes(ctx, pollID, userID)
+		if err != nil {
+			return nil, err.WithService("message-service")
+		}
+		if len(previous) > 0 {
+			return nil, pkgErrors.New(pkgErrors.CodeConflict, "quiz answers cannot be changed").
+				WithService("message-service").
+				WithDetail("poll_id", pollID.String())
+		}
+	}
+
+	updated, err := s.repo.CastPollVote(ctx, pollID, userID, optionIDs)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+
+	now := s.clock()
+	go s.broadcastPoll("poll.voted", updated, userID, now)
+
+	return pollView(updated, userID, optionIDs, now), nil
+}
+
+// ClosePoll stops a poll from taking votes; only its creator or a
+// conversation admin who may delete messages can close it
+func (s *messageService) ClosePoll(ctx context.Context, pollID, userID uuid.UUID) (*models.Poll, error) {
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+	participant, err := s.requireParticipant(ctx, poll.ConversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if poll.CreatorUserID != userID && !participant.CanDeleteMessages {
+		return nil, pkgErrors.New(pkgErrors.CodeForbidden, "only the poll creator can close it").
+			WithService("message-service").
+			WithDetail("poll_id", pollID.String())
+	}
+
+	closed, err := s.repo.ClosePoll(ctx, pollID)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+
+	myVotes, err := s.repo.GetPollVotes(ctx, pollID, userID)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+
+	now := s.clock()
+	go s.broadcastPoll("poll.closed", closed, userID, now)
+
+	return pollView(closed, userID, myVotes, now), nil
+}
+
+// requireParticipant loads the user's membership of a conversation, turning
+// a missing membership into Forbidden
+func (s *messageService) requireParticipant(ctx context.Context, conversationID, userID uuid.UUID) (*models.ConversationParticipant, pkgErrors.AppError) {
+	participant, err := s.repo.GetParticipant(ctx, conversationID, userID)
+	if err != nil {
+		if err.Code() == pkgErrors.CodeNotFound {
+			return nil, pkgErrors.New(pkgErrors.CodeForbidden, "user is not a participant of this conversation").
+				WithService("message-service").
+				WithDetail("conversation_id", conversationID.String()).
+				WithDetail("user_id", userID.String())
+		}
+		return nil, err.WithService("message-service")
+	}
+	return participant, nil
+}
+
+func (s *messageService) broadcastPoll(eventType string, poll *models.Poll, actorID uuid.UUID, now time.Time) {
+	participantIDs, err := s.repo.GetParticipantUserIDs(context.Background(), poll.ConversationID)
+	if err != nil {
+		return
+	}
+
+	event := models.MessageEvent{
+		Type:           eventType,
+		MessageID:      poll.MessageID,
+		ConversationID: poll.ConversationID,
+		Poll:           pollView(poll, uuid.Nil, nil, now),
+		Timestamp:      now,
+	}
+	if !poll.IsAnonymous {
+		event.UserID = actorID
+	}
+
+	if err := s.hub.SendToUsers(participantIDs, event, nil); err != nil {
+		s.logger.Debug("Failed to broadcast poll update",
+			logger.String("poll_id", poll.ID.String()),
+			logger.String("event", eventType),
+			logger.Error(err),
+		)
+	}
+}
+
+// newPoll validates a create request and builds the poll it describes
+func newPoll(req *models.CreatePollRequest, now time.Time) (*models.Poll, pkgErrors.AppError) {
+	question := strings.TrimSpace(req.Question)
+	if question == "" {
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "poll question is required")
+	}
+	if len(req.Options) < models.MinPollOptions || len(req.Options) > models.MaxPollOptions {
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "poll must have between 2 and 10 options").
+			WithDetail("options", len(req.Options))
+	}
+
+	poll := &models.Poll{
+		Question:      question,
+		AllowMultiple: req.AllowMultiple,
+		IsAnonymous:   req.IsAnonymous,
+		IsQuiz:        req.IsQuiz,
+		ClosesAt:      req.ClosesAt,
+		Options:       make([]models.PollOption, 0, len(req.Options)),
+	}
+
+	for i, text := range req.Options {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "poll options cannot be empty").
+				WithDetail("option_order", i)
+		}
+		poll.Options = append(poll.Options, models.PollOption{OptionText: text, OptionOrder: i})
+	}
+
+	if req.ClosesAt != nil && !req.ClosesAt.After(now) {
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "closes_at must be in the future")
+	}
+
+	if req.IsQuiz {
+		if req.AllowMultiple {
+			return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "a quiz takes a single answer")
+		}
+		if req.CorrectOption == nil || *req.CorrectOption < 0 || *req.CorrectOption >= len(req.Options) {
+			return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "a quiz needs a valid correct_option_id")
+		}
+		poll.CorrectOptionID = req.CorrectOption
+		poll.Explanation = req.Explanation
+	}
+
+	return poll, nil
+}
+
+// pollView is the poll as one viewer sees it. A quiz keeps its answer and
+// explanation hidden until the viewer has answered, the viewer created it,
+// or the poll is closed. Pass uuid.Nil for the view shared with everyone.
+func pollView(poll *models.Poll, viewerID uuid.UUID, myVotes []uuid.UUID, now time.Time) *models.Poll {
+	view := *poll
+	view.Options = append([]models.PollOption(nil), poll.Options...)
+	view.MyVotes = myVotes
+	view.IsCorrect = nil
+
+	if !poll.IsQuiz {
+		return &view
+	}
+
+	revealed := !poll.IsOpen(now) || len(myVotes) > 0 || (viewerID != uuid.Nil && viewerID == poll.CreatorUserID)
+	if !revealed {
+		view.CorrectOptionID = nil
+		view.Explanation = nil
+		return &view
+	}
+
+	if len(myVotes) > 0 && poll.CorrectOptionID != nil {
+		correct := false
+		for _, option := range poll.Options {
+			if option.ID == myVotes[0] {
+				correct = option.OptionOrder == *poll.CorrectOptionID
+			}
+		}
+		view.IsCorrect = &correct
+	}
+	return &view
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"echo-backend/services/message-service/internal/models"
+
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+func testPoll(options int) *models.Poll {
+	poll := &models.Poll{ID: uuid.New(), ConversationID: uuid.New(), CreatorUserID: uuid.New(), Question: "Lunch?"}
+	for i := 0; i < options; i++ {
+		poll.Options = append(poll.Options, models.PollOption{ID: uuid.New(), OptionOrder: i})
+	}
+	return poll
+}
+
+func TestVotePoll_SingleChoiceRevoteReplacesVote(t *testing.T) {
+	poll := testPoll(2)
+	svc, _ := newTestService(&fakeRepo{poll: poll}, Config{})
+	voter := uuid.New()
+
+	if _, err := svc.VotePoll(context.Background(), poll.ID, voter, []uuid.UUID{poll.Options[0].ID}); err != nil {
+		t.Fatalf("first vote returned error: %v", err)
+	}
+	updated, err := svc.VotePoll(context.Background(), poll.ID, voter, []uuid.UUID{poll.Options[1].ID})
+	if err != nil {
+		t.Fatalf("second vote returned error: %v", err)
+	}
+
+	if updated.TotalVotes != 1 || updated.Options[0].VoteCount != 0 || updated.Options[1].VoteCount != 1 {
+		t.Fatalf("expected the re-vote to move the single vote, got total=%d counts=%d/%d",
+			updated.TotalVotes, updated.Options[0].VoteCount, updated.Options[1].VoteCount)
+	}
+
+	_, err = svc.VotePoll(context.Background(), poll.ID, voter, []uuid.UUID{poll.Options[0].ID, poll.Options[1].ID})
+	if pkgErrors.GetCode(err) != pkgErrors.CodeInvalidArgument {
+		t.Fatalf("expected invalid argument for two options on a single-choice poll, got %v", err)
+	}
+}
+
+func TestVotePoll_RejectsClosedPoll(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	poll := testPoll(2)
+	closesAt := now.Add(-time.Minute)
+	poll.ClosesAt = &closesAt
+	svc, _ := newTestService(&fakeRepo{poll: poll}, Config{})
+	svc.clock = func() time.Time { return now }
+
+	_, err := svc.VotePoll(context.Background(), poll.ID, uuid.New(), []uuid.UUID{poll.Options[0].ID})
+	if pkgErrors.GetCode(err) != pkgErrors.CodeConflict {
+		t.Fatalf("expected conflict once the poll has closed, got %v", err)
+	}
+}
+
+func TestVotePoll_QuizRevealsAnswerAfterVoting(t *testing.T) {
+	poll := testPoll(3)
+	poll.IsQuiz = true
+	correct := 2
+	poll.CorrectOptionID = &correct
+	svc, _ := newTestService(&fakeRepo{poll: poll}, Config{})
+
+	if shared := pollView(poll, uuid.Nil, nil, time.Now()); shared.CorrectOptionID != nil {
+		t.Fatalf("expected the shared view to hide the quiz answer")
+	}
+
+	voter := uuid.New()
+	answered, err := svc.VotePoll(context.Background(), poll.ID, voter, []uuid.UUID{poll.Options[2].ID})
+	if err != nil {
+		t.Fatalf("VotePoll returned error: %v", err)
+	}
+	if answered.CorrectOptionID == nil || answered.IsCorrect == nil || !*answered.IsCorrect {
+		t.Fatalf("expected the voter to see a correct answer, got %+v", answered)
+	}
+
+	_, err = svc.VotePoll(context.Background(), poll.ID, voter, []uuid.UUID{poll.Options[0].ID})
+	if pkgErrors.GetCode(err) != pkgErrors.CodeConflict {
+		t.Fatalf("expected quiz answers to be final, got %v", err)
+	}
+}
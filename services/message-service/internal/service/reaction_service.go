@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"echo-backend/services/message-service/internal/models"
+
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// AddReaction reacts to a message on behalf of a conversation participant.
+// Reacting twice with the same type is idempotent.
+func (s *messageService) AddReaction(ctx context.Context, messageID, userID uuid.UUID, reactionType string, emoji, skinTone *string) (*models.ReactionSummary, error) {
+	message, err := s.reactableMessage(ctx, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	reaction := &models.Reaction{
+		MessageID:        messageID,
+		UserID:           userID,
+		ReactionType:     reactionType,
+		ReactionEmoji:    emoji,
+		ReactionSkinTone: skinTone,
+		CreatedAt:        time.Now(),
+	}
+
+	added, err := s.repo.AddReaction(ctx, reaction)
+	if err != nil {
+		s.logger.Error("Failed to add reaction",
+			logger.String("message_id", messageID.String()),
+			logger.String("user_id", userID.String()),
+			logger.Error(err),
+		)
+		return nil, err.WithService("message-service")
+	}
+	if added {
+		go s.broadcastReaction("reaction.added", message.ConversationID, reaction)
+	}
+
+	return s.reactionSummary(ctx, messageID, userID)
+}
+
+// RemoveReaction withdraws the user's reaction of the given type; removing a
+// reaction that does not exist is not an error
+func (s *messageService) RemoveReaction(ctx context.Context, messageID, userID uuid.UUID, reactionType string) (*models.ReactionSummary, error) {
+	message, err := s.reactableMessage(ctx, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := s.repo.RemoveReaction(ctx, messageID, userID, reactionType)
+	if err != nil {
+		s.logger.Error("Failed to remove reaction",
+			logger.String("message_id", messageID.String()),
+			logger.String("user_id", userID.String()),
+			logger.Error(err),
+		)
+		return nil, err.WithService("message-service")
+	}
+	if removed {
+		go s.broadcastReaction("reaction.removed", message.ConversationID, &models.Reaction{
+			MessageID:    messageID,
+			UserID:       userID,
+			ReactionType: reactionType,
+			CreatedAt:    time.Now(),
+		})
+	}
+
+	return s.reactionSummary(ctx, messageID, userID)
+}
+
+// reactableMessage loads a live message and checks the user belongs to its
+// conversation
+func (s *messageService) reactableMessage(ctx context.Context, messageID, userID uuid.UUID) (*models.Message, pkgErrors.AppError) {
+	message, err := s.repo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+
+	if _, err := s.repo.GetParticipant(ctx, message.ConversationID, userID); err != nil {
+		if err.Code() == pkgErrors.CodeNotFound {
+			return nil, pkgErrors.New(pkgErrors.CodeForbidden, "user is not a participant of this conversation").
+				WithService("message-service").
+				WithDetail("message_id", messageID.String()).
+				WithDetail("user_id", userID.String())
+		}
+		return nil, err.WithService("message-service")
+	}
+
+	return message, nil
+}
+
+func (s *messageService) reactionSummary(ctx context.Context, messageID, userID uuid.UUID) (*models.ReactionSummary, error) {
+	summary, err := s.repo.GetReactionSummary(ctx, messageID, userID)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+	return summary, nil
+}
+
+func (s *messageService) broadcastReaction(eventType string, conversationID uuid.UUID, reaction *models.Reaction) {
+	participantIDs, err := s.repo.GetParticipantUserIDs(context.Background(), conversationID)
+	if err != nil {
+		return
+	}
+
+	event := models.MessageEvent{
+		Type:      eventType,
+		MessageID: reaction.MessageID,
+		UserID:    reaction.UserID,
+		Reaction:  reaction,
+		Timestamp: reaction.CreatedAt,
+	}
+
+	if err := s.hub.SendToUsers(participantIDs, event, nil); err != nil {
+		s.logger.Debug("Failed to broadcast reaction",
+			logger.String("message_id", reaction.MessageID.String()),
+			logger.String("event", eventType),
+			logger.Error(err),
+		)
+	}
+}
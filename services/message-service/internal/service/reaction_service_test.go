@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"echo-backend/services/message-service/internal/models"
+
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+func TestAddReaction_DuplicateIsIdempotent(t *testing.T) {
+	member := uuid.New()
+	msg := &models.Message{ID: uuid.New(), ConversationID: uuid.New(), SenderUserID: uuid.New()}
+	svc, _ := newTestService(&fakeRepo{message: msg, participant: &models.ConversationParticipant{UserID: member}}, Config{})
+
+	for i := 0; i < 2; i++ {
+		summary, err := svc.AddReaction(context.Background(), msg.ID, member, "thumbs_up", nil, nil)
+		if err != nil {
+			t.Fatalf("add reaction %d failed: %v", i, err)
+		}
+		if summary.Total != 1 || !summary.Reactions[0].ReactedByMe {
+			t.Fatalf("attempt %d: unexpected summary %+v", i, summary)
+		}
+	}
+}
+
+func TestAddReaction_RequiresParticipant(t *testing.T) {
+	msg := &models.Message{ID: uuid.New(), ConversationID: uuid.New(), SenderUserID: uuid.New()}
+	r := &fakeRepo{message: msg, participant: &models.ConversationParticipant{UserID: uuid.New()}}
+	svc, _ := newTestService(r, Config{})
+
+	_, err := svc.AddReaction(context.Background(), msg.ID, uuid.New(), "heart", nil, nil)
+	if got := pkgErrors.GetCode(err); got != pkgErrors.CodeForbidden {
+		t.Fatalf("expected %s, got %s (%v)", pkgErrors.CodeForbidden, got, err)
+	}
+	if len(r.reactions) != 0 {
+		t.Fatalf("non-participant reaction must not be stored")
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"echo-backend/services/message-service/internal/models"
+
+	"shared/pkg/logger"
+	"shared/pkg/messaging"
+)
+
+// scheduledBatchSize caps how many due messages are claimed per transaction
+const scheduledBatchSize = 100
+
+// DeliverScheduledMessages sends every scheduled message that has come due
+// and returns how many went out. Each batch is marked sent and its
+// message.sent events published in one transaction; the broadcast and the
+// rest of the fan-out only happen after the commit.
+func (s *messageService) DeliverScheduledMessages(ctx context.Context) (int, error) {
+	now := s.clock()
+	publish := func(messages []models.Message) error {
+		return s.publishMessagesSent(ctx, messages)
+	}
+
+	total := 0
+	for {
+		delivered, err := s.repo.DeliverDueScheduledMessages(ctx, now, scheduledBatchSize, publish)
+		if err != nil {
+			return total, err.WithService("message-service")
+		}
+
+		for i := range delivered {
+			s.fanOutMessage(ctx, &delivered[i])
+		}
+		total += len(delivered)
+
+		if len(delivered) < scheduledBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		s.logger.Info("Delivered scheduled messages", logger.Int("count", total))
+	}
+	return total, nil
+}
+
+// publishMessagesSent emits message.sent for each message, keyed by
+// conversation. The dedup key lets consumers drop the repeat if a crash
+// between publishing and committing makes a later sweep send it again.
+func (s *messageService) publishMessagesSent(ctx context.Context, messages []models.Message) error {
+	for _, message := range messages {
+		event := map[string]interface{}{
+			"type":            "message.sent",
+			"message_id":      message.ID.String(),
+			"conversation_id": message.ConversationID.String(),
+			"sender_id":       message.SenderUserID.String(),
+			"content":         message.Content,
+			"message_type":    message.MessageType,
+			"scheduled_at":    message.ScheduledAt,
+			"sent_at":         message.CreatedAt,
+		}
+
+		eventJSON, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		if err := s.kafka.ProduceWithKey(ctx, s.cfg.EventsTopic, message.ConversationID.String(), eventJSON,
+			messaging.WithDedupKey(message.ID.String()+":sent")); err != nil {
+			s.logger.Error("Failed to publish message.sent event",
+				logger.String("message_id", message.ID.String()),
+				logger.Error(err),
+			)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"echo-backend/services/message-service/internal/models"
+
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+func scheduleMessage(t *testing.T, svc *messageService, at time.Time) *models.Message {
+	t.Helper()
+	msg, err := svc.SendMessage(context.Background(), &models.SendMessageRequest{
+		ConversationID: uuid.New(),
+		SenderUserID:   uuid.New(),
+		Content:        "see you tomorrow",
+		MessageType:    "text",
+		ScheduledAt:    &at,
+	})
+	if err != nil {
+		t.Fatalf("SendMessage returned error: %v", err)
+	}
+	return msg
+}
+
+func TestScheduledMessage_DeliveredOnceWhenDue(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	r := &fakeRepo{}
+	svc, p := newTestService(r, Config{})
+	svc.clock = func() time.Time { return now }
+
+	msg := scheduleMessage(t, svc, now.Add(time.Hour))
+	if !msg.IsScheduled || msg.Status != models.MessageStatusScheduled {
+		t.Fatalf("expected a scheduled message, got is_scheduled=%v status=%q", msg.IsScheduled, msg.Status)
+	}
+
+	if n, err := svc.DeliverScheduledMessages(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected nothing due yet, got %d (err %v)", n, err)
+	}
+	if len(p.produced) != 0 {
+		t.Fatalf("expected no event before the scheduled time")
+	}
+
+	now = now.Add(time.Hour)
+	if n, err := svc.DeliverScheduledMessages(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected one delivery, got %d (err %v)", n, err)
+	}
+
+	event := <-p.produced
+	var payload map[string]interface{}
+	if err := json.Unmarshal(event.value, &payload); err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	if payload["type"] != "message.sent" || payload["message_id"] != msg.ID.String() {
+		t.Fatalf("unexpected event: %v", payload)
+	}
+
+	stored := r.messages[0]
+	if stored.IsScheduled || stored.Status != "sent" || !stored.CreatedAt.Equal(now) {
+		t.Fatalf("expected message sent at %v, got is_scheduled=%v status=%q created_at=%v",
+			now, stored.IsScheduled, stored.Status, stored.CreatedAt)
+	}
+
+	now = now.Add(time.Minute)
+	if n, err := svc.DeliverScheduledMessages(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected no second delivery, got %d (err %v)", n, err)
+	}
+	if len(p.produced) != 0 {
+		t.Fatalf("expected message.sent to be published once")
+	}
+}
+
+func TestScheduledMessage_PublishFailureLeavesMessageScheduled(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	r := &fakeRepo{}
+	svc, p := newTestService(r, Config{})
+	svc.clock = func() time.Time { return now }
+
+	scheduleMessage(t, svc, now.Add(time.Minute))
+	now = now.Add(time.Minute)
+
+	p.err = pkgErrors.New(pkgErrors.CodeUnavailable, "broker down")
+	if _, err := svc.DeliverScheduledMessages(context.Background()); err == nil {
+		t.Fatalf("expected the sweep to fail while publishing fails")
+	}
+	if !r.messages[0].IsScheduled {
+		t.Fatalf("expected message to stay scheduled for the next sweep")
+	}
+
+	p.err = nil
+	if n, err := svc.DeliverScheduledMessages(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected the retry to deliver, got %d (err %v)", n, err)
+	}
+}
+
+func TestSendMessage_RejectsScheduleInThePast(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	svc, _ := newTestService(&fakeRepo{}, Config{})
+	svc.clock = func() time.Time { return now }
+
+	past := now.Add(-time.Minute)
+	_, err := svc.SendMessage(context.Background(), &models.SendMessageRequest{
+		ConversationID: uuid.New(),
+		SenderUserID:   uuid.New(),
+		Content:        "too late",
+		MessageType:    "text",
+		ScheduledAt:    &past,
+	})
+	if pkgErrors.GetCode(err) != pkgErrors.CodeInvalidArgument {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"echo-backend/services/message-service/internal/models"
+
+	"shared/pkg/database"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// SearchMessages runs a ranked full-text search over a conversation the user
+// participates in
+func (s *messageService) SearchMessages(ctx context.Context, conversationID, userID uuid.UUID, query string, limit, offset int) (*models.SearchResponse, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "search query is required").
+			WithService("message-service")
+	}
+
+	if _, err := s.repo.GetParticipant(ctx, conversationID, userID); err != nil {
+		if err.Code() == pkgErrors.CodeNotFound {
+			return nil, pkgErrors.New(pkgErrors.CodeForbidden, "user is not a participant of this conversation").
+				WithService("message-service").
+				WithDetail("conversation_id", conversationID.String()).
+				WithDetail("user_id", userID.String())
+		}
+		return nil, err.WithService("message-service")
+	}
+
+	limit = database.ClampLimit(limit)
+	if offset < 0 {
+		offset = 0
+	}
+
+	results, hasMore, err := s.repo.SearchMessages(ctx, conversationID, userID, query, s.cfg.SearchLanguage, limit, offset)
+	if err != nil {
+		return nil, err.WithService("message-service")
+	}
+
+	resp := &models.SearchResponse{Results: results, HasMore: hasMore}
+	if hasMore {
+		resp.NextOffset = offset + len(results)
+	}
+	return resp, nil
+}
+
+// indexMessage keeps the search index in step with a text message's content.
+// It runs before the message is broadcast so search never trails the chat,
+// and it is the only writer of search_index rows for new and edited messages.
+func (s *messageService) indexMessage(ctx context.Context, message *models.Message) {
+	if message.MessageType != "text" {
+		return
+	}
+	if err := s.repo.IndexMessage(ctx, message, s.cfg.SearchLanguage); err != nil {
+		s.logger.Warn("Failed to index message for search",
+			logger.String("message_id", message.ID.String()),
+			logger.Error(err),
+		)
+	}
+}
+
+func (s *messageService) removeFromIndex(messageID uuid.UUID) {
+	if err := s.repo.RemoveFromIndex(context.Background(), messageID); err != nil {
+		s.logger.Warn("Failed to remove message from search index",
+			logger.String("message_id", messageID.String()),
+			logger.Error(err),
+		)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"echo-backend/services/message-service/internal/models"
+
+	pkgErrors "shared/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchMessages(t *testing.T) {
+	conversationID, member := uuid.New(), uuid.New()
+	r := &fakeRepo{participant: &models.ConversationParticipant{UserID: member}}
+	svc, _ := newTestService(r, Config{SearchLanguage: "simple"})
+	ctx := context.Background()
+
+	if _, err := svc.SearchMessages(ctx, conversationID, member, "   ", 10, 0); pkgErrors.GetCode(err) != pkgErrors.CodeInvalidArgument {
+		t.Fatalf("blank query should be rejected, got %v", err)
+	}
+	if _, err := svc.SearchMessages(ctx, conversationID, uuid.New(), "hello", 10, 0); pkgErrors.GetCode(err) != pkgErrors.CodeForbidden {
+		t.Fatalf("non-participant should be rejected, got %v", err)
+	}
+
+	resp, err := svc.SearchMessages(ctx, conversationID, member, `"see you"`, 10, 20)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if !resp.HasMore || resp.NextOffset != 30 {
+		t.Fatalf("expected next offset 30, got %+v", resp)
+	}
+	if r.language != "simple" {
+		t.Fatalf("configured language not used, got %q", r.language)
+	}
+}
+
+func TestEditMessage_IndexesBeforeReturning(t *testing.T) {
+	sender := uuid.New()
+	msg := &models.Message{ID: uuid.New(), ConversationID: uuid.New(), SenderUserID: sender, MessageType: "text", Content: "helo", CreatedAt: time.Now()}
+	r := &fakeRepo{message: msg}
+	cfg := messageTestConfig
+	cfg.SearchLanguage = "simple"
+	svc, _ := newTestService(r, cfg)
+
+	if _, err := svc.EditMessage(context.Background(), msg.ID, sender, "hello"); err != nil {
+		t.Fatalf("edit failed: %v", err)
+	}
+	if got, ok := r.indexed[msg.ID]; !ok || got != "simple" {
+		t.Fatalf("expected the edit indexed with the configured language, got %q (indexed=%v)", got, ok)
+	}
+}
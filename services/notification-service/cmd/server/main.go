@@ -1 +1,300 @@
-package server
+package main
+
+import (
+	"context"
+	"fmt"
+	"notification-service/internal/config"
+	"notification-service/internal/repo"
+	"notification-service/internal/service"
+
+	// Quiet hours are evaluated in each user's timezone; embed the zone
+	// database so slim images without tzdata still resolve them
+	_ "time/tzdata"
+
+	"shared/pkg/cache"
+	"shared/pkg/cache/redis"
+	"shared/pkg/database"
+	"shared/pkg/database/postgres"
+	"shared/pkg/logger"
+	adapter "shared/pkg/logger/adapter"
+	"shared/pkg/messaging"
+	"shared/pkg/messaging/kafka"
+	env "shared/server/env"
+	"shared/server/shutdown"
+)
+
+func createLogger(name string) logger.Logger {
+	log, err := adapter.NewZap(logger.Config{
+		Level:   logger.GetLoggerLevel(),
+		Format:  logger.GetLoggerFormat(),
+		Output:  logger.GetLoggerOutput(),
+		File:    logger.GetLoggerFile(),
+		Service: name,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create logger: %v", err))
+	}
+	return log
+}
+
+func loadConfig() (*config.Config, error) {
+	configLogger := createLogger("config-loader")
+	defer configLogger.Sync()
+
+	appEnv := env.GetEnv("APP_ENV", "development")
+	configPath := env.GetEnv("CONFIG_PATH", "configs/config.yaml")
+	configLogger.Debug("Loading config from environment variables",
+		logger.String("configPath", configPath),
+		logger.String("environment", appEnv))
+
+	cfg, err := config.Load(configPath, appEnv)
+	if err != nil {
+		configLogger.Error("Failed to load config", logger.Error(err))
+		return nil, err
+	}
+
+	if err := config.ValidateAndSetDefaults(cfg); err != nil {
+		configLogger.Error("Invalid configuration", logger.Error(err))
+		return nil, err
+	}
+
+	configLogger.Debug("Config loaded successfully")
+	return cfg, nil
+}
+
+func createDBClient(cfg config.PostgresConfig, log logger.Logger) (database.Database, error) {
+	log.Debug("Creating database client")
+	dbClient, err := postgres.New(database.Config{
+		Host:            cfg.Host,
+		Port:            cfg.Port,
+		User:            cfg.User,
+		Password:        cfg.Password,
+		Database:        cfg.DBName,
+		SSLMode:         cfg.SSLMode,
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Database client created successfully")
+	return dbClient, nil
+}
+
+func createCacheClient(cfg config.CacheConfig, log logger.Logger) (cache.Cache, error) {
+	log.Debug("Creating cache client")
+	cacheClient, err := redis.New(cache.Config{
+		Host:         cfg.Host,
+		Port:         cfg.Port,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		MaxRetries:   cfg.MaxRetries,
+		PoolSize:     cfg.PoolSize,
+		MinIdleConns: cfg.MinIdleConns,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Cache client created successfully")
+	return cacheClient, nil
+}
+
+// enableBundling holds notifications in Redis for users who bundle them. Without
+// a Redis cache every notification is delivered as it arrives.
+func enableBundling(worker *service.Worker, cacheClient cache.Cache, cfg config.BundlingConfig, log logger.Logger) {
+	if cacheClient == nil {
+		log.Warn("Cache is disabled; notification bundling is off")
+		return
+	}
+	rdb, err := redis.ClientFromCache(cacheClient)
+	if err != nil {
+		log.Warn("Notification bundling is off", logger.Error(err))
+		return
+	}
+
+	worker.SetBundling(
+		repo.NewRedisBundleStore(rdb, log),
+		service.NewRedisFlushLocker(redis.NewLocker(rdb), cfg.LockTTL, log),
+		service.BundleConfig{
+			MaxCount:      cfg.MaxCount,
+			FlushInterval: cfg.FlushInterval,
+			BatchSize:     cfg.BatchSize,
+		},
+	)
+	worker.StartFlusher()
+}
+
+func createKafkaProducer(cfg config.KafkaConfig, log logger.Logger) (messaging.Producer, error) {
+	log.Debug("Creating Kafka producer",
+		logger.String("brokers", fmt.Sprintf("%v", cfg.Brokers)),
+	)
+	producer, err := kafka.NewProducer(messaging.Config{
+		Brokers:    cfg.Brokers,
+		ClientID:   cfg.ClientID,
+		MaxRetries: cfg.MaxRetries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Kafka producer created successfully")
+	return producer, nil
+}
+
+func createKafkaConsumer(cfg config.KafkaConfig, log logger.Logger) (messaging.Consumer, error) {
+	log.Debug("Creating Kafka consumer",
+		logger.String("brokers", fmt.Sprintf("%v", cfg.Brokers)),
+		logger.String("group_id", cfg.GroupID),
+	)
+	consumer, err := kafka.NewConsumer(messaging.ConsumerConfig{
+		Brokers:      cfg.Brokers,
+		ClientID:     cfg.ClientID,
+		GroupID:      cfg.GroupID,
+		MaxRetries:   cfg.MaxRetries,
+		RetryBackoff: int(cfg.RetryBackoff.Milliseconds()),
+		Logger:       log,
+
+		DeadLetterEnabled: cfg.DeadLetterEnabled,
+		DeadLetterSuffix:  cfg.DeadLetterSuffix,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Kafka consumer created successfully",
+		logger.String("group_id", cfg.GroupID),
+	)
+	return consumer, nil
+}
+
+func setupShutdownManager(consumer messaging.Consumer, producer messaging.Producer, worker *service.Worker, log logger.Logger, cfg *config.Config) *shutdown.Manager {
+	shutdownMgr := shutdown.New(
+		shutdown.WithTimeout(cfg.Shutdown.Timeout),
+		shutdown.WithLogger(log),
+	)
+
+	// Stop taking events before flushing the deliveries they queued
+	shutdownMgr.RegisterWithPriority(
+		"kafka-consumer",
+		shutdown.Hook(func(ctx context.Context) error {
+			log.Info("Draining Kafka consumer")
+			return consumer.Close()
+		}),
+		shutdown.PriorityHigh,
+	)
+
+	shutdownMgr.RegisterWithPriority(
+		"bundle-flusher",
+		shutdown.Hook(func(ctx context.Context) error {
+			log.Info("Stopping notification bundle flusher")
+			return worker.StopFlusher(ctx)
+		}),
+		shutdown.PriorityHigh,
+	)
+
+	shutdownMgr.RegisterWithPriority(
+		"kafka-producer",
+		shutdown.Hook(func(ctx context.Context) error {
+			log.Info("Flushing Kafka producer")
+			if err := producer.Flush(ctx); err != nil {
+				log.Warn("Failed to flush Kafka producer", logger.Error(err))
+			}
+			return producer.Close()
+		}),
+		shutdown.PriorityNormal,
+	)
+
+	shutdownMgr.RegisterWithPriority(
+		"logger-sync",
+		shutdown.Hook(func(ctx context.Context) error {
+			log.Info("Syncing logger before shutdown")
+			return log.Sync()
+		}),
+		shutdown.PriorityLow,
+	)
+
+	return shutdownMgr
+}
+
+func main() {
+	env.LoadEnv()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load configuration: %v", err))
+	}
+
+	log := createLogger(cfg.Service.Name)
+	defer log.Sync()
+
+	log.Info("Starting Notification Service",
+		logger.String("service", cfg.Service.Name),
+		logger.String("version", cfg.Service.Version),
+		logger.String("environment", cfg.Service.Environment),
+	)
+
+	dbClient, err := createDBClient(cfg.Database.Postgres, log)
+	if err != nil {
+		log.Fatal("Failed to create database client", logger.Error(err))
+	}
+	defer func() {
+		if dbClient != nil {
+			log.Info("Closing database connection")
+			if err := dbClient.Close(); err != nil {
+				log.Error("Failed to close database connection", logger.Error(err))
+			}
+		}
+	}()
+
+	var cacheClient cache.Cache
+	if cfg.Cache.Enabled {
+		cacheClient, err = createCacheClient(cfg.Cache, log)
+		if err != nil {
+			log.Fatal("Failed to create cache client", logger.Error(err))
+		}
+		defer func() {
+			if cacheClient != nil {
+				log.Info("Closing cache connection")
+				if err := cacheClient.Close(); err != nil {
+					log.Error("Failed to close cache connection", logger.Error(err))
+				}
+			}
+		}()
+	} else {
+		log.Info("Cache is disabled in configuration")
+	}
+
+	producer, err := createKafkaProducer(cfg.Kafka, log)
+	if err != nil {
+		log.Fatal("Failed to create Kafka producer", logger.Error(err))
+	}
+
+	consumer, err := createKafkaConsumer(cfg.Kafka, log)
+	if err != nil {
+		log.Fatal("Failed to create Kafka consumer", logger.Error(err))
+	}
+
+	worker := service.NewWorker(
+		repo.NewNotificationRepository(dbClient, log),
+		service.NewKafkaDispatcher(producer, cfg.Kafka.PushTopic, cfg.Kafka.EmailTopic),
+		log,
+	)
+	enableBundling(worker, cacheClient, cfg.Bundling, log)
+
+	if err := consumer.Subscribe(context.Background(), cfg.Kafka.EventTopics, worker.Handle); err != nil {
+		log.Fatal("Failed to subscribe to message events", logger.Error(err))
+	}
+
+	log.Info("Notification Service is running",
+		logger.Any("topics", cfg.Kafka.EventTopics),
+	)
+
+	shutdownMgr := setupShutdownManager(consumer, producer, worker, log, cfg)
+	if err := shutdownMgr.Wait(); err != nil {
+		log.Error("Shutdown completed with errors", logger.Error(err))
+	}
+	log.Info("Notification Service stopped gracefully")
+}
@@ -0,0 +1,92 @@
+package config
+
+import "time"
+
+type Config struct {
+	Service  ServiceConfig  `yaml:"service" mapstructure:"service"`
+	Database DatabaseConfig `yaml:"database" mapstructure:"database"`
+	Cache    CacheConfig    `yaml:"cache" mapstructure:"cache"`
+	Kafka    KafkaConfig    `yaml:"kafka" mapstructure:"kafka"`
+	Bundling BundlingConfig `yaml:"bundling" mapstructure:"bundling"`
+	Logging  LoggingConfig  `yaml:"logging" mapstructure:"logging"`
+	Shutdown ShutdownConfig `yaml:"shutdown" mapstructure:"shutdown"`
+}
+
+type ServiceConfig struct {
+	Name        string `yaml:"name" mapstructure:"name"`
+	Version     string `yaml:"version" mapstructure:"version"`
+	Environment string `yaml:"environment" mapstructure:"environment"`
+}
+
+type DatabaseConfig struct {
+	Postgres PostgresConfig `yaml:"postgres" mapstructure:"postgres"`
+}
+
+type PostgresConfig struct {
+	Host            string        `yaml:"host" mapstructure:"host"`
+	Port            int           `yaml:"port" mapstructure:"port"`
+	User            string        `yaml:"user" mapstructure:"user"`
+	Password        string        `yaml:"password" mapstructure:"password"`
+	DBName          string        `yaml:"db_name" mapstructure:"db_name"`
+	SSLMode         string        `yaml:"ssl_mode" mapstructure:"ssl_mode"`
+	MaxOpenConns    int           `yaml:"max_open_conns" mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" mapstructure:"conn_max_idle_time"`
+}
+
+type CacheConfig struct {
+	Enabled      bool          `yaml:"enabled" mapstructure:"enabled"`
+	Host         string        `yaml:"host" mapstructure:"host"`
+	Port         int           `yaml:"port" mapstructure:"port"`
+	Password     string        `yaml:"password" mapstructure:"password"`
+	DB           int           `yaml:"db" mapstructure:"db"`
+	MaxRetries   int           `yaml:"max_retries" mapstructure:"max_retries"`
+	PoolSize     int           `yaml:"pool_size" mapstructure:"pool_size"`
+	MinIdleConns int           `yaml:"min_idle_conns" mapstructure:"min_idle_conns"`
+	DialTimeout  time.Duration `yaml:"dial_timeout" mapstructure:"dial_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout" mapstructure:"write_timeout"`
+}
+
+type KafkaConfig struct {
+	Brokers  []string `yaml:"brokers" mapstructure:"brokers"`
+	ClientID string   `yaml:"client_id" mapstructure:"client_id"`
+	GroupID  string   `yaml:"group_id" mapstructure:"group_id"`
+	// EventTopics carry the message, mention and reaction events that
+	// generate notifications
+	EventTopics []string `yaml:"event_topics" mapstructure:"event_topics"`
+	// PushTopic and EmailTopic receive the deliveries the senders work off
+	PushTopic    string        `yaml:"push_topic" mapstructure:"push_topic"`
+	EmailTopic   string        `yaml:"email_topic" mapstructure:"email_topic"`
+	MaxRetries   int           `yaml:"max_retries" mapstructure:"max_retries"`
+	RetryBackoff time.Duration `yaml:"retry_backoff" mapstructure:"retry_backoff"`
+	// DeadLetterEnabled moves messages that still fail after MaxRetries to
+	// <topic><DeadLetterSuffix> instead of redelivering them forever
+	DeadLetterEnabled bool   `yaml:"dead_letter_enabled" mapstructure:"dead_letter_enabled"`
+	DeadLetterSuffix  string `yaml:"dead_letter_suffix" mapstructure:"dead_letter_suffix"`
+}
+
+// BundlingConfig controls how notifications are held back for users who
+// bundle them. Bundles live in Redis, so bundling is off when the cache is.
+type BundlingConfig struct {
+	// MaxCount flushes a bundle once it holds this many notifications
+	MaxCount int `yaml:"max_count" mapstructure:"max_count"`
+	// FlushInterval is how often due bundles are looked for
+	FlushInterval time.Duration `yaml:"flush_interval" mapstructure:"flush_interval"`
+	// BatchSize caps how many bundles one sweep flushes
+	BatchSize int `yaml:"batch_size" mapstructure:"batch_size"`
+	// LockTTL bounds how long a crashed replica can hold a user's flush lock
+	LockTTL time.Duration `yaml:"lock_ttl" mapstructure:"lock_ttl"`
+}
+
+type LoggingConfig struct {
+	Level      string `yaml:"level" mapstructure:"level"`
+	Format     string `yaml:"format" mapstructure:"format"`
+	Output     string `yaml:"output" mapstructure:"output"`
+	TimeFormat string `yaml:"time_format" mapstructure:"time_format"`
+}
+
+type ShutdownConfig struct {
+	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout"`
+}
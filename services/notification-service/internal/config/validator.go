@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+func ValidateAndSetDefaults(cfg *Config) error {
+	if cfg.Service.Name == "" {
+		cfg.Service.Name = "notification-service"
+	}
+
+	if cfg.Database.Postgres.Host == "" {
+		return errors.New("database host is required")
+	}
+
+	if cfg.Database.Postgres.Port == 0 {
+		cfg.Database.Postgres.Port = 5432
+	}
+
+	if cfg.Database.Postgres.User == "" {
+		return errors.New("database user is required")
+	}
+
+	if cfg.Database.Postgres.DBName == "" {
+		return errors.New("database name is required")
+	}
+
+	if err := validateCache(&cfg.Cache); err != nil {
+		return err
+	}
+
+	if len(cfg.Kafka.Brokers) == 0 {
+		return errors.New("kafka brokers are required")
+	}
+	if cfg.Kafka.ClientID == "" {
+		cfg.Kafka.ClientID = cfg.Service.Name
+	}
+	if cfg.Kafka.GroupID == "" {
+		cfg.Kafka.GroupID = "notification-service-group"
+	}
+	if len(cfg.Kafka.EventTopics) == 0 {
+		cfg.Kafka.EventTopics = []string{"notifications", "messages"}
+	}
+	if cfg.Kafka.PushTopic == "" {
+		cfg.Kafka.PushTopic = "notifications.push"
+	}
+	if cfg.Kafka.EmailTopic == "" {
+		cfg.Kafka.EmailTopic = "notifications.email"
+	}
+	if cfg.Kafka.MaxRetries == 0 {
+		cfg.Kafka.MaxRetries = 3
+	}
+	if cfg.Kafka.RetryBackoff == 0 {
+		cfg.Kafka.RetryBackoff = 200 * time.Millisecond
+	}
+	if cfg.Kafka.DeadLetterSuffix == "" {
+		cfg.Kafka.DeadLetterSuffix = ".dlq"
+	}
+
+	if cfg.Bundling.MaxCount == 0 {
+		cfg.Bundling.MaxCount = 20
+	}
+	if cfg.Bundling.FlushInterval == 0 {
+		cfg.Bundling.FlushInterval = 15 * time.Second
+	}
+	if cfg.Bundling.BatchSize == 0 {
+		cfg.Bundling.BatchSize = 100
+	}
+	if cfg.Bundling.LockTTL == 0 {
+		cfg.Bundling.LockTTL = 30 * time.Second
+	}
+
+	if cfg.Logging.Level == "" {
+		cfg.Logging.Level = "info"
+	}
+
+	if cfg.Logging.Format == "" {
+		cfg.Logging.Format = "json"
+	}
+
+	if cfg.Shutdown.Timeout == 0 {
+		cfg.Shutdown.Timeout = 30 * time.Second
+	}
+
+	return nil
+}
+
+func validateCache(cache *CacheConfig) error {
+	if !cache.Enabled {
+		return nil
+	}
+
+	if cache.Host == "" {
+		return fmt.Errorf("cache host is required when cache is enabled")
+	}
+
+	if cache.Port <= 0 || cache.Port > 65535 {
+		return fmt.Errorf("invalid cache port: %d", cache.Port)
+	}
+
+	if cache.PoolSize == 0 {
+		cache.PoolSize = 10
+	}
+
+	if cache.MinIdleConns == 0 {
+		cache.MinIdleConns = 5
+	}
+
+	if cache.MaxRetries == 0 {
+		cache.MaxRetries = 3
+	}
+
+	if cache.DialTimeout == 0 {
+		cache.DialTimeout = 5 * time.Second
+	}
+
+	if cache.ReadTimeout == 0 {
+		cache.ReadTimeout = 3 * time.Second
+	}
+
+	if cache.WriteTimeout == 0 {
+		cache.WriteTimeout = 3 * time.Second
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// BundledNotification is a notification held back in a user's bundle until
+// the bundle is flushed as a single summary
+type BundledNotification struct {
+	UserID         string    `json:"user_id"`
+	GroupKey       string    `json:"group_key"`
+	Kind           string    `json:"kind"`
+	Title          string    `json:"title"`
+	Body           string    `json:"body"`
+	SenderID       string    `json:"sender_id,omitempty"`
+	MessageID      string    `json:"message_id,omitempty"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// BundleKey identifies one of a user's pending bundles
+type BundleKey struct {
+	UserID   string
+	GroupKey string
+}
@@ -0,0 +1,88 @@
+package model
+
+import "time"
+
+// Event types the worker turns into notifications
+const (
+	// EventNewMessage is published by message-service on the notifications
+	// topic for each offline recipient of a message
+	EventNewMessage = "new_message"
+	// EventMessageSent is published on the message events topic once per
+	// message, without a recipient; every other member is notified
+	EventMessageSent = "message.sent"
+	// EventMention is published for each user mentioned in a message
+	EventMention = "message.mention"
+	// EventReaction is published to the author of a message someone reacted to
+	EventReaction = "message.reaction"
+)
+
+// Notification types, as stored in notifications.notifications
+const (
+	KindMessage  = "message"
+	KindMention  = "mention"
+	KindReaction = "reaction"
+)
+
+// MessageEvent is the union of the message events the worker consumes.
+// UserID is the recipient when the publisher already knows it.
+type MessageEvent struct {
+	Type           string    `json:"type"`
+	UserID         string    `json:"user_id,omitempty"`
+	SenderID       string    `json:"sender_id"`
+	ConversationID string    `json:"conversation_id"`
+	MessageID      string    `json:"message_id"`
+	Content        string    `json:"content,omitempty"`
+	MessageType    string    `json:"message_type,omitempty"`
+	Reaction       string    `json:"reaction,omitempty"`
+	Timestamp      time.Time `json:"timestamp,omitempty"`
+}
+
+// Kind returns the notification type for an event, or "" for events that do
+// not notify anyone
+func (e *MessageEvent) Kind() string {
+	switch e.Type {
+	case EventNewMessage, EventMessageSent:
+		return KindMessage
+	case EventMention:
+		return KindMention
+	case EventReaction:
+		return KindReaction
+	}
+	return ""
+}
+
+// PushTarget is a device a user receives push notifications on
+type PushTarget struct {
+	DeviceID string
+	Token    string
+	Provider string
+}
+
+// Push providers, as stored in notifications.push_delivery_log
+const (
+	PushProviderFCM  = "fcm"
+	PushProviderAPNS = "apns"
+)
+
+// PushJob asks the push sender to deliver a notification to one device
+type PushJob struct {
+	DeliveryLogID  string `json:"delivery_log_id"`
+	NotificationID string `json:"notification_id"`
+	UserID         string `json:"user_id"`
+	DeviceID       string `json:"device_id"`
+	PushToken      string `json:"push_token"`
+	PushProvider   string `json:"push_provider"`
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	Sound          string `json:"sound,omitempty"`
+}
+
+// EmailJob asks the email sender to deliver a queued email
+type EmailJob struct {
+	EmailID        string `json:"email_id"`
+	NotificationID string `json:"notification_id"`
+	UserID         string `json:"user_id"`
+	To             string `json:"to"`
+	Subject        string `json:"subject"`
+	BodyText       string `json:"body_text"`
+}
@@ -0,0 +1,150 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"notification-service/internal/model"
+
+	"shared/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// bundleKeyPrefix namespaces the per-user, per-group bundle lists
+	bundleKeyPrefix = "notification-service:bundle:"
+	// bundleDueKey is a sorted set of pending bundles scored by when they are
+	// due, in unix milliseconds
+	bundleDueKey = "notification-service:bundles:due"
+	// bundleRetention keeps a bundle around this long past its due time so a
+	// flusher outage does not lose it, while still bounding orphaned keys
+	bundleRetention = 24 * time.Hour
+)
+
+// BundleStore holds the notifications waiting in users' bundles
+type BundleStore interface {
+	// Add appends a notification to its bundle and returns how many the
+	// bundle now holds. dueAt only applies when it starts a new bundle.
+	Add(ctx context.Context, item *model.BundledNotification, dueAt time.Time) (int, error)
+
+	// Take removes a bundle and returns its notifications, oldest first
+	Take(ctx context.Context, userID, groupKey string) ([]*model.BundledNotification, error)
+
+	// Due returns up to limit bundles whose interval has elapsed at now
+	Due(ctx context.Context, now time.Time, limit int) ([]model.BundleKey, error)
+}
+
+type redisBundleStore struct {
+	rdb *redis.Client
+	log logger.Logger
+}
+
+// NewRedisBundleStore creates a bundle store on Redis. Each bundle is a list
+// of notifications, indexed in a sorted set by when it is due; both are
+// written in one transaction so a bundle taken by a flusher is never
+// appended to afterwards.
+func NewRedisBundleStore(rdb *redis.Client, log logger.Logger) BundleStore {
+	return &redisBundleStore{
+		rdb: rdb,
+		log: log,
+	}
+}
+
+func bundleKey(userID, groupKey string) string {
+	return bundleKeyPrefix + userID + ":" + groupKey
+}
+
+// bundleMember names a bundle in the due set. User IDs are UUIDs, so the
+// first "|" always separates them from the group key.
+func bundleMember(userID, groupKey string) string {
+	return userID + "|" + groupKey
+}
+
+// Add appends a notification to its bundle and returns how many the bundle
+// now holds
+func (s *redisBundleStore) Add(ctx context.Context, item *model.BundledNotification, dueAt time.Time) (int, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return 0, err
+	}
+
+	key := bundleKey(item.UserID, item.GroupKey)
+	pipe := s.rdb.TxPipeline()
+	length := pipe.RPush(ctx, key, data)
+	pipe.ZAddNX(ctx, bundleDueKey, redis.Z{
+		Score:  float64(dueAt.UnixMilli()),
+		Member: bundleMember(item.UserID, item.GroupKey),
+	})
+	pipe.Expire(ctx, key, dueAt.Sub(item.CreatedAt)+bundleRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.log.Error("Failed to add notification to bundle",
+			logger.String("user_id", item.UserID),
+			logger.String("group_key", item.GroupKey),
+			logger.Error(err),
+		)
+		return 0, err
+	}
+
+	return int(length.Val()), nil
+}
+
+// Take removes a bundle and returns its notifications, oldest first
+func (s *redisBundleStore) Take(ctx context.Context, userID, groupKey string) ([]*model.BundledNotification, error) {
+	key := bundleKey(userID, groupKey)
+	pipe := s.rdb.TxPipeline()
+	entries := pipe.LRange(ctx, key, 0, -1)
+	pipe.Del(ctx, key)
+	pipe.ZRem(ctx, bundleDueKey, bundleMember(userID, groupKey))
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.log.Error("Failed to take bundle",
+			logger.String("user_id", userID),
+			logger.String("group_key", groupKey),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	items := make([]*model.BundledNotification, 0, len(entries.Val()))
+	for _, entry := range entries.Val() {
+		var item model.BundledNotification
+		if err := json.Unmarshal([]byte(entry), &item); err != nil {
+			s.log.Warn("Dropping malformed bundled notification",
+				logger.String("user_id", userID),
+				logger.String("group_key", groupKey),
+				logger.Error(err),
+			)
+			continue
+		}
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
+
+// Due returns up to limit bundles whose interval has elapsed at now
+func (s *redisBundleStore) Due(ctx context.Context, now time.Time, limit int) ([]model.BundleKey, error) {
+	members, err := s.rdb.ZRangeByScore(ctx, bundleDueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		s.log.Error("Failed to list due bundles", logger.Error(err))
+		return nil, err
+	}
+
+	keys := make([]model.BundleKey, 0, len(members))
+	for _, member := range members {
+		userID, groupKey, ok := strings.Cut(member, "|")
+		if !ok {
+			continue
+		}
+		keys = append(keys, model.BundleKey{UserID: userID, GroupKey: groupKey})
+	}
+
+	return keys, nil
+}
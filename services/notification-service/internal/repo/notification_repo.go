@@ -0,0 +1,416 @@
+package repo
+
+import (
+	"context"
+
+	"notification-service/internal/model"
+
+	"shared/pkg/database"
+	"shared/pkg/database/postgres"
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// NotificationRepository reads what the worker needs to decide who to notify
+// and records the notifications and delivery attempts it makes
+type NotificationRepository interface {
+	// GetPreference returns a user's notification settings, or nil when they
+	// never saved any
+	GetPreference(ctx context.Context, userID string) (*models.UserPreference, error)
+
+	// GetConversationRecipients returns the members of a conversation other
+	// than excludeUserID
+	GetConversationRecipients(ctx context.Context, conversationID, excludeUserID string) ([]string, error)
+
+	// CreateNotification inserts a notification, filling in its ID
+	CreateNotification(ctx context.Context, n *models.Notification) error
+
+	// GetPushTargets returns the active devices a user receives pushes on
+	GetPushTargets(ctx context.Context, userID string) ([]model.PushTarget, error)
+
+	// CreatePushDeliveryLog records a push delivery attempt, filling in its ID
+	CreatePushDeliveryLog(ctx context.Context, entry *models.PushDeliveryLog) error
+
+	// UpdatePushDeliveryStatus records the outcome of a push delivery attempt
+	UpdatePushDeliveryStatus(ctx context.Context, id string, status models.PushDeliveryStatus, errorCode, errorMessage string) error
+
+	// GetUserEmail returns a user's email address, or "" when they have none
+	GetUserEmail(ctx context.Context, userID string) (string, error)
+
+	// CreateEmailNotification records a queued email, filling in its ID
+	CreateEmailNotification(ctx context.Context, email *models.EmailNotification) error
+
+	// UpdateEmailStatus records the outcome of queueing an email
+	UpdateEmailStatus(ctx context.Context, id string, status models.EmailStatus) error
+
+	// ClaimNotification records that userID is being notified about the
+	// event identified by dedupKey. It returns false when the event was
+	// already claimed, so the caller must not notify again.
+	ClaimNotification(ctx context.Context, userID, dedupKey string) (bool, error)
+
+	// ReleaseNotification drops a claim whose notification could not be
+	// made, so a retry of the event notifies the user
+	ReleaseNotification(ctx context.Context, userID, dedupKey string) error
+}
+
+type notificationRepository struct {
+	db  database.Database
+	log logger.Logger
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db database.Database, log logger.Logger) NotificationRepository {
+	return &notificationRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// GetPreference returns a user's notification settings, or nil when they
+// never saved any
+func (r *notificationRepository) GetPreference(ctx context.Context, userID string) (*models.UserPreference, error) {
+	query := `
+		SELECT id, user_id, push_enabled, email_enabled, sms_enabled, in_app_enabled,
+		       message_push, message_email, mention_push, mention_email,
+		       reaction_push, reaction_email,
+		       quiet_hours_enabled, quiet_hours_start, quiet_hours_end,
+		       quiet_hours_timezone, quiet_hours_days,
+		       bundle_notifications, bundle_interval_minutes, notification_sound
+		FROM notifications.user_preferences
+		WHERE user_id = $1
+	`
+
+	var pref models.UserPreference
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&pref.ID,
+		&pref.UserID,
+		&pref.PushEnabled,
+		&pref.EmailEnabled,
+		&pref.SMSEnabled,
+		&pref.InAppEnabled,
+		&pref.MessagePush,
+		&pref.MessageEmail,
+		&pref.MentionPush,
+		&pref.MentionEmail,
+		&pref.ReactionPush,
+		&pref.ReactionEmail,
+		&pref.QuietHoursEnabled,
+		&pref.QuietHoursStart,
+		&pref.QuietHoursEnd,
+		&pref.QuietHoursTimezone,
+		&pref.QuietHoursDays,
+		&pref.BundleNotifications,
+		&pref.BundleIntervalMinutes,
+		&pref.NotificationSound,
+	)
+	if err != nil {
+		if postgres.IsNoRowsError(err) {
+			return nil, nil
+		}
+		r.log.Error("Failed to get notification preferences",
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	return &pref, nil
+}
+
+// GetConversationRecipients returns the members of a conversation other than
+// excludeUserID
+func (r *notificationRepository) GetConversationRecipients(ctx context.Context, conversationID, excludeUserID string) ([]string, error) {
+	query := `
+		SELECT user_id
+		FROM messages.conversation_participants
+		WHERE conversation_id = $1
+		  AND user_id <> $2
+		  AND left_at IS NULL
+		  AND removed_at IS NULL
+	`
+
+	rows, err := r.db.Query(ctx, query, conversationID, excludeUserID)
+	if err != nil {
+		r.log.Error("Failed to get conversation recipients",
+			logger.String("conversation_id", conversationID),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// CreateNotification inserts a notification, filling in its ID
+func (r *notificationRepository) CreateNotification(ctx context.Context, n *models.Notification) error {
+	if n.ID == "" {
+		n.ID = uuid.NewString()
+	}
+
+	query := `
+		INSERT INTO notifications.notifications (
+			id, user_id, notification_type, title, body, sound,
+			related_user_id, related_message_id, related_conversation_id,
+			delivery_status, priority, scheduled_for, group_key, group_count,
+			is_group_summary, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $16)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		n.ID,
+		n.UserID,
+		n.NotificationType,
+		n.Title,
+		n.Body,
+		n.Sound,
+		n.RelatedUserID,
+		n.RelatedMessageID,
+		n.RelatedConversationID,
+		n.DeliveryStatus,
+		n.Priority,
+		n.ScheduledFor,
+		n.GroupKey,
+		n.GroupCount,
+		n.IsGroupSummary,
+		n.CreatedAt,
+	)
+	if err != nil {
+		r.log.Error("Failed to create notification",
+			logger.String("user_id", n.UserID),
+			logger.String("type", n.NotificationType),
+			logger.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// GetPushTargets returns the active devices a user receives pushes on
+func (r *notificationRepository) GetPushTargets(ctx context.Context, userID string) ([]model.PushTarget, error) {
+	query := `
+		SELECT device_id, COALESCE(fcm_token, ''), COALESCE(apns_token, '')
+		FROM users.devices
+		WHERE user_id = $1
+		  AND is_active = TRUE
+		  AND push_enabled = TRUE
+		  AND (fcm_token IS NOT NULL OR apns_token IS NOT NULL)
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		r.log.Error("Failed to get push targets",
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []model.PushTarget
+	for rows.Next() {
+		var deviceID, fcmToken, apnsToken string
+		if err := rows.Scan(&deviceID, &fcmToken, &apnsToken); err != nil {
+			return nil, err
+		}
+		// APNs is preferred on devices that registered both
+		if apnsToken != "" {
+			targets = append(targets, model.PushTarget{DeviceID: deviceID, Token: apnsToken, Provider: model.PushProviderAPNS})
+		} else if fcmToken != "" {
+			targets = append(targets, model.PushTarget{DeviceID: deviceID, Token: fcmToken, Provider: model.PushProviderFCM})
+		}
+	}
+
+	return targets, rows.Err()
+}
+
+// CreatePushDeliveryLog records a push delivery attempt, filling in its ID
+func (r *notificationRepository) CreatePushDeliveryLog(ctx context.Context, entry *models.PushDeliveryLog) error {
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	}
+
+	query := `
+		INSERT INTO notifications.push_delivery_log (
+			id, notification_id, user_id, device_id, push_token, push_provider,
+			status, error_code, error_message, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		entry.ID,
+		entry.NotificationID,
+		entry.UserID,
+		entry.DeviceID,
+		entry.PushToken,
+		entry.PushProvider,
+		entry.Status,
+		entry.ErrorCode,
+		entry.ErrorMessage,
+		entry.CreatedAt,
+	)
+	if err != nil {
+		r.log.Error("Failed to record push delivery",
+			logger.String("notification_id", entry.NotificationID),
+			logger.String("user_id", entry.UserID),
+			logger.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePushDeliveryStatus records the outcome of a push delivery attempt
+func (r *notificationRepository) UpdatePushDeliveryStatus(ctx context.Context, id string, status models.PushDeliveryStatus, errorCode, errorMessage string) error {
+	query := `
+		UPDATE notifications.push_delivery_log
+		SET status = $2, error_code = NULLIF($3, ''), error_message = NULLIF($4, '')
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(ctx, query, id, status, errorCode, errorMessage); err != nil {
+		r.log.Error("Failed to update push delivery",
+			logger.String("delivery_log_id", id),
+			logger.String("status", string(status)),
+			logger.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// GetUserEmail returns a user's email address, or "" when they have none
+func (r *notificationRepository) GetUserEmail(ctx context.Context, userID string) (string, error) {
+	query := `
+		SELECT email
+		FROM auth.users
+		WHERE id = $1
+	`
+
+	var email string
+	if err := r.db.QueryRow(ctx, query, userID).Scan(&email); err != nil {
+		if postgres.IsNoRowsError(err) {
+			return "", nil
+		}
+		r.log.Error("Failed to get user email",
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+		return "", err
+	}
+
+	return email, nil
+}
+
+// CreateEmailNotification records a queued email, filling in its ID
+func (r *notificationRepository) CreateEmailNotification(ctx context.Context, email *models.EmailNotification) error {
+	if email.ID == "" {
+		email.ID = uuid.NewString()
+	}
+
+	query := `
+		INSERT INTO notifications.email_notifications (
+			id, user_id, notification_id, email_to, subject, body_text,
+			status, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		email.ID,
+		email.UserID,
+		email.NotificationID,
+		email.EmailTo,
+		email.Subject,
+		email.BodyText,
+		email.Status,
+		email.CreatedAt,
+	)
+	if err != nil {
+		r.log.Error("Failed to record email notification",
+			logger.String("user_id", email.UserID),
+			logger.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateEmailStatus records the outcome of queueing an email
+func (r *notificationRepository) UpdateEmailStatus(ctx context.Context, id string, status models.EmailStatus) error {
+	query := `
+		UPDATE notifications.email_notifications
+		SET status = $2
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(ctx, query, id, status); err != nil {
+		r.log.Error("Failed to update email notification",
+			logger.String("email_id", id),
+			logger.String("status", string(status)),
+			logger.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimNotification inserts the user's dedup row, reporting whether this call
+// was the one that created it
+func (r *notificationRepository) ClaimNotification(ctx context.Context, userID, dedupKey string) (bool, error) {
+	query := `
+		INSERT INTO notifications.notification_dedup (user_id, dedup_key)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, dedup_key) DO NOTHING
+	`
+
+	result, err := r.db.Exec(ctx, query, userID, dedupKey)
+	if err != nil {
+		r.log.Error("Failed to claim notification",
+			logger.String("user_id", userID),
+			logger.String("dedup_key", dedupKey),
+			logger.Error(err),
+		)
+		return false, err
+	}
+
+	claimed, rowsErr := result.RowsAffected()
+	if rowsErr != nil {
+		return false, rowsErr
+	}
+	return claimed == 1, nil
+}
+
+// ReleaseNotification deletes the user's dedup row
+func (r *notificationRepository) ReleaseNotification(ctx context.Context, userID, dedupKey string) error {
+	query := `
+		DELETE FROM notifications.notification_dedup
+		WHERE user_id = $1 AND dedup_key = $2
+	`
+
+	if _, err := r.db.Exec(ctx, query, userID, dedupKey); err != nil {
+		r.log.Error("Failed to release notification",
+			logger.String("user_id", userID),
+			logger.String("dedup_key", dedupKey),
+			logger.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"notification-service/internal/model"
+	"notification-service/internal/repo"
+
+	"shared/pkg/cache/redis"
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+)
+
+// flushLockPrefix namespaces the per-user bundle flush locks
+const flushLockPrefix = "notification-service:bundle-flush:"
+
+// BundleConfig tunes how bundled notifications are flushed
+type BundleConfig struct {
+	// MaxCount flushes a bundle as soon as it holds this many notifications,
+	// without waiting out the interval
+	MaxCount int
+	// FlushInterval is how often due bundles are looked for
+	FlushInterval time.Duration
+	// BatchSize caps how many due bundles one sweep flushes
+	BatchSize int
+}
+
+// FlushLocker keeps each user's bundles to one flusher at a time across
+// replicas, so a user never gets two summaries for the same bundle
+type FlushLocker interface {
+	// TryLock takes the user's flush lock, reporting false when another
+	// flusher holds it
+	TryLock(ctx context.Context, userID string) (unlock func(), ok bool, err error)
+}
+
+type redisFlushLocker struct {
+	locker *redis.Locker
+	ttl    time.Duration
+	log    logger.Logger
+}
+
+// NewRedisFlushLocker creates a flush locker on Redis. ttl bounds how long a
+// crashed flusher can hold a user's lock.
+func NewRedisFlushLocker(locker *redis.Locker, ttl time.Duration, log logger.Logger) FlushLocker {
+	return &redisFlushLocker{
+		locker: locker,
+		ttl:    ttl,
+		log:    log,
+	}
+}
+
+func (l *redisFlushLocker) TryLock(ctx context.Context, userID string) (func(), bool, error) {
+	lock, err := l.locker.TryAcquire(ctx, flushLockPrefix+userID, l.ttl)
+	if errors.Is(err, redis.ErrLockNotAcquired) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	unlock := func() {
+		if err := lock.Release(context.Background()); err != nil && !errors.Is(err, redis.ErrLockNotHeld) {
+			l.log.Warn("Failed to release bundle flush lock",
+				logger.String("user_id", userID),
+				logger.Error(err),
+			)
+		}
+	}
+	return unlock, true, nil
+}
+
+// bundler holds what the worker needs to bundle notifications; it is only
+// set when Redis is available
+type bundler struct {
+	store  repo.BundleStore
+	locker FlushLocker
+	cfg    BundleConfig
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// SetBundling enables bundling for users who ask for it. Without it every
+// notification is delivered as soon as it arrives.
+func (w *Worker) SetBundling(store repo.BundleStore, locker FlushLocker, cfg BundleConfig) {
+	w.bundler = &bundler{
+		store:  store,
+		locker: locker,
+		cfg:    cfg,
+	}
+}
+
+// shouldBundle reports whether a notification waits in a bundle rather than
+// going out immediately. High-priority notifications, such as mentions and
+// security alerts, always bypass bundling.
+func (w *Worker) shouldBundle(pref *models.UserPreference, n *models.Notification) bool {
+	if w.bundler == nil || !pref.BundleNotifications || pref.BundleIntervalMinutes <= 0 {
+		return false
+	}
+	if n.RelatedConversationID == nil {
+		return false
+	}
+	switch n.Priority {
+	case models.NotificationPriorityHigh, models.NotificationPriorityUrgent:
+		return false
+	}
+	return true
+}
+
+// bundle adds a notification to the user's bundle for its conversation. A
+// new bundle is due one interval after its first notification; a bundle that
+// reaches the max count is flushed straight away.
+func (w *Worker) bundle(ctx context.Context, pref *models.UserPreference, n *models.Notification) error {
+	item := &model.BundledNotification{
+		UserID:         n.UserID,
+		GroupKey:       n.NotificationType + ":" + *n.RelatedConversationID,
+		Kind:           n.NotificationType,
+		Title:          n.Title,
+		Body:           n.Body,
+		ConversationID: *n.RelatedConversationID,
+		CreatedAt:      n.CreatedAt,
+	}
+	if n.RelatedUserID != nil {
+		item.SenderID = *n.RelatedUserID
+	}
+	if n.RelatedMessageID != nil {
+		item.MessageID = *n.RelatedMessageID
+	}
+
+	dueAt := n.CreatedAt.Add(time.Duration(pref.BundleIntervalMinutes) * time.Minute)
+	count, err := w.bundler.store.Add(ctx, item, dueAt)
+	if err != nil {
+		return err
+	}
+
+	if w.bundler.cfg.MaxCount > 0 && count >= w.bundler.cfg.MaxCount {
+		_, err := w.flushBundle(ctx, model.BundleKey{UserID: item.UserID, GroupKey: item.GroupKey})
+		return err
+	}
+	return nil
+}
+
+// FlushDue delivers every bundle whose interval has elapsed and returns how
+// many it flushed. Bundles whose user is being flushed elsewhere are left
+// for that flusher.
+func (w *Worker) FlushDue(ctx context.Context) (int, error) {
+	if w.bundler == nil {
+		return 0, nil
+	}
+
+	keys, err := w.bundler.store.Due(ctx, w.now(), w.bundler.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	flushed := 0
+	for _, key := range keys {
+		ok, err := w.flushBundle(ctx, key)
+		if err != nil {
+			w.log.Error("Failed to flush notification bundle",
+				logger.String("user_id", key.UserID),
+				logger.String("group_key", key.GroupKey),
+				logger.Error(err),
+			)
+			continue
+		}
+		if ok {
+			flushed++
+		}
+	}
+	return flushed, nil
+}
+
+// flushBundle delivers one bundle as a single notification under the user's
+// flush lock, reporting whether it delivered anything
+func (w *Worker) flushBundle(ctx context.Context, key model.BundleKey) (bool, error) {
+	unlock, ok, err := w.bundler.locker.TryLock(ctx, key.UserID)
+	if err != nil || !ok {
+		return false, err
+	}
+	defer unlock()
+
+	items, err := w.bundler.store.Take(ctx, key.UserID, key.GroupKey)
+	if err != nil || len(items) == 0 {
+		return false, err
+	}
+
+	// Preferences are read again so a bundle flushed during quiet hours, or
+	// after the user muted the channel, respects the current settings
+	pref, err := w.repo.GetPreference(ctx, key.UserID)
+	if err != nil {
+		w.requeue(ctx, items)
+		return false, err
+	}
+	if pref == nil {
+		pref = DefaultPreference(key.UserID)
+	}
+
+	now := w.now()
+	n := summarize(items, pref.NotificationSound, now)
+	channels := ChannelsFor(pref, n.NotificationType, now)
+	if !channels.Any() {
+		return false, nil
+	}
+
+	if err := w.deliver(ctx, n, channels); err != nil {
+		w.requeue(ctx, items)
+		return false, err
+	}
+	return true, nil
+}
+
+// requeue puts taken notifications back in their bundle, due immediately, so
+// a failed flush is retried on the next sweep
+func (w *Worker) requeue(ctx context.Context, items []*model.BundledNotification) {
+	now := w.now()
+	for _, item := range items {
+		if _, err := w.bundler.store.Add(ctx, item, now); err != nil {
+			w.log.Error("Lost bundled notification",
+				logger.String("user_id", item.UserID),
+				logger.String("group_key", item.GroupKey),
+				logger.Error(err),
+			)
+		}
+	}
+}
+
+// summarize turns a bundle into the notification that is delivered for it.
+// A bundle of one is delivered as it was; larger ones become a group summary
+// showing the latest notification's body.
+func summarize(items []*model.BundledNotification, sound string, now time.Time) *models.Notification {
+	latest := items[len(items)-1]
+	groupKey := latest.GroupKey
+
+	n := &models.Notification{
+		UserID:           latest.UserID,
+		NotificationType: latest.Kind,
+		Title:            latest.Title,
+		Body:             latest.Body,
+		Sound:            sound,
+		DeliveryStatus:   models.NotificationDeliveryStatusPending,
+		Priority:         models.NotificationPriorityNormal,
+		GroupKey:         &groupKey,
+		GroupCount:       len(items),
+		CreatedAt:        now,
+	}
+	if len(items) > 1 {
+		n.Title = fmt.Sprintf("%d new %ss", len(items), latest.Kind)
+		n.IsGroupSummary = true
+	}
+	if latest.SenderID != "" {
+		n.RelatedUserID = &latest.SenderID
+	}
+	if latest.MessageID != "" {
+		n.RelatedMessageID = &latest.MessageID
+	}
+	if latest.ConversationID != "" {
+		n.RelatedConversationID = &latest.ConversationID
+	}
+	return n
+}
+
+// StartFlusher flushes due bundles every flush interval until StopFlusher is
+// called
+func (w *Worker) StartFlusher() {
+	b := w.bundler
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stop != nil {
+		return
+	}
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+
+	go w.runFlusher(b.stop, b.done)
+
+	w.log.Info("Notification bundle flusher started",
+		logger.Duration("interval", b.cfg.FlushInterval),
+		logger.Int("max_count", b.cfg.MaxCount),
+	)
+}
+
+// StopFlusher ends the flush loop and waits for an in-flight sweep to finish
+// or ctx to expire
+func (w *Worker) StopFlusher(ctx context.Context) error {
+	b := w.bundler
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	stop, done := b.stop, b.done
+	b.stop, b.done = nil, nil
+	b.mu.Unlock()
+
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) runFlusher(stop, done chan struct{}) {
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(w.bundler.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := w.FlushDue(ctx); err != nil && ctx.Err() == nil {
+				w.log.Error("Notification bundle sweep failed", logger.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"notification-service/internal/model"
+
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+	"shared/pkg/messaging"
+)
+
+const (
+	bundleUser   = "6f1c2a3e-0000-4000-8000-000000000001"
+	bundleSender = "6f1c2a3e-0000-4000-8000-000000000002"
+	bundleConv   = "6f1c2a3e-0000-4000-8000-0000000000c1"
+)
+
+type memNotificationRepo struct {
+	prefs         map[string]*models.UserPreference
+	recipients    []string
+	notifications []*models.Notification
+	pushes        int
+	claims        map[string]bool
+	// failFor makes CreateNotification fail for that user
+	failFor string
+}
+
+func (r *memNotificationRepo) GetPreference(ctx context.Context, userID string) (*models.UserPreference, error) {
+	return r.prefs[userID], nil
+}
+
+func (r *memNotificationRepo) GetConversationRecipients(ctx context.Context, conversationID, excludeUserID string) ([]string, error) {
+	return r.recipients, nil
+}
+
+func (r *memNotificationRepo) CreateNotification(ctx context.Context, n *models.Notification) error {
+	if n.UserID == r.failFor {
+		return errors.New("insert failed")
+	}
+	n.ID = "notification-" + strconv.Itoa(len(r.notifications)+1)
+	r.notifications = append(r.notifications, n)
+	return nil
+}
+
+func (r *memNotificationRepo) GetPushTargets(ctx context.Context, userID string) ([]model.PushTarget, error) {
+	return []model.PushTarget{{DeviceID: "device-1", Token: "token", Provider: model.PushProviderFCM}}, nil
+}
+
+func (r *memNotificationRepo) CreatePushDeliveryLog(ctx context.Context, entry *models.PushDeliveryLog) error {
+	r.pushes++
+	entry.ID = "push-" + strconv.Itoa(r.pushes)
+	return nil
+}
+
+func (r *memNotificationRepo) UpdatePushDeliveryStatus(ctx context.Context, id string, status models.PushDeliveryStatus, errorCode, errorMessage string) error {
+	return nil
+}
+
+func (r *memNotificationRepo) GetUserEmail(ctx context.Context, userID string) (string, error) {
+	return "", nil
+}
+
+func (r *memNotificationRepo) CreateEmailNotification(ctx context.Context, email *models.EmailNotification) error {
+	return nil
+}
+
+func (r *memNotificationRepo) UpdateEmailStatus(ctx context.Context, id string, status models.EmailStatus) error {
+	return nil
+}
+
+func (r *memNotificationRepo) ClaimNotification(ctx context.Context, userID, dedupKey string) (bool, error) {
+	if r.claims == nil {
+		r.claims = make(map[string]bool)
+	}
+	if r.claims[userID+"/"+dedupKey] {
+		return false, nil
+	}
+	r.claims[userID+"/"+dedupKey] = true
+	return true, nil
+}
+
+func (r *memNotificationRepo) ReleaseNotification(ctx context.Context, userID, dedupKey string) error {
+	delete(r.claims, userID+"/"+dedupKey)
+	return nil
+}
+
+type memDispatcher struct {
+	pushes []*model.PushJob
+}
+
+func (d *memDispatcher) EnqueuePush(ctx context.Context, job *model.PushJob) error {
+	d.pushes = append(d.pushes, job)
+	return nil
+}
+
+func (d *memDispatcher) EnqueueEmail(ctx context.Context, job *model.EmailJob) error {
+	return nil
+}
+
+type memBundleStore struct {
+	bundles map[model.BundleKey][]*model.BundledNotification
+	dueAt   map[model.BundleKey]time.Time
+}
+
+func newMemBundleStore() *memBundleStore {
+	return &memBundleStore{
+		bundles: make(map[model.BundleKey][]*model.BundledNotification),
+		dueAt:   make(map[model.BundleKey]time.Time),
+	}
+}
+
+func (s *memBundleStore) Add(ctx context.Context, item *model.BundledNotification, dueAt time.Time) (int, error) {
+	key := model.BundleKey{UserID: item.UserID, GroupKey: item.GroupKey}
+	if _, ok := s.dueAt[key]; !ok {
+		s.dueAt[key] = dueAt
+	}
+	s.bundles[key] = append(s.bundles[key], item)
+	return len(s.bundles[key]), nil
+}
+
+func (s *memBundleStore) Take(ctx context.Context, userID, groupKey string) ([]*model.BundledNotification, error) {
+	key := model.BundleKey{UserID: userID, GroupKey: groupKey}
+	items := s.bundles[key]
+	delete(s.bundles, key)
+	delete(s.dueAt, key)
+	return items, nil
+}
+
+func (s *memBundleStore) Due(ctx context.Context, now time.Time, limit int) ([]model.BundleKey, error) {
+	var keys []model.BundleKey
+	for key, dueAt := range s.dueAt {
+		if !dueAt.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].GroupKey < keys[j].GroupKey })
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys, nil
+}
+
+type memFlushLocker struct {
+	held map[string]bool
+}
+
+func (l *memFlushLocker) TryLock(ctx context.Context, userID string) (func(), bool, error) {
+	if l.held[userID] {
+		return nil, false, nil
+	}
+	l.held[userID] = true
+	return func() { delete(l.held, userID) }, true, nil
+}
+
+type bundleFixture struct {
+	worker     *Worker
+	repo       *memNotificationRepo
+	dispatcher *memDispatcher
+	store      *memBundleStore
+	locker     *memFlushLocker
+	now        time.Time
+}
+
+func newBundleFixture(t *testing.T, maxCount int) *bundleFixture {
+	t.Helper()
+
+	pref := DefaultPreference(bundleUser)
+	pref.BundleNotifications = true
+	pref.BundleIntervalMinutes = 5
+
+	f := &bundleFixture{
+		repo:       &memNotificationRepo{prefs: map[string]*models.UserPreference{bundleUser: pref}},
+		dispatcher: &memDispatcher{},
+		store:      newMemBundleStore(),
+		locker:     &memFlushLocker{held: make(map[string]bool)},
+		now:        time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC),
+	}
+	f.worker = NewWorker(f.repo, f.dispatcher, logger.NewNoop())
+	f.worker.now = func() time.Time { return f.now }
+	f.worker.SetBundling(f.store, f.locker, BundleConfig{MaxCount: maxCount, BatchSize: 10})
+	return f
+}
+
+func (f *bundleFixture) publish(t *testing.T, eventType, content string) {
+	t.Helper()
+	data, err := json.Marshal(model.MessageEvent{
+		Type:           eventType,
+		UserID:         bundleUser,
+		SenderID:       bundleSender,
+		ConversationID: bundleConv,
+		MessageID:      "message-" + content,
+		Content:        content,
+	})
+	if err != nil {
+		t.Fatalf("marshal event: %v", err)
+	}
+	if err := f.worker.Handle(context.Background(), &messaging.Message{Value: data}); err != nil {
+		t.Fatalf("Handle(%s) failed: %v", eventType, err)
+	}
+}
+
+func TestBundling_AccumulatesUntilIntervalElapses(t *testing.T) {
+	f := newBundleFixture(t, 20)
+	ctx := context.Background()
+
+	f.publish(t, model.EventNewMessage, "one")
+	f.now = f.now.Add(time.Minute)
+	f.publish(t, model.EventNewMessage, "two")
+	f.publish(t, model.EventNewMessage, "three")
+
+	if len(f.repo.notifications) != 0 || len(f.dispatcher.pushes) != 0 {
+		t.Fatalf("bundled notifications delivered early: %d stored, %d pushed", len(f.repo.notifications), len(f.dispatcher.pushes))
+	}
+	key := model.BundleKey{UserID: bundleUser, GroupKey: model.KindMessage + ":" + bundleConv}
+	if got := len(f.store.bundles[key]); got != 3 {
+		t.Fatalf("bundle holds %d notifications, want 3", got)
+	}
+
+	// The interval runs from the first notification, not the latest
+	f.now = time.Date(2026, 3, 4, 12, 4, 59, 0, time.UTC)
+	if flushed, err := f.worker.FlushDue(ctx); err != nil || flushed != 0 {
+		t.Fatalf("FlushDue before the interval = %d, %v; want 0", flushed, err)
+	}
+
+	f.now = time.Date(2026, 3, 4, 12, 5, 0, 0, time.UTC)
+	if flushed, err := f.worker.FlushDue(ctx); err != nil || flushed != 1 {
+		t.Fatalf("FlushDue after the interval = %d, %v; want 1", flushed, err)
+	}
+
+	if len(f.repo.notifications) != 1 {
+		t.Fatalf("stored %d notifications, want one summary", len(f.repo.notifications))
+	}
+	summary := f.repo.notifications[0]
+	if !summary.IsGroupSummary || summary.GroupCount != 3 {
+		t.Fatalf("summary IsGroupSummary=%v GroupCount=%d, want true and 3", summary.IsGroupSummary, summary.GroupCount)
+	}
+	if summary.Title != "3 new messages" || summary.Body != "three" {
+		t.Fatalf("summary = %q / %q, want the count and the latest body", summary.Title, summary.Body)
+	}
+	if len(f.dispatcher.pushes) != 1 {
+		t.Fatalf("queued %d pushes, want 1", len(f.dispatcher.pushes))
+	}
+	if len(f.store.bundles) != 0 {
+		t.Fatalf("bundle left behind after flush")
+	}
+}
+
+func TestBundling_FlushesAtMaxCount(t *testing.T) {
+	f := newBundleFixture(t, 3)
+
+	f.publish(t, model.EventNewMessage, "one")
+	f.publish(t, model.EventNewMessage, "two")
+	if len(f.repo.notifications) != 0 {
+		t.Fatalf("bundle flushed before reaching max count")
+	}
+
+	f.publish(t, model.EventNewMessage, "three")
+	if len(f.repo.notifications) != 1 || f.repo.notifications[0].GroupCount != 3 {
+		t.Fatalf("expected one summary of 3 at max count, got %d notifications", len(f.repo.notifications))
+	}
+}
+
+func TestBundling_SkipsUserLockedByAnotherFlusher(t *testing.T) {
+	f := newBundleFixture(t, 20)
+	ctx := context.Background()
+
+	f.publish(t, model.EventNewMessage, "one")
+	f.now = f.now.Add(10 * time.Minute)
+
+	f.locker.held[bundleUser] = true
+	if flushed, _ := f.worker.FlushDue(ctx); flushed != 0 || len(f.repo.notifications) != 0 {
+		t.Fatalf("flushed a bundle whose user is locked elsewhere")
+	}
+
+	delete(f.locker.held, bundleUser)
+	if flushed, _ := f.worker.FlushDue(ctx); flushed != 1 {
+		t.Fatalf("bundle not flushed once the lock was free")
+	}
+	if n := f.repo.notifications[0]; n.IsGroupSummary || n.Title != "New message" {
+		t.Fatalf("single bundled notification should be delivered as is, got %+v", n)
+	}
+}
+
+func TestBundling_HighPriorityBypasses(t *testing.T) {
+	f := newBundleFixture(t, 20)
+
+	f.publish(t, model.EventMention, "@you look")
+
+	if len(f.repo.notifications) != 1 {
+		t.Fatalf("mention was bundled; stored %d notifications", len(f.repo.notifications))
+	}
+	if n := f.repo.notifications[0]; n.Priority != models.NotificationPriorityHigh || n.IsGroupSummary {
+		t.Fatalf("unexpected mention notification %+v", n)
+	}
+	if len(f.store.bundles) != 0 {
+		t.Fatalf("mention left in a bundle")
+	}
+}
+
+func TestBundling_OffWhenUserDoesNotBundle(t *testing.T) {
+	f := newBundleFixture(t, 20)
+	f.repo.prefs[bundleUser].BundleNotifications = false
+
+	f.publish(t, model.EventNewMessage, "one")
+	f.publish(t, model.EventNewMessage, "two")
+
+	if len(f.repo.notifications) != 2 || len(f.store.bundles) != 0 {
+		t.Fatalf("expected immediate delivery, got %d stored and %d bundles", len(f.repo.notifications), len(f.store.bundles))
+	}
+}
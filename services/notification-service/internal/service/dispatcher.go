@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"notification-service/internal/model"
+
+	"shared/pkg/messaging"
+)
+
+// kafkaDispatcher queues deliveries on Kafka topics read by the push and
+// email senders. Jobs are keyed by user so each user's deliveries stay in
+// order.
+type kafkaDispatcher struct {
+	producer   messaging.Producer
+	pushTopic  string
+	emailTopic string
+}
+
+// NewKafkaDispatcher creates a dispatcher that publishes push jobs to
+// pushTopic and email jobs to emailTopic
+func NewKafkaDispatcher(producer messaging.Producer, pushTopic, emailTopic string) Dispatcher {
+	return &kafkaDispatcher{
+		producer:   producer,
+		pushTopic:  pushTopic,
+		emailTopic: emailTopic,
+	}
+}
+
+func (d *kafkaDispatcher) EnqueuePush(ctx context.Context, job *model.PushJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if err := d.producer.ProduceWithKey(ctx, d.pushTopic, job.UserID, data,
+		messaging.WithDedupKey(job.DeliveryLogID)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *kafkaDispatcher) EnqueueEmail(ctx context.Context, job *model.EmailJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if err := d.producer.ProduceWithKey(ctx, d.emailTopic, job.UserID, data,
+		messaging.WithDedupKey(job.EmailID)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"time"
+
+	"notification-service/internal/model"
+
+	"shared/pkg/database/postgres/models"
+)
+
+// Channels says how a notification may reach its recipient
+type Channels struct {
+	InApp bool
+	Push  bool
+	Email bool
+}
+
+// Any reports whether the notification reaches the user at all
+func (c Channels) Any() bool {
+	return c.InApp || c.Push || c.Email
+}
+
+// DefaultPreference is used for users who never saved their notification
+// settings; it mirrors the column defaults of notifications.user_preferences
+func DefaultPreference(userID string) *models.UserPreference {
+	return &models.UserPreference{
+		UserID:                userID,
+		PushEnabled:           true,
+		EmailEnabled:          true,
+		InAppEnabled:          true,
+		MessagePush:           true,
+		MentionPush:           true,
+		MentionEmail:          true,
+		ReactionPush:          true,
+		BundleNotifications:   true,
+		BundleIntervalMinutes: 5,
+		NotificationSound:     "default",
+	}
+}
+
+// ChannelsFor applies a user's global toggles, the toggles for this kind of
+// notification and their quiet hours. Quiet hours silence push and email;
+// the notification is still kept in-app.
+func ChannelsFor(pref *models.UserPreference, kind string, now time.Time) Channels {
+	c := Channels{
+		InApp: pref.InAppEnabled,
+		Push:  pref.PushEnabled,
+		Email: pref.EmailEnabled,
+	}
+
+	switch kind {
+	case model.KindMessage:
+		c.Push = c.Push && pref.MessagePush
+		c.Email = c.Email && pref.MessageEmail
+	case model.KindMention:
+		c.Push = c.Push && pref.MentionPush
+		c.Email = c.Email && pref.MentionEmail
+	case model.KindReaction:
+		c.Push = c.Push && pref.ReactionPush
+		c.Email = c.Email && pref.ReactionEmail
+	default:
+		c.Push, c.Email = false, false
+	}
+
+	if InQuietHours(pref, now) {
+		c.Push, c.Email = false, false
+	}
+	return c
+}
+
+// InQuietHours reports whether now falls in the user's quiet hours, read on
+// the clock of their QuietHoursTimezone (UTC when unset or unknown). A window
+// whose end is before its start runs overnight, and QuietHoursDays, when set,
+// lists the weekdays (0=Sunday) a window may start on: with 22:00-07:00 on
+// Fridays only, Saturday 02:00 is quiet and Friday 02:00 is not.
+func InQuietHours(pref *models.UserPreference, now time.Time) bool {
+	if !pref.QuietHoursEnabled || pref.QuietHoursStart == nil || pref.QuietHoursEnd == nil {
+		return false
+	}
+
+	local := now.In(quietHoursLocation(pref.QuietHoursTimezone))
+	start, end, at := clockSeconds(*pref.QuietHoursStart), clockSeconds(*pref.QuietHoursEnd), clockSeconds(local)
+	if start == end {
+		return false
+	}
+
+	windowDay := local.Weekday()
+	if start < end {
+		if at < start || at >= end {
+			return false
+		}
+	} else {
+		switch {
+		case at >= start:
+		case at < end:
+			// The early-morning tail of a window that started yesterday
+			windowDay = (windowDay + 6) % 7
+		default:
+			return false
+		}
+	}
+
+	if len(pref.QuietHoursDays) == 0 {
+		return true
+	}
+	for _, day := range pref.QuietHoursDays {
+		if time.Weekday(day) == windowDay {
+			return true
+		}
+	}
+	return false
+}
+
+func quietHoursLocation(tz *string) *time.Location {
+	if tz == nil || *tz == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// clockSeconds is the time of day of t in seconds since midnight
+func clockSeconds(t time.Time) int {
+	return t.Hour()*3600 + t.Minute()*60 + t.Second()
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"notification-service/internal/model"
+
+	"shared/pkg/database/postgres/models"
+)
+
+func clock(hour, minute int) *time.Time {
+	t := time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC)
+	return &t
+}
+
+func quietPreference(start, end *time.Time, tz string, days ...int64) *models.UserPreference {
+	pref := DefaultPreference("user")
+	pref.QuietHoursEnabled = true
+	pref.QuietHoursStart = start
+	pref.QuietHoursEnd = end
+	if tz != "" {
+		pref.QuietHoursTimezone = &tz
+	}
+	pref.QuietHoursDays = days
+	return pref
+}
+
+func TestChannelsFor_AppliesGlobalAndPerTypeToggles(t *testing.T) {
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		modify func(p *models.UserPreference)
+		kind   string
+		want   Channels
+	}{
+		{"defaults for messages", nil, model.KindMessage, Channels{InApp: true, Push: true}},
+		{"defaults for mentions", nil, model.KindMention, Channels{InApp: true, Push: true, Email: true}},
+		{"push disabled globally", func(p *models.UserPreference) { p.PushEnabled = false }, model.KindMention, Channels{InApp: true, Email: true}},
+		{"email disabled globally", func(p *models.UserPreference) { p.EmailEnabled = false }, model.KindMention, Channels{InApp: true, Push: true}},
+		{"reaction push off", func(p *models.UserPreference) { p.ReactionPush = false }, model.KindReaction, Channels{InApp: true}},
+		{"message email on", func(p *models.UserPreference) { p.MessageEmail = true }, model.KindMessage, Channels{InApp: true, Push: true, Email: true}},
+		{"everything off", func(p *models.UserPreference) {
+			p.InAppEnabled, p.MessagePush = false, false
+		}, model.KindMessage, Channels{}},
+		{"unknown kind", nil, "friend_request", Channels{InApp: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pref := DefaultPreference("user")
+			if tt.modify != nil {
+				tt.modify(pref)
+			}
+			if got := ChannelsFor(pref, tt.kind, now); got != tt.want {
+				t.Fatalf("ChannelsFor = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelsFor_QuietHoursSilencePushAndEmail(t *testing.T) {
+	pref := quietPreference(clock(22, 0), clock(7, 0), "")
+	night := time.Date(2026, 3, 4, 23, 30, 0, 0, time.UTC)
+
+	got := ChannelsFor(pref, model.KindMention, night)
+	if got != (Channels{InApp: true}) {
+		t.Fatalf("ChannelsFor during quiet hours = %+v, want in-app only", got)
+	}
+
+	day := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	if got := ChannelsFor(pref, model.KindMention, day); !got.Push || !got.Email {
+		t.Fatalf("ChannelsFor outside quiet hours = %+v, want push and email", got)
+	}
+}
+
+func TestInQuietHours_Windows(t *testing.T) {
+	// 2026-03-06 is a Friday
+	friday := func(hour, minute int) time.Time { return time.Date(2026, 3, 6, hour, minute, 0, 0, time.UTC) }
+	saturday := func(hour, minute int) time.Time { return time.Date(2026, 3, 7, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		pref *models.UserPreference
+		at   time.Time
+		want bool
+	}{
+		{"same-day window, inside", quietPreference(clock(13, 0), clock(14, 0), ""), friday(13, 30), true},
+		{"same-day window, at end", quietPreference(clock(13, 0), clock(14, 0), ""), friday(14, 0), false},
+		{"same-day window, before", quietPreference(clock(13, 0), clock(14, 0), ""), friday(12, 59), false},
+		{"overnight, late evening", quietPreference(clock(22, 0), clock(7, 0), ""), friday(23, 0), true},
+		{"overnight, early morning", quietPreference(clock(22, 0), clock(7, 0), ""), friday(6, 59), true},
+		{"overnight, daytime", quietPreference(clock(22, 0), clock(7, 0), ""), friday(12, 0), false},
+		{"empty window", quietPreference(clock(9, 0), clock(9, 0), ""), friday(9, 0), false},
+		{"missing start", quietPreference(nil, clock(7, 0), ""), friday(3, 0), false},
+
+		// 23:30 UTC is 18:30 in New York (EST, UTC-5)
+		{"timezone, outside locally", quietPreference(clock(22, 0), clock(7, 0), "America/New_York"), friday(23, 30), false},
+		// 04:00 UTC is 23:00 the previous evening in New York
+		{"timezone, inside locally", quietPreference(clock(22, 0), clock(7, 0), "America/New_York"), saturday(4, 0), true},
+		// 20:00 UTC is 05:00 the next morning in Tokyo
+		{"timezone ahead of UTC", quietPreference(clock(22, 0), clock(7, 0), "Asia/Tokyo"), friday(20, 0), true},
+		{"unknown timezone falls back to UTC", quietPreference(clock(22, 0), clock(7, 0), "Mars/Olympus"), friday(23, 0), true},
+
+		// Days name the weekday a window starts on (0=Sunday, 5=Friday)
+		{"day listed", quietPreference(clock(13, 0), clock(14, 0), "", 5), friday(13, 30), true},
+		{"day not listed", quietPreference(clock(13, 0), clock(14, 0), "", 6), friday(13, 30), false},
+		{"overnight tail of a listed day", quietPreference(clock(22, 0), clock(7, 0), "", 5), saturday(2, 0), true},
+		{"overnight tail of an unlisted day", quietPreference(clock(22, 0), clock(7, 0), "", 6), saturday(2, 0), false},
+		{"listed day in the user's timezone", quietPreference(clock(22, 0), clock(7, 0), "America/New_York", 5), saturday(4, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InQuietHours(tt.pref, tt.at); got != tt.want {
+				t.Fatalf("InQuietHours(%s) = %v, want %v", tt.at.Format(time.RFC3339), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInQuietHours_Disabled(t *testing.T) {
+	pref := quietPreference(clock(0, 0), clock(23, 59), "")
+	pref.QuietHoursEnabled = false
+	if InQuietHours(pref, time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("InQuietHours = true with quiet hours disabled")
+	}
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"notification-service/internal/model"
+	"notification-service/internal/repo"
+
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+	"shared/pkg/messaging"
+
+	"github.com/google/uuid"
+)
+
+// maxBodyLength caps how much of a message is copied into a notification
+const maxBodyLength = 200
+
+// Dispatcher hands push and email deliveries to the senders
+type Dispatcher interface {
+	EnqueuePush(ctx context.Context, job *model.PushJob) error
+	EnqueueEmail(ctx context.Context, job *model.EmailJob) error
+}
+
+// Worker turns message events into notifications. For every recipient it
+// applies their preferences, records the notification and queues push and
+// email delivery. Users who bundle notifications get them held back and
+// delivered as one summary per conversation.
+type Worker struct {
+	repo       repo.NotificationRepository
+	dispatcher Dispatcher
+	bundler    *bundler
+	log        logger.Logger
+	now        func() time.Time
+}
+
+// NewWorker creates a notification worker
+func NewWorker(r repo.NotificationRepository, dispatcher Dispatcher, log logger.Logger) *Worker {
+	return &Worker{
+		repo:       r,
+		dispatcher: dispatcher,
+		log:        log,
+		now:        time.Now,
+	}
+}
+
+// Handle returns an error only for failures worth retrying; malformed events
+// are logged and acknowledged so they do not block the partition. Delivery
+// failures are recorded rather than retried, since the notification itself
+// has already been stored.
+//
+// Each recipient is claimed under the event's dedup key before being
+// notified, so a retry after a failure partway through the recipients skips
+// those already handled, and a message seen as both new_message and
+// message.sent notifies each recipient once.
+func (w *Worker) Handle(ctx context.Context, msg *messaging.Message) error {
+	var event model.MessageEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		w.log.Warn("Skipping malformed message event",
+			logger.String("topic", msg.Topic),
+			logger.Int64("offset", msg.Offset),
+			logger.Error(err),
+		)
+		return nil
+	}
+
+	kind := event.Kind()
+	if kind == "" {
+		return nil
+	}
+
+	recipients, err := w.recipients(ctx, &event)
+	if err != nil {
+		return err
+	}
+
+	key := dedupKey(&event, kind, msg)
+	for _, recipientID := range recipients {
+		if recipientID == event.SenderID {
+			continue
+		}
+		if err := w.notify(ctx, &event, kind, key, recipientID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dedupKey identifies what a recipient is notified about. Message and
+// mention keys are built from the message ID so both message topics agree on
+// them; a reaction is keyed by who reacted with what. Other events fall back
+// to the producer's x-dedup-key header, and without one are not deduplicated.
+func dedupKey(event *model.MessageEvent, kind string, msg *messaging.Message) string {
+	if event.MessageID == "" {
+		return msg.DedupKey()
+	}
+	if kind == model.KindReaction {
+		return kind + ":" + event.MessageID + ":" + event.SenderID + ":" + event.Reaction
+	}
+	return kind + ":" + event.MessageID
+}
+
+func (w *Worker) recipients(ctx context.Context, event *model.MessageEvent) ([]string, error) {
+	if event.UserID != "" {
+		if _, err := uuid.Parse(event.UserID); err != nil {
+			w.log.Warn("Skipping message event with invalid recipient", logger.String("user_id", event.UserID))
+			return nil, nil
+		}
+		return []string{event.UserID}, nil
+	}
+
+	if event.Type != model.EventMessageSent {
+		w.log.Warn("Skipping message event without a recipient", logger.String("type", event.Type))
+		return nil, nil
+	}
+	if _, err := uuid.Parse(event.ConversationID); err != nil {
+		w.log.Warn("Skipping message event with invalid conversation", logger.String("conversation_id", event.ConversationID))
+		return nil, nil
+	}
+	return w.repo.GetConversationRecipients(ctx, event.ConversationID, event.SenderID)
+}
+
+func (w *Worker) notify(ctx context.Context, event *model.MessageEvent, kind, key, recipientID string) error {
+	pref, err := w.repo.GetPreference(ctx, recipientID)
+	if err != nil {
+		return err
+	}
+	if pref == nil {
+		pref = DefaultPreference(recipientID)
+	}
+
+	now := w.now()
+	channels := ChannelsFor(pref, kind, now)
+	if !channels.Any() {
+		w.log.Debug("Notification suppressed by preferences",
+			logger.String("user_id", recipientID),
+			logger.String("type", kind),
+		)
+		return nil
+	}
+
+	title, body := render(event, kind)
+	n := &models.Notification{
+		UserID:           recipientID,
+		NotificationType: kind,
+		Title:            title,
+		Body:             body,
+		Sound:            pref.NotificationSound,
+		DeliveryStatus:   models.NotificationDeliveryStatusPending,
+		Priority:         models.NotificationPriorityNormal,
+		GroupCount:       1,
+		CreatedAt:        now,
+	}
+	if kind == model.KindMention {
+		n.Priority = models.NotificationPriorityHigh
+	}
+	if event.SenderID != "" {
+		n.RelatedUserID = &event.SenderID
+	}
+	if event.MessageID != "" {
+		n.RelatedMessageID = &event.MessageID
+	}
+	if event.ConversationID != "" {
+		n.RelatedConversationID = &event.ConversationID
+	}
+
+	if key != "" {
+		claimed, err := w.repo.ClaimNotification(ctx, recipientID, key)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			w.log.Debug("Skipping notification already made",
+				logger.String("user_id", recipientID),
+				logger.String("dedup_key", key),
+			)
+			return nil
+		}
+	}
+
+	if w.shouldBundle(pref, n) {
+		err = w.bundle(ctx, pref, n)
+	} else {
+		err = w.deliver(ctx, n, channels)
+	}
+	if err != nil && key != "" {
+		if releaseErr := w.repo.ReleaseNotification(ctx, recipientID, key); releaseErr != nil {
+			w.log.Warn("Failed to release notification claim; the retry will skip this user",
+				logger.String("user_id", recipientID),
+				logger.String("dedup_key", key),
+				logger.Error(releaseErr),
+			)
+		}
+	}
+	return err
+}
+
+// deliver stores a notification and queues it on the channels it may use
+func (w *Worker) deliver(ctx context.Context, n *models.Notification, channels Channels) error {
+	if err := w.repo.CreateNotification(ctx, n); err != nil {
+		return err
+	}
+
+	if channels.Push {
+		w.enqueuePush(ctx, n)
+	}
+	if channels.Email {
+		w.enqueueEmail(ctx, n)
+	}
+	return nil
+}
+
+// enqueuePush queues a push to each of the user's devices, logging every
+// attempt in the push delivery log
+func (w *Worker) enqueuePush(ctx context.Context, n *models.Notification) {
+	targets, err := w.repo.GetPushTargets(ctx, n.UserID)
+	if err != nil {
+		return
+	}
+
+	for _, target := range targets {
+		deviceID := target.DeviceID
+		entry := &models.PushDeliveryLog{
+			NotificationID: n.ID,
+			UserID:         n.UserID,
+			DeviceID:       &deviceID,
+			PushToken:      target.Token,
+			PushProvider:   target.Provider,
+			Status:         models.PushDeliveryStatusPending,
+			CreatedAt:      w.now(),
+		}
+		if err := w.repo.CreatePushDeliveryLog(ctx, entry); err != nil {
+			continue
+		}
+
+		err := w.dispatcher.EnqueuePush(ctx, &model.PushJob{
+			DeliveryLogID:  entry.ID,
+			NotificationID: n.ID,
+			UserID:         n.UserID,
+			DeviceID:       target.DeviceID,
+			PushToken:      target.Token,
+			PushProvider:   target.Provider,
+			Title:          n.Title,
+			Body:           n.Body,
+			Sound:          n.Sound,
+		})
+		if err != nil {
+			w.log.Warn("Failed to queue push notification",
+				logger.String("notification_id", n.ID),
+				logger.String("device_id", target.DeviceID),
+				logger.Error(err),
+			)
+			w.repo.UpdatePushDeliveryStatus(ctx, entry.ID, models.PushDeliveryStatusFailed, "enqueue_failed", err.Error())
+		}
+	}
+}
+
+// enqueueEmail queues an email to the user's address, if they have one
+func (w *Worker) enqueueEmail(ctx context.Context, n *models.Notification) {
+	address, err := w.repo.GetUserEmail(ctx, n.UserID)
+	if err != nil || address == "" {
+		return
+	}
+
+	notificationID := n.ID
+	email := &models.EmailNotification{
+		UserID:         n.UserID,
+		NotificationID: &notificationID,
+		EmailTo:        address,
+		Subject:        n.Title,
+		BodyText:       n.Body,
+		Status:         models.EmailStatusPending,
+		CreatedAt:      w.now(),
+	}
+	if err := w.repo.CreateEmailNotification(ctx, email); err != nil {
+		return
+	}
+
+	err = w.dispatcher.EnqueueEmail(ctx, &model.EmailJob{
+		EmailID:        email.ID,
+		NotificationID: n.ID,
+		UserID:         n.UserID,
+		To:             address,
+		Subject:        email.Subject,
+		BodyText:       email.BodyText,
+	})
+	if err != nil {
+		w.log.Warn("Failed to queue email notification",
+			logger.String("notification_id", n.ID),
+			logger.Error(err),
+		)
+		w.repo.UpdateEmailStatus(ctx, email.ID, models.EmailStatusFailed)
+	}
+}
+
+// render builds a notification's title and body from the event
+func render(event *model.MessageEvent, kind string) (title, body string) {
+	switch kind {
+	case model.KindMention:
+		title = "You were mentioned"
+	case model.KindReaction:
+		return "New reaction", fmt.Sprintf("Reacted %s to your message", event.Reaction)
+	default:
+		title = "New message"
+	}
+
+	body = event.Content
+	if body == "" && event.MessageType != "" && event.MessageType != "text" {
+		body = fmt.Sprintf("Sent a %s", event.MessageType)
+	}
+	return title, truncate(body, maxBodyLength)
+}
+
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"notification-service/internal/model"
+
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+	"shared/pkg/messaging"
+)
+
+const workerRecipient = "6f1c2a3e-0000-4000-8000-000000000003"
+
+func newTestWorker(recipients ...string) (*Worker, *memNotificationRepo) {
+	r := &memNotificationRepo{prefs: map[string]*models.UserPreference{}, recipients: recipients}
+	return NewWorker(r, &memDispatcher{}, logger.NewNoop()), r
+}
+
+func handleEvent(w *Worker, event model.MessageEvent, dedupKey string) error {
+	data, _ := json.Marshal(event)
+	return w.Handle(context.Background(), messaging.NewMessage(data).WithDedupKey(dedupKey))
+}
+
+func TestHandle_NotifiesOnceForMessageOnBothTopics(t *testing.T) {
+	w, r := newTestWorker(bundleUser)
+	event := model.MessageEvent{
+		SenderID:       bundleSender,
+		ConversationID: bundleConv,
+		MessageID:      "message-1",
+		Content:        "hello",
+	}
+
+	offline := event
+	offline.Type = model.EventNewMessage
+	offline.UserID = bundleUser
+	if err := handleEvent(w, offline, "message-1:"+bundleUser); err != nil {
+		t.Fatalf("new_message: %v", err)
+	}
+
+	sent := event
+	sent.Type = model.EventMessageSent
+	if err := handleEvent(w, sent, "message-1:sent"); err != nil {
+		t.Fatalf("message.sent: %v", err)
+	}
+
+	if len(r.notifications) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(r.notifications))
+	}
+}
+
+func TestHandle_RetrySkipsRecipientsAlreadyNotified(t *testing.T) {
+	w, r := newTestWorker(bundleUser, workerRecipient)
+	event := model.MessageEvent{
+		Type:           model.EventMessageSent,
+		SenderID:       bundleSender,
+		ConversationID: bundleConv,
+		MessageID:      "message-1",
+		Content:        "hello",
+	}
+
+	r.failFor = workerRecipient
+	if err := handleEvent(w, event, ""); err == nil {
+		t.Fatalf("expected the failed insert to be returned for retry")
+	}
+	if len(r.notifications) != 1 {
+		t.Fatalf("got %d notifications before retry, want 1", len(r.notifications))
+	}
+
+	r.failFor = ""
+	if err := handleEvent(w, event, ""); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if len(r.notifications) != 2 {
+		t.Fatalf("got %d notifications after retry, want 2", len(r.notifications))
+	}
+	for i, userID := range []string{bundleUser, workerRecipient} {
+		if r.notifications[i].UserID != userID {
+			t.Fatalf("notification %d went to %s, want %s", i, r.notifications[i].UserID, userID)
+		}
+	}
+}
+
+func TestHandle_FallsBackToDedupKeyHeader(t *testing.T) {
+	w, r := newTestWorker()
+	event := model.MessageEvent{
+		Type:     model.EventNewMessage,
+		UserID:   bundleUser,
+		SenderID: bundleSender,
+		Content:  "no message id",
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := handleEvent(w, event, "event-1"); err != nil {
+			t.Fatalf("Handle: %v", err)
+		}
+	}
+	if len(r.notifications) != 1 {
+		t.Fatalf("got %d notifications for a redelivered event, want 1", len(r.notifications))
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"presence-service/internal/errors"
 	"presence-service/internal/model"
@@ -40,7 +41,21 @@ func (h *PresenceHandler) GetBulkPresence(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	presences, svcErr := h.service.GetBulkPresence(r.Context(), req.UserIDs, requesterID)
+	userIDs := make([]uuid.UUID, 0, len(req.UserIDs))
+	seen := make(map[uuid.UUID]bool, len(req.UserIDs))
+	for _, userID := range req.UserIDs {
+		if !seen[userID] {
+			seen[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+
+	if len(userIDs) > model.MaxBulkPresenceUsers {
+		response.BadRequestError(r.Context(), r, w, fmt.Sprintf("At most %d user IDs per request", model.MaxBulkPresenceUsers), nil)
+		return
+	}
+
+	presences, svcErr := h.service.GetBulkPresence(r.Context(), userIDs, requesterID)
 	if svcErr != nil {
 		if appErr, ok := svcErr.(pkgErrors.AppError); ok {
 			h.log.Error("Failed to get bulk presence",
@@ -6,6 +6,7 @@ type PresenceHandlerInterface interface {
 	UpdatePresence(w http.ResponseWriter, r *http.Request)
 	GetPresence(w http.ResponseWriter, r *http.Request)
 	GetBulkPresence(w http.ResponseWriter, r *http.Request)
+	UpdatePrivacy(w http.ResponseWriter, r *http.Request)
 	Heartbeat(w http.ResponseWriter, r *http.Request)
 	GetActiveDevices(w http.ResponseWriter, r *http.Request)
 	SetTypingIndicator(w http.ResponseWriter, r *http.Request)
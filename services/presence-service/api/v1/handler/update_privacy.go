@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"presence-service/internal/errors"
+	"presence-service/internal/model"
+
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+	"shared/server/request"
+	"shared/server/response"
+)
+
+func (h *PresenceHandler) UpdatePrivacy(w http.ResponseWriter, r *http.Request) {
+	handler := request.NewHandler(r, w)
+	requestID := handler.GetRequestID()
+
+	h.log.Info("Update privacy request received",
+		logger.String("service", errors.ServiceName),
+		logger.String("request_id", requestID),
+	)
+
+	userId, ok := request.GetUserIDUUIDFromContext(r.Context())
+	if !ok {
+		h.log.Warn("User ID missing in context for updating privacy",
+			logger.String("request_id", requestID),
+		)
+		response.BadRequestError(r.Context(), r, w, "User ID missing in context", nil)
+		return
+	}
+
+	var req model.PrivacyUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequestError(r.Context(), r, w, "Invalid request body", err)
+		return
+	}
+
+	req.UserID = userId
+
+	privacy, svcErr := h.service.UpdatePrivacy(r.Context(), &req)
+	if svcErr != nil {
+		if pkgErrors.GetCode(svcErr) == pkgErrors.CodeInvalidArgument {
+			response.BadRequestError(r.Context(), r, w, svcErr.Error(), svcErr)
+			return
+		}
+		h.log.Error("Failed to update privacy", logger.Error(svcErr))
+		response.InternalServerError(r.Context(), r, w, "Failed to update privacy", svcErr)
+		return
+	}
+
+	response.JSONWithContext(r.Context(), r, w, http.StatusOK, privacy)
+}
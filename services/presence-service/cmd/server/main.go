@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"presence-service/api/v1/handler"
 	"presence-service/internal/config"
+	"presence-service/internal/events"
 	"presence-service/internal/health"
 	healthCheckers "presence-service/internal/health/checkers"
 	"presence-service/internal/repo"
@@ -18,6 +19,8 @@ import (
 	"shared/pkg/database/postgres"
 	"shared/pkg/logger"
 	adapter "shared/pkg/logger/adapter"
+	"shared/pkg/messaging"
+	"shared/pkg/messaging/kafka"
 	env "shared/server/env"
 	"shared/server/middleware"
 	"shared/server/response"
@@ -30,6 +33,8 @@ func createLogger(name string) logger.Logger {
 	log, err := adapter.NewZap(logger.Config{
 		Level:   logger.GetLoggerLevel(),
 		Format:  logger.GetLoggerFormat(),
+		Output:  logger.GetLoggerOutput(),
+		File:    logger.GetLoggerFile(),
 		Service: name,
 	})
 	if err != nil {
@@ -105,6 +110,31 @@ func createCacheClient(cfg config.RedisConfig, log logger.Logger) (cache.Cache,
 	return cacheClient, nil
 }
 
+func createKafkaConsumer(cfg config.KafkaConfig, log logger.Logger) (messaging.Consumer, error) {
+	log.Debug("Creating Kafka consumer",
+		logger.String("brokers", fmt.Sprintf("%v", cfg.Brokers)),
+		logger.String("group_id", cfg.GroupID),
+	)
+	consumer, err := kafka.NewConsumer(messaging.ConsumerConfig{
+		Brokers:      cfg.Brokers,
+		ClientID:     cfg.ClientID,
+		GroupID:      cfg.GroupID,
+		MaxRetries:   cfg.MaxRetries,
+		RetryBackoff: int(cfg.RetryBackoff.Milliseconds()),
+		Logger:       log,
+
+		DeadLetterEnabled: cfg.DeadLetterEnabled,
+		DeadLetterSuffix:  cfg.DeadLetterSuffix,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Kafka consumer created successfully",
+		logger.String("group_id", cfg.GroupID),
+	)
+	return consumer, nil
+}
+
 func setupRoutes(
 	builder *router.Builder,
 	presenceHandler *handler.PresenceHandler,
@@ -118,6 +148,8 @@ func setupRoutes(
 		r.UseChain(chain)
 		r.Get("/", presenceHandler.GetPresence)                                 // Get user presence
 		r.Post("/", presenceHandler.UpdatePresence)                             // Update presence
+		r.Post("/bulk", presenceHandler.GetBulkPresence)                        // Get many users' presence at once
+		r.Put("/privacy", presenceHandler.UpdatePrivacy)                        // Update last-seen and online status visibility
 		r.Post("/heartbeat", presenceHandler.Heartbeat)                         // Send heartbeat
 		r.Get("/devices", presenceHandler.GetActiveDevices)                     // Get active devices
 		r.Post("/typing", presenceHandler.SetTypingIndicator)                   // Set typing indicator
@@ -129,11 +161,13 @@ func setupRoutes(
 func createRouter(
 	presenceHandler *handler.PresenceHandler,
 	healthHandler *health.Handler,
+	inFlight *shutdown.InFlight,
 	log logger.Logger,
 ) (*router.Router, error) {
 
 	builder := router.NewBuilder().
 		WithHealthEndpoint("/health", healthHandler.Health).
+		WithLogLevelEndpoint("/admin/loglevel", log, env.LogLevelEndpointEnabled()).
 		WithNotFoundHandler(func(w http.ResponseWriter, r *http.Request) {
 			response.RouteNotFoundError(r.Context(), r, w, log)
 		}).
@@ -141,11 +175,13 @@ func createRouter(
 			response.MethodNotAllowedError(r.Context(), r, w)
 		}).
 		WithEarlyMiddleware(
+			router.Middleware(inFlight.Middleware),
 			router.Middleware(middleware.RequestReceivedLogger(log)),
 		).
 		WithLateMiddleware(
 			router.Middleware(middleware.Recovery(log)),
 			router.Middleware(middleware.RequestCompletedLogger(log)),
+			router.Middleware(middleware.BodyLogger(middleware.BodyLoggerConfig{Enabled: env.BodyLoggingEnabled(), Logger: log})),
 		)
 
 	// Add liveness and readiness endpoints
@@ -162,24 +198,32 @@ func createRouter(
 	return r, nil
 }
 
-func setupShutdownManager(srv *server.Server, log logger.Logger, cfg *config.Config) *shutdown.Manager {
+func setupShutdownManager(srv *server.Server, inFlight *shutdown.InFlight, consumer messaging.Consumer, log logger.Logger, cfg *config.Config) *shutdown.Manager {
 	shutdownMgr := shutdown.New(
 		shutdown.WithTimeout(cfg.Server.ShutdownTimeout),
 		shutdown.WithLogger(log),
 	)
 
+	// Draining runs inside the server hook, after the listeners close, so it
+	// sees the requests the server is still handling
+	serverHook := shutdown.ServerShutdownHook(srv)
+	if cfg.Shutdown.WaitForConnections && cfg.Shutdown.DrainTimeout > 0 {
+		serverHook = shutdown.ServerDrainHook(srv, inFlight, cfg.Shutdown.DrainTimeout, log)
+	}
 	shutdownMgr.RegisterWithPriority(
 		"http-server",
-		shutdown.ServerShutdownHook(srv),
+		serverHook,
 		shutdown.PriorityHigh,
 	)
 
-	if cfg.Shutdown.WaitForConnections && cfg.Shutdown.DrainTimeout > 0 {
-		shutdownMgr.RegisterWithOptions(
-			"drain-connections",
-			shutdown.DelayHook(cfg.Shutdown.DrainTimeout),
-			shutdown.PriorityHigh,
-			cfg.Shutdown.DrainTimeout,
+	if consumer != nil {
+		shutdownMgr.RegisterWithPriority(
+			"kafka-consumer",
+			shutdown.Hook(func(ctx context.Context) error {
+				log.Info("Draining Kafka consumer")
+				return consumer.Close()
+			}),
+			shutdown.PriorityNormal,
 		)
 	}
 
@@ -254,6 +298,7 @@ func main() {
 	}
 
 	healthMgr := health.NewManager(cfg.Service.Name, cfg.Service.Version)
+	healthMgr.SetCacheTTL(cfg.Server.HealthCacheTTL)
 	healthMgr.RegisterChecker(healthCheckers.NewDatabaseChecker(dbClient))
 	if cfg.Cache.Enabled && cacheClient != nil {
 		healthMgr.RegisterChecker(healthCheckers.NewCacheChecker(cacheClient))
@@ -264,13 +309,28 @@ func main() {
 	presenceRepo := repo.NewPresenceRepository(dbClient, log)
 
 	// Initialize legacy HTTP service
-	presenceService := service.NewPresenceService(presenceRepo, cacheClient, log)
+	presenceService := service.NewPresenceService(presenceRepo, cacheClient, cfg.Presence.CacheTTL, log)
+
+	var messageConsumer messaging.Consumer
+	if cfg.Kafka.Enabled {
+		messageConsumer, err = createKafkaConsumer(cfg.Kafka, log)
+		if err != nil {
+			log.Fatal("Failed to create Kafka consumer", logger.Error(err))
+		}
+		messageEvents := events.NewMessageEventHandler(presenceService, log)
+		if err := messageConsumer.Subscribe(context.Background(), []string{cfg.Kafka.MessageTopic}, messageEvents.Handle); err != nil {
+			log.Fatal("Failed to subscribe to message events", logger.Error(err))
+		}
+	} else {
+		log.Info("Kafka is disabled in configuration")
+	}
 
 	// Initialize handlers
 	presenceHandler := handler.NewPresenceHandler(presenceService, log)
 	healthHandler := health.NewHandler(healthMgr)
 
-	routerInstance, err := createRouter(presenceHandler, healthHandler, log)
+	inFlight := shutdown.NewInFlight()
+	routerInstance, err := createRouter(presenceHandler, healthHandler, inFlight, log)
 	if err != nil {
 		log.Fatal("Failed to create router", logger.Error(err))
 	}
@@ -291,7 +351,7 @@ func main() {
 		log.Fatal("Failed to create server", logger.Error(err))
 	}
 
-	shutdownMgr := setupShutdownManager(srv, log, cfg)
+	shutdownMgr := setupShutdownManager(srv, inFlight, messageConsumer, log, cfg)
 
 	serverErrors := make(chan error, 1)
 	go func() {
@@ -8,6 +8,7 @@ type Config struct {
 	Database DatabaseConfig `yaml:"database" mapstructure:"database"`
 	Cache    CacheConfig    `yaml:"cache" mapstructure:"cache"`
 	Presence PresenceConfig `yaml:"presence" mapstructure:"presence"`
+	Kafka    KafkaConfig    `yaml:"kafka" mapstructure:"kafka"`
 	Logging  LoggingConfig  `yaml:"logging" mapstructure:"logging"`
 	Shutdown ShutdownConfig `yaml:"shutdown" mapstructure:"shutdown"`
 }
@@ -26,6 +27,7 @@ type ServerConfig struct {
 	IdleTimeout     time.Duration `yaml:"idle_timeout" mapstructure:"idle_timeout"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" mapstructure:"shutdown_timeout"`
 	MaxHeaderBytes  int           `yaml:"max_header_bytes" mapstructure:"max_header_bytes"`
+	HealthCacheTTL  time.Duration `yaml:"health_cache_ttl" mapstructure:"health_cache_ttl"`
 }
 
 type DatabaseConfig struct {
@@ -68,6 +70,21 @@ type PresenceConfig struct {
 	SessionTimeout     time.Duration `yaml:"session_timeout" mapstructure:"session_timeout"`
 	CleanupInterval    time.Duration `yaml:"cleanup_interval" mapstructure:"cleanup_interval"`
 	TypingIndicatorTTL time.Duration `yaml:"typing_indicator_ttl" mapstructure:"typing_indicator_ttl"`
+	CacheTTL           time.Duration `yaml:"cache_ttl" mapstructure:"cache_ttl"`
+}
+
+type KafkaConfig struct {
+	Enabled      bool          `yaml:"enabled" mapstructure:"enabled"`
+	Brokers      []string      `yaml:"brokers" mapstructure:"brokers"`
+	ClientID     string        `yaml:"client_id" mapstructure:"client_id"`
+	GroupID      string        `yaml:"group_id" mapstructure:"group_id"`
+	MessageTopic string        `yaml:"message_topic" mapstructure:"message_topic"`
+	MaxRetries   int           `yaml:"max_retries" mapstructure:"max_retries"`
+	RetryBackoff time.Duration `yaml:"retry_backoff" mapstructure:"retry_backoff"`
+	// DeadLetterEnabled moves messages that still fail after MaxRetries to
+	// <topic><DeadLetterSuffix> instead of redelivering them forever
+	DeadLetterEnabled bool   `yaml:"dead_letter_enabled" mapstructure:"dead_letter_enabled"`
+	DeadLetterSuffix  string `yaml:"dead_letter_suffix" mapstructure:"dead_letter_suffix"`
 }
 
 type LoggingConfig struct {
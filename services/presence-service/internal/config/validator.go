@@ -34,6 +34,10 @@ func ValidateAndSetDefaults(cfg *Config) error {
 		cfg.Server.ShutdownTimeout = 30 * time.Second
 	}
 
+	if cfg.Server.HealthCacheTTL <= 0 {
+		cfg.Server.HealthCacheTTL = 5 * time.Second
+	}
+
 	if cfg.Database.Postgres.Host == "" {
 		return errors.New("database host is required")
 	}
@@ -66,6 +70,34 @@ func ValidateAndSetDefaults(cfg *Config) error {
 		cfg.Presence.TypingIndicatorTTL = 10 * time.Second
 	}
 
+	if cfg.Presence.CacheTTL == 0 {
+		cfg.Presence.CacheTTL = 30 * time.Second
+	}
+
+	if cfg.Kafka.Enabled {
+		if len(cfg.Kafka.Brokers) == 0 {
+			return errors.New("kafka brokers are required when kafka is enabled")
+		}
+		if cfg.Kafka.ClientID == "" {
+			cfg.Kafka.ClientID = cfg.Service.Name
+		}
+		if cfg.Kafka.GroupID == "" {
+			cfg.Kafka.GroupID = "presence-service-group"
+		}
+		if cfg.Kafka.MessageTopic == "" {
+			cfg.Kafka.MessageTopic = "notifications"
+		}
+		if cfg.Kafka.MaxRetries == 0 {
+			cfg.Kafka.MaxRetries = 3
+		}
+		if cfg.Kafka.RetryBackoff == 0 {
+			cfg.Kafka.RetryBackoff = 200 * time.Millisecond
+		}
+		if cfg.Kafka.DeadLetterSuffix == "" {
+			cfg.Kafka.DeadLetterSuffix = ".dlq"
+		}
+	}
+
 	if cfg.Logging.Level == "" {
 		cfg.Logging.Level = "info"
 	}
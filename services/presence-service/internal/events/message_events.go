@@ -0,0 +1,72 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"presence-service/internal/model"
+	"presence-service/internal/service"
+
+	"github.com/google/uuid"
+
+	"shared/pkg/logger"
+	"shared/pkg/messaging"
+)
+
+const eventTypeNewMessage = "new_message"
+
+// messageEvent is the subset of the notification message-service publishes
+// for every delivered message that presence cares about.
+type messageEvent struct {
+	Type           string `json:"type"`
+	SenderID       string `json:"sender_id"`
+	ConversationID string `json:"conversation_id"`
+}
+
+// MessageEventHandler reacts to message-service events. Sending a message ends
+// the sender's typing state in that conversation, so the indicator is cleared
+// without waiting for its TTL.
+type MessageEventHandler struct {
+	presence service.PresenceService
+	log      logger.Logger
+}
+
+func NewMessageEventHandler(presence service.PresenceService, log logger.Logger) *MessageEventHandler {
+	return &MessageEventHandler{presence: presence, log: log}
+}
+
+// Handle returns an error only for failures worth retrying; malformed events
+// are logged and acknowledged so they do not block the partition.
+func (h *MessageEventHandler) Handle(ctx context.Context, msg *messaging.Message) error {
+	var event messageEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		h.log.Warn("Skipping malformed message event",
+			logger.String("topic", msg.Topic),
+			logger.Int64("offset", msg.Offset),
+			logger.Error(err),
+		)
+		return nil
+	}
+	if event.Type != eventTypeNewMessage {
+		return nil
+	}
+
+	senderID, err := uuid.Parse(event.SenderID)
+	if err != nil {
+		h.log.Warn("Skipping message event with invalid sender", logger.String("sender_id", event.SenderID))
+		return nil
+	}
+	conversationID, err := uuid.Parse(event.ConversationID)
+	if err != nil {
+		h.log.Warn("Skipping message event with invalid conversation", logger.String("conversation_id", event.ConversationID))
+		return nil
+	}
+
+	return h.presence.SetTypingIndicator(ctx, &model.TypingIndicator{
+		ConversationID: conversationID,
+		UserID:         senderID,
+		IsTyping:       false,
+		UpdatedAt:      time.Now(),
+	})
+}
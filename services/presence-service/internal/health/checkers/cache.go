@@ -19,6 +19,11 @@ func (c *CacheChecker) Name() string {
 	return "cache"
 }
 
+// IsCritical reports that the service keeps running, degraded, without its cache
+func (c *CacheChecker) IsCritical() bool {
+	return false
+}
+
 func (c *CacheChecker) Check(ctx context.Context) health.CheckResult {
 	if err := c.cache.Ping(ctx); err != nil {
 		return health.CheckResult{
@@ -31,9 +36,27 @@ func (c *CacheChecker) Check(ctx context.Context) health.CheckResult {
 		}
 	}
 
-	return health.CheckResult{
+	result := health.CheckResult{
 		Name:    c.Name(),
 		Status:  health.StatusHealthy,
 		Message: "Cache is responsive",
 	}
+
+	if info, err := c.cache.Info(ctx); err == nil {
+		if version := serverVersion(info); version != "" {
+			result.Metadata = map[string]interface{}{
+				"server_version": version,
+			}
+		}
+	}
+	return result
+}
+
+// serverVersion finds redis_version in INFO output, whose keys may be
+// prefixed with their section name
+func serverVersion(info map[string]string) string {
+	if v, ok := info["redis_version"]; ok {
+		return v
+	}
+	return info["Server.redis_version"]
 }
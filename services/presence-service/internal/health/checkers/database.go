@@ -19,6 +19,11 @@ func (c *DatabaseChecker) Name() string {
 	return "database"
 }
 
+// IsCritical reports that the service cannot serve requests without its database
+func (c *DatabaseChecker) IsCritical() bool {
+	return true
+}
+
 func (c *DatabaseChecker) Check(ctx context.Context) health.CheckResult {
 	if err := c.db.Ping(ctx); err != nil {
 		return health.CheckResult{
@@ -31,9 +36,17 @@ func (c *DatabaseChecker) Check(ctx context.Context) health.CheckResult {
 		}
 	}
 
-	return health.CheckResult{
+	result := health.CheckResult{
 		Name:    c.Name(),
 		Status:  health.StatusHealthy,
 		Message: "Database is responsive",
 	}
+
+	var version string
+	if err := c.db.QueryRow(ctx, "SHOW server_version").Scan(&version); err == nil {
+		result.Metadata = map[string]interface{}{
+			"server_version": version,
+		}
+	}
+	return result
 }
@@ -3,6 +3,9 @@ package health
 import (
 	"context"
 	"sync"
+	"time"
+
+	"shared/server/env"
 )
 
 type Status string
@@ -14,10 +17,15 @@ const (
 )
 
 type CheckResult struct {
-	Name    string                 `json:"name"`
-	Status  Status                 `json:"status"`
-	Message string                 `json:"message,omitempty"`
-	Details map[string]interface{} `json:"details,omitempty"`
+	Name        string                 `json:"name"`
+	Status      Status                 `json:"status"`
+	Message     string                 `json:"message,omitempty"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+	DurationMS  float64                `json:"duration_ms"`
+	LastChecked string                 `json:"last_checked,omitempty"`
+	// Metadata carries non-sensitive facts about the dependency, such as its
+	// server version
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type Checker interface {
@@ -25,11 +33,46 @@ type Checker interface {
 	Check(ctx context.Context) CheckResult
 }
 
+// CriticalityChecker is implemented by checkers that declare whether the
+// service can run without their dependency. Checkers that don't implement it
+// are treated as critical.
+type CriticalityChecker interface {
+	IsCritical() bool
+}
+
+func isCritical(checker Checker) bool {
+	if c, ok := checker.(CriticalityChecker); ok {
+		return c.IsCritical()
+	}
+	return true
+}
+
+// combineStatus folds one checker's status into the overall status: an
+// unhealthy critical checker makes the service unhealthy, while an unhealthy
+// optional checker or any degraded checker only degrades it
+func combineStatus(overall, status Status, critical bool) Status {
+	switch {
+	case overall == StatusUnhealthy || status == StatusHealthy:
+		return overall
+	case status == StatusUnhealthy && critical:
+		return StatusUnhealthy
+	default:
+		return StatusDegraded
+	}
+}
+
 type Manager struct {
 	serviceName    string
 	serviceVersion string
 	checkers       []Checker
 	mu             sync.RWMutex
+	cache          map[string]cachedResult
+	cacheTTL       time.Duration
+}
+
+type cachedResult struct {
+	result    CheckResult
+	timestamp time.Time
 }
 
 func NewManager(serviceName, serviceVersion string) *Manager {
@@ -37,9 +80,19 @@ func NewManager(serviceName, serviceVersion string) *Manager {
 		serviceName:    serviceName,
 		serviceVersion: serviceVersion,
 		checkers:       make([]Checker, 0),
+		cache:          make(map[string]cachedResult),
+		cacheTTL:       5 * time.Second,
 	}
 }
 
+// SetCacheTTL sets how long a check result is reused before the checker runs
+// again; zero runs every checker on every request
+func (m *Manager) SetCacheTTL(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheTTL = ttl
+}
+
 func (m *Manager) RegisterChecker(checker Checker) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -56,24 +109,45 @@ func (m *Manager) Check(ctx context.Context) map[string]interface{} {
 	overallStatus := StatusHealthy
 
 	for _, checker := range checkers {
-		result := checker.Check(ctx)
+		result := m.check(ctx, checker)
 		results = append(results, result)
 
-		if result.Status == StatusUnhealthy {
-			overallStatus = StatusUnhealthy
-		} else if result.Status == StatusDegraded && overallStatus == StatusHealthy {
-			overallStatus = StatusDegraded
-		}
+		overallStatus = combineStatus(overallStatus, result.Status, isCritical(checker))
 	}
 
 	return map[string]interface{}{
 		"service": m.serviceName,
 		"version": m.serviceVersion,
+		"build":   env.Build(m.serviceVersion),
 		"status":  overallStatus,
 		"checks":  results,
 	}
 }
 
+// check runs a checker, or returns its last result while that is younger
+// than the cache TTL
+func (m *Manager) check(ctx context.Context, checker Checker) CheckResult {
+	name := checker.Name()
+
+	m.mu.RLock()
+	cached, ok := m.cache[name]
+	ttl := m.cacheTTL
+	m.mu.RUnlock()
+	if ok && time.Since(cached.timestamp) < ttl {
+		return cached.result
+	}
+
+	start := time.Now()
+	result := checker.Check(ctx)
+	result.DurationMS = float64(time.Since(start).Microseconds()) / 1000.0
+	result.LastChecked = start.UTC().Format(time.RFC3339)
+
+	m.mu.Lock()
+	m.cache[name] = cachedResult{result: result, timestamp: start}
+	m.mu.Unlock()
+	return result
+}
+
 func (m *Manager) Liveness(ctx context.Context) map[string]interface{} {
 	return map[string]interface{}{
 		"service": m.serviceName,
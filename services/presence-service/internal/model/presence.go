@@ -3,6 +3,8 @@ package model
 import (
 	"time"
 
+	pkgPresence "shared/pkg/presence"
+
 	"github.com/google/uuid"
 )
 
@@ -12,7 +14,7 @@ type UserPresence struct {
 	OnlineStatus string     `json:"online_status"` // online, offline, away, busy, invisible
 	LastSeenAt   *time.Time `json:"last_seen_at"`
 	CustomStatus string     `json:"custom_status,omitempty"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
 }
 
 // Device represents a user's device information
@@ -58,6 +60,34 @@ type PresencePrivacy struct {
 	ReadReceiptsEnabled     bool      `json:"read_receipts_enabled"`
 }
 
+// MaxBulkPresenceUsers caps how many users one bulk presence request may ask about
+const MaxBulkPresenceUsers = 200
+
+// Presence visibility levels for last-seen and online status
+const (
+	VisibilityEveryone = pkgPresence.VisibilityEveryone
+	VisibilityContacts = pkgPresence.VisibilityContacts
+	VisibilityNobody   = pkgPresence.VisibilityNobody
+)
+
+// DefaultPresencePrivacy is what applies to a user who never changed their settings
+func DefaultPresencePrivacy(userID uuid.UUID) *PresencePrivacy {
+	return &PresencePrivacy{
+		UserID:                  userID,
+		LastSeenVisibility:      VisibilityEveryone,
+		OnlineStatusVisibility:  VisibilityEveryone,
+		TypingIndicatorsEnabled: true,
+		ReadReceiptsEnabled:     true,
+	}
+}
+
+// PrivacyUpdate changes who can see a user's presence; nil fields are left as they are
+type PrivacyUpdate struct {
+	UserID                 uuid.UUID `json:"-"`
+	LastSeenVisibility     *string   `json:"last_seen_visibility,omitempty"`
+	OnlineStatusVisibility *string   `json:"online_status_visibility,omitempty"`
+}
+
 // BulkPresenceRequest represents a request for multiple users' presence
 type BulkPresenceRequest struct {
 	UserIDs []uuid.UUID `json:"user_ids"`
@@ -11,6 +11,7 @@ import (
 	pkgErrors "shared/pkg/errors"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type PresenceRepository interface {
@@ -23,6 +24,9 @@ type PresenceRepository interface {
 	SetTypingIndicator(ctx context.Context, indicator *model.TypingIndicator) pkgErrors.AppError
 	GetTypingIndicators(ctx context.Context, conversationID uuid.UUID) ([]*model.TypingIndicator, pkgErrors.AppError)
 	GetPrivacySettings(ctx context.Context, userID uuid.UUID) (*model.PresencePrivacy, pkgErrors.AppError)
+	GetBulkPrivacySettings(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*model.PresencePrivacy, pkgErrors.AppError)
+	UpdatePrivacySettings(ctx context.Context, update *model.PrivacyUpdate) (*model.PresencePrivacy, pkgErrors.AppError)
+	GetUsersWithContact(ctx context.Context, userIDs []uuid.UUID, contactID uuid.UUID) (map[uuid.UUID]bool, pkgErrors.AppError)
 }
 
 type presenceRepo struct {
@@ -123,7 +127,7 @@ func (r *presenceRepo) GetBulkPresence(ctx context.Context, userIDs []uuid.UUID)
 		WHERE user_id = ANY($1)
 	`
 
-	rows, err := r.db.Query(ctx, query, userIDs)
+	rows, err := r.db.Query(ctx, query, pq.Array(userIDs))
 	if err != nil {
 		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to get bulk presence")
 	}
@@ -243,9 +247,37 @@ func (r *presenceRepo) SetTypingIndicator(ctx context.Context, indicator *model.
 	return nil
 }
 
+// GetTypingIndicators returns the users typing in a conversation whose
+// indicator has not yet expired. ws-service writes these rows as clients send
+// typing.start and typing.stop.
 func (r *presenceRepo) GetTypingIndicators(ctx context.Context, conversationID uuid.UUID) ([]*model.TypingIndicator, pkgErrors.AppError) {
-	// TODO: Implement with Redis or dedicated table
-	return []*model.TypingIndicator{}, nil
+	query := `
+		SELECT conversation_id, user_id, started_at
+		FROM messages.typing_indicators
+		WHERE conversation_id = $1
+		  AND expires_at > NOW()
+		ORDER BY started_at
+	`
+
+	rows, err := r.db.Query(ctx, query, conversationID)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to get typing indicators")
+	}
+	defer rows.Close()
+
+	indicators := []*model.TypingIndicator{}
+	for rows.Next() {
+		indicator := &model.TypingIndicator{IsTyping: true}
+		if err := rows.Scan(&indicator.ConversationID, &indicator.UserID, &indicator.UpdatedAt); err != nil {
+			return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan typing indicator")
+		}
+		indicators = append(indicators, indicator)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to read typing indicators")
+	}
+
+	return indicators, nil
 }
 
 func (r *presenceRepo) GetPrivacySettings(ctx context.Context, userID uuid.UUID) (*model.PresencePrivacy, pkgErrors.AppError) {
@@ -271,3 +303,104 @@ func (r *presenceRepo) GetPrivacySettings(ctx context.Context, userID uuid.UUID)
 
 	return &privacy, nil
 }
+
+// GetBulkPrivacySettings loads the settings of several users at once; users
+// without a settings row are absent from the result
+func (r *presenceRepo) GetBulkPrivacySettings(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*model.PresencePrivacy, pkgErrors.AppError) {
+	settings := make(map[uuid.UUID]*model.PresencePrivacy)
+	if len(userIDs) == 0 {
+		return settings, nil
+	}
+
+	query := `
+		SELECT user_id, last_seen_visibility, online_status_visibility,
+		       typing_indicators_enabled, read_receipts_enabled
+		FROM users.settings
+		WHERE user_id = ANY($1)
+	`
+
+	rows, err := r.db.Query(ctx, query, pq.Array(userIDs))
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to get bulk privacy settings")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var privacy model.PresencePrivacy
+		if err := rows.Scan(
+			&privacy.UserID,
+			&privacy.LastSeenVisibility,
+			&privacy.OnlineStatusVisibility,
+			&privacy.TypingIndicatorsEnabled,
+			&privacy.ReadReceiptsEnabled,
+		); err != nil {
+			return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan privacy settings")
+		}
+		settings[privacy.UserID] = &privacy
+	}
+
+	return settings, nil
+}
+
+// UpdatePrivacySettings changes the presence visibility of a user, creating
+// their settings row on first use
+func (r *presenceRepo) UpdatePrivacySettings(ctx context.Context, update *model.PrivacyUpdate) (*model.PresencePrivacy, pkgErrors.AppError) {
+	query := `
+		INSERT INTO users.settings (user_id, last_seen_visibility, online_status_visibility)
+		VALUES ($1, COALESCE($2, 'everyone'), COALESCE($3, 'everyone'))
+		ON CONFLICT (user_id) DO UPDATE
+		SET last_seen_visibility = COALESCE($2, users.settings.last_seen_visibility),
+		    online_status_visibility = COALESCE($3, users.settings.online_status_visibility),
+		    updated_at = NOW()
+		RETURNING user_id, last_seen_visibility, online_status_visibility,
+		          typing_indicators_enabled, read_receipts_enabled
+	`
+
+	var privacy model.PresencePrivacy
+	err := r.db.QueryRow(ctx, query, update.UserID, update.LastSeenVisibility, update.OnlineStatusVisibility).Scan(
+		&privacy.UserID,
+		&privacy.LastSeenVisibility,
+		&privacy.OnlineStatusVisibility,
+		&privacy.TypingIndicatorsEnabled,
+		&privacy.ReadReceiptsEnabled,
+	)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to update privacy settings").
+			WithDetail("user_id", update.UserID.String())
+	}
+
+	return &privacy, nil
+}
+
+// GetUsersWithContact returns which of userIDs have contactID as an accepted contact
+func (r *presenceRepo) GetUsersWithContact(ctx context.Context, userIDs []uuid.UUID, contactID uuid.UUID) (map[uuid.UUID]bool, pkgErrors.AppError) {
+	owners := make(map[uuid.UUID]bool)
+	if len(userIDs) == 0 {
+		return owners, nil
+	}
+
+	query := `
+		SELECT user_id
+		FROM users.contacts
+		WHERE user_id = ANY($1)
+		  AND contact_user_id = $2
+		  AND status = 'accepted'
+		  AND relationship_type != 'blocked'
+	`
+
+	rows, err := r.db.Query(ctx, query, pq.Array(userIDs), contactID)
+	if err != nil {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to check contacts")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, pkgErrors.FromError(err, pkgErrors.CodeDatabaseError, "failed to scan contact")
+		}
+		owners[userID] = true
+	}
+
+	return owners, nil
+}
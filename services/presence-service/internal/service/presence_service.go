@@ -2,12 +2,17 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"presence-service/internal/errors"
 	"presence-service/internal/model"
 	"presence-service/internal/repo"
+	"time"
 
 	"shared/pkg/cache"
+	pkgErrors "shared/pkg/errors"
 	"shared/pkg/logger"
+	pkgPresence "shared/pkg/presence"
 
 	"github.com/google/uuid"
 )
@@ -17,6 +22,7 @@ type PresenceService interface {
 	UpdatePresence(ctx context.Context, update *model.PresenceUpdate) (*model.UserPresence, error)
 	GetPresence(ctx context.Context, userID uuid.UUID, requesterID uuid.UUID) (*model.UserPresence, error)
 	GetBulkPresence(ctx context.Context, userIDs []uuid.UUID, requesterID uuid.UUID) (map[uuid.UUID]*model.UserPresence, error)
+	UpdatePrivacy(ctx context.Context, update *model.PrivacyUpdate) (*model.PresencePrivacy, error)
 	Heartbeat(ctx context.Context, userID uuid.UUID, deviceID string) error
 	GetActiveDevices(ctx context.Context, userID uuid.UUID) ([]*model.Device, error)
 
@@ -26,16 +32,18 @@ type PresenceService interface {
 }
 
 type presenceService struct {
-	repo  repo.PresenceRepository
-	cache cache.Cache
-	log   logger.Logger
+	repo     repo.PresenceRepository
+	cache    cache.Cache
+	cacheTTL time.Duration
+	log      logger.Logger
 }
 
-func NewPresenceService(repo repo.PresenceRepository, cache cache.Cache, log logger.Logger) PresenceService {
+func NewPresenceService(repo repo.PresenceRepository, cache cache.Cache, cacheTTL time.Duration, log logger.Logger) PresenceService {
 	return &presenceService{
-		repo:  repo,
-		cache: cache,
-		log:   log,
+		repo:     repo,
+		cache:    cache,
+		cacheTTL: cacheTTL,
+		log:      log,
 	}
 }
 
@@ -57,8 +65,7 @@ func (s *presenceService) UpdatePresence(ctx context.Context, update *model.Pres
 	}
 
 	if s.cache != nil {
-		cacheKey := fmt.Sprintf("presence:%s", update.UserID.String())
-		_ = s.cache.Delete(ctx, cacheKey)
+		_ = s.cache.Delete(ctx, presenceCacheKey(update.UserID))
 	}
 
 	return presence, nil
@@ -70,35 +77,234 @@ func (s *presenceService) GetPresence(ctx context.Context, userID uuid.UUID, req
 		return nil, err
 	}
 
-	privacy, err := s.repo.GetPrivacySettings(ctx, userID)
-	if err != nil {
-		s.log.Warn("Failed to get privacy settings", logger.Error(err))
-	} else {
-		presence = s.applyPrivacyFilters(presence, privacy, requesterID, userID)
+	presences := map[uuid.UUID]*model.UserPresence{userID: presence}
+	if err := s.filterPresences(ctx, presences, requesterID); err != nil {
+		return nil, err
 	}
 
-	return presence, nil
+	return presences[userID], nil
 }
 
+// GetBulkPresence returns the presence of every requested user as the
+// requester may see it. Cached entries are read in one round-trip and only
+// the misses go to the database; users with no profile come back offline.
 func (s *presenceService) GetBulkPresence(ctx context.Context, userIDs []uuid.UUID, requesterID uuid.UUID) (map[uuid.UUID]*model.UserPresence, error) {
-	presences, err := s.repo.GetBulkPresence(ctx, userIDs)
-	if err != nil {
+	presences := s.getCachedPresences(ctx, userIDs)
+
+	missing := make([]uuid.UUID, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if _, ok := presences[userID]; !ok {
+			missing = append(missing, userID)
+		}
+	}
+
+	if len(missing) > 0 {
+		loaded, err := s.repo.GetBulkPresence(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		s.cachePresences(ctx, loaded)
+		for userID, presence := range loaded {
+			presences[userID] = presence
+		}
+	}
+
+	if err := s.filterPresences(ctx, presences, requesterID); err != nil {
 		return nil, err
 	}
 
+	for _, userID := range userIDs {
+		if _, ok := presences[userID]; !ok {
+			presences[userID] = &model.UserPresence{UserID: userID, OnlineStatus: "offline"}
+		}
+	}
+
+	return presences, nil
+}
+
+// UpdatePrivacy changes who may see the user's last-seen and online status
+func (s *presenceService) UpdatePrivacy(ctx context.Context, update *model.PrivacyUpdate) (*model.PresencePrivacy, error) {
+	if update.LastSeenVisibility == nil && update.OnlineStatusVisibility == nil {
+		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "no privacy setting to update").
+			WithService(errors.ServiceName)
+	}
+	for _, visibility := range []*string{update.LastSeenVisibility, update.OnlineStatusVisibility} {
+		if visibility != nil && !validVisibility[*visibility] {
+			return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "visibility must be everyone, contacts or nobody").
+				WithService(errors.ServiceName).
+				WithDetail("visibility", *visibility)
+		}
+	}
+
+	privacy, err := s.repo.UpdatePrivacySettings(ctx, update)
+	if err != nil {
+		return nil, err.WithService(errors.ServiceName)
+	}
+
+	if s.cache != nil {
+		_ = s.cache.Delete(ctx, privacyCacheKey(update.UserID))
+	}
+
+	return privacy, nil
+}
+
+var validVisibility = map[string]bool{
+	model.VisibilityEveryone: true,
+	model.VisibilityContacts: true,
+	model.VisibilityNobody:   true,
+}
+
+// filterPresences applies each user's privacy settings to what the requester
+// sees, in place. Settings that cannot be loaded fail the request rather than
+// exposing presence the user chose to hide.
+func (s *presenceService) filterPresences(ctx context.Context, presences map[uuid.UUID]*model.UserPresence, requesterID uuid.UUID) error {
+	targets := make([]uuid.UUID, 0, len(presences))
+	for userID := range presences {
+		if userID != requesterID {
+			targets = append(targets, userID)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	settings, err := s.getPrivacySettings(ctx, targets)
+	if err != nil {
+		return err
+	}
+
+	contactsOnly := make([]uuid.UUID, 0)
+	for _, userID := range targets {
+		privacy := settings[userID]
+		if privacy.LastSeenVisibility == model.VisibilityContacts || privacy.OnlineStatusVisibility == model.VisibilityContacts {
+			contactsOnly = append(contactsOnly, userID)
+		}
+	}
+
+	contacts := make(map[uuid.UUID]bool)
+	if len(contactsOnly) > 0 {
+		var appErr pkgErrors.AppError
+		contacts, appErr = s.repo.GetUsersWithContact(ctx, contactsOnly, requesterID)
+		if appErr != nil {
+			return appErr.WithService(errors.ServiceName)
+		}
+	}
+
+	for _, userID := range targets {
+		presences[userID] = applyPrivacyFilters(presences[userID], settings[userID], contacts[userID])
+	}
+	return nil
+}
+
+// getPrivacySettings returns the settings of every user, cache first, with
+// defaults for users who never saved any
+func (s *presenceService) getPrivacySettings(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*model.PresencePrivacy, error) {
+	settings := make(map[uuid.UUID]*model.PresencePrivacy, len(userIDs))
+	missing := userIDs
+
+	if s.cache != nil {
+		keys := make([]string, len(userIDs))
+		for i, userID := range userIDs {
+			keys[i] = privacyCacheKey(userID)
+		}
+
+		values, err := s.cache.MGet(ctx, keys...)
+		if err != nil {
+			s.log.Warn("Failed to read cached privacy settings", logger.Error(err))
+		} else {
+			missing = make([]uuid.UUID, 0, len(userIDs))
+			for i, value := range values {
+				var privacy model.PresencePrivacy
+				if value == nil || json.Unmarshal(value, &privacy) != nil {
+					missing = append(missing, userIDs[i])
+					continue
+				}
+				settings[userIDs[i]] = &privacy
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return settings, nil
+	}
+
+	loaded, err := s.repo.GetBulkPrivacySettings(ctx, missing)
+	if err != nil {
+		return nil, err.WithService(errors.ServiceName)
+	}
+
+	pairs := make(map[string][]byte, len(missing))
+	for _, userID := range missing {
+		privacy, ok := loaded[userID]
+		if !ok {
+			privacy = model.DefaultPresencePrivacy(userID)
+		}
+		settings[userID] = privacy
+		if data, err := json.Marshal(privacy); err == nil {
+			pairs[privacyCacheKey(userID)] = data
+		}
+	}
+
+	if s.cache != nil {
+		if err := s.cache.MSet(ctx, pairs, s.cacheTTL); err != nil {
+			s.log.Warn("Failed to cache privacy settings", logger.Error(err))
+		}
+	}
+
+	return settings, nil
+}
+
+// getCachedPresences reads the cached presence of each user with a single
+// MGET. A cache failure only means every user is loaded from the database.
+func (s *presenceService) getCachedPresences(ctx context.Context, userIDs []uuid.UUID) map[uuid.UUID]*model.UserPresence {
+	presences := make(map[uuid.UUID]*model.UserPresence, len(userIDs))
+	if s.cache == nil || len(userIDs) == 0 {
+		return presences
+	}
+
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = presenceCacheKey(userID)
+	}
+
+	values, err := s.cache.MGet(ctx, keys...)
+	if err != nil {
+		s.log.Warn("Failed to read cached presence", logger.Error(err))
+		return presences
+	}
+
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		var presence model.UserPresence
+		if err := json.Unmarshal(value, &presence); err != nil {
+			continue
+		}
+		presences[userIDs[i]] = &presence
+	}
+	return presences
+}
+
+// cachePresences stores unfiltered presences; privacy is applied per
+// requester on the way out
+func (s *presenceService) cachePresences(ctx context.Context, presences map[uuid.UUID]*model.UserPresence) {
+	if s.cache == nil || len(presences) == 0 {
+		return
+	}
+
+	pairs := make(map[string][]byte, len(presences))
 	for userID, presence := range presences {
-		privacy, err := s.repo.GetPrivacySettings(ctx, userID)
+		data, err := json.Marshal(presence)
 		if err != nil {
-			s.log.Warn("Failed to get privacy settings for user",
-				logger.String("user_id", userID.String()),
-				logger.Error(err),
-			)
 			continue
 		}
-		presences[userID] = s.applyPrivacyFilters(presence, privacy, requesterID, userID)
+		pairs[presenceCacheKey(userID)] = data
 	}
 
-	return presences, nil
+	if err := s.cache.MSet(ctx, pairs, s.cacheTTL); err != nil {
+		s.log.Warn("Failed to cache presence", logger.Error(err))
+	}
 }
 
 func (s *presenceService) Heartbeat(ctx context.Context, userID uuid.UUID, deviceID string) error {
@@ -107,8 +313,7 @@ func (s *presenceService) Heartbeat(ctx context.Context, userID uuid.UUID, devic
 	}
 
 	if s.cache != nil {
-		cacheKey := fmt.Sprintf("presence:%s", userID.String())
-		_ = s.cache.Delete(ctx, cacheKey)
+		_ = s.cache.Delete(ctx, presenceCacheKey(userID))
 	}
 
 	return nil
@@ -135,31 +340,31 @@ func (s *presenceService) GetTypingIndicators(ctx context.Context, conversationI
 	return s.repo.GetTypingIndicators(ctx, conversationID)
 }
 
-func (s *presenceService) applyPrivacyFilters(
-	presence *model.UserPresence,
-	privacy *model.PresencePrivacy,
-	requesterID uuid.UUID,
-	targetUserID uuid.UUID,
-) *model.UserPresence {
-	if requesterID == targetUserID {
-		return presence
-	}
-
+// applyPrivacyFilters returns the presence as someone other than its owner
+// may see it. Hidden last-seen drops every timestamp and coarsens the status
+// to online/offline; a hidden or invisible status reads as offline.
+func applyPrivacyFilters(presence *model.UserPresence, privacy *model.PresencePrivacy, isContact bool) *model.UserPresence {
 	filtered := *presence
 
-	switch privacy.LastSeenVisibility {
-	case "nobody":
+	if !pkgPresence.VisibleTo(privacy.LastSeenVisibility, isContact) {
 		filtered.LastSeenAt = nil
-	case "contacts":
-		// TODO: Check if requester is in target's contacts
+		filtered.UpdatedAt = nil
+		if filtered.OnlineStatus != "offline" && filtered.OnlineStatus != "invisible" {
+			filtered.OnlineStatus = "online"
+		}
 	}
 
-	switch privacy.OnlineStatusVisibility {
-	case "nobody":
+	if filtered.OnlineStatus == "invisible" || !pkgPresence.VisibleTo(privacy.OnlineStatusVisibility, isContact) {
 		filtered.OnlineStatus = "offline"
-	case "contacts":
-		// TODO: Check if requester is in target's contacts
 	}
 
 	return &filtered
 }
+
+func presenceCacheKey(userID uuid.UUID) string {
+	return fmt.Sprintf("presence:%s", userID.String())
+}
+
+func privacyCacheKey(userID uuid.UUID) string {
+	return fmt.Sprintf("presence:privacy:%s", userID.String())
+}
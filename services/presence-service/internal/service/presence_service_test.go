@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"presence-service/internal/model"
+	"presence-service/internal/repo"
+
+	"shared/pkg/cache"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+type fakeCache struct {
+	cache.Cache
+	values map[string][]byte
+}
+
+func (c *fakeCache) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = c.values[key]
+	}
+	return values, nil
+}
+
+func (c *fakeCache) MSet(ctx context.Context, pairs map[string][]byte, ttl time.Duration) pkgErrors.AppError {
+	for key, value := range pairs {
+		c.values[key] = value
+	}
+	return nil
+}
+
+type fakePresenceRepo struct {
+	repo.PresenceRepository
+	presences map[uuid.UUID]*model.UserPresence
+	privacy   map[uuid.UUID]*model.PresencePrivacy
+	contacts  map[uuid.UUID]uuid.UUID
+	loaded    [][]uuid.UUID
+}
+
+func (r *fakePresenceRepo) GetPresence(ctx context.Context, userID uuid.UUID) (*model.UserPresence, pkgErrors.AppError) {
+	return r.presences[userID], nil
+}
+
+func (r *fakePresenceRepo) GetBulkPresence(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*model.UserPresence, pkgErrors.AppError) {
+	r.loaded = append(r.loaded, userIDs)
+	found := make(map[uuid.UUID]*model.UserPresence)
+	for _, userID := range userIDs {
+		if presence, ok := r.presences[userID]; ok {
+			found[userID] = presence
+		}
+	}
+	return found, nil
+}
+
+func (r *fakePresenceRepo) GetBulkPrivacySettings(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*model.PresencePrivacy, pkgErrors.AppError) {
+	return r.privacy, nil
+}
+
+func (r *fakePresenceRepo) GetUsersWithContact(ctx context.Context, userIDs []uuid.UUID, contactID uuid.UUID) (map[uuid.UUID]bool, pkgErrors.AppError) {
+	owners := make(map[uuid.UUID]bool)
+	for _, userID := range userIDs {
+		owners[userID] = r.contacts[userID] == contactID
+	}
+	return owners, nil
+}
+
+func TestGetPresence_ContactsOnlyLastSeen(t *testing.T) {
+	seen := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	target, friend := uuid.New(), uuid.New()
+
+	r := &fakePresenceRepo{
+		presences: map[uuid.UUID]*model.UserPresence{
+			target: {UserID: target, OnlineStatus: "away", LastSeenAt: &seen, UpdatedAt: &seen},
+		},
+		privacy: map[uuid.UUID]*model.PresencePrivacy{
+			target: {UserID: target, LastSeenVisibility: model.VisibilityContacts, OnlineStatusVisibility: model.VisibilityEveryone},
+		},
+		contacts: map[uuid.UUID]uuid.UUID{target: friend},
+	}
+	svc := NewPresenceService(r, nil, time.Minute, logger.NewNoop())
+
+	visible, err := svc.GetPresence(context.Background(), target, friend)
+	if err != nil {
+		t.Fatalf("GetPresence returned error: %v", err)
+	}
+	if visible.LastSeenAt == nil || visible.OnlineStatus != "away" {
+		t.Fatalf("expected a contact to see last-seen, got %+v", visible)
+	}
+
+	hidden, err := svc.GetPresence(context.Background(), target, uuid.New())
+	if err != nil {
+		t.Fatalf("GetPresence returned error: %v", err)
+	}
+	if hidden.LastSeenAt != nil || hidden.UpdatedAt != nil || hidden.OnlineStatus != "online" {
+		t.Fatalf("expected a stranger to see only a coarse status, got %+v", hidden)
+	}
+}
+
+func TestGetBulkPresence_LoadsOnlyCacheMisses(t *testing.T) {
+	seen := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	online, private, unknown := uuid.New(), uuid.New(), uuid.New()
+
+	r := &fakePresenceRepo{
+		presences: map[uuid.UUID]*model.UserPresence{
+			online:  {UserID: online, OnlineStatus: "online", LastSeenAt: &seen},
+			private: {UserID: private, OnlineStatus: "online", LastSeenAt: &seen},
+		},
+		privacy: map[uuid.UUID]*model.PresencePrivacy{
+			private: {UserID: private, LastSeenVisibility: "nobody", OnlineStatusVisibility: "nobody"},
+		},
+	}
+	c := &fakeCache{values: make(map[string][]byte)}
+	svc := NewPresenceService(r, c, time.Minute, logger.NewNoop())
+
+	ids := []uuid.UUID{online, private, unknown}
+	presences, err := svc.GetBulkPresence(context.Background(), ids, uuid.New())
+	if err != nil {
+		t.Fatalf("GetBulkPresence returned error: %v", err)
+	}
+
+	if presences[online].OnlineStatus != "online" || presences[online].LastSeenAt == nil {
+		t.Fatalf("expected visible presence, got %+v", presences[online])
+	}
+	if presences[private].OnlineStatus != "offline" || presences[private].LastSeenAt != nil {
+		t.Fatalf("expected privacy to hide presence, got %+v", presences[private])
+	}
+	if presences[unknown] == nil || presences[unknown].OnlineStatus != "offline" {
+		t.Fatalf("expected unknown user to be offline, got %+v", presences[unknown])
+	}
+
+	if _, err := svc.GetBulkPresence(context.Background(), ids, uuid.New()); err != nil {
+		t.Fatalf("second GetBulkPresence returned error: %v", err)
+	}
+	if len(r.loaded) != 2 || len(r.loaded[1]) != 1 || r.loaded[1][0] != unknown {
+		t.Fatalf("expected the second call to load only the uncached user, got %v", r.loaded)
+	}
+}
@@ -1,20 +1,131 @@
 package dto
 
-import "time"
+import (
+	"encoding/json"
+	"sort"
+	"time"
 
-// UpdateProfileRequest represents a request to update a user profile
+	"shared/server/request"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// immutableProfileFields can't be changed through a profile update
+var immutableProfileFields = map[string]bool{
+	"id":          true,
+	"user_id":     true,
+	"is_verified": true,
+	"created_at":  true,
+	"updated_at":  true,
+}
+
+// UpdateProfileRequest represents a partial profile update; nil fields are
+// left unchanged and empty strings clear the field
 type UpdateProfileRequest struct {
 	Username     *string `json:"username,omitempty" validate:"omitempty,min=3,max=30,alphanum"`
-	DisplayName  *string `json:"display_name,omitempty" validate:"omitempty,max=100"`
-	FirstName    *string `json:"first_name,omitempty" validate:"omitempty,max=50"`
-	LastName     *string `json:"last_name,omitempty" validate:"omitempty,max=50"`
-	Bio          *string `json:"bio,omitempty" validate:"omitempty,max=500"`
+	DisplayName  *string `json:"display_name,omitempty" validate:"omitempty,max=50"`
+	FirstName    *string `json:"first_name,omitempty" validate:"omitempty,max=30"`
+	LastName     *string `json:"last_name,omitempty" validate:"omitempty,max=30"`
+	Bio          *string `json:"bio,omitempty" validate:"omitempty,max=160"`
 	AvatarURL    *string `json:"avatar_url,omitempty" validate:"omitempty,url"`
 	LanguageCode *string `json:"language_code,omitempty" validate:"omitempty,len=2"`
 	Timezone     *string `json:"timezone,omitempty"`
 	CountryCode  *string `json:"country_code,omitempty" validate:"omitempty,len=2"`
 }
 
+func NewUpdateProfileRequest() *UpdateProfileRequest {
+	return &UpdateProfileRequest{}
+}
+
+// ImmutableFields lists the immutable fields present in a raw request body
+func ImmutableFields(body []byte) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+
+	var found []string
+	for field := range fields {
+		if immutableProfileFields[field] {
+			found = append(found, field)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+// IsEmpty reports whether the request changes nothing
+func (upr *UpdateProfileRequest) IsEmpty() bool {
+	return upr.Username == nil && upr.DisplayName == nil && upr.FirstName == nil &&
+		upr.LastName == nil && upr.Bio == nil && upr.AvatarURL == nil &&
+		upr.LanguageCode == nil && upr.Timezone == nil && upr.CountryCode == nil
+}
+
+func (upr *UpdateProfileRequest) GetValue() interface{} {
+	return upr
+}
+
+func (upr *UpdateProfileRequest) ValidateErrors(ve validator.ValidationErrors) ([]request.ValidationErrorDetail, error) {
+	var errors []request.ValidationErrorDetail
+	for _, err := range ve {
+		switch err.Field() {
+		case "Username":
+			if err.Tag() == "alphanum" {
+				errors = append(errors, request.ValidationErrorDetail{
+					Msg:  "Username may only contain letters and numbers",
+					Code: request.INVALID_FORMAT,
+				})
+			} else {
+				errors = append(errors, request.ValidationErrorDetail{
+					Msg:  "Username must be between 3 and 30 characters long",
+					Code: request.INVALID_FORMAT,
+				})
+			}
+		case "DisplayName":
+			errors = append(errors, request.ValidationErrorDetail{
+				Msg:  "Display name must be at most 50 characters long",
+				Code: request.INVALID_FORMAT,
+			})
+		case "FirstName":
+			errors = append(errors, request.ValidationErrorDetail{
+				Msg:  "First name must be at most 30 characters long",
+				Code: request.INVALID_FORMAT,
+			})
+		case "LastName":
+			errors = append(errors, request.ValidationErrorDetail{
+				Msg:  "Last name must be at most 30 characters long",
+				Code: request.INVALID_FORMAT,
+			})
+		case "Bio":
+			errors = append(errors, request.ValidationErrorDetail{
+				Msg:  "Bio must be at most 160 characters long",
+				Code: request.INVALID_FORMAT,
+			})
+		case "AvatarURL":
+			errors = append(errors, request.ValidationErrorDetail{
+				Msg:  "Avatar URL must be a valid URL",
+				Code: request.INVALID_FORMAT,
+			})
+		case "LanguageCode":
+			errors = append(errors, request.ValidationErrorDetail{
+				Msg:  "Language code must be 2 characters long",
+				Code: request.INVALID_FORMAT,
+			})
+		case "CountryCode":
+			errors = append(errors, request.ValidationErrorDetail{
+				Msg:  "Country code must be 2 characters long",
+				Code: request.INVALID_FORMAT,
+			})
+		default:
+			errors = append(errors, request.ValidationErrorDetail{
+				Msg:  err.Error(),
+				Code: request.INVALID_FORMAT,
+			})
+		}
+	}
+	return errors, nil
+}
+
 // UpdateProfileResponse represents the response for updating a user profile
 type UpdateProfileResponse struct {
 	ID           string    `json:"id"`
@@ -18,7 +18,7 @@ func (h *UserHandler) CreateProfile(w http.ResponseWriter, r *http.Request) {
 
 	userId, _ := request.GetUserIDFromContext(r.Context())
 	h.log.Debug("creating profile", logger.String("user_id", userId))
-	location, err := h.locationService.Lookup(handler.GetClientIP())
+	location, err := h.locationService.Lookup(r.Context(), handler.GetClientIP())
 	if err != nil {
 		h.log.Error("failed to lookup location", logger.Error(err))
 		response.InternalServerError(r.Context(), r, w, "Failed to lookup location", err)
@@ -58,6 +58,9 @@ func (h *UserHandler) CreateProfile(w http.ResponseWriter, r *http.Request) {
 	})
 	if err != nil {
 		h.log.Error("failed to create profile", logger.String("user_id", userId), logger.Error(err))
+		if writeDBError(w, r, err) {
+			return
+		}
 		response.InternalServerError(r.Context(), r, w, "Failed to create profile", err)
 		return
 	}
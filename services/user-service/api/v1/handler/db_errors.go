@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"shared/pkg/database"
+	"shared/server/response"
+)
+
+// writeDBError answers a database failure with response.FromDBError, so a
+// duplicate username is a 409 and a dangling reference a 422 just as in every
+// other service. It reports false when err carries no database error so the
+// caller can answer it instead.
+func writeDBError(w http.ResponseWriter, r *http.Request, err error) bool {
+	var dbErr *database.DBError
+	if !errors.As(err, &dbErr) {
+		return false
+	}
+	response.FromDBError(r.Context(), r, w, dbErr)
+	return true
+}
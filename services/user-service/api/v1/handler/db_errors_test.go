@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shared/pkg/database"
+)
+
+func TestWriteDBError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "duplicate key",
+			err:        database.NewDBError(database.CodeDBDuplicateKey, "Duplicate key violation").WithConstraint("profiles_username_key"),
+			wantStatus: http.StatusConflict,
+			wantBody:   "profiles_username_key",
+		},
+		{
+			name:       "foreign key",
+			err:        database.NewDBError(database.CodeDBForeignKey, "Foreign key constraint violation").WithConstraint("profiles_user_id_fkey"),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   "profiles_user_id_fkey",
+		},
+		{
+			name:       "connection failure",
+			err:        database.NewDBError(database.CodeDBConnection, "Connection failed").WithQuery("INSERT INTO users.profiles"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Database operation failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/profile", nil)
+
+			if !writeDBError(rec, req, tt.err) {
+				t.Fatalf("writeDBError() = false, want true")
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Fatalf("body %s does not name %s", rec.Body.String(), tt.wantBody)
+			}
+			if strings.Contains(rec.Body.String(), "INSERT INTO") {
+				t.Fatalf("body %s leaks the query", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestWriteDBErrorIgnoresOtherErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/profile", nil)
+
+	if err := errors.New("boom"); writeDBError(rec, req, err) {
+		t.Fatalf("writeDBError(%v) = true, want false", err)
+	}
+	if rec.Body.Len() != 0 {
+		t.Fatalf("nothing should be written, got %s", rec.Body.String())
+	}
+}
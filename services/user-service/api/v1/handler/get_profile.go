@@ -31,7 +31,10 @@ func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 			logger.String("user_id", userID),
 			logger.Error(err),
 		)
-		response.BadRequestError(ctx, r, w, "Failed to get user profile", err)
+		if writeDBError(w, r, err) {
+			return
+		}
+		response.InternalServerError(ctx, r, w, "Failed to get user profile", err)
 		return
 	}
 
@@ -12,6 +12,7 @@ type UserHandlerInterface interface {
 	// Profile endpoints
 	GetProfile(w http.ResponseWriter, r *http.Request)
 	CreateProfile(w http.ResponseWriter, r *http.Request)
+	UpdateProfile(w http.ResponseWriter, r *http.Request)
 }
 
 // Compile-time interface compliance check
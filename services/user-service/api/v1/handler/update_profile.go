@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"shared/pkg/logger"
+	"shared/server/request"
+	"shared/server/response"
+	"user-service/api/v1/dto"
+	"user-service/internal/model"
+)
+
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	handler := request.NewHandler(r, w)
+
+	userID := handler.PathParam("user_id")
+	if userID == "" {
+		response.BadRequestError(ctx, r, w, "User ID is required", errors.New("user_id path parameter is missing"))
+		return
+	}
+
+	callerID, _ := request.GetUserIDFromContext(ctx)
+	if callerID != userID {
+		h.log.Warn("Rejected profile update for another user",
+			logger.String("user_id", userID),
+			logger.String("caller_id", callerID),
+		)
+		response.ForbiddenError(ctx, r, w, "You can only update your own profile", fmt.Errorf("user %s may not update profile %s", callerID, userID))
+		return
+	}
+
+	if r.Body != nil {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, request.DefaultMaxBodySize))
+		if err != nil {
+			response.BadRequestError(ctx, r, w, "Failed to read request body", err)
+			return
+		}
+		if fields := dto.ImmutableFields(body); len(fields) > 0 {
+			fieldErrors := make([]response.FieldError, 0, len(fields))
+			for _, field := range fields {
+				fieldErrors = append(fieldErrors, response.InvalidFieldError(field, "cannot be changed"))
+			}
+			response.BadRequestFieldsError(ctx, r, w, "Immutable fields cannot be updated", fieldErrors)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	updateRequest := dto.NewUpdateProfileRequest()
+	if !handler.ParseValidateAndSend(updateRequest) {
+		return
+	}
+	if updateRequest.IsEmpty() {
+		response.BadRequestError(ctx, r, w, "No fields to update", errors.New("request body sets no profile fields"))
+		return
+	}
+
+	user, err := h.service.UpdateProfile(ctx, userID, &model.ProfileUpdate{
+		Username:     updateRequest.Username,
+		DisplayName:  updateRequest.DisplayName,
+		FirstName:    updateRequest.FirstName,
+		LastName:     updateRequest.LastName,
+		Bio:          updateRequest.Bio,
+		AvatarURL:    updateRequest.AvatarURL,
+		LanguageCode: updateRequest.LanguageCode,
+		Timezone:     updateRequest.Timezone,
+		CountryCode:  updateRequest.CountryCode,
+	})
+	if err != nil {
+		h.log.Error("Failed to update profile",
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+		if writeDBError(w, r, err) {
+			return
+		}
+		response.InternalServerError(ctx, r, w, "Failed to update profile", err)
+		return
+	}
+	if user == nil {
+		response.NotFoundError(ctx, r, w, "Profile")
+		return
+	}
+
+	response.JSONWithMessage(ctx, r, w, http.StatusOK, "Profile updated successfully", dto.UpdateProfileResponse{
+		ID:           user.ID,
+		Username:     user.Username,
+		DisplayName:  user.DisplayName,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Bio:          user.Bio,
+		AvatarURL:    user.AvatarURL,
+		LanguageCode: user.LanguageCode,
+		Timezone:     user.Timezone,
+		CountryCode:  user.CountryCode,
+		IsVerified:   user.IsVerified,
+		UpdatedAt:    user.UpdatedAt,
+	})
+}
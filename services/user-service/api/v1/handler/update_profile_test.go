@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	repository "user-service/internal/repo"
+	"user-service/internal/service"
+
+	dbmodels "shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+	"shared/pkg/utils"
+	"shared/server/request"
+
+	"github.com/gorilla/mux"
+)
+
+// fakeProfileRepo records the last update it was asked to apply
+type fakeProfileRepo struct {
+	repository.UserRepositoryInterface
+	profile *dbmodels.Profile
+	updates []repository.UpdateProfileParams
+}
+
+func (f *fakeProfileRepo) UpdateProfile(ctx context.Context, params repository.UpdateProfileParams) (*dbmodels.Profile, error) {
+	f.updates = append(f.updates, params)
+	if f.profile == nil || f.profile.UserID != params.UserID {
+		return nil, nil
+	}
+	if params.Bio != nil {
+		f.profile.Bio = params.Bio
+	}
+	if params.DisplayName != nil {
+		f.profile.DisplayName = params.DisplayName
+	}
+	f.profile.UpdatedAt = utils.Ptr(time.Now())
+	return f.profile, nil
+}
+
+func newUpdateTestHandler(repo *fakeProfileRepo) *UserHandler {
+	userService := service.NewUserServiceBuilder().WithRepo(repo).WithLogger(logger.NewNoop()).Build()
+	return NewUserHandler(userService, nil, nil, logger.NewNoop())
+}
+
+func patchProfile(h *UserHandler, callerID, userID, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, "/profile/"+userID, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = mux.SetURLVars(req, map[string]string{"user_id": userID})
+	req = req.WithContext(request.WithUserID(req.Context(), callerID))
+
+	rec := httptest.NewRecorder()
+	h.UpdateProfile(rec, req)
+	return rec
+}
+
+func TestUpdateProfileAppliesOnlyProvidedFields(t *testing.T) {
+	repo := &fakeProfileRepo{profile: &dbmodels.Profile{
+		UserID:       "u1",
+		Username:     "echo",
+		DisplayName:  utils.Ptr("Echo"),
+		Bio:          utils.Ptr("old bio"),
+		LanguageCode: "en",
+	}}
+	rec := patchProfile(newUpdateTestHandler(repo), "u1", "u1", `{"bio":""}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if len(repo.updates) != 1 {
+		t.Fatalf("got %d updates, want 1", len(repo.updates))
+	}
+	update := repo.updates[0]
+	if update.Bio == nil || *update.Bio != "" {
+		t.Fatalf("bio = %v, want it cleared", update.Bio)
+	}
+	if update.DisplayName != nil || update.Username != nil || update.AvatarURL != nil {
+		t.Fatalf("fields not in the request were set: %+v", update)
+	}
+
+	var body struct {
+		Data struct {
+			DisplayName string `json:"display_name"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Data.DisplayName != "Echo" {
+		t.Fatalf("display_name = %q, want it kept", body.Data.DisplayName)
+	}
+}
+
+func TestUpdateProfileRejectsOtherUsers(t *testing.T) {
+	repo := &fakeProfileRepo{profile: &dbmodels.Profile{UserID: "u1", DisplayName: utils.Ptr("Echo")}}
+	rec := patchProfile(newUpdateTestHandler(repo), "u2", "u1", `{"bio":"hijacked"}`)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", rec.Code)
+	}
+	if len(repo.updates) != 0 {
+		t.Fatalf("repository was called for another user's profile")
+	}
+}
+
+func TestUpdateProfileRejectsImmutableFields(t *testing.T) {
+	repo := &fakeProfileRepo{profile: &dbmodels.Profile{UserID: "u1", DisplayName: utils.Ptr("Echo")}}
+	rec := patchProfile(newUpdateTestHandler(repo), "u1", "u1", `{"id":"other","bio":"x"}`)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+	var body struct {
+		Error struct {
+			Fields []struct {
+				Field string `json:"field"`
+			} `json:"fields"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(body.Error.Fields) != 1 || body.Error.Fields[0].Field != "id" {
+		t.Fatalf("fields = %+v, want only id", body.Error.Fields)
+	}
+	if len(repo.updates) != 0 {
+		t.Fatalf("repository was called despite an immutable field")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"shared/pkg/database/postgres"
 	"shared/pkg/logger"
 	adapter "shared/pkg/logger/adapter"
+	"shared/pkg/monitoring/tracing"
 
 	"shared/server/common/token"
 	env "shared/server/env"
@@ -33,6 +34,7 @@ func createLogger(name string) logger.Logger {
 		Level:      logger.GetLoggerLevel(),
 		Format:     logger.GetLoggerFormat(),
 		Output:     logger.GetLoggerOutput(),
+		File:       logger.GetLoggerFile(),
 		TimeFormat: logger.GetLoggerTimeFormat(),
 		Service:    name,
 	})
@@ -117,10 +119,18 @@ func createCacheClient(cacheConfig config.CacheConfig, log logger.Logger) (cache
 }
 
 func createTokenService(cfg *config.Config, log logger.Logger) *token.JWTTokenService {
-	keyset, err := token.NewStaticKeySet([]byte(cfg.JWT.SecretKey))
-	if err != nil {
-		log.Fatal("Failed to create JWT keyset", logger.Error(err))
-		return nil
+	var keyset token.KeySet
+	if cfg.JWT.SigningMethod == token.AlgorithmRS256 {
+		// Validate with the issuer's published public keys; no shared secret needed.
+		keyset = token.NewJWKSKeySet(cfg.JWT.JWKSURL)
+		log.Info("Validating JWTs against JWKS", logger.String("url", cfg.JWT.JWKSURL))
+	} else {
+		static, err := token.NewStaticKeySet([]byte(cfg.JWT.SecretKey))
+		if err != nil {
+			log.Fatal("Failed to create JWT keyset", logger.Error(err))
+			return nil
+		}
+		keyset = static
 	}
 
 	tokenService, err := token.NewJWTTokenService(token.Config{
@@ -137,8 +147,32 @@ func createTokenService(cfg *config.Config, log logger.Logger) *token.JWTTokenSe
 	return tokenService
 }
 
+func createTracer(cfg *config.Config, log logger.Logger) *tracing.Tracer {
+	tracingCfg := cfg.Observability.Tracing
+	tracer, err := tracing.New(tracing.Config{
+		ServiceName:    cfg.Service.Name,
+		ServiceVersion: cfg.Service.Version,
+		Environment:    cfg.Service.Environment,
+		JaegerEndpoint: tracingCfg.Endpoint,
+		SamplingRate:   tracingCfg.SampleRate,
+		Enabled:        tracingCfg.Enabled,
+	})
+	if err != nil {
+		log.Fatal("Failed to create tracer", logger.Error(err))
+	}
+
+	if tracingCfg.Enabled {
+		log.Info("Tracing enabled",
+			logger.String("endpoint", tracingCfg.Endpoint),
+			logger.Float64("sample_rate", tracingCfg.SampleRate),
+		)
+	}
+	return tracer
+}
+
 func setupHealthChecks(dbClient database.Database, cacheClient cache.Cache, cfg *config.Config) *health.Manager {
 	healthMgr := health.NewManager(cfg.Service.Name, cfg.Service.Version)
+	healthMgr.SetCacheTTL(cfg.Observability.Health.CacheTTL)
 
 	// Register database health checker
 	if dbClient != nil {
@@ -159,14 +193,21 @@ func setupRoutes(builder *router.Builder, h *handler.UserHandler, log logger.Log
 	builder = builder.WithRoutes(func(r *router.Router) {
 		r.Post("/profile", h.CreateProfile)
 		r.Get("/profile/{user_id}", h.GetProfile)
+		r.Patch("/profile/{user_id}", h.UpdateProfile)
 	})
 	log.Debug("User routes registered successfully")
 	return builder
 }
 
-func createRouter(h *handler.UserHandler, healthHandler *health.Handler, log logger.Logger) (*router.Router, error) {
-	builder := router.NewBuilder().
+func createRouter(h *handler.UserHandler, healthHandler *health.Handler, inFlight *shutdown.InFlight, cfg *config.Config, log logger.Logger) (*router.Router, error) {
+	builder := router.NewBuilder()
+	if cfg.Observability.Tracing.Enabled {
+		builder = builder.WithEarlyMiddleware(router.Middleware(coreMiddleware.Tracing(cfg.Service.Name)))
+	}
+
+	builder = builder.
 		WithHealthEndpoint("/health", healthHandler.Health).
+		WithLogLevelEndpoint("/admin/loglevel", log, env.LogLevelEndpointEnabled()).
 		WithNotFoundHandler(func(w http.ResponseWriter, r *http.Request) {
 			response.RouteNotFoundError(r.Context(), r, w, log)
 		}).
@@ -174,6 +215,7 @@ func createRouter(h *handler.UserHandler, healthHandler *health.Handler, log log
 			response.MethodNotAllowedError(r.Context(), r, w)
 		}).
 		WithEarlyMiddleware(
+			router.Middleware(inFlight.Middleware),
 			router.Middleware(coreMiddleware.RequestReceivedLogger(log)),
 			router.Middleware(coreMiddleware.InterceptUserId()),
 			router.Middleware(coreMiddleware.InterceptSessionId()),
@@ -182,6 +224,7 @@ func createRouter(h *handler.UserHandler, healthHandler *health.Handler, log log
 		WithLateMiddleware(
 			router.Middleware(coreMiddleware.Recovery(log)),
 			router.Middleware(coreMiddleware.RequestCompletedLogger(log)),
+			router.Middleware(coreMiddleware.BodyLogger(coreMiddleware.BodyLoggerConfig{Enabled: env.BodyLoggingEnabled(), Logger: log})),
 		)
 
 	builder = builder.WithRoutes(func(r *router.Router) {
@@ -196,26 +239,29 @@ func createRouter(h *handler.UserHandler, healthHandler *health.Handler, log log
 	return r, nil
 }
 
-func setupShutdownManager(srv *server.Server, log logger.Logger, cfg *config.Config) *shutdown.Manager {
+func setupShutdownManager(srv *server.Server, inFlight *shutdown.InFlight, tracer *tracing.Tracer, log logger.Logger, cfg *config.Config) *shutdown.Manager {
 	shutdownMgr := shutdown.New(
 		shutdown.WithTimeout(cfg.Server.ShutdownTimeout),
 		shutdown.WithLogger(log),
 	)
 
+	// Draining runs inside the server hook, after the listeners close, so it
+	// sees the requests the server is still handling
+	serverHook := shutdown.ServerShutdownHook(srv)
+	if cfg.Shutdown.WaitForConnections && cfg.Shutdown.DrainTimeout > 0 {
+		serverHook = shutdown.ServerDrainHook(srv, inFlight, cfg.Shutdown.DrainTimeout, log)
+	}
 	shutdownMgr.RegisterWithPriority(
 		"http-server",
-		shutdown.ServerShutdownHook(srv),
+		serverHook,
 		shutdown.PriorityHigh,
 	)
 
-	if cfg.Shutdown.WaitForConnections && cfg.Shutdown.DrainTimeout > 0 {
-		shutdownMgr.RegisterWithOptions(
-			"drain-connections",
-			shutdown.DelayHook(cfg.Shutdown.DrainTimeout),
-			shutdown.PriorityHigh,
-			cfg.Shutdown.DrainTimeout,
-		)
-	}
+	shutdownMgr.RegisterWithPriority(
+		"tracer-shutdown",
+		shutdown.Hook(tracer.Shutdown),
+		shutdown.PriorityNormal,
+	)
 
 	shutdownMgr.RegisterWithPriority(
 		"logger-sync",
@@ -249,6 +295,9 @@ func main() {
 
 	log := createLogger(cfg.Service.Name)
 	defer log.Sync()
+	response.SetLogger(log)
+
+	tracer := createTracer(cfg, log)
 
 	dbClient, err := createDBClient(cfg.Database, log)
 	if err != nil {
@@ -287,6 +336,7 @@ func main() {
 	userService := service.NewUserServiceBuilder().
 		WithRepo(userRepo).
 		WithCache(cacheClient).
+		WithProfileCacheTTL(cfg.Cache.ProfileTTL, cfg.Cache.ProfileNotFoundTTL).
 		WithLogger(log).
 		Build()
 	locationService := service.NewLocationService(cfg.Server.LocationServiceEndpoint, log)
@@ -295,7 +345,8 @@ func main() {
 	healthMgr := setupHealthChecks(dbClient, cacheClient, cfg)
 	healthHandler := health.NewHandler(healthMgr)
 
-	routerInstance, err := createRouter(userHandler, healthHandler, log)
+	inFlight := shutdown.NewInFlight()
+	routerInstance, err := createRouter(userHandler, healthHandler, inFlight, cfg, log)
 	if err != nil {
 		log.Fatal("Failed to create router", logger.Error(err))
 	}
@@ -315,7 +366,7 @@ func main() {
 		log.Fatal("Failed to create server", logger.Error(err))
 	}
 
-	shutdownMgr := setupShutdownManager(srv, log, cfg)
+	shutdownMgr := setupShutdownManager(srv, inFlight, tracer, log, cfg)
 
 	serverErrors := make(chan error, 1)
 	go func() {
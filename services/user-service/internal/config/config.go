@@ -59,8 +59,10 @@ type PostgresConfig struct {
 
 // CacheConfig contains cache configuration
 type CacheConfig struct {
-	Enabled     bool        `yaml:"enabled" mapstructure:"enabled"`
-	RedisConfig RedisConfig `yaml:"redis" mapstructure:"redis"`
+	Enabled            bool          `yaml:"enabled" mapstructure:"enabled"`
+	ProfileTTL         time.Duration `yaml:"profile_ttl" mapstructure:"profile_ttl"`
+	ProfileNotFoundTTL time.Duration `yaml:"profile_not_found_ttl" mapstructure:"profile_not_found_ttl"`
+	RedisConfig        RedisConfig   `yaml:"redis" mapstructure:"redis"`
 }
 
 // RedisConfig contains Redis specific configuration
@@ -166,8 +168,9 @@ type TracingConfig struct {
 
 // HealthConfig contains health check configuration
 type HealthConfig struct {
-	Enabled  bool   `yaml:"enabled" mapstructure:"enabled"`
-	Endpoint string `yaml:"endpoint" mapstructure:"endpoint"`
+	Enabled  bool          `yaml:"enabled" mapstructure:"enabled"`
+	Endpoint string        `yaml:"endpoint" mapstructure:"endpoint"`
+	CacheTTL time.Duration `yaml:"cache_ttl" mapstructure:"cache_ttl"`
 }
 
 // ShutdownConfig contains graceful shutdown configuration
@@ -211,4 +214,6 @@ type JWTConfig struct {
 	SigningMethod   string        `yaml:"signing_method" mapstructure:"signing_method"`
 	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" mapstructure:"access_token_ttl"`
 	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" mapstructure:"refresh_token_ttl"`
+	// JWKSURL is the issuer's key endpoint, used when SigningMethod is RS256.
+	JWKSURL string `yaml:"jwks_url" mapstructure:"jwks_url"`
 }
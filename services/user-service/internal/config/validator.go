@@ -165,6 +165,14 @@ func validateCache(cfg *Config) error {
 		redis.RedisDialTimeout = 5 * time.Second
 	}
 
+	if cfg.Cache.ProfileTTL <= 0 {
+		cfg.Cache.ProfileTTL = 5 * time.Minute
+	}
+
+	if cfg.Cache.ProfileNotFoundTTL <= 0 {
+		cfg.Cache.ProfileNotFoundTTL = 30 * time.Second
+	}
+
 	return nil
 }
 
@@ -242,7 +250,7 @@ func validateLogging(cfg *Config) error {
 		return fmt.Errorf("logging.format must be one of: %s", strings.Join(validFormats, ", "))
 	}
 
-	validOutputs := []string{"stdout", "stderr", "file"}
+	validOutputs := []string{"stdout", "stderr", "file", "both"}
 	if !contains(validOutputs, cfg.Logging.Output) {
 		return fmt.Errorf("logging.output must be one of: %s", strings.Join(validOutputs, ", "))
 	}
@@ -253,9 +261,9 @@ func validateLogging(cfg *Config) error {
 	}
 
 	// Validate file logging settings
-	if cfg.Logging.Output == "file" {
+	if cfg.Logging.Output == "file" || cfg.Logging.Output == "both" {
 		if cfg.Logging.File.Path == "" {
-			return fmt.Errorf("logging.file.path is required when output is 'file'")
+			return fmt.Errorf("logging.file.path is required when output is '%s'", cfg.Logging.Output)
 		}
 
 		if cfg.Logging.File.MaxSize <= 0 {
@@ -303,6 +311,9 @@ func validateObservability(cfg *Config) error {
 		if cfg.Observability.Health.Endpoint == "" {
 			cfg.Observability.Health.Endpoint = "/health"
 		}
+		if cfg.Observability.Health.CacheTTL <= 0 {
+			cfg.Observability.Health.CacheTTL = 5 * time.Second
+		}
 	}
 
 	return nil
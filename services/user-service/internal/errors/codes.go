@@ -17,6 +17,8 @@ const (
 	ErrCodeProfileNotFound     = "PROFILE_NOT_FOUND"
 	ErrCodeInvalidProfileData  = "INVALID_PROFILE_DATA"
 	ErrCodeProfileUpdateFailed = "PROFILE_UPDATE_FAILED"
+	ErrCodeProfileConflict     = "PROFILE_CONFLICT"
+	ErrCodeInvalidReference    = "INVALID_REFERENCE"
 
 	// Search errors
 	ErrCodeSearchFailed       = "SEARCH_FAILED"
@@ -34,13 +34,14 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 		"service":   health.Service,
 		"version":   health.Version,
 		"uptime":    health.Uptime,
+		"build":     health.Build,
 		"liveness": map[string]interface{}{
 			"status": liveness.Status,
 			"ok":     liveness.Status == StatusHealthy,
 		},
 		"readiness": map[string]interface{}{
 			"status": readiness.Status,
-			"ok":     readiness.Status == StatusHealthy,
+			"ok":     readiness.Status != StatusUnhealthy,
 		},
 	}
 
@@ -50,11 +51,17 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 		} else {
 			sanitizedChecks := make(map[string]interface{})
 			for name, check := range health.Checks {
-				sanitizedChecks[name] = map[string]interface{}{
+				sanitizedCheck := map[string]interface{}{
 					"status":        check.Status,
 					"message":       check.Message,
 					"response_time": check.ResponseTime,
+					"duration_ms":   check.DurationMS,
+					"last_checked":  check.LastChecked,
 				}
+				if check.Metadata != nil {
+					sanitizedCheck["metadata"] = check.Metadata
+				}
+				sanitizedChecks[name] = sanitizedCheck
 			}
 			resp["checks"] = sanitizedChecks
 		}
@@ -83,7 +90,7 @@ func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
 
 	resp := map[string]interface{}{
 		"status": readiness.Status,
-		"ok":     readiness.Status == StatusHealthy,
+		"ok":     readiness.Status != StatusUnhealthy,
 	}
 
 	// Add checks in development mode
@@ -108,7 +115,9 @@ func (h *Handler) sanitizeResponse(resp Response) Response {
 			Status:       check.Status,
 			Message:      check.Message,
 			ResponseTime: check.ResponseTime,
+			DurationMS:   check.DurationMS,
 			LastChecked:  check.LastChecked,
+			Metadata:     check.Metadata,
 		}
 
 		if check.Status == StatusHealthy || check.Status == StatusDegraded {
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type fakeChecker struct {
+	name   string
+	status Status
+}
+
+func (c fakeChecker) Name() string { return c.name }
+
+func (c fakeChecker) Check(ctx context.Context) CheckResult {
+	return CheckResult{Status: c.status}
+}
+
+type declaredChecker struct {
+	fakeChecker
+	critical bool
+}
+
+func (c declaredChecker) IsCritical() bool { return c.critical }
+
+func critical(name string, status Status) Checker {
+	return declaredChecker{fakeChecker{name, status}, true}
+}
+
+func optional(name string, status Status) Checker {
+	return declaredChecker{fakeChecker{name, status}, false}
+}
+
+func TestManager_StatusAggregation(t *testing.T) {
+	tests := []struct {
+		name     string
+		checkers []Checker
+		want     Status
+	}{
+		{"no checkers", nil, StatusHealthy},
+		{"all healthy", []Checker{critical("database", StatusHealthy), optional("cache", StatusHealthy)}, StatusHealthy},
+		{"optional unhealthy", []Checker{critical("database", StatusHealthy), optional("cache", StatusUnhealthy)}, StatusDegraded},
+		{"optional degraded", []Checker{critical("database", StatusHealthy), optional("cache", StatusDegraded)}, StatusDegraded},
+		{"critical degraded", []Checker{critical("database", StatusDegraded), optional("cache", StatusHealthy)}, StatusDegraded},
+		{"critical unhealthy", []Checker{critical("database", StatusUnhealthy), optional("cache", StatusHealthy)}, StatusUnhealthy},
+		{"both unhealthy", []Checker{critical("database", StatusUnhealthy), optional("cache", StatusUnhealthy)}, StatusUnhealthy},
+		{"undeclared unhealthy is critical", []Checker{fakeChecker{"custom", StatusUnhealthy}}, StatusUnhealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager("user-service", "test")
+			for _, c := range tt.checkers {
+				m.RegisterChecker(c)
+			}
+
+			ctx := context.Background()
+			if got := m.Health(ctx, true).Status; got != tt.want {
+				t.Fatalf("health status = %s, want %s", got, tt.want)
+			}
+
+			readiness := m.Readiness(ctx).Status
+			wantCode := http.StatusOK
+			if tt.want == StatusUnhealthy {
+				wantCode = http.StatusServiceUnavailable
+			}
+			if code := m.HTTPStatus(readiness); code != wantCode {
+				t.Fatalf("readiness code = %d, want %d", code, wantCode)
+			}
+
+			if got := m.Liveness(ctx).Status; got != StatusHealthy {
+				t.Fatalf("liveness status = %s, want healthy", got)
+			}
+		})
+	}
+}
+
+type countingChecker struct {
+	calls int
+}
+
+func (c *countingChecker) Name() string { return "database" }
+
+func (c *countingChecker) Check(ctx context.Context) CheckResult {
+	c.calls++
+	return CheckResult{
+		Status:   StatusHealthy,
+		Metadata: map[string]interface{}{"server_version": "16.2"},
+	}
+}
+
+func TestManager_ReportsTimingsAndCachesResults(t *testing.T) {
+	checker := &countingChecker{}
+	m := NewManager("user-service", "1.2.3")
+	m.RegisterChecker(checker)
+	ctx := context.Background()
+
+	first := m.Health(ctx, true)
+	if first.Build.Version != "1.2.3" || first.Build.GoVersion == "" {
+		t.Fatalf("build = %+v, want version 1.2.3 and a go version", first.Build)
+	}
+	check := first.Checks["database"]
+	if check.LastChecked == "" {
+		t.Fatalf("last_checked not set")
+	}
+	if check.DurationMS < 0 {
+		t.Fatalf("duration_ms = %v, want >= 0", check.DurationMS)
+	}
+	if check.Metadata["server_version"] != "16.2" {
+		t.Fatalf("metadata = %v, want server_version", check.Metadata)
+	}
+
+	second := m.Health(ctx, true)
+	if checker.calls != 1 {
+		t.Fatalf("checker ran %d times within the cache TTL, want 1", checker.calls)
+	}
+	if second.Checks["database"].LastChecked != check.LastChecked {
+		t.Fatalf("cached result changed last_checked")
+	}
+
+	m.SetCacheTTL(0)
+	m.Health(ctx, true)
+	if checker.calls != 2 {
+		t.Fatalf("checker ran %d times with caching disabled, want 2", checker.calls)
+	}
+
+	m.SetCacheTTL(time.Minute)
+	m.Health(ctx, true)
+	if checker.calls != 2 {
+		t.Fatalf("checker ran %d times after re-enabling the cache, want 2", checker.calls)
+	}
+}
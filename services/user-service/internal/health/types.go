@@ -1,6 +1,9 @@
 package health
 
-import "time"
+import (
+	"shared/server/env"
+	"time"
+)
 
 type Status string
 
@@ -16,6 +19,7 @@ type Response struct {
 	Service   string                 `json:"service"`
 	Version   string                 `json:"version"`
 	Uptime    string                 `json:"uptime"`
+	Build     env.BuildInfo          `json:"build"`
 	Checks    map[string]CheckResult `json:"checks,omitempty"`
 }
 
@@ -23,9 +27,13 @@ type CheckResult struct {
 	Status       Status                 `json:"status"`
 	Message      string                 `json:"message,omitempty"`
 	ResponseTime float64                `json:"response_time_ms,omitempty"`
+	DurationMS   float64                `json:"duration_ms"`
 	LastChecked  string                 `json:"last_checked,omitempty"`
 	Error        string                 `json:"error,omitempty"`
 	Details      map[string]interface{} `json:"details,omitempty"`
+	// Metadata carries non-sensitive facts about the dependency, such as its
+	// server version, and is reported in every environment
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // DatabaseDetails contains detailed information about database health
@@ -19,6 +19,7 @@ type UserRepositoryInterface interface {
 	// Profile management
 	CreateProfile(ctx context.Context, profile models.Profile) (*models.Profile, error)
 	UpdateProfile(ctx context.Context, params UpdateProfileParams) (*models.Profile, error)
+	GenerateUniqueUsername(ctx context.Context, baseUsername string) (*string, error)
 
 	// Search and validation
 	SearchProfiles(ctx context.Context, query string, limit, offset int) ([]*models.Profile, int, error)
@@ -15,6 +15,7 @@ type UserServiceInterface interface {
 	// Profile operations
 	GetProfile(ctx context.Context, userID string) (*model.User, error)
 	CreateProfile(ctx context.Context, profile *models.Profile) (*model.User, error)
+	UpdateProfile(ctx context.Context, userID string, update *model.ProfileUpdate) (*model.User, error)
 }
 
 // Compile-time interface compliance check
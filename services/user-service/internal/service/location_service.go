@@ -1,20 +1,26 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	pkgErrors "shared/pkg/errors"
 	"shared/pkg/logger"
+	"shared/pkg/monitoring/tracing"
+	"shared/server/circuitbreaker"
 	"shared/server/request"
+	"shared/server/retry"
 	"time"
 )
 
 type LocationService struct {
 	Endpoint string
 	client   *http.Client
+	breaker  *circuitbreaker.Breaker
 	log      logger.Logger
 }
 
@@ -23,24 +29,44 @@ func NewLocationService(endpoint string, log logger.Logger) *LocationService {
 		Endpoint: endpoint,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
-			Transport: &http.Transport{
+			// Each retry gets its own client span
+			Transport: retry.NewTransport(tracing.NewTransport(&http.Transport{
 				MaxIdleConns:        100,
 				MaxIdleConnsPerHost: 10,
 				IdleConnTimeout:     90 * time.Second,
-			},
+			}, "location-service-client"), retry.Config{
+				MaxAttempts: 3,
+				BaseDelay:   100 * time.Millisecond,
+				MaxDelay:    time.Second,
+			}),
 		},
+		breaker: circuitbreaker.New(circuitbreaker.Config{
+			FailureThreshold: 5,
+			OpenTimeout:      30 * time.Second,
+			HalfOpenMaxCalls: 1,
+			OnStateChange: func(from, to circuitbreaker.State) {
+				log.Warn("Location service circuit breaker state changed",
+					logger.String("from", from.String()),
+					logger.String("to", to.String()),
+				)
+			},
+		}),
 		log: log,
 	}
 }
 
-func (s *LocationService) Lookup(ip string) (*request.IpAddressInfo, error) {
+func (s *LocationService) CircuitState() circuitbreaker.State {
+	return s.breaker.State()
+}
+
+func (s *LocationService) Lookup(ctx context.Context, ip string) (*request.IpAddressInfo, error) {
 	if ip == "" {
 		return nil, pkgErrors.New(pkgErrors.CodeInvalidArgument, "ip address is required")
 	}
 
 	url := fmt.Sprintf("%s?ip=%s", s.Endpoint, url.QueryEscape(ip))
 	s.log.Info("Looking up location", logger.String("url", url))
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, pkgErrors.FromError(err, pkgErrors.CodeInternal, "failed to create location lookup request").
 			WithDetail("ip", ip)
@@ -48,27 +74,43 @@ func (s *LocationService) Lookup(ip string) (*request.IpAddressInfo, error) {
 
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, pkgErrors.FromError(err, pkgErrors.CodeServiceUnavailable, "failed to execute location lookup request").
-			WithDetail("ip", ip)
-	}
-	defer resp.Body.Close()
+	var locationData LocationData
+	var lookupErr pkgErrors.AppError
+	err = s.breaker.Execute(context.Background(), func() error {
+		resp, err := s.client.Do(req)
+		if err != nil {
+			lookupErr = pkgErrors.FromError(err, pkgErrors.CodeServiceUnavailable, "failed to execute location lookup request").
+				WithDetail("ip", ip)
+			return lookupErr
+		}
+		defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, pkgErrors.New(pkgErrors.CodeServiceUnavailable, "location lookup request failed").
-			WithDetail("status_code", resp.StatusCode).
-			WithDetail("response_body", string(body)).
-			WithDetail("ip", ip)
-	}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			lookupErr = pkgErrors.New(pkgErrors.CodeServiceUnavailable, "location lookup request failed").
+				WithDetail("status_code", resp.StatusCode).
+				WithDetail("response_body", string(body)).
+				WithDetail("ip", ip)
+			// Only server-side failures count against the breaker.
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return lookupErr
+			}
+			return nil
+		}
 
-	var locationData LocationData
-	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(&locationData); err != nil {
-		return nil, pkgErrors.FromError(err, pkgErrors.CodeInternal, "failed to decode location response").
+		if err := json.NewDecoder(resp.Body).Decode(&locationData); err != nil {
+			lookupErr = pkgErrors.FromError(err, pkgErrors.CodeInternal, "failed to decode location response").
+				WithDetail("ip", ip)
+		}
+		return nil
+	})
+	if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		return nil, pkgErrors.FromError(err, pkgErrors.CodeServiceUnavailable, "location service is unavailable").
 			WithDetail("ip", ip)
 	}
+	if lookupErr != nil {
+		return nil, lookupErr
+	}
 
 	return &request.IpAddressInfo{
 		Latitude:    locationData.Latitude,
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"user-service/internal/model"
+
+	"shared/pkg/logger"
+)
+
+// notFoundMarker is cached for user IDs without a profile so repeated lookups
+// of a missing profile don't all reach the database
+var notFoundMarker = []byte("null")
+
+func profileCacheKey(userID string) string {
+	return fmt.Sprintf("user:profile:%s", userID)
+}
+
+// cachedProfile returns the cached profile for userID. found is true for a
+// cached miss too, in which case user is nil.
+func (s *UserService) cachedProfile(ctx context.Context, userID string) (user *model.User, found bool) {
+	if s.cache == nil {
+		return nil, false
+	}
+
+	data, err := s.cache.Get(ctx, profileCacheKey(userID))
+	if err != nil || data == nil {
+		return nil, false
+	}
+	if bytes.Equal(data, notFoundMarker) {
+		s.log.Debug("Profile miss found in cache", logger.String("user_id", userID))
+		return nil, true
+	}
+
+	var cached model.User
+	if err := json.Unmarshal(data, &cached); err != nil {
+		s.log.Warn("Dropping unreadable cached profile",
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+		s.invalidateProfile(ctx, userID)
+		return nil, false
+	}
+	s.log.Debug("Profile found in cache", logger.String("user_id", userID))
+	return &cached, true
+}
+
+// cacheProfile stores user for userID, or remembers the miss for a shorter
+// while when user is nil
+func (s *UserService) cacheProfile(ctx context.Context, userID string, user *model.User) {
+	if s.cache == nil {
+		return
+	}
+
+	data, ttl := notFoundMarker, s.profileNotFoundTTL
+	if user != nil {
+		var err error
+		if data, err = json.Marshal(user); err != nil {
+			return
+		}
+		ttl = s.profileTTL
+	}
+	if err := s.cache.Set(ctx, profileCacheKey(userID), data, ttl); err != nil {
+		s.log.Warn("Failed to cache profile",
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+	}
+}
+
+// invalidateProfile drops the cached profile after it changed
+func (s *UserService) invalidateProfile(ctx context.Context, userID string) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Delete(ctx, profileCacheKey(userID)); err != nil {
+		s.log.Warn("Failed to invalidate cached profile",
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+	}
+}
@@ -2,8 +2,6 @@ package service
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"time"
 
 	"user-service/internal/model"
@@ -16,10 +14,18 @@ import (
 	"shared/pkg/utils"
 )
 
+const (
+	defaultProfileCacheTTL         = 5 * time.Minute
+	defaultProfileNotFoundCacheTTL = 30 * time.Second
+)
+
 type UserService struct {
-	repo  *repository.UserRepository
+	repo  repository.UserRepositoryInterface
 	cache cache.Cache
 	log   logger.Logger
+
+	profileTTL         time.Duration
+	profileNotFoundTTL time.Duration
 }
 
 func NewUserServiceBuilder() *UserServiceBuilder {
@@ -27,12 +33,14 @@ func NewUserServiceBuilder() *UserServiceBuilder {
 }
 
 type UserServiceBuilder struct {
-	repo  *repository.UserRepository
-	cache cache.Cache
-	log   logger.Logger
+	repo               repository.UserRepositoryInterface
+	cache              cache.Cache
+	log                logger.Logger
+	profileTTL         time.Duration
+	profileNotFoundTTL time.Duration
 }
 
-func (b *UserServiceBuilder) WithRepo(repo *repository.UserRepository) *UserServiceBuilder {
+func (b *UserServiceBuilder) WithRepo(repo repository.UserRepositoryInterface) *UserServiceBuilder {
 	b.repo = repo
 	return b
 }
@@ -42,6 +50,14 @@ func (b *UserServiceBuilder) WithCache(cache cache.Cache) *UserServiceBuilder {
 	return b
 }
 
+// WithProfileCacheTTL sets how long profiles are cached and how long a missing
+// profile is remembered; 5m and 30s when unset
+func (b *UserServiceBuilder) WithProfileCacheTTL(ttl, notFoundTTL time.Duration) *UserServiceBuilder {
+	b.profileTTL = ttl
+	b.profileNotFoundTTL = notFoundTTL
+	return b
+}
+
 func (b *UserServiceBuilder) WithLogger(log logger.Logger) *UserServiceBuilder {
 	b.log = log
 	return b
@@ -55,14 +71,23 @@ func (b *UserServiceBuilder) Build() *UserService {
 		panic("Logger is required")
 	}
 
+	if b.profileTTL <= 0 {
+		b.profileTTL = defaultProfileCacheTTL
+	}
+	if b.profileNotFoundTTL <= 0 {
+		b.profileNotFoundTTL = defaultProfileNotFoundCacheTTL
+	}
+
 	b.log.Info("Building UserService",
 		logger.String("service", "user-service"),
 	)
 
 	return &UserService{
-		repo:  b.repo,
-		cache: b.cache,
-		log:   b.log,
+		repo:               b.repo,
+		cache:              b.cache,
+		log:                b.log,
+		profileTTL:         b.profileTTL,
+		profileNotFoundTTL: b.profileNotFoundTTL,
 	}
 }
 
@@ -87,19 +112,10 @@ func (s *UserService) GetProfile(ctx context.Context, userID string) (*model.Use
 	s.log.Info("Getting user profile",
 		logger.String("user_id", userID),
 	)
-	if s.cache != nil {
-		cacheKey := fmt.Sprintf("user:profile:%s", userID)
-		cachedData, err := s.cache.Get(ctx, cacheKey)
-		if err == nil && cachedData != nil {
-			var cachedProfile model.User
-			if err := json.Unmarshal(cachedData, &cachedProfile); err == nil {
-				s.log.Debug("Profile found in cache",
-					logger.String("user_id", userID),
-				)
-				return &cachedProfile, nil
-			}
-		}
+	if user, found := s.cachedProfile(ctx, userID); found {
+		return user, nil
 	}
+
 	var repoProfile *dbmodels.Profile
 	var err error
 
@@ -114,20 +130,11 @@ func (s *UserService) GetProfile(ctx context.Context, userID string) (*model.Use
 	}
 
 	if repoProfile == nil {
+		s.cacheProfile(ctx, userID, nil)
 		return nil, nil
 	}
-	profile := fromRepoProfile(repoProfile)
-	if profile == nil {
-		return nil, nil
-	}
-	user := s.profileToUser(profile)
-
-	if s.cache != nil {
-		cacheKey := fmt.Sprintf("user:profile:%s", userID)
-		if data, err := json.Marshal(user); err == nil {
-			_ = s.cache.Set(ctx, cacheKey, data, 5*time.Minute)
-		}
-	}
+	user := s.profileToUser(fromRepoProfile(repoProfile))
+	s.cacheProfile(ctx, userID, user)
 
 	return user, nil
 }
@@ -171,6 +178,7 @@ func (s *UserService) CreateProfile(ctx context.Context, profile *models.Profile
 			)
 			return nil, err
 		}
+		s.invalidateProfile(ctx, profile.UserID)
 		createdProfile = fromRepoProfile(result)
 		return s.profileToUser(createdProfile), nil
 	}
@@ -183,6 +191,7 @@ func (s *UserService) CreateProfile(ctx context.Context, profile *models.Profile
 		)
 		return nil, err
 	}
+	s.invalidateProfile(ctx, profile.UserID)
 	createdProfile = fromRepoProfile(result)
 
 	if createdProfile == nil {
@@ -194,6 +203,44 @@ func (s *UserService) CreateProfile(ctx context.Context, profile *models.Profile
 	return user, nil
 }
 
+// UpdateProfile applies the fields set in update and returns the updated
+// profile, or nil when the user has no active profile
+func (s *UserService) UpdateProfile(ctx context.Context, userID string, update *model.ProfileUpdate) (*model.User, error) {
+	s.log.Info("Updating user profile",
+		logger.String("user_id", userID),
+	)
+
+	result, err := s.repo.UpdateProfile(ctx, repository.UpdateProfileParams{
+		UserID:       userID,
+		Username:     update.Username,
+		DisplayName:  update.DisplayName,
+		FirstName:    update.FirstName,
+		LastName:     update.LastName,
+		Bio:          update.Bio,
+		AvatarURL:    update.AvatarURL,
+		LanguageCode: update.LanguageCode,
+		Timezone:     update.Timezone,
+		CountryCode:  update.CountryCode,
+	})
+	if err != nil {
+		s.log.Error("Failed to update profile",
+			logger.String("user_id", userID),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	s.invalidateProfile(ctx, userID)
+	if result == nil {
+		return nil, nil
+	}
+
+	user := s.profileToUser(fromRepoProfile(result))
+	if result.UpdatedAt != nil {
+		user.UpdatedAt = *result.UpdatedAt
+	}
+	return user, nil
+}
+
 func (s *UserService) profileToUser(profile *models.Profile) *model.User {
 	return &model.User{
 		ID:           profile.UserID,
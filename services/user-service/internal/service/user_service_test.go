@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	repository "user-service/internal/repo"
+	"user-service/internal/service/models"
+
+	"shared/pkg/cache/memory"
+	dbmodels "shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+	"shared/pkg/utils"
+)
+
+// fakeRepo serves profiles from a map and counts lookups
+type fakeRepo struct {
+	repository.UserRepositoryInterface
+	profiles map[string]*dbmodels.Profile
+	lookups  int
+}
+
+func (f *fakeRepo) GetProfileByUserID(ctx context.Context, userID string) (*dbmodels.Profile, error) {
+	f.lookups++
+	return f.profiles[userID], nil
+}
+
+func (f *fakeRepo) CreateProfile(ctx context.Context, profile dbmodels.Profile) (*dbmodels.Profile, error) {
+	f.profiles[profile.UserID] = &profile
+	return &profile, nil
+}
+
+func newTestService(repo *fakeRepo) *UserService {
+	return NewUserServiceBuilder().
+		WithRepo(repo).
+		WithCache(memory.New()).
+		WithLogger(logger.NewNoop()).
+		Build()
+}
+
+func testProfile(userID, displayName string) *dbmodels.Profile {
+	return &dbmodels.Profile{UserID: userID, Username: "echo", DisplayName: utils.Ptr(displayName), LanguageCode: "en"}
+}
+
+func TestGetProfileReadsThroughCache(t *testing.T) {
+	repo := &fakeRepo{profiles: map[string]*dbmodels.Profile{"u1": testProfile("u1", "Echo")}}
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		user, err := s.GetProfile(ctx, "u1")
+		if err != nil {
+			t.Fatalf("GetProfile: %v", err)
+		}
+		if user == nil || *user.DisplayName != "Echo" {
+			t.Fatalf("GetProfile = %+v, want Echo", user)
+		}
+	}
+	if repo.lookups != 1 {
+		t.Fatalf("repo lookups = %d, want 1", repo.lookups)
+	}
+}
+
+func TestGetProfileCachesMisses(t *testing.T) {
+	repo := &fakeRepo{profiles: map[string]*dbmodels.Profile{}}
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		user, err := s.GetProfile(ctx, "missing")
+		if err != nil || user != nil {
+			t.Fatalf("GetProfile = %+v, %v; want nil, nil", user, err)
+		}
+	}
+	if repo.lookups != 1 {
+		t.Fatalf("repo lookups = %d, want 1", repo.lookups)
+	}
+
+	s.profileNotFoundTTL = time.Millisecond
+	s.cacheProfile(ctx, "expiring", nil)
+	time.Sleep(5 * time.Millisecond)
+	if _, found := s.cachedProfile(ctx, "expiring"); found {
+		t.Fatalf("negative entry outlived its TTL")
+	}
+}
+
+func TestCreateProfileInvalidatesCache(t *testing.T) {
+	repo := &fakeRepo{profiles: map[string]*dbmodels.Profile{}}
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	if user, _ := s.GetProfile(ctx, "u1"); user != nil {
+		t.Fatalf("GetProfile before create = %+v, want nil", user)
+	}
+
+	_, err := s.CreateProfile(ctx, &models.Profile{UserID: "u1", Username: "echo", DisplayName: "Echo", LanguageCode: utils.Ptr("en")})
+	if err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+
+	user, err := s.GetProfile(ctx, "u1")
+	if err != nil || user == nil {
+		t.Fatalf("GetProfile after create = %+v, %v; want the new profile", user, err)
+	}
+}
+
+func TestProfileCacheDisabled(t *testing.T) {
+	repo := &fakeRepo{profiles: map[string]*dbmodels.Profile{"u1": testProfile("u1", "Echo")}}
+	s := NewUserServiceBuilder().WithRepo(repo).WithLogger(logger.NewNoop()).Build()
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.GetProfile(context.Background(), "u1"); err != nil {
+			t.Fatalf("GetProfile: %v", err)
+		}
+	}
+	if repo.lookups != 2 {
+		t.Fatalf("repo lookups = %d, want 2 without a cache", repo.lookups)
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/http"
 
+	"ws-service/internal/broadcast"
 	"ws-service/internal/config"
 	"ws-service/internal/health"
 	healthCheckers "ws-service/internal/health/checkers"
+	"ws-service/internal/repo"
 	"ws-service/internal/service"
 	wsManager "ws-service/internal/websocket"
 
@@ -18,6 +20,7 @@ import (
 	"shared/pkg/database/postgres"
 	"shared/pkg/logger"
 	adapter "shared/pkg/logger/adapter"
+	"shared/server/common/token"
 	env "shared/server/env"
 	"shared/server/middleware"
 	"shared/server/response"
@@ -27,12 +30,17 @@ import (
 	"shared/server/websocket/handler"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func createLogger(name string) logger.Logger {
 	log, err := adapter.NewZap(logger.Config{
 		Level:   logger.GetLoggerLevel(),
 		Format:  logger.GetLoggerFormat(),
+		Output:  logger.GetLoggerOutput(),
+		File:    logger.GetLoggerFile(),
 		Service: name,
 	})
 	if err != nil {
@@ -121,6 +129,7 @@ func createCacheClient(cfg config.RedisConfig, log logger.Logger) (cache.Cache,
 
 func setupHealthChecks(dbClient database.Database, cacheClient cache.Cache, cfg *config.Config) *health.Manager {
 	healthMgr := health.NewManager(cfg.Service.Name, cfg.Service.Version)
+	healthMgr.SetCacheTTL(cfg.Server.HealthCacheTTL)
 
 	if dbClient != nil {
 		healthMgr.RegisterChecker(healthCheckers.NewDatabaseChecker(dbClient))
@@ -133,29 +142,101 @@ func setupHealthChecks(dbClient database.Database, cacheClient cache.Cache, cfg
 	return healthMgr
 }
 
+func convertWebSocketConfig(cfg config.WebSocketConfig) wsManager.Config {
+	rateLimits := make(map[string]wsManager.MessageRateLimit, len(cfg.MessageRateLimits))
+	for _, limit := range cfg.MessageRateLimits {
+		rateLimits[limit.Type] = wsManager.MessageRateLimit{
+			Rate:   limit.Rate,
+			Burst:  limit.Burst,
+			Policy: limit.Policy,
+		}
+	}
+
+	return wsManager.Config{
+		MaxConnections:         cfg.MaxConnections,
+		MaxReconnectAttempts:   cfg.MaxReconnectAttempts,
+		ReconnectBackoff:       cfg.ReconnectBackoff,
+		StaleConnectionTimeout: cfg.StaleConnectionTimeout,
+		PresenceReapInterval:   cfg.PresenceReapInterval,
+		MessageRateLimits:      rateLimits,
+		TypingIndicatorTTL:     cfg.TypingIndicatorTTL,
+		TypingSweepInterval:    cfg.TypingSweepInterval,
+	}
+}
+
+// setupMetrics registers the hub metrics with a new registry and returns the
+// handler that serves it
+func setupMetrics(manager *wsManager.Manager, cfg config.WebSocketConfig) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	manager.SetMetrics(wsManager.NewMetrics(registry, cfg.MetricsInterval))
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+func createTokenService(cfg *config.Config, log logger.Logger) *token.JWTTokenService {
+	var keyset token.KeySet
+	if cfg.JWT.SigningMethod == token.AlgorithmRS256 {
+		// Validate with the issuer's published public keys; no shared secret needed.
+		keyset = token.NewJWKSKeySet(cfg.JWT.JWKSURL)
+		log.Info("Validating JWTs against JWKS", logger.String("url", cfg.JWT.JWKSURL))
+	} else {
+		static, err := token.NewStaticKeySet([]byte(cfg.JWT.SecretKey))
+		if err != nil {
+			log.Fatal("Failed to create JWT keyset", logger.Error(err))
+			return nil
+		}
+		keyset = static
+	}
+
+	tokenService, err := token.NewJWTTokenService(token.Config{
+		KeySet:          keyset,
+		Issuer:          cfg.JWT.Issuer,
+		Audience:        []string{cfg.JWT.Audience},
+		AccessTokenTTL:  cfg.JWT.AccessTokenTTL,
+		RefreshTokenTTL: cfg.JWT.RefreshTokenTTL,
+		Leeway:          cfg.JWT.Leeway,
+	})
+	if err != nil {
+		log.Fatal("Failed to create JWT token service", logger.Error(err))
+		return nil
+	}
+	return tokenService
+}
+
 func createWebSocketHandler(
 	manager *wsManager.Manager,
 	wsService service.WSService,
+	tokenService *token.JWTTokenService,
+	denylist *token.Denylist,
 	cfg *config.Config,
 	log logger.Logger,
 ) *handler.Handler {
+	checkOrigin := func(r *http.Request) bool { return true }
+	if cfg.WebSocket.CheckOrigin {
+		checkOrigin = handler.AllowedOriginChecker(cfg.WebSocket.AllowedOrigins)
+	}
+
 	handlerCfg := &handler.Config{
 		// Connection settings from config
-		SendBufferSize:    cfg.WebSocket.ClientBufferSize,
-		MaxMessageSize:    int64(cfg.WebSocket.MaxMessageSize),
-		PingInterval:      cfg.WebSocket.PingPeriod,
-		WriteTimeout:      cfg.WebSocket.WriteWait,
-		ReadTimeout:       cfg.WebSocket.PongWait,
-		StaleTimeout:      cfg.WebSocket.StaleConnectionTimeout,
-		CheckOrigin:       func(r *http.Request) bool { return true },
-		ReadBufferSize:    cfg.WebSocket.ReadBufferSize,
-		WriteBufferSize:   cfg.WebSocket.WriteBufferSize,
-		EnableCompression: false,
+		SendBufferSize:        cfg.WebSocket.ClientBufferSize,
+		MaxMessageSize:        int64(cfg.WebSocket.MaxMessageSize),
+		PingInterval:          cfg.WebSocket.PingPeriod,
+		WriteTimeout:          cfg.WebSocket.WriteWait,
+		ReadTimeout:           cfg.WebSocket.PongWait,
+		StaleTimeout:          cfg.WebSocket.StaleConnectionTimeout,
+		MaxConnectionsPerUser: cfg.WebSocket.MaxConnectionsPerUser,
+		CheckOrigin:           checkOrigin,
+		ReadBufferSize:        cfg.WebSocket.ReadBufferSize,
+		WriteBufferSize:       cfg.WebSocket.WriteBufferSize,
+		EnableCompression:     false,
 
 		ValidateUser: func(ctx context.Context, userID uuid.UUID) (bool, error) {
 			return wsService.ValidateUserExists(ctx, userID)
 		},
-		ExtractUserID: handler.DefaultUserIDExtractor,
+		Authenticate: handler.TokenAuthenticator(tokenService, denylist),
 		HandleMessage: func(ctx context.Context, conn *handler.Connection, message []byte) error {
 			return manager.HandleMessage(ctx, conn, message)
 		},
@@ -202,10 +283,12 @@ func setupAPIRoutes(
 func createRouter(
 	wsHandler *handler.Handler,
 	healthHandler *health.Handler,
+	metricsHandler http.Handler,
 	log logger.Logger,
 ) (*router.Router, error) {
 	builder := router.NewBuilder().
 		WithHealthEndpoint("/health", healthHandler.Health).
+		WithLogLevelEndpoint("/admin/loglevel", log, env.LogLevelEndpointEnabled()).
 		WithNotFoundHandler(func(w http.ResponseWriter, r *http.Request) {
 			response.RouteNotFoundError(r.Context(), r, w, log)
 		}).
@@ -218,8 +301,13 @@ func createRouter(
 		WithLateMiddleware(
 			router.Middleware(middleware.Recovery(log)),
 			router.Middleware(middleware.RequestCompletedLogger(log)),
+			router.Middleware(middleware.BodyLogger(middleware.BodyLoggerConfig{Enabled: env.BodyLoggingEnabled(), Logger: log})),
 		)
 
+	if metricsHandler != nil {
+		builder = builder.WithMetricsEndpoint("/metrics", metricsHandler.ServeHTTP)
+	}
+
 	// Health check endpoints
 	builder = builder.WithRoutes(func(r *router.Router) {
 		r.Get("/live", healthHandler.Liveness)
@@ -362,7 +450,38 @@ func main() {
 	}
 
 	// Initialize WebSocket manager
-	manager := wsManager.NewManager(log)
+	manager := wsManager.NewManager(convertWebSocketConfig(cfg.WebSocket), log)
+	if cacheClient != nil {
+		manager.SetReplayBuffer(wsManager.NewReplayBuffer(cacheClient, cfg.WebSocket.ReplayBufferSize, cfg.WebSocket.ReplayTTL))
+		log.Info("WebSocket replay buffer enabled",
+			logger.Int("size", cfg.WebSocket.ReplayBufferSize),
+			logger.Duration("ttl", cfg.WebSocket.ReplayTTL),
+		)
+	}
+	if cfg.WebSocket.BridgeEnabled {
+		if cacheClient == nil {
+			log.Fatal("WebSocket broadcast bridge requires the cache to be enabled")
+		}
+		bridge := broadcast.NewBridge(cacheClient, cfg.WebSocket.BridgeChannel, "", log)
+		manager.SetBridge(bridge)
+		log.Info("WebSocket broadcast bridge enabled",
+			logger.String("channel", cfg.WebSocket.BridgeChannel),
+			logger.String("instance_id", bridge.InstanceID()),
+		)
+	}
+	if cacheClient != nil {
+		manager.SetRevocationListener(broadcast.NewRevocationListener(cacheClient, log))
+	}
+	manager.SetPresencePrivacy(service.NewPresencePrivacy(repo.NewPrivacyRepository(dbClient, log)))
+	manager.SetCallSignaling(service.NewCallService(repo.NewCallRepository(dbClient, log)))
+	manager.SetTypingStore(repo.NewTypingRepository(dbClient, log))
+	var metricsHandler http.Handler
+	if cfg.WebSocket.EnableMetrics {
+		metricsHandler = setupMetrics(manager, cfg.WebSocket)
+		log.Info("WebSocket metrics enabled",
+			logger.Duration("interval", cfg.WebSocket.MetricsInterval),
+		)
+	}
 	log.Info("WebSocket manager initialized")
 
 	// Start WebSocket engine
@@ -379,11 +498,22 @@ func main() {
 	healthHandler := health.NewHandler(healthMgr)
 	log.Info("Health checks registered")
 
+	tokenService := createTokenService(cfg, log)
+
+	// Revoked tokens are shared with auth-service through the cache; without
+	// it a revoked device can reconnect until its access token expires
+	var denylist *token.Denylist
+	if cacheClient != nil {
+		denylist = token.NewDenylist(cacheClient)
+	} else {
+		log.Warn("Cache is disabled; revoked access tokens are accepted until they expire")
+	}
+
 	// Initialize WebSocket handler with config
-	wsHandler := createWebSocketHandler(manager, wsService, cfg, log)
+	wsHandler := createWebSocketHandler(manager, wsService, tokenService, denylist, cfg, log)
 
 	// Create HTTP server
-	routerInstance, err := createRouter(wsHandler, healthHandler, log)
+	routerInstance, err := createRouter(wsHandler, healthHandler, metricsHandler, log)
 	if err != nil {
 		log.Fatal("Failed to create router", logger.Error(err))
 	}
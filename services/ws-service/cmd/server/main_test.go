@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"ws-service/internal/config"
+	wsManager "ws-service/internal/websocket"
+
+	"shared/pkg/logger"
+	"shared/server/websocket/connection"
+
+	"github.com/google/uuid"
+)
+
+func TestConvertWebSocketConfig_AppliesMaxConnections(t *testing.T) {
+	cfg := convertWebSocketConfig(config.WebSocketConfig{MaxConnections: 1})
+	if cfg.MaxConnections != 1 {
+		t.Fatalf("MaxConnections = %d, want 1", cfg.MaxConnections)
+	}
+
+	conns := wsManager.NewManager(cfg, logger.NewNoop()).GetEngine().ConnectionManager()
+	if err := conns.Add(connection.New(uuid.New().String(), nil, connection.DefaultConfig(), logger.NewNoop())); err != nil {
+		t.Fatalf("first connection rejected: %v", err)
+	}
+	err := conns.Add(connection.New(uuid.New().String(), nil, connection.DefaultConfig(), logger.NewNoop()))
+	if !errors.Is(err, connection.ErrMaxConnectionsReached) {
+		t.Fatalf("second connection error = %v, want %v", err, connection.ErrMaxConnectionsReached)
+	}
+}
@@ -0,0 +1,137 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"shared/pkg/cache"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+const DefaultChannel = "ws:broadcast"
+
+// PubSub is the transport the bridge runs on; cache.Cache satisfies it
+type PubSub interface {
+	Publish(ctx context.Context, channel string, payload []byte) error
+	Subscribe(ctx context.Context, channels ...string) (cache.Subscription, error)
+}
+
+// DeliverFunc hands an already encoded topic event to the local connections
+// subscribed to topic and returns how many received it
+type DeliverFunc func(topic string, data []byte, exclude []uuid.UUID) int
+
+// envelope is what travels between replicas. Data is the exact frame sent to
+// clients, so every replica delivers byte-identical events (same event ID).
+type envelope struct {
+	Origin  string          `json:"origin"`
+	Topic   string          `json:"topic"`
+	Exclude []uuid.UUID     `json:"exclude,omitempty"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Bridge fans topic broadcasts out to every ws-service replica. Each replica
+// delivers its own broadcasts locally and publishes them; events received
+// back from the channel are delivered only if another replica sent them.
+type Bridge struct {
+	ps         PubSub
+	channel    string
+	instanceID string
+	log        logger.Logger
+
+	mu   sync.Mutex
+	sub  cache.Subscription
+	done chan struct{}
+}
+
+func NewBridge(ps PubSub, channel, instanceID string, log logger.Logger) *Bridge {
+	if channel == "" {
+		channel = DefaultChannel
+	}
+	if instanceID == "" {
+		instanceID = uuid.New().String()
+	}
+	return &Bridge{ps: ps, channel: channel, instanceID: instanceID, log: log}
+}
+
+func (b *Bridge) InstanceID() string {
+	return b.instanceID
+}
+
+// Start subscribes to the bridge channel and delivers remote events until
+// Stop is called
+func (b *Bridge) Start(ctx context.Context, deliver DeliverFunc) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.sub != nil {
+		return errors.New("broadcast bridge already started")
+	}
+
+	sub, err := b.ps.Subscribe(ctx, b.channel)
+	if err != nil {
+		return err
+	}
+	b.sub = sub
+	b.done = make(chan struct{})
+
+	go b.receive(sub, deliver, b.done)
+
+	b.log.Info("Broadcast bridge started",
+		logger.String("channel", b.channel),
+		logger.String("instance_id", b.instanceID),
+	)
+	return nil
+}
+
+// Stop closes the subscription and waits for the receive loop to exit
+func (b *Bridge) Stop() error {
+	b.mu.Lock()
+	sub, done := b.sub, b.done
+	b.sub, b.done = nil, nil
+	b.mu.Unlock()
+
+	if sub == nil {
+		return nil
+	}
+	err := sub.Close()
+	<-done
+	return err
+}
+
+// Publish sends a locally delivered event to the other replicas
+func (b *Bridge) Publish(ctx context.Context, topic string, data []byte, exclude []uuid.UUID) error {
+	payload, err := json.Marshal(envelope{
+		Origin:  b.instanceID,
+		Topic:   topic,
+		Exclude: exclude,
+		Data:    data,
+	})
+	if err != nil {
+		return err
+	}
+	return b.ps.Publish(ctx, b.channel, payload)
+}
+
+func (b *Bridge) receive(sub cache.Subscription, deliver DeliverFunc, done chan struct{}) {
+	defer close(done)
+	for msg := range sub.Messages() {
+		var env envelope
+		if err := json.Unmarshal(msg.Payload, &env); err != nil {
+			b.log.Warn("Dropping malformed bridge event", logger.Error(err))
+			continue
+		}
+		// Local subscribers were already served when the event was published
+		if env.Origin == b.instanceID {
+			continue
+		}
+		delivered := deliver(env.Topic, env.Data, env.Exclude)
+		b.log.Debug("Delivered remote broadcast",
+			logger.String("topic", env.Topic),
+			logger.String("origin", env.Origin),
+			logger.Int("recipients", delivered),
+		)
+	}
+}
@@ -0,0 +1,99 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"shared/pkg/cache"
+	"shared/pkg/logger"
+	"shared/server/common/token"
+
+	"github.com/google/uuid"
+)
+
+// DisconnectFunc closes the local connection userID holds on deviceID and
+// reports whether there was one
+type DisconnectFunc func(userID uuid.UUID, deviceID string) bool
+
+// RevocationListener drops local connections of devices auth-service has
+// signed out. Every replica listens, and the one holding the device's
+// connection closes it.
+type RevocationListener struct {
+	ps  PubSub
+	log logger.Logger
+
+	mu   sync.Mutex
+	sub  cache.Subscription
+	done chan struct{}
+}
+
+func NewRevocationListener(ps PubSub, log logger.Logger) *RevocationListener {
+	return &RevocationListener{ps: ps, log: log}
+}
+
+// Start subscribes to device revocations and disconnects the devices named
+// until Stop is called
+func (l *RevocationListener) Start(ctx context.Context, disconnect DisconnectFunc) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sub != nil {
+		return errors.New("revocation listener already started")
+	}
+
+	sub, err := l.ps.Subscribe(ctx, token.DeviceRevokedChannel)
+	if err != nil {
+		return err
+	}
+	l.sub = sub
+	l.done = make(chan struct{})
+
+	go l.receive(sub, disconnect, l.done)
+
+	l.log.Info("Device revocation listener started",
+		logger.String("channel", token.DeviceRevokedChannel),
+	)
+	return nil
+}
+
+// Stop closes the subscription and waits for the receive loop to exit
+func (l *RevocationListener) Stop() error {
+	l.mu.Lock()
+	sub, done := l.sub, l.done
+	l.sub, l.done = nil, nil
+	l.mu.Unlock()
+
+	if sub == nil {
+		return nil
+	}
+	err := sub.Close()
+	<-done
+	return err
+}
+
+func (l *RevocationListener) receive(sub cache.Subscription, disconnect DisconnectFunc, done chan struct{}) {
+	defer close(done)
+	for msg := range sub.Messages() {
+		var revocation token.DeviceRevocation
+		if err := json.Unmarshal(msg.Payload, &revocation); err != nil {
+			l.log.Warn("Dropping malformed device revocation", logger.Error(err))
+			continue
+		}
+		userID, err := uuid.Parse(revocation.UserID)
+		if err != nil || revocation.DeviceID == "" {
+			l.log.Warn("Dropping device revocation without user or device",
+				logger.String("user_id", revocation.UserID),
+				logger.String("device_id", revocation.DeviceID),
+			)
+			continue
+		}
+		if disconnect(userID, revocation.DeviceID) {
+			l.log.Info("Disconnected revoked device",
+				logger.String("user_id", revocation.UserID),
+				logger.String("device_id", revocation.DeviceID),
+				logger.String("reason", revocation.Reason),
+			)
+		}
+	}
+}
@@ -8,6 +8,7 @@ type Config struct {
 	Database  DatabaseConfig  `yaml:"database" mapstructure:"database"`
 	Cache     CacheConfig     `yaml:"cache" mapstructure:"cache"`
 	WebSocket WebSocketConfig `yaml:"websocket" mapstructure:"websocket"`
+	JWT       JWTConfig       `yaml:"jwt" mapstructure:"jwt"`
 	Logging   LoggingConfig   `yaml:"logging" mapstructure:"logging"`
 	Shutdown  ShutdownConfig  `yaml:"shutdown" mapstructure:"shutdown"`
 }
@@ -26,6 +27,7 @@ type ServerConfig struct {
 	IdleTimeout     time.Duration `yaml:"idle_timeout" mapstructure:"idle_timeout"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" mapstructure:"shutdown_timeout"`
 	MaxHeaderBytes  int           `yaml:"max_header_bytes" mapstructure:"max_header_bytes"`
+	HealthCacheTTL  time.Duration `yaml:"health_cache_ttl" mapstructure:"health_cache_ttl"`
 }
 
 type DatabaseConfig struct {
@@ -78,6 +80,38 @@ type WebSocketConfig struct {
 	// Cleanup and maintenance
 	CleanupInterval         time.Duration `yaml:"cleanup_interval" mapstructure:"cleanup_interval"`
 	StaleConnectionTimeout  time.Duration `yaml:"stale_connection_timeout" mapstructure:"stale_connection_timeout"`
+	PresenceReapInterval    time.Duration `yaml:"presence_reap_interval" mapstructure:"presence_reap_interval"`
+
+	// How long a typing.start lasts unless renewed, and how often lapsed
+	// indicators are swept
+	TypingIndicatorTTL  time.Duration `yaml:"typing_indicator_ttl" mapstructure:"typing_indicator_ttl"`
+	TypingSweepInterval time.Duration `yaml:"typing_sweep_interval" mapstructure:"typing_sweep_interval"`
+
+	// Browser origins allowed to open a socket, enforced when CheckOrigin is
+	// set. Entries may use a leading "*." to allow subdomains.
+	CheckOrigin    bool     `yaml:"check_origin" mapstructure:"check_origin"`
+	AllowedOrigins []string `yaml:"allowed_origins" mapstructure:"allowed_origins"`
+
+	// Connection limits and client reconnect policy
+	MaxConnections        int           `yaml:"max_connections" mapstructure:"max_connections"`
+	MaxConnectionsPerUser int           `yaml:"max_connections_per_user" mapstructure:"max_connections_per_user"`
+	MaxReconnectAttempts  int           `yaml:"max_reconnect_attempts" mapstructure:"max_reconnect_attempts"`
+	ReconnectBackoff      time.Duration `yaml:"reconnect_backoff" mapstructure:"reconnect_backoff"`
+
+	// Missed-event replay for reconnecting clients (requires cache)
+	ReplayBufferSize int           `yaml:"replay_buffer_size" mapstructure:"replay_buffer_size"`
+	ReplayTTL        time.Duration `yaml:"replay_ttl" mapstructure:"replay_ttl"`
+
+	// Cross-replica broadcast over Redis pub/sub (requires cache)
+	BridgeEnabled bool   `yaml:"bridge_enabled" mapstructure:"bridge_enabled"`
+	BridgeChannel string `yaml:"bridge_channel" mapstructure:"bridge_channel"`
+
+	// Per-connection limits on inbound frames by message type
+	MessageRateLimits []MessageRateLimitConfig `yaml:"message_rate_limits" mapstructure:"message_rate_limits"`
+
+	// Prometheus hub metrics served on /metrics, sampled every MetricsInterval
+	EnableMetrics   bool          `yaml:"enable_metrics" mapstructure:"enable_metrics"`
+	MetricsInterval time.Duration `yaml:"metrics_interval" mapstructure:"metrics_interval"`
 
 	// Hub channels
 	RegisterBuffer   int `yaml:"register_buffer" mapstructure:"register_buffer"`
@@ -85,6 +119,29 @@ type WebSocketConfig struct {
 	BroadcastBuffer  int `yaml:"broadcast_buffer" mapstructure:"broadcast_buffer"`
 }
 
+// MessageRateLimitConfig limits one message type to Rate frames per second
+// with bursts of up to Burst. Policy says what happens to frames over the
+// limit: drop, nack or disconnect.
+type MessageRateLimitConfig struct {
+	Type   string `yaml:"type" mapstructure:"type"`
+	Rate   int    `yaml:"rate" mapstructure:"rate"`
+	Burst  int    `yaml:"burst" mapstructure:"burst"`
+	Policy string `yaml:"policy" mapstructure:"policy"`
+}
+
+// JWTConfig configures validation of the access tokens clients connect with
+type JWTConfig struct {
+	SigningMethod   string        `yaml:"signing_method" mapstructure:"signing_method"`
+	SecretKey       string        `yaml:"secret_key" mapstructure:"secret_key"`
+	Issuer          string        `yaml:"issuer" mapstructure:"issuer"`
+	Audience        string        `yaml:"audience" mapstructure:"audience"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" mapstructure:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" mapstructure:"refresh_token_ttl"`
+	Leeway          time.Duration `yaml:"leeway" mapstructure:"leeway"`
+	// JWKSURL is the issuer's key endpoint, used when SigningMethod is RS256.
+	JWKSURL string `yaml:"jwks_url" mapstructure:"jwks_url"`
+}
+
 type LoggingConfig struct {
 	Level      string `yaml:"level" mapstructure:"level"`
 	Format     string `yaml:"format" mapstructure:"format"`
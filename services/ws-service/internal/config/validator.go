@@ -40,6 +40,9 @@ func ValidateAndSetDefaults(cfg *Config) error {
 	if cfg.Server.MaxHeaderBytes == 0 {
 		cfg.Server.MaxHeaderBytes = 1 << 20 // 1 MB
 	}
+	if cfg.Server.HealthCacheTTL <= 0 {
+		cfg.Server.HealthCacheTTL = 5 * time.Second
+	}
 
 	// Database validation
 	if cfg.Database.Postgres.Host == "" {
@@ -95,6 +98,35 @@ func ValidateAndSetDefaults(cfg *Config) error {
 		}
 	}
 
+	// JWT validation
+	if cfg.JWT.SigningMethod == "" {
+		cfg.JWT.SigningMethod = "HS256"
+	}
+	switch cfg.JWT.SigningMethod {
+	case "RS256":
+		if cfg.JWT.JWKSURL == "" {
+			return fmt.Errorf("jwt.jwks_url is required for RS256")
+		}
+	case "HS256":
+		if cfg.JWT.SecretKey == "" {
+			return fmt.Errorf("jwt.secret_key is required for HS256")
+		}
+	default:
+		return fmt.Errorf("jwt.signing_method must be HS256 or RS256")
+	}
+	if cfg.JWT.Issuer == "" {
+		cfg.JWT.Issuer = "auth-service"
+	}
+	if cfg.JWT.Audience == "" {
+		cfg.JWT.Audience = "api"
+	}
+	if cfg.JWT.AccessTokenTTL <= 0 {
+		cfg.JWT.AccessTokenTTL = 15 * time.Minute
+	}
+	if cfg.JWT.RefreshTokenTTL <= 0 {
+		cfg.JWT.RefreshTokenTTL = 168 * time.Hour
+	}
+
 	// WebSocket validation
 	if cfg.WebSocket.WriteWait == 0 {
 		cfg.WebSocket.WriteWait = 10 * time.Second
@@ -123,6 +155,75 @@ func ValidateAndSetDefaults(cfg *Config) error {
 	if cfg.WebSocket.StaleConnectionTimeout == 0 {
 		cfg.WebSocket.StaleConnectionTimeout = 90 * time.Second
 	}
+	if cfg.WebSocket.PresenceReapInterval == 0 {
+		cfg.WebSocket.PresenceReapInterval = 30 * time.Second
+	}
+	if cfg.WebSocket.TypingIndicatorTTL == 0 {
+		cfg.WebSocket.TypingIndicatorTTL = 10 * time.Second
+	}
+	if cfg.WebSocket.TypingSweepInterval == 0 {
+		cfg.WebSocket.TypingSweepInterval = 2 * time.Second
+	}
+	if cfg.WebSocket.CheckOrigin && len(cfg.WebSocket.AllowedOrigins) == 0 {
+		return fmt.Errorf("websocket.allowed_origins is required when check_origin is enabled")
+	}
+	if cfg.WebSocket.MaxConnections < 0 {
+		return fmt.Errorf("websocket.max_connections cannot be negative")
+	}
+	if cfg.WebSocket.MaxConnections == 0 {
+		cfg.WebSocket.MaxConnections = 10000
+	}
+	if cfg.WebSocket.MaxConnectionsPerUser < 0 {
+		return fmt.Errorf("websocket.max_connections_per_user cannot be negative")
+	}
+	if cfg.WebSocket.MaxConnectionsPerUser == 0 {
+		cfg.WebSocket.MaxConnectionsPerUser = 10
+	}
+	if cfg.WebSocket.MaxReconnectAttempts == 0 {
+		cfg.WebSocket.MaxReconnectAttempts = 5
+	}
+	if cfg.WebSocket.ReconnectBackoff == 0 {
+		cfg.WebSocket.ReconnectBackoff = time.Second
+	}
+	if cfg.WebSocket.ReplayBufferSize == 0 {
+		cfg.WebSocket.ReplayBufferSize = 100
+	}
+	if cfg.WebSocket.ReplayTTL == 0 {
+		cfg.WebSocket.ReplayTTL = 5 * time.Minute
+	}
+	if cfg.WebSocket.BridgeChannel == "" {
+		cfg.WebSocket.BridgeChannel = "ws:broadcast"
+	}
+	if cfg.WebSocket.MetricsInterval == 0 {
+		cfg.WebSocket.MetricsInterval = 15 * time.Second
+	}
+	seenLimits := make(map[string]bool, len(cfg.WebSocket.MessageRateLimits))
+	for i := range cfg.WebSocket.MessageRateLimits {
+		limit := &cfg.WebSocket.MessageRateLimits[i]
+		if limit.Type == "" {
+			return fmt.Errorf("websocket.message_rate_limits[%d].type is required", i)
+		}
+		if seenLimits[limit.Type] {
+			return fmt.Errorf("websocket.message_rate_limits has %q more than once", limit.Type)
+		}
+		seenLimits[limit.Type] = true
+		if limit.Rate <= 0 {
+			return fmt.Errorf("websocket.message_rate_limits %q: rate must be positive", limit.Type)
+		}
+		if limit.Burst < 0 {
+			return fmt.Errorf("websocket.message_rate_limits %q: burst cannot be negative", limit.Type)
+		}
+		if limit.Burst == 0 {
+			limit.Burst = limit.Rate
+		}
+		switch limit.Policy {
+		case "":
+			limit.Policy = "drop"
+		case "drop", "nack", "disconnect":
+		default:
+			return fmt.Errorf("websocket.message_rate_limits %q: policy must be drop, nack or disconnect", limit.Type)
+		}
+	}
 	if cfg.WebSocket.RegisterBuffer == 0 {
 		cfg.WebSocket.RegisterBuffer = 256
 	}
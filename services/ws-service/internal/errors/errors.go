@@ -14,6 +14,11 @@ const (
 	ErrCodeInvalidDeviceID     = "INVALID_DEVICE_ID"
 	ErrCodeDatabaseError       = "DATABASE_ERROR"
 	ErrCodeCacheError          = "CACHE_ERROR"
+	ErrCodeNotParticipant      = "NOT_CONVERSATION_PARTICIPANT"
+	ErrCodeCallNotFound        = "CALL_NOT_FOUND"
+	ErrCodeNotInvited          = "NOT_INVITED_TO_CALL"
+	ErrCodeCallEnded           = "CALL_ENDED"
+	ErrCodeInvalidCallType     = "INVALID_CALL_TYPE"
 )
 
 // ServiceError represents a ws-service specific error
@@ -19,9 +19,33 @@ func (c *CacheChecker) Name() string {
 	return "cache"
 }
 
+// IsCritical reports that the service keeps running, degraded, without its cache
+func (c *CacheChecker) IsCritical() bool {
+	return false
+}
+
 func (c *CacheChecker) Check(ctx context.Context) (health.Status, string) {
 	if err := c.cache.Ping(ctx); err != nil {
 		return health.StatusUnhealthy, "Cache connection failed: " + err.Error()
 	}
 	return health.StatusHealthy, "Cache connection successful"
 }
+
+// Metadata reports the Redis server version from INFO
+func (c *CacheChecker) Metadata(ctx context.Context) map[string]interface{} {
+	info, err := c.cache.Info(ctx)
+	if err != nil {
+		return nil
+	}
+	version, ok := info["redis_version"]
+	if !ok {
+		// INFO keys may be prefixed with their section name
+		version = info["Server.redis_version"]
+	}
+	if version == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"server_version": version,
+	}
+}
@@ -19,9 +19,25 @@ func (c *DatabaseChecker) Name() string {
 	return "database"
 }
 
+// IsCritical reports that the service cannot serve requests without its database
+func (c *DatabaseChecker) IsCritical() bool {
+	return true
+}
+
 func (c *DatabaseChecker) Check(ctx context.Context) (health.Status, string) {
 	if err := c.db.Ping(ctx); err != nil {
 		return health.StatusUnhealthy, "Database connection failed: " + err.Error()
 	}
 	return health.StatusHealthy, "Database connection successful"
 }
+
+// Metadata reports the Postgres server version
+func (c *DatabaseChecker) Metadata(ctx context.Context) map[string]interface{} {
+	var version string
+	if err := c.db.QueryRow(ctx, "SHOW server_version").Scan(&version); err != nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"server_version": version,
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"shared/server/env"
 )
 
 type Status string
@@ -15,15 +17,21 @@ const (
 )
 
 type Check struct {
-	Name     string        `json:"name"`
-	Status   Status        `json:"status"`
-	Message  string        `json:"message,omitempty"`
-	Duration time.Duration `json:"duration"`
+	Name        string        `json:"name"`
+	Status      Status        `json:"status"`
+	Message     string        `json:"message,omitempty"`
+	Duration    time.Duration `json:"duration"`
+	DurationMS  float64       `json:"duration_ms"`
+	LastChecked string        `json:"last_checked,omitempty"`
+	// Metadata carries non-sensitive facts about the dependency, such as its
+	// server version
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type Response struct {
 	Service string           `json:"service"`
 	Version string           `json:"version"`
+	Build   *env.BuildInfo   `json:"build,omitempty"`
 	Status  Status           `json:"status"`
 	Checks  map[string]Check `json:"checks,omitempty"`
 }
@@ -33,11 +41,52 @@ type Checker interface {
 	Check(ctx context.Context) (Status, string)
 }
 
+// MetadataReporter is implemented by checkers that can describe their
+// dependency once it has answered, for example with its server version
+type MetadataReporter interface {
+	Metadata(ctx context.Context) map[string]interface{}
+}
+
+// CriticalityChecker is implemented by checkers that declare whether the
+// service can run without their dependency. Checkers that don't implement it
+// are treated as critical.
+type CriticalityChecker interface {
+	IsCritical() bool
+}
+
+func isCritical(checker Checker) bool {
+	if c, ok := checker.(CriticalityChecker); ok {
+		return c.IsCritical()
+	}
+	return true
+}
+
+// combineStatus folds one checker's status into the overall status: an
+// unhealthy critical checker makes the service unhealthy, while an unhealthy
+// optional checker or any degraded checker only degrades it
+func combineStatus(overall, status Status, critical bool) Status {
+	switch {
+	case overall == StatusUnhealthy || status == StatusHealthy:
+		return overall
+	case status == StatusUnhealthy && critical:
+		return StatusUnhealthy
+	default:
+		return StatusDegraded
+	}
+}
+
 type Manager struct {
 	serviceName string
 	version     string
 	checkers    []Checker
 	mu          sync.RWMutex
+	cache       map[string]cachedResult
+	cacheTTL    time.Duration
+}
+
+type cachedResult struct {
+	check     Check
+	timestamp time.Time
 }
 
 func NewManager(serviceName, version string) *Manager {
@@ -45,9 +94,19 @@ func NewManager(serviceName, version string) *Manager {
 		serviceName: serviceName,
 		version:     version,
 		checkers:    make([]Checker, 0),
+		cache:       make(map[string]cachedResult),
+		cacheTTL:    5 * time.Second,
 	}
 }
 
+// SetCacheTTL sets how long a check result is reused before the checker runs
+// again; zero runs every checker on every request
+func (m *Manager) SetCacheTTL(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheTTL = ttl
+}
+
 func (m *Manager) RegisterChecker(checker Checker) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -63,32 +122,59 @@ func (m *Manager) Check(ctx context.Context) Response {
 	overallStatus := StatusHealthy
 
 	for _, checker := range checkers {
-		start := time.Now()
-		status, message := checker.Check(ctx)
-		duration := time.Since(start)
-
-		checks[checker.Name()] = Check{
-			Name:     checker.Name(),
-			Status:   status,
-			Message:  message,
-			Duration: duration,
-		}
-
-		if status == StatusUnhealthy {
-			overallStatus = StatusUnhealthy
-		} else if status == StatusDegraded && overallStatus != StatusUnhealthy {
-			overallStatus = StatusDegraded
-		}
+		check := m.check(ctx, checker)
+		checks[checker.Name()] = check
+
+		overallStatus = combineStatus(overallStatus, check.Status, isCritical(checker))
 	}
 
+	build := env.Build(m.version)
 	return Response{
 		Service: m.serviceName,
 		Version: m.version,
+		Build:   &build,
 		Status:  overallStatus,
 		Checks:  checks,
 	}
 }
 
+// check runs a checker, or returns its last result while that is younger
+// than the cache TTL
+func (m *Manager) check(ctx context.Context, checker Checker) Check {
+	name := checker.Name()
+
+	m.mu.RLock()
+	cached, ok := m.cache[name]
+	ttl := m.cacheTTL
+	m.mu.RUnlock()
+	if ok && time.Since(cached.timestamp) < ttl {
+		return cached.check
+	}
+
+	start := time.Now()
+	status, message := checker.Check(ctx)
+	var metadata map[string]interface{}
+	if reporter, ok := checker.(MetadataReporter); ok && status != StatusUnhealthy {
+		metadata = reporter.Metadata(ctx)
+	}
+	duration := time.Since(start)
+
+	check := Check{
+		Name:        name,
+		Status:      status,
+		Message:     message,
+		Duration:    duration,
+		DurationMS:  float64(duration.Microseconds()) / 1000.0,
+		LastChecked: start.UTC().Format(time.RFC3339),
+		Metadata:    metadata,
+	}
+
+	m.mu.Lock()
+	m.cache[name] = cachedResult{check: check, timestamp: start}
+	m.mu.Unlock()
+	return check
+}
+
 func (m *Manager) Liveness() Response {
 	return Response{
 		Service: m.serviceName,
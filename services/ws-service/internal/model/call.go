@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Call types
+const (
+	CallTypeVoice = "voice"
+	CallTypeVideo = "video"
+)
+
+// Call statuses, as stored in messages.calls
+const (
+	CallStatusRinging  = "ringing"
+	CallStatusActive   = "active"
+	CallStatusEnded    = "ended"
+	CallStatusMissed   = "missed"
+	CallStatusRejected = "rejected"
+)
+
+// Why a call ended
+const (
+	CallEndCompleted = "completed"
+	CallEndMissed    = "missed"
+	CallEndRejected  = "rejected"
+)
+
+// Call participant statuses, as stored in messages.call_participants
+const (
+	CallParticipantInvited  = "invited"
+	CallParticipantJoined   = "joined"
+	CallParticipantLeft     = "left"
+	CallParticipantRejected = "rejected"
+)
+
+// Call is a voice or video call in a conversation
+type Call struct {
+	ID              uuid.UUID  `json:"id"`
+	ConversationID  uuid.UUID  `json:"conversation_id"`
+	CallType        string     `json:"call_type"`
+	InitiatorUserID uuid.UUID  `json:"initiator_user_id"`
+	Status          string     `json:"status"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	DurationSeconds *int       `json:"duration_seconds,omitempty"`
+	EndReason       *string    `json:"end_reason,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// IsOver reports whether the call has finished one way or another
+func (c *Call) IsOver() bool {
+	switch c.Status {
+	case CallStatusEnded, CallStatusMissed, CallStatusRejected:
+		return true
+	}
+	return false
+}
+
+// CallParticipant is a user invited to or taking part in a call
+type CallParticipant struct {
+	CallID          uuid.UUID  `json:"call_id"`
+	UserID          uuid.UUID  `json:"user_id"`
+	Status          string     `json:"status"`
+	JoinedAt        *time.Time `json:"joined_at,omitempty"`
+	LeftAt          *time.Time `json:"left_at,omitempty"`
+	DurationSeconds *int       `json:"duration_seconds,omitempty"`
+	IsVideoEnabled  bool       `json:"is_video_enabled"`
+	RejectionReason *string    `json:"rejection_reason,omitempty"`
+}
+
+// InCallOrInvited reports whether the participant is on the call or still
+// being rung
+func (p *CallParticipant) InCallOrInvited() bool {
+	return p.Status == CallParticipantInvited || p.Status == CallParticipantJoined
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	pkgPresence "shared/pkg/presence"
+
+	"github.com/google/uuid"
+)
+
+// Presence visibility values stored in users.settings
+const (
+	VisibilityEveryone = pkgPresence.VisibilityEveryone
+	VisibilityContacts = pkgPresence.VisibilityContacts
+	VisibilityNobody   = pkgPresence.VisibilityNobody
+)
+
+// PresencePrivacy represents who may see a user's last seen and online status
+type PresencePrivacy struct {
+	UserID                 uuid.UUID `json:"user_id"`
+	LastSeenVisibility     string    `json:"last_seen_visibility"`
+	OnlineStatusVisibility string    `json:"online_status_visibility"`
+}
+
+// PresenceVisibility represents how much of one user's presence a viewer may see
+type PresenceVisibility struct {
+	Status   bool `json:"status"`
+	LastSeen bool `json:"last_seen"`
+}
+
+// FullPresenceVisibility is what users see of their own presence
+var FullPresenceVisibility = PresenceVisibility{Status: true, LastSeen: true}
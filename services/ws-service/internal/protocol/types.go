@@ -13,6 +13,7 @@ type ClientMessage struct {
 	Type      string          `json:"type"`
 	Payload   json.RawMessage `json:"payload"`
 	Timestamp time.Time       `json:"timestamp,omitempty"`
+	AckID     string          `json:"ack_id,omitempty"` // opt-in: server replies with ack/nack
 }
 
 // ServerMessage represents a message to client
@@ -22,6 +23,20 @@ type ServerMessage struct {
 	Payload   interface{} `json:"payload"`
 	Timestamp time.Time   `json:"timestamp"`
 	RequestID string      `json:"request_id,omitempty"`
+
+	// Set on topic broadcasts. EventID is the per-topic sequence clients pass
+	// back as last_event_id when resubscribing.
+	Topic   string `json:"topic,omitempty"`
+	EventID int64  `json:"event_id,omitempty"`
+}
+
+// AckMessage confirms (type "ack") or rejects (type "nack") a client message
+// that carried an ack_id
+type AckMessage struct {
+	Type      string    `json:"type"`
+	AckID     string    `json:"ack_id"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // ErrorPayload represents error response
@@ -46,11 +61,23 @@ const (
 type SubscribePayload struct {
 	Topics  []Topic           `json:"topics"`
 	Filters map[string]string `json:"filters,omitempty"`
+
+	// LastEventID requests replay of events after this ID on each topic
+	LastEventID *int64 `json:"last_event_id,omitempty"`
+}
+
+// ResyncRequiredPayload tells the client that events after LastEventID are no
+// longer buffered and it must refetch the topic's state
+type ResyncRequiredPayload struct {
+	Topic         string `json:"topic"`
+	LastEventID   int64  `json:"last_event_id"`
+	LatestEventID int64  `json:"latest_event_id"`
 }
 
 // UnsubscribePayload represents unsubscribe request
 type UnsubscribePayload struct {
-	Topics []Topic `json:"topics"`
+	Topics  []Topic           `json:"topics"`
+	Filters map[string]string `json:"filters,omitempty"`
 }
 
 // SubscribedPayload represents subscription confirmation
@@ -74,6 +101,15 @@ type PresenceQueryPayload struct {
 	UserIDs []uuid.UUID `json:"user_ids"`
 }
 
+// PresenceSubscribePayload represents a presence subscription request. The
+// same payload is used to unsubscribe.
+type PresenceSubscribePayload struct {
+	UserIDs []uuid.UUID `json:"user_ids"`
+}
+
+// MaxPresenceSubscriptions caps how many users one presence.subscribe may watch
+const MaxPresenceSubscriptions = 200
+
 // TypingPayload represents typing indicator
 type TypingPayload struct {
 	ConversationID uuid.UUID `json:"conversation_id"`
@@ -116,6 +152,57 @@ type DeliveredReceiptEvent struct {
 	Timestamp      time.Time   `json:"timestamp"`
 }
 
+// CallOfferPayload starts a call with the other members of a conversation
+type CallOfferPayload struct {
+	ConversationID uuid.UUID       `json:"conversation_id"`
+	CallType       string          `json:"call_type"` // voice or video
+	SDP            json.RawMessage `json:"sdp"`
+}
+
+// CallAnswerPayload accepts a call the user was invited to
+type CallAnswerPayload struct {
+	CallID uuid.UUID       `json:"call_id"`
+	SDP    json.RawMessage `json:"sdp"`
+}
+
+// CallICEPayload sends an ICE candidate to another participant of the call
+type CallICEPayload struct {
+	CallID       uuid.UUID       `json:"call_id"`
+	TargetUserID uuid.UUID       `json:"target_user_id"`
+	Candidate    json.RawMessage `json:"candidate"`
+}
+
+// CallHangupPayload leaves a call, or declines it before answering
+type CallHangupPayload struct {
+	CallID uuid.UUID `json:"call_id"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// CallSignalEvent relays an offer, answer or ICE candidate from one
+// participant to another
+type CallSignalEvent struct {
+	CallID         uuid.UUID       `json:"call_id"`
+	ConversationID uuid.UUID       `json:"conversation_id"`
+	FromUserID     uuid.UUID       `json:"from_user_id"`
+	CallType       string          `json:"call_type,omitempty"`
+	SDP            json.RawMessage `json:"sdp,omitempty"`
+	Candidate      json.RawMessage `json:"candidate,omitempty"`
+	Timestamp      time.Time       `json:"timestamp"`
+}
+
+// CallStateEvent tells a conversation that one of its calls changed state
+type CallStateEvent struct {
+	CallID          uuid.UUID `json:"call_id"`
+	ConversationID  uuid.UUID `json:"conversation_id"`
+	CallType        string    `json:"call_type"`
+	InitiatorUserID uuid.UUID `json:"initiator_user_id"`
+	Status          string    `json:"status"`
+	UserID          uuid.UUID `json:"user_id"` // whose action changed it
+	EndReason       string    `json:"end_reason,omitempty"`
+	DurationSeconds *int      `json:"duration_seconds,omitempty"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
 // GetResourceID extracts resource ID from filters based on topic
 func GetResourceID(topic Topic, filters map[string]string) string {
 	switch topic {
@@ -0,0 +1,290 @@
+package repo
+
+import (
+	"context"
+	"ws-service/internal/model"
+
+	"shared/pkg/database"
+	"shared/pkg/database/postgres"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// CallRepository persists calls and their participants in messages.calls and
+// messages.call_participants
+type CallRepository interface {
+	// GetConversationMemberIDs returns the users currently in a conversation
+	GetConversationMemberIDs(ctx context.Context, conversationID uuid.UUID) ([]uuid.UUID, error)
+
+	// CreateCall inserts a call together with its participants
+	CreateCall(ctx context.Context, call *model.Call, participants []*model.CallParticipant) error
+
+	// GetCall returns a call, or nil when it does not exist
+	GetCall(ctx context.Context, callID uuid.UUID) (*model.Call, error)
+
+	// GetCallParticipants returns everyone invited to a call
+	GetCallParticipants(ctx context.Context, callID uuid.UUID) ([]*model.CallParticipant, error)
+
+	// ModifyCall locks a call and its participants, lets modify change them
+	// and saves the result in the same transaction, so concurrent answers and
+	// hangups apply one after another. It returns nil without calling modify
+	// when the call does not exist; an error from modify rolls back and is
+	// returned as is.
+	ModifyCall(ctx context.Context, callID uuid.UUID, modify func(call *model.Call, participants []*model.CallParticipant) error) (*model.Call, error)
+}
+
+const (
+	selectCallQuery = `
+		SELECT id, conversation_id, call_type, initiator_user_id, status,
+		       started_at, ended_at, duration_seconds, end_reason, created_at
+		FROM messages.calls
+		WHERE id = $1
+	`
+
+	selectCallParticipantsQuery = `
+		SELECT call_id, user_id, status, joined_at, left_at,
+		       duration_seconds, is_video_enabled, rejection_reason
+		FROM messages.call_participants
+		WHERE call_id = $1
+	`
+)
+
+type callRepository struct {
+	db  database.Database
+	log logger.Logger
+}
+
+// NewCallRepository creates a new call repository
+func NewCallRepository(db database.Database, log logger.Logger) CallRepository {
+	return &callRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// GetConversationMemberIDs returns the users currently in a conversation
+func (r *callRepository) GetConversationMemberIDs(ctx context.Context, conversationID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT user_id
+		FROM messages.conversation_participants
+		WHERE conversation_id = $1
+		  AND left_at IS NULL
+		  AND removed_at IS NULL
+	`
+
+	rows, err := r.db.Query(ctx, query, conversationID)
+	if err != nil {
+		r.log.Error("Failed to get conversation members",
+			logger.String("conversation_id", conversationID.String()),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		memberIDs = append(memberIDs, userID)
+	}
+
+	return memberIDs, rows.Err()
+}
+
+// CreateCall inserts a call together with its participants
+func (r *callRepository) CreateCall(ctx context.Context, call *model.Call, participants []*model.CallParticipant) error {
+	dbErr := r.db.WithTransaction(ctx, func(tx database.Transaction) *database.DBError {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO messages.calls (
+				id, conversation_id, call_type, initiator_user_id, status, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6)
+		`, call.ID, call.ConversationID, call.CallType, call.InitiatorUserID, call.Status, call.CreatedAt)
+		if err != nil {
+			return database.WrapDBError(err, database.CodeDBInternal, "failed to insert call")
+		}
+
+		for _, p := range participants {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO messages.call_participants (
+					call_id, user_id, status, joined_at, is_video_enabled, is_audio_enabled
+				) VALUES ($1, $2, $3, $4, $5, TRUE)
+			`, p.CallID, p.UserID, p.Status, p.JoinedAt, p.IsVideoEnabled)
+			if err != nil {
+				return database.WrapDBError(err, database.CodeDBInternal, "failed to insert call participant")
+			}
+		}
+		return nil
+	})
+	if dbErr != nil {
+		r.log.Error("Failed to create call",
+			logger.String("call_id", call.ID.String()),
+			logger.String("conversation_id", call.ConversationID.String()),
+			logger.Error(dbErr),
+		)
+		return dbErr
+	}
+
+	return nil
+}
+
+// GetCall returns a call, or nil when it does not exist
+func (r *callRepository) GetCall(ctx context.Context, callID uuid.UUID) (*model.Call, error) {
+	call, err := scanCall(r.db.QueryRow(ctx, selectCallQuery, callID))
+	if err != nil {
+		if postgres.IsNoRowsError(err) {
+			return nil, nil
+		}
+		r.log.Error("Failed to get call",
+			logger.String("call_id", callID.String()),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	return call, nil
+}
+
+// GetCallParticipants returns everyone invited to a call
+func (r *callRepository) GetCallParticipants(ctx context.Context, callID uuid.UUID) ([]*model.CallParticipant, error) {
+	rows, err := r.db.Query(ctx, selectCallParticipantsQuery, callID)
+	if err != nil {
+		r.log.Error("Failed to get call participants",
+			logger.String("call_id", callID.String()),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	return scanCallParticipants(rows)
+}
+
+// ModifyCall locks the call and its participants with SELECT ... FOR UPDATE,
+// applies modify and writes the call and every participant back before
+// committing
+func (r *callRepository) ModifyCall(ctx context.Context, callID uuid.UUID, modify func(call *model.Call, participants []*model.CallParticipant) error) (*model.Call, error) {
+	tx, dbErr := r.db.BeginTx(ctx, nil)
+	if dbErr != nil {
+		r.log.Error("Failed to begin call transaction",
+			logger.String("call_id", callID.String()),
+			logger.Error(dbErr),
+		)
+		return nil, dbErr
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	call, err := scanCall(tx.QueryRow(ctx, selectCallQuery+" FOR UPDATE", callID))
+	if err != nil {
+		if postgres.IsNoRowsError(err) {
+			return nil, nil
+		}
+		r.log.Error("Failed to lock call",
+			logger.String("call_id", callID.String()),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, selectCallParticipantsQuery+" FOR UPDATE", callID)
+	if err != nil {
+		r.log.Error("Failed to lock call participants",
+			logger.String("call_id", callID.String()),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	participants, err := scanCallParticipants(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := modify(call, participants); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE messages.calls
+		SET status = $2, started_at = $3, ended_at = $4, duration_seconds = $5, end_reason = $6
+		WHERE id = $1
+	`, call.ID, call.Status, call.StartedAt, call.EndedAt, call.DurationSeconds, call.EndReason)
+	if err != nil {
+		r.log.Error("Failed to update call",
+			logger.String("call_id", call.ID.String()),
+			logger.String("status", call.Status),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	for _, p := range participants {
+		_, err := tx.Exec(ctx, `
+			UPDATE messages.call_participants
+			SET status = $3, joined_at = $4, left_at = $5, duration_seconds = $6, rejection_reason = $7
+			WHERE call_id = $1 AND user_id = $2
+		`, p.CallID, p.UserID, p.Status, p.JoinedAt, p.LeftAt, p.DurationSeconds, p.RejectionReason)
+		if err != nil {
+			r.log.Error("Failed to update call participant",
+				logger.String("call_id", p.CallID.String()),
+				logger.String("user_id", p.UserID.String()),
+				logger.Error(err),
+			)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.log.Error("Failed to commit call update",
+			logger.String("call_id", callID.String()),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	return call, nil
+}
+
+func scanCall(row database.Row) (*model.Call, error) {
+	var call model.Call
+	err := row.Scan(
+		&call.ID,
+		&call.ConversationID,
+		&call.CallType,
+		&call.InitiatorUserID,
+		&call.Status,
+		&call.StartedAt,
+		&call.EndedAt,
+		&call.DurationSeconds,
+		&call.EndReason,
+		&call.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &call, nil
+}
+
+func scanCallParticipants(rows database.Rows) ([]*model.CallParticipant, error) {
+	defer rows.Close()
+
+	var participants []*model.CallParticipant
+	for rows.Next() {
+		var p model.CallParticipant
+		if err := rows.Scan(
+			&p.CallID,
+			&p.UserID,
+			&p.Status,
+			&p.JoinedAt,
+			&p.LeftAt,
+			&p.DurationSeconds,
+			&p.IsVideoEnabled,
+			&p.RejectionReason,
+		); err != nil {
+			return nil, err
+		}
+		participants = append(participants, &p)
+	}
+
+	return participants, rows.Err()
+}
@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"context"
+	"ws-service/internal/model"
+
+	"shared/pkg/database"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PrivacyRepository reads the presence privacy settings and contact lists
+// that decide who may see a user's presence
+type PrivacyRepository interface {
+	// GetPresencePrivacy loads the settings of several users; users without
+	// a settings row are absent from the result
+	GetPresencePrivacy(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*model.PresencePrivacy, error)
+
+	// GetUsersWithContact returns which of userIDs have contactID as an accepted contact
+	GetUsersWithContact(ctx context.Context, userIDs []uuid.UUID, contactID uuid.UUID) (map[uuid.UUID]bool, error)
+
+	// GetContactsAmong returns which of candidateIDs are accepted contacts of userID
+	GetContactsAmong(ctx context.Context, userID uuid.UUID, candidateIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+type privacyRepository struct {
+	db  database.Database
+	log logger.Logger
+}
+
+// NewPrivacyRepository creates a new privacy repository
+func NewPrivacyRepository(db database.Database, log logger.Logger) PrivacyRepository {
+	return &privacyRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// GetPresencePrivacy loads the presence visibility settings of several users
+func (r *privacyRepository) GetPresencePrivacy(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*model.PresencePrivacy, error) {
+	settings := make(map[uuid.UUID]*model.PresencePrivacy)
+	if len(userIDs) == 0 {
+		return settings, nil
+	}
+
+	query := `
+		SELECT user_id, last_seen_visibility, online_status_visibility
+		FROM users.settings
+		WHERE user_id = ANY($1)
+	`
+
+	rows, err := r.db.Query(ctx, query, pq.Array(userIDs))
+	if err != nil {
+		r.log.Error("Failed to get presence privacy settings",
+			logger.Int("users", len(userIDs)),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var privacy model.PresencePrivacy
+		if err := rows.Scan(&privacy.UserID, &privacy.LastSeenVisibility, &privacy.OnlineStatusVisibility); err != nil {
+			return nil, err
+		}
+		settings[privacy.UserID] = &privacy
+	}
+
+	return settings, rows.Err()
+}
+
+// GetUsersWithContact returns which of userIDs have contactID as an accepted contact
+func (r *privacyRepository) GetUsersWithContact(ctx context.Context, userIDs []uuid.UUID, contactID uuid.UUID) (map[uuid.UUID]bool, error) {
+	query := `
+		SELECT user_id
+		FROM users.contacts
+		WHERE user_id = ANY($1)
+		  AND contact_user_id = $2
+		  AND status = 'accepted'
+		  AND relationship_type != 'blocked'
+	`
+	return r.queryContacts(ctx, query, userIDs, contactID)
+}
+
+// GetContactsAmong returns which of candidateIDs are accepted contacts of userID
+func (r *privacyRepository) GetContactsAmong(ctx context.Context, userID uuid.UUID, candidateIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	query := `
+		SELECT contact_user_id
+		FROM users.contacts
+		WHERE contact_user_id = ANY($1)
+		  AND user_id = $2
+		  AND status = 'accepted'
+		  AND relationship_type != 'blocked'
+	`
+	return r.queryContacts(ctx, query, candidateIDs, userID)
+}
+
+func (r *privacyRepository) queryContacts(ctx context.Context, query string, ids []uuid.UUID, userID uuid.UUID) (map[uuid.UUID]bool, error) {
+	found := make(map[uuid.UUID]bool)
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	rows, err := r.db.Query(ctx, query, pq.Array(ids), userID)
+	if err != nil {
+		r.log.Error("Failed to check contacts",
+			logger.String("user_id", userID.String()),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found[id] = true
+	}
+
+	return found, rows.Err()
+}
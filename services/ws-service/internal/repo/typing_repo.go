@@ -0,0 +1,127 @@
+package repo
+
+import (
+	"context"
+	"time"
+	"ws-service/internal/model"
+
+	"shared/pkg/database"
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// TypingRepository persists typing indicators in messages.typing_indicators
+// so services reading the table see typing started over WebSocket
+type TypingRepository interface {
+	// UpsertTypingIndicator records a user as typing until expiresAt,
+	// extending the expiry when they already are
+	UpsertTypingIndicator(ctx context.Context, indicator *model.TypingIndicator) error
+
+	// DeleteTypingIndicator removes a user's typing indicator
+	DeleteTypingIndicator(ctx context.Context, conversationID, userID uuid.UUID) error
+
+	// DeleteExpiredTypingIndicators removes indicators that expired before
+	// now and returns them
+	DeleteExpiredTypingIndicators(ctx context.Context, now time.Time) ([]*model.TypingIndicator, error)
+}
+
+type typingRepository struct {
+	db  database.Database
+	log logger.Logger
+}
+
+// NewTypingRepository creates a new typing indicator repository
+func NewTypingRepository(db database.Database, log logger.Logger) TypingRepository {
+	return &typingRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// UpsertTypingIndicator records a user as typing until expiresAt. A row
+// that already lapsed restarts rather than keeping its old started_at.
+func (r *typingRepository) UpsertTypingIndicator(ctx context.Context, indicator *model.TypingIndicator) error {
+	query := `
+		INSERT INTO messages.typing_indicators (conversation_id, user_id, started_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (conversation_id, user_id) DO UPDATE
+		SET started_at = CASE
+		        WHEN messages.typing_indicators.expires_at < EXCLUDED.started_at THEN EXCLUDED.started_at
+		        ELSE messages.typing_indicators.started_at
+		    END,
+		    expires_at = EXCLUDED.expires_at
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		indicator.ConversationID,
+		indicator.UserID,
+		indicator.StartedAt,
+		indicator.ExpiresAt,
+	)
+	if err != nil {
+		r.log.Error("Failed to upsert typing indicator",
+			logger.String("conversation_id", indicator.ConversationID.String()),
+			logger.String("user_id", indicator.UserID.String()),
+			logger.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteTypingIndicator removes a user's typing indicator
+func (r *typingRepository) DeleteTypingIndicator(ctx context.Context, conversationID, userID uuid.UUID) error {
+	query := `
+		DELETE FROM messages.typing_indicators
+		WHERE conversation_id = $1 AND user_id = $2
+	`
+
+	_, err := r.db.Exec(ctx, query, conversationID, userID)
+	if err != nil {
+		r.log.Error("Failed to delete typing indicator",
+			logger.String("conversation_id", conversationID.String()),
+			logger.String("user_id", userID.String()),
+			logger.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpiredTypingIndicators removes indicators that expired before now
+// and returns them
+func (r *typingRepository) DeleteExpiredTypingIndicators(ctx context.Context, now time.Time) ([]*model.TypingIndicator, error) {
+	query := `
+		DELETE FROM messages.typing_indicators
+		WHERE expires_at <= $1
+		RETURNING conversation_id, user_id, started_at, expires_at
+	`
+
+	rows, err := r.db.Query(ctx, query, now)
+	if err != nil {
+		r.log.Error("Failed to delete expired typing indicators",
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expired []*model.TypingIndicator
+	for rows.Next() {
+		var indicator model.TypingIndicator
+		if err := rows.Scan(
+			&indicator.ConversationID,
+			&indicator.UserID,
+			&indicator.StartedAt,
+			&indicator.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		expired = append(expired, &indicator)
+	}
+
+	return expired, rows.Err()
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"time"
+	wsErrors "ws-service/internal/errors"
+	"ws-service/internal/model"
+	"ws-service/internal/repo"
+
+	"github.com/google/uuid"
+)
+
+// CallService records the lifecycle of calls as clients signal them: an offer
+// rings the other members of the conversation, answers join the call and
+// hangups leave or decline it, ending the call once nobody is left to talk to
+type CallService interface {
+	// StartCall creates a ringing call from a member of the conversation and
+	// invites every other member
+	StartCall(ctx context.Context, conversationID, initiatorID uuid.UUID, callType string) (*model.Call, []*model.CallParticipant, error)
+
+	// AnswerCall joins an invited user to the call, making it active
+	AnswerCall(ctx context.Context, callID, userID uuid.UUID) (*model.Call, error)
+
+	// AuthorizeSignal checks that both users are in a call that is still
+	// going, before relaying ICE candidates between them
+	AuthorizeSignal(ctx context.Context, callID, fromUserID, toUserID uuid.UUID) (*model.Call, error)
+
+	// Hangup leaves the call, or declines it when userID never answered
+	Hangup(ctx context.Context, callID, userID uuid.UUID, reason string) (*model.Call, error)
+}
+
+type callService struct {
+	repo repo.CallRepository
+	now  func() time.Time
+}
+
+// NewCallService creates a call service backed by the repository
+func NewCallService(r repo.CallRepository) CallService {
+	return &callService{repo: r, now: time.Now}
+}
+
+func (s *callService) StartCall(ctx context.Context, conversationID, initiatorID uuid.UUID, callType string) (*model.Call, []*model.CallParticipant, error) {
+	if callType != model.CallTypeVoice && callType != model.CallTypeVideo {
+		return nil, nil, wsErrors.New(wsErrors.ErrCodeInvalidCallType, "call_type must be voice or video")
+	}
+
+	memberIDs, err := s.repo.GetConversationMemberIDs(ctx, conversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	isMember := false
+	for _, memberID := range memberIDs {
+		if memberID == initiatorID {
+			isMember = true
+			break
+		}
+	}
+	if !isMember {
+		return nil, nil, wsErrors.New(wsErrors.ErrCodeNotParticipant, "not a participant of this conversation")
+	}
+
+	now := s.now()
+	call := &model.Call{
+		ID:              uuid.New(),
+		ConversationID:  conversationID,
+		CallType:        callType,
+		InitiatorUserID: initiatorID,
+		Status:          model.CallStatusRinging,
+		CreatedAt:       now,
+	}
+
+	video := callType == model.CallTypeVideo
+	participants := make([]*model.CallParticipant, 0, len(memberIDs))
+	for _, memberID := range memberIDs {
+		p := &model.CallParticipant{
+			CallID:         call.ID,
+			UserID:         memberID,
+			Status:         model.CallParticipantInvited,
+			IsVideoEnabled: video,
+		}
+		if memberID == initiatorID {
+			p.Status = model.CallParticipantJoined
+			p.JoinedAt = &now
+		}
+		participants = append(participants, p)
+	}
+
+	if err := s.repo.CreateCall(ctx, call, participants); err != nil {
+		return nil, nil, err
+	}
+	return call, participants, nil
+}
+
+func (s *callService) AnswerCall(ctx context.Context, callID, userID uuid.UUID) (*model.Call, error) {
+	return s.modify(ctx, callID, func(call *model.Call, participants []*model.CallParticipant) error {
+		p := findParticipant(participants, userID)
+		if p == nil || p.Status != model.CallParticipantInvited {
+			return wsErrors.New(wsErrors.ErrCodeNotInvited, "not invited to this call")
+		}
+
+		now := s.now()
+		p.Status = model.CallParticipantJoined
+		p.JoinedAt = &now
+		if call.Status == model.CallStatusRinging {
+			call.Status = model.CallStatusActive
+			call.StartedAt = &now
+		}
+		return nil
+	})
+}
+
+func (s *callService) AuthorizeSignal(ctx context.Context, callID, fromUserID, toUserID uuid.UUID) (*model.Call, error) {
+	call, participants, err := s.load(ctx, callID)
+	if err != nil {
+		return nil, err
+	}
+	for _, userID := range []uuid.UUID{fromUserID, toUserID} {
+		if p := findParticipant(participants, userID); p == nil || !p.InCallOrInvited() {
+			return nil, wsErrors.New(wsErrors.ErrCodeNotInvited, "not a participant of this call")
+		}
+	}
+	return call, nil
+}
+
+func (s *callService) Hangup(ctx context.Context, callID, userID uuid.UUID, reason string) (*model.Call, error) {
+	return s.modify(ctx, callID, func(call *model.Call, participants []*model.CallParticipant) error {
+		p := findParticipant(participants, userID)
+		if p == nil || !p.InCallOrInvited() {
+			return wsErrors.New(wsErrors.ErrCodeNotInvited, "not a participant of this call")
+		}
+
+		now := s.now()
+		if p.Status == model.CallParticipantInvited {
+			p.Status = model.CallParticipantRejected
+			if reason != "" {
+				p.RejectionReason = &reason
+			}
+		} else {
+			s.leave(p, now)
+		}
+
+		status, endReason, over := callOutcome(call, participants)
+		if !over {
+			return nil
+		}
+
+		// Whoever is still on the line has nobody left to talk to
+		for _, other := range participants {
+			if other.Status == model.CallParticipantJoined {
+				s.leave(other, now)
+			}
+		}
+
+		call.Status = status
+		call.EndReason = &endReason
+		call.EndedAt = &now
+		if call.StartedAt != nil {
+			duration := int(now.Sub(*call.StartedAt).Seconds())
+			call.DurationSeconds = &duration
+		}
+		return nil
+	})
+}
+
+// callOutcome decides whether a call is over after a hangup and how it ended.
+// An unanswered call ends as missed when the caller gives up and as rejected
+// once every invitee declined; an answered one ends when fewer than two
+// people remain on it.
+func callOutcome(call *model.Call, participants []*model.CallParticipant) (status, endReason string, over bool) {
+	joined, invited := 0, 0
+	for _, p := range participants {
+		switch p.Status {
+		case model.CallParticipantJoined:
+			joined++
+		case model.CallParticipantInvited:
+			invited++
+		}
+	}
+
+	if call.Status == model.CallStatusRinging {
+		switch {
+		case joined == 0:
+			return model.CallStatusMissed, model.CallEndMissed, true
+		case invited == 0:
+			return model.CallStatusRejected, model.CallEndRejected, true
+		}
+		return "", "", false
+	}
+
+	if joined < 2 {
+		return model.CallStatusEnded, model.CallEndCompleted, true
+	}
+	return "", "", false
+}
+
+func (s *callService) leave(p *model.CallParticipant, now time.Time) {
+	p.Status = model.CallParticipantLeft
+	p.LeftAt = &now
+	if p.JoinedAt != nil {
+		duration := int(now.Sub(*p.JoinedAt).Seconds())
+		p.DurationSeconds = &duration
+	}
+}
+
+// modify applies change to a call that is still going while the repository
+// holds its rows locked, so answers and hangups racing on the same call see
+// each other's writes
+func (s *callService) modify(ctx context.Context, callID uuid.UUID, change func(call *model.Call, participants []*model.CallParticipant) error) (*model.Call, error) {
+	call, err := s.repo.ModifyCall(ctx, callID, func(call *model.Call, participants []*model.CallParticipant) error {
+		if call.IsOver() {
+			return wsErrors.New(wsErrors.ErrCodeCallEnded, "call has ended")
+		}
+		return change(call, participants)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if call == nil {
+		return nil, wsErrors.New(wsErrors.ErrCodeCallNotFound, "call not found")
+	}
+	return call, nil
+}
+
+// load returns a call that is still going along with its participants
+func (s *callService) load(ctx context.Context, callID uuid.UUID) (*model.Call, []*model.CallParticipant, error) {
+	call, err := s.repo.GetCall(ctx, callID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if call == nil {
+		return nil, nil, wsErrors.New(wsErrors.ErrCodeCallNotFound, "call not found")
+	}
+	if call.IsOver() {
+		return nil, nil, wsErrors.New(wsErrors.ErrCodeCallEnded, "call has ended")
+	}
+
+	participants, err := s.repo.GetCallParticipants(ctx, callID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return call, participants, nil
+}
+
+func findParticipant(participants []*model.CallParticipant, userID uuid.UUID) *model.CallParticipant {
+	for _, p := range participants {
+		if p.UserID == userID {
+			return p
+		}
+	}
+	return nil
+}
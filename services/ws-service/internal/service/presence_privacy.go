@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"ws-service/internal/model"
+	"ws-service/internal/repo"
+
+	pkgPresence "shared/pkg/presence"
+
+	"github.com/google/uuid"
+)
+
+// PresencePrivacy applies the last seen and online status settings users keep
+// in users.settings, with the rules in shared/pkg/presence that presence-service lookups use
+type PresencePrivacy interface {
+	// VisibilityForViewer returns what viewerID may see of each user's presence
+	VisibilityForViewer(ctx context.Context, viewerID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]model.PresenceVisibility, error)
+
+	// VisibilityForViewers returns what each viewer may see of userID's presence
+	VisibilityForViewers(ctx context.Context, userID uuid.UUID, viewerIDs []uuid.UUID) (map[uuid.UUID]model.PresenceVisibility, error)
+}
+
+type presencePrivacy struct {
+	repo repo.PrivacyRepository
+}
+
+// NewPresencePrivacy creates a presence privacy check backed by the repository
+func NewPresencePrivacy(r repo.PrivacyRepository) PresencePrivacy {
+	return &presencePrivacy{repo: r}
+}
+
+func (p *presencePrivacy) VisibilityForViewer(ctx context.Context, viewerID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]model.PresenceVisibility, error) {
+	visibility := make(map[uuid.UUID]model.PresenceVisibility, len(userIDs))
+	others := make([]uuid.UUID, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID == viewerID {
+			visibility[userID] = model.FullPresenceVisibility
+			continue
+		}
+		others = append(others, userID)
+	}
+	if len(others) == 0 {
+		return visibility, nil
+	}
+
+	settings, err := p.repo.GetPresencePrivacy(ctx, others)
+	if err != nil {
+		return nil, err
+	}
+
+	contactsOnly := make([]uuid.UUID, 0)
+	for _, userID := range others {
+		if needsContactCheck(settings[userID]) {
+			contactsOnly = append(contactsOnly, userID)
+		}
+	}
+	contacts, err := p.repo.GetUsersWithContact(ctx, contactsOnly, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range others {
+		visibility[userID] = presenceVisibility(settings[userID], contacts[userID])
+	}
+	return visibility, nil
+}
+
+func (p *presencePrivacy) VisibilityForViewers(ctx context.Context, userID uuid.UUID, viewerIDs []uuid.UUID) (map[uuid.UUID]model.PresenceVisibility, error) {
+	visibility := make(map[uuid.UUID]model.PresenceVisibility, len(viewerIDs))
+	others := make([]uuid.UUID, 0, len(viewerIDs))
+	for _, viewerID := range viewerIDs {
+		if viewerID == userID {
+			visibility[viewerID] = model.FullPresenceVisibility
+			continue
+		}
+		others = append(others, viewerID)
+	}
+	if len(others) == 0 {
+		return visibility, nil
+	}
+
+	settings, err := p.repo.GetPresencePrivacy(ctx, []uuid.UUID{userID})
+	if err != nil {
+		return nil, err
+	}
+	privacy := settings[userID]
+
+	contacts := make(map[uuid.UUID]bool)
+	if needsContactCheck(privacy) {
+		if contacts, err = p.repo.GetContactsAmong(ctx, userID, others); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, viewerID := range others {
+		visibility[viewerID] = presenceVisibility(privacy, contacts[viewerID])
+	}
+	return visibility, nil
+}
+
+func needsContactCheck(privacy *model.PresencePrivacy) bool {
+	return privacy != nil &&
+		(privacy.LastSeenVisibility == model.VisibilityContacts || privacy.OnlineStatusVisibility == model.VisibilityContacts)
+}
+
+// presenceVisibility applies a user's settings to one viewer; users without
+// saved settings are visible to everyone
+func presenceVisibility(privacy *model.PresencePrivacy, isContact bool) model.PresenceVisibility {
+	if privacy == nil {
+		return model.FullPresenceVisibility
+	}
+	return model.PresenceVisibility{
+		Status:   pkgPresence.VisibleTo(privacy.OnlineStatusVisibility, isContact),
+		LastSeen: pkgPresence.VisibleTo(privacy.LastSeenVisibility, isContact),
+	}
+}
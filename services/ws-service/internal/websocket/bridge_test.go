@@ -0,0 +1,141 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"shared/pkg/cache/memory"
+	"shared/pkg/logger"
+	"shared/server/common/token"
+	"ws-service/internal/broadcast"
+
+	"github.com/google/uuid"
+)
+
+func newBridgedManager(t *testing.T, ps broadcast.PubSub) *Manager {
+	t.Helper()
+	m := NewManager(Config{}, logger.NewNoop())
+	m.SetBridge(broadcast.NewBridge(ps, "", "", logger.NewNoop()))
+	if err := m.Start(); err != nil {
+		t.Fatalf("failed to start manager: %v", err)
+	}
+	t.Cleanup(func() { _ = m.Stop() })
+	return m
+}
+
+func TestBridge_DeliversAcrossReplicas(t *testing.T) {
+	ps := memory.New()
+	replicaA := newBridgedManager(t, ps)
+	replicaB := newBridgedManager(t, ps)
+	conv := uuid.New()
+
+	localConn := newTestConnection(t, replicaA, uuid.New())
+	remoteConn := newTestConnection(t, replicaB, uuid.New())
+	replicaA.subscriptions.Subscribe(localConn.ID(), ConversationTopic(conv))
+	replicaB.subscriptions.Subscribe(remoteConn.ID(), ConversationTopic(conv))
+
+	sent, err := replicaA.BroadcastToConversation(conv, "message.new", map[string]string{"content": "hi"})
+	if err != nil {
+		t.Fatalf("broadcast failed: %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("expected 1 local recipient, got %d", sent)
+	}
+
+	select {
+	case frame := <-remoteConn.SendChan():
+		local := <-localConn.SendChan()
+		if string(frame) != string(local) {
+			t.Fatalf("replicas delivered different frames:\n%s\n%s", frame, local)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("subscriber on replica B did not receive the event")
+	}
+
+	// The originating replica must not deliver its own event a second time
+	time.Sleep(50 * time.Millisecond)
+	if n := len(localConn.SendChan()); n != 0 {
+		t.Fatalf("local subscriber received %d duplicate frames", n)
+	}
+}
+
+func TestBridge_RespectsExclusionsRemotely(t *testing.T) {
+	ps := memory.New()
+	replicaA := newBridgedManager(t, ps)
+	replicaB := newBridgedManager(t, ps)
+	conv := uuid.New()
+	sender := uuid.New()
+
+	senderDevice := newTestConnection(t, replicaB, sender)
+	replicaB.subscriptions.Subscribe(senderDevice.ID(), ConversationTopic(conv))
+
+	if _, err := replicaA.BroadcastToConversation(conv, "message.read", nil, sender); err != nil {
+		t.Fatalf("broadcast failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(senderDevice.SendChan()) != 0 {
+		t.Fatalf("excluded user received the event on another replica")
+	}
+}
+
+func TestRevocationListener_DisconnectsRevokedDevice(t *testing.T) {
+	ps := memory.New()
+	m := NewManager(Config{}, logger.NewNoop())
+	m.SetRevocationListener(broadcast.NewRevocationListener(ps, logger.NewNoop()))
+	if err := m.Start(); err != nil {
+		t.Fatalf("failed to start manager: %v", err)
+	}
+	t.Cleanup(func() { _ = m.Stop() })
+
+	user := uuid.New()
+	revoked := newTestConnection(t, m, user)
+	other := newTestConnection(t, m, user)
+
+	payload, _ := json.Marshal(token.DeviceRevocation{UserID: user.String(), DeviceID: revoked.ID()})
+	if err := ps.Publish(context.Background(), token.DeviceRevokedChannel, payload); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for revoked.IsConnected() {
+		if time.Now().After(deadline) {
+			t.Fatalf("revoked device is still connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !other.IsConnected() {
+		t.Fatalf("the user's other device was disconnected too")
+	}
+}
+
+func TestBridge_RelaysCallSignalsToOtherReplicas(t *testing.T) {
+	ps := memory.New()
+	replicaA := newBridgedManager(t, ps)
+	replicaB := newBridgedManager(t, ps)
+	callee := uuid.New()
+
+	calleeDevice := newTestConnection(t, replicaB, callee)
+	eavesdropper := newTestConnection(t, replicaB, uuid.New())
+	replicaB.subscriptions.Subscribe(eavesdropper.ID(), UserTopic(callee))
+
+	replicaA.sendToUser(callee, "call.offer", map[string]string{"sdp": "offer-sdp"})
+
+	select {
+	case frame := <-calleeDevice.SendChan():
+		var msg struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(frame, &msg); err != nil || msg.Type != "call.offer" {
+			t.Fatalf("callee got %s, want a call.offer", frame)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("callee on replica B did not receive the offer")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if n := len(eavesdropper.SendChan()); n != 0 {
+		t.Fatalf("a subscriber to another user's topic received %d frames", n)
+	}
+}
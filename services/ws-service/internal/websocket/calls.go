@@ -0,0 +1,225 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"shared/pkg/logger"
+	"shared/server/websocket/router"
+	"ws-service/internal/model"
+	"ws-service/internal/protocol"
+
+	"github.com/google/uuid"
+)
+
+// CallSignaling records calls as clients signal them and decides who may
+// offer, answer and exchange candidates
+type CallSignaling interface {
+	StartCall(ctx context.Context, conversationID, initiatorID uuid.UUID, callType string) (*model.Call, []*model.CallParticipant, error)
+	AnswerCall(ctx context.Context, callID, userID uuid.UUID) (*model.Call, error)
+	AuthorizeSignal(ctx context.Context, callID, fromUserID, toUserID uuid.UUID) (*model.Call, error)
+	Hangup(ctx context.Context, callID, userID uuid.UUID, reason string) (*model.Call, error)
+}
+
+// errCallsDisabled is returned for call frames when no CallSignaling is set
+var errCallsDisabled = errors.New("call signaling is not available")
+
+// callSignalingTimeout bounds the database work behind each call frame
+const callSignalingTimeout = 5 * time.Second
+
+// SetCallSignaling enables the call.* message handlers
+func (m *Manager) SetCallSignaling(c CallSignaling) {
+	m.calls = c
+}
+
+// handleCallOffer creates the call, rings the other members of the
+// conversation with the caller's SDP and replies with the new call's state
+func (m *Manager) handleCallOffer(ctx context.Context, msg *router.Message) error {
+	conn, ok := m.getConnection(msg)
+	if !ok {
+		return nil
+	}
+	if m.calls == nil {
+		return errCallsDisabled
+	}
+	userIDVal, _ := conn.GetMetadata("user_id")
+	userID := userIDVal.(uuid.UUID)
+
+	var payload protocol.CallOfferPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callSignalingTimeout)
+	defer cancel()
+	call, participants, err := m.calls.StartCall(ctx, payload.ConversationID, userID, payload.CallType)
+	if err != nil {
+		return err
+	}
+
+	offer := protocol.CallSignalEvent{
+		CallID:         call.ID,
+		ConversationID: call.ConversationID,
+		FromUserID:     userID,
+		CallType:       call.CallType,
+		SDP:            payload.SDP,
+		Timestamp:      time.Now(),
+	}
+	for _, p := range participants {
+		if p.UserID != userID {
+			m.sendToUser(p.UserID, "call.offer", offer)
+		}
+	}
+
+	state := callStateEvent(call, userID)
+	m.broadcastCallState(state)
+
+	reply := protocol.ServerMessage{
+		ID:        uuid.New().String(),
+		Type:      "call.offered",
+		Payload:   state,
+		Timestamp: time.Now(),
+		RequestID: msg.Metadata["message_id"].(string),
+	}
+	data, _ := json.Marshal(reply)
+	return conn.Send(data)
+}
+
+// handleCallAnswer joins an invited user to the call and relays their SDP to
+// the caller
+func (m *Manager) handleCallAnswer(ctx context.Context, msg *router.Message) error {
+	conn, ok := m.getConnection(msg)
+	if !ok {
+		return nil
+	}
+	if m.calls == nil {
+		return errCallsDisabled
+	}
+	userIDVal, _ := conn.GetMetadata("user_id")
+	userID := userIDVal.(uuid.UUID)
+
+	var payload protocol.CallAnswerPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callSignalingTimeout)
+	defer cancel()
+	call, err := m.calls.AnswerCall(ctx, payload.CallID, userID)
+	if err != nil {
+		return err
+	}
+
+	m.sendToUser(call.InitiatorUserID, "call.answer", protocol.CallSignalEvent{
+		CallID:         call.ID,
+		ConversationID: call.ConversationID,
+		FromUserID:     userID,
+		SDP:            payload.SDP,
+		Timestamp:      time.Now(),
+	})
+	m.broadcastCallState(callStateEvent(call, userID))
+	return nil
+}
+
+// handleCallICE relays an ICE candidate between two participants of a call
+func (m *Manager) handleCallICE(ctx context.Context, msg *router.Message) error {
+	conn, ok := m.getConnection(msg)
+	if !ok {
+		return nil
+	}
+	if m.calls == nil {
+		return errCallsDisabled
+	}
+	userIDVal, _ := conn.GetMetadata("user_id")
+	userID := userIDVal.(uuid.UUID)
+
+	var payload protocol.CallICEPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callSignalingTimeout)
+	defer cancel()
+	call, err := m.calls.AuthorizeSignal(ctx, payload.CallID, userID, payload.TargetUserID)
+	if err != nil {
+		return err
+	}
+
+	m.sendToUser(payload.TargetUserID, "call.ice", protocol.CallSignalEvent{
+		CallID:         call.ID,
+		ConversationID: call.ConversationID,
+		FromUserID:     userID,
+		Candidate:      payload.Candidate,
+		Timestamp:      time.Now(),
+	})
+	return nil
+}
+
+// handleCallHangup leaves or declines the call and tells the conversation
+func (m *Manager) handleCallHangup(ctx context.Context, msg *router.Message) error {
+	conn, ok := m.getConnection(msg)
+	if !ok {
+		return nil
+	}
+	if m.calls == nil {
+		return errCallsDisabled
+	}
+	userIDVal, _ := conn.GetMetadata("user_id")
+	userID := userIDVal.(uuid.UUID)
+
+	var payload protocol.CallHangupPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callSignalingTimeout)
+	defer cancel()
+	call, err := m.calls.Hangup(ctx, payload.CallID, userID, payload.Reason)
+	if err != nil {
+		return err
+	}
+
+	m.broadcastCallState(callStateEvent(call, userID))
+	return nil
+}
+
+// broadcastCallState sends a call's new state to its conversation's subscribers
+func (m *Manager) broadcastCallState(state protocol.CallStateEvent) {
+	if _, err := m.BroadcastToConversation(state.ConversationID, "call.state", state); err != nil {
+		m.log.Warn("Failed to broadcast call state",
+			logger.String("call_id", state.CallID.String()),
+			logger.String("status", state.Status),
+			logger.Error(err),
+		)
+	}
+}
+
+// sendToUser relays a signaling frame to every device userID has connected,
+// here and, through the bridge, on the other replicas. Signaling frames skip
+// the replay buffer: a stale offer or ICE candidate is no use after a
+// reconnect.
+func (m *Manager) sendToUser(userID uuid.UUID, messageType string, payload interface{}) {
+	topic := UserTopic(userID)
+	data := m.marshalPayload(messageType, payload)
+	m.deliverToUser(topic, userID, data)
+	m.publishToReplicas(topic, data, nil)
+}
+
+func callStateEvent(call *model.Call, userID uuid.UUID) protocol.CallStateEvent {
+	state := protocol.CallStateEvent{
+		CallID:          call.ID,
+		ConversationID:  call.ConversationID,
+		CallType:        call.CallType,
+		InitiatorUserID: call.InitiatorUserID,
+		Status:          call.Status,
+		UserID:          userID,
+		DurationSeconds: call.DurationSeconds,
+		Timestamp:       time.Now(),
+	}
+	if call.EndReason != nil {
+		state.EndReason = *call.EndReason
+	}
+	return state
+}
@@ -0,0 +1,324 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"shared/pkg/logger"
+	"shared/server/websocket/connection"
+	wsErrors "ws-service/internal/errors"
+	"ws-service/internal/model"
+	"ws-service/internal/protocol"
+	"ws-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// memCallRepo keeps calls in memory in place of messages.calls and
+// messages.call_participants
+type memCallRepo struct {
+	mu           sync.Mutex
+	members      map[uuid.UUID][]uuid.UUID
+	calls        map[uuid.UUID]*model.Call
+	participants map[uuid.UUID][]*model.CallParticipant
+}
+
+func newMemCallRepo() *memCallRepo {
+	return &memCallRepo{
+		members:      make(map[uuid.UUID][]uuid.UUID),
+		calls:        make(map[uuid.UUID]*model.Call),
+		participants: make(map[uuid.UUID][]*model.CallParticipant),
+	}
+}
+
+func (r *memCallRepo) GetConversationMemberIDs(ctx context.Context, conversationID uuid.UUID) ([]uuid.UUID, error) {
+	return r.members[conversationID], nil
+}
+
+func (r *memCallRepo) CreateCall(ctx context.Context, call *model.Call, participants []*model.CallParticipant) error {
+	stored := *call
+	r.calls[call.ID] = &stored
+	for _, p := range participants {
+		copied := *p
+		r.participants[call.ID] = append(r.participants[call.ID], &copied)
+	}
+	return nil
+}
+
+func (r *memCallRepo) GetCall(ctx context.Context, callID uuid.UUID) (*model.Call, error) {
+	call, ok := r.calls[callID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *call
+	return &copied, nil
+}
+
+func (r *memCallRepo) GetCallParticipants(ctx context.Context, callID uuid.UUID) ([]*model.CallParticipant, error) {
+	participants := make([]*model.CallParticipant, 0, len(r.participants[callID]))
+	for _, p := range r.participants[callID] {
+		copied := *p
+		participants = append(participants, &copied)
+	}
+	return participants, nil
+}
+
+// ModifyCall applies modify to copies and stores them only when it succeeds,
+// holding mu the way the real repository holds the rows locked
+func (r *memCallRepo) ModifyCall(ctx context.Context, callID uuid.UUID, modify func(*model.Call, []*model.CallParticipant) error) (*model.Call, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	call, _ := r.GetCall(ctx, callID)
+	if call == nil {
+		return nil, nil
+	}
+	participants, _ := r.GetCallParticipants(ctx, callID)
+	if err := modify(call, participants); err != nil {
+		return nil, err
+	}
+
+	stored := *call
+	r.calls[callID] = &stored
+	r.participants[callID] = participants
+	return call, nil
+}
+
+func (r *memCallRepo) participantStatus(callID, userID uuid.UUID) string {
+	for _, p := range r.participants[callID] {
+		if p.UserID == userID {
+			return p.Status
+		}
+	}
+	return ""
+}
+
+type callFixture struct {
+	m            *Manager
+	repo         *memCallRepo
+	conversation uuid.UUID
+	caller       uuid.UUID
+	callee       uuid.UUID
+	callerConn   *connection.Connection
+	calleeConn   *connection.Connection
+}
+
+func newCallFixture(t *testing.T) *callFixture {
+	t.Helper()
+	f := &callFixture{
+		m:            NewManager(Config{}, logger.NewNoop()),
+		repo:         newMemCallRepo(),
+		conversation: uuid.New(),
+		caller:       uuid.New(),
+		callee:       uuid.New(),
+	}
+	f.repo.members[f.conversation] = []uuid.UUID{f.caller, f.callee}
+	f.m.SetCallSignaling(service.NewCallService(f.repo))
+
+	f.callerConn = newTestConnection(t, f.m, f.caller)
+	f.calleeConn = newTestConnection(t, f.m, f.callee)
+	f.m.subscriptions.Subscribe(f.callerConn.ID(), ConversationTopic(f.conversation))
+	f.m.subscriptions.Subscribe(f.calleeConn.ID(), ConversationTopic(f.conversation))
+	return f
+}
+
+// send hands a frame from conn to the manager as if it came off the socket
+func (f *callFixture) send(t *testing.T, conn *connection.Connection, msgType string, payload interface{}) {
+	t.Helper()
+	raw, _ := json.Marshal(payload)
+	data, _ := json.Marshal(protocol.ClientMessage{ID: uuid.New().String(), Type: msgType, Payload: raw})
+	if err := f.m.HandleMessage(context.Background(), conn, data); err != nil {
+		t.Fatalf("%s: %v", msgType, err)
+	}
+}
+
+// drain returns the frames queued for conn, keyed by type
+func drain(t *testing.T, conn *connection.Connection) map[string][]protocol.ServerMessage {
+	t.Helper()
+	frames := make(map[string][]protocol.ServerMessage)
+	for len(conn.SendChan()) > 0 {
+		var msg protocol.ServerMessage
+		if err := json.Unmarshal(<-conn.SendChan(), &msg); err != nil {
+			t.Fatalf("decode frame: %v", err)
+		}
+		frames[msg.Type] = append(frames[msg.Type], msg)
+	}
+	return frames
+}
+
+func callStatus(t *testing.T, msg protocol.ServerMessage) string {
+	t.Helper()
+	payload, _ := json.Marshal(msg.Payload)
+	var state protocol.CallStateEvent
+	if err := json.Unmarshal(payload, &state); err != nil {
+		t.Fatalf("decode call state: %v", err)
+	}
+	return state.Status
+}
+
+func (f *callFixture) offer(t *testing.T) uuid.UUID {
+	t.Helper()
+	f.send(t, f.callerConn, "call.offer", protocol.CallOfferPayload{
+		ConversationID: f.conversation,
+		CallType:       model.CallTypeVideo,
+		SDP:            json.RawMessage(`"offer-sdp"`),
+	})
+	if len(f.repo.calls) != 1 {
+		t.Fatalf("got %d calls recorded, want 1", len(f.repo.calls))
+	}
+	for id := range f.repo.calls {
+		return id
+	}
+	return uuid.Nil
+}
+
+func TestCallLifecycle_OfferAnswerHangup(t *testing.T) {
+	f := newCallFixture(t)
+
+	callID := f.offer(t)
+	if got := f.repo.calls[callID].Status; got != model.CallStatusRinging {
+		t.Fatalf("call status after offer = %q, want ringing", got)
+	}
+	if got := f.repo.participantStatus(callID, f.callee); got != model.CallParticipantInvited {
+		t.Fatalf("callee status after offer = %q, want invited", got)
+	}
+	callee := drain(t, f.calleeConn)
+	if len(callee["call.offer"]) != 1 || callStatus(t, callee["call.state"][0]) != model.CallStatusRinging {
+		t.Fatalf("callee got %v, want the offer and a ringing state", callee)
+	}
+	caller := drain(t, f.callerConn)
+	if len(caller["call.offered"]) != 1 || len(caller["call.offer"]) != 0 {
+		t.Fatalf("caller got %v, want only its reply and the state", caller)
+	}
+
+	f.send(t, f.calleeConn, "call.answer", protocol.CallAnswerPayload{CallID: callID, SDP: json.RawMessage(`"answer-sdp"`)})
+	call := f.repo.calls[callID]
+	if call.Status != model.CallStatusActive || call.StartedAt == nil {
+		t.Fatalf("call after answer = %+v, want active with a start time", call)
+	}
+	if got := f.repo.participantStatus(callID, f.callee); got != model.CallParticipantJoined {
+		t.Fatalf("callee status after answer = %q, want joined", got)
+	}
+	caller = drain(t, f.callerConn)
+	if len(caller["call.answer"]) != 1 || callStatus(t, caller["call.state"][0]) != model.CallStatusActive {
+		t.Fatalf("caller got %v, want the answer and an active state", caller)
+	}
+	drain(t, f.calleeConn)
+
+	f.send(t, f.calleeConn, "call.ice", protocol.CallICEPayload{CallID: callID, TargetUserID: f.caller, Candidate: json.RawMessage(`{"candidate":"c1"}`)})
+	if got := len(drain(t, f.callerConn)["call.ice"]); got != 1 {
+		t.Fatalf("caller got %d ICE candidates, want 1", got)
+	}
+
+	f.send(t, f.callerConn, "call.hangup", protocol.CallHangupPayload{CallID: callID})
+	call = f.repo.calls[callID]
+	if call.Status != model.CallStatusEnded || call.EndReason == nil || *call.EndReason != model.CallEndCompleted {
+		t.Fatalf("call after hangup = %+v, want ended as completed", call)
+	}
+	if call.EndedAt == nil || call.DurationSeconds == nil {
+		t.Fatalf("call after hangup has no end time or duration")
+	}
+	for _, userID := range []uuid.UUID{f.caller, f.callee} {
+		if got := f.repo.participantStatus(callID, userID); got != model.CallParticipantLeft {
+			t.Fatalf("participant %s status = %q, want left", userID, got)
+		}
+	}
+	if got := callStatus(t, drain(t, f.calleeConn)["call.state"][0]); got != model.CallStatusEnded {
+		t.Fatalf("callee saw state %q, want ended", got)
+	}
+}
+
+func TestCallLifecycle_DeclineEndsAsRejected(t *testing.T) {
+	f := newCallFixture(t)
+	callID := f.offer(t)
+
+	f.send(t, f.calleeConn, "call.hangup", protocol.CallHangupPayload{CallID: callID, Reason: "busy"})
+
+	call := f.repo.calls[callID]
+	if call.Status != model.CallStatusRejected || *call.EndReason != model.CallEndRejected {
+		t.Fatalf("call after decline = %+v, want rejected", call)
+	}
+	if got := f.repo.participantStatus(callID, f.callee); got != model.CallParticipantRejected {
+		t.Fatalf("callee status = %q, want rejected", got)
+	}
+}
+
+func TestCallSignaling_RejectsOutsiders(t *testing.T) {
+	f := newCallFixture(t)
+	outsider := newTestConnection(t, f.m, uuid.New())
+
+	f.send(t, outsider, "call.offer", protocol.CallOfferPayload{ConversationID: f.conversation, CallType: model.CallTypeVoice})
+	if got := errorMessage(t, outsider); !strings.Contains(got, wsErrors.ErrCodeNotParticipant) {
+		t.Fatalf("offer from a non-member failed with %q, want %s", got, wsErrors.ErrCodeNotParticipant)
+	}
+	if len(f.repo.calls) != 0 {
+		t.Fatalf("offer from a non-member recorded a call")
+	}
+
+	callID := f.offer(t)
+	f.send(t, outsider, "call.answer", protocol.CallAnswerPayload{CallID: callID})
+	if got := errorMessage(t, outsider); !strings.Contains(got, wsErrors.ErrCodeNotInvited) {
+		t.Fatalf("answer from an uninvited user failed with %q, want %s", got, wsErrors.ErrCodeNotInvited)
+	}
+	if got := f.repo.calls[callID].Status; got != model.CallStatusRinging {
+		t.Fatalf("call status = %q, want it still ringing", got)
+	}
+}
+
+// errorMessage returns the message of the single error frame queued for conn
+func errorMessage(t *testing.T, conn *connection.Connection) string {
+	t.Helper()
+	errs := drain(t, conn)["error"]
+	if len(errs) != 1 {
+		t.Fatalf("got %d error frames, want 1", len(errs))
+	}
+	payload, _ := json.Marshal(errs[0].Payload)
+	var e protocol.ErrorPayload
+	if err := json.Unmarshal(payload, &e); err != nil {
+		t.Fatalf("decode error frame: %v", err)
+	}
+	return e.Message
+}
+
+func TestCallService_ConcurrentHangupsEndTheCall(t *testing.T) {
+	repo := newMemCallRepo()
+	conversation, caller := uuid.New(), uuid.New()
+	callees := []uuid.UUID{uuid.New(), uuid.New()}
+	repo.members[conversation] = append([]uuid.UUID{caller}, callees...)
+	calls := service.NewCallService(repo)
+	ctx := context.Background()
+
+	call, _, err := calls.StartCall(ctx, conversation, caller, model.CallTypeVoice)
+	if err != nil {
+		t.Fatalf("start call: %v", err)
+	}
+	for _, callee := range callees {
+		if _, err := calls.AnswerCall(ctx, call.ID, callee); err != nil {
+			t.Fatalf("answer: %v", err)
+		}
+	}
+
+	// Each hangup leaves the caller with one fewer peer; only seeing the
+	// other's write tells the second one the caller is now alone
+	var wg sync.WaitGroup
+	for _, callee := range callees {
+		wg.Add(1)
+		go func(userID uuid.UUID) {
+			defer wg.Done()
+			if _, err := calls.Hangup(ctx, call.ID, userID, ""); err != nil {
+				t.Errorf("hangup: %v", err)
+			}
+		}(callee)
+	}
+	wg.Wait()
+
+	if got := repo.calls[call.ID].Status; got != model.CallStatusEnded {
+		t.Fatalf("call status = %q, want ended once both callees left", got)
+	}
+	if got := repo.participantStatus(call.ID, caller); got != model.CallParticipantLeft {
+		t.Fatalf("caller status = %q, want left", got)
+	}
+}
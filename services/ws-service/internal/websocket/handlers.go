@@ -3,10 +3,13 @@ package websocket
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
+	"shared/pkg/logger"
 	"shared/server/websocket/connection"
 	"shared/server/websocket/router"
+	"ws-service/internal/model"
 	"ws-service/internal/protocol"
 
 	"github.com/google/uuid"
@@ -37,7 +40,17 @@ func (m *Manager) handleSubscribe(ctx context.Context, msg *router.Message) erro
 	for _, topic := range payload.Topics {
 		resourceID := protocol.GetResourceID(topic, payload.Filters)
 		topicKey := string(topic) + ":" + resourceID
+
+		if payload.LastEventID == nil || m.replay == nil {
+			m.subscriptions.Subscribe(conn.ID(), topicKey)
+			continue
+		}
+
+		// Go live first so nothing published during the replay is lost.
+		// Those events may arrive twice or ahead of the replayed ones;
+		// clients dedupe and order on event_id.
 		m.subscriptions.Subscribe(conn.ID(), topicKey)
+		m.replayMissed(ctx, conn, topicKey, *payload.LastEventID)
 	}
 
 	// Send acknowledgment
@@ -53,6 +66,51 @@ func (m *Manager) handleSubscribe(ctx context.Context, msg *router.Message) erro
 	return conn.Send(data)
 }
 
+// replayMissed sends buffered topic events after lastID to the connection.
+// When the buffer no longer covers lastID it sends a resync_required frame
+// instead.
+func (m *Manager) replayMissed(ctx context.Context, conn *connection.Connection, topic string, lastID int64) {
+	ctx, cancel := context.WithTimeout(ctx, cacheCallTimeout)
+	defer cancel()
+	events, latest, err := m.replay.Since(ctx, topic, lastID)
+	if err != nil {
+		if !errors.Is(err, ErrResyncRequired) {
+			m.log.Warn("Failed to load replay buffer",
+				logger.String("topic", topic),
+				logger.Error(err),
+			)
+		}
+		resync := protocol.ServerMessage{
+			ID:   uuid.New().String(),
+			Type: "resync_required",
+			Payload: protocol.ResyncRequiredPayload{
+				Topic:         topic,
+				LastEventID:   lastID,
+				LatestEventID: latest,
+			},
+			Timestamp: time.Now(),
+			Topic:     topic,
+		}
+		data, _ := json.Marshal(resync)
+		conn.Send(data)
+		return
+	}
+
+	for _, data := range events {
+		if err := conn.Send(data); err != nil {
+			return
+		}
+	}
+
+	if len(events) > 0 {
+		m.log.Debug("Replayed missed events",
+			logger.String("conn_id", conn.ID()),
+			logger.String("topic", topic),
+			logger.Int("count", len(events)),
+		)
+	}
+}
+
 // handleUnsubscribe handles unsubscribe requests
 func (m *Manager) handleUnsubscribe(ctx context.Context, msg *router.Message) error {
 	conn, ok := m.getConnection(msg)
@@ -66,7 +124,8 @@ func (m *Manager) handleUnsubscribe(ctx context.Context, msg *router.Message) er
 	}
 
 	for _, topic := range payload.Topics {
-		m.subscriptions.Unsubscribe(conn.ID(), string(topic))
+		resourceID := protocol.GetResourceID(topic, payload.Filters)
+		m.subscriptions.Unsubscribe(conn.ID(), string(topic)+":"+resourceID)
 	}
 
 	// Send acknowledgment
@@ -97,10 +156,8 @@ func (m *Manager) handlePresenceUpdate(ctx context.Context, msg *router.Message)
 		return err
 	}
 
+	// Subscribers are notified through the tracker's change callback
 	m.presence.UpdatePresence(userID, PresenceStatus(payload.Status), payload.CustomStatus)
-
-	// Broadcast presence update to contacts
-	// TODO: Get user's contacts and broadcast
 	return nil
 }
 
@@ -111,12 +168,18 @@ func (m *Manager) handlePresenceQuery(ctx context.Context, msg *router.Message)
 		return nil
 	}
 
+	userIDVal, _ := conn.GetMetadata("user_id")
+	userID := userIDVal.(uuid.UUID)
+
 	var payload protocol.PresenceQueryPayload
 	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
 		return err
 	}
 
-	presences := m.presence.GetBulkPresence(payload.UserIDs)
+	presences, _, err := m.visiblePresences(userID, payload.UserIDs)
+	if err != nil {
+		return err
+	}
 
 	response := protocol.ServerMessage{
 		ID:        uuid.New().String(),
@@ -130,6 +193,103 @@ func (m *Manager) handlePresenceQuery(ctx context.Context, msg *router.Message)
 	return conn.Send(data)
 }
 
+// handlePresenceSubscribe starts pushing presence.update events for the given
+// users to this connection and replies with their current presence. Users
+// whose status the subscriber may not see read as offline and are not
+// watched. The subscriptions are dropped when the connection closes.
+func (m *Manager) handlePresenceSubscribe(ctx context.Context, msg *router.Message) error {
+	conn, ok := m.getConnection(msg)
+	if !ok {
+		return nil
+	}
+
+	userIDVal, _ := conn.GetMetadata("user_id")
+	userID := userIDVal.(uuid.UUID)
+
+	var payload protocol.PresenceSubscribePayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return err
+	}
+
+	if len(payload.UserIDs) > protocol.MaxPresenceSubscriptions {
+		return ErrTooManyPresenceUsers
+	}
+
+	presences, visible, err := m.visiblePresences(userID, payload.UserIDs)
+	if err != nil {
+		return err
+	}
+
+	for watchedID := range visible {
+		m.subscriptions.Subscribe(conn.ID(), PresenceTopic(watchedID))
+	}
+
+	response := protocol.ServerMessage{
+		ID:        uuid.New().String(),
+		Type:      "presence.subscribed",
+		Payload:   presences,
+		Timestamp: time.Now(),
+		RequestID: msg.Metadata["message_id"].(string),
+	}
+
+	data, _ := json.Marshal(response)
+	return conn.Send(data)
+}
+
+// handlePresenceUnsubscribe stops presence.update events for the given users
+func (m *Manager) handlePresenceUnsubscribe(ctx context.Context, msg *router.Message) error {
+	conn, ok := m.getConnection(msg)
+	if !ok {
+		return nil
+	}
+
+	var payload protocol.PresenceSubscribePayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return err
+	}
+
+	for _, userID := range payload.UserIDs {
+		m.subscriptions.Unsubscribe(conn.ID(), PresenceTopic(userID))
+	}
+
+	response := protocol.ServerMessage{
+		ID:        uuid.New().String(),
+		Type:      "presence.unsubscribed",
+		Payload:   payload,
+		Timestamp: time.Now(),
+		RequestID: msg.Metadata["message_id"].(string),
+	}
+
+	data, _ := json.Marshal(response)
+	return conn.Send(data)
+}
+
+// visiblePresences returns each user's presence as viewerID may see it, and
+// the users whose status the viewer may see at all
+func (m *Manager) visiblePresences(viewerID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]*PresenceInfo, map[uuid.UUID]bool, error) {
+	visibility, err := m.presenceVisibilityForViewer(viewerID, userIDs)
+	if err != nil {
+		m.log.Warn("Failed to check presence privacy",
+			logger.String("user_id", viewerID.String()),
+			logger.Error(err),
+		)
+		return nil, nil, ErrPresenceUnavailable
+	}
+
+	presences := make(map[uuid.UUID]*PresenceInfo, len(userIDs))
+	visible := make(map[uuid.UUID]bool, len(userIDs))
+	for _, userID := range userIDs {
+		info, ok := m.presence.snapshot(userID).visibleAs(visibility[userID])
+		if !ok {
+			presences[userID] = hiddenPresence(userID)
+			continue
+		}
+		presences[userID] = &info
+		visible[userID] = true
+	}
+	return presences, visible, nil
+}
+
 // handleTypingStart handles typing start events
 func (m *Manager) handleTypingStart(ctx context.Context, msg *router.Message) error {
 	conn, ok := m.getConnection(msg)
@@ -145,16 +305,17 @@ func (m *Manager) handleTypingStart(ctx context.Context, msg *router.Message) er
 		return err
 	}
 
-	m.typing.StartTyping(payload.ConversationID, userID)
+	m.typing.StartTyping(ctx, payload.ConversationID, userID)
 
 	// Broadcast to conversation participants
-	return m.BroadcastToConversation(payload.ConversationID, "typing.start",
+	_, err := m.BroadcastToConversation(payload.ConversationID, "typing.start",
 		protocol.TypingEvent{
 			UserID:         userID,
 			ConversationID: payload.ConversationID,
 			IsTyping:       true,
 			Timestamp:      time.Now(),
 		}, userID)
+	return err
 }
 
 // handleTypingStop handles typing stop events
@@ -172,16 +333,36 @@ func (m *Manager) handleTypingStop(ctx context.Context, msg *router.Message) err
 		return err
 	}
 
-	m.typing.StopTyping(payload.ConversationID, userID)
+	m.typing.StopTyping(ctx, payload.ConversationID, userID)
 
 	// Broadcast to conversation participants
-	return m.BroadcastToConversation(payload.ConversationID, "typing.stop",
+	_, err := m.BroadcastToConversation(payload.ConversationID, "typing.stop",
 		protocol.TypingEvent{
 			UserID:         userID,
 			ConversationID: payload.ConversationID,
 			IsTyping:       false,
 			Timestamp:      time.Now(),
 		}, userID)
+	return err
+}
+
+// broadcastTypingExpired tells a conversation that a user whose client never
+// sent typing.stop is no longer typing
+func (m *Manager) broadcastTypingExpired(indicator *model.TypingIndicator) {
+	_, err := m.BroadcastToConversation(indicator.ConversationID, "typing.stop",
+		protocol.TypingEvent{
+			UserID:         indicator.UserID,
+			ConversationID: indicator.ConversationID,
+			IsTyping:       false,
+			Timestamp:      time.Now(),
+		}, indicator.UserID)
+	if err != nil {
+		m.log.Warn("Failed to broadcast expired typing indicator",
+			logger.String("user_id", indicator.UserID.String()),
+			logger.String("conversation_id", indicator.ConversationID.String()),
+			logger.Error(err),
+		)
+	}
 }
 
 // handleMarkRead handles read receipt
@@ -200,13 +381,14 @@ func (m *Manager) handleMarkRead(ctx context.Context, msg *router.Message) error
 	}
 
 	// Broadcast read receipt
-	return m.BroadcastToConversation(payload.ConversationID, "message.read",
+	_, err := m.BroadcastToConversation(payload.ConversationID, "message.read",
 		protocol.ReadReceiptEvent{
 			UserID:         userID,
 			ConversationID: payload.ConversationID,
 			MessageIDs:     payload.MessageIDs,
 			Timestamp:      time.Now(),
 		}, userID)
+	return err
 }
 
 // handleMarkDelivered handles delivery receipt
@@ -225,37 +407,14 @@ func (m *Manager) handleMarkDelivered(ctx context.Context, msg *router.Message)
 	}
 
 	// Broadcast delivery receipt
-	return m.BroadcastToConversation(payload.ConversationID, "message.delivered",
+	_, err := m.BroadcastToConversation(payload.ConversationID, "message.delivered",
 		protocol.DeliveredReceiptEvent{
 			UserID:         userID,
 			ConversationID: payload.ConversationID,
 			MessageIDs:     payload.MessageIDs,
 			Timestamp:      time.Now(),
 		}, userID)
-}
-
-// handleCallOffer handles call offer
-func (m *Manager) handleCallOffer(ctx context.Context, msg *router.Message) error {
-	// TODO: Implement call signaling
-	return nil
-}
-
-// handleCallAnswer handles call answer
-func (m *Manager) handleCallAnswer(ctx context.Context, msg *router.Message) error {
-	// TODO: Implement call signaling
-	return nil
-}
-
-// handleCallICE handles ICE candidate
-func (m *Manager) handleCallICE(ctx context.Context, msg *router.Message) error {
-	// TODO: Implement call signaling
-	return nil
-}
-
-// handleCallHangup handles call hangup
-func (m *Manager) handleCallHangup(ctx context.Context, msg *router.Message) error {
-	// TODO: Implement call signaling
-	return nil
+	return err
 }
 
 // handlePing handles ping message
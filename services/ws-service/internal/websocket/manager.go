@@ -3,6 +3,7 @@ package websocket
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"time"
 
 	"shared/pkg/logger"
@@ -10,7 +11,10 @@ import (
 	"shared/server/websocket/connection"
 	"shared/server/websocket/hub"
 	"shared/server/websocket/pubsub"
+	"shared/server/websocket/reconnect"
 	"shared/server/websocket/router"
+	"ws-service/internal/broadcast"
+	"ws-service/internal/model"
 	"ws-service/internal/protocol"
 
 	"github.com/google/uuid"
@@ -29,18 +33,92 @@ type Manager struct {
 
 	// Message router for application messages
 	messageRouter *router.Router
+
+	// Per-connection limits on how often each message type may be sent
+	rateLimits *messageRateLimiter
+
+	// Optional buffer of recent topic events for reconnecting clients
+	replay *ReplayBuffer
+
+	// Optional cross-replica fan-out of topic broadcasts
+	bridge *broadcast.Bridge
+
+	// Optional listener that drops devices signed out through auth-service
+	revocations *broadcast.RevocationListener
+
+	// Decides what each viewer may see of other users' presence
+	privacy PresencePrivacy
+
+	// Records calls for the call.* handlers; without it they are rejected
+	calls CallSignaling
+
+	// Optional Prometheus metrics for the hub
+	metrics *Metrics
+
+	presenceReapInterval time.Duration
+	typingSweepInterval  time.Duration
+}
+
+// PresencePrivacy decides what a viewer may see of other users' presence.
+// Without one the manager shows users only their own presence.
+type PresencePrivacy interface {
+	VisibilityForViewer(ctx context.Context, viewerID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]model.PresenceVisibility, error)
+	VisibilityForViewers(ctx context.Context, userID uuid.UUID, viewerIDs []uuid.UUID) (map[uuid.UUID]model.PresenceVisibility, error)
+}
+
+// presencePrivacyTimeout bounds each privacy lookup made while routing or
+// broadcasting presence
+const presencePrivacyTimeout = 2 * time.Second
+
+// cacheCallTimeout bounds the Redis calls made on the broadcast path to
+// buffer events for replay and fan them out to other replicas
+const cacheCallTimeout = 2 * time.Second
+
+// Config holds the engine settings the manager takes from service config
+type Config struct {
+	MaxConnections       int
+	MaxReconnectAttempts int
+	ReconnectBackoff     time.Duration
+
+	// Presence is reaped after StaleConnectionTimeout without a heartbeat,
+	// checked every PresenceReapInterval
+	StaleConnectionTimeout time.Duration
+	PresenceReapInterval   time.Duration
+
+	// MessageRateLimits throttles inbound frames by message type, so
+	// ephemeral types like typing.start can be held tighter than call
+	// signaling. Types not listed are unlimited.
+	MessageRateLimits map[string]MessageRateLimit
+
+	// A typing.start keeps a user typing for TypingIndicatorTTL; expired
+	// indicators are swept every TypingSweepInterval
+	TypingIndicatorTTL  time.Duration
+	TypingSweepInterval time.Duration
 }
 
 // NewManager creates a new WebSocket manager
-func NewManager(log logger.Logger) *Manager {
+func NewManager(cfg Config, log logger.Logger) *Manager {
+	reconnectConfig := reconnect.DefaultConfig()
+	if cfg.MaxReconnectAttempts > 0 {
+		reconnectConfig.MaxAttempts = cfg.MaxReconnectAttempts
+	}
+	if cfg.ReconnectBackoff > 0 {
+		reconnectConfig.InitialDelay = cfg.ReconnectBackoff
+	}
+	maxConnections := cfg.MaxConnections
+	if maxConnections <= 0 {
+		maxConnections = 10000
+	}
+
 	// Build the engine with required components
 	engine := websocket.NewEngineBuilder().
 		WithLogger(log).
-		WithMaxConnections(10000).
+		WithMaxConnections(maxConnections).
 		WithDispatcher(10, 1000).
 		WithPubSub().
 		WithSessions(24 * time.Hour).
 		WithHealthCheck(30 * time.Second).
+		WithReconnect(reconnectConfig).
 		Build()
 
 	// Create hub for multi-device management
@@ -54,10 +132,20 @@ func NewManager(log logger.Logger) *Manager {
 		presence:      NewPresenceTracker(log),
 		typing:        NewTypingManager(log),
 		messageRouter: router.New(),
+		rateLimits:    newMessageRateLimiter(cfg.MessageRateLimits),
+
+		presenceReapInterval: cfg.PresenceReapInterval,
+		typingSweepInterval:  cfg.TypingSweepInterval,
 	}
 
+	mgr.presence.SetStaleTimeout(cfg.StaleConnectionTimeout)
+	mgr.presence.SetOnChange(mgr.broadcastPresenceChange)
+	mgr.typing.SetTTL(cfg.TypingIndicatorTTL)
+	mgr.typing.SetOnExpire(mgr.broadcastTypingExpired)
+
 	// Register application-specific message handlers
 	mgr.registerHandlers()
+	mgr.messageRouter.SetAckHandler(mgr.sendAck)
 
 	// Setup connection lifecycle hooks
 	mgr.setupLifecycleHooks()
@@ -74,6 +162,8 @@ func (m *Manager) registerHandlers() {
 	// Presence handlers
 	m.messageRouter.Register("presence.update", m.handlePresenceUpdate)
 	m.messageRouter.Register("presence.query", m.handlePresenceQuery)
+	m.messageRouter.Register("presence.subscribe", m.handlePresenceSubscribe)
+	m.messageRouter.Register("presence.unsubscribe", m.handlePresenceUnsubscribe)
 
 	// Typing handlers
 	m.messageRouter.Register("typing.start", m.handleTypingStart)
@@ -170,6 +260,7 @@ func (m *Manager) setupLifecycleHooks() {
 
 		// Unsubscribe from all topics
 		m.subscriptions.UnsubscribeAll(conn.ID())
+		m.rateLimits.forget(conn.ID())
 
 		// Update presence if user has no more connections
 		if !m.hub.IsOnline(userID) {
@@ -186,11 +277,40 @@ func (m *Manager) setupLifecycleHooks() {
 
 // Start starts the WebSocket manager
 func (m *Manager) Start() error {
-	return m.engine.Start()
+	if err := m.engine.Start(); err != nil {
+		return err
+	}
+	m.presence.StartReaper(m.presenceReapInterval)
+	m.typing.StartSweeper(m.typingSweepInterval)
+	if m.bridge != nil {
+		if err := m.bridge.Start(context.Background(), m.deliverToTopic); err != nil {
+			return err
+		}
+	}
+	if m.revocations != nil {
+		if err := m.revocations.Start(context.Background(), m.DisconnectDevice); err != nil {
+			return err
+		}
+	}
+	m.metrics.start(m.hub, m.engine.Metrics())
+	return nil
 }
 
 // Stop stops the WebSocket manager
 func (m *Manager) Stop() error {
+	if m.revocations != nil {
+		if err := m.revocations.Stop(); err != nil {
+			m.log.Warn("Failed to stop device revocation listener", logger.Error(err))
+		}
+	}
+	if m.bridge != nil {
+		if err := m.bridge.Stop(); err != nil {
+			m.log.Warn("Failed to stop broadcast bridge", logger.Error(err))
+		}
+	}
+	m.metrics.stopSampling()
+	m.presence.StopReaper()
+	m.typing.StopSweeper()
 	return m.engine.Stop()
 }
 
@@ -209,6 +329,23 @@ func (m *Manager) HandleMessage(ctx context.Context, conn *connection.Connection
 		logger.String("id", msg.ID),
 	)
 
+	if m.messageRouter.HasHandler(msg.Type) {
+		m.metrics.messageRouted(msg.Type)
+	} else {
+		m.metrics.messageRouted(unknownMessageType)
+	}
+
+	// Any inbound frame (ping, presence.update, ...) counts as a heartbeat
+	if userIDVal, ok := conn.GetMetadata("user_id"); ok {
+		if userID, ok := userIDVal.(uuid.UUID); ok {
+			m.presence.Heartbeat(userID)
+		}
+	}
+
+	if allowed, policy := m.rateLimits.allow(conn.ID(), msg.Type); !allowed {
+		return m.rejectRateLimited(conn, &msg, policy)
+	}
+
 	// Route to handler
 	routerMsg := &router.Message{
 		Type:     msg.Type,
@@ -217,6 +354,7 @@ func (m *Manager) HandleMessage(ctx context.Context, conn *connection.Connection
 			"connection": conn,
 			"message_id": msg.ID,
 		},
+		AckID: msg.AckID,
 	}
 
 	if err := m.messageRouter.Route(ctx, routerMsg); err != nil {
@@ -224,12 +362,50 @@ func (m *Manager) HandleMessage(ctx context.Context, conn *connection.Connection
 			logger.String("type", msg.Type),
 			logger.Error(err),
 		)
+		// Clients that asked for an ack already got a nack
+		if msg.AckID != "" {
+			return nil
+		}
 		return m.sendError(conn, msg.ID, "routing_failed", err.Error())
 	}
 
 	return nil
 }
 
+// rejectRateLimited applies policy to a frame over its type's rate limit
+func (m *Manager) rejectRateLimited(conn *connection.Connection, msg *protocol.ClientMessage, policy string) error {
+	conn.IncrementRateLimited()
+	m.messageDropped(dropReasonRateLimited)
+	m.log.Debug("Message rate limited",
+		logger.String("conn_id", conn.ID()),
+		logger.String("type", msg.Type),
+		logger.String("policy", policy),
+	)
+
+	switch policy {
+	case RateLimitNack:
+		if msg.AckID == "" {
+			return m.sendError(conn, msg.ID, "rate_limited", "Too many "+msg.Type+" messages")
+		}
+		data, _ := json.Marshal(protocol.AckMessage{
+			Type:      "nack",
+			AckID:     msg.AckID,
+			Error:     "rate limit exceeded",
+			Timestamp: time.Now(),
+		})
+		return conn.Send(data)
+
+	case RateLimitDisconnect:
+		m.log.Warn("Closing connection over message rate limit",
+			logger.String("conn_id", conn.ID()),
+			logger.String("type", msg.Type),
+		)
+		m.sendError(conn, msg.ID, "rate_limited", "Too many "+msg.Type+" messages")
+		return conn.Close()
+	}
+	return nil
+}
+
 // BroadcastToUser broadcasts a message to all devices of a user
 func (m *Manager) BroadcastToUser(userID uuid.UUID, messageType string, payload interface{}) error {
 	msg := &pubsub.Message{
@@ -246,11 +422,81 @@ func (m *Manager) BroadcastToUser(userID uuid.UUID, messageType string, payload
 }
 
 // BroadcastToConversation broadcasts to all conversation participants
-func (m *Manager) BroadcastToConversation(conversationID uuid.UUID, messageType string, payload interface{}, excludeUserID ...uuid.UUID) error {
-	// Get subscribers for this conversation
-	subscribers := m.subscriptions.GetSubscribers("conversation:" + conversationID.String())
+func (m *Manager) BroadcastToConversation(conversationID uuid.UUID, messageType string, payload interface{}, excludeUserID ...uuid.UUID) (int, error) {
+	return m.BroadcastToTopic(ConversationTopic(conversationID), messageType, payload, excludeUserID...)
+}
+
+// BroadcastToTopic sends a message only to connections subscribed to the
+// topic key (e.g. "conversation:<id>") and returns how many local connections
+// received it. With a bridge the event also reaches other replicas.
+func (m *Manager) BroadcastToTopic(topic string, messageType string, payload interface{}, excludeUserID ...uuid.UUID) (int, error) {
+	data := m.marshalTopicMessage(topic, messageType, payload)
 
-	for _, connID := range subscribers {
+	delivered := m.deliverToTopic(topic, data, excludeUserID)
+	m.metrics.broadcast(messageType, delivered)
+	m.publishToReplicas(topic, data, excludeUserID)
+
+	m.log.Debug("Topic broadcast sent",
+		logger.String("topic", topic),
+		logger.String("type", messageType),
+		logger.Int("recipients", delivered),
+	)
+
+	return delivered, nil
+}
+
+// publishToReplicas hands an event this replica already delivered to the
+// bridge, if any, so the other replicas deliver it too
+func (m *Manager) publishToReplicas(topic string, data []byte, excludeUserID []uuid.UUID) {
+	if m.bridge == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cacheCallTimeout)
+	defer cancel()
+	if err := m.bridge.Publish(ctx, topic, data, excludeUserID); err != nil {
+		m.log.Warn("Failed to publish broadcast to other replicas",
+			logger.String("topic", topic),
+			logger.Error(err),
+		)
+	}
+}
+
+// deliverToTopic sends an encoded event to this replica's subscribers of topic
+func (m *Manager) deliverToTopic(topic string, data []byte, excludeUserID []uuid.UUID) int {
+	if userID, ok := presenceTopicUser(topic); ok {
+		return m.deliverPresence(topic, userID, data, excludeUserID)
+	}
+	if userID, ok := userTopicUser(topic); ok {
+		return m.deliverToUser(topic, userID, data)
+	}
+
+	delivered := 0
+	for _, sub := range m.topicSubscribers(topic, excludeUserID) {
+		if m.sendToSubscriber(topic, sub.conn, data) {
+			delivered++
+		}
+	}
+
+	return delivered
+}
+
+// topicSubscriber is a local connection subscribed to a topic
+type topicSubscriber struct {
+	conn   *connection.Connection
+	userID uuid.UUID
+}
+
+// topicSubscribers returns this replica's connections subscribed to topic,
+// skipping those of excluded users
+func (m *Manager) topicSubscribers(topic string, excludeUserID []uuid.UUID) []topicSubscriber {
+	connIDs := m.subscriptions.GetSubscribers(topic)
+	if len(connIDs) == 0 {
+		return nil
+	}
+
+	subscribers := make([]topicSubscriber, 0, len(connIDs))
+	for _, connID := range connIDs {
 		conn, ok := m.engine.ConnectionManager().Get(connID)
 		if !ok {
 			continue
@@ -262,7 +508,10 @@ func (m *Manager) BroadcastToConversation(conversationID uuid.UUID, messageType
 			continue
 		}
 
-		userID := userIDVal.(uuid.UUID)
+		userID, ok := userIDVal.(uuid.UUID)
+		if !ok {
+			continue
+		}
 		skip := false
 		for _, excludeID := range excludeUserID {
 			if userID == excludeID {
@@ -275,12 +524,237 @@ func (m *Manager) BroadcastToConversation(conversationID uuid.UUID, messageType
 			continue
 		}
 
-		// Send message
-		data := m.marshalPayload(messageType, payload)
-		conn.Send(data)
+		subscribers = append(subscribers, topicSubscriber{conn: conn, userID: userID})
 	}
 
-	return nil
+	return subscribers
+}
+
+// deliverToUser sends an encoded event to every device userID has connected
+// to this replica. User topics are addresses, not subscriptions, so nobody
+// else can listen in on them.
+func (m *Manager) deliverToUser(topic string, userID uuid.UUID, data []byte) int {
+	client, ok := m.hub.GetClient(userID)
+	if !ok {
+		return 0
+	}
+
+	delivered := 0
+	for _, conn := range client.GetAllConnections() {
+		if m.sendToSubscriber(topic, conn, data) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+func (m *Manager) sendToSubscriber(topic string, conn *connection.Connection, data []byte) bool {
+	if err := conn.Send(data); err != nil {
+		m.messageDropped(dropReasonSendFailed)
+		m.log.Warn("Failed to send topic broadcast",
+			logger.String("topic", topic),
+			logger.String("conn_id", conn.ID()),
+			logger.Error(err),
+		)
+		return false
+	}
+	return true
+}
+
+// broadcastPresenceChange notifies the connections watching a user that their
+// presence changed
+func (m *Manager) broadcastPresenceChange(info PresenceInfo) {
+	if _, err := m.BroadcastToTopic(PresenceTopic(info.UserID), "presence.update", info); err != nil {
+		m.log.Warn("Failed to broadcast presence change",
+			logger.String("user_id", info.UserID.String()),
+			logger.Error(err),
+		)
+	}
+}
+
+// deliverPresence sends a presence.update to the local watchers of userID,
+// each seeing only what the user's privacy settings allow. Watchers who may
+// not see the user's status get nothing; if the settings cannot be loaded
+// the event is dropped for everyone but the user.
+func (m *Manager) deliverPresence(topic string, userID uuid.UUID, data []byte, excludeUserID []uuid.UUID) int {
+	subscribers := m.topicSubscribers(topic, excludeUserID)
+	if len(subscribers) == 0 {
+		return 0
+	}
+
+	var info PresenceInfo
+	msg := protocol.ServerMessage{Payload: &info}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		m.log.Warn("Dropping malformed presence event",
+			logger.String("topic", topic),
+			logger.Error(err),
+		)
+		return 0
+	}
+
+	viewerIDs := make([]uuid.UUID, 0, len(subscribers))
+	seen := make(map[uuid.UUID]bool, len(subscribers))
+	for _, sub := range subscribers {
+		if !seen[sub.userID] {
+			seen[sub.userID] = true
+			viewerIDs = append(viewerIDs, sub.userID)
+		}
+	}
+
+	visibility, err := m.presenceVisibilityForViewers(userID, viewerIDs)
+	if err != nil {
+		m.log.Warn("Failed to check presence privacy",
+			logger.String("user_id", userID.String()),
+			logger.Error(err),
+		)
+	}
+
+	frames := make(map[PresenceInfo][]byte, 1)
+	delivered := 0
+	for _, sub := range subscribers {
+		visible, ok := info.visibleAs(visibility[sub.userID])
+		if !ok {
+			continue
+		}
+
+		frame, ok := frames[visible]
+		if !ok {
+			frame = data
+			if visible != info {
+				msg.Payload = visible
+				frame, _ = json.Marshal(msg)
+			}
+			frames[visible] = frame
+		}
+
+		if m.sendToSubscriber(topic, sub.conn, frame) {
+			delivered++
+		}
+	}
+
+	return delivered
+}
+
+// presenceVisibilityForViewer returns what viewerID may see of each user.
+// Without a privacy check, or when it fails, only the viewer's own presence
+// is visible.
+func (m *Manager) presenceVisibilityForViewer(viewerID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]model.PresenceVisibility, error) {
+	if m.privacy != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), presencePrivacyTimeout)
+		defer cancel()
+		visibility, err := m.privacy.VisibilityForViewer(ctx, viewerID, userIDs)
+		if err == nil {
+			return visibility, nil
+		}
+		return map[uuid.UUID]model.PresenceVisibility{viewerID: model.FullPresenceVisibility}, err
+	}
+	return map[uuid.UUID]model.PresenceVisibility{viewerID: model.FullPresenceVisibility}, nil
+}
+
+// presenceVisibilityForViewers returns what each viewer may see of userID,
+// with the same fallback as presenceVisibilityForViewer
+func (m *Manager) presenceVisibilityForViewers(userID uuid.UUID, viewerIDs []uuid.UUID) (map[uuid.UUID]model.PresenceVisibility, error) {
+	if m.privacy != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), presencePrivacyTimeout)
+		defer cancel()
+		visibility, err := m.privacy.VisibilityForViewers(ctx, userID, viewerIDs)
+		if err == nil {
+			return visibility, nil
+		}
+		return map[uuid.UUID]model.PresenceVisibility{userID: model.FullPresenceVisibility}, err
+	}
+	return map[uuid.UUID]model.PresenceVisibility{userID: model.FullPresenceVisibility}, nil
+}
+
+// ConversationTopic returns the subscription key for a conversation
+func ConversationTopic(conversationID uuid.UUID) string {
+	return string(protocol.TopicConversation) + ":" + conversationID.String()
+}
+
+// PresenceTopic returns the subscription key for a user's presence
+func PresenceTopic(userID uuid.UUID) string {
+	return string(protocol.TopicPresence) + ":" + userID.String()
+}
+
+// UserTopic returns the key that addresses every device of one user
+func UserTopic(userID uuid.UUID) string {
+	return string(protocol.TopicUser) + ":" + userID.String()
+}
+
+// userTopicUser returns the user a user topic addresses
+func userTopicUser(topic string) (uuid.UUID, bool) {
+	id, ok := strings.CutPrefix(topic, string(protocol.TopicUser)+":")
+	if !ok {
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(id)
+	return userID, err == nil
+}
+
+// presenceTopicUser returns the user a presence topic watches; the shared
+// "presence:global" topic has none
+func presenceTopicUser(topic string) (uuid.UUID, bool) {
+	id, ok := strings.CutPrefix(topic, string(protocol.TopicPresence)+":")
+	if !ok {
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(id)
+	return userID, err == nil
+}
+
+// SetPresencePrivacy enables privacy checks on presence subscriptions,
+// queries and pushed presence changes
+func (m *Manager) SetPresencePrivacy(p PresencePrivacy) {
+	m.privacy = p
+}
+
+// SetTypingStore persists typing indicators as users start and stop typing
+func (m *Manager) SetTypingStore(store TypingStore) {
+	m.typing.SetStore(store)
+}
+
+// SetReplayBuffer enables missed-event replay for topic broadcasts
+func (m *Manager) SetReplayBuffer(rb *ReplayBuffer) {
+	m.replay = rb
+}
+
+// SetBridge fans topic broadcasts out to other replicas; call before Start
+func (m *Manager) SetBridge(b *broadcast.Bridge) {
+	m.bridge = b
+}
+
+// SetRevocationListener disconnects devices as they are revoked; call before Start
+func (m *Manager) SetRevocationListener(l *broadcast.RevocationListener) {
+	m.revocations = l
+}
+
+// SetMetrics exports hub activity through metrics; call before Start
+func (m *Manager) SetMetrics(metrics *Metrics) {
+	m.metrics = metrics
+}
+
+// messageDropped counts an undelivered message in the engine's totals and,
+// by reason, in the exported metrics
+func (m *Manager) messageDropped(reason string) {
+	m.engine.Metrics().IncrementMessagesDropped()
+	m.metrics.messageDropped(reason)
+}
+
+// DisconnectDevice closes the connection userID holds on deviceID, if it is
+// held by this replica. The disconnect hook then unregisters it as usual.
+func (m *Manager) DisconnectDevice(userID uuid.UUID, deviceID string) bool {
+	conn, ok := m.hub.GetConnection(userID, deviceID)
+	if !ok {
+		return false
+	}
+	if err := conn.Close(); err != nil {
+		m.log.Warn("Failed to close revoked device connection",
+			logger.String("user_id", userID.String()),
+			logger.String("device_id", deviceID),
+			logger.Error(err),
+		)
+	}
+	return true
 }
 
 // GetEngine returns the underlying engine for advanced use cases
@@ -310,6 +784,74 @@ func (m *Manager) sendError(conn *connection.Connection, requestID, code, messag
 	return conn.Send(data)
 }
 
+// sendAck replies to a message that carried an ack_id once it has been routed
+func (m *Manager) sendAck(ctx context.Context, msg *router.Message, routeErr error) {
+	conn, ok := m.getConnection(msg)
+	if !ok {
+		return
+	}
+
+	ack := protocol.AckMessage{
+		Type:      "ack",
+		AckID:     msg.AckID,
+		Timestamp: time.Now(),
+	}
+	if routeErr != nil {
+		ack.Type = "nack"
+		ack.Error = routeErr.Error()
+	}
+
+	data, _ := json.Marshal(ack)
+	if err := conn.Send(data); err != nil {
+		m.log.Warn("Failed to send message ack",
+			logger.String("conn_id", conn.ID()),
+			logger.String("ack_id", msg.AckID),
+			logger.Error(err),
+		)
+	}
+}
+
+// marshalTopicMessage encodes a topic broadcast and, when replay is enabled,
+// stamps it with the topic's next sequence number and buffers it. Typing
+// indicators and presence changes are ephemeral and never replayed.
+func (m *Manager) marshalTopicMessage(topic, messageType string, payload interface{}) []byte {
+	msg := protocol.ServerMessage{
+		ID:        uuid.New().String(),
+		Type:      messageType,
+		Payload:   payload,
+		Timestamp: time.Now(),
+		Topic:     topic,
+	}
+
+	if m.replay == nil || strings.HasPrefix(messageType, "typing.") || strings.HasPrefix(messageType, "presence.") {
+		data, _ := json.Marshal(msg)
+		return data
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cacheCallTimeout)
+	defer cancel()
+	seq, err := m.replay.NextSeq(ctx, topic)
+	if err != nil {
+		m.log.Warn("Failed to reserve replay sequence",
+			logger.String("topic", topic),
+			logger.Error(err),
+		)
+		data, _ := json.Marshal(msg)
+		return data
+	}
+
+	msg.EventID = seq
+	data, _ := json.Marshal(msg)
+	if err := m.replay.Store(ctx, topic, seq, data); err != nil {
+		m.log.Warn("Failed to buffer event for replay",
+			logger.String("topic", topic),
+			logger.Int64("event_id", seq),
+			logger.Error(err),
+		)
+	}
+	return data
+}
+
 // marshalPayload marshals a payload to JSON
 func (m *Manager) marshalPayload(messageType string, payload interface{}) []byte {
 	msg := protocol.ServerMessage{
@@ -0,0 +1,215 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"shared/pkg/logger"
+	"shared/server/websocket/connection"
+	"shared/server/websocket/state"
+	"ws-service/internal/model"
+	"ws-service/internal/protocol"
+
+	"github.com/google/uuid"
+)
+
+func newTestConnection(t *testing.T, m *Manager, userID uuid.UUID) *connection.Connection {
+	t.Helper()
+	conn := connection.New(uuid.New().String(), nil, connection.DefaultConfig(), logger.NewNoop())
+	conn.SetMetadata("user_id", userID)
+	conn.SetMetadata("device_id", conn.ID())
+	if err := conn.TransitionTo(state.StateConnected); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	if err := m.GetEngine().ConnectionManager().Add(conn); err != nil {
+		t.Fatalf("failed to add connection: %v", err)
+	}
+	return conn
+}
+
+func TestBroadcastToConversation_OnlyReachesSubscribers(t *testing.T) {
+	m := NewManager(Config{}, logger.NewNoop())
+	convA, convB := uuid.New(), uuid.New()
+
+	connA := newTestConnection(t, m, uuid.New())
+	connB := newTestConnection(t, m, uuid.New())
+	m.subscriptions.Subscribe(connA.ID(), ConversationTopic(convA))
+	m.subscriptions.Subscribe(connB.ID(), ConversationTopic(convB))
+
+	sent, err := m.BroadcastToConversation(convA, "typing.start", map[string]string{"conversation_id": convA.String()})
+	if err != nil {
+		t.Fatalf("broadcast failed: %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("expected 1 recipient, got %d", sent)
+	}
+	if len(connA.SendChan()) != 1 {
+		t.Fatalf("subscriber of conversation A did not receive the event")
+	}
+	if len(connB.SendChan()) != 0 {
+		t.Fatalf("subscriber of conversation B received an event for A")
+	}
+}
+
+func TestBroadcastToConversation_ExcludesSender(t *testing.T) {
+	m := NewManager(Config{}, logger.NewNoop())
+	conv := uuid.New()
+	sender := uuid.New()
+
+	senderConn := newTestConnection(t, m, sender)
+	peerConn := newTestConnection(t, m, uuid.New())
+	m.subscriptions.Subscribe(senderConn.ID(), ConversationTopic(conv))
+	m.subscriptions.Subscribe(peerConn.ID(), ConversationTopic(conv))
+
+	sent, err := m.BroadcastToConversation(conv, "message.read", nil, sender)
+	if err != nil {
+		t.Fatalf("broadcast failed: %v", err)
+	}
+	if sent != 1 || len(senderConn.SendChan()) != 0 {
+		t.Fatalf("expected only the peer to receive the event, sent=%d", sent)
+	}
+}
+
+// hiddenFrom is a PresencePrivacy where each user in the map hides their
+// status from the listed viewers
+type hiddenFrom map[uuid.UUID]uuid.UUID
+
+func (h hiddenFrom) VisibilityForViewer(ctx context.Context, viewerID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]model.PresenceVisibility, error) {
+	visibility := make(map[uuid.UUID]model.PresenceVisibility)
+	for _, userID := range userIDs {
+		visibility[userID] = model.PresenceVisibility{Status: h[userID] != viewerID, LastSeen: true}
+	}
+	return visibility, nil
+}
+
+func (h hiddenFrom) VisibilityForViewers(ctx context.Context, userID uuid.UUID, viewerIDs []uuid.UUID) (map[uuid.UUID]model.PresenceVisibility, error) {
+	visibility := make(map[uuid.UUID]model.PresenceVisibility)
+	for _, viewerID := range viewerIDs {
+		visibility[viewerID] = model.PresenceVisibility{Status: h[userID] != viewerID, LastSeen: true}
+	}
+	return visibility, nil
+}
+
+func TestPresenceSubscribe_PushesWatchedUserChanges(t *testing.T) {
+	m := NewManager(Config{}, logger.NewNoop())
+	watcher, watched := uuid.New(), uuid.New()
+	m.SetPresencePrivacy(hiddenFrom{})
+
+	watcherConn := newTestConnection(t, m, watcher)
+	watchedConn := newTestConnection(t, m, watched)
+
+	subscribe := `{"id":"1","type":"presence.subscribe","payload":{"user_ids":["` + watched.String() + `"]}}`
+	if err := m.HandleMessage(context.Background(), watcherConn, []byte(subscribe)); err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	if snapshot := nextFrame(t, watcherConn); snapshot.Type != "presence.subscribed" {
+		t.Fatalf("expected a presence snapshot, got %q", snapshot.Type)
+	}
+
+	update := `{"id":"2","type":"presence.update","payload":{"status":"busy"}}`
+	if err := m.HandleMessage(context.Background(), watchedConn, []byte(update)); err != nil {
+		t.Fatalf("presence update failed: %v", err)
+	}
+	if status := nextPresenceStatus(t, watcherConn); status != StatusBusy {
+		t.Fatalf("expected watcher to see busy, got %q", status)
+	}
+	if n := len(watchedConn.SendChan()); n != 0 {
+		t.Fatalf("unsubscribed connection received %d presence frames", n)
+	}
+
+	m.GetEngine().ConnectionManager().Remove(watchedConn.ID())
+	if status := nextPresenceStatus(t, watcherConn); status != StatusOffline {
+		t.Fatalf("expected watcher to see the disconnect, got %q", status)
+	}
+
+	m.GetEngine().ConnectionManager().Remove(watcherConn.ID())
+	if subs := m.subscriptions.GetSubscribers(PresenceTopic(watched)); len(subs) != 0 {
+		t.Fatalf("expected presence subscriptions to be dropped on disconnect, got %v", subs)
+	}
+}
+
+func TestPresenceSubscribe_RespectsHiddenStatus(t *testing.T) {
+	m := NewManager(Config{}, logger.NewNoop())
+	watcher, private := uuid.New(), uuid.New()
+	m.SetPresencePrivacy(hiddenFrom{private: watcher})
+
+	watcherConn := newTestConnection(t, m, watcher)
+	privateConn := newTestConnection(t, m, private)
+
+	subscribe := `{"id":"1","type":"presence.subscribe","payload":{"user_ids":["` + private.String() + `"]}}`
+	if err := m.HandleMessage(context.Background(), watcherConn, []byte(subscribe)); err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	snapshot := nextFrame(t, watcherConn)
+	if !strings.Contains(string(mustJSON(t, snapshot.Payload)), `"status":"offline"`) {
+		t.Fatalf("expected the hidden user to read as offline, got %s", mustJSON(t, snapshot.Payload))
+	}
+
+	// Even a subscriber who slipped past the check gets nothing
+	m.subscriptions.Subscribe(watcherConn.ID(), PresenceTopic(private))
+	update := `{"id":"2","type":"presence.update","payload":{"status":"away"}}`
+	if err := m.HandleMessage(context.Background(), privateConn, []byte(update)); err != nil {
+		t.Fatalf("presence update failed: %v", err)
+	}
+	if n := len(watcherConn.SendChan()); n != 0 {
+		t.Fatalf("watcher received %d frames for a hidden user", n)
+	}
+}
+
+func TestPresenceSubscribe_RejectsTooManyUsers(t *testing.T) {
+	m := NewManager(Config{}, logger.NewNoop())
+	m.SetPresencePrivacy(hiddenFrom{})
+	conn := newTestConnection(t, m, uuid.New())
+
+	userIDs := make([]uuid.UUID, protocol.MaxPresenceSubscriptions+1)
+	for i := range userIDs {
+		userIDs[i] = uuid.New()
+	}
+	subscribe := `{"id":"1","type":"presence.subscribe","payload":{"user_ids":` + string(mustJSON(t, userIDs)) + `}}`
+	if err := m.HandleMessage(context.Background(), conn, []byte(subscribe)); err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	if frame := nextFrame(t, conn); frame.Type != "error" {
+		t.Fatalf("expected an error frame, got %q", frame.Type)
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	return data
+}
+
+func nextFrame(t *testing.T, conn *connection.Connection) protocol.ServerMessage {
+	t.Helper()
+	select {
+	case data := <-conn.SendChan():
+		var msg protocol.ServerMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("invalid frame: %v", err)
+		}
+		return msg
+	default:
+		t.Fatalf("expected a frame, got none")
+	}
+	return protocol.ServerMessage{}
+}
+
+func nextPresenceStatus(t *testing.T, conn *connection.Connection) PresenceStatus {
+	t.Helper()
+	msg := nextFrame(t, conn)
+	if msg.Type != "presence.update" {
+		t.Fatalf("expected presence.update, got %q", msg.Type)
+	}
+	payload, _ := json.Marshal(msg.Payload)
+	var info PresenceInfo
+	if err := json.Unmarshal(payload, &info); err != nil {
+		t.Fatalf("invalid presence payload: %v", err)
+	}
+	return info.Status
+}
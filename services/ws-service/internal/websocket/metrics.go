@@ -0,0 +1,177 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+
+	"shared/pkg/monitoring/metrics"
+	promMetrics "shared/pkg/monitoring/metrics/prometheus"
+	"shared/server/websocket/hub"
+	wsMetrics "shared/server/websocket/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "echo"
+	metricsSubsystem = "ws"
+
+	// defaultMetricsInterval is how often connection gauges and message
+	// totals are sampled when none is configured
+	defaultMetricsInterval = 15 * time.Second
+
+	// unknownMessageType labels inbound frames whose type has no handler, so
+	// clients can't grow the type label with made-up values
+	unknownMessageType = "unknown"
+
+	// Reasons a message counted in messages_dropped_total was not delivered
+	dropReasonSendFailed  = "send_failed"
+	dropReasonRateLimited = "rate_limited"
+)
+
+// Metrics exports hub activity to Prometheus. Connection and frame totals
+// come from the engine's collector, sampled every interval; routed message
+// types, broadcasts and drops are counted as they happen. A nil *Metrics
+// records nothing.
+type Metrics struct {
+	connections metrics.Gauge
+	onlineUsers metrics.Gauge
+	received    metrics.Counter
+	sent        metrics.Counter
+	routed      metrics.Counter
+	broadcasts  metrics.Counter
+	fanout      metrics.Histogram
+	dropped     metrics.Counter
+
+	// The collector totals last added to the received and sent counters
+	sampleMu sync.Mutex
+	lastRecv int64
+	lastSent int64
+
+	interval time.Duration
+	mu       sync.Mutex
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewMetrics registers the WebSocket metrics with reg. interval sets how
+// often gauges and message totals are sampled; zero uses the default.
+func NewMetrics(reg prometheus.Registerer, interval time.Duration) *Metrics {
+	if interval <= 0 {
+		interval = defaultMetricsInterval
+	}
+
+	m := &Metrics{
+		connections: promMetrics.NewGaugeWith(reg, metricsNamespace, metricsSubsystem,
+			"connections", "Number of open WebSocket connections.", nil),
+		onlineUsers: promMetrics.NewGaugeWith(reg, metricsNamespace, metricsSubsystem,
+			"online_users", "Number of users with at least one open connection.", nil),
+		received: promMetrics.NewCounterWith(reg, metricsNamespace, metricsSubsystem,
+			"messages_received_total", "Frames read from clients.", nil),
+		sent: promMetrics.NewCounterWith(reg, metricsNamespace, metricsSubsystem,
+			"messages_sent_total", "Frames written to clients.", nil),
+		routed: promMetrics.NewCounterWith(reg, metricsNamespace, metricsSubsystem,
+			"routed_messages_total", "Inbound messages by type; types without a handler are counted as unknown.", []string{"type"}),
+		broadcasts: promMetrics.NewCounterWith(reg, metricsNamespace, metricsSubsystem,
+			"broadcasts_total", "Topic broadcasts by message type.", []string{"type"}),
+		fanout: promMetrics.NewHistogramWith(reg, metricsNamespace, metricsSubsystem,
+			"broadcast_fanout", "Local connections reached by each topic broadcast.", nil, prometheus.ExponentialBuckets(1, 4, 8)),
+		dropped: promMetrics.NewCounterWith(reg, metricsNamespace, metricsSubsystem,
+			"messages_dropped_total", "Messages not delivered, by reason.", []string{"reason"}),
+		interval: interval,
+	}
+
+	// Export every series from the first scrape, not the first sample or drop
+	m.received.Add(0, nil)
+	m.sent.Add(0, nil)
+	m.dropped.Add(0, map[string]string{"reason": dropReasonSendFailed})
+	m.dropped.Add(0, map[string]string{"reason": dropReasonRateLimited})
+
+	return m
+}
+
+// start samples h and collector every interval until stop is called
+func (m *Metrics) start(h *hub.Hub, collector *wsMetrics.Collector) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		return
+	}
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+
+	go func(stop <-chan struct{}, done chan<- struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		m.sample(h, collector)
+		for {
+			select {
+			case <-ticker.C:
+				m.sample(h, collector)
+			case <-stop:
+				return
+			}
+		}
+	}(m.stop, m.done)
+}
+
+// stopSampling stops the sampler started by start
+func (m *Metrics) stopSampling() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop == nil {
+		return
+	}
+	close(m.stop)
+	<-m.done
+	m.stop, m.done = nil, nil
+}
+
+// sample sets the gauges from the hub and a collector snapshot, and brings
+// the message counters up to the collector's totals
+func (m *Metrics) sample(h *hub.Hub, collector *wsMetrics.Collector) {
+	snapshot := collector.GetSnapshot()
+	m.connections.Set(float64(snapshot.ActiveConnections), nil)
+	m.onlineUsers.Set(float64(h.ClientCount()), nil)
+
+	m.sampleMu.Lock()
+	defer m.sampleMu.Unlock()
+	if snapshot.MessagesReceived > m.lastRecv {
+		m.received.Add(float64(snapshot.MessagesReceived-m.lastRecv), nil)
+		m.lastRecv = snapshot.MessagesReceived
+	}
+	if snapshot.MessagesSent > m.lastSent {
+		m.sent.Add(float64(snapshot.MessagesSent-m.lastSent), nil)
+		m.lastSent = snapshot.MessagesSent
+	}
+}
+
+func (m *Metrics) messageRouted(messageType string) {
+	if m == nil {
+		return
+	}
+	m.routed.Inc(map[string]string{"type": messageType})
+}
+
+func (m *Metrics) broadcast(messageType string, delivered int) {
+	if m == nil {
+		return
+	}
+	m.broadcasts.Inc(map[string]string{"type": messageType})
+	m.fanout.Observe(float64(delivered), nil)
+}
+
+func (m *Metrics) messageDropped(reason string) {
+	if m == nil {
+		return
+	}
+	m.dropped.Inc(map[string]string{"reason": reason})
+}
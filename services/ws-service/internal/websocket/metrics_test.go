@@ -0,0 +1,116 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func gatherMetrics(t *testing.T, reg *prometheus.Registry) map[string]*dto.MetricFamily {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+	return byName
+}
+
+// labeledCounter returns the counter in family whose label matches value
+func labeledCounter(family *dto.MetricFamily, label, value string) float64 {
+	for _, metric := range family.GetMetric() {
+		for _, pair := range metric.GetLabel() {
+			if pair.GetName() == label && pair.GetValue() == value {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return -1
+}
+
+func TestMetrics_ExportsHubActivity(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewManager(Config{}, logger.NewNoop())
+	metrics := NewMetrics(reg, time.Hour)
+	m.SetMetrics(metrics)
+
+	// The shared upgrade handler feeds the engine's collector as connections
+	// open and frames arrive; these connections skip it, so count them here
+	collector := m.engine.Metrics()
+	conv := uuid.New()
+	connA := newTestConnection(t, m, uuid.New())
+	connB := newTestConnection(t, m, uuid.New())
+	collector.IncrementConnections()
+	collector.IncrementConnections()
+	m.subscriptions.Subscribe(connA.ID(), ConversationTopic(conv))
+	m.subscriptions.Subscribe(connB.ID(), ConversationTopic(conv))
+
+	if _, err := m.BroadcastToConversation(conv, "message.new", map[string]string{"id": "m1"}); err != nil {
+		t.Fatalf("broadcast failed: %v", err)
+	}
+	m.HandleMessage(context.Background(), connA, mustJSON(t, map[string]interface{}{"type": "ping"}))
+	m.HandleMessage(context.Background(), connA, mustJSON(t, map[string]interface{}{"type": "made.up"}))
+	collector.IncrementMessagesReceived()
+	collector.IncrementMessagesReceived()
+	metrics.sample(m.hub, collector)
+
+	families := gatherMetrics(t, reg)
+	for _, name := range []string{
+		"echo_ws_connections",
+		"echo_ws_online_users",
+		"echo_ws_messages_received_total",
+		"echo_ws_messages_sent_total",
+		"echo_ws_routed_messages_total",
+		"echo_ws_broadcasts_total",
+		"echo_ws_broadcast_fanout",
+		"echo_ws_messages_dropped_total",
+	} {
+		if _, ok := families[name]; !ok {
+			t.Fatalf("metric %s not exported", name)
+		}
+	}
+
+	if got := families["echo_ws_connections"].GetMetric()[0].GetGauge().GetValue(); got != 2 {
+		t.Fatalf("connections = %v, want 2", got)
+	}
+	if got := families["echo_ws_online_users"].GetMetric()[0].GetGauge().GetValue(); got != 2 {
+		t.Fatalf("online users = %v, want 2", got)
+	}
+	if got := families["echo_ws_messages_received_total"].GetMetric()[0].GetCounter().GetValue(); got != 2 {
+		t.Fatalf("messages received = %v, want the collector's 2", got)
+	}
+
+	// Sampling again adds only what the collector counted since
+	collector.IncrementMessagesReceived()
+	metrics.sample(m.hub, collector)
+	families = gatherMetrics(t, reg)
+	if got := families["echo_ws_messages_received_total"].GetMetric()[0].GetCounter().GetValue(); got != 3 {
+		t.Fatalf("messages received after resampling = %v, want 3", got)
+	}
+	if got := labeledCounter(families["echo_ws_broadcasts_total"], "type", "message.new"); got != 1 {
+		t.Fatalf("message.new broadcasts = %v, want 1", got)
+	}
+	// Connecting also broadcasts presence, which no one here is watching
+	fanout := families["echo_ws_broadcast_fanout"].GetMetric()[0].GetHistogram()
+	if fanout.GetSampleSum() != 2 {
+		t.Fatalf("fan-out histogram sums to %v, want the 2 conversation subscribers", fanout.GetSampleSum())
+	}
+	if got := labeledCounter(families["echo_ws_routed_messages_total"], "type", "ping"); got != 1 {
+		t.Fatalf("routed ping messages = %v, want 1", got)
+	}
+	if got := labeledCounter(families["echo_ws_routed_messages_total"], "type", unknownMessageType); got != 1 {
+		t.Fatalf("unhandled types counted as %v unknown, want 1", got)
+	}
+	if got := labeledCounter(families["echo_ws_routed_messages_total"], "type", "made.up"); got != -1 {
+		t.Fatalf("unhandled type got its own label")
+	}
+}
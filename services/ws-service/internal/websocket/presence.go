@@ -1,30 +1,43 @@
 package websocket
 
 import (
+	"errors"
 	"sync"
 	"time"
 
 	"shared/pkg/logger"
+	"ws-service/internal/model"
 
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrTooManyPresenceUsers rejects a presence.subscribe over MaxPresenceSubscriptions
+	ErrTooManyPresenceUsers = errors.New("presence: too many users in one subscription")
+
+	// ErrPresenceUnavailable is returned when privacy settings cannot be checked
+	ErrPresenceUnavailable = errors.New("presence: privacy settings unavailable")
+)
+
 // PresenceStatus represents user presence status
 type PresenceStatus string
 
 const (
-	StatusOnline  PresenceStatus = "online"
-	StatusOffline PresenceStatus = "offline"
-	StatusAway    PresenceStatus = "away"
-	StatusBusy    PresenceStatus = "busy"
+	StatusOnline    PresenceStatus = "online"
+	StatusOffline   PresenceStatus = "offline"
+	StatusAway      PresenceStatus = "away"
+	StatusBusy      PresenceStatus = "busy"
+	StatusInvisible PresenceStatus = "invisible"
 )
 
-// PresenceInfo represents user presence information
+// PresenceInfo represents user presence information. LastSeenAt is only
+// used for reaping and never sent to clients: last-seen is subject to each
+// user's privacy settings.
 type PresenceInfo struct {
 	UserID       uuid.UUID      `json:"user_id"`
 	Status       PresenceStatus `json:"status"`
 	CustomStatus string         `json:"custom_status,omitempty"`
-	LastSeenAt   time.Time      `json:"last_seen_at"`
+	LastSeenAt   time.Time      `json:"-"`
 	DeviceCount  int            `json:"device_count"`
 }
 
@@ -33,6 +46,16 @@ type PresenceTracker struct {
 	presences map[uuid.UUID]*PresenceInfo
 	mu        sync.RWMutex
 	log       logger.Logger
+
+	// Stale presence reaping: users with no heartbeat for staleTimeout are
+	// marked offline even if their socket never closed cleanly
+	staleTimeout time.Duration
+	onStale      func(info PresenceInfo)
+	onChange     func(info PresenceInfo)
+	clock        func() time.Time
+	stopReaper   chan struct{}
+	reaperDone   chan struct{}
+	reaperMu     sync.Mutex
 }
 
 // NewPresenceTracker creates a new presence tracker
@@ -40,61 +63,201 @@ func NewPresenceTracker(log logger.Logger) *PresenceTracker {
 	return &PresenceTracker{
 		presences: make(map[uuid.UUID]*PresenceInfo),
 		log:       log,
+		clock:     time.Now,
 	}
 }
 
-// OnUserConnected handles user connection event
-func (pt *PresenceTracker) OnUserConnected(userID uuid.UUID) {
+// SetStaleTimeout sets how long a user may go without a heartbeat before
+// the reaper marks them offline. Zero disables reaping.
+func (pt *PresenceTracker) SetStaleTimeout(timeout time.Duration) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.staleTimeout = timeout
+}
+
+// SetOnStale sets the callback invoked for each user the reaper marks offline
+func (pt *PresenceTracker) SetOnStale(fn func(info PresenceInfo)) {
 	pt.mu.Lock()
 	defer pt.mu.Unlock()
+	pt.onStale = fn
+}
+
+// SetOnChange sets the callback invoked whenever a user's visible presence
+// changes: connecting, going offline, a status update or being reaped. It is
+// called without the tracker lock held.
+func (pt *PresenceTracker) SetOnChange(fn func(info PresenceInfo)) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.onChange = fn
+}
+
+// notify hands a presence snapshot to the change callback. Callers pass the
+// callback read under the lock and invoke notify after releasing it.
+func notify(onChange func(info PresenceInfo), info PresenceInfo, changed bool) {
+	if changed && onChange != nil {
+		onChange(info)
+	}
+}
+
+// Heartbeat records activity from a connected user, bringing them back
+// online if the reaper had marked them offline
+func (pt *PresenceTracker) Heartbeat(userID uuid.UUID) {
+	pt.mu.Lock()
+	presence, exists := pt.presences[userID]
+	if !exists {
+		pt.mu.Unlock()
+		return
+	}
+
+	presence.LastSeenAt = pt.clock()
+	revived := presence.Status == StatusOffline && presence.DeviceCount > 0
+	if revived {
+		presence.Status = StatusOnline
+	}
+	info, onChange := *presence, pt.onChange
+	pt.mu.Unlock()
+
+	notify(onChange, info, revived)
+}
+
+// ReapStale marks users offline whose last heartbeat is older than the stale
+// timeout and returns them
+func (pt *PresenceTracker) ReapStale() []PresenceInfo {
+	pt.mu.Lock()
+	if pt.staleTimeout <= 0 {
+		pt.mu.Unlock()
+		return nil
+	}
+
+	cutoff := pt.clock().Add(-pt.staleTimeout)
+	var reaped []PresenceInfo
+	for _, presence := range pt.presences {
+		if presence.Status == StatusOffline || !presence.LastSeenAt.Before(cutoff) {
+			continue
+		}
+		presence.Status = StatusOffline
+		reaped = append(reaped, *presence)
+	}
+	onStale, onChange := pt.onStale, pt.onChange
+	pt.mu.Unlock()
+
+	for _, info := range reaped {
+		pt.log.Info("Reaped stale presence",
+			logger.String("user_id", info.UserID.String()),
+			logger.Time("last_seen_at", info.LastSeenAt),
+		)
+		if onStale != nil {
+			onStale(info)
+		}
+		notify(onChange, info, true)
+	}
+
+	return reaped
+}
+
+// StartReaper runs ReapStale every interval until StopReaper is called
+func (pt *PresenceTracker) StartReaper(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	pt.reaperMu.Lock()
+	defer pt.reaperMu.Unlock()
+	if pt.stopReaper != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	pt.stopReaper = stop
+	pt.reaperDone = done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				pt.ReapStale()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopReaper stops the background reaper and waits for it to exit
+func (pt *PresenceTracker) StopReaper() {
+	pt.reaperMu.Lock()
+	defer pt.reaperMu.Unlock()
+	if pt.stopReaper == nil {
+		return
+	}
 
+	close(pt.stopReaper)
+	<-pt.reaperDone
+	pt.stopReaper = nil
+	pt.reaperDone = nil
+}
+
+// OnUserConnected handles user connection event
+func (pt *PresenceTracker) OnUserConnected(userID uuid.UUID) {
+	pt.mu.Lock()
 	presence, exists := pt.presences[userID]
 	if !exists {
 		presence = &PresenceInfo{
 			UserID: userID,
-			Status: StatusOnline,
+			Status: StatusOffline,
 		}
 		pt.presences[userID] = presence
 	}
 
+	changed := presence.Status != StatusOnline
 	presence.DeviceCount++
 	presence.Status = StatusOnline
-	presence.LastSeenAt = time.Now()
+	presence.LastSeenAt = pt.clock()
+	info, onChange := *presence, pt.onChange
+	pt.mu.Unlock()
 
 	pt.log.Debug("User presence updated (connected)",
 		logger.String("user_id", userID.String()),
-		logger.Int("device_count", presence.DeviceCount),
+		logger.Int("device_count", info.DeviceCount),
 	)
+	notify(onChange, info, changed)
 }
 
 // OnUserDisconnected handles user disconnection event
 func (pt *PresenceTracker) OnUserDisconnected(userID uuid.UUID) {
 	pt.mu.Lock()
-	defer pt.mu.Unlock()
-
 	presence, exists := pt.presences[userID]
 	if !exists {
+		pt.mu.Unlock()
 		return
 	}
 
+	changed := false
 	presence.DeviceCount--
 	if presence.DeviceCount <= 0 {
+		changed = presence.Status != StatusOffline
 		presence.DeviceCount = 0
 		presence.Status = StatusOffline
-		presence.LastSeenAt = time.Now()
+		presence.LastSeenAt = pt.clock()
 	}
+	info, onChange := *presence, pt.onChange
+	pt.mu.Unlock()
 
 	pt.log.Debug("User presence updated (disconnected)",
 		logger.String("user_id", userID.String()),
-		logger.Int("device_count", presence.DeviceCount),
+		logger.Int("device_count", info.DeviceCount),
 	)
+	notify(onChange, info, changed)
 }
 
 // UpdatePresence updates user presence status
 func (pt *PresenceTracker) UpdatePresence(userID uuid.UUID, status PresenceStatus, customStatus string) {
 	pt.mu.Lock()
-	defer pt.mu.Unlock()
-
 	presence, exists := pt.presences[userID]
 	if !exists {
 		presence = &PresenceInfo{
@@ -103,9 +266,14 @@ func (pt *PresenceTracker) UpdatePresence(userID uuid.UUID, status PresenceStatu
 		pt.presences[userID] = presence
 	}
 
+	changed := presence.Status != status || presence.CustomStatus != customStatus
 	presence.Status = status
 	presence.CustomStatus = customStatus
-	presence.LastSeenAt = time.Now()
+	presence.LastSeenAt = pt.clock()
+	info, onChange := *presence, pt.onChange
+	pt.mu.Unlock()
+
+	notify(onChange, info, changed)
 }
 
 // GetPresence returns user presence
@@ -123,6 +291,17 @@ func (pt *PresenceTracker) GetPresence(userID uuid.UUID) *PresenceInfo {
 	}
 }
 
+// snapshot returns a copy of the user's presence, offline if unknown
+func (pt *PresenceTracker) snapshot(userID uuid.UUID) PresenceInfo {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	if presence, exists := pt.presences[userID]; exists {
+		return *presence
+	}
+	return PresenceInfo{UserID: userID, Status: StatusOffline}
+}
+
 // GetBulkPresence returns presence for multiple users
 func (pt *PresenceTracker) GetBulkPresence(userIDs []uuid.UUID) map[uuid.UUID]*PresenceInfo {
 	result := make(map[uuid.UUID]*PresenceInfo)
@@ -133,3 +312,24 @@ func (pt *PresenceTracker) GetBulkPresence(userIDs []uuid.UUID) map[uuid.UUID]*P
 
 	return result
 }
+
+// visibleAs returns the presence as a viewer with the given visibility sees
+// it, and false when the viewer may not see the user's status at all. Hidden
+// last seen coarsens away/busy to online; an invisible status reads as offline.
+func (info PresenceInfo) visibleAs(visibility model.PresenceVisibility) (PresenceInfo, bool) {
+	if !visibility.Status {
+		return PresenceInfo{}, false
+	}
+	if !visibility.LastSeen && info.Status != StatusOffline && info.Status != StatusInvisible {
+		info.Status = StatusOnline
+	}
+	if info.Status == StatusInvisible {
+		info.Status = StatusOffline
+	}
+	return info, true
+}
+
+// hiddenPresence is what a viewer who may not see a user's status gets
+func hiddenPresence(userID uuid.UUID) *PresenceInfo {
+	return &PresenceInfo{UserID: userID, Status: StatusOffline}
+}
@@ -0,0 +1,71 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+func TestPresenceTracker_ReapsStaleUsers(t *testing.T) {
+	now := time.Now()
+	pt := NewPresenceTracker(logger.NewNoop())
+	pt.clock = func() time.Time { return now }
+	pt.SetStaleTimeout(time.Minute)
+
+	var notified []uuid.UUID
+	pt.SetOnStale(func(info PresenceInfo) {
+		notified = append(notified, info.UserID)
+	})
+
+	stale, active := uuid.New(), uuid.New()
+	pt.OnUserConnected(stale)
+	pt.OnUserConnected(active)
+
+	now = now.Add(45 * time.Second)
+	pt.Heartbeat(active)
+	if reaped := pt.ReapStale(); len(reaped) != 0 {
+		t.Fatalf("expected nothing reaped before timeout, got %d", len(reaped))
+	}
+
+	now = now.Add(30 * time.Second)
+	reaped := pt.ReapStale()
+	if len(reaped) != 1 || reaped[0].UserID != stale {
+		t.Fatalf("expected only the silent user to be reaped, got %v", reaped)
+	}
+	if pt.GetPresence(stale).Status != StatusOffline {
+		t.Fatalf("expected reaped user to be offline")
+	}
+	if pt.GetPresence(active).Status != StatusOnline {
+		t.Fatalf("expected user with recent heartbeat to stay online")
+	}
+	if len(notified) != 1 || notified[0] != stale {
+		t.Fatalf("expected one presence change notification, got %v", notified)
+	}
+
+	// A late heartbeat from a still-open socket brings the user back.
+	pt.Heartbeat(stale)
+	if pt.GetPresence(stale).Status != StatusOnline {
+		t.Fatalf("expected heartbeat to restore online status")
+	}
+}
+
+func TestPresenceTracker_StopReaper(t *testing.T) {
+	pt := NewPresenceTracker(logger.NewNoop())
+	pt.SetStaleTimeout(time.Minute)
+	pt.StartReaper(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		pt.StopReaper()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("reaper did not stop")
+	}
+}
@@ -0,0 +1,74 @@
+package websocket
+
+import (
+	"shared/server/websocket/ratelimit"
+)
+
+// What happens to a frame over its message type's limit
+const (
+	// RateLimitDrop discards the frame without telling the client
+	RateLimitDrop = "drop"
+	// RateLimitNack discards the frame and answers with a nack, or a
+	// rate_limited error when the frame carried no ack_id
+	RateLimitNack = "nack"
+	// RateLimitDisconnect closes the connection
+	RateLimitDisconnect = "disconnect"
+)
+
+// MessageRateLimit caps how often one connection may send a message type
+type MessageRateLimit struct {
+	// Rate is the sustained number of frames allowed per second
+	Rate int
+	// Burst is how many frames may arrive at once; Rate when unset
+	Burst int
+	// Policy is RateLimitDrop, RateLimitNack or RateLimitDisconnect;
+	// RateLimitDrop when unset
+	Policy string
+}
+
+// messageRateLimiter keeps a token bucket per connection for each limited
+// message type. Types without a limit are never throttled.
+type messageRateLimiter struct {
+	policies map[string]string
+	buckets  map[string]*ratelimit.TokenBucketLimiter
+}
+
+func newMessageRateLimiter(limits map[string]MessageRateLimit) *messageRateLimiter {
+	l := &messageRateLimiter{
+		policies: make(map[string]string, len(limits)),
+		buckets:  make(map[string]*ratelimit.TokenBucketLimiter, len(limits)),
+	}
+	for msgType, limit := range limits {
+		if limit.Rate <= 0 {
+			continue
+		}
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = limit.Rate
+		}
+		policy := limit.Policy
+		if policy == "" {
+			policy = RateLimitDrop
+		}
+		l.policies[msgType] = policy
+		l.buckets[msgType] = ratelimit.NewTokenBucketLimiter(limit.Rate, burst)
+	}
+	return l
+}
+
+// allow takes a token for msgType from connID's bucket. When none is left it
+// returns false and the policy to apply.
+func (l *messageRateLimiter) allow(connID, msgType string) (bool, string) {
+	bucket, ok := l.buckets[msgType]
+	if !ok || bucket.Allow(connID) {
+		return true, ""
+	}
+	return false, l.policies[msgType]
+}
+
+// forget drops connID's buckets once it disconnects
+func (l *messageRateLimiter) forget(connID string) {
+	for _, bucket := range l.buckets {
+		bucket.Reset(connID)
+	}
+}
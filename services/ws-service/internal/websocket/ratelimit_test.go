@@ -0,0 +1,108 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"shared/pkg/logger"
+	"shared/server/websocket/connection"
+	"ws-service/internal/protocol"
+
+	"github.com/google/uuid"
+)
+
+func typingFrame(t *testing.T, conversationID uuid.UUID, ackID string) []byte {
+	t.Helper()
+	payload, _ := json.Marshal(protocol.TypingPayload{ConversationID: conversationID, IsTyping: true})
+	data, err := json.Marshal(protocol.ClientMessage{ID: uuid.New().String(), Type: "typing.start", Payload: payload, AckID: ackID})
+	if err != nil {
+		t.Fatalf("marshal frame: %v", err)
+	}
+	return data
+}
+
+// drainTypes empties conn's send queue and counts the frame types in it
+func drainTypes(t *testing.T, conn *connection.Connection) map[string]int {
+	t.Helper()
+	types := make(map[string]int)
+	for len(conn.SendChan()) > 0 {
+		var frame struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(<-conn.SendChan(), &frame); err != nil {
+			t.Fatalf("decode frame: %v", err)
+		}
+		types[frame.Type]++
+	}
+	return types
+}
+
+func TestHandleMessage_DropsTypingBurstPastLimit(t *testing.T) {
+	m := NewManager(Config{MessageRateLimits: map[string]MessageRateLimit{
+		"typing.start": {Rate: 1, Burst: 3, Policy: RateLimitDrop},
+	}}, logger.NewNoop())
+	conv := uuid.New()
+	sender := newTestConnection(t, m, uuid.New())
+	peer := newTestConnection(t, m, uuid.New())
+	m.subscriptions.Subscribe(peer.ID(), ConversationTopic(conv))
+
+	for i := 0; i < 10; i++ {
+		if err := m.HandleMessage(context.Background(), sender, typingFrame(t, conv, "")); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+	}
+
+	if got := drainTypes(t, peer)["typing.start"]; got != 3 {
+		t.Fatalf("peer got %d typing events, want the burst of 3", got)
+	}
+	if got := len(sender.SendChan()); got != 0 {
+		t.Fatalf("sender got %d frames, want dropped frames to go unanswered", got)
+	}
+	if got := sender.Stats().RateLimited; got != 7 {
+		t.Fatalf("RateLimited = %d, want 7", got)
+	}
+	if !sender.IsConnected() {
+		t.Fatalf("sender was disconnected for typing too fast")
+	}
+}
+
+func TestHandleMessage_NacksFramesPastLimit(t *testing.T) {
+	m := NewManager(Config{MessageRateLimits: map[string]MessageRateLimit{
+		"typing.start": {Rate: 1, Burst: 2, Policy: RateLimitNack},
+	}}, logger.NewNoop())
+	conv := uuid.New()
+	sender := newTestConnection(t, m, uuid.New())
+
+	for i := 0; i < 5; i++ {
+		if err := m.HandleMessage(context.Background(), sender, typingFrame(t, conv, fmt.Sprint(i))); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+	}
+
+	types := drainTypes(t, sender)
+	if types["ack"] != 2 || types["nack"] != 3 {
+		t.Fatalf("got %v, want 2 acks and 3 nacks", types)
+	}
+	if got := sender.Stats().RateLimited; got != 3 {
+		t.Fatalf("RateLimited = %d, want 3", got)
+	}
+}
+
+func TestHandleMessage_UnlimitedTypesPassThrough(t *testing.T) {
+	m := NewManager(Config{MessageRateLimits: map[string]MessageRateLimit{
+		"typing.start": {Rate: 1, Burst: 1},
+	}}, logger.NewNoop())
+	conn := newTestConnection(t, m, uuid.New())
+
+	ping, _ := json.Marshal(protocol.ClientMessage{ID: "p", Type: "ping"})
+	for i := 0; i < 5; i++ {
+		if err := m.HandleMessage(context.Background(), conn, ping); err != nil {
+			t.Fatalf("ping %d: %v", i, err)
+		}
+	}
+	if got := conn.Stats().RateLimited; got != 0 {
+		t.Fatalf("RateLimited = %d, want pings unlimited", got)
+	}
+}
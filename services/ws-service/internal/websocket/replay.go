@@ -0,0 +1,152 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"shared/pkg/cache"
+)
+
+const replayKeyPrefix = "ws:replay:"
+
+// ErrResyncRequired means the requested events are no longer buffered and the
+// client must refetch state instead of relying on replay
+var ErrResyncRequired = errors.New("replay: events no longer buffered, resync required")
+
+// ReplayBuffer keeps the most recent events of each topic in the shared cache
+// so reconnecting clients can catch up on what they missed. Every topic has
+// its own monotonic sequence, which is the event ID clients resume from.
+type ReplayBuffer struct {
+	cache    cache.Cache
+	capacity int64
+	ttl      time.Duration
+}
+
+// NewReplayBuffer keeps up to capacity events per topic for ttl
+func NewReplayBuffer(c cache.Cache, capacity int, ttl time.Duration) *ReplayBuffer {
+	if capacity <= 0 {
+		capacity = 100
+	}
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &ReplayBuffer{cache: c, capacity: int64(capacity), ttl: ttl}
+}
+
+func (rb *ReplayBuffer) seqKey(topic string) string {
+	return replayKeyPrefix + topic + ":seq"
+}
+
+func (rb *ReplayBuffer) eventKey(topic string, seq int64) string {
+	return replayKeyPrefix + topic + ":" + strconv.FormatInt(seq, 10)
+}
+
+// NextSeq reserves the next event ID for a topic
+func (rb *ReplayBuffer) NextSeq(ctx context.Context, topic string) (int64, error) {
+	seq, err := rb.cache.Increment(ctx, rb.seqKey(topic), 1)
+	if err != nil {
+		return 0, fmt.Errorf("replay: next seq: %w", err)
+	}
+	// The sequence outlives the events so an expired buffer is detected as a
+	// gap rather than silently restarting from 1
+	_ = rb.cache.Expire(ctx, rb.seqKey(topic), 12*rb.ttl)
+	return seq, nil
+}
+
+// Store buffers an already encoded event under its sequence number and drops
+// the entry that fell out of the capacity window
+func (rb *ReplayBuffer) Store(ctx context.Context, topic string, seq int64, data []byte) error {
+	if err := rb.cache.Set(ctx, rb.eventKey(topic, seq), data, rb.ttl); err != nil {
+		return fmt.Errorf("replay: store: %w", err)
+	}
+	if evicted := seq - rb.capacity; evicted > 0 {
+		_ = rb.cache.Delete(ctx, rb.eventKey(topic, evicted))
+	}
+	return nil
+}
+
+// LatestSeq returns the newest event ID issued for a topic, 0 if none
+func (rb *ReplayBuffer) LatestSeq(ctx context.Context, topic string) (int64, error) {
+	raw, err := rb.cache.Get(ctx, rb.seqKey(topic))
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("replay: latest seq: %w", err)
+	}
+	seq, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("replay: latest seq: %w", err)
+	}
+	return seq, nil
+}
+
+// Since returns the buffered events after lastSeq in order, together with the
+// sequence of the last one returned. It returns ErrResyncRequired when any
+// event in that range has been evicted or expired.
+//
+// Publishers reserve a sequence before storing the event, so the newest
+// events may briefly be missing. Such a missing tail is left out rather than
+// reported as a gap; the events reach live subscribers once stored.
+func (rb *ReplayBuffer) Since(ctx context.Context, topic string, lastSeq int64) ([][]byte, int64, error) {
+	latest, err := rb.LatestSeq(ctx, topic)
+	if err != nil {
+		return nil, 0, err
+	}
+	if lastSeq > latest {
+		// The sequence was lost (expired or reset); the client's ID is meaningless
+		return nil, latest, ErrResyncRequired
+	}
+	if lastSeq == latest {
+		return nil, latest, nil
+	}
+	if latest-lastSeq > rb.capacity {
+		return nil, latest, ErrResyncRequired
+	}
+
+	keys := make([]string, 0, latest-lastSeq)
+	for seq := lastSeq + 1; seq <= latest; seq++ {
+		keys = append(keys, rb.eventKey(topic, seq))
+	}
+	found, err := rb.cache.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, latest, fmt.Errorf("replay: load events: %w", err)
+	}
+
+	events := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		data, ok := found[key]
+		if !ok {
+			break
+		}
+		events = append(events, data)
+	}
+	if len(events) == len(keys) {
+		return events, latest, nil
+	}
+
+	// The missing events are only in flight if nothing after them is
+	// buffered and the buffer still reaches back to lastSeq; otherwise they
+	// were evicted or expired.
+	for _, key := range keys[len(events):] {
+		if _, ok := found[key]; ok {
+			return nil, latest, ErrResyncRequired
+		}
+	}
+	if len(events) == 0 {
+		if lastSeq == 0 {
+			return nil, latest, ErrResyncRequired
+		}
+		buffered, err := rb.cache.Exists(ctx, rb.eventKey(topic, lastSeq))
+		if err != nil {
+			return nil, latest, fmt.Errorf("replay: load events: %w", err)
+		}
+		if !buffered {
+			return nil, latest, ErrResyncRequired
+		}
+	}
+	return events, lastSeq + int64(len(events)), nil
+}
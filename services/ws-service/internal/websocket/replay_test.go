@@ -0,0 +1,220 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"shared/pkg/cache"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+	"shared/server/websocket/connection"
+	"shared/server/websocket/router"
+	"ws-service/internal/protocol"
+
+	"github.com/google/uuid"
+)
+
+type fakeCache struct {
+	cache.Cache
+	mu    sync.Mutex
+	items map[string][]byte
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{items: make(map[string][]byte)}
+}
+
+func (f *fakeCache) Get(ctx context.Context, key string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.items[key]
+	if !ok {
+		return nil, cache.ErrNotFound
+	}
+	return v, nil
+}
+
+func (f *fakeCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) pkgErrors.AppError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.items[key] = value
+	return nil
+}
+
+func (f *fakeCache) Delete(ctx context.Context, key string) pkgErrors.AppError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.items, key)
+	return nil
+}
+
+func (f *fakeCache) Exists(ctx context.Context, key string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.items[key]
+	return ok, nil
+}
+
+func (f *fakeCache) Expire(ctx context.Context, key string, ttl time.Duration) pkgErrors.AppError {
+	return nil
+}
+
+func (f *fakeCache) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	found := make(map[string][]byte)
+	for _, key := range keys {
+		if v, ok := f.items[key]; ok {
+			found[key] = v
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeCache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, _ := strconv.ParseInt(string(f.items[key]), 10, 64)
+	n += delta
+	f.items[key] = []byte(strconv.FormatInt(n, 10))
+	return n, nil
+}
+
+func subscribeWithLastEvent(t *testing.T, m *Manager, conn *connection.Connection, conversationID uuid.UUID, lastEventID int64) {
+	t.Helper()
+	payload, _ := json.Marshal(protocol.SubscribePayload{
+		Topics:      []protocol.Topic{protocol.TopicConversation},
+		Filters:     map[string]string{"conversation_id": conversationID.String()},
+		LastEventID: &lastEventID,
+	})
+	err := m.handleSubscribe(context.Background(), &router.Message{
+		Type:     "subscribe",
+		Payload:  payload,
+		Metadata: map[string]interface{}{"connection": conn, "message_id": "sub-1"},
+	})
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+}
+
+func drainFrames(conn *connection.Connection) []protocol.ServerMessage {
+	var frames []protocol.ServerMessage
+	for {
+		select {
+		case data := <-conn.SendChan():
+			var msg protocol.ServerMessage
+			_ = json.Unmarshal(data, &msg)
+			frames = append(frames, msg)
+		default:
+			return frames
+		}
+	}
+}
+
+func TestReplay_FillsGapOnResubscribe(t *testing.T) {
+	m := NewManager(Config{}, logger.NewNoop())
+	m.SetReplayBuffer(NewReplayBuffer(newFakeCache(), 10, time.Minute))
+	conv := uuid.New()
+
+	// Published while the client was offline.
+	for i := 0; i < 3; i++ {
+		if _, err := m.BroadcastToConversation(conv, "message.read", map[string]int{"n": i}); err != nil {
+			t.Fatalf("broadcast failed: %v", err)
+		}
+	}
+
+	conn := newTestConnection(t, m, uuid.New())
+	subscribeWithLastEvent(t, m, conn, conv, 1)
+
+	frames := drainFrames(conn)
+	if len(frames) != 3 {
+		t.Fatalf("expected 2 replayed events and a subscribed ack, got %d frames", len(frames))
+	}
+	if frames[0].EventID != 2 || frames[1].EventID != 3 {
+		t.Fatalf("expected events 2 and 3 in order, got %d and %d", frames[0].EventID, frames[1].EventID)
+	}
+	if frames[2].Type != "subscribed" {
+		t.Fatalf("expected subscribed ack last, got %q", frames[2].Type)
+	}
+
+	// Live delivery continues the same sequence.
+	if _, err := m.BroadcastToConversation(conv, "message.read", nil); err != nil {
+		t.Fatalf("broadcast failed: %v", err)
+	}
+	live := drainFrames(conn)
+	if len(live) != 1 || live[0].EventID != 4 {
+		t.Fatalf("expected live event 4, got %+v", live)
+	}
+}
+
+func TestReplay_ExpiredBufferRequestsResync(t *testing.T) {
+	store := newFakeCache()
+	m := NewManager(Config{}, logger.NewNoop())
+	m.SetReplayBuffer(NewReplayBuffer(store, 10, time.Minute))
+	conv := uuid.New()
+
+	for i := 0; i < 3; i++ {
+		m.BroadcastToConversation(conv, "message.read", nil)
+	}
+	// Simulate the buffered events expiring while the sequence survives.
+	for seq := int64(1); seq <= 3; seq++ {
+		store.Delete(context.Background(), m.replay.eventKey(ConversationTopic(conv), seq))
+	}
+
+	conn := newTestConnection(t, m, uuid.New())
+	subscribeWithLastEvent(t, m, conn, conv, 1)
+
+	frames := drainFrames(conn)
+	if len(frames) == 0 || frames[0].Type != "resync_required" {
+		t.Fatalf("expected resync_required first, got %+v", frames)
+	}
+	if frames[len(frames)-1].Type != "subscribed" {
+		t.Fatalf("expected client to still be subscribed after resync signal")
+	}
+}
+
+func TestReplayBuffer_CapacityWindow(t *testing.T) {
+	rb := NewReplayBuffer(newFakeCache(), 2, time.Minute)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		seq, err := rb.NextSeq(ctx, "conversation:x")
+		if err != nil {
+			t.Fatalf("next seq failed: %v", err)
+		}
+		rb.Store(ctx, "conversation:x", seq, []byte(strconv.FormatInt(seq, 10)))
+	}
+
+	events, latest, err := rb.Since(ctx, "conversation:x", 3)
+	if err != nil || latest != 5 || len(events) != 2 {
+		t.Fatalf("expected events 4..5, got %d events latest=%d err=%v", len(events), latest, err)
+	}
+	if _, _, err := rb.Since(ctx, "conversation:x", 1); err != ErrResyncRequired {
+		t.Fatalf("expected resync outside the capacity window, got %v", err)
+	}
+}
+
+func TestReplayBuffer_InFlightTailIsNotAGap(t *testing.T) {
+	rb := NewReplayBuffer(newFakeCache(), 10, time.Minute)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		seq, _ := rb.NextSeq(ctx, "conversation:x")
+		rb.Store(ctx, "conversation:x", seq, []byte(strconv.FormatInt(seq, 10)))
+	}
+	// Sequence 3 is reserved but its event is not stored yet.
+	if _, err := rb.NextSeq(ctx, "conversation:x"); err != nil {
+		t.Fatalf("next seq failed: %v", err)
+	}
+
+	events, latest, err := rb.Since(ctx, "conversation:x", 1)
+	if err != nil || latest != 2 || len(events) != 1 {
+		t.Fatalf("expected event 2 only, got %d events latest=%d err=%v", len(events), latest, err)
+	}
+	events, latest, err = rb.Since(ctx, "conversation:x", 2)
+	if err != nil || latest != 2 || len(events) != 0 {
+		t.Fatalf("expected no events yet, got %d events latest=%d err=%v", len(events), latest, err)
+	}
+}
@@ -1,66 +1,161 @@
 package websocket
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"shared/pkg/logger"
+	"ws-service/internal/model"
 
 	"github.com/google/uuid"
 )
 
-// TypingIndicator represents a typing indicator
-type TypingIndicator struct {
-	UserID         uuid.UUID
-	ConversationID uuid.UUID
-	Timestamp      time.Time
+// DefaultTypingTTL is how long a typing.start keeps a user typing when no
+// TTL is configured
+const DefaultTypingTTL = 10 * time.Second
+
+// typingStoreTimeout bounds each typing indicator write or sweep
+const typingStoreTimeout = 2 * time.Second
+
+// TypingStore persists typing indicators so other services can read them
+type TypingStore interface {
+	UpsertTypingIndicator(ctx context.Context, indicator *model.TypingIndicator) error
+	DeleteTypingIndicator(ctx context.Context, conversationID, userID uuid.UUID) error
+	DeleteExpiredTypingIndicators(ctx context.Context, now time.Time) ([]*model.TypingIndicator, error)
+}
+
+// typingEntry is a user typing in a conversation until expiresAt
+type typingEntry struct {
+	startedAt time.Time
+	expiresAt time.Time
 }
 
-// TypingManager manages typing indicators (application-specific)
+// TypingManager manages typing indicators (application-specific). A
+// typing.start keeps a user typing for the TTL; repeated starts extend it and
+// the sweeper expires users whose client stopped sending them.
 type TypingManager struct {
-	// conversation ID -> user ID -> timestamp
-	indicators map[uuid.UUID]map[uuid.UUID]time.Time
+	// conversation ID -> user ID -> entry
+	indicators map[uuid.UUID]map[uuid.UUID]typingEntry
+
+	ttl      time.Duration
+	store    TypingStore
+	onExpire func(indicator *model.TypingIndicator)
+	clock    func() time.Time
 
 	mu  sync.RWMutex
 	log logger.Logger
+
+	stopSweeper chan struct{}
+	sweeperDone chan struct{}
+	sweeperMu   sync.Mutex
 }
 
 // NewTypingManager creates a new typing manager
 func NewTypingManager(log logger.Logger) *TypingManager {
 	return &TypingManager{
-		indicators: make(map[uuid.UUID]map[uuid.UUID]time.Time),
+		indicators: make(map[uuid.UUID]map[uuid.UUID]typingEntry),
+		ttl:        DefaultTypingTTL,
+		clock:      time.Now,
 		log:        log,
 	}
 }
 
-// StartTyping marks a user as typing in a conversation
-func (tm *TypingManager) StartTyping(conversationID, userID uuid.UUID) {
+// SetTTL sets how long a typing.start keeps a user typing. Zero keeps the
+// default.
+func (tm *TypingManager) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.ttl = ttl
+}
+
+// SetStore sets where typing indicators are persisted
+func (tm *TypingManager) SetStore(store TypingStore) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.store = store
+}
+
+// SetOnExpire sets the callback invoked for each indicator the sweeper
+// expires. It is called without the manager lock held.
+func (tm *TypingManager) SetOnExpire(fn func(indicator *model.TypingIndicator)) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
+	tm.onExpire = fn
+}
 
+// StartTyping marks a user as typing in a conversation until the TTL
+// elapses. A user already typing keeps their start time and only has the
+// expiry pushed out; the return value reports whether they were not typing.
+func (tm *TypingManager) StartTyping(ctx context.Context, conversationID, userID uuid.UUID) bool {
+	tm.mu.Lock()
+	now := tm.clock()
 	if tm.indicators[conversationID] == nil {
-		tm.indicators[conversationID] = make(map[uuid.UUID]time.Time)
+		tm.indicators[conversationID] = make(map[uuid.UUID]typingEntry)
 	}
 
-	tm.indicators[conversationID][userID] = time.Now()
+	entry, exists := tm.indicators[conversationID][userID]
+	started := !exists || !entry.expiresAt.After(now)
+	if started {
+		entry.startedAt = now
+	}
+	entry.expiresAt = now.Add(tm.ttl)
+	tm.indicators[conversationID][userID] = entry
+	store := tm.store
+	tm.mu.Unlock()
 
-	tm.log.Debug("User started typing",
-		logger.String("user_id", userID.String()),
-		logger.String("conversation_id", conversationID.String()),
-	)
+	if store != nil {
+		ctx, cancel := context.WithTimeout(ctx, typingStoreTimeout)
+		defer cancel()
+		if err := store.UpsertTypingIndicator(ctx, &model.TypingIndicator{
+			ConversationID: conversationID,
+			UserID:         userID,
+			StartedAt:      entry.startedAt,
+			ExpiresAt:      entry.expiresAt,
+		}); err != nil {
+			tm.log.Warn("Failed to persist typing indicator",
+				logger.String("user_id", userID.String()),
+				logger.String("conversation_id", conversationID.String()),
+				logger.Error(err),
+			)
+		}
+	}
+
+	if started {
+		tm.log.Debug("User started typing",
+			logger.String("user_id", userID.String()),
+			logger.String("conversation_id", conversationID.String()),
+		)
+	}
+	return started
 }
 
 // StopTyping marks a user as stopped typing
-func (tm *TypingManager) StopTyping(conversationID, userID uuid.UUID) {
+func (tm *TypingManager) StopTyping(ctx context.Context, conversationID, userID uuid.UUID) {
 	tm.mu.Lock()
-	defer tm.mu.Unlock()
-
 	if users, ok := tm.indicators[conversationID]; ok {
 		delete(users, userID)
 		if len(users) == 0 {
 			delete(tm.indicators, conversationID)
 		}
 	}
+	store := tm.store
+	tm.mu.Unlock()
+
+	if store != nil {
+		ctx, cancel := context.WithTimeout(ctx, typingStoreTimeout)
+		defer cancel()
+		if err := store.DeleteTypingIndicator(ctx, conversationID, userID); err != nil {
+			tm.log.Warn("Failed to remove typing indicator",
+				logger.String("user_id", userID.String()),
+				logger.String("conversation_id", conversationID.String()),
+				logger.Error(err),
+			)
+		}
+	}
 
 	tm.log.Debug("User stopped typing",
 		logger.String("user_id", userID.String()),
@@ -78,12 +173,11 @@ func (tm *TypingManager) GetTypingUsers(conversationID uuid.UUID) []uuid.UUID {
 		return nil
 	}
 
-	// Filter out stale indicators (older than 5 seconds)
-	cutoff := time.Now().Add(-5 * time.Second)
+	now := tm.clock()
 	activeUsers := make([]uuid.UUID, 0)
 
-	for userID, timestamp := range users {
-		if timestamp.After(cutoff) {
+	for userID, entry := range users {
+		if entry.expiresAt.After(now) {
 			activeUsers = append(activeUsers, userID)
 		}
 	}
@@ -91,22 +185,122 @@ func (tm *TypingManager) GetTypingUsers(conversationID uuid.UUID) []uuid.UUID {
 	return activeUsers
 }
 
-// Cleanup removes stale typing indicators
-func (tm *TypingManager) Cleanup() {
+// Sweep removes expired typing indicators, both those started on this
+// replica and any left in the store by a replica that went away, and hands
+// each to the expire callback once
+func (tm *TypingManager) Sweep(ctx context.Context) []*model.TypingIndicator {
 	tm.mu.Lock()
-	defer tm.mu.Unlock()
-
-	cutoff := time.Now().Add(-5 * time.Second)
-
+	now := tm.clock()
+	var expired []*model.TypingIndicator
 	for convID, users := range tm.indicators {
-		for userID, timestamp := range users {
-			if timestamp.Before(cutoff) {
-				delete(users, userID)
+		for userID, entry := range users {
+			if entry.expiresAt.After(now) {
+				continue
 			}
+			delete(users, userID)
+			expired = append(expired, &model.TypingIndicator{
+				ConversationID: convID,
+				UserID:         userID,
+				StartedAt:      entry.startedAt,
+				ExpiresAt:      entry.expiresAt,
+			})
 		}
 
 		if len(users) == 0 {
 			delete(tm.indicators, convID)
 		}
 	}
+	store, onExpire := tm.store, tm.onExpire
+	tm.mu.Unlock()
+
+	if store != nil {
+		ctx, cancel := context.WithTimeout(ctx, typingStoreTimeout)
+		stored, err := store.DeleteExpiredTypingIndicators(ctx, now)
+		cancel()
+		if err != nil {
+			tm.log.Warn("Failed to sweep expired typing indicators", logger.Error(err))
+		}
+		expired = tm.mergeExpired(expired, stored)
+	}
+
+	for _, indicator := range expired {
+		tm.log.Debug("Typing indicator expired",
+			logger.String("user_id", indicator.UserID.String()),
+			logger.String("conversation_id", indicator.ConversationID.String()),
+		)
+		if onExpire != nil {
+			onExpire(indicator)
+		}
+	}
+
+	return expired
+}
+
+// mergeExpired adds the stored indicators that are not already expired and
+// not renewed on this replica since the sweep began
+func (tm *TypingManager) mergeExpired(expired, stored []*model.TypingIndicator) []*model.TypingIndicator {
+	type key struct{ conversationID, userID uuid.UUID }
+	seen := make(map[key]bool, len(expired))
+	for _, indicator := range expired {
+		seen[key{indicator.ConversationID, indicator.UserID}] = true
+	}
+
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	for _, indicator := range stored {
+		k := key{indicator.ConversationID, indicator.UserID}
+		if _, typing := tm.indicators[k.conversationID][k.userID]; seen[k] || typing {
+			continue
+		}
+		seen[k] = true
+		expired = append(expired, indicator)
+	}
+	return expired
+}
+
+// StartSweeper runs Sweep every interval until StopSweeper is called
+func (tm *TypingManager) StartSweeper(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	tm.sweeperMu.Lock()
+	defer tm.sweeperMu.Unlock()
+	if tm.stopSweeper != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	tm.stopSweeper = stop
+	tm.sweeperDone = done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				tm.Sweep(context.Background())
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopSweeper stops the background sweeper and waits for it to exit
+func (tm *TypingManager) StopSweeper() {
+	tm.sweeperMu.Lock()
+	defer tm.sweeperMu.Unlock()
+	if tm.stopSweeper == nil {
+		return
+	}
+
+	close(tm.stopSweeper)
+	<-tm.sweeperDone
+	tm.stopSweeper = nil
+	tm.sweeperDone = nil
 }
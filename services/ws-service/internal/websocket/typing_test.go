@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"shared/pkg/logger"
+	"ws-service/internal/model"
+	"ws-service/internal/protocol"
+
+	"github.com/google/uuid"
+)
+
+// memTypingStore keeps typing indicators in memory in place of
+// messages.typing_indicators
+type memTypingStore struct {
+	rows map[[2]uuid.UUID]model.TypingIndicator
+}
+
+func newMemTypingStore() *memTypingStore {
+	return &memTypingStore{rows: make(map[[2]uuid.UUID]model.TypingIndicator)}
+}
+
+func (s *memTypingStore) UpsertTypingIndicator(ctx context.Context, indicator *model.TypingIndicator) error {
+	s.rows[[2]uuid.UUID{indicator.ConversationID, indicator.UserID}] = *indicator
+	return nil
+}
+
+func (s *memTypingStore) DeleteTypingIndicator(ctx context.Context, conversationID, userID uuid.UUID) error {
+	delete(s.rows, [2]uuid.UUID{conversationID, userID})
+	return nil
+}
+
+func (s *memTypingStore) DeleteExpiredTypingIndicators(ctx context.Context, now time.Time) ([]*model.TypingIndicator, error) {
+	var expired []*model.TypingIndicator
+	for key, row := range s.rows {
+		if !row.ExpiresAt.After(now) {
+			row := row
+			expired = append(expired, &row)
+			delete(s.rows, key)
+		}
+	}
+	return expired, nil
+}
+
+func typingStart(t *testing.T, conversationID uuid.UUID) []byte {
+	t.Helper()
+	payload, _ := json.Marshal(protocol.TypingPayload{ConversationID: conversationID, IsTyping: true})
+	data, _ := json.Marshal(protocol.ClientMessage{ID: uuid.New().String(), Type: "typing.start", Payload: payload})
+	return data
+}
+
+func newTypingManager(t *testing.T) (*Manager, *memTypingStore, *time.Time) {
+	t.Helper()
+	m := NewManager(Config{TypingIndicatorTTL: 10 * time.Second}, logger.NewNoop())
+	store := newMemTypingStore()
+	m.SetTypingStore(store)
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.typing.clock = func() time.Time { return now }
+	return m, store, &now
+}
+
+func TestTypingStart_PersistsAndExtendsIndicator(t *testing.T) {
+	m, store, now := newTypingManager(t)
+	typist, conv := uuid.New(), uuid.New()
+	conn := newTestConnection(t, m, typist)
+
+	if err := m.HandleMessage(context.Background(), conn, typingStart(t, conv)); err != nil {
+		t.Fatalf("typing.start: %v", err)
+	}
+	row, ok := store.rows[[2]uuid.UUID{conv, typist}]
+	if !ok {
+		t.Fatalf("typing.start did not persist an indicator")
+	}
+	started := *now
+	if !row.StartedAt.Equal(started) || !row.ExpiresAt.Equal(started.Add(10*time.Second)) {
+		t.Fatalf("indicator = %+v, want started now and expiring in 10s", row)
+	}
+
+	*now = now.Add(4 * time.Second)
+	if err := m.HandleMessage(context.Background(), conn, typingStart(t, conv)); err != nil {
+		t.Fatalf("repeated typing.start: %v", err)
+	}
+	if len(store.rows) != 1 {
+		t.Fatalf("got %d indicators, want repeated starts to share one", len(store.rows))
+	}
+	row = store.rows[[2]uuid.UUID{conv, typist}]
+	if !row.StartedAt.Equal(started) || !row.ExpiresAt.Equal(now.Add(10*time.Second)) {
+		t.Fatalf("indicator after repeat = %+v, want original start and extended expiry", row)
+	}
+
+	stop, _ := json.Marshal(protocol.ClientMessage{Type: "typing.stop", Payload: json.RawMessage(`{"conversation_id":"` + conv.String() + `"}`)})
+	if err := m.HandleMessage(context.Background(), conn, stop); err != nil {
+		t.Fatalf("typing.stop: %v", err)
+	}
+	if len(store.rows) != 0 || len(m.typing.GetTypingUsers(conv)) != 0 {
+		t.Fatalf("typing.stop left the user typing")
+	}
+}
+
+func TestTypingSweep_ExpiresAndBroadcastsStop(t *testing.T) {
+	m, store, now := newTypingManager(t)
+	typist, watcher, conv := uuid.New(), uuid.New(), uuid.New()
+	typistConn := newTestConnection(t, m, typist)
+	watcherConn := newTestConnection(t, m, watcher)
+	m.subscriptions.Subscribe(watcherConn.ID(), ConversationTopic(conv))
+
+	if err := m.HandleMessage(context.Background(), typistConn, typingStart(t, conv)); err != nil {
+		t.Fatalf("typing.start: %v", err)
+	}
+	<-watcherConn.SendChan()
+
+	*now = now.Add(9 * time.Second)
+	if swept := m.typing.Sweep(context.Background()); len(swept) != 0 {
+		t.Fatalf("swept %d indicators before the TTL elapsed", len(swept))
+	}
+
+	*now = now.Add(2 * time.Second)
+	if swept := m.typing.Sweep(context.Background()); len(swept) != 1 {
+		t.Fatalf("swept %d indicators, want 1", len(swept))
+	}
+	if len(store.rows) != 0 || len(m.typing.GetTypingUsers(conv)) != 0 {
+		t.Fatalf("expired indicator was not removed")
+	}
+
+	select {
+	case data := <-watcherConn.SendChan():
+		var msg protocol.ServerMessage
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "typing.stop" {
+			t.Fatalf("watcher got %s, want typing.stop", data)
+		}
+	default:
+		t.Fatalf("watcher was not told the typist stopped")
+	}
+}
+
+func TestTypingSweep_ClearsIndicatorsLeftByOtherReplicas(t *testing.T) {
+	m, store, now := newTypingManager(t)
+	typist, conv := uuid.New(), uuid.New()
+	store.rows[[2]uuid.UUID{conv, typist}] = model.TypingIndicator{
+		ConversationID: conv,
+		UserID:         typist,
+		StartedAt:      now.Add(-time.Minute),
+		ExpiresAt:      now.Add(-time.Second),
+	}
+
+	swept := m.typing.Sweep(context.Background())
+	if len(swept) != 1 || swept[0].UserID != typist {
+		t.Fatalf("swept %+v, want the orphaned indicator", swept)
+	}
+	if len(store.rows) != 0 {
+		t.Fatalf("orphaned indicator was not removed")
+	}
+}
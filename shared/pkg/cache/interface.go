@@ -10,6 +10,9 @@ import (
 type Cache interface {
 	Get(ctx context.Context, key string) ([]byte, error)
 	Set(ctx context.Context, key string, value []byte, ttl time.Duration) pkgErrors.AppError
+	// SetNX sets key with ttl only if it does not exist and reports whether
+	// it was set
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
 
 	GetString(ctx context.Context, key string) (string, pkgErrors.AppError)
 	SetString(ctx context.Context, key string, value string, ttl time.Duration) pkgErrors.AppError
@@ -29,6 +32,17 @@ type Cache interface {
 	SetMulti(ctx context.Context, items map[string][]byte, ttl time.Duration) pkgErrors.AppError
 	DeleteMulti(ctx context.Context, keys []string) pkgErrors.AppError
 
+	// MGet returns values in key order, nil where a key is missing
+	MGet(ctx context.Context, keys ...string) ([][]byte, error)
+	// MSet writes all pairs atomically
+	MSet(ctx context.Context, pairs map[string][]byte, ttl time.Duration) pkgErrors.AppError
+	Pipeline() Pipeliner
+
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe returns once the subscription is active, so messages
+	// published after it returns are not missed
+	Subscribe(ctx context.Context, channels ...string) (Subscription, error)
+
 	Increment(ctx context.Context, key string, delta int64) (int64, error)
 	Decrement(ctx context.Context, key string, delta int64) (int64, error)
 
@@ -18,11 +18,15 @@ type item struct {
 type memoryCache struct {
 	mu    sync.RWMutex
 	items map[string]*item
+
+	subMu       sync.RWMutex
+	subscribers map[string]map[*subscription]struct{}
 }
 
 func New() cache.Cache {
 	c := &memoryCache{
-		items: make(map[string]*item),
+		items:       make(map[string]*item),
+		subscribers: make(map[string]map[*subscription]struct{}),
 	}
 
 	go c.cleanup()
@@ -63,6 +67,26 @@ func (c *memoryCache) Set(ctx context.Context, key string, value []byte, ttl tim
 	return nil
 }
 
+func (c *memoryCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	if existing, found := c.items[key]; found && (existing.expiration == 0 || now <= existing.expiration) {
+		return false, nil
+	}
+
+	var expiration int64
+	if ttl > 0 {
+		expiration = now + int64(ttl)
+	}
+	c.items[key] = &item{
+		value:      value,
+		expiration: expiration,
+	}
+	return true, nil
+}
+
 func (c *memoryCache) GetString(ctx context.Context, key string) (string, pkgErrors.AppError) {
 	data, err := c.Get(ctx, key)
 	if err != nil {
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"shared/pkg/cache"
+	pkgErrors "shared/pkg/errors"
+)
+
+func (c *memoryCache) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	found, err := c.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = found[key]
+	}
+	return values, nil
+}
+
+func (c *memoryCache) MSet(ctx context.Context, pairs map[string][]byte, ttl time.Duration) pkgErrors.AppError {
+	return c.SetMulti(ctx, pairs, ttl)
+}
+
+func (c *memoryCache) Pipeline() cache.Pipeliner {
+	return &pipeline{cache: c}
+}
+
+type pipeline struct {
+	cache *memoryCache
+	ops   []func(ctx context.Context) cache.PipelineResult
+}
+
+func (p *pipeline) Get(key string) {
+	p.ops = append(p.ops, func(ctx context.Context) cache.PipelineResult {
+		value, err := p.cache.Get(ctx, key)
+		return cache.PipelineResult{Op: cache.PipelineGet, Key: key, Value: value, Err: err}
+	})
+}
+
+func (p *pipeline) Set(key string, value []byte, ttl time.Duration) {
+	p.ops = append(p.ops, func(ctx context.Context) cache.PipelineResult {
+		return cache.PipelineResult{Op: cache.PipelineSet, Key: key, Err: toError(p.cache.Set(ctx, key, value, ttl))}
+	})
+}
+
+func (p *pipeline) Del(keys ...string) {
+	p.ops = append(p.ops, func(ctx context.Context) cache.PipelineResult {
+		var key string
+		if len(keys) == 1 {
+			key = keys[0]
+		}
+		return cache.PipelineResult{Op: cache.PipelineDel, Key: key, Err: toError(p.cache.DeleteMulti(ctx, keys))}
+	})
+}
+
+func (p *pipeline) Expire(key string, ttl time.Duration) {
+	p.ops = append(p.ops, func(ctx context.Context) cache.PipelineResult {
+		result := cache.PipelineResult{Op: cache.PipelineExpire, Key: key}
+		if err := p.cache.Expire(ctx, key, ttl); err != nil {
+			result.Err = cache.ErrNotFound
+		}
+		return result
+	})
+}
+
+func (p *pipeline) Len() int {
+	return len(p.ops)
+}
+
+func (p *pipeline) Exec(ctx context.Context) ([]cache.PipelineResult, error) {
+	results := make([]cache.PipelineResult, 0, len(p.ops))
+	for _, op := range p.ops {
+		results = append(results, op(ctx))
+	}
+	p.ops = nil
+	return results, nil
+}
+
+// toError keeps a nil AppError from becoming a non-nil error interface
+func toError(err pkgErrors.AppError) error {
+	if err == nil {
+		return nil
+	}
+	return err
+}
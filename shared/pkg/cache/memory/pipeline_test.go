@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"shared/pkg/cache"
+)
+
+func TestPipeline_AppliesCommandsInOrder(t *testing.T) {
+	c := New()
+	ctx := context.Background()
+
+	pipe := c.Pipeline()
+	pipe.Set("a", []byte("1"), time.Minute)
+	pipe.Get("a")
+	pipe.Del("a")
+	pipe.Get("a")
+	if pipe.Len() != 4 {
+		t.Fatalf("expected 4 queued commands, got %d", pipe.Len())
+	}
+	results, err := pipe.Exec(ctx)
+	if err != nil {
+		t.Fatalf("exec failed: %v", err)
+	}
+	if string(results[1].Value) != "1" {
+		t.Fatalf("expected get after set to see value, got %+v", results[1])
+	}
+	if !errors.Is(results[3].Err, cache.ErrNotFound) {
+		t.Fatalf("expected get after delete to miss, got %+v", results[3])
+	}
+	if pipe.Len() != 0 {
+		t.Fatalf("exec should reset the pipeline")
+	}
+}
+
+func TestMGet_PreservesKeyOrder(t *testing.T) {
+	c := New()
+	ctx := context.Background()
+	_ = c.MSet(ctx, map[string][]byte{"a": []byte("1"), "c": []byte("3")}, 0)
+
+	values, err := c.MGet(ctx, "c", "b", "a")
+	if err != nil {
+		t.Fatalf("mget failed: %v", err)
+	}
+	if string(values[0]) != "3" || values[1] != nil || string(values[2]) != "1" {
+		t.Fatalf("unexpected values: %q", values)
+	}
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"shared/pkg/cache"
+)
+
+const subscriptionBuffer = 100
+
+// Publish delivers to in-process subscribers only. A subscriber whose buffer
+// is full misses the message rather than blocking the publisher.
+func (c *memoryCache) Publish(ctx context.Context, channel string, payload []byte) error {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	for sub := range c.subscribers[channel] {
+		sub.deliver(cache.Message{Channel: channel, Payload: payload})
+	}
+	return nil
+}
+
+func (c *memoryCache) Subscribe(ctx context.Context, channels ...string) (cache.Subscription, error) {
+	sub := &subscription{
+		cache:    c,
+		channels: channels,
+		messages: make(chan cache.Message, subscriptionBuffer),
+	}
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	for _, channel := range channels {
+		if c.subscribers[channel] == nil {
+			c.subscribers[channel] = make(map[*subscription]struct{})
+		}
+		c.subscribers[channel][sub] = struct{}{}
+	}
+	return sub, nil
+}
+
+type subscription struct {
+	cache    *memoryCache
+	channels []string
+	messages chan cache.Message
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (s *subscription) deliver(msg cache.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.messages <- msg:
+	default:
+	}
+}
+
+func (s *subscription) Messages() <-chan cache.Message {
+	return s.messages
+}
+
+func (s *subscription) Close() error {
+	s.cache.subMu.Lock()
+	for _, channel := range s.channels {
+		delete(s.cache.subscribers[channel], s)
+		if len(s.cache.subscribers[channel]) == 0 {
+			delete(s.cache.subscribers, channel)
+		}
+	}
+	s.cache.subMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.messages)
+	}
+	return nil
+}
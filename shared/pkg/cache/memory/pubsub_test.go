@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPubSub_DeliversToSubscribedChannelsOnly(t *testing.T) {
+	c := New()
+	ctx := context.Background()
+
+	sub, err := c.Subscribe(ctx, "presence")
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	_ = c.Publish(ctx, "typing", []byte("ignored"))
+	_ = c.Publish(ctx, "presence", []byte("online"))
+
+	msg := <-sub.Messages()
+	if msg.Channel != "presence" || string(msg.Payload) != "online" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+
+	_ = sub.Close()
+	_ = c.Publish(ctx, "presence", []byte("after close"))
+	if _, ok := <-sub.Messages(); ok {
+		t.Fatalf("expected no delivery after close")
+	}
+}
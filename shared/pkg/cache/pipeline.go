@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+type PipelineOp string
+
+const (
+	PipelineGet    PipelineOp = "get"
+	PipelineSet    PipelineOp = "set"
+	PipelineDel    PipelineOp = "del"
+	PipelineExpire PipelineOp = "expire"
+)
+
+// PipelineResult is the outcome of one buffered command. Value is only set
+// for gets; a missing key reports ErrNotFound in Err.
+type PipelineResult struct {
+	Op    PipelineOp
+	Key   string
+	Value []byte
+	Err   error
+}
+
+// Pipeliner buffers commands and sends them in a single round-trip on Exec.
+// Commands are not atomic; other clients may interleave with them.
+type Pipeliner interface {
+	Get(key string)
+	Set(key string, value []byte, ttl time.Duration)
+	Del(keys ...string)
+	Expire(key string, ttl time.Duration)
+	Len() int
+	// Exec flushes the buffered commands and returns their results in the
+	// order they were queued. The error reports a transport failure or the
+	// first failed command other than a missing key.
+	Exec(ctx context.Context) ([]PipelineResult, error)
+}
@@ -0,0 +1,14 @@
+package cache
+
+// Message is a payload received on a pub/sub channel
+type Message struct {
+	Channel string
+	Payload []byte
+}
+
+// Subscription delivers messages for the channels it was opened with until
+// Close is called, after which Messages is closed.
+type Subscription interface {
+	Messages() <-chan Message
+	Close() error
+}
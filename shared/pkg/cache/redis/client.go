@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -55,6 +56,17 @@ func New(config cache.Config) (cache.Cache, error) {
 	return &client{rdb: rdb, logger: lgr}, nil
 }
 
+// ClientFromCache returns the go-redis client behind a cache created by New,
+// for callers that need Redis data structures the Cache interface does not
+// expose. It fails for caches that are not Redis-backed.
+func ClientFromCache(c cache.Cache) (*redis.Client, error) {
+	rc, ok := c.(*client)
+	if !ok {
+		return nil, errors.New("cache is not redis-backed")
+	}
+	return rc.rdb, nil
+}
+
 func (c *client) Get(ctx context.Context, key string) ([]byte, error) {
 	c.logger.Debug("Getting key from Redis", logger.String("key", key))
 	result, err := c.rdb.Get(ctx, key).Bytes()
@@ -74,6 +86,11 @@ func (c *client) Set(ctx context.Context, key string, value []byte, ttl time.Dur
 	return nil
 }
 
+func (c *client) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	c.logger.Debug("Setting key in Redis if absent", logger.String("key", key))
+	return c.rdb.SetNX(ctx, key, value, ttl).Result()
+}
+
 func (c *client) GetString(ctx context.Context, key string) (string, pkgErrors.AppError) {
 	c.logger.Debug("Getting string key from Redis", logger.String("key", key))
 	result, err := c.rdb.Get(ctx, key).Result()
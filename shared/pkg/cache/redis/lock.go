@@ -5,9 +5,36 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"shared/pkg/cache"
+)
+
+var (
+	ErrLockNotAcquired = errors.New("lock already held by another owner")
+	ErrLockNotHeld     = errors.New("lock not held")
+)
+
+// Only the owner's token may delete or extend the key, so a holder whose TTL
+// ran out cannot release a lock that has since been taken by someone else.
+var (
+	releaseScript = redis.NewScript(`
+        if redis.call("get", KEYS[1]) == ARGV[1] then
+            return redis.call("del", KEYS[1])
+        else
+            return 0
+        end
+    `)
+	extendScript = redis.NewScript(`
+        if redis.call("get", KEYS[1]) == ARGV[1] then
+            return redis.call("pexpire", KEYS[1], ARGV[2])
+        else
+            return 0
+        end
+    `)
 )
 
 type Lock struct {
@@ -15,6 +42,11 @@ type Lock struct {
 	key    string
 	token  string
 	ttl    time.Duration
+
+	mu        sync.Mutex
+	stopRenew chan struct{}
+	lost      chan struct{}
+	lostOnce  sync.Once
 }
 
 func NewLock(client *redis.Client, key string, ttl time.Duration) *Lock {
@@ -23,9 +55,14 @@ func NewLock(client *redis.Client, key string, ttl time.Duration) *Lock {
 		key:    key,
 		token:  generateToken(),
 		ttl:    ttl,
+		lost:   make(chan struct{}),
 	}
 }
 
+func (l *Lock) Key() string {
+	return l.key
+}
+
 func (l *Lock) Acquire(ctx context.Context) (bool, error) {
 	success, err := l.client.SetNX(ctx, l.key, l.token, l.ttl).Result()
 	if err != nil {
@@ -34,46 +71,144 @@ func (l *Lock) Acquire(ctx context.Context) (bool, error) {
 	return success, nil
 }
 
+// Release stops any auto-renewal and deletes the key if this lock still owns
+// it. It returns ErrLockNotHeld when the lock already expired.
 func (l *Lock) Release(ctx context.Context) error {
-	script := `
-        if redis.call("get", KEYS[1]) == ARGV[1] then
-            return redis.call("del", KEYS[1])
-        else
-            return 0
-        end
-    `
+	l.stopRenewal()
 
-	result, err := l.client.Eval(ctx, script, []string{l.key}, l.token).Result()
+	result, err := releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Int64()
 	if err != nil {
 		return err
 	}
-
-	if result.(int64) == 0 {
-		return errors.New("lock not held")
+	if result == 0 {
+		return ErrLockNotHeld
 	}
-
 	return nil
 }
 
 func (l *Lock) Extend(ctx context.Context, ttl time.Duration) error {
-	script := `
-        if redis.call("get", KEYS[1]) == ARGV[1] then
-            return redis.call("pexpire", KEYS[1], ARGV[2])
-        else
-            return 0
-        end
-    `
-
-	result, err := l.client.Eval(ctx, script, []string{l.key}, l.token, int64(ttl/time.Millisecond)).Result()
+	result, err := extendScript.Run(ctx, l.client, []string{l.key}, l.token, ttl.Milliseconds()).Int64()
 	if err != nil {
 		return err
 	}
+	if result == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
 
-	if result.(int64) == 0 {
-		return errors.New("lock not held")
+// AutoRenew extends the lock back to its full TTL every interval (a third of
+// the TTL when interval is zero) until Release is called. If an extension
+// fails the renewal stops and Lost is closed; the holder should abandon its
+// work since another replica may take over. A lost lock is never renewed
+// again.
+func (l *Lock) AutoRenew(interval time.Duration) {
+	if interval <= 0 {
+		interval = l.ttl / 3
 	}
 
-	return nil
+	l.mu.Lock()
+	if l.stopRenew != nil || l.isLost() {
+		l.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	l.stopRenew = stop
+	l.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				err := l.Extend(ctx, l.ttl)
+				cancel()
+				if err != nil {
+					l.lostOnce.Do(func() { close(l.lost) })
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Lost is closed when auto-renewal could not extend the lock.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *Lock) isLost() bool {
+	select {
+	case <-l.lost:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *Lock) stopRenewal() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopRenew != nil {
+		close(l.stopRenew)
+		l.stopRenew = nil
+	}
+}
+
+// Locker hands out distributed locks backed by SET NX PX, for work that must
+// run on exactly one replica at a time.
+type Locker struct {
+	client        *redis.Client
+	retryInterval time.Duration
+}
+
+func NewLocker(client *redis.Client) *Locker {
+	return &Locker{client: client, retryInterval: 50 * time.Millisecond}
+}
+
+// NewLockerFromCache builds a Locker on the connection of a cache created by
+// New. It fails for caches that are not Redis-backed.
+func NewLockerFromCache(c cache.Cache) (*Locker, error) {
+	rdb, err := ClientFromCache(c)
+	if err != nil {
+		return nil, errors.New("distributed locks require a redis cache")
+	}
+	return NewLocker(rdb), nil
+}
+
+// TryAcquire takes the lock without waiting, returning ErrLockNotAcquired if
+// another owner holds it.
+func (l *Locker) TryAcquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	lock := NewLock(l.client, key, ttl)
+	ok, err := lock.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLockNotAcquired
+	}
+	return lock, nil
+}
+
+// Acquire waits until the lock is free or ctx is done.
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	ticker := time.NewTicker(l.retryInterval)
+	defer ticker.Stop()
+	for {
+		lock, err := l.TryAcquire(ctx, key, ttl)
+		if !errors.Is(err, ErrLockNotAcquired) {
+			return lock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
 }
 
 func generateToken() string {
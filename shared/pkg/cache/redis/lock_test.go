@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLocker_ContentionAndRelease(t *testing.T) {
+	rdb := newTestClient(t).rdb
+	locker := NewLocker(rdb)
+	ctx := context.Background()
+	key := "test:lock:" + generateToken()
+
+	held, err := locker.TryAcquire(ctx, key, time.Second)
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+	if _, err := locker.TryAcquire(ctx, key, time.Second); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("expected contention error, got %v", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := locker.Acquire(waitCtx, key, time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected blocking acquire to time out, got %v", err)
+	}
+
+	if err := held.Release(ctx); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	next, err := locker.TryAcquire(ctx, key, time.Second)
+	if err != nil {
+		t.Fatalf("acquire after release failed: %v", err)
+	}
+	_ = next.Release(ctx)
+}
+
+func TestLock_ReleaseAfterExpiryKeepsNewOwner(t *testing.T) {
+	rdb := newTestClient(t).rdb
+	locker := NewLocker(rdb)
+	ctx := context.Background()
+	key := "test:lock:" + generateToken()
+
+	stale, err := locker.TryAcquire(ctx, key, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	owner, err := locker.TryAcquire(ctx, key, time.Second)
+	if err != nil {
+		t.Fatalf("acquire after expiry failed: %v", err)
+	}
+	if err := stale.Release(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("expected stale release to report not held, got %v", err)
+	}
+	if token, _ := rdb.Get(ctx, key).Result(); token != owner.token {
+		t.Fatalf("stale release deleted the new owner's lock")
+	}
+	_ = owner.Release(ctx)
+}
+
+func TestLock_AutoRenewOutlivesTTL(t *testing.T) {
+	rdb := newTestClient(t).rdb
+	locker := NewLocker(rdb)
+	ctx := context.Background()
+	key := "test:lock:" + generateToken()
+
+	lock, err := locker.TryAcquire(ctx, key, 90*time.Millisecond)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	lock.AutoRenew(0)
+	time.Sleep(250 * time.Millisecond)
+
+	if _, err := locker.TryAcquire(ctx, key, time.Second); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("renewed lock should still be held, got %v", err)
+	}
+	select {
+	case <-lock.Lost():
+		t.Fatalf("renewal reported the lock as lost")
+	default:
+	}
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+}
+
+func TestLock_AutoRenewAfterLossDoesNotRestart(t *testing.T) {
+	rdb := newTestClient(t).rdb
+	locker := NewLocker(rdb)
+	ctx := context.Background()
+	key := "test:lock:" + generateToken()
+
+	lock, err := locker.TryAcquire(ctx, key, time.Second)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	rdb.Del(ctx, key)
+	lock.AutoRenew(10 * time.Millisecond)
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatalf("renewal did not report the lock as lost")
+	}
+
+	_ = lock.Release(ctx)
+	lock.AutoRenew(10 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	if err := lock.Release(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("expected ErrLockNotHeld, got %v", err)
+	}
+}
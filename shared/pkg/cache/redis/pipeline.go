@@ -0,0 +1,143 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"shared/pkg/cache"
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+)
+
+func (c *client) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	c.logger.Debug("Getting multiple keys from Redis", logger.Int("count", len(keys)))
+	values := make([][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	results, err := c.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range results {
+		if val, ok := result.(string); ok {
+			values[i] = []byte(val)
+		}
+	}
+	return values, nil
+}
+
+// MSet writes the pairs with one MSET; a TTL is applied inside the same
+// transaction so no key is ever visible without its expiry.
+func (c *client) MSet(ctx context.Context, pairs map[string][]byte, ttl time.Duration) pkgErrors.AppError {
+	c.logger.Debug("Setting multiple keys in Redis atomically", logger.Int("count", len(pairs)))
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	values := make([]interface{}, 0, len(pairs)*2)
+	for key, value := range pairs {
+		values = append(values, key, value)
+	}
+
+	pipe := c.rdb.TxPipeline()
+	pipe.MSet(ctx, values...)
+	if ttl > 0 {
+		for key := range pairs {
+			pipe.PExpire(ctx, key, ttl)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return pkgErrors.FromError(err, pkgErrors.CodeCacheError, "failed to set multiple keys").
+			WithService("redis-client").
+			WithDetail("count", len(pairs))
+	}
+	return nil
+}
+
+func (c *client) Pipeline() cache.Pipeliner {
+	return &pipeline{pipe: c.rdb.Pipeline()}
+}
+
+type pipelineCmd struct {
+	op  cache.PipelineOp
+	key string
+	cmd redis.Cmder
+}
+
+type pipeline struct {
+	pipe redis.Pipeliner
+	cmds []pipelineCmd
+}
+
+func (p *pipeline) queue(op cache.PipelineOp, key string, cmd redis.Cmder) {
+	p.cmds = append(p.cmds, pipelineCmd{op: op, key: key, cmd: cmd})
+}
+
+func (p *pipeline) Get(key string) {
+	p.queue(cache.PipelineGet, key, p.pipe.Get(context.Background(), key))
+}
+
+func (p *pipeline) Set(key string, value []byte, ttl time.Duration) {
+	p.queue(cache.PipelineSet, key, p.pipe.Set(context.Background(), key, value, ttl))
+}
+
+func (p *pipeline) Del(keys ...string) {
+	var key string
+	if len(keys) == 1 {
+		key = keys[0]
+	}
+	p.queue(cache.PipelineDel, key, p.pipe.Del(context.Background(), keys...))
+}
+
+func (p *pipeline) Expire(key string, ttl time.Duration) {
+	p.queue(cache.PipelineExpire, key, p.pipe.PExpire(context.Background(), key, ttl))
+}
+
+func (p *pipeline) Len() int {
+	return len(p.cmds)
+}
+
+func (p *pipeline) Exec(ctx context.Context) ([]cache.PipelineResult, error) {
+	cmds := p.cmds
+	p.cmds = nil
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+
+	// Exec reports the first failed command, which includes redis.Nil for a
+	// missing key, so errors are read per command instead. A transport
+	// failure is set on every command and surfaces as firstErr.
+	_, _ = p.pipe.Exec(ctx)
+
+	var firstErr error
+	results := make([]cache.PipelineResult, len(cmds))
+	for i, queued := range cmds {
+		result := cache.PipelineResult{Op: queued.op, Key: queued.key}
+		switch cmd := queued.cmd.(type) {
+		case *redis.StringCmd:
+			value, err := cmd.Bytes()
+			if errors.Is(err, redis.Nil) {
+				err = cache.ErrNotFound
+			}
+			result.Value, result.Err = value, err
+		case *redis.BoolCmd:
+			ok, err := cmd.Result()
+			if err == nil && !ok {
+				err = cache.ErrNotFound
+			}
+			result.Err = err
+		default:
+			result.Err = cmd.Err()
+		}
+		if result.Err != nil && !errors.Is(result.Err, cache.ErrNotFound) && firstErr == nil {
+			firstErr = result.Err
+		}
+		results[i] = result
+	}
+	return results, firstErr
+}
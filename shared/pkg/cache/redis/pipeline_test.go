@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"shared/pkg/cache"
+	"shared/pkg/logger"
+)
+
+func newTestClient(tb testing.TB) *client {
+	tb.Helper()
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		tb.Skip("REDIS_TEST_ADDR not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		tb.Fatalf("failed to reach redis at %s: %v", addr, err)
+	}
+	tb.Cleanup(func() { rdb.Close() })
+	return &client{rdb: rdb, logger: logger.NewNoop()}
+}
+
+func TestPipeline_ReturnsPerCommandResults(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	prefix := "test:pipe:" + generateToken()
+
+	pipe := c.Pipeline()
+	pipe.Set(prefix+":a", []byte("1"), time.Minute)
+	pipe.Get(prefix + ":a")
+	pipe.Get(prefix + ":missing")
+	pipe.Expire(prefix+":missing", time.Minute)
+	pipe.Del(prefix + ":a")
+	results, err := pipe.Exec(ctx)
+	if err != nil {
+		t.Fatalf("exec failed: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	if results[0].Err != nil || string(results[1].Value) != "1" {
+		t.Fatalf("unexpected set/get results: %+v %+v", results[0], results[1])
+	}
+	if !errors.Is(results[2].Err, cache.ErrNotFound) || !errors.Is(results[3].Err, cache.ErrNotFound) {
+		t.Fatalf("missing key should report ErrNotFound: %+v %+v", results[2], results[3])
+	}
+	if _, err := c.Get(ctx, prefix+":a"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("expected key deleted by pipeline, got %v", err)
+	}
+}
+
+func TestMSetMGet(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	prefix := "test:mset:" + generateToken()
+
+	if err := c.MSet(ctx, map[string][]byte{prefix + ":a": []byte("a"), prefix + ":b": []byte("b")}, time.Minute); err != nil {
+		t.Fatalf("mset failed: %v", err)
+	}
+	values, err := c.MGet(ctx, prefix+":a", prefix+":missing", prefix+":b")
+	if err != nil {
+		t.Fatalf("mget failed: %v", err)
+	}
+	if string(values[0]) != "a" || values[1] != nil || string(values[2]) != "b" {
+		t.Fatalf("unexpected values: %q", values)
+	}
+	if ttl, _ := c.TTL(ctx, prefix+":a"); ttl <= 0 {
+		t.Fatalf("expected mset to apply ttl, got %v", ttl)
+	}
+}
+
+const benchKeys = 100
+
+func seedBenchKeys(b *testing.B, c *client) []string {
+	ctx := context.Background()
+	prefix := "bench:" + generateToken()
+	keys := make([]string, benchKeys)
+	pairs := make(map[string][]byte, benchKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%s:%d", prefix, i)
+		pairs[keys[i]] = []byte("value")
+	}
+	if err := c.MSet(ctx, pairs, time.Minute); err != nil {
+		b.Fatalf("seed failed: %v", err)
+	}
+	b.Cleanup(func() { _ = c.DeleteMulti(ctx, keys) })
+	return keys
+}
+
+func BenchmarkSequentialGet(b *testing.B) {
+	c := newTestClient(b)
+	keys := seedBenchKeys(b, c)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, key := range keys {
+			if _, err := c.Get(ctx, key); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkMGet(b *testing.B) {
+	c := newTestClient(b)
+	keys := seedBenchKeys(b, c)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.MGet(ctx, keys...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
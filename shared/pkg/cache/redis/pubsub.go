@@ -2,8 +2,12 @@ package redis
 
 import (
 	"context"
+	"sync"
 
 	"github.com/redis/go-redis/v9"
+
+	"shared/pkg/cache"
+	"shared/pkg/logger"
 )
 
 type PubSub struct {
@@ -45,3 +49,69 @@ func (p *PubSub) Close() error {
 	}
 	return p.pubsub.Close()
 }
+
+func (c *client) Publish(ctx context.Context, channel string, payload []byte) error {
+	c.logger.Debug("Publishing to Redis channel", logger.String("channel", channel))
+	return c.rdb.Publish(ctx, channel, payload).Err()
+}
+
+// Subscribe opens a dedicated pub/sub connection. go-redis health-checks it
+// and, when it drops, reconnects and resubscribes to the same channels, so
+// consumers only see a gap in messages, never a closed channel.
+func (c *client) Subscribe(ctx context.Context, channels ...string) (cache.Subscription, error) {
+	c.logger.Debug("Subscribing to Redis channels", logger.Any("channels", channels))
+	ps := c.rdb.Subscribe(ctx, channels...)
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+
+	sub := &subscription{
+		pubsub:   ps,
+		messages: make(chan cache.Message, subscriptionBuffer),
+		done:     make(chan struct{}),
+	}
+	go sub.forward(ps.Channel(redis.WithChannelSize(subscriptionBuffer)))
+	return sub, nil
+}
+
+const subscriptionBuffer = 100
+
+type subscription struct {
+	pubsub    *redis.PubSub
+	messages  chan cache.Message
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *subscription) forward(in <-chan *redis.Message) {
+	defer close(s.messages)
+	for {
+		select {
+		case <-s.done:
+			return
+		case msg, ok := <-in:
+			if !ok {
+				return
+			}
+			select {
+			case s.messages <- cache.Message{Channel: msg.Channel, Payload: []byte(msg.Payload)}:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+func (s *subscription) Messages() <-chan cache.Message {
+	return s.messages
+}
+
+func (s *subscription) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.pubsub.Close()
+	})
+	return err
+}
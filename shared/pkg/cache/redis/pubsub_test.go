@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPubSub_DeliversAcrossConnections(t *testing.T) {
+	publisher := newTestClient(t)
+	subscriber := newTestClient(t)
+	ctx := context.Background()
+	channel := "test:pubsub:" + generateToken()
+
+	sub, err := subscriber.Subscribe(ctx, channel)
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	defer sub.Close()
+
+	if err := publisher.Publish(ctx, channel, []byte("hello")); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	select {
+	case msg := <-sub.Messages():
+		if msg.Channel != channel || string(msg.Payload) != "hello" {
+			t.Fatalf("unexpected message: %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("message not received")
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	select {
+	case _, ok := <-sub.Messages():
+		if ok {
+			t.Fatalf("expected messages channel to close")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("messages channel not closed after Close")
+	}
+}
@@ -37,6 +37,7 @@ const (
 	CodeDBTransaction          = "DB_TRANSACTION_ERROR"
 	CodeDBDeadlock             = "DB_DEADLOCK"
 	CodeDBSerializationFailure = "DB_SERIALIZATION_FAILURE"
+	CodeDBConflict             = "DB_CONFLICT"
 	CodeDBQuery                = "DB_QUERY_ERROR"
 	CodeDBInvalidInput         = "DB_INVALID_INPUT"
 	CodeDBSyntaxError          = "DB_SYNTAX_ERROR"
@@ -165,7 +166,8 @@ func (e *DBError) IsRetryable() bool {
 func (e *DBError) IsClientError() bool {
 	switch e.code {
 	case CodeDBInvalidInput, CodeDBSyntaxError, CodeDBDuplicateKey,
-		CodeDBForeignKey, CodeDBConstraint, CodeDBNotNull, CodeDBCheckViolation:
+		CodeDBForeignKey, CodeDBConstraint, CodeDBNotNull, CodeDBCheckViolation,
+		CodeDBConflict:
 		return true
 	default:
 		return false
@@ -214,6 +216,13 @@ func ConstraintError(table, constraint, detail string) *DBError {
 	return err
 }
 
+func ConflictError(table string, primaryKey interface{}, version int64) *DBError {
+	return NewDBError(CodeDBConflict, "Record was modified concurrently").
+		WithTable(table).
+		WithDetail("primary_key", primaryKey).
+		WithDetail("version", version)
+}
+
 func ConnectionError(message string, err error) *DBError {
 	return NewDBError(CodeDBConnection, message).WithWrapped(err)
 }
@@ -0,0 +1,54 @@
+package database
+
+import "reflect"
+
+// ColumnFields returns the db-tagged fields of struct type t in declaration
+// order. Anonymous embedded structs without a db tag are flattened into their
+// parent, so shared columns such as id and created_at can live in an embedded
+// base model; each field's Index is its path for reflect.Value.FieldByIndex.
+// Named struct fields like time.Time stay single columns. When a column is
+// declared at more than one depth the shallowest declaration wins, as with
+// Go's own field promotion.
+func ColumnFields(t reflect.Type) []reflect.StructField {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []reflect.StructField
+	depths := make(map[string]int)
+	collectColumnFields(t, nil, 0, &fields, depths)
+
+	kept := fields[:0]
+	for _, field := range fields {
+		if depths[field.Tag.Get("db")] == len(field.Index)-1 {
+			kept = append(kept, field)
+			// Later declarations at the same depth are ambiguous; keep the first
+			depths[field.Tag.Get("db")] = -1
+		}
+	}
+	return kept
+}
+
+func collectColumnFields(t reflect.Type, index []int, depth int, fields *[]reflect.StructField, depths map[string]int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		field.Index = append(append([]int(nil), index...), i)
+
+		tag := field.Tag.Get("db")
+		if tag == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectColumnFields(field.Type, field.Index, depth+1, fields, depths)
+			continue
+		}
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		if d, ok := depths[tag]; !ok || depth < d {
+			depths[tag] = depth
+		}
+		*fields = append(*fields, field)
+	}
+}
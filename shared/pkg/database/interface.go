@@ -13,14 +13,17 @@ type Model interface {
 
 type Database interface {
 	Insert(ctx context.Context, model Model) (id *string, err *DBError)
-	Upsert(ctx context.Context, model Model) *DBError
+	CreateMany(ctx context.Context, models []Model) ([]string, *DBError)
+	Upsert(ctx context.Context, model Model, conflictColumns []string, updateColumns []string) (*string, *DBError)
 	FindByID(ctx context.Context, model Model, id interface{}) *DBError
-	Update(ctx context.Context, model Model) *DBError
+	FindByIDWithDeleted(ctx context.Context, model Model, id interface{}) *DBError
+	Update(ctx context.Context, model Model, opts ...UpdateOption) *DBError
 	Delete(ctx context.Context, model Model) *DBError
 	HardDelete(ctx context.Context, model Model) *DBError
 
 	FindOne(ctx context.Context, model Model, query string, args ...interface{}) *DBError
 	FindMany(ctx context.Context, dest interface{}, query string, args ...interface{}) *DBError
+	FindPage(ctx context.Context, dest interface{}, opts PageOptions, baseQuery string, args ...interface{}) (*Page, *DBError)
 	FindOneAndUpdate(ctx context.Context, dest interface{}, query string, args ...interface{}) *DBError
 	Exists(ctx context.Context, model Model, query string, args ...interface{}) (bool, error)
 	Count(ctx context.Context, model Model, query string, args ...interface{}) (int64, error)
@@ -40,8 +43,11 @@ type Database interface {
 
 type Transaction interface {
 	Create(ctx context.Context, model Model) *DBError
+	CreateMany(ctx context.Context, models []Model) ([]string, *DBError)
+	Upsert(ctx context.Context, model Model, conflictColumns []string, updateColumns []string) (*string, *DBError)
 	FindByID(ctx context.Context, model Model, id interface{}) *DBError
-	Update(ctx context.Context, model Model) *DBError
+	FindByIDWithDeleted(ctx context.Context, model Model, id interface{}) *DBError
+	Update(ctx context.Context, model Model, opts ...UpdateOption) *DBError
 	Delete(ctx context.Context, model Model) *DBError
 	HardDelete(ctx context.Context, model Model) *DBError
 
@@ -52,6 +58,10 @@ type Transaction interface {
 	QueryRow(ctx context.Context, query string, args ...interface{}) Row
 	Exec(ctx context.Context, query string, args ...interface{}) (Result, error)
 
+	Savepoint(ctx context.Context, name string) error
+	RollbackTo(ctx context.Context, name string) error
+	ReleaseSavepoint(ctx context.Context, name string) error
+
 	Commit() error
 	Rollback() error
 }
@@ -59,6 +69,7 @@ type Transaction interface {
 type Rows interface {
 	Next() bool
 	Scan(dest ...interface{}) error
+	ScanOne(model Model) *DBError
 	Close() error
 	Err() error
 }
@@ -101,4 +112,31 @@ type Config struct {
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+	BatchSize       int
+
+	ConnectRetries      int
+	ConnectRetryBackoff time.Duration
+
+	SlowQueryThreshold time.Duration
+	// DefaultQueryTimeout bounds queries whose context has no deadline
+	DefaultQueryTimeout time.Duration
+
+	// EnableStmtCache prepares Exec, Query and QueryRow statements once and
+	// reuses them, keeping the StmtCacheSize most recent; 100 when unset
+	EnableStmtCache bool
+	StmtCacheSize   int
+
+	ReadReplicas       []ReplicaConfig
+	ReplicaLagFallback bool
+}
+
+// ReplicaConfig describes a read replica. Empty credentials, database and SSL
+// mode fall back to the primary's values.
+type ReplicaConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Database string
+	SSLMode  string
 }
@@ -65,3 +65,75 @@ func WithConnMaxIdleTime(idleTime time.Duration) Option {
 		c.ConnMaxIdleTime = idleTime
 	}
 }
+
+func WithBatchSize(batchSize int) Option {
+	return func(c *Config) {
+		c.BatchSize = batchSize
+	}
+}
+
+func WithConnectRetries(retries int) Option {
+	return func(c *Config) {
+		c.ConnectRetries = retries
+	}
+}
+
+func WithConnectRetryBackoff(backoff time.Duration) Option {
+	return func(c *Config) {
+		c.ConnectRetryBackoff = backoff
+	}
+}
+
+func WithSlowQueryThreshold(threshold time.Duration) Option {
+	return func(c *Config) {
+		c.SlowQueryThreshold = threshold
+	}
+}
+
+func WithReadReplicas(replicas ...ReplicaConfig) Option {
+	return func(c *Config) {
+		c.ReadReplicas = append(c.ReadReplicas, replicas...)
+	}
+}
+
+func WithDefaultQueryTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.DefaultQueryTimeout = timeout
+	}
+}
+
+// WithStmtCache enables the prepared statement cache holding up to size
+// statements per pool
+func WithStmtCache(size int) Option {
+	return func(c *Config) {
+		c.EnableStmtCache = true
+		c.StmtCacheSize = size
+	}
+}
+
+func WithReplicaLagFallback(enabled bool) Option {
+	return func(c *Config) {
+		c.ReplicaLagFallback = enabled
+	}
+}
+
+type UpdateOptions struct {
+	SkipTimestamp bool
+}
+
+type UpdateOption func(*UpdateOptions)
+
+// WithoutTimestamp leaves the model's updated_at untouched on Update.
+func WithoutTimestamp() UpdateOption {
+	return func(o *UpdateOptions) {
+		o.SkipTimestamp = true
+	}
+}
+
+func ApplyUpdateOptions(opts ...UpdateOption) UpdateOptions {
+	var options UpdateOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
@@ -0,0 +1,51 @@
+package database
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+const (
+	SortAsc  = "ASC"
+	SortDesc = "DESC"
+)
+
+// ClampLimit applies the default page size to a missing limit and caps it at
+// MaxPageLimit
+func ClampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
+
+type PageOptions struct {
+	Limit     int
+	Offset    int
+	OrderBy   string
+	Direction string
+}
+
+type Page struct {
+	Total      int64
+	Limit      int
+	Offset     int
+	HasNext    bool
+	NextOffset int
+}
+
+func NewPage(total int64, limit, offset, fetched int) *Page {
+	page := &Page{
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	if int64(offset+fetched) < total {
+		page.HasNext = true
+		page.NextOffset = offset + fetched
+	}
+	return page
+}
@@ -3,10 +3,12 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
@@ -17,9 +19,34 @@ import (
 	"github.com/lib/pq"
 )
 
+const (
+	DefaultBatchSize = 1000
+
+	DefaultConnectRetries      = 5
+	DefaultConnectRetryBackoff = 500 * time.Millisecond
+	maxConnectRetryBackoff     = 30 * time.Second
+
+	// DefaultQueryTimeout bounds queries whose context has no deadline when
+	// Config.DefaultQueryTimeout is unset. Callers needing longer pass a
+	// context with their own deadline.
+	DefaultQueryTimeout = 30 * time.Second
+
+	// Postgres rejects statements carrying more bind parameters than this.
+	maxQueryParams = 65535
+)
+
 type client struct {
-	db     *sql.DB
-	logger logger.Logger
+	db           *sql.DB
+	logger       logger.Logger
+	batchSize    int
+	timer        queryTimer
+	queryTimeout time.Duration
+	// stmts holds a prepared statement cache per pool when enabled
+	stmts map[*sql.DB]*stmtCache
+
+	replicas        []*sql.DB
+	replicaFallback bool
+	nextReplica     uint32
 }
 
 func New(config database.Config) (database.Database, error) {
@@ -31,14 +58,60 @@ func New(config database.Config) (database.Database, error) {
 		Service:    "postgres-client",
 	})
 
+	db, err := openPool(config, config.Host, config.Port, config.User, config.Password, config.Database, config.SSLMode)
+	if err != nil {
+		lgr.Error("Failed to open database", logger.Error(err))
+		return nil, err
+	}
+
+	if err := pingWithRetry(db, config, lgr); err != nil {
+		lgr.Error("Failed to ping database", logger.Error(err))
+		db.Close()
+		return nil, database.ConnectionError("failed to connect to database", err).
+			WithDetail("host", config.Host).
+			WithDetail("port", config.Port)
+	}
+
+	lgr.Info("Connected to database")
+
+	replicas, dbErr := openReplicas(config, lgr)
+	if dbErr != nil {
+		db.Close()
+		return nil, dbErr
+	}
+
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	queryTimeout := config.DefaultQueryTimeout
+	if queryTimeout <= 0 {
+		queryTimeout = DefaultQueryTimeout
+	}
+
+	c := &client{
+		db:              db,
+		logger:          lgr,
+		batchSize:       batchSize,
+		timer:           queryTimer{logger: lgr, threshold: config.SlowQueryThreshold},
+		queryTimeout:    queryTimeout,
+		replicas:        replicas,
+		replicaFallback: config.ReplicaLagFallback,
+	}
+	if config.EnableStmtCache {
+		c.enableStmtCache(config.StmtCacheSize)
+	}
+	return c, nil
+}
+
+func openPool(config database.Config, host string, port int, user, password, dbName, sslMode string) (*sql.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.User, config.Password, config.Database, config.SSLMode,
+		host, port, user, password, dbName, sslMode,
 	)
 
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		lgr.Error("Failed to open database", logger.Error(err))
 		return nil, err
 	}
 
@@ -46,24 +119,52 @@ func New(config database.Config) (database.Database, error) {
 	db.SetMaxIdleConns(config.MaxIdleConns)
 	db.SetConnMaxLifetime(config.ConnMaxLifetime)
 	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	return db, nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := db.PingContext(ctx); err != nil {
-		lgr.Error("Failed to ping database", logger.Error(err))
-		return nil, err
+func pingWithRetry(db *sql.DB, config database.Config, lgr logger.Logger) error {
+	retries := config.ConnectRetries
+	if retries <= 0 {
+		retries = DefaultConnectRetries
+	}
+	backoff := config.ConnectRetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultConnectRetryBackoff
 	}
 
-	lgr.Info("Connected to database")
+	var err error
+	for attempt := 1; attempt <= retries; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		lgr.Debug("Database ping failed",
+			logger.Int("attempt", attempt),
+			logger.Int("max_attempts", retries),
+			logger.Duration("backoff", backoff),
+			logger.Error(err),
+		)
+
+		if attempt == retries {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxConnectRetryBackoff {
+			backoff = maxConnectRetryBackoff
+		}
+	}
 
-	return &client{
-		db:     db,
-		logger: lgr,
-	}, nil
+	return err
 }
 
 func (c *client) Insert(ctx context.Context, model database.Model) (*string, *database.DBError) {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
 	fields, values := getFieldsAndValues(model)
 	if len(fields) == 0 {
 		return nil, database.NewDBError(database.CodeDBInternal, "no db tags found in model").
@@ -110,7 +211,7 @@ func (c *client) Insert(ctx context.Context, model database.Model) (*string, *da
 	)
 
 	var returnedID interface{}
-	if err := c.db.QueryRowContext(ctx, query, nargs...).Scan(&returnedID); err != nil {
+	if err := c.timer.queryRow(ctx, c.db, "Insert", query, nargs...).Scan(&returnedID); err != nil {
 		c.logDatabaseError("Create", query, nargs, err)
 		return nil, wrapDatabaseError(err, "Create", model.TableName(), query)
 	}
@@ -124,147 +225,109 @@ func (c *client) Insert(ctx context.Context, model database.Model) (*string, *da
 	return &formattedID, nil
 }
 
-func (c *client) Upsert(ctx context.Context, model database.Model) *database.DBError {
-	fields, values := getFieldsAndValues(model)
-	if len(fields) == 0 {
-		return database.NewDBError(database.CodeDBInternal, "no db tags found in model").
-			WithDetail("table", model.TableName())
+func (c *client) CreateMany(ctx context.Context, models []database.Model) ([]string, *database.DBError) {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
+	batches, dbErr := buildInsertBatches(models, c.batchSize)
+	if dbErr != nil {
+		return nil, dbErr
 	}
 
-	pkField := getPrimaryKeyField(model)
-	filteredFields := []string{}
-	filteredValues := []interface{}{}
+	ids := make([]string, 0, len(models))
+	for _, batch := range batches {
+		c.logger.Debug("CreateMany",
+			logger.String("query", batch.query),
+			logger.String("table", batch.table),
+			logger.Int("rows", len(batch.models)),
+		)
 
-	for i, field := range fields {
-		if field == pkField {
-			val := values[i]
-			if str, ok := val.(string); ok && str == "" {
-				continue
-			}
+		batchIDs, err := insertBatchRows(ctx, c.timer.queryer(c.db, "CreateMany"), batch)
+		if err != nil {
+			c.logDatabaseError("CreateMany", batch.query, batch.args, err)
+			return nil, wrapDatabaseError(err, "CreateMany", batch.table, batch.query)
 		}
-		filteredFields = append(filteredFields, field)
-		filteredValues = append(filteredValues, values[i])
+		ids = append(ids, batchIDs...)
 	}
 
-	if len(filteredFields) == 0 {
-		return database.NewDBError(database.CodeDBInternal, "no fields to upsert").
-			WithDetail("table", model.TableName())
-	}
+	return ids, nil
+}
 
-	placeholders := make([]string, len(filteredFields))
-	for i := range placeholders {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
-	}
+// Upsert inserts model or, when a row with the same conflictColumns already
+// exists, updates updateColumns on it. conflictColumns defaults to the primary
+// key. A nil updateColumns updates every column except the primary key and
+// created_at; a non-nil empty slice emits DO NOTHING, in which case a conflict
+// returns a nil id and leaves the model untouched.
+func (c *client) Upsert(ctx context.Context, model database.Model, conflictColumns []string, updateColumns []string) (*string, *database.DBError) {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
 
-	// Build update clause excluding primary key and created_at
-	updateParts := make([]string, 0)
-	for _, field := range filteredFields {
-		if field != pkField && field != "created_at" {
-			updateParts = append(updateParts, fmt.Sprintf("%s = EXCLUDED.%s", field, field))
-		}
+	query, args, dbErr := buildUpsertQuery(model, conflictColumns, updateColumns)
+	if dbErr != nil {
+		return nil, dbErr
 	}
 
-	query := fmt.Sprintf(
-		"INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s RETURNING %s",
-		model.TableName(),
-		strings.Join(filteredFields, ", "),
-		strings.Join(placeholders, ", "),
-		pkField,
-		strings.Join(updateParts, ", "),
-		pkField,
-	)
-
-	nargs := normalizeArgs(filteredValues)
 	c.logger.Debug("Upsert",
 		logger.String("query", query),
 		logger.String("table", model.TableName()),
 	)
 
-	var returnedID interface{}
-	if err := c.db.QueryRowContext(ctx, query, nargs...).Scan(&returnedID); err != nil {
-		c.logDatabaseError("Upsert", query, nargs, err)
-		return wrapDatabaseError(err, "Upsert", model.TableName(), query)
+	id, err := upsertRow(ctx, c.timer.queryer(c.db, "Upsert"), model, query, args)
+	if err != nil {
+		c.logDatabaseError("Upsert", query, args, err)
+		return nil, wrapDatabaseError(err, "Upsert", model.TableName(), query)
 	}
+	return id, nil
+}
 
-	if err := setPrimaryKeyValue(model, pkField, returnedID); err != nil {
-		return database.WrapDBError(err, database.CodeDBInternal, "failed to set primary key value").
-			WithDetail("table", model.TableName()).
-			WithDetail("pk_field", pkField)
-	}
+func (c *client) FindByID(ctx context.Context, model database.Model, id interface{}) *database.DBError {
+	return c.findByID(ctx, "FindByID", model, id, false)
+}
 
-	return nil
+func (c *client) FindByIDWithDeleted(ctx context.Context, model database.Model, id interface{}) *database.DBError {
+	return c.findByID(ctx, "FindByIDWithDeleted", model, id, true)
 }
 
-func (c *client) FindByID(ctx context.Context, model database.Model, id interface{}) *database.DBError {
-	fields := getFields(model)
-	if len(fields) == 0 {
-		return database.NewDBError(database.CodeDBInternal, "no db tags found in model").
-			WithDetail("table", model.TableName())
-	}
+func (c *client) findByID(ctx context.Context, operation string, model database.Model, id interface{}, withDeleted bool) *database.DBError {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
 
-	pkField := getPrimaryKeyField(model)
-	query := fmt.Sprintf(
-		"SELECT %s FROM %s WHERE %s = $1",
-		strings.Join(fields, ", "),
-		model.TableName(),
-		pkField,
-	)
+	query, dbErr := buildFindByIDQuery(model, withDeleted)
+	if dbErr != nil {
+		return dbErr
+	}
 
-	c.logger.Debug("FindByID",
+	c.logger.Debug(operation,
 		logger.String("query", query),
 		logger.String("table", model.TableName()),
 	)
 
-	row := c.db.QueryRowContext(ctx, query, id)
-	if err := scanStruct(row, model); err != nil {
-		c.logDatabaseError("FindByID", query, []interface{}{id}, err)
-		return wrapDatabaseError(err, "FindByID", model.TableName(), query)
+	err := c.read(operation, func(conn sqlConn) error {
+		return scanStruct(c.timer.queryRow(ctx, conn, operation, query, id), model)
+	})
+	if err != nil {
+		c.logDatabaseError(operation, query, []interface{}{id}, err)
+		return wrapDatabaseError(err, operation, model.TableName(), query)
 	}
 	return nil
 }
 
-func (c *client) Update(ctx context.Context, model database.Model) *database.DBError {
-	fields, values := getFieldsAndValues(model)
-	if len(fields) == 0 {
-		return database.NewDBError(database.CodeDBInternal, "no db tags found in model").
-			WithDetail("table", model.TableName())
-	}
-
-	pkField := getPrimaryKeyField(model)
-	setParts := make([]string, 0, len(fields))
-	updateValues := make([]interface{}, 0, len(values))
-
-	for i, field := range fields {
-		if field == pkField || field == "created_at" {
-			continue
-		}
-		setParts = append(setParts, fmt.Sprintf("%s = $%d", field, len(setParts)+1))
-		updateValues = append(updateValues, values[i])
-	}
+func (c *client) Update(ctx context.Context, model database.Model, opts ...database.UpdateOption) *database.DBError {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
 
-	if len(setParts) == 0 {
-		return database.NewDBError(database.CodeDBInternal, "no fields to update").
-			WithDetail("table", model.TableName())
+	query, nargs, version, dbErr := buildUpdateQuery(model, database.ApplyUpdateOptions(opts...))
+	if dbErr != nil {
+		return dbErr
 	}
 
-	updateValues = append(updateValues, model.PrimaryKey())
-
-	query := fmt.Sprintf(
-		"UPDATE %s SET %s WHERE %s = $%d",
-		model.TableName(),
-		strings.Join(setParts, ", "),
-		pkField,
-		len(updateValues),
-	)
-
-	nargs := normalizeArgs(updateValues)
 	c.logger.Debug("Update",
 		logger.String("query", query),
 		logger.String("table", model.TableName()),
 		logger.Any("primary_key", model.PrimaryKey()),
 	)
 
-	result, err := c.db.ExecContext(ctx, query, nargs...)
+	result, err := c.timer.exec(ctx, c.db, "Update", query, nargs...)
 	if err != nil {
 		c.logDatabaseError("Update", query, nargs, err)
 		return wrapDatabaseError(err, "Update", model.TableName(), query)
@@ -276,16 +339,28 @@ func (c *client) Update(ctx context.Context, model database.Model) *database.DBE
 			WithDetail("table", model.TableName())
 	}
 	if rows == 0 {
+		if version != nil {
+			return database.ConflictError(model.TableName(), model.PrimaryKey(), *version).
+				WithOperation("Update").
+				WithQuery(query)
+		}
 		return database.NewDBError(database.CodeDBInternal, "record not found").
 			WithDetail("operation", "Update").
 			WithDetail("table", model.TableName()).
 			WithDetail("primary_key", model.PrimaryKey())
 	}
 
+	if version != nil {
+		setVersionValue(model, *version+1)
+	}
+
 	return nil
 }
 
 func (c *client) Delete(ctx context.Context, model database.Model) *database.DBError {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
 	pkField := getPrimaryKeyField(model)
 	query := fmt.Sprintf(
 		"UPDATE %s SET deleted_at = $1 WHERE %s = $2 AND deleted_at IS NULL",
@@ -298,7 +373,7 @@ func (c *client) Delete(ctx context.Context, model database.Model) *database.DBE
 		logger.String("table", model.TableName()),
 	)
 
-	result, err := c.db.ExecContext(ctx, query, time.Now(), model.PrimaryKey())
+	result, err := c.timer.exec(ctx, c.db, "Delete", query, time.Now(), model.PrimaryKey())
 	if err != nil {
 		c.logDatabaseError("Delete", query, []interface{}{time.Now(), model.PrimaryKey()}, err)
 		return wrapDatabaseError(err, "Delete", model.TableName(), query)
@@ -320,6 +395,9 @@ func (c *client) Delete(ctx context.Context, model database.Model) *database.DBE
 }
 
 func (c *client) HardDelete(ctx context.Context, model database.Model) *database.DBError {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
 	pkField := getPrimaryKeyField(model)
 	query := fmt.Sprintf(
 		"DELETE FROM %s WHERE %s = $1",
@@ -332,7 +410,7 @@ func (c *client) HardDelete(ctx context.Context, model database.Model) *database
 		logger.String("table", model.TableName()),
 	)
 
-	result, err := c.db.ExecContext(ctx, query, model.PrimaryKey())
+	result, err := c.timer.exec(ctx, c.db, "HardDelete", query, model.PrimaryKey())
 	if err != nil {
 		c.logDatabaseError("HardDelete", query, []interface{}{model.PrimaryKey()}, err)
 		return wrapDatabaseError(err, "HardDelete", model.TableName(), query)
@@ -354,11 +432,16 @@ func (c *client) HardDelete(ctx context.Context, model database.Model) *database
 }
 
 func (c *client) FindOne(ctx context.Context, model database.Model, query string, args ...interface{}) *database.DBError {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
 	nargs := normalizeArgs(args)
 	c.logger.Debug("FindOne", logger.String("query", query))
 
-	row := c.db.QueryRowContext(ctx, query, nargs...)
-	if err := scanStruct(row, model); err != nil {
+	err := c.read("FindOne", func(conn sqlConn) error {
+		return scanStruct(c.timer.queryRow(ctx, conn, "FindOne", query, nargs...), model)
+	})
+	if err != nil {
 		c.logDatabaseError("FindOne", query, nargs, err)
 		return wrapDatabaseError(err, "FindOne", model.TableName(), query)
 	}
@@ -366,10 +449,13 @@ func (c *client) FindOne(ctx context.Context, model database.Model, query string
 }
 
 func (c *client) FindOneAndUpdate(ctx context.Context, dest interface{}, query string, args ...interface{}) *database.DBError {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
 	nargs := normalizeArgs(args)
 	c.logger.Debug("FindOneAndUpdate", logger.String("query", query))
 
-	row := c.db.QueryRowContext(ctx, query, nargs...)
+	row := c.timer.queryRow(ctx, c.db, "FindOneAndUpdate", query, nargs...)
 	if err := scanStruct(row, dest); err != nil {
 		c.logDatabaseError("FindOneAndUpdate", query, nargs, err)
 		return wrapDatabaseError(err, "FindOneAndUpdate", "Table", query)
@@ -378,17 +464,30 @@ func (c *client) FindOneAndUpdate(ctx context.Context, dest interface{}, query s
 }
 
 func (c *client) FindMany(ctx context.Context, dest interface{}, query string, args ...interface{}) *database.DBError {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
 	nargs := normalizeArgs(args)
 	c.logger.Debug("FindMany", logger.String("query", query))
 
-	rows, err := c.db.QueryContext(ctx, query, nargs...)
-	if err != nil {
+	restore := sliceLenRestorer(dest)
+	var scanErr error
+	err := c.read("FindMany", func(conn sqlConn) error {
+		restore()
+		rows, err := c.timer.query(ctx, conn, "FindMany", query, nargs...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		scanErr = scanStructs(rows, dest, c.logger)
+		return scanErr
+	})
+	if err != nil && scanErr == nil {
 		c.logDatabaseError("FindMany", query, nargs, err)
 		return wrapDatabaseError(err, "FindMany", "", query)
 	}
-	defer rows.Close()
-
-	if err := scanStructs(rows, dest, c.logger); err != nil {
+	if err != nil {
 		c.logDatabaseError("FindMany:Scan", query, nargs, err)
 		return database.WrapDBError(err, database.CodeDBInternal, "failed to scan results").
 			WithDetail("operation", "FindMany")
@@ -396,12 +495,76 @@ func (c *client) FindMany(ctx context.Context, dest interface{}, query string, a
 	return nil
 }
 
+// FindPage runs baseQuery with ORDER BY/LIMIT/OFFSET appended and reports the
+// total row count of baseQuery. OrderBy must be a db-tagged column of the
+// destination element type; baseQuery must not carry its own ORDER BY or LIMIT.
+func (c *client) FindPage(ctx context.Context, dest interface{}, opts database.PageOptions, baseQuery string, args ...interface{}) (*database.Page, *database.DBError) {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
+	pageQuery, countQuery, limit, offset, dbErr := buildPageQueries(dest, opts, baseQuery, len(args))
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	nargs := normalizeArgs(args)
+	c.logger.Debug("FindPage",
+		logger.String("query", pageQuery),
+		logger.Int("limit", limit),
+		logger.Int("offset", offset),
+	)
+
+	pageArgs := append(append(make([]interface{}, 0, len(nargs)+2), nargs...), limit, offset)
+	sliceValue := reflect.ValueOf(dest).Elem()
+
+	var total int64
+	var countErr, scanErr error
+	err := c.read("FindPage", func(conn sqlConn) error {
+		countErr, scanErr = nil, nil
+		sliceValue.Set(sliceValue.Slice(0, 0))
+
+		if countErr = c.timer.queryRow(ctx, conn, "FindPage:Count", countQuery, nargs...).Scan(&total); countErr != nil {
+			return countErr
+		}
+
+		rows, err := c.timer.query(ctx, conn, "FindPage", pageQuery, pageArgs...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		scanErr = scanStructs(rows, dest, c.logger)
+		return scanErr
+	})
+	if countErr != nil {
+		c.logDatabaseError("FindPage:Count", countQuery, nargs, err)
+		return nil, wrapDatabaseError(err, "FindPage", "", countQuery)
+	}
+	if err != nil && scanErr == nil {
+		c.logDatabaseError("FindPage", pageQuery, pageArgs, err)
+		return nil, wrapDatabaseError(err, "FindPage", "", pageQuery)
+	}
+	if err != nil {
+		c.logDatabaseError("FindPage:Scan", pageQuery, pageArgs, err)
+		return nil, database.WrapDBError(err, database.CodeDBInternal, "failed to scan results").
+			WithDetail("operation", "FindPage")
+	}
+
+	fetched := sliceValue.Len()
+	return database.NewPage(total, limit, offset, fetched), nil
+}
+
 func (c *client) Exists(ctx context.Context, model database.Model, query string, args ...interface{}) (bool, error) {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
 	nargs := normalizeArgs(args)
 	c.logger.Debug("Exists", logger.String("query", query))
 
 	var exists bool
-	err := c.db.QueryRowContext(ctx, query, nargs...).Scan(&exists)
+	err := c.read("Exists", func(conn sqlConn) error {
+		return c.timer.queryRow(ctx, conn, "Exists", query, nargs...).Scan(&exists)
+	})
 	if err != nil {
 		c.logDatabaseError("Exists", query, nargs, err)
 		return false, wrapDatabaseError(err, "Exists", model.TableName(), query)
@@ -410,11 +573,16 @@ func (c *client) Exists(ctx context.Context, model database.Model, query string,
 }
 
 func (c *client) Count(ctx context.Context, model database.Model, query string, args ...interface{}) (int64, error) {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
 	nargs := normalizeArgs(args)
 	c.logger.Debug("Count", logger.String("query", query))
 
 	var count int64
-	err := c.db.QueryRowContext(ctx, query, nargs...).Scan(&count)
+	err := c.read("Count", func(conn sqlConn) error {
+		return c.timer.queryRow(ctx, conn, "Count", query, nargs...).Scan(&count)
+	})
 	if err != nil {
 		c.logDatabaseError("Count", query, nargs, err)
 		return 0, wrapDatabaseError(err, "Count", model.TableName(), query)
@@ -423,28 +591,74 @@ func (c *client) Count(ctx context.Context, model database.Model, query string,
 }
 
 func (c *client) Query(ctx context.Context, query string, args ...interface{}) (database.Rows, *database.DBError) {
+	ctx, cancel := c.withQueryTimeout(ctx)
+
 	nargs := normalizeArgs(args)
 	c.logger.Debug("Query", logger.String("query", query))
 
-	rows, err := c.db.QueryContext(ctx, query, nargs...)
+	var rows *sql.Rows
+	err := c.read("Query", func(conn sqlConn) error {
+		var err error
+		rows, err = c.timer.query(ctx, c.prepared(conn), "Query", query, nargs...)
+		return err
+	})
 	if err != nil {
+		cancel()
 		c.logDatabaseError("Query", query, nargs, err)
 		return nil, wrapDatabaseError(err, "Query", "", query)
 	}
-	return &rowsWrapper{rows: rows, log: c.logger}, nil
+	return &rowsWrapper{rows: rows, log: c.logger, cancel: cancel}, nil
 }
 
 func (c *client) QueryRow(ctx context.Context, query string, args ...interface{}) database.Row {
+	ctx, cancel := c.withQueryTimeout(ctx)
+
 	nargs := normalizeArgs(args)
 	c.logger.Debug("QueryRow", logger.String("query", query))
-	return &rowWrapper{row: c.db.QueryRowContext(ctx, query, nargs...), log: c.logger}
+
+	// The row is only read on Scan, so that is where a failed replica read
+	// falls back to the primary
+	read := func(scan func(row *sql.Row) error) error {
+		return c.read("QueryRow", func(conn sqlConn) error {
+			return scan(c.timer.queryRow(ctx, c.prepared(conn), "QueryRow", query, nargs...))
+		})
+	}
+	return &rowWrapper{read: read, log: c.logger, cancel: cancel}
+}
+
+// enableStmtCache gives the primary and every replica its own prepared
+// statement cache holding up to size statements
+func (c *client) enableStmtCache(size int) {
+	c.stmts = map[*sql.DB]*stmtCache{c.db: newStmtCache(c.db, size)}
+	for _, replica := range c.replicas {
+		c.stmts[replica] = newStmtCache(replica, size)
+	}
+}
+
+// prepared routes conn through its statement cache when caching is enabled
+func (c *client) prepared(conn sqlConn) sqlConn {
+	if db, ok := conn.(*sql.DB); ok {
+		if cache, ok := c.stmts[db]; ok {
+			return cache
+		}
+	}
+	return conn
+}
+
+// withQueryTimeout applies the client's default timeout to ctx when the caller
+// didn't set a deadline
+func (c *client) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return database.WithQueryTimeout(ctx, c.queryTimeout)
 }
 
 func (c *client) Exec(ctx context.Context, query string, args ...interface{}) (database.Result, *database.DBError) {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
 	nargs := normalizeArgs(args)
 	c.logger.Debug("Exec", logger.String("query", query))
 
-	result, err := c.db.ExecContext(ctx, query, nargs...)
+	result, err := c.timer.exec(ctx, c.prepared(c.db), "Exec", query, nargs...)
 	if err != nil {
 		c.logDatabaseError("Exec", query, nargs, err)
 		return nil, wrapDatabaseError(err, "Exec", "", query)
@@ -459,7 +673,7 @@ func (c *client) Begin(ctx context.Context) (database.Transaction, *database.DBE
 		c.logger.Error("Failed to begin transaction", logger.Error(err))
 		return nil, database.WrapDBError(err, database.CodeDBInternal, "failed to begin transaction")
 	}
-	return &transactionWrapper{tx: tx, logger: c.logger}, nil
+	return &transactionWrapper{tx: tx, logger: c.logger, batchSize: c.batchSize, timer: c.timer}, nil
 }
 
 func (c *client) BeginTx(ctx context.Context, opts *database.TxOptions) (database.Transaction, *database.DBError) {
@@ -475,7 +689,7 @@ func (c *client) BeginTx(ctx context.Context, opts *database.TxOptions) (databas
 		c.logger.Error("Failed to begin transaction with options", logger.Error(err))
 		return nil, database.WrapDBError(err, database.CodeDBInternal, "failed to begin transaction with options")
 	}
-	return &transactionWrapper{tx: tx, logger: c.logger}, nil
+	return &transactionWrapper{tx: tx, logger: c.logger, batchSize: c.batchSize, timer: c.timer}, nil
 }
 
 func (c *client) WithTransaction(ctx context.Context, fn func(tx database.Transaction) *database.DBError) *database.DBError {
@@ -512,6 +726,14 @@ func (c *client) WithTransaction(ctx context.Context, fn func(tx database.Transa
 
 func (c *client) Close() *database.DBError {
 	c.logger.Debug("Closing database")
+	for _, cache := range c.stmts {
+		cache.close()
+	}
+	for _, replica := range c.replicas {
+		if err := replica.Close(); err != nil {
+			c.logger.Error("Failed to close read replica", logger.Error(err))
+		}
+	}
 	if err := c.db.Close(); err != nil {
 		return database.WrapDBError(err, database.CodeDBInternal, "failed to close database")
 	}
@@ -519,6 +741,9 @@ func (c *client) Close() *database.DBError {
 }
 
 func (c *client) Ping(ctx context.Context) *database.DBError {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
 	if err := c.db.PingContext(ctx); err != nil {
 		return database.WrapDBError(err, database.CodeDBInternal, "failed to ping database")
 	}
@@ -526,23 +751,18 @@ func (c *client) Ping(ctx context.Context) *database.DBError {
 }
 
 func (c *client) Stats() database.Stats {
-	stats := c.db.Stats()
-	return database.Stats{
-		MaxOpenConnections: stats.MaxOpenConnections,
-		OpenConnections:    stats.OpenConnections,
-		InUse:              stats.InUse,
-		Idle:               stats.Idle,
-		WaitCount:          stats.WaitCount,
-		WaitDuration:       stats.WaitDuration,
-		MaxIdleClosed:      stats.MaxIdleClosed,
-		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
-		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	stats := toStats(c.db.Stats())
+	for _, replica := range c.replicas {
+		stats = addStats(stats, toStats(replica.Stats()))
 	}
+	return stats
 }
 
 type transactionWrapper struct {
-	tx     *sql.Tx
-	logger logger.Logger
+	tx        *sql.Tx
+	logger    logger.Logger
+	batchSize int
+	timer     queryTimer
 }
 
 func (c *client) logDatabaseError(operation string, query string, args []interface{}, err error) {
@@ -668,7 +888,7 @@ func (t *transactionWrapper) Create(ctx context.Context, model database.Model) *
 	pkField := getPrimaryKeyField(model)
 	var returnedID interface{}
 
-	err := t.tx.QueryRowContext(ctx, query, nargs...).Scan(&returnedID)
+	err := t.timer.queryRow(ctx, t.tx, "TX:Create", query, nargs...).Scan(&returnedID)
 	if err != nil {
 		t.logDatabaseError("Create", query, nargs, err)
 		return wrapDatabaseError(err, "TX:Create", model.TableName(), query)
@@ -683,72 +903,86 @@ func (t *transactionWrapper) Create(ctx context.Context, model database.Model) *
 	return nil
 }
 
-func (t *transactionWrapper) FindByID(ctx context.Context, model database.Model, id interface{}) *database.DBError {
-	fields := getFields(model)
-	if len(fields) == 0 {
-		return database.NewDBError(database.CodeDBInternal, "no db tags found in model").
-			WithDetail("table", model.TableName())
+func (t *transactionWrapper) CreateMany(ctx context.Context, models []database.Model) ([]string, *database.DBError) {
+	batches, dbErr := buildInsertBatches(models, t.batchSize)
+	if dbErr != nil {
+		return nil, dbErr
 	}
 
-	pkField := getPrimaryKeyField(model)
-	query := fmt.Sprintf(
-		"SELECT %s FROM %s WHERE %s = $1",
-		strings.Join(fields, ", "),
-		model.TableName(),
-		pkField,
-	)
-
-	t.logger.Debug("TX FindByID", logger.String("query", query))
+	ids := make([]string, 0, len(models))
+	for _, batch := range batches {
+		t.logger.Debug("TX CreateMany",
+			logger.String("query", batch.query),
+			logger.String("table", batch.table),
+			logger.Int("rows", len(batch.models)),
+		)
 
-	row := t.tx.QueryRowContext(ctx, query, id)
-	if err := scanStruct(row, model); err != nil {
-		t.logDatabaseError("FindByID", query, []interface{}{id}, err)
-		return wrapDatabaseError(err, "TX:FindByID", model.TableName(), query)
+		batchIDs, err := insertBatchRows(ctx, t.timer.queryer(t.tx, "TX:CreateMany"), batch)
+		if err != nil {
+			t.logDatabaseError("CreateMany", batch.query, batch.args, err)
+			return nil, wrapDatabaseError(err, "TX:CreateMany", batch.table, batch.query)
+		}
+		ids = append(ids, batchIDs...)
 	}
-	return nil
+
+	return ids, nil
 }
 
-func (t *transactionWrapper) Update(ctx context.Context, model database.Model) *database.DBError {
-	fields, values := getFieldsAndValues(model)
-	if len(fields) == 0 {
-		return database.NewDBError(database.CodeDBInternal, "no db tags found in model").
-			WithDetail("table", model.TableName())
+func (t *transactionWrapper) Upsert(ctx context.Context, model database.Model, conflictColumns []string, updateColumns []string) (*string, *database.DBError) {
+	query, args, dbErr := buildUpsertQuery(model, conflictColumns, updateColumns)
+	if dbErr != nil {
+		return nil, dbErr
 	}
 
-	pkField := getPrimaryKeyField(model)
-	setParts := make([]string, 0, len(fields))
-	updateValues := make([]interface{}, 0, len(values))
+	t.logger.Debug("TX Upsert",
+		logger.String("query", query),
+		logger.String("table", model.TableName()),
+	)
 
-	for i, field := range fields {
-		if field == pkField || field == "created_at" {
-			continue
-		}
-		setParts = append(setParts, fmt.Sprintf("%s = $%d", field, len(setParts)+1))
-		updateValues = append(updateValues, values[i])
+	id, err := upsertRow(ctx, t.timer.queryer(t.tx, "TX:Upsert"), model, query, args)
+	if err != nil {
+		t.logDatabaseError("Upsert", query, args, err)
+		return nil, wrapDatabaseError(err, "TX:Upsert", model.TableName(), query)
 	}
+	return id, nil
+}
 
-	if len(setParts) == 0 {
-		return database.NewDBError(database.CodeDBInternal, "no fields to update").
-			WithDetail("table", model.TableName())
-	}
+func (t *transactionWrapper) FindByID(ctx context.Context, model database.Model, id interface{}) *database.DBError {
+	return t.findByID(ctx, "FindByID", model, id, false)
+}
 
-	updateValues = append(updateValues, model.PrimaryKey())
+func (t *transactionWrapper) FindByIDWithDeleted(ctx context.Context, model database.Model, id interface{}) *database.DBError {
+	return t.findByID(ctx, "FindByIDWithDeleted", model, id, true)
+}
 
-	query := fmt.Sprintf(
-		"UPDATE %s SET %s WHERE %s = $%d",
-		model.TableName(),
-		strings.Join(setParts, ", "),
-		pkField,
-		len(updateValues),
-	)
+func (t *transactionWrapper) findByID(ctx context.Context, operation string, model database.Model, id interface{}, withDeleted bool) *database.DBError {
+	query, dbErr := buildFindByIDQuery(model, withDeleted)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	t.logger.Debug("TX "+operation, logger.String("query", query))
+
+	row := t.timer.queryRow(ctx, t.tx, "TX:"+operation, query, id)
+	if err := scanStruct(row, model); err != nil {
+		t.logDatabaseError(operation, query, []interface{}{id}, err)
+		return wrapDatabaseError(err, "TX:"+operation, model.TableName(), query)
+	}
+	return nil
+}
+
+func (t *transactionWrapper) Update(ctx context.Context, model database.Model, opts ...database.UpdateOption) *database.DBError {
+	query, nargs, version, dbErr := buildUpdateQuery(model, database.ApplyUpdateOptions(opts...))
+	if dbErr != nil {
+		return dbErr
+	}
 
-	nargs := normalizeArgs(updateValues)
 	t.logger.Debug("TX Update",
 		logger.String("query", query),
 		logger.String("table", model.TableName()),
 	)
 
-	result, err := t.tx.ExecContext(ctx, query, nargs...)
+	result, err := t.timer.exec(ctx, t.tx, "TX:Update", query, nargs...)
 	if err != nil {
 		t.logDatabaseError("Update", query, nargs, err)
 		return wrapDatabaseError(err, "TX:Update", model.TableName(), query)
@@ -760,12 +994,21 @@ func (t *transactionWrapper) Update(ctx context.Context, model database.Model) *
 			WithDetail("table", model.TableName())
 	}
 	if rows == 0 {
+		if version != nil {
+			return database.ConflictError(model.TableName(), model.PrimaryKey(), *version).
+				WithOperation("TX:Update").
+				WithQuery(query)
+		}
 		return database.NewDBError(database.CodeDBInternal, "record not found").
 			WithDetail("operation", "TX:Update").
 			WithDetail("table", model.TableName()).
 			WithDetail("primary_key", model.PrimaryKey())
 	}
 
+	if version != nil {
+		setVersionValue(model, *version+1)
+	}
+
 	return nil
 }
 
@@ -779,7 +1022,7 @@ func (t *transactionWrapper) Delete(ctx context.Context, model database.Model) *
 
 	t.logger.Debug("TX Delete", logger.String("query", query))
 
-	result, err := t.tx.ExecContext(ctx, query, time.Now(), model.PrimaryKey())
+	result, err := t.timer.exec(ctx, t.tx, "TX:Delete", query, time.Now(), model.PrimaryKey())
 	if err != nil {
 		t.logDatabaseError("Delete", query, []interface{}{time.Now(), model.PrimaryKey()}, err)
 		return wrapDatabaseError(err, "TX:Delete", model.TableName(), query)
@@ -810,7 +1053,7 @@ func (t *transactionWrapper) HardDelete(ctx context.Context, model database.Mode
 
 	t.logger.Debug("TX HardDelete", logger.String("query", query))
 
-	result, err := t.tx.ExecContext(ctx, query, model.PrimaryKey())
+	result, err := t.timer.exec(ctx, t.tx, "TX:HardDelete", query, model.PrimaryKey())
 	if err != nil {
 		t.logDatabaseError("HardDelete", query, []interface{}{model.PrimaryKey()}, err)
 		return wrapDatabaseError(err, "TX:HardDelete", model.TableName(), query)
@@ -835,7 +1078,7 @@ func (t *transactionWrapper) FindOne(ctx context.Context, model database.Model,
 	nargs := normalizeArgs(args)
 	t.logger.Debug("TX FindOne", logger.String("query", query))
 
-	row := t.tx.QueryRowContext(ctx, query, nargs...)
+	row := t.timer.queryRow(ctx, t.tx, "TX:FindOne", query, nargs...)
 	if err := scanStruct(row, model); err != nil {
 		t.logDatabaseError("FindOne", query, nargs, err)
 		return wrapDatabaseError(err, "TX:FindOne", model.TableName(), query)
@@ -847,7 +1090,7 @@ func (t *transactionWrapper) FindMany(ctx context.Context, dest interface{}, que
 	nargs := normalizeArgs(args)
 	t.logger.Debug("TX FindMany", logger.String("query", query))
 
-	rows, err := t.tx.QueryContext(ctx, query, nargs...)
+	rows, err := t.timer.query(ctx, t.tx, "TX:FindMany", query, nargs...)
 	if err != nil {
 		t.logDatabaseError("FindMany", query, nargs, err)
 		return wrapDatabaseError(err, "TX:FindMany", "", query)
@@ -866,25 +1109,25 @@ func (t *transactionWrapper) Query(ctx context.Context, query string, args ...in
 	nargs := normalizeArgs(args)
 	t.logger.Debug("TX Query", logger.String("query", query))
 
-	rows, err := t.tx.QueryContext(ctx, query, nargs...)
+	rows, err := t.timer.query(ctx, t.tx, "TX:Query", query, nargs...)
 	if err != nil {
 		t.logDatabaseError("Query", query, nargs, err)
 		return nil, wrapDatabaseError(err, "TX:Query", "", query)
 	}
-	return &rowsWrapper{rows: rows}, nil
+	return &rowsWrapper{rows: rows, log: t.logger}, nil
 }
 
 func (t *transactionWrapper) QueryRow(ctx context.Context, query string, args ...interface{}) database.Row {
 	nargs := normalizeArgs(args)
 	t.logger.Debug("TX QueryRow", logger.String("query", query))
-	return &rowWrapper{row: t.tx.QueryRowContext(ctx, query, nargs...)}
+	return &rowWrapper{row: t.timer.queryRow(ctx, t.tx, "TX:QueryRow", query, nargs...), log: t.logger}
 }
 
 func (t *transactionWrapper) Exec(ctx context.Context, query string, args ...interface{}) (database.Result, error) {
 	nargs := normalizeArgs(args)
 	t.logger.Debug("TX Exec", logger.String("query", query))
 
-	result, err := t.tx.ExecContext(ctx, query, nargs...)
+	result, err := t.timer.exec(ctx, t.tx, "TX:Exec", query, nargs...)
 	if err != nil {
 		t.logDatabaseError("Exec", query, nargs, err)
 		return nil, wrapDatabaseError(err, "TX:Exec", "", query)
@@ -892,10 +1135,46 @@ func (t *transactionWrapper) Exec(ctx context.Context, query string, args ...int
 	return &resultWrapper{result: result}, nil
 }
 
+var savepointNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+func (t *transactionWrapper) Savepoint(ctx context.Context, name string) error {
+	return t.execSavepoint(ctx, "Savepoint", "SAVEPOINT", name)
+}
+
+func (t *transactionWrapper) RollbackTo(ctx context.Context, name string) error {
+	return t.execSavepoint(ctx, "RollbackTo", "ROLLBACK TO SAVEPOINT", name)
+}
+
+func (t *transactionWrapper) ReleaseSavepoint(ctx context.Context, name string) error {
+	return t.execSavepoint(ctx, "ReleaseSavepoint", "RELEASE SAVEPOINT", name)
+}
+
+func (t *transactionWrapper) execSavepoint(ctx context.Context, operation, statement, name string) error {
+	if !savepointNamePattern.MatchString(name) {
+		return database.NewDBError(database.CodeDBInvalidInput, "invalid savepoint name").
+			WithOperation("TX:"+operation).
+			WithDetail("savepoint", name)
+	}
+
+	query := statement + " " + name
+	t.logger.Debug("TX "+operation, logger.String("savepoint", name))
+
+	if _, err := t.timer.exec(ctx, t.tx, "TX:"+operation, query); err != nil {
+		t.logDatabaseError(operation, query, nil, err)
+		return wrapDatabaseError(err, "TX:"+operation, "", query)
+	}
+	return nil
+}
+
 func (t *transactionWrapper) Commit() error {
 	err := t.tx.Commit()
 	if err != nil {
 		t.logger.Error("Failed to commit transaction", logger.Error(err))
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			// Keep the SQLSTATE so a serialization failure at COMMIT can be retried
+			return wrapDatabaseError(err, "Commit", "", "")
+		}
 		return database.WrapDBError(err, database.CodeDBInternal, "failed to commit transaction")
 	}
 	t.logger.Debug("Transaction committed")
@@ -915,6 +1194,8 @@ func (t *transactionWrapper) Rollback() error {
 type rowsWrapper struct {
 	rows *sql.Rows
 	log  logger.Logger
+	// cancel releases the query timeout once the rows are closed
+	cancel context.CancelFunc
 }
 
 func (r *rowsWrapper) Next() bool {
@@ -946,7 +1227,11 @@ func (r *rowsWrapper) ScanOne(model database.Model) *database.DBError {
 }
 
 func (r *rowsWrapper) Close() error {
-	return r.rows.Close()
+	err := r.rows.Close()
+	if r.cancel != nil {
+		r.cancel()
+	}
+	return err
 }
 
 func (r *rowsWrapper) Err() error {
@@ -955,12 +1240,27 @@ func (r *rowsWrapper) Err() error {
 
 type rowWrapper struct {
 	row *sql.Row
-	log logger.Logger
+	// read, when set, runs the query on Scan instead of row so a replica
+	// read can be retried on the primary
+	read func(scan func(row *sql.Row) error) error
+	log  logger.Logger
+	// cancel releases the query timeout once the row is scanned
+	cancel context.CancelFunc
+}
+
+func (r *rowWrapper) scan(fn func(row *sql.Row) error) error {
+	if r.read != nil {
+		return r.read(fn)
+	}
+	return fn(r.row)
 }
 
 func (r *rowWrapper) Scan(dest ...interface{}) error {
 	r.log.Debug("Scanning single row", logger.Int("num_fields", len(dest)))
-	return r.row.Scan(dest...)
+	if r.cancel != nil {
+		defer r.cancel()
+	}
+	return r.scan(func(row *sql.Row) error { return row.Scan(dest...) })
 }
 
 func (r *rowWrapper) ScanOne(model database.Model) error {
@@ -969,7 +1269,10 @@ func (r *rowWrapper) ScanOne(model database.Model) error {
 		logger.String("operation", "ScanOne"),
 		logger.Any("model", model),
 	)
-	return scanStruct(r.row, model)
+	if r.cancel != nil {
+		defer r.cancel()
+	}
+	return r.scan(func(row *sql.Row) error { return scanStruct(row, model) })
 }
 
 type resultWrapper struct {
@@ -993,6 +1296,14 @@ func normalizeArgs(args []interface{}) []interface{} {
 		switch v := a.(type) {
 		case []string:
 			out[i] = pq.Array(v)
+		case []int64:
+			out[i] = pq.Array(v)
+		case json.RawMessage:
+			if v == nil {
+				out[i] = nil
+			} else {
+				out[i] = string(v)
+			}
 		default:
 			out[i] = v
 		}
@@ -1000,6 +1311,543 @@ func normalizeArgs(args []interface{}) []interface{} {
 	return out
 }
 
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type sqlConn interface {
+	queryer
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+const maxSlowQueryLength = 500
+
+// queryTimer logs statements that run longer than threshold. A zero
+// threshold calls straight through without reading the clock.
+type queryTimer struct {
+	logger    logger.Logger
+	threshold time.Duration
+}
+
+func (qt queryTimer) query(ctx context.Context, conn sqlConn, operation, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, endSpan := startQuerySpan(ctx, operation, query)
+	start := time.Now()
+	rows, err := conn.QueryContext(ctx, query, args...)
+	qt.observe(operation, query, time.Since(start))
+	endSpan(err)
+	return rows, err
+}
+
+func (qt queryTimer) exec(ctx context.Context, conn sqlConn, operation, query string, args ...interface{}) (sql.Result, error) {
+	ctx, endSpan := startQuerySpan(ctx, operation, query)
+	start := time.Now()
+	result, err := conn.ExecContext(ctx, query, args...)
+	qt.observe(operation, query, time.Since(start))
+	endSpan(err)
+	return result, err
+}
+
+func (qt queryTimer) queryRow(ctx context.Context, conn sqlConn, operation, query string, args ...interface{}) *sql.Row {
+	ctx, endSpan := startQuerySpan(ctx, operation, query)
+	start := time.Now()
+	row := conn.QueryRowContext(ctx, query, args...)
+	qt.observe(operation, query, time.Since(start))
+	endSpan(row.Err())
+	return row
+}
+
+func (qt queryTimer) queryer(conn sqlConn, operation string) queryer {
+	return timedQueryer{timer: qt, conn: conn, operation: operation}
+}
+
+func (qt queryTimer) observe(operation, query string, elapsed time.Duration) {
+	if qt.threshold <= 0 || elapsed < qt.threshold {
+		return
+	}
+	if len(query) > maxSlowQueryLength {
+		query = query[:maxSlowQueryLength] + "..."
+	}
+	qt.logger.Warn("Slow database query",
+		logger.String("operation", operation),
+		logger.String("query", query),
+		logger.Duration("elapsed", elapsed),
+		logger.Duration("threshold", qt.threshold),
+	)
+}
+
+type timedQueryer struct {
+	timer     queryTimer
+	conn      sqlConn
+	operation string
+}
+
+func (q timedQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return q.timer.query(ctx, q.conn, q.operation, query, args...)
+}
+
+type insertBatch struct {
+	table   string
+	pkField string
+	query   string
+	args    []interface{}
+	models  []database.Model
+}
+
+// buildInsertBatches turns models into multi-row INSERT statements. Columns
+// missing from a row (empty primary key, zero timestamps) are sent as DEFAULT
+// so every row shares the same column list.
+func buildInsertBatches(models []database.Model, batchSize int) ([]insertBatch, *database.DBError) {
+	if len(models) == 0 {
+		return nil, nil
+	}
+
+	table := models[0].TableName()
+	pkField := getPrimaryKeyField(models[0])
+
+	rows := make([]map[string]interface{}, len(models))
+	present := make(map[string]bool)
+	for i, model := range models {
+		if model.TableName() != table {
+			return nil, database.NewDBError(database.CodeDBInvalidInput, "models must belong to the same table").
+				WithOperation("CreateMany").
+				WithTable(table).
+				WithDetail("index", i).
+				WithDetail("other_table", model.TableName())
+		}
+
+		fields, values := getFieldsAndValues(model)
+		row := make(map[string]interface{}, len(fields))
+		for j, field := range fields {
+			if field == pkField {
+				if str, ok := values[j].(string); ok && str == "" {
+					continue
+				}
+			}
+			row[field] = values[j]
+			present[field] = true
+		}
+		rows[i] = row
+	}
+
+	columns := make([]string, 0, len(present))
+	for _, field := range getFields(models[0]) {
+		if present[field] {
+			columns = append(columns, field)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, database.NewDBError(database.CodeDBInternal, "no fields to insert").
+			WithDetail("table", table)
+	}
+
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	if maxRows := maxQueryParams / len(columns); batchSize > maxRows {
+		batchSize = maxRows
+	}
+
+	batches := make([]insertBatch, 0, (len(models)+batchSize-1)/batchSize)
+	for start := 0; start < len(models); start += batchSize {
+		end := start + batchSize
+		if end > len(models) {
+			end = len(models)
+		}
+
+		tuples := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for _, row := range rows[start:end] {
+			placeholders := make([]string, len(columns))
+			for j, column := range columns {
+				value, ok := row[column]
+				if !ok {
+					placeholders[j] = "DEFAULT"
+					continue
+				}
+				args = append(args, value)
+				placeholders[j] = fmt.Sprintf("$%d", len(args))
+			}
+			tuples = append(tuples, "("+strings.Join(placeholders, ", ")+")")
+		}
+
+		batches = append(batches, insertBatch{
+			table:   table,
+			pkField: pkField,
+			query: fmt.Sprintf(
+				"INSERT INTO %s (%s) VALUES %s RETURNING %s",
+				table,
+				strings.Join(columns, ", "),
+				strings.Join(tuples, ", "),
+				pkField,
+			),
+			args:   normalizeArgs(args),
+			models: models[start:end],
+		})
+	}
+
+	return batches, nil
+}
+
+func insertBatchRows(ctx context.Context, q queryer, batch insertBatch) ([]string, error) {
+	rows, err := q.QueryContext(ctx, batch.query, batch.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0, len(batch.models))
+	for rows.Next() {
+		if len(ids) >= len(batch.models) {
+			return nil, database.NewDBError(database.CodeDBInternal, "insert returned more rows than models").
+				WithDetail("table", batch.table)
+		}
+
+		var returnedID interface{}
+		if err := rows.Scan(&returnedID); err != nil {
+			return nil, err
+		}
+		if err := setPrimaryKeyValue(batch.models[len(ids)], batch.pkField, returnedID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, formatPrimaryKey(returnedID))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func buildUpsertQuery(model database.Model, conflictColumns []string, updateColumns []string) (string, []interface{}, *database.DBError) {
+	fields, values := getFieldsAndValues(model)
+	if len(fields) == 0 {
+		return "", nil, database.NewDBError(database.CodeDBInternal, "no db tags found in model").
+			WithDetail("table", model.TableName())
+	}
+
+	pkField := getPrimaryKeyField(model)
+	filteredFields := []string{}
+	filteredValues := []interface{}{}
+
+	for i, field := range fields {
+		if field == pkField {
+			if str, ok := values[i].(string); ok && str == "" {
+				continue
+			}
+		}
+		filteredFields = append(filteredFields, field)
+		filteredValues = append(filteredValues, values[i])
+	}
+
+	if len(filteredFields) == 0 {
+		return "", nil, database.NewDBError(database.CodeDBInternal, "no fields to upsert").
+			WithDetail("table", model.TableName())
+	}
+
+	known := make(map[string]bool)
+	for _, field := range getFields(model) {
+		known[field] = true
+	}
+
+	if len(conflictColumns) == 0 {
+		conflictColumns = []string{pkField}
+	}
+	for _, column := range conflictColumns {
+		if !known[column] {
+			return "", nil, database.NewDBError(database.CodeDBInvalidInput, "unknown conflict column").
+				WithTable(model.TableName()).
+				WithColumn(column)
+		}
+	}
+
+	if updateColumns == nil {
+		for _, field := range filteredFields {
+			if field != pkField && field != "created_at" {
+				updateColumns = append(updateColumns, field)
+			}
+		}
+	}
+
+	updateParts := make([]string, 0, len(updateColumns))
+	for _, column := range updateColumns {
+		if !known[column] {
+			return "", nil, database.NewDBError(database.CodeDBInvalidInput, "unknown update column").
+				WithTable(model.TableName()).
+				WithColumn(column)
+		}
+		updateParts = append(updateParts, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+	}
+
+	action := "DO NOTHING"
+	if len(updateParts) > 0 {
+		action = "DO UPDATE SET " + strings.Join(updateParts, ", ")
+	}
+
+	placeholders := make([]string, len(filteredFields))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) %s RETURNING %s",
+		model.TableName(),
+		strings.Join(filteredFields, ", "),
+		strings.Join(placeholders, ", "),
+		strings.Join(conflictColumns, ", "),
+		action,
+		pkField,
+	)
+
+	return query, normalizeArgs(filteredValues), nil
+}
+
+func upsertRow(ctx context.Context, q queryer, model database.Model, query string, args []interface{}) (*string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		// ON CONFLICT DO NOTHING returns no row when the insert was skipped.
+		return nil, rows.Err()
+	}
+
+	var returnedID interface{}
+	if err := rows.Scan(&returnedID); err != nil {
+		return nil, err
+	}
+
+	pkField := getPrimaryKeyField(model)
+	if err := setPrimaryKeyValue(model, pkField, returnedID); err != nil {
+		return nil, err
+	}
+
+	formattedID := formatPrimaryKey(returnedID)
+	return &formattedID, nil
+}
+
+// buildUpdateQuery returns the UPDATE statement for model. When the model has
+// a db-tagged integer version column the statement only matches the version
+// the caller read and bumps it, and the read version is returned so a zero
+// row count can be reported as a conflict instead of a missing record.
+func buildUpdateQuery(model database.Model, opts database.UpdateOptions) (string, []interface{}, *int64, *database.DBError) {
+	if !opts.SkipTimestamp {
+		stampUpdatedAt(model)
+	}
+
+	fields, values := getFieldsAndValues(model)
+	if len(fields) == 0 {
+		return "", nil, nil, database.NewDBError(database.CodeDBInternal, "no db tags found in model").
+			WithDetail("table", model.TableName())
+	}
+
+	pkField := getPrimaryKeyField(model)
+	version, versioned := getVersionValue(model)
+	setParts := make([]string, 0, len(fields))
+	updateValues := make([]interface{}, 0, len(values))
+
+	for i, field := range fields {
+		if field == pkField || field == "created_at" {
+			continue
+		}
+		if versioned && field == versionField {
+			continue
+		}
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", field, len(setParts)+1))
+		updateValues = append(updateValues, values[i])
+	}
+
+	if len(setParts) == 0 {
+		return "", nil, nil, database.NewDBError(database.CodeDBInternal, "no fields to update").
+			WithDetail("table", model.TableName())
+	}
+
+	updateValues = append(updateValues, model.PrimaryKey())
+	where := fmt.Sprintf("%s = $%d", pkField, len(updateValues))
+
+	if !versioned {
+		query := fmt.Sprintf(
+			"UPDATE %s SET %s WHERE %s",
+			model.TableName(),
+			strings.Join(setParts, ", "),
+			where,
+		)
+		return query, normalizeArgs(updateValues), nil, nil
+	}
+
+	setParts = append(setParts, fmt.Sprintf("%s = %s + 1", versionField, versionField))
+	updateValues = append(updateValues, version)
+	where = fmt.Sprintf("%s AND %s = $%d", where, versionField, len(updateValues))
+
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s WHERE %s",
+		model.TableName(),
+		strings.Join(setParts, ", "),
+		where,
+	)
+	return query, normalizeArgs(updateValues), &version, nil
+}
+
+// buildFindByIDQuery selects model by primary key, hiding soft-deleted rows
+// unless withDeleted is set or the model has no deleted_at column.
+func buildFindByIDQuery(model database.Model, withDeleted bool) (string, *database.DBError) {
+	fields := getFields(model)
+	if len(fields) == 0 {
+		return "", database.NewDBError(database.CodeDBInternal, "no db tags found in model").
+			WithDetail("table", model.TableName())
+	}
+
+	pkField := getPrimaryKeyField(model)
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = $1",
+		strings.Join(fields, ", "),
+		model.TableName(),
+		pkField,
+	)
+
+	if !withDeleted {
+		for _, field := range fields {
+			if field == deletedAtField {
+				query += " AND deleted_at IS NULL"
+				break
+			}
+		}
+	}
+
+	return query, nil
+}
+
+// stampUpdatedAt sets a db-tagged updated_at time field to the current time.
+// It runs before getFieldsAndValues so the fresh value is never dropped by the
+// zero-time guard.
+func stampUpdatedAt(model interface{}) {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	v = v.Elem()
+
+	now := time.Now().UTC()
+	for _, field := range database.ColumnFields(v.Type()) {
+		if field.Tag.Get("db") != "updated_at" {
+			continue
+		}
+		fieldValue := v.FieldByIndex(field.Index)
+		if !fieldValue.CanSet() {
+			return
+		}
+		switch fieldValue.Interface().(type) {
+		case time.Time:
+			fieldValue.Set(reflect.ValueOf(now))
+		case *time.Time:
+			fieldValue.Set(reflect.ValueOf(&now))
+		}
+		return
+	}
+}
+
+func buildPageQueries(dest interface{}, opts database.PageOptions, baseQuery string, argCount int) (string, string, int, int, *database.DBError) {
+	destType := reflect.TypeOf(dest)
+	if destType == nil || destType.Kind() != reflect.Ptr || destType.Elem().Kind() != reflect.Slice {
+		return "", "", 0, 0, database.NewDBError(database.CodeDBInternal, "dest must be a pointer to slice")
+	}
+	elemType := destType.Elem().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return "", "", 0, 0, database.NewDBError(database.CodeDBInternal, "slice element must be a struct or pointer to struct")
+	}
+
+	limit := database.ClampLimit(opts.Limit)
+	offset := opts.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	baseQuery = strings.TrimRight(strings.TrimSpace(baseQuery), ";")
+	pageQuery := baseQuery
+
+	if opts.OrderBy != "" {
+		allowed := false
+		for _, field := range getFields(reflect.New(elemType).Interface()) {
+			if field == opts.OrderBy {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "", "", 0, 0, database.NewDBError(database.CodeDBInvalidInput, "invalid order by column").
+				WithColumn(opts.OrderBy)
+		}
+
+		direction := strings.ToUpper(opts.Direction)
+		switch direction {
+		case "":
+			direction = database.SortAsc
+		case database.SortAsc, database.SortDesc:
+		default:
+			return "", "", 0, 0, database.NewDBError(database.CodeDBInvalidInput, "invalid sort direction").
+				WithDetail("direction", opts.Direction)
+		}
+
+		pageQuery = fmt.Sprintf("%s ORDER BY %s %s", pageQuery, opts.OrderBy, direction)
+	}
+
+	pageQuery = fmt.Sprintf("%s LIMIT $%d OFFSET $%d", pageQuery, argCount+1, argCount+2)
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM (%s) t", baseQuery)
+
+	return pageQuery, countQuery, limit, offset, nil
+}
+
+const (
+	versionField   = "version"
+	deletedAtField = "deleted_at"
+)
+
+func getVersionValue(model interface{}) (int64, bool) {
+	v := reflect.ValueOf(model)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return 0, false
+	}
+
+	for _, field := range database.ColumnFields(v.Type()) {
+		if field.Tag.Get("db") != versionField {
+			continue
+		}
+		fieldValue := v.FieldByIndex(field.Index)
+		switch fieldValue.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return fieldValue.Int(), true
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+func setVersionValue(model interface{}, version int64) {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	v = v.Elem()
+
+	for _, field := range database.ColumnFields(v.Type()) {
+		if field.Tag.Get("db") != versionField {
+			continue
+		}
+		if fieldValue := v.FieldByIndex(field.Index); fieldValue.CanSet() {
+			fieldValue.SetInt(version)
+		}
+		return
+	}
+}
+
 func getFields(dest interface{}) []string {
 	v := reflect.ValueOf(dest)
 	if v.Kind() == reflect.Ptr {
@@ -1008,13 +1856,10 @@ func getFields(dest interface{}) []string {
 	if v.Kind() != reflect.Struct {
 		return nil
 	}
-	t := v.Type()
-	fields := make([]string, 0, t.NumField())
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if tag := field.Tag.Get("db"); tag != "" && tag != "-" {
-			fields = append(fields, tag)
-		}
+	columns := database.ColumnFields(v.Type())
+	fields := make([]string, 0, len(columns))
+	for _, field := range columns {
+		fields = append(fields, field.Tag.Get("db"))
 	}
 	return fields
 }
@@ -1027,18 +1872,13 @@ func getFieldsAndValues(src interface{}) ([]string, []interface{}) {
 	if v.Kind() != reflect.Struct {
 		return nil, nil
 	}
-	t := v.Type()
-	fields := make([]string, 0, t.NumField())
-	values := make([]interface{}, 0, t.NumField())
+	columns := database.ColumnFields(v.Type())
+	fields := make([]string, 0, len(columns))
+	values := make([]interface{}, 0, len(columns))
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
+	for _, field := range columns {
 		tag := field.Tag.Get("db")
-		if tag == "" || tag == "-" {
-			continue
-		}
-
-		fieldValue := v.Field(i)
+		fieldValue := v.FieldByIndex(field.Index)
 
 		if fieldValue.Kind() == reflect.Ptr {
 			if fieldValue.IsNil() {
@@ -1067,9 +1907,9 @@ func getFieldsAndValues(src interface{}) ([]string, []interface{}) {
 			continue
 		}
 
-		if fieldValue.Kind() == reflect.Slice && fieldValue.Type().String() == "pq.StringArray" {
+		if isPQArrayType(fieldValue.Type()) {
 			fields = append(fields, tag)
-			values = append(values, fieldValue.Interface())
+			values = append(values, pq.Array(fieldValue.Interface()))
 			continue
 		}
 
@@ -1079,15 +1919,25 @@ func getFieldsAndValues(src interface{}) ([]string, []interface{}) {
 	return fields, values
 }
 
+var (
+	pqStringArrayType = reflect.TypeOf(pq.StringArray{})
+	pqInt64ArrayType  = reflect.TypeOf(pq.Int64Array{})
+)
+
+// isPQArrayType reports whether t is one of the pq array types that must go
+// through pq.Array. Pointer forms are scanned through their address so that
+// database/sql can allocate them on non-NULL values.
+func isPQArrayType(t reflect.Type) bool {
+	return t == pqStringArrayType || t == pqInt64ArrayType
+}
+
 func getPrimaryKeyField(model interface{}) string {
 	v := reflect.ValueOf(model)
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
-	t := v.Type()
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
+	for _, field := range database.ColumnFields(v.Type()) {
 		if pk := field.Tag.Get("pk"); pk == "true" {
 			return field.Tag.Get("db")
 		}
@@ -1103,12 +1953,10 @@ func setPrimaryKeyValue(model interface{}, pkField string, value interface{}) er
 			WithDetail("pk_field", pkField)
 	}
 	v = v.Elem()
-	t := v.Type()
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
+	for _, field := range database.ColumnFields(v.Type()) {
 		if field.Tag.Get("db") == pkField {
-			fieldValue := v.Field(i)
+			fieldValue := v.FieldByIndex(field.Index)
 			if !fieldValue.CanSet() {
 				return database.NewDBError(database.CodeDBInternal, "cannot set primary key field").
 					WithDetail("pk_field", pkField).
@@ -1148,30 +1996,26 @@ func scanStruct(row *sql.Row, dest interface{}) error {
 		return database.NewDBError(database.CodeDBInternal, "dest must be a pointer to struct")
 	}
 	v = v.Elem()
-	t := v.Type()
-	dests := make([]interface{}, 0, t.NumField())
-
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		tag := field.Tag.Get("db")
-		if tag != "" && tag != "-" {
-			fieldValue := v.Field(i)
-
-			if field.Type.String() == "pq.StringArray" {
-				dests = append(dests, pq.Array(fieldValue.Addr().Interface()))
-			} else if field.Type.Kind() == reflect.Pointer {
-				dests = append(dests, fieldValue.Addr().Interface())
-			} else if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Uint8 {
+	columns := database.ColumnFields(v.Type())
+	dests := make([]interface{}, 0, len(columns))
+
+	for _, field := range columns {
+		fieldValue := v.FieldByIndex(field.Index)
+
+		if isPQArrayType(field.Type) {
+			dests = append(dests, pq.Array(fieldValue.Addr().Interface()))
+		} else if field.Type.Kind() == reflect.Pointer {
+			dests = append(dests, fieldValue.Addr().Interface())
+		} else if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Uint8 {
+			dests = append(dests, fieldValue.Addr().Interface())
+		} else if field.Type.Kind() == reflect.Struct {
+			if field.Type.String() == "time.Time" {
 				dests = append(dests, fieldValue.Addr().Interface())
-			} else if field.Type.Kind() == reflect.Struct {
-				if field.Type.String() == "time.Time" {
-					dests = append(dests, fieldValue.Addr().Interface())
-				} else {
-					return fmt.Errorf("unsupported struct type: %s", field.Type.String())
-				}
 			} else {
-				dests = append(dests, fieldValue.Addr().Interface())
+				return fmt.Errorf("unsupported struct type: %s", field.Type.String())
 			}
+		} else {
+			dests = append(dests, fieldValue.Addr().Interface())
 		}
 	}
 
@@ -1179,43 +2023,48 @@ func scanStruct(row *sql.Row, dest interface{}) error {
 }
 
 func scanStructRows(rows *sql.Rows, dest interface{}) error {
+	dests, err := structRowDests(dest)
+	if err != nil {
+		return err
+	}
+	return rows.Scan(dests...)
+}
+
+// structRowDests returns exactly one scan destination per db-tagged field of
+// dest, in declaration order.
+func structRowDests(dest interface{}) ([]interface{}, error) {
 	destValue := reflect.ValueOf(dest)
 	if destValue.Kind() != reflect.Ptr || destValue.Elem().Kind() != reflect.Struct {
-		return database.NewDBError(database.CodeDBInternal, "dest must be a pointer to struct")
+		return nil, database.NewDBError(database.CodeDBInternal, "dest must be a pointer to struct")
 	}
 	destValue = destValue.Elem()
-	destType := destValue.Type()
-	dests := make([]interface{}, 0, destType.NumField())
-
-	for i := 0; i < destType.NumField(); i++ {
-		field := destType.Field(i)
-		tag := field.Tag.Get("db")
-		if tag != "" && tag != "-" {
-			fieldValue := destValue.Field(i)
-
-			if field.Type.String() == "pq.StringArray" {
-				dests = append(dests, pq.Array(fieldValue.Addr().Interface()))
-			} else if field.Type.String() == "json.RawMessage" {
-				dests = append(dests, fieldValue.Addr().Interface())
-				dests = append(dests, fieldValue.Addr().Interface())
-			} else if field.Type.Kind() == reflect.Ptr {
-				dests = append(dests, fieldValue.Addr().Interface())
-			} else if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Uint8 {
+	columns := database.ColumnFields(destValue.Type())
+	dests := make([]interface{}, 0, len(columns))
+
+	for _, field := range columns {
+		fieldValue := destValue.FieldByIndex(field.Index)
+
+		if isPQArrayType(field.Type) {
+			dests = append(dests, pq.Array(fieldValue.Addr().Interface()))
+		} else if field.Type.String() == "json.RawMessage" {
+			dests = append(dests, fieldValue.Addr().Interface())
+		} else if field.Type.Kind() == reflect.Ptr {
+			dests = append(dests, fieldValue.Addr().Interface())
+		} else if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Uint8 {
+			dests = append(dests, fieldValue.Addr().Interface())
+		} else if field.Type.Kind() == reflect.Struct {
+			if field.Type.String() == "time.Time" {
 				dests = append(dests, fieldValue.Addr().Interface())
-			} else if field.Type.Kind() == reflect.Struct {
-				if field.Type.String() == "time.Time" {
-					dests = append(dests, fieldValue.Addr().Interface())
-				} else {
-					return database.NewDBError(database.CodeDBInternal, "unsupported struct type").
-						WithDetail("type", field.Type.String())
-				}
 			} else {
-				dests = append(dests, fieldValue.Addr().Interface())
+				return nil, database.NewDBError(database.CodeDBInternal, "unsupported struct type").
+					WithDetail("type", field.Type.String())
 			}
+		} else {
+			dests = append(dests, fieldValue.Addr().Interface())
 		}
 	}
 
-	return rows.Scan(dests...)
+	return dests, nil
 }
 
 func scanStructs(rows *sql.Rows, dest interface{}, log logger.Logger) error {
@@ -1237,26 +2086,22 @@ func scanStructs(rows *sql.Rows, dest interface{}, log logger.Logger) error {
 		return database.NewDBError(database.CodeDBInternal, "slice element must be a struct or pointer to struct")
 	}
 
+	columns := database.ColumnFields(elemType)
 	for rows.Next() {
 		log.Debug("Advancing to next row",
 			logger.String("element_type", elemType.String()),
 		)
 		elemValue := reflect.New(elemType)
 		elem := elemValue.Elem()
-		dests := make([]interface{}, 0, elemType.NumField())
-
-		for i := 0; i < elemType.NumField(); i++ {
-			field := elemType.Field(i)
-			if tag := field.Tag.Get("db"); tag != "" && tag != "-" {
-				fieldValue := elem.Field(i)
-
-				if field.Type.String() == "pq.StringArray" {
-					dests = append(dests, pq.Array(fieldValue.Addr().Interface()))
-				} else if field.Type.String() == "*pq.StringArray" {
-					dests = append(dests, fieldValue.Interface())
-				} else {
-					dests = append(dests, fieldValue.Addr().Interface())
-				}
+		dests := make([]interface{}, 0, len(columns))
+
+		for _, field := range columns {
+			fieldValue := elem.FieldByIndex(field.Index)
+
+			if isPQArrayType(field.Type) {
+				dests = append(dests, pq.Array(fieldValue.Addr().Interface()))
+			} else {
+				dests = append(dests, fieldValue.Addr().Interface())
 			}
 		}
 
@@ -0,0 +1,695 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"shared/pkg/database"
+	"shared/pkg/database/postgres/models"
+	"shared/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+// newIntegrationClient connects to the database named by POSTGRES_TEST_DSN and
+// skips the test when it is unset. The pool is pinned to one connection so
+// temporary tables stay visible for the whole test.
+func newIntegrationClient(t testing.TB) *client {
+	t.Helper()
+
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("failed to ping database: %v", err)
+	}
+
+	return &client{db: db, logger: logger.NewNoop(), batchSize: DefaultBatchSize}
+}
+
+// requireTable fails the test when the database behind POSTGRES_TEST_DSN has
+// not been migrated with table
+func requireTable(t testing.TB, c *client, table string) {
+	t.Helper()
+	var exists bool
+	if err := c.db.QueryRow("SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists); err != nil {
+		t.Fatalf("failed to look up %s: %v", table, err)
+	}
+	if !exists {
+		t.Fatalf("%s does not exist; apply database/schemas and migrations/postgres first", table)
+	}
+}
+
+func mustExec(t testing.TB, c *client, query string) {
+	t.Helper()
+	if _, err := c.db.Exec(query); err != nil {
+		t.Fatalf("exec %q failed: %v", query, err)
+	}
+}
+
+type testWidget struct {
+	ID        string    `db:"id" pk:"true"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (w *testWidget) TableName() string       { return "test.widgets" }
+func (w *testWidget) PrimaryKey() interface{} { return w.ID }
+
+type testGadget struct {
+	ID string `db:"id" pk:"true"`
+}
+
+func (g *testGadget) TableName() string       { return "test.gadgets" }
+func (g *testGadget) PrimaryKey() interface{} { return g.ID }
+
+func TestBuildInsertBatches_FillsMissingColumnsWithDefault(t *testing.T) {
+	models := []database.Model{
+		&testWidget{Name: "a"},
+		&testWidget{ID: "fixed-id", Name: "b", CreatedAt: time.Now()},
+	}
+
+	batches, err := buildInsertBatches(models, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(batches) != 1 {
+		t.Fatalf("expected 1 batch, got %d", len(batches))
+	}
+
+	want := "INSERT INTO test.widgets (id, name, created_at) VALUES (DEFAULT, $1, DEFAULT), ($2, $3, $4) RETURNING id"
+	if batches[0].query != want {
+		t.Fatalf("unexpected query:\n got: %s\nwant: %s", batches[0].query, want)
+	}
+	if len(batches[0].args) != 4 {
+		t.Fatalf("expected 4 args, got %d", len(batches[0].args))
+	}
+}
+
+func TestBuildInsertBatches_Chunks(t *testing.T) {
+	models := make([]database.Model, 5)
+	for i := range models {
+		models[i] = &testWidget{Name: "w"}
+	}
+
+	batches, err := buildInsertBatches(models, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(batches) != 3 {
+		t.Fatalf("expected 3 batches, got %d", len(batches))
+	}
+	if got := len(batches[2].models); got != 1 {
+		t.Fatalf("expected last batch to hold 1 model, got %d", got)
+	}
+	if strings.Contains(batches[1].query, "$3") {
+		t.Fatalf("placeholders must restart per batch: %s", batches[1].query)
+	}
+}
+
+func TestBuildInsertBatches_RespectsParameterLimit(t *testing.T) {
+	models := make([]database.Model, maxQueryParams)
+	for i := range models {
+		models[i] = &testWidget{ID: "id", Name: "w"}
+	}
+
+	batches, err := buildInsertBatches(models, maxQueryParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, batch := range batches {
+		if len(batch.args) > maxQueryParams {
+			t.Fatalf("batch exceeds parameter limit: %d args", len(batch.args))
+		}
+	}
+}
+
+func TestBuildInsertBatches_RejectsMixedTables(t *testing.T) {
+	models := []database.Model{&testWidget{Name: "a"}, &testGadget{ID: "g"}}
+
+	_, err := buildInsertBatches(models, 0)
+	if err == nil {
+		t.Fatal("expected error for models from different tables")
+	}
+	if err.Code() != database.CodeDBInvalidInput {
+		t.Fatalf("unexpected error code: %s", err.Code())
+	}
+}
+
+func TestBuildUpsertQuery_DefaultsToAllColumns(t *testing.T) {
+	query, args, err := buildUpsertQuery(&testWidget{ID: "w1", Name: "a"}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "INSERT INTO test.widgets (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name RETURNING id"
+	if query != want {
+		t.Fatalf("unexpected query:\n got: %s\nwant: %s", query, want)
+	}
+	if len(args) != 2 {
+		t.Fatalf("expected 2 args, got %d", len(args))
+	}
+}
+
+func TestBuildUpsertQuery_DoNothing(t *testing.T) {
+	query, _, err := buildUpsertQuery(&testWidget{Name: "a"}, []string{"name"}, []string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "INSERT INTO test.widgets (name) VALUES ($1) ON CONFLICT (name) DO NOTHING RETURNING id"
+	if query != want {
+		t.Fatalf("unexpected query:\n got: %s\nwant: %s", query, want)
+	}
+}
+
+func TestBuildUpsertQuery_RejectsUnknownColumns(t *testing.T) {
+	if _, _, err := buildUpsertQuery(&testWidget{Name: "a"}, []string{"name; DROP TABLE x"}, nil); err == nil {
+		t.Fatal("expected error for unknown conflict column")
+	}
+	if _, _, err := buildUpsertQuery(&testWidget{Name: "a"}, []string{"name"}, []string{"missing"}); err == nil {
+		t.Fatal("expected error for unknown update column")
+	}
+}
+
+func TestBuildUpdateQuery_StampsUpdatedAt(t *testing.T) {
+	conv := &models.Conversation{
+		ID:        "conv-1",
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+
+	before := time.Now().UTC()
+	query, args, _, err := buildUpdateQuery(conv, database.ApplyUpdateOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if conv.UpdatedAt.Before(before) {
+		t.Fatalf("expected updated_at to be stamped, got %v", conv.UpdatedAt)
+	}
+	if !strings.Contains(query, "updated_at = $") {
+		t.Fatalf("expected updated_at in SET clause: %s", query)
+	}
+	if strings.Contains(query, "created_at") {
+		t.Fatalf("created_at must never be updated: %s", query)
+	}
+
+	found := false
+	for _, arg := range args {
+		if ts, ok := arg.(time.Time); ok && ts.Equal(conv.UpdatedAt) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected stamped updated_at among query args")
+	}
+}
+
+func TestBuildUpdateQuery_WithoutTimestamp(t *testing.T) {
+	conv := &models.Conversation{ID: "conv-1"}
+
+	query, _, _, err := buildUpdateQuery(conv, database.ApplyUpdateOptions(database.WithoutTimestamp()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !conv.UpdatedAt.IsZero() {
+		t.Fatalf("expected updated_at to stay zero, got %v", conv.UpdatedAt)
+	}
+	if strings.Contains(query, "updated_at") {
+		t.Fatalf("unexpected updated_at in SET clause: %s", query)
+	}
+}
+
+func TestBuildPageQueries_MessagesByCreatedAt(t *testing.T) {
+	var messages []models.Message
+	base := "SELECT * FROM messages.messages WHERE conversation_id = $1"
+
+	pageQuery, countQuery, limit, offset, err := buildPageQueries(&messages, database.PageOptions{
+		Limit:     50,
+		Offset:    100,
+		OrderBy:   "created_at",
+		Direction: "desc",
+	}, base, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := base + " ORDER BY created_at DESC LIMIT $2 OFFSET $3"; pageQuery != want {
+		t.Fatalf("unexpected page query:\n got: %s\nwant: %s", pageQuery, want)
+	}
+	if want := "SELECT COUNT(*) FROM (" + base + ") t"; countQuery != want {
+		t.Fatalf("unexpected count query:\n got: %s\nwant: %s", countQuery, want)
+	}
+	if limit != 50 || offset != 100 {
+		t.Fatalf("unexpected limit/offset: %d/%d", limit, offset)
+	}
+}
+
+func TestBuildPageQueries_RejectsUnsafeOrdering(t *testing.T) {
+	var messages []*models.Message
+	base := "SELECT * FROM messages.messages"
+
+	if _, _, _, _, err := buildPageQueries(&messages, database.PageOptions{OrderBy: "created_at; DROP TABLE x"}, base, 0); err == nil {
+		t.Fatal("expected error for column outside the allowlist")
+	}
+	if _, _, _, _, err := buildPageQueries(&messages, database.PageOptions{OrderBy: "created_at", Direction: "sideways"}, base, 0); err == nil {
+		t.Fatal("expected error for invalid direction")
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	page := database.NewPage(45, 20, 20, 20)
+	if !page.HasNext || page.NextOffset != 40 {
+		t.Fatalf("expected next page at offset 40, got %+v", page)
+	}
+
+	page = database.NewPage(45, 20, 40, 5)
+	if page.HasNext {
+		t.Fatalf("expected last page, got %+v", page)
+	}
+}
+
+type testVersionedWidget struct {
+	ID      string `db:"id" pk:"true"`
+	Name    string `db:"name"`
+	Version int    `db:"version"`
+}
+
+func (w *testVersionedWidget) TableName() string       { return "test.versioned_widgets" }
+func (w *testVersionedWidget) PrimaryKey() interface{} { return w.ID }
+
+func TestBuildUpdateQuery_OptimisticLocking(t *testing.T) {
+	widget := &testVersionedWidget{ID: "w1", Name: "a", Version: 3}
+
+	query, args, version, err := buildUpdateQuery(widget, database.ApplyUpdateOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "UPDATE test.versioned_widgets SET name = $1, version = version + 1 WHERE id = $2 AND version = $3"
+	if query != want {
+		t.Fatalf("unexpected query:\n got: %s\nwant: %s", query, want)
+	}
+	if version == nil || *version != 3 {
+		t.Fatalf("expected read version 3, got %v", version)
+	}
+	if got := args[len(args)-1]; got != int64(3) {
+		t.Fatalf("expected version as last arg, got %v", got)
+	}
+
+	setVersionValue(widget, *version+1)
+	if widget.Version != 4 {
+		t.Fatalf("expected model version to be bumped, got %d", widget.Version)
+	}
+}
+
+func TestBuildUpdateQuery_UnversionedModel(t *testing.T) {
+	_, _, version, err := buildUpdateQuery(&testWidget{ID: "w1", Name: "a"}, database.ApplyUpdateOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != nil {
+		t.Fatalf("expected no version for unversioned model, got %d", *version)
+	}
+}
+
+func TestGetFieldsAndValues_Int64Array(t *testing.T) {
+	pref := &models.UserPreference{QuietHoursDays: pq.Int64Array{0, 6}}
+
+	fields, values := getFieldsAndValues(pref)
+	for i, field := range fields {
+		if field != "quiet_hours_days" {
+			continue
+		}
+		valuer, ok := values[i].(driver.Valuer)
+		if !ok {
+			t.Fatalf("expected quiet_hours_days to be a driver.Valuer, got %T", values[i])
+		}
+		v, err := valuer.Value()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v != "{0,6}" {
+			t.Fatalf("unexpected array literal: %v", v)
+		}
+		return
+	}
+	t.Fatal("quiet_hours_days not found in fields")
+}
+
+func TestInt64ArrayRoundTrip(t *testing.T) {
+	c := newIntegrationClient(t)
+	ctx := context.Background()
+	requireTable(t, c, "notifications.user_preferences")
+
+	// user_preferences.user_id references auth.users; deleting the user
+	// cascades to the preference row
+	var userID string
+	err := c.db.QueryRowContext(ctx,
+		"INSERT INTO auth.users (email, password_hash, password_salt) VALUES ($1, 'hash', 'salt') RETURNING id",
+		"int64-array-"+time.Now().Format("20060102150405.000000000")+"@example.com",
+	).Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	t.Cleanup(func() { c.db.Exec("DELETE FROM auth.users WHERE id = $1", userID) })
+
+	pref := &models.UserPreference{UserID: userID, QuietHoursDays: pq.Int64Array{0, 6}}
+	if _, err := c.Insert(ctx, pref); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	var loaded []models.UserPreference
+	if err := c.FindMany(ctx, &loaded,
+		"SELECT id, user_id, quiet_hours_days FROM notifications.user_preferences WHERE user_id = $1", userID); err != nil {
+		t.Fatalf("find many failed: %v", err)
+	}
+	if len(loaded) != 1 || len(loaded[0].QuietHoursDays) != 2 || loaded[0].QuietHoursDays[1] != 6 {
+		t.Fatalf("unexpected rows: %+v", loaded)
+	}
+}
+
+func TestStructRowDests_OneDestinationPerColumn(t *testing.T) {
+	event := &models.Event{}
+
+	dests, err := structRowDests(event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := len(getFields(event)); len(dests) != want {
+		t.Fatalf("expected %d scan destinations, got %d", want, len(dests))
+	}
+}
+
+type testPayload struct {
+	ID         string          `db:"id" pk:"true"`
+	Properties json.RawMessage `db:"properties"`
+	Name       string          `db:"name"`
+}
+
+func (p *testPayload) TableName() string       { return "pg_temp.test_payloads" }
+func (p *testPayload) PrimaryKey() interface{} { return p.ID }
+
+func TestRowsScanOne_RawMessage(t *testing.T) {
+	c := newIntegrationClient(t)
+	ctx := context.Background()
+	mustExec(t, c, "CREATE TEMP TABLE test_payloads (id TEXT PRIMARY KEY, properties JSONB, name TEXT)")
+	mustExec(t, c, `INSERT INTO test_payloads VALUES ('p1', '{"screen":"home"}', 'viewed')`)
+
+	rows, dbErr := c.Query(ctx, "SELECT id, properties, name FROM pg_temp.test_payloads")
+	if dbErr != nil {
+		t.Fatalf("query failed: %v", dbErr)
+	}
+	defer rows.Close()
+
+	var payload testPayload
+	if err := rows.ScanOne(&payload); err != nil {
+		t.Fatalf("scan one failed: %v", err)
+	}
+	if string(payload.Properties) != `{"screen": "home"}` || payload.Name != "viewed" {
+		t.Fatalf("unexpected payload: id=%s properties=%s name=%s", payload.ID, payload.Properties, payload.Name)
+	}
+}
+
+func TestBuildFindByIDQuery_SoftDelete(t *testing.T) {
+	query, err := buildFindByIDQuery(&models.Message{}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(query, "WHERE id = $1 AND deleted_at IS NULL") {
+		t.Fatalf("expected soft-delete filter: %s", query)
+	}
+
+	query, err = buildFindByIDQuery(&models.Message{}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(query, "deleted_at IS NULL") {
+		t.Fatalf("unexpected soft-delete filter: %s", query)
+	}
+
+	query, err = buildFindByIDQuery(&testWidget{}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(query, "deleted_at") {
+		t.Fatalf("models without deleted_at must not be filtered: %s", query)
+	}
+}
+
+type testNote struct {
+	ID        string     `db:"id" pk:"true"`
+	Body      string     `db:"body"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+func (n *testNote) TableName() string       { return "pg_temp.test_notes" }
+func (n *testNote) PrimaryKey() interface{} { return n.ID }
+
+func TestFindByID_HidesSoftDeleted(t *testing.T) {
+	c := newIntegrationClient(t)
+	ctx := context.Background()
+	mustExec(t, c, "CREATE TEMP TABLE test_notes (id TEXT PRIMARY KEY, body TEXT, deleted_at TIMESTAMPTZ)")
+
+	note := &testNote{ID: "n1", Body: "hello"}
+	if _, err := c.Insert(ctx, note); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+	if err := c.Delete(ctx, note); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	err := c.FindByID(ctx, &testNote{}, "n1")
+	if err == nil || err.Code() != database.CodeDBNoRows {
+		t.Fatalf("expected no rows for soft-deleted note, got %v", err)
+	}
+
+	var deleted testNote
+	if err := c.FindByIDWithDeleted(ctx, &deleted, "n1"); err != nil {
+		t.Fatalf("find with deleted failed: %v", err)
+	}
+	if deleted.DeletedAt == nil {
+		t.Fatal("expected deleted_at to be set")
+	}
+}
+
+// testBaseModel carries the audit columns shared by testArticle, including
+// its primary key
+type testBaseModel struct {
+	ID        string     `db:"id" pk:"true"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+type testArticle struct {
+	testBaseModel
+	Title    string          `db:"title"`
+	Metadata json.RawMessage `db:"metadata"`
+	Draft    bool            `db:"-"`
+}
+
+func (a *testArticle) TableName() string       { return "pg_temp.test_articles" }
+func (a *testArticle) PrimaryKey() interface{} { return a.ID }
+
+func TestEmbeddedModel_FlattensColumns(t *testing.T) {
+	article := &testArticle{
+		testBaseModel: testBaseModel{ID: "a1", CreatedAt: time.Now().Add(-time.Hour)},
+		Title:         "hello",
+		Metadata:      json.RawMessage(`{"tags":["go"]}`),
+	}
+
+	want := "id,created_at,updated_at,deleted_at,title,metadata"
+	if got := strings.Join(getFields(article), ","); got != want {
+		t.Fatalf("fields = %s, want %s", got, want)
+	}
+	if pk := getPrimaryKeyField(article); pk != "id" {
+		t.Fatalf("primary key = %s, want id", pk)
+	}
+
+	fields, values := getFieldsAndValues(article)
+	if len(fields) != len(values) || fields[0] != "id" || values[0] != "a1" {
+		t.Fatalf("fields %v values %v", fields, values)
+	}
+	if dests, err := structRowDests(article); err != nil || len(dests) != 6 {
+		t.Fatalf("row dests = %d, %v; want one per column", len(dests), err)
+	}
+
+	query, err := buildFindByIDQuery(article, false)
+	if err != nil || !strings.HasSuffix(query, "WHERE id = $1 AND deleted_at IS NULL") {
+		t.Fatalf("find query = %s, %v", query, err)
+	}
+
+	update, args, _, err := buildUpdateQuery(article, database.ApplyUpdateOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if article.UpdatedAt.IsZero() || !strings.Contains(update, "updated_at = $") || !strings.Contains(update, "title = $") {
+		t.Fatalf("update query = %s", update)
+	}
+	if strings.Contains(update, "created_at") || !strings.HasSuffix(update, "WHERE id = $5") || args[len(args)-1] != "a1" {
+		t.Fatalf("update query = %s args %v", update, args)
+	}
+
+	if err := setPrimaryKeyValue(article, "id", "a2"); err != nil || article.ID != "a2" {
+		t.Fatalf("set primary key = %v, id %s", err, article.ID)
+	}
+}
+
+func TestEmbeddedModel_RoundTrip(t *testing.T) {
+	c := newIntegrationClient(t)
+	ctx := context.Background()
+	mustExec(t, c, `CREATE TEMP TABLE test_articles (
+		id TEXT PRIMARY KEY,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ,
+		deleted_at TIMESTAMPTZ,
+		title TEXT NOT NULL,
+		metadata JSONB
+	)`)
+
+	article := &testArticle{
+		testBaseModel: testBaseModel{ID: "a1"},
+		Title:         "draft",
+		Metadata:      json.RawMessage(`{"tags":["go"]}`),
+	}
+	id, dbErr := c.Insert(ctx, article)
+	if dbErr != nil || id == nil || *id != "a1" {
+		t.Fatalf("insert = %v, %v", id, dbErr)
+	}
+
+	var found testArticle
+	if err := c.FindByID(ctx, &found, "a1"); err != nil {
+		t.Fatalf("find failed: %v", err)
+	}
+	if found.ID != "a1" || found.Title != "draft" || found.CreatedAt.IsZero() {
+		t.Fatalf("found %+v", found)
+	}
+
+	found.Title = "published"
+	if err := c.Update(ctx, &found); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+
+	var updated testArticle
+	if err := c.FindByID(ctx, &updated, "a1"); err != nil {
+		t.Fatalf("find after update failed: %v", err)
+	}
+	if updated.Title != "published" || updated.UpdatedAt.IsZero() || !updated.CreatedAt.Equal(found.CreatedAt) {
+		t.Fatalf("updated %+v", updated)
+	}
+}
+
+func TestSavepointNameValidation(t *testing.T) {
+	tx := &transactionWrapper{logger: logger.NewNoop()}
+
+	for _, name := range []string{"", "1abc", "sp; DROP TABLE x", "sp-1", strings.Repeat("a", 64)} {
+		err := tx.Savepoint(context.Background(), name)
+		if err == nil {
+			t.Fatalf("expected %q to be rejected", name)
+		}
+		if !IsClientError(err) {
+			t.Fatalf("expected client error for %q, got %v", name, err)
+		}
+	}
+}
+
+func TestSavepoint_RollbackToKeepsEarlierWork(t *testing.T) {
+	c := newIntegrationClient(t)
+	ctx := context.Background()
+	mustExec(t, c, "CREATE TEMP TABLE test_notes (id TEXT PRIMARY KEY, body TEXT, deleted_at TIMESTAMPTZ)")
+
+	err := c.WithTransaction(ctx, func(tx database.Transaction) *database.DBError {
+		if err := tx.Create(ctx, &testNote{ID: "n1", Body: "kept"}); err != nil {
+			return err
+		}
+		if err := tx.Savepoint(ctx, "link_preview"); err != nil {
+			t.Fatalf("savepoint failed: %v", err)
+		}
+		if err := tx.Create(ctx, &testNote{ID: "n1", Body: "duplicate"}); err == nil {
+			t.Fatal("expected duplicate insert to fail")
+		}
+		if err := tx.RollbackTo(ctx, "link_preview"); err != nil {
+			t.Fatalf("rollback to savepoint failed: %v", err)
+		}
+		if err := tx.ReleaseSavepoint(ctx, "link_preview"); err != nil {
+			t.Fatalf("release savepoint failed: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+
+	var note testNote
+	if err := c.FindByID(ctx, &note, "n1"); err != nil {
+		t.Fatalf("expected first row to survive commit: %v", err)
+	}
+	if note.Body != "kept" {
+		t.Fatalf("unexpected body: %s", note.Body)
+	}
+}
+
+type warnRecorder struct {
+	logger.Logger
+	warnings []string
+}
+
+func (w *warnRecorder) Warn(msg string, fields ...logger.Field) {
+	w.warnings = append(w.warnings, msg)
+}
+
+func TestQueryTimer_ObserveThreshold(t *testing.T) {
+	rec := &warnRecorder{Logger: logger.NewNoop()}
+	timer := queryTimer{logger: rec, threshold: 100 * time.Millisecond}
+
+	timer.observe("FindMany", "SELECT 1", 10*time.Millisecond)
+	if len(rec.warnings) != 0 {
+		t.Fatalf("fast query must not be logged, got %v", rec.warnings)
+	}
+
+	timer.observe("FindMany", strings.Repeat("x", 2*maxSlowQueryLength), 150*time.Millisecond)
+	if len(rec.warnings) != 1 {
+		t.Fatalf("expected slow query warning, got %v", rec.warnings)
+	}
+}
+
+func TestWrapDatabaseError_ConstraintViolations(t *testing.T) {
+	tests := []struct {
+		code     pq.ErrorCode
+		wantCode string
+	}{
+		{PQCodeUniqueViolation, database.CodeDBDuplicateKey},
+		{PQCodeForeignKeyViolation, database.CodeDBForeignKey},
+	}
+
+	for _, tt := range tests {
+		err := &pq.Error{Code: tt.code, Constraint: "profiles_user_id_key", Column: "user_id"}
+		dbErr := wrapDatabaseError(err, "Create", "users.profiles", "INSERT INTO users.profiles")
+		if dbErr.Code() != tt.wantCode {
+			t.Fatalf("code %s: got %s, want %s", tt.code, dbErr.Code(), tt.wantCode)
+		}
+		if dbErr.Constraint() != "profiles_user_id_key" || dbErr.Column() != "user_id" {
+			t.Fatalf("code %s: constraint %q column %q not carried over", tt.code, dbErr.Constraint(), dbErr.Column())
+		}
+		if dbErr.Table() != "users.profiles" {
+			t.Fatalf("code %s: table = %q", tt.code, dbErr.Table())
+		}
+	}
+}
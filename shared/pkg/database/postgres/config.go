@@ -18,5 +18,9 @@ func DefaultConfig() database.Config {
 		MaxIdleConns:    5,
 		ConnMaxLifetime: 5 * time.Minute,
 		ConnMaxIdleTime: 5 * time.Minute,
+		BatchSize:       DefaultBatchSize,
+
+		ConnectRetries:      DefaultConnectRetries,
+		ConnectRetryBackoff: DefaultConnectRetryBackoff,
 	}
 }
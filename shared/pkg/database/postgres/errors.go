@@ -25,6 +25,9 @@ const (
 
 	PQCodeQueryCanceled = "57014"
 
+	PQCodeInvalidSQLStatementName = "26000"
+	PQCodeFeatureNotSupported     = "0A000"
+
 	PQCodeDiskFull    = "53100"
 	PQCodeOutOfMemory = "53200"
 )
@@ -246,6 +249,14 @@ func IsSerializationError(err error) bool {
 	return false
 }
 
+func IsConflictError(err error) bool {
+	var dbErr *db.DBError
+	if errors.As(err, &dbErr) {
+		return dbErr.Code() == db.CodeDBConflict
+	}
+	return false
+}
+
 func IsConnectionError(err error) bool {
 	var dbErr *db.DBError
 	if errors.As(err, &dbErr) {
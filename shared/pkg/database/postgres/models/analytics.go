@@ -766,7 +766,8 @@ type WebhookDelivery struct {
 	NextRetryAt   *time.Time `db:"next_retry_at" json:"next_retry_at,omitempty"`
 
 	// Payload
-	PayloadSizeBytes *int `db:"payload_size_bytes" json:"payload_size_bytes,omitempty"`
+	Payload          json.RawMessage `db:"payload" json:"payload,omitempty"`
+	PayloadSizeBytes *int            `db:"payload_size_bytes" json:"payload_size_bytes,omitempty"`
 
 	// Error
 	ErrorMessage *string `db:"error_message" json:"error_message,omitempty"`
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sync/atomic"
+
+	"shared/pkg/database"
+	"shared/pkg/logger"
+)
+
+func openReplicas(config database.Config, lgr logger.Logger) ([]*sql.DB, *database.DBError) {
+	replicas := make([]*sql.DB, 0, len(config.ReadReplicas))
+	closeAll := func() {
+		for _, replica := range replicas {
+			replica.Close()
+		}
+	}
+
+	for _, rc := range config.ReadReplicas {
+		user := firstNonEmpty(rc.User, config.User)
+		password := firstNonEmpty(rc.Password, config.Password)
+		dbName := firstNonEmpty(rc.Database, config.Database)
+		sslMode := firstNonEmpty(rc.SSLMode, config.SSLMode)
+		port := rc.Port
+		if port == 0 {
+			port = config.Port
+		}
+
+		replica, err := openPool(config, rc.Host, port, user, password, dbName, sslMode)
+		if err != nil {
+			closeAll()
+			return nil, database.ConnectionError("failed to open read replica", err).
+				WithDetail("host", rc.Host).
+				WithDetail("port", port)
+		}
+
+		if err := pingWithRetry(replica, config, lgr); err != nil {
+			replica.Close()
+			closeAll()
+			return nil, database.ConnectionError("failed to connect to read replica", err).
+				WithDetail("host", rc.Host).
+				WithDetail("port", port)
+		}
+
+		lgr.Info("Connected to read replica",
+			logger.String("host", rc.Host),
+			logger.Int("port", port),
+		)
+		replicas = append(replicas, replica)
+	}
+
+	return replicas, nil
+}
+
+// reader picks the pool for a read-only statement, rotating across replicas
+// and using the primary when none are configured.
+func (c *client) reader() sqlConn {
+	if len(c.replicas) == 0 {
+		return c.db
+	}
+	n := atomic.AddUint32(&c.nextReplica, 1)
+	return c.replicas[int(n)%len(c.replicas)]
+}
+
+// read runs fn against a replica. With ReplicaLagFallback enabled a failed
+// replica read, including a missing row the replica has not caught up on, is
+// retried once against the primary.
+func (c *client) read(operation string, fn func(conn sqlConn) error) error {
+	conn := c.reader()
+	err := fn(conn)
+	if err == nil || !c.replicaFallback || conn == sqlConn(c.db) {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	c.logger.Warn("Replica read failed, retrying on primary",
+		logger.String("operation", operation),
+		logger.Error(err),
+	)
+	return fn(c.db)
+}
+
+// sliceLenRestorer returns a func that truncates the slice behind dest back to
+// its current length, so a retried read does not keep rows from a failed one.
+func sliceLenRestorer(dest interface{}) func() {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return func() {}
+	}
+	n := v.Elem().Len()
+	return func() {
+		v.Elem().SetLen(n)
+	}
+}
+
+func toStats(stats sql.DBStats) database.Stats {
+	return database.Stats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration,
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
+
+func addStats(a, b database.Stats) database.Stats {
+	return database.Stats{
+		MaxOpenConnections: a.MaxOpenConnections + b.MaxOpenConnections,
+		OpenConnections:    a.OpenConnections + b.OpenConnections,
+		InUse:              a.InUse + b.InUse,
+		Idle:               a.Idle + b.Idle,
+		WaitCount:          a.WaitCount + b.WaitCount,
+		WaitDuration:       a.WaitDuration + b.WaitDuration,
+		MaxIdleClosed:      a.MaxIdleClosed + b.MaxIdleClosed,
+		MaxIdleTimeClosed:  a.MaxIdleTimeClosed + b.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  a.MaxLifetimeClosed + b.MaxLifetimeClosed,
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"shared/pkg/logger"
+)
+
+func newReplicaTestClient(t *testing.T, replicas int, fallback bool) *client {
+	t.Helper()
+
+	open := func() *sql.DB {
+		db, err := sql.Open("postgres", "host=localhost dbname=test sslmode=disable")
+		if err != nil {
+			t.Fatalf("failed to open pool: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	c := &client{db: open(), logger: logger.NewNoop(), replicaFallback: fallback}
+	for i := 0; i < replicas; i++ {
+		c.replicas = append(c.replicas, open())
+	}
+	return c
+}
+
+func TestReader_RoundRobin(t *testing.T) {
+	c := newReplicaTestClient(t, 2, false)
+
+	seen := map[sqlConn]int{}
+	for i := 0; i < 4; i++ {
+		seen[c.reader()]++
+	}
+	if len(seen) != 2 || seen[c.replicas[0]] != 2 || seen[c.replicas[1]] != 2 {
+		t.Fatalf("expected reads to alternate across replicas, got %v", seen)
+	}
+	if _, ok := seen[c.db]; ok {
+		t.Fatal("reads must not hit the primary when replicas are configured")
+	}
+
+	if conn := newReplicaTestClient(t, 0, false).reader(); conn == nil {
+		t.Fatal("expected primary when no replicas are configured")
+	}
+}
+
+func TestRead_FallsBackToPrimary(t *testing.T) {
+	replicaErr := errors.New("replica unavailable")
+
+	c := newReplicaTestClient(t, 1, true)
+	var calls []sqlConn
+	err := c.read("FindOne", func(conn sqlConn) error {
+		calls = append(calls, conn)
+		if conn != sqlConn(c.db) {
+			return replicaErr
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected primary retry to succeed, got %v", err)
+	}
+	if len(calls) != 2 || calls[1] != sqlConn(c.db) {
+		t.Fatalf("expected replica then primary, got %d calls", len(calls))
+	}
+
+	c = newReplicaTestClient(t, 1, false)
+	err = c.read("FindOne", func(conn sqlConn) error { return replicaErr })
+	if !errors.Is(err, replicaErr) {
+		t.Fatalf("expected replica error without fallback, got %v", err)
+	}
+}
+
+func TestQueryRow_FallsBackToPrimary(t *testing.T) {
+	// A closed replica fails every read with "database is closed"; the
+	// unreachable primary fails differently, so the error shows who answered
+	c := newReplicaTestClient(t, 1, true)
+	c.replicas[0].Close()
+
+	var n int
+	err := c.QueryRow(context.Background(), "SELECT 1").Scan(&n)
+	if err == nil || strings.Contains(err.Error(), "database is closed") {
+		t.Fatalf("expected the primary to be tried after the replica, got %v", err)
+	}
+
+	c = newReplicaTestClient(t, 1, false)
+	c.replicas[0].Close()
+	err = c.QueryRow(context.Background(), "SELECT 1").Scan(&n)
+	if err == nil || !strings.Contains(err.Error(), "database is closed") {
+		t.Fatalf("expected the replica error without fallback, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/lib/pq"
+)
+
+const DefaultStmtCacheSize = 100
+
+// stmtCache keeps the most recently used prepared statements of one pool and
+// serves them through the sqlConn interface, so callers swap it in for the
+// *sql.DB it wraps.
+type stmtCache struct {
+	db       *sql.DB
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+}
+
+type cachedStmt struct {
+	query string
+	stmt  *sql.Stmt
+	// refs counts calls still starting on stmt; an evicted statement is
+	// closed once the last of them has handed it to database/sql, which keeps
+	// it open for any rows still being read.
+	refs    int
+	evicted bool
+}
+
+func newStmtCache(db *sql.DB, capacity int) *stmtCache {
+	if capacity <= 0 {
+		capacity = DefaultStmtCacheSize
+	}
+	return &stmtCache{
+		db:       db,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (sc *stmtCache) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := sc.run(ctx, query, func(stmt *sql.Stmt) error {
+		var err error
+		rows, err = stmt.QueryContext(ctx, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (sc *stmtCache) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := sc.run(ctx, query, func(stmt *sql.Stmt) error {
+		var err error
+		result, err = stmt.ExecContext(ctx, args...)
+		return err
+	})
+	return result, err
+}
+
+func (sc *stmtCache) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	var row *sql.Row
+	sc.run(ctx, query, func(stmt *sql.Stmt) error {
+		row = stmt.QueryRowContext(ctx, args...)
+		return row.Err()
+	})
+	if row == nil {
+		// Preparing failed and sql.Row can't carry that error, so run the
+		// query unprepared and let Scan report what went wrong
+		return sc.db.QueryRowContext(ctx, query, args...)
+	}
+	return row
+}
+
+// run calls fn with the cached statement for query. A statement the server no
+// longer accepts, typically after a schema change, is dropped and fn retried
+// once on a fresh one.
+func (sc *stmtCache) run(ctx context.Context, query string, fn func(stmt *sql.Stmt) error) error {
+	for attempt := 0; ; attempt++ {
+		entry, err := sc.acquire(ctx, query)
+		if err != nil {
+			return err
+		}
+		err = fn(entry.stmt)
+		sc.release(entry)
+
+		if err == nil || attempt > 0 || !isStaleStmtError(err) {
+			return err
+		}
+		sc.evict(entry)
+	}
+}
+
+func (sc *stmtCache) acquire(ctx context.Context, query string) (*cachedStmt, error) {
+	sc.mu.Lock()
+	if elem, ok := sc.entries[query]; ok {
+		sc.lru.MoveToFront(elem)
+		entry := elem.Value.(*cachedStmt)
+		entry.refs++
+		sc.mu.Unlock()
+		return entry, nil
+	}
+	sc.mu.Unlock()
+
+	// Prepare outside the lock so a slow round trip doesn't hold up hits
+	stmt, err := sc.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if elem, ok := sc.entries[query]; ok {
+		// Another caller prepared the same query meanwhile
+		stmt.Close()
+		sc.lru.MoveToFront(elem)
+		entry := elem.Value.(*cachedStmt)
+		entry.refs++
+		return entry, nil
+	}
+
+	entry := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	sc.entries[query] = sc.lru.PushFront(entry)
+	for sc.lru.Len() > sc.capacity {
+		sc.removeLocked(sc.lru.Back().Value.(*cachedStmt))
+	}
+	return entry, nil
+}
+
+func (sc *stmtCache) release(entry *cachedStmt) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+func (sc *stmtCache) evict(entry *cachedStmt) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.removeLocked(entry)
+}
+
+// removeLocked drops entry from the cache, closing it unless a call is still
+// starting on it
+func (sc *stmtCache) removeLocked(entry *cachedStmt) {
+	if entry.evicted {
+		return
+	}
+	if elem, ok := sc.entries[entry.query]; ok && elem.Value.(*cachedStmt) == entry {
+		delete(sc.entries, entry.query)
+		sc.lru.Remove(elem)
+	}
+	entry.evicted = true
+	if entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+func (sc *stmtCache) len() int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.lru.Len()
+}
+
+func (sc *stmtCache) close() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for sc.lru.Len() > 0 {
+		sc.removeLocked(sc.lru.Front().Value.(*cachedStmt))
+	}
+}
+
+// isStaleStmtError reports whether err means a prepared statement must be
+// prepared again: it was deallocated, or a schema change altered the columns
+// its cached plan returns
+func isStaleStmtError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case PQCodeInvalidSQLStatementName:
+		return true
+	case PQCodeFeatureNotSupported:
+		return strings.Contains(pqErr.Message, "cached plan must not change result type")
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"shared/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+// schemaDriver prepares statements against a schema version; bumping the
+// version makes statements prepared earlier fail like Postgres does after an
+// ALTER TABLE
+type schemaDriver struct {
+	mu       sync.Mutex
+	version  int
+	prepares int
+}
+
+func (d *schemaDriver) Connect(context.Context) (driver.Conn, error) { return &schemaConn{d: d}, nil }
+func (d *schemaDriver) Driver() driver.Driver                        { return d }
+func (d *schemaDriver) Open(string) (driver.Conn, error)             { return &schemaConn{d: d}, nil }
+
+func (d *schemaDriver) alterSchema() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.version++
+}
+
+func (d *schemaDriver) prepareCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.prepares
+}
+
+type schemaConn struct{ d *schemaDriver }
+
+func (c *schemaConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.prepares++
+	return &schemaStmt{d: c.d, version: c.d.version}, nil
+}
+func (c *schemaConn) Close() error              { return nil }
+func (c *schemaConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type schemaStmt struct {
+	d       *schemaDriver
+	version int
+}
+
+func (s *schemaStmt) Close() error  { return nil }
+func (s *schemaStmt) NumInput() int { return -1 }
+
+func (s *schemaStmt) check() error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if s.version != s.d.version {
+		return &pq.Error{Code: PQCodeFeatureNotSupported, Message: "cached plan must not change result type"}
+	}
+	return nil
+}
+
+func (s *schemaStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *schemaStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	return &oneRow{}, nil
+}
+
+type oneRow struct{ done bool }
+
+func (r *oneRow) Columns() []string { return []string{"n"} }
+func (r *oneRow) Close() error      { return nil }
+func (r *oneRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func newStmtCacheTestClient(t *testing.T, size int) (*client, *schemaDriver) {
+	t.Helper()
+	d := &schemaDriver{}
+	db := sql.OpenDB(d)
+	t.Cleanup(func() { db.Close() })
+
+	c := &client{db: db, logger: logger.NewNoop()}
+	c.enableStmtCache(size)
+	return c, d
+}
+
+func TestStmtCache_ReusesStatements(t *testing.T) {
+	c, d := newStmtCacheTestClient(t, 10)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Exec(ctx, "INSERT INTO t (n) VALUES ($1)", i); err != nil {
+			t.Fatalf("Exec: %v", err)
+		}
+	}
+	if got := d.prepareCount(); got != 1 {
+		t.Fatalf("prepared %d times, want 1", got)
+	}
+}
+
+func TestStmtCache_ReprepareAfterSchemaChange(t *testing.T) {
+	c, d := newStmtCacheTestClient(t, 10)
+	ctx := context.Background()
+
+	var n int
+	if err := c.QueryRow(ctx, "SELECT n FROM t").Scan(&n); err != nil {
+		t.Fatalf("QueryRow: %v", err)
+	}
+	if _, err := c.Exec(ctx, "UPDATE t SET n = 2"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+
+	d.alterSchema()
+
+	if err := c.QueryRow(ctx, "SELECT n FROM t").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("QueryRow after schema change = %d, %v; want 1, nil", n, err)
+	}
+	if _, err := c.Exec(ctx, "UPDATE t SET n = 2"); err != nil {
+		t.Fatalf("Exec after schema change: %v", err)
+	}
+	rows, dbErr := c.Query(ctx, "SELECT n FROM t")
+	if dbErr != nil {
+		t.Fatalf("Query: %v", dbErr)
+	}
+	rows.Close()
+
+	// Two statements prepared before the change and each prepared again once;
+	// Query shares the SELECT with QueryRow
+	if got := d.prepareCount(); got != 4 {
+		t.Fatalf("prepared %d times, want 4", got)
+	}
+}
+
+func TestStmtCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c, d := newStmtCacheTestClient(t, 2)
+	ctx := context.Background()
+
+	for _, query := range []string{"SELECT 1", "SELECT 2", "SELECT 1", "SELECT 3", "SELECT 1"} {
+		if _, err := c.Exec(ctx, query); err != nil {
+			t.Fatalf("Exec %q: %v", query, err)
+		}
+	}
+	// SELECT 2 was evicted by SELECT 3; SELECT 1 stayed cached throughout
+	if got := d.prepareCount(); got != 3 {
+		t.Fatalf("prepared %d times, want 3", got)
+	}
+
+	cache := c.stmts[c.db]
+	if cache.len() != 2 {
+		t.Fatalf("cache holds %d statements, want 2", cache.len())
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if cache.len() != 0 {
+		t.Fatalf("cache holds %d statements after Close, want 0", cache.len())
+	}
+}
+
+func BenchmarkRepeatedInsert(b *testing.B) {
+	for _, cached := range []bool{false, true} {
+		b.Run(fmt.Sprintf("cached=%v", cached), func(b *testing.B) {
+			c := newIntegrationClient(b)
+			if cached {
+				c.enableStmtCache(DefaultStmtCacheSize)
+			}
+			mustExec(b, c, "CREATE TEMP TABLE bench_inserts (id bigserial PRIMARY KEY, body text NOT NULL)")
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Exec(ctx, "INSERT INTO bench_inserts (body) VALUES ($1)", "hello"); err != nil {
+					b.Fatalf("insert: %v", err)
+				}
+			}
+		})
+	}
+}
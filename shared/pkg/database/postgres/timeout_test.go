@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"shared/pkg/database"
+	"shared/pkg/logger"
+)
+
+// hangingConnector hands out connections whose statements only return once
+// their context is done, like a database that stopped answering
+type hangingConnector struct{}
+
+func (hangingConnector) Connect(context.Context) (driver.Conn, error) { return hangingConn{}, nil }
+func (hangingConnector) Driver() driver.Driver                        { return hangingDriver{} }
+
+type hangingDriver struct{}
+
+func (hangingDriver) Open(string) (driver.Conn, error) { return hangingConn{}, nil }
+
+type hangingConn struct{}
+
+func (hangingConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (hangingConn) Close() error                        { return nil }
+func (hangingConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (hangingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (hangingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func newHangingClient(t *testing.T, timeout time.Duration) *client {
+	t.Helper()
+	db := sql.OpenDB(hangingConnector{})
+	t.Cleanup(func() { db.Close() })
+	return &client{db: db, logger: logger.NewNoop(), queryTimeout: timeout}
+}
+
+func TestDefaultQueryTimeout_BoundsQueriesWithoutDeadline(t *testing.T) {
+	c := newHangingClient(t, 20*time.Millisecond)
+
+	start := time.Now()
+	_, dbErr := c.Exec(context.Background(), "SELECT 1")
+	if dbErr == nil || dbErr.Code() != database.CodeDBTimeout {
+		t.Fatalf("Exec error = %v, want %s", dbErr, database.CodeDBTimeout)
+	}
+
+	var count int64
+	err := c.QueryRow(context.Background(), "SELECT count(*) FROM widgets").Scan(&count)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("QueryRow error = %v, want deadline exceeded", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("queries took %v, want them cut off by the default timeout", elapsed)
+	}
+}
+
+func TestDefaultQueryTimeout_KeepsCallerDeadline(t *testing.T) {
+	c := newHangingClient(t, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, dbErr := c.Exec(ctx, "SELECT 1")
+	if dbErr == nil || dbErr.Code() != database.CodeDBTimeout {
+		t.Fatalf("Exec error = %v, want %s", dbErr, database.CodeDBTimeout)
+	}
+}
+
+func TestDefaultQueryTimeout_PgSleep(t *testing.T) {
+	c := newIntegrationClient(t)
+	c.queryTimeout = 50 * time.Millisecond
+
+	_, dbErr := c.Exec(context.Background(), "SELECT pg_sleep(5)")
+	if dbErr == nil || dbErr.Code() != database.CodeDBTimeout {
+		t.Fatalf("Exec error = %v, want %s", dbErr, database.CodeDBTimeout)
+	}
+}
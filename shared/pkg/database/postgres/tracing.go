@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("shared/pkg/database/postgres")
+
+// startQuerySpan starts a client span for one statement when the caller is
+// part of a recorded trace. Untraced callers, including every caller while
+// the global tracer provider is the default no-op, get ctx back unchanged.
+func startQuerySpan(ctx context.Context, operation, query string) (context.Context, func(error)) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return ctx, func(error) {}
+	}
+
+	if len(query) > maxSlowQueryLength {
+		query = query[:maxSlowQueryLength] + "..."
+	}
+	ctx, span := tracer.Start(ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", query),
+		),
+	)
+
+	return ctx, func(err error) {
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,242 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Condition is a WHERE predicate built with Cond, And or Or
+type Condition struct {
+	column string
+	op     string
+	value  interface{}
+
+	joiner string
+	group  []Condition
+}
+
+// Cond compares column against value. op is one of =, !=, <>, <, <=, >, >=,
+// LIKE, ILIKE, IN, NOT IN, IS NULL and IS NOT NULL; IN takes a slice and the
+// IS forms ignore value.
+func Cond(column, op string, value interface{}) Condition {
+	return Condition{column: column, op: strings.ToUpper(strings.TrimSpace(op)), value: value}
+}
+
+// And matches when every condition matches
+func And(conditions ...Condition) Condition {
+	return Condition{joiner: "AND", group: conditions}
+}
+
+// Or matches when any condition matches
+func Or(conditions ...Condition) Condition {
+	return Condition{joiner: "OR", group: conditions}
+}
+
+var queryOperators = map[string]bool{
+	"=": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true,
+	"LIKE": true, "ILIKE": true, "IN": true, "NOT IN": true, "IS NULL": true, "IS NOT NULL": true,
+}
+
+type orderClause struct {
+	column    string
+	direction string
+}
+
+// SelectQuery builds a parameterized SELECT over one model's table for
+// FindMany and FindOne. Columns are checked against the model's db tags, so
+// only values ever reach the query as arguments. The first mistake is kept
+// and returned by Build.
+type SelectQuery struct {
+	table   string
+	columns []string
+	known   map[string]bool
+
+	where   []Condition
+	orderBy []orderClause
+	limit   int
+	offset  int
+	err     *DBError
+}
+
+// Select starts a query selecting every db-tagged column of model
+func Select(model Model) *SelectQuery {
+	columns := modelColumns(model)
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+	return &SelectQuery{table: model.TableName(), columns: columns, known: known}
+}
+
+// Where adds a condition ANDed with the others
+func (q *SelectQuery) Where(column, op string, value interface{}) *SelectQuery {
+	return q.WhereCond(Cond(column, op, value))
+}
+
+// WhereNull matches rows where column IS NULL
+func (q *SelectQuery) WhereNull(column string) *SelectQuery {
+	return q.WhereCond(Cond(column, "IS NULL", nil))
+}
+
+// WhereCond adds a condition, typically an And or Or group, ANDed with the
+// others
+func (q *SelectQuery) WhereCond(condition Condition) *SelectQuery {
+	q.where = append(q.where, condition)
+	return q
+}
+
+// OrderBy sorts by column in direction, SortAsc or SortDesc. Repeated calls
+// add tie breakers.
+func (q *SelectQuery) OrderBy(column, direction string) *SelectQuery {
+	direction = strings.ToUpper(direction)
+	switch {
+	case !q.known[column]:
+		q.fail(NewDBError(CodeDBInvalidInput, "invalid order by column").WithColumn(column))
+	case direction != SortAsc && direction != SortDesc:
+		q.fail(NewDBError(CodeDBInvalidInput, "invalid sort direction").WithDetail("direction", direction))
+	default:
+		q.orderBy = append(q.orderBy, orderClause{column: column, direction: direction})
+	}
+	return q
+}
+
+func (q *SelectQuery) Limit(limit int) *SelectQuery {
+	q.limit = limit
+	return q
+}
+
+func (q *SelectQuery) Offset(offset int) *SelectQuery {
+	q.offset = offset
+	return q
+}
+
+// Build returns the SQL and its arguments, numbered from $1
+func (q *SelectQuery) Build() (string, []interface{}, *DBError) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	if len(q.columns) == 0 {
+		return "", nil, NewDBError(CodeDBInvalidInput, "model has no db tagged columns").WithTable(q.table)
+	}
+
+	var sb strings.Builder
+	var args []interface{}
+	fmt.Fprintf(&sb, "SELECT %s FROM %s", strings.Join(q.columns, ", "), q.table)
+
+	if len(q.where) > 0 {
+		clause, err := q.buildCondition(And(q.where...), &args, false)
+		if err != nil {
+			return "", nil, err
+		}
+		sb.WriteString(" WHERE ")
+		sb.WriteString(clause)
+	}
+
+	if len(q.orderBy) > 0 {
+		parts := make([]string, len(q.orderBy))
+		for i, order := range q.orderBy {
+			parts[i] = order.column + " " + order.direction
+		}
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(parts, ", "))
+	}
+
+	if q.limit > 0 {
+		args = append(args, q.limit)
+		fmt.Fprintf(&sb, " LIMIT $%d", len(args))
+	}
+	if q.offset > 0 {
+		args = append(args, q.offset)
+		fmt.Fprintf(&sb, " OFFSET $%d", len(args))
+	}
+
+	return sb.String(), args, nil
+}
+
+// buildCondition renders condition, appending its values to args. Nested
+// groups are parenthesized so AND and OR keep their meaning.
+func (q *SelectQuery) buildCondition(condition Condition, args *[]interface{}, nested bool) (string, *DBError) {
+	if condition.joiner == "" {
+		return q.buildComparison(condition, args)
+	}
+	if len(condition.group) == 0 {
+		return "", NewDBError(CodeDBInvalidInput, "empty condition group").WithTable(q.table)
+	}
+
+	parts := make([]string, len(condition.group))
+	for i, inner := range condition.group {
+		part, err := q.buildCondition(inner, args, true)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = part
+	}
+	clause := strings.Join(parts, " "+condition.joiner+" ")
+	if nested && len(parts) > 1 {
+		clause = "(" + clause + ")"
+	}
+	return clause, nil
+}
+
+func (q *SelectQuery) buildComparison(condition Condition, args *[]interface{}) (string, *DBError) {
+	if !q.known[condition.column] {
+		return "", NewDBError(CodeDBInvalidInput, "invalid where column").
+			WithTable(q.table).
+			WithColumn(condition.column)
+	}
+	if !queryOperators[condition.op] {
+		return "", NewDBError(CodeDBInvalidInput, "invalid where operator").
+			WithColumn(condition.column).
+			WithDetail("operator", condition.op)
+	}
+
+	switch condition.op {
+	case "IS NULL", "IS NOT NULL":
+		return condition.column + " " + condition.op, nil
+
+	case "IN", "NOT IN":
+		values := reflect.ValueOf(condition.value)
+		if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+			return "", NewDBError(CodeDBInvalidInput, "IN needs a slice of values").
+				WithColumn(condition.column)
+		}
+		if values.Len() == 0 {
+			return "", NewDBError(CodeDBInvalidInput, "IN needs at least one value").
+				WithColumn(condition.column)
+		}
+		placeholders := make([]string, values.Len())
+		for i := range placeholders {
+			*args = append(*args, values.Index(i).Interface())
+			placeholders[i] = fmt.Sprintf("$%d", len(*args))
+		}
+		return fmt.Sprintf("%s %s (%s)", condition.column, condition.op, strings.Join(placeholders, ", ")), nil
+	}
+
+	*args = append(*args, condition.value)
+	return fmt.Sprintf("%s %s $%d", condition.column, condition.op, len(*args)), nil
+}
+
+func (q *SelectQuery) fail(err *DBError) {
+	if q.err == nil {
+		q.err = err.WithTable(q.table)
+	}
+}
+
+// modelColumns lists the db tags of model's fields in declaration order
+func modelColumns(model Model) []string {
+	t := reflect.TypeOf(model)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := ColumnFields(t)
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, field.Tag.Get("db"))
+	}
+	return columns
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testMessage struct {
+	ID             string     `db:"id"`
+	ConversationID string     `db:"conversation_id"`
+	Status         string     `db:"status"`
+	CreatedAt      time.Time  `db:"created_at"`
+	DeletedAt      *time.Time `db:"deleted_at"`
+	Draft          string     `db:"-"`
+}
+
+func (m *testMessage) TableName() string       { return "messages.messages" }
+func (m *testMessage) PrimaryKey() interface{} { return m.ID }
+
+func TestSelectQuery_Build(t *testing.T) {
+	query, args, err := Select(&testMessage{}).
+		Where("status", "=", "sent").
+		Where("conversation_id", "=", "c1").
+		OrderBy("created_at", SortDesc).
+		Limit(50).
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	want := "SELECT id, conversation_id, status, created_at, deleted_at FROM messages.messages" +
+		" WHERE status = $1 AND conversation_id = $2 ORDER BY created_at DESC LIMIT $3"
+	if query != want {
+		t.Fatalf("query =\n%s\nwant\n%s", query, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"sent", "c1", 50}) {
+		t.Fatalf("args = %v", args)
+	}
+}
+
+func TestSelectQuery_InNullAndGroups(t *testing.T) {
+	query, args, err := Select(&testMessage{}).
+		Where("id", "in", []string{"m1", "m2", "m3"}).
+		WhereNull("deleted_at").
+		WhereCond(Or(
+			Cond("status", "=", "read"),
+			And(Cond("status", "=", "sent"), Cond("created_at", "<", "2024-01-01")),
+		)).
+		Offset(10).
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	want := "SELECT id, conversation_id, status, created_at, deleted_at FROM messages.messages" +
+		" WHERE id IN ($1, $2, $3) AND deleted_at IS NULL AND (status = $4 OR (status = $5 AND created_at < $6)) OFFSET $7"
+	if query != want {
+		t.Fatalf("query =\n%s\nwant\n%s", query, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"m1", "m2", "m3", "read", "sent", "2024-01-01", 10}) {
+		t.Fatalf("args = %v", args)
+	}
+}
+
+func TestSelectQuery_RejectsUnsafeInput(t *testing.T) {
+	tests := map[string]*SelectQuery{
+		"unknown column":   Select(&testMessage{}).Where("status; DROP TABLE x", "=", 1),
+		"ignored column":   Select(&testMessage{}).Where("Draft", "=", 1),
+		"unknown operator": Select(&testMessage{}).Where("status", "= 1 OR 1 =", 1),
+		"empty IN":         Select(&testMessage{}).Where("id", "IN", []string{}),
+		"scalar IN":        Select(&testMessage{}).Where("id", "IN", "m1"),
+		"order column":     Select(&testMessage{}).OrderBy("random()", SortAsc),
+		"order direction":  Select(&testMessage{}).OrderBy("created_at", "sideways"),
+		"empty group":      Select(&testMessage{}).WhereCond(Or()),
+	}
+
+	for name, q := range tests {
+		if _, _, err := q.Build(); err == nil || err.Code() != CodeDBInvalidInput {
+			t.Fatalf("%s: err = %v, want %s", name, err, CodeDBInvalidInput)
+		}
+	}
+}
+
+type auditColumns struct {
+	ID        string `db:"id"`
+	UpdatedAt string `db:"updated_at"`
+}
+
+type auditedRow struct {
+	auditColumns
+	UpdatedAt string `db:"updated_at"`
+	Name      string `db:"name"`
+}
+
+func TestColumnFields_OuterFieldShadowsEmbedded(t *testing.T) {
+	fields := ColumnFields(reflect.TypeOf(&auditedRow{}))
+
+	var got []string
+	for _, f := range fields {
+		got = append(got, f.Tag.Get("db"))
+	}
+	if strings.Join(got, ",") != "id,updated_at,name" {
+		t.Fatalf("columns = %v", got)
+	}
+	if idx := fields[1].Index; len(idx) != 1 || idx[0] != 1 {
+		t.Fatalf("updated_at index = %v, want the outer field", idx)
+	}
+}
@@ -0,0 +1,19 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// WithQueryTimeout bounds ctx to d unless it already carries a deadline or d
+// is not positive, so callers that chose their own deadline keep it. The
+// returned cancel must always be called.
+func WithQueryTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
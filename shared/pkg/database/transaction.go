@@ -2,6 +2,14 @@ package database
 
 import (
 	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+const (
+	txRetryBaseDelay = 20 * time.Millisecond
+	txRetryMaxDelay  = time.Second
 )
 
 type TxFunc func(ctx context.Context, tx Transaction) error
@@ -51,3 +59,54 @@ func WithTransactionOpts(ctx context.Context, db Database, opts *TxOptions, fn T
 
 	return tx.Commit()
 }
+
+// WithTransactionRetry runs fn like WithTransactionOpts and, when the
+// transaction fails with a serialization failure or deadlock, rolls it back
+// and runs fn again in a new one, up to maxRetries more times with jittered
+// exponential backoff. fn may run several times, so it must do its work only
+// through tx and leave nothing behind outside the database that a rerun would
+// repeat.
+func WithTransactionRetry(ctx context.Context, db Database, opts *TxOptions, maxRetries int, fn TxFunc) error {
+	delay := txRetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		// Every attempt ends in a commit or a rollback before the next begins
+		err := WithTransactionOpts(ctx, db, opts, fn)
+		if err == nil || attempt >= maxRetries || !IsTxConflict(err) {
+			return err
+		}
+
+		// Sleep between half and all of delay so conflicting callers spread out
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if delay *= 2; delay > txRetryMaxDelay {
+			delay = txRetryMaxDelay
+		}
+	}
+}
+
+// IsTxConflict reports whether err, or any error it wraps, is a
+// serialization failure (40001) or deadlock (40P01), after which the whole
+// transaction can be retried
+func IsTxConflict(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if dbErr, ok := err.(*DBError); ok {
+			switch dbErr.Code() {
+			case CodeDBSerializationFailure, CodeDBDeadlock:
+				return true
+			}
+		}
+		if stateErr, ok := err.(interface{ SQLState() string }); ok {
+			switch stateErr.SQLState() {
+			case "40001", "40P01":
+				return true
+			}
+		}
+	}
+	return false
+}
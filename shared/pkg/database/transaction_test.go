@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// conflictDB hands out transactions whose Exec fails with a serialization
+// failure until failures runs out
+type conflictDB struct {
+	Database
+	failures  int
+	begins    int
+	commits   int
+	rollbacks int
+}
+
+func (db *conflictDB) BeginTx(ctx context.Context, opts *TxOptions) (Transaction, *DBError) {
+	db.begins++
+	return &conflictTx{db: db}, nil
+}
+
+type conflictTx struct {
+	Transaction
+	db   *conflictDB
+	done bool
+}
+
+func (tx *conflictTx) Exec(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	if tx.db.failures > 0 {
+		tx.db.failures--
+		return nil, NewDBError(CodeDBSerializationFailure, "Serialization failure").WithSQLState("40001")
+	}
+	return nil, nil
+}
+
+func (tx *conflictTx) Commit() error {
+	if tx.done {
+		return errors.New("transaction already finished")
+	}
+	tx.done = true
+	tx.db.commits++
+	return nil
+}
+
+func (tx *conflictTx) Rollback() error {
+	if tx.done {
+		return errors.New("transaction already finished")
+	}
+	tx.done = true
+	tx.db.rollbacks++
+	return nil
+}
+
+func exec(ctx context.Context, tx Transaction) error {
+	_, err := tx.Exec(ctx, "UPDATE accounts SET balance = balance - 1")
+	return err
+}
+
+func TestWithTransactionRetry_RetriesConflictThenSucceeds(t *testing.T) {
+	db := &conflictDB{failures: 2}
+	runs := 0
+	err := WithTransactionRetry(context.Background(), db, nil, 3, func(ctx context.Context, tx Transaction) error {
+		runs++
+		return exec(ctx, tx)
+	})
+	if err != nil {
+		t.Fatalf("WithTransactionRetry() = %v, want nil", err)
+	}
+	if runs != 3 {
+		t.Fatalf("fn ran %d times, want 3", runs)
+	}
+	if db.begins != 3 || db.rollbacks != 2 || db.commits != 1 {
+		t.Fatalf("begins=%d rollbacks=%d commits=%d, want every failed attempt rolled back before the next",
+			db.begins, db.rollbacks, db.commits)
+	}
+}
+
+func TestWithTransactionRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	db := &conflictDB{failures: 10}
+	runs := 0
+	err := WithTransactionRetry(context.Background(), db, nil, 2, func(ctx context.Context, tx Transaction) error {
+		runs++
+		return exec(ctx, tx)
+	})
+	if !IsTxConflict(err) {
+		t.Fatalf("WithTransactionRetry() = %v, want the serialization failure", err)
+	}
+	if runs != 3 || db.rollbacks != 3 || db.commits != 0 {
+		t.Fatalf("runs=%d rollbacks=%d commits=%d, want 3 rolled back attempts", runs, db.rollbacks, db.commits)
+	}
+}
+
+func TestWithTransactionRetry_DoesNotRetryOtherErrors(t *testing.T) {
+	db := &conflictDB{}
+	runs := 0
+	want := NewDBError(CodeDBDuplicateKey, "Duplicate key violation")
+	err := WithTransactionRetry(context.Background(), db, nil, 3, func(ctx context.Context, tx Transaction) error {
+		runs++
+		return want
+	})
+	if err != want || runs != 1 {
+		t.Fatalf("err=%v runs=%d, want the duplicate key error after one run", err, runs)
+	}
+}
+
+func TestIsTxConflict(t *testing.T) {
+	wrapped := WrapDBError(NewDBError(CodeDBDeadlock, "Deadlock detected"), CodeDBInternal, "failed to commit transaction")
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("boom"), false},
+		{NewDBError(CodeDBSerializationFailure, "Serialization failure"), true},
+		{NewDBError(CodeDBInternal, "internal").WithSQLState("40P01"), true},
+		{wrapped, true},
+		{NewDBError(CodeDBTimeout, "Query canceled"), false},
+	}
+	for _, c := range cases {
+		if got := IsTxConflict(c.err); got != c.want {
+			t.Fatalf("IsTxConflict(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package adapter
+
+import "sync/atomic"
+
+const defaultAsyncBufferSize = 1024
+
+// asyncQueue runs log writes on a single goroutine, in the order they were
+// queued
+type asyncQueue struct {
+	entries chan func()
+	drop    bool
+	dropped atomic.Int64
+}
+
+func newAsyncQueue(size int, drop bool) *asyncQueue {
+	if size <= 0 {
+		size = defaultAsyncBufferSize
+	}
+	q := &asyncQueue{
+		entries: make(chan func(), size),
+		drop:    drop,
+	}
+	go q.run()
+	return q
+}
+
+func (q *asyncQueue) run() {
+	for write := range q.entries {
+		write()
+	}
+}
+
+func (q *asyncQueue) enqueue(write func()) {
+	if !q.drop {
+		q.entries <- write
+		return
+	}
+
+	select {
+	case q.entries <- write:
+	default:
+		q.dropped.Add(1)
+	}
+}
+
+// flush blocks until every entry queued before the call has been written and
+// returns how many entries were dropped since the last flush
+func (q *asyncQueue) flush() int64 {
+	done := make(chan struct{})
+	q.entries <- func() { close(done) }
+	<-done
+	return q.dropped.Swap(0)
+}
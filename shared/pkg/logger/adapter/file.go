@@ -0,0 +1,48 @@
+package adapter
+
+import (
+	"sync"
+	"time"
+
+	"shared/pkg/logger"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	fileBufferSize    = 256 * 1024
+	fileFlushInterval = time.Second
+)
+
+var (
+	fileSinksMu sync.Mutex
+	// fileSinks is keyed by path so loggers writing the same file share one
+	// rotator instead of racing each other on rotation
+	fileSinks = make(map[string]zapcore.WriteSyncer)
+)
+
+// openFileSink returns the buffered, rotating writer for cfg.Path. Entries
+// reach the file at least every second and on Sync.
+func openFileSink(cfg *logger.FileConfig) zapcore.WriteSyncer {
+	fileSinksMu.Lock()
+	defer fileSinksMu.Unlock()
+
+	if sink, ok := fileSinks[cfg.Path]; ok {
+		return sink
+	}
+
+	sink := &zapcore.BufferedWriteSyncer{
+		WS: zapcore.AddSync(&lumberjack.Logger{
+			Filename:   cfg.Path,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+			Compress:   cfg.Compress,
+		}),
+		Size:          fileBufferSize,
+		FlushInterval: fileFlushInterval,
+	}
+	fileSinks[cfg.Path] = sink
+	return sink
+}
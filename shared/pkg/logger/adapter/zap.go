@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"hash/fnv"
+	"io"
 	"os"
 	"regexp"
 	"runtime"
@@ -57,6 +58,8 @@ const (
 	callerSkipFormatLog = 2
 	callerSkipError     = 3
 
+	samplingTick = time.Second
+
 	truncationSuffix   = "..."
 	truncationMinWidth = 3
 
@@ -75,9 +78,6 @@ const (
 
 	fieldSeparator      = " | "
 	fieldKeyValueFormat = "%s=%v"
-
-	sensitiveFieldPrefix = 4
-	sanitizationMask     = "****"
 )
 
 var (
@@ -86,30 +86,24 @@ var (
 		"\x1b[38;5;39m", "\x1b[38;5;202m", "\x1b[38;5;99m", "\x1b[38;5;34m",
 		"\x1b[38;5;161m", "\x1b[38;5;208m", "\x1b[38;5;46m", "\x1b[38;5;33m",
 	}
-	sensitiveFields = map[string]bool{
-		"password":      true,
-		"token":         true,
-		"secret":        true,
-		"api_key":       true,
-		"apikey":        true,
-		"access_token":  true,
-		"refresh_token": true,
-		"bearer":        true,
-		"authorization": true,
-		"auth":          true,
-		"credential":    true,
-		"credentials":   true,
-		"private_key":   true,
-		"privatekey":    true,
-	}
 )
 
 type zapLogger struct {
 	logger      *zap.Logger
 	consoleMode bool
+	fileCopy    bool // console mode also writes plain entries to the log file
 	service     string
 	color       string
 	termWidth   int
+	level       zap.AtomicLevel
+	redactor    *logger.Sanitizer
+	out         io.Writer
+	// queue is shared by every logger derived from the same root, nil unless
+	// async mode is on
+	queue *asyncQueue
+	// fields are the ones added through With; the zap core already carries
+	// them, the boxed console output needs them passed explicitly
+	fields []logger.Field
 }
 
 func getTerminalWidth() int {
@@ -419,29 +413,6 @@ func formatDuration(d time.Duration) string {
 	}
 }
 
-func isSensitiveField(key string) bool {
-	lowerKey := strings.ToLower(key)
-
-	if sensitiveFields[lowerKey] {
-		return true
-	}
-
-	for sensitiveWord := range sensitiveFields {
-		if strings.Contains(lowerKey, sensitiveWord) {
-			return true
-		}
-	}
-
-	return false
-}
-
-func sanitizeValue(value string) string {
-	if len(value) <= sensitiveFieldPrefix {
-		return sanitizationMask
-	}
-	return value[:sensitiveFieldPrefix] + sanitizationMask
-}
-
 func (l *zapLogger) drawRequestBox(timestamp, level, file, service, method, routePath string, statusCode int, duration time.Duration, bodySize int64, message string) string {
 	var result strings.Builder
 
@@ -604,6 +575,21 @@ func toZapLevel(level logger.Level) zapcore.Level {
 	}
 }
 
+func fromZapLevel(level zapcore.Level) logger.Level {
+	switch level {
+	case zapcore.DebugLevel:
+		return logger.DebugLevel
+	case zapcore.InfoLevel:
+		return logger.InfoLevel
+	case zapcore.WarnLevel:
+		return logger.WarnLevel
+	case zapcore.ErrorLevel:
+		return logger.ErrorLevel
+	default:
+		return logger.FatalLevel
+	}
+}
+
 func NewZap(cfg logger.Config) (logger.Logger, error) {
 	var zapCfg zap.Config
 
@@ -634,24 +620,75 @@ func NewZap(cfg logger.Config) (logger.Logger, error) {
 		zapCfg.Encoding = "json"
 	}
 
-	zapCfg.Level = zap.NewAtomicLevelAt(toZapLevel(cfg.Level))
-	zapCfg.OutputPaths = []string{"stdout"}
-	zapCfg.ErrorOutputPaths = []string{"stderr"}
-	zapCfg.DisableCaller = false
-	zapCfg.DisableStacktrace = true
+	level := zap.NewAtomicLevelAt(toZapLevel(cfg.Level))
+	zapCfg.Level = level
 
-	zl, err := zapCfg.Build(zap.AddCallerSkip(callerSkipDefault))
-	if err != nil {
-		return nil, err
+	output := cfg.Output
+	if output == nil && cfg.File == nil {
+		output = os.Stdout
 	}
 
-	return &zapLogger{
+	// Boxes are drawn for the console stream only; files get plain entries
+	// from the zap encoder
+	consoleMode := cfg.Format == logger.FormatText && output != nil
+
+	var sinks []zapcore.WriteSyncer
+	if output != nil && !consoleMode {
+		sinks = append(sinks, zapcore.Lock(zapcore.AddSync(output)))
+	}
+	if cfg.File != nil {
+		sinks = append(sinks, openFileSink(cfg.File))
+	}
+
+	// With no sink left the core still decides level and sampling for the
+	// console boxes
+	sink := zapcore.AddSync(io.Discard)
+	if len(sinks) > 0 {
+		sink = zapcore.NewMultiWriteSyncer(sinks...)
+	}
+
+	var encoder zapcore.Encoder
+	if zapCfg.Encoding == "console" {
+		encoder = zapcore.NewConsoleEncoder(zapCfg.EncoderConfig)
+	} else {
+		encoder = zapcore.NewJSONEncoder(zapCfg.EncoderConfig)
+	}
+
+	opts := []zap.Option{
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+		zap.AddCaller(),
+		zap.AddCallerSkip(callerSkipDefault),
+	}
+	if zapCfg.Development {
+		opts = append(opts, zap.Development())
+	}
+	core := withSampling(zapcore.NewCore(encoder, sink, level), cfg)
+	zl := zap.New(core, opts...)
+
+	l := &zapLogger{
 		logger:      zl,
-		consoleMode: cfg.Format == logger.FormatText,
+		consoleMode: consoleMode,
+		fileCopy:    consoleMode && cfg.File != nil,
 		service:     cfg.Service,
 		color:       pickColor(cfg.Service),
 		termWidth:   getTerminalWidth(),
-	}, nil
+		level:       level,
+		redactor:    logger.NewSanitizer(cfg),
+		out:         output,
+	}
+	if cfg.Async {
+		l.queue = newAsyncQueue(cfg.AsyncBufferSize, cfg.AsyncOverflow == logger.OverflowDrop)
+	}
+	return l, nil
+}
+
+// withSampling wraps core in zap's sampler, keyed by level and message, when
+// cfg enables it
+func withSampling(core zapcore.Core, cfg logger.Config) zapcore.Core {
+	if cfg.SamplingInitial <= 0 {
+		return core
+	}
+	return zapcore.NewSamplerWithOptions(core, samplingTick, cfg.SamplingInitial, cfg.SamplingThereafter)
 }
 
 func (l *zapLogger) makeZapFields(extra []logger.Field) []zap.Field {
@@ -662,14 +699,8 @@ func (l *zapLogger) makeZapFields(extra []logger.Field) []zap.Field {
 		}
 		k := f.Key()
 
-		if isSensitiveField(k) {
-			switch v := f.Value().(type) {
-			case string:
-				zfs = append(zfs, zap.String(k, sanitizeValue(v)))
-			default:
-				strVal := fmt.Sprintf("%v", v)
-				zfs = append(zfs, zap.String(k, sanitizeValue(strVal)))
-			}
+		if l.redactor.IsSensitive(k) {
+			zfs = append(zfs, zap.String(k, l.redactor.Mask(f.Value())))
 			continue
 		}
 
@@ -693,7 +724,7 @@ func (l *zapLogger) makeZapFields(extra []logger.Field) []zap.Field {
 	return zfs
 }
 
-func formatFields(fields []logger.Field, maxWidth int) []string {
+func formatFields(fields []logger.Field, maxWidth int, redactor *logger.Sanitizer) []string {
 	if len(fields) == 0 {
 		return []string{}
 	}
@@ -707,13 +738,8 @@ func formatFields(fields []logger.Field, maxWidth int) []string {
 		k := f.Key()
 		v := f.Value()
 
-		if isSensitiveField(k) {
-			switch val := v.(type) {
-			case string:
-				v = sanitizeValue(val)
-			default:
-				v = sanitizeValue(fmt.Sprintf("%v", val))
-			}
+		if redactor.IsSensitive(k) {
+			v = redactor.Mask(v)
 		}
 
 		if k == "error" {
@@ -831,113 +857,181 @@ func formatFieldLine(fields []string, maxWidth int) string {
 	return result.String()
 }
 
-func (l *zapLogger) formatLog(level string, msg string, fields []logger.Field) string {
-	pc, file, line, _ := runtime.Caller(callerSkipFormatLog)
-	fn := runtime.FuncForPC(pc)
-	funcName := "unknown"
-	if fn != nil {
-		funcName = fn.Name()
-		if lastSlash := strings.LastIndex(funcName, "/"); lastSlash != -1 {
-			funcName = funcName[lastSlash+1:]
-		}
+// printLog writes a boxed console entry. The caller and timestamp are taken
+// here; drawing the box happens on the queue's goroutine in async mode.
+func (l *zapLogger) printLog(level string, msg string, fields []logger.Field) {
+	_, file, line, _ := runtime.Caller(callerSkipFormatLog)
+	fileLoc := callerLocation(file, line)
+	now := time.Now()
+	fields = l.withFields(fields)
+
+	l.print(func() string {
+		return l.renderLog(now, level, fileLoc, msg, fields)
+	})
+}
+
+func (l *zapLogger) renderLog(now time.Time, level, fileLoc, msg string, fields []logger.Field) string {
+	timestamp := now.UTC().Format("2006-01-02 15:04:05.000")
+	dims := calculateStandardBoxDimensions(l.termWidth)
+
+	// Combine message with formatted fields
+	message := msg
+	fieldLines := formatFields(fields, dims.Message.Width, l.redactor)
+	if len(fieldLines) > 0 {
+		message = msg + "\n" + strings.Join(fieldLines, "\n")
 	}
 
+	return l.drawBoxedLog(timestamp, level, fileLoc, l.serviceColumn(), message)
+}
+
+func (l *zapLogger) serviceColumn() string {
+	service := truncateEnd(l.service, serviceColumnWidth)
+	if l.color != "" {
+		service = fmt.Sprintf("%s%s%s", l.color, service, ansiReset)
+	}
+	return padANSI(service, serviceColumnWidth)
+}
+
+func callerLocation(file string, line int) string {
 	parts := strings.Split(file, "/")
 	shortFile := parts[len(parts)-1]
 	fileLoc := fmt.Sprintf("%s:%d", shortFile, line)
 	if len(fileLoc) > fileColumnWidth {
 		fileLoc = truncateStart(fileLoc, fileColumnWidth)
 	}
+	return fileLoc
+}
 
-	service := truncateEnd(l.service, serviceColumnWidth)
-	if l.color != "" {
-		service = fmt.Sprintf("%s%s%s", l.color, service, ansiReset)
+// print writes a rendered console entry, from the queue's goroutine in async mode
+func (l *zapLogger) print(render func() string) {
+	if l.queue == nil {
+		fmt.Fprint(l.out, render())
+		return
 	}
-	service = padANSI(service, serviceColumnWidth)
-
-	timestamp := time.Now().UTC().Format("2006-01-02 15:04:05.000")
-
-	dims := calculateStandardBoxDimensions(l.termWidth)
+	l.queue.enqueue(func() {
+		fmt.Fprint(l.out, render())
+	})
+}
 
-	// Combine message with formatted fields
-	message := msg
-	fieldLines := formatFields(fields, dims.Message.Width)
-	if len(fieldLines) > 0 {
-		message = msg + "\n" + strings.Join(fieldLines, "\n")
+// write finishes a checked zap entry, from the queue's goroutine in async mode.
+// Check runs on the caller's goroutine, so caller and timestamp stay accurate.
+func (l *zapLogger) write(ce *zapcore.CheckedEntry, fields []zap.Field) {
+	if l.queue == nil {
+		ce.Write(fields...)
+		return
 	}
-
-	return l.drawBoxedLog(timestamp, level, fileLoc, service, message)
+	l.queue.enqueue(func() {
+		ce.Write(fields...)
+	})
 }
 
 func (l *zapLogger) Debug(msg string, fields ...logger.Field) {
-	if l.consoleMode && l.logger.Core().Enabled(zapcore.DebugLevel) {
-		fmt.Print(l.formatLog("DEBUG", msg, fields))
+	ce := l.logger.Check(zapcore.DebugLevel, msg)
+	if ce == nil {
 		return
 	}
-	l.logger.Debug(msg, l.makeZapFields(fields)...)
+	if l.consoleMode {
+		l.printLog("DEBUG", msg, fields)
+		if !l.fileCopy {
+			return
+		}
+	}
+	l.write(ce, l.makeZapFields(fields))
 }
 
 func (l *zapLogger) Info(msg string, fields ...logger.Field) {
-	if l.consoleMode && l.logger.Core().Enabled(zapcore.InfoLevel) {
-		fmt.Print(l.formatLog("INFO", msg, fields))
+	ce := l.logger.Check(zapcore.InfoLevel, msg)
+	if ce == nil {
 		return
 	}
-	l.logger.Info(msg, l.makeZapFields(fields)...)
+	if l.consoleMode {
+		l.printLog("INFO", msg, fields)
+		if !l.fileCopy {
+			return
+		}
+	}
+	l.write(ce, l.makeZapFields(fields))
 }
 
 func (l *zapLogger) Warn(msg string, fields ...logger.Field) {
-	if l.consoleMode && l.logger.Core().Enabled(zapcore.WarnLevel) {
-		fmt.Print(l.formatLog("WARN", msg, fields))
+	ce := l.logger.Check(zapcore.WarnLevel, msg)
+	if ce == nil {
 		return
 	}
-	l.logger.Warn(msg, l.makeZapFields(fields)...)
+	if l.consoleMode {
+		l.printLog("WARN", msg, fields)
+		if !l.fileCopy {
+			return
+		}
+	}
+	l.write(ce, l.makeZapFields(fields))
 }
 
 func (l *zapLogger) Error(msg string, fields ...logger.Field) {
-	if l.consoleMode && l.logger.Core().Enabled(zapcore.ErrorLevel) {
-		// Don't pass fields to formatLog since they're already handled there
-		content := msg + "\n" + customStackTrace(callerSkipError, 0)
-		fmt.Print(l.formatLog("ERROR", content, fields))
+	ce := l.logger.Check(zapcore.ErrorLevel, msg)
+	if ce == nil {
 		return
 	}
+	stack := customStackTrace(callerSkipError, 0)
+	if l.consoleMode {
+		// Don't pass fields to printLog since they're already handled there
+		l.printLog("ERROR", msg+"\n"+stack, fields)
+		if !l.fileCopy {
+			return
+		}
+	}
 	zfs := l.makeZapFields(fields)
-
-	zfs = append(zfs, zap.String("stack", customStackTrace(callerSkipError, 0)))
-	l.logger.Error(msg, zfs...)
+	zfs = append(zfs, zap.String("stack", stack))
+	l.write(ce, zfs)
 }
 
 func (l *zapLogger) Fatal(msg string, fields ...logger.Field) {
+	stack := customStackTrace(callerSkipError, 0)
 	if l.consoleMode {
-		// Don't pass fields to formatLog since they're already handled there
-		content := msg + "\n" + customStackTrace(callerSkipError, 0)
-		fmt.Print(l.formatLog("FATAL", content, fields))
-		os.Exit(1)
-		return
+		// Don't pass fields to printLog since they're already handled there
+		l.printLog("FATAL", msg+"\n"+stack, fields)
+		if !l.fileCopy {
+			l.flush()
+			os.Exit(1)
+			return
+		}
 	}
+	l.flush()
 	zfs := l.makeZapFields(fields)
-	zfs = append(zfs, zap.String("stack", customStackTrace(callerSkipError, 0)))
+	zfs = append(zfs, zap.String("stack", stack))
 	l.logger.Fatal(msg, zfs...)
 }
 
 func (l *zapLogger) Request(ctx context.Context, method string, routePath string, statusCode int, duration time.Duration, bodySize int64, msg string, fields ...logger.Field) {
+	ce := l.logger.Check(zapcore.InfoLevel, msg)
+	if ce == nil {
+		return
+	}
 	if l.consoleMode {
-		_, file, line, _ := runtime.Caller(callerSkipDefault)
-
-		parts := strings.Split(file, "/")
-		shortFile := parts[len(parts)-1]
-		fileLoc := fmt.Sprintf("%s:%d", shortFile, line)
-		if len(fileLoc) > fileColumnWidth {
-			fileLoc = truncateStart(fileLoc, fileColumnWidth)
-		}
-
-		service := truncateEnd(l.service, serviceColumnWidth)
-		if l.color != "" {
-			service = fmt.Sprintf("%s%s%s", l.color, service, ansiReset)
+		l.printRequest(method, routePath, statusCode, duration, bodySize, msg, fields)
+		if !l.fileCopy {
+			return
 		}
-		service = padANSI(service, serviceColumnWidth)
+	}
+	zfs := l.makeZapFields(fields)
+	zfs = append(zfs,
+		zap.String("method", method),
+		zap.Int64("duration_ms", duration.Milliseconds()),
+		zap.String("service", l.service),
+	)
+	l.write(ce, zfs)
+}
 
-		timestamp := time.Now().UTC().Format("2006-01-02 15:04:05.000")
+// printRequest writes a boxed request entry; like printLog it takes the caller
+// and timestamp before handing off the drawing
+func (l *zapLogger) printRequest(method string, routePath string, statusCode int, duration time.Duration, bodySize int64, msg string, fields []logger.Field) {
+	_, file, line, _ := runtime.Caller(callerSkipFormatLog)
+	fileLoc := callerLocation(file, line)
+	now := time.Now()
+	fields = l.withFields(fields)
 
+	l.print(func() string {
+		timestamp := now.UTC().Format("2006-01-02 15:04:05.000")
 		dims := calculateRequestBoxDimensions(l.termWidth)
 
 		message := msg
@@ -949,39 +1043,72 @@ func (l *zapLogger) Request(ctx context.Context, method string, routePath string
 				}
 			}
 			if len(extraFields) > 0 {
-				fieldLines := formatFields(extraFields, dims.Message.Width)
+				fieldLines := formatFields(extraFields, dims.Message.Width, l.redactor)
 				if len(fieldLines) > 0 {
 					message = msg + "\n" + strings.Join(fieldLines, "\n")
 				}
 			}
 		}
 
-		fmt.Print(l.drawRequestBox(timestamp, "INFO", fileLoc, service, method, routePath, statusCode, duration, bodySize, message))
-		return
-	}
-	zfs := l.makeZapFields(fields)
-	zfs = append(zfs,
-		zap.String("method", method),
-		zap.Int64("duration_ms", duration.Milliseconds()),
-		zap.String("service", l.service),
-	)
-	l.logger.Info(msg, zfs...)
+		return l.drawRequestBox(timestamp, "INFO", fileLoc, l.serviceColumn(), method, routePath, statusCode, duration, bodySize, message)
+	})
 }
 
 func (l *zapLogger) With(fields ...logger.Field) logger.Logger {
 	return &zapLogger{
 		logger:      l.logger.With(l.makeZapFields(fields)...),
 		consoleMode: l.consoleMode,
+		fileCopy:    l.fileCopy,
 		service:     l.service,
 		color:       l.color,
 		termWidth:   l.termWidth,
+		level:       l.level,
+		redactor:    l.redactor,
+		out:         l.out,
+		queue:       l.queue,
+		fields:      l.withFields(fields),
+	}
+}
+
+// withFields prepends the fields added through With to a call's own fields
+func (l *zapLogger) withFields(fields []logger.Field) []logger.Field {
+	if len(l.fields) == 0 {
+		return fields
 	}
+	merged := make([]logger.Field, 0, len(l.fields)+len(fields))
+	merged = append(merged, l.fields...)
+	return append(merged, fields...)
 }
 
 func (l *zapLogger) WithContext(ctx context.Context) logger.Logger {
-	return l
+	fields := logger.ContextFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
+}
+
+// SetLevel flips the shared atomic level, so the JSON core and the console
+// path, which both consult l.logger.Core().Enabled, pick it up immediately
+func (l *zapLogger) SetLevel(level logger.Level) {
+	l.level.SetLevel(toZapLevel(level))
 }
 
+func (l *zapLogger) Level() logger.Level {
+	return fromZapLevel(l.level.Level())
+}
+
+// Sync writes out everything queued in async mode before syncing zap
 func (l *zapLogger) Sync() error {
+	l.flush()
 	return l.logger.Sync()
 }
+
+func (l *zapLogger) flush() {
+	if l.queue == nil {
+		return
+	}
+	if dropped := l.queue.flush(); dropped > 0 {
+		l.logger.Warn("Dropped log entries while the async buffer was full", zap.Int64("dropped", dropped))
+	}
+}
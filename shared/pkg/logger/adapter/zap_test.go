@@ -0,0 +1,378 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shared/pkg/logger"
+	contextx "shared/server/context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedLogger() (*zapLogger, *observer.ObservedLogs) {
+	return newObservedLoggerWithConfig(logger.Config{})
+}
+
+func newObservedLoggerWithConfig(cfg logger.Config) (*zapLogger, *observer.ObservedLogs) {
+	level := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	core, logs := observer.New(level)
+	l := &zapLogger{
+		logger:   zap.New(withSampling(core, cfg)),
+		service:  "test",
+		level:    level,
+		redactor: logger.NewSanitizer(cfg),
+		out:      io.Discard,
+	}
+	if cfg.Async {
+		l.queue = newAsyncQueue(cfg.AsyncBufferSize, cfg.AsyncOverflow == logger.OverflowDrop)
+	}
+	return l, logs
+}
+
+func TestZapLogger_WithContextAddsContextFields(t *testing.T) {
+	l, logs := newObservedLogger()
+
+	ctx := context.Background()
+	ctx = contextx.WithValue(ctx, contextx.RequestIDKey, "req-1")
+	ctx = contextx.WithValue(ctx, contextx.CorrelationIDKey, "corr-1")
+	ctx = contextx.WithValue(ctx, contextx.UserIDKey, "user-1")
+	ctx = contextx.WithValue(ctx, contextx.SessionIDKey, "session-1")
+	ctx = contextx.WithValue(ctx, contextx.APIVersionKey, "v1")
+
+	ctxLogger := l.WithContext(ctx)
+	ctxLogger.Info("first")
+	ctxLogger.Warn("second", logger.String("extra", "x"))
+
+	want := map[string]string{
+		"request_id":     "req-1",
+		"correlation_id": "corr-1",
+		"user_id":        "user-1",
+		"session_id":     "session-1",
+		"api_version":    "v1",
+	}
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	for _, entry := range entries {
+		fields := entry.ContextMap()
+		for key, value := range want {
+			if fields[key] != value {
+				t.Fatalf("%q: field %s = %v, want %s", entry.Message, key, fields[key], value)
+			}
+		}
+	}
+	if got := entries[1].ContextMap()["extra"]; got != "x" {
+		t.Fatalf("call field extra = %v, want x", got)
+	}
+}
+
+func TestZapLogger_WithContextSkipsMissingValues(t *testing.T) {
+	l, logs := newObservedLogger()
+
+	ctx := contextx.WithValue(context.Background(), contextx.RequestIDKey, "req-1")
+	l.WithContext(ctx).Info("partial")
+
+	fields := logs.All()[0].ContextMap()
+	if len(fields) != 1 || fields["request_id"] != "req-1" {
+		t.Fatalf("fields = %v, want only request_id", fields)
+	}
+
+	if got := l.WithContext(context.Background()); got != logger.Logger(l) {
+		t.Fatal("WithContext on an empty context should return the same logger")
+	}
+}
+
+func TestZapLogger_WithKeepsFieldsForConsoleOutput(t *testing.T) {
+	l, _ := newObservedLogger()
+
+	child := l.With(logger.String("a", "1")).With(logger.String("b", "2")).(*zapLogger)
+	fields := child.withFields([]logger.Field{logger.String("c", "3")})
+
+	keys := make([]string, 0, len(fields))
+	for _, f := range fields {
+		keys = append(keys, f.Key())
+	}
+	if len(keys) != 3 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+		t.Fatalf("fields = %v, want [a b c]", keys)
+	}
+}
+
+func TestZapLogger_SetLevelAppliesToDerivedLoggers(t *testing.T) {
+	l, logs := newObservedLogger()
+	child := l.With(logger.String("component", "child"))
+
+	l.SetLevel(logger.WarnLevel)
+	child.Info("dropped")
+	child.Warn("kept")
+
+	if got := child.Level(); got != logger.WarnLevel {
+		t.Fatalf("child level = %s, want warn", got)
+	}
+	if entries := logs.All(); len(entries) != 1 || entries[0].Message != "kept" {
+		t.Fatalf("entries = %v, want only the warn entry", entries)
+	}
+
+	child.SetLevel(logger.DebugLevel)
+	l.Debug("visible")
+	if logs.Len() != 2 {
+		t.Fatalf("got %d entries after lowering the level, want 2", logs.Len())
+	}
+}
+
+func TestZapLogger_RedactsCustomSensitiveFields(t *testing.T) {
+	l, logs := newObservedLoggerWithConfig(logger.Config{
+		SensitiveFields: []string{"OTP", "ssn"},
+	})
+
+	l.Info("verify",
+		logger.String("otp", "123456"),
+		logger.String("user_ssn", "078-05-1120"),
+		logger.String("password", "hunter2hunter2"),
+		logger.String("name", "alice"),
+	)
+
+	fields := logs.All()[0].ContextMap()
+	want := map[string]string{
+		"otp":      "1234****",
+		"user_ssn": "078-****",
+		"password": "hunt****",
+		"name":     "alice",
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Fatalf("field %s = %v, want %s", key, fields[key], value)
+		}
+	}
+}
+
+func TestZapLogger_RedactExactMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		exactMatch bool
+		key        string
+		masked     bool
+	}{
+		{"substring mode masks exact key", false, "password", true},
+		{"substring mode masks containing key", false, "user_password", true},
+		{"exact mode masks exact key", true, "password", true},
+		{"exact mode keeps containing key", true, "user_password", false},
+		{"exact mode ignores case", true, "PASSWORD", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := newObservedLoggerWithConfig(logger.Config{RedactExactMatch: tt.exactMatch})
+			l.Info("msg", logger.String(tt.key, "plaintext"))
+
+			got := logs.All()[0].ContextMap()[tt.key]
+			if masked := got != "plaintext"; masked != tt.masked {
+				t.Fatalf("%s = %v, masked %v, want masked %v", tt.key, got, masked, tt.masked)
+			}
+		})
+	}
+}
+
+func TestZapLogger_CustomMaskAppliesToConsoleFields(t *testing.T) {
+	r := logger.NewSanitizer(logger.Config{
+		SensitiveFields: []string{"session_token"},
+		Mask:            func(string) string { return "[redacted]" },
+	})
+
+	lines := formatFields([]logger.Field{
+		logger.String("session_token", "abcdef123456"),
+		logger.Int("attempt", 3),
+	}, 200, r)
+
+	out := strings.Join(lines, "\n")
+	if strings.Contains(out, "abcdef") || !strings.Contains(out, "[redacted]") {
+		t.Fatalf("console fields = %q, want the token replaced by the custom mask", out)
+	}
+	if !strings.Contains(out, "3") {
+		t.Fatalf("console fields = %q, want the non-sensitive field kept", out)
+	}
+}
+
+func TestZapLogger_AsyncKeepsOrderAndSyncFlushes(t *testing.T) {
+	l, logs := newObservedLoggerWithConfig(logger.Config{Async: true, AsyncBufferSize: 4})
+
+	child := l.With(logger.String("component", "worker"))
+	for i := 0; i < 100; i++ {
+		child.Info(fmt.Sprintf("entry %d", i))
+	}
+	if err := l.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	entries := logs.All()
+	if len(entries) != 100 {
+		t.Fatalf("got %d entries after Sync, want 100", len(entries))
+	}
+	for i, entry := range entries {
+		if want := fmt.Sprintf("entry %d", i); entry.Message != want {
+			t.Fatalf("entry %d = %q, want %q", i, entry.Message, want)
+		}
+	}
+}
+
+func TestZapLogger_AsyncDropReportsDroppedEntries(t *testing.T) {
+	l, logs := newObservedLoggerWithConfig(logger.Config{
+		Async:           true,
+		AsyncBufferSize: 2,
+		AsyncOverflow:   logger.OverflowDrop,
+	})
+
+	// Hold the writer goroutine so the buffer fills up
+	gate := make(chan struct{})
+	started := make(chan struct{})
+	l.queue.enqueue(func() {
+		close(started)
+		<-gate
+	})
+	<-started
+
+	for i := 0; i < 5; i++ {
+		l.Info(fmt.Sprintf("entry %d", i))
+	}
+	close(gate)
+	if err := l.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 2 kept and 1 drop report", len(entries))
+	}
+	if entries[0].Message != "entry 0" || entries[1].Message != "entry 1" {
+		t.Fatalf("kept entries = %q, %q, want the first two", entries[0].Message, entries[1].Message)
+	}
+	if got := entries[2].ContextMap()["dropped"]; got != int64(3) {
+		t.Fatalf("dropped = %v, want 3", got)
+	}
+}
+
+func benchmarkConsoleLogger(b *testing.B, cfg logger.Config) {
+	l, _ := newObservedLoggerWithConfig(cfg)
+	l.consoleMode = true
+	l.termWidth = 120
+	b.Cleanup(func() { l.Sync() })
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info("request handled", logger.String("path", "/api/v1/users"), logger.Int("status", 200))
+	}
+}
+
+func BenchmarkZapLogger_ConsoleSync(b *testing.B) {
+	benchmarkConsoleLogger(b, logger.Config{})
+}
+
+func BenchmarkZapLogger_ConsoleAsync(b *testing.B) {
+	benchmarkConsoleLogger(b, logger.Config{Async: true, AsyncOverflow: logger.OverflowDrop})
+}
+
+func TestZapLogger_SamplingBoundsRepeatedEntries(t *testing.T) {
+	cfg := logger.Config{SamplingInitial: 5, SamplingThereafter: 10}
+	l, logs := newObservedLoggerWithConfig(cfg)
+
+	for i := 0; i < 100; i++ {
+		l.Info("request completed")
+	}
+	l.Warn("request completed")
+
+	// 5 initial entries, then every 10th of the remaining 95
+	if got := logs.FilterMessage("request completed").FilterLevelExact(zapcore.InfoLevel).Len(); got != 14 {
+		t.Fatalf("got %d info entries, want 14", got)
+	}
+	if got := logs.FilterLevelExact(zapcore.WarnLevel).Len(); got != 1 {
+		t.Fatalf("got %d warn entries, want 1 since sampling is keyed by level", got)
+	}
+}
+
+func TestZapLogger_SamplingAppliesToConsoleOutput(t *testing.T) {
+	cfg := logger.Config{SamplingInitial: 5, SamplingThereafter: 10}
+	l, _ := newObservedLoggerWithConfig(cfg)
+	var out bytes.Buffer
+	l.out = &out
+	l.consoleMode = true
+	l.termWidth = 120
+
+	for i := 0; i < 100; i++ {
+		l.Info("request completed")
+	}
+
+	if got := strings.Count(out.String(), "request completed"); got != 14 {
+		t.Fatalf("got %d console entries, want 14", got)
+	}
+}
+
+func TestNewZap_TextWritesBoxesToOutputAndPlainLinesToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	var out bytes.Buffer
+	l, err := NewZap(logger.Config{
+		Level:   logger.InfoLevel,
+		Format:  logger.FormatText,
+		Output:  &out,
+		File:    &logger.FileConfig{Path: path, MaxSize: 1},
+		Service: "test",
+	})
+	if err != nil {
+		t.Fatalf("NewZap() error = %v", err)
+	}
+
+	l.Info("user signed in", logger.String("user_id", "u-1"))
+	l.Sync()
+
+	if !strings.Contains(out.String(), "│") || !strings.Contains(out.String(), "user signed in") {
+		t.Fatalf("console output = %q, want a boxed entry", out.String())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read log file: %v", err)
+	}
+	file := string(data)
+	if !strings.Contains(file, "user signed in") || !strings.Contains(file, "u-1") {
+		t.Fatalf("log file = %q, want the entry and its fields", file)
+	}
+	if strings.Contains(file, "│") {
+		t.Fatalf("log file = %q, want no boxes", file)
+	}
+}
+
+func TestNewZap_JSONWritesToFileOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	l, err := NewZap(logger.Config{
+		Level:   logger.InfoLevel,
+		Format:  logger.FormatJSON,
+		File:    &logger.FileConfig{Path: path, MaxSize: 1},
+		Service: "test",
+	})
+	if err != nil {
+		t.Fatalf("NewZap() error = %v", err)
+	}
+	if l.(*zapLogger).out != nil {
+		t.Fatal("out is set, want no console stream when only a file is configured")
+	}
+
+	l.Info("stored")
+	l.Sync()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read log file: %v", err)
+	}
+	if !strings.Contains(string(data), `"message":"stored"`) {
+		t.Fatalf("log file = %q, want a JSON entry", data)
+	}
+}
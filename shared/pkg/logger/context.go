@@ -2,12 +2,23 @@ package logger
 
 import (
 	"context"
+	"sync"
 )
 
 type contextKey struct{}
 
 var loggerKey = contextKey{}
 
+// ContextExtractor returns the log fields carried by a context. Packages that
+// own context keys register one, so WithContext can pick their values up
+// without this package importing them.
+type ContextExtractor func(ctx context.Context) []Field
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
 func FromContext(ctx context.Context) Logger {
 	if logger, ok := ctx.Value(loggerKey).(Logger); ok {
 		return logger
@@ -18,3 +29,26 @@ func FromContext(ctx context.Context) Logger {
 func WithContext(ctx context.Context, logger Logger) context.Context {
 	return context.WithValue(ctx, loggerKey, logger)
 }
+
+// RegisterContextExtractor adds an extractor consulted by Logger.WithContext
+func RegisterContextExtractor(extractor ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, extractor)
+}
+
+// ContextFields collects the fields every registered extractor finds in ctx
+func ContextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+
+	var fields []Field
+	for _, extract := range extractors {
+		fields = append(fields, extract(ctx)...)
+	}
+	return fields
+}
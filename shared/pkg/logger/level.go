@@ -1,7 +1,9 @@
 package logger
 
 import (
+	"io"
 	"os"
+	"strconv"
 )
 
 type Level int
@@ -24,15 +26,46 @@ func GetLoggerFormat() Format {
 	return ParseFormat(formatStr)
 }
 
-func GetLoggerOutput() *os.File {
-	output := os.Getenv("LOG_OUTPUT")
-	if output == "stderr" {
+// GetLoggerOutput returns the console stream for LOG_OUTPUT, or nil when it
+// asks for the log file only
+func GetLoggerOutput() io.Writer {
+	switch os.Getenv("LOG_OUTPUT") {
+	case "stderr":
 		return os.Stderr
+	case "file":
+		if os.Getenv("LOG_FILE_PATH") != "" {
+			return nil
+		}
 	}
 	// Default to stdout
 	return os.Stdout
 }
 
+// GetLoggerFile returns the rotating file settings when LOG_OUTPUT is "file" or
+// "both" and LOG_FILE_PATH is set
+func GetLoggerFile() *FileConfig {
+	output := os.Getenv("LOG_OUTPUT")
+	path := os.Getenv("LOG_FILE_PATH")
+	if (output != "file" && output != "both") || path == "" {
+		return nil
+	}
+	return &FileConfig{
+		Path:       path,
+		MaxSize:    envInt("LOG_FILE_MAX_SIZE", 100),
+		MaxBackups: envInt("LOG_FILE_MAX_BACKUPS", 3),
+		MaxAge:     envInt("LOG_FILE_MAX_AGE", 28),
+		Compress:   os.Getenv("LOG_FILE_COMPRESS") != "false",
+	}
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
 func GetLoggerTimeFormat() string {
 	timeFormat := os.Getenv("LOG_TIME_FORMAT")
 	if timeFormat == "" {
@@ -59,19 +92,28 @@ func (l Level) String() string {
 }
 
 func ParseLevel(s string) Level {
+	if level, ok := LookupLevel(s); ok {
+		return level
+	}
+	return InfoLevel
+}
+
+// LookupLevel parses a level name, reporting false for names ParseLevel
+// would silently turn into InfoLevel
+func LookupLevel(s string) (Level, bool) {
 	switch s {
 	case "debug":
-		return DebugLevel
+		return DebugLevel, true
 	case "info":
-		return InfoLevel
+		return InfoLevel, true
 	case "warn", "warning":
-		return WarnLevel
+		return WarnLevel, true
 	case "error":
-		return ErrorLevel
+		return ErrorLevel, true
 	case "fatal":
-		return FatalLevel
+		return FatalLevel, true
 	default:
-		return InfoLevel
+		return InfoLevel, false
 	}
 }
 
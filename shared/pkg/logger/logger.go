@@ -21,6 +21,11 @@ type Logger interface {
 	With(fields ...Field) Logger
 	WithContext(ctx context.Context) Logger
 
+	// SetLevel changes the minimum level logged from now on, by this logger
+	// and every logger derived from it through With or WithContext
+	SetLevel(level Level)
+	Level() Level
+
 	Sync() error
 }
 
@@ -91,13 +96,65 @@ func Error(err error) Field {
 }
 
 type Config struct {
-	Level      Level
+	Level Level
+	// Output is the console stream; stdout when both Output and File are unset
 	Output     io.Writer
 	Format     Format
 	TimeFormat string
 	Service    string
+	// File adds a rotating log file, next to Output when that is set too. Text
+	// format writes plain lines to it; boxes are only drawn on Output.
+	File *FileConfig
+
+	// SensitiveFields are masked in addition to the built-in set (password,
+	// token, secret, ...). Matching is case-insensitive.
+	SensitiveFields []string
+	// RedactExactMatch masks only keys equal to a sensitive field; by default
+	// any key containing one, like user_password, is masked too
+	RedactExactMatch bool
+	// Mask replaces the value of a sensitive field. The default keeps the
+	// first four characters and masks the rest.
+	Mask func(value string) string
+
+	// Async hands entries to a background goroutine so callers don't wait on
+	// formatting or output. Entries from one logger keep their order and Sync
+	// flushes everything queued.
+	Async bool
+	// AsyncBufferSize is the number of entries async mode queues; 1024 when unset
+	AsyncBufferSize int
+	// AsyncOverflow decides what a caller does when the async queue is full
+	AsyncOverflow OverflowPolicy
+
+	// SamplingInitial is how many entries with the same level and message are
+	// logged each second before sampling starts; zero turns sampling off
+	SamplingInitial int
+	// SamplingThereafter logs every Mth entry once SamplingInitial is reached;
+	// zero drops the rest of the second
+	SamplingThereafter int
+}
+
+// FileConfig describes a size rotated log file
+type FileConfig struct {
+	Path string
+	// MaxSize is the size in megabytes a file may reach before it's rotated
+	MaxSize int
+	// MaxBackups is how many rotated files to keep; zero keeps all of them
+	MaxBackups int
+	// MaxAge is how many days to keep rotated files; zero keeps them forever
+	MaxAge   int
+	Compress bool
 }
 
+// OverflowPolicy decides what async logging does when its buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes the caller wait for room, so no entry is lost
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDrop discards the entry; Sync reports how many were dropped
+	OverflowDrop
+)
+
 type Format string
 
 const (
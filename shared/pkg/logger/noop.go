@@ -27,6 +27,12 @@ func (l *noopLogger) WithContext(ctx context.Context) Logger {
 	return l
 }
 
+func (l *noopLogger) SetLevel(level Level) {}
+
+func (l *noopLogger) Level() Level {
+	return InfoLevel
+}
+
 func (l *noopLogger) Sync() error {
 	return nil
 }
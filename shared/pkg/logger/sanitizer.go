@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	sensitiveFieldPrefix = 4
+	sanitizationMask     = "****"
+)
+
+var defaultSensitiveFields = []string{
+	"password",
+	"token",
+	"secret",
+	"api_key",
+	"apikey",
+	"access_token",
+	"refresh_token",
+	"bearer",
+	"authorization",
+	"auth",
+	"credential",
+	"credentials",
+	"private_key",
+	"privatekey",
+}
+
+// Sanitizer masks the values of fields whose keys look sensitive. Loggers
+// apply it to every field; other code that logs payloads can reuse it so the
+// same keys are hidden everywhere.
+type Sanitizer struct {
+	fields     map[string]bool
+	exactMatch bool
+	mask       func(value string) string
+}
+
+// NewSanitizer builds a sanitizer from the redaction settings in cfg
+func NewSanitizer(cfg Config) *Sanitizer {
+	fields := make(map[string]bool, len(defaultSensitiveFields)+len(cfg.SensitiveFields))
+	for _, field := range defaultSensitiveFields {
+		fields[field] = true
+	}
+	for _, field := range cfg.SensitiveFields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			fields[field] = true
+		}
+	}
+
+	mask := cfg.Mask
+	if mask == nil {
+		mask = MaskValue
+	}
+
+	return &Sanitizer{
+		fields:     fields,
+		exactMatch: cfg.RedactExactMatch,
+		mask:       mask,
+	}
+}
+
+// IsSensitive reports whether values under key must be masked
+func (s *Sanitizer) IsSensitive(key string) bool {
+	lowerKey := strings.ToLower(key)
+
+	if s.fields[lowerKey] {
+		return true
+	}
+	if s.exactMatch {
+		return false
+	}
+
+	for sensitiveWord := range s.fields {
+		if strings.Contains(lowerKey, sensitiveWord) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Mask returns the masked form of value
+func (s *Sanitizer) Mask(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return s.mask(str)
+	}
+	return s.mask(fmt.Sprintf("%v", value))
+}
+
+// MaskValue keeps the first four characters of value and masks the rest
+func MaskValue(value string) string {
+	if len(value) <= sensitiveFieldPrefix {
+		return sanitizationMask
+	}
+	return value[:sensitiveFieldPrefix] + sanitizationMask
+}
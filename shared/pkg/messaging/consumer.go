@@ -0,0 +1,51 @@
+package messaging
+
+import (
+	"shared/pkg/logger"
+	"shared/pkg/monitoring/metrics"
+)
+
+const (
+	OffsetNewest = "newest"
+	OffsetOldest = "oldest"
+)
+
+const DefaultDeadLetterSuffix = ".dlq"
+
+// Headers added to a dead-lettered message, next to its original headers
+const (
+	HeaderDLQOriginalTopic = "x-dlq-original-topic"
+	HeaderDLQPartition     = "x-dlq-partition"
+	HeaderDLQOffset        = "x-dlq-offset"
+	HeaderDLQError         = "x-dlq-error"
+	HeaderDLQAttempts      = "x-dlq-attempts"
+	HeaderDLQFailedAt      = "x-dlq-failed-at"
+)
+
+type ConsumerConfig struct {
+	Brokers  []string
+	ClientID string
+	GroupID  string
+	// InitialOffset is where a group without committed offsets starts:
+	// OffsetNewest (default) or OffsetOldest
+	InitialOffset string
+	// MaxRetries is how many times a failed message is retried. Without a
+	// dead-letter queue the partition is then released so the message is
+	// redelivered from the last commit.
+	MaxRetries int
+	// RetryBackoff, SessionTimeout and HeartbeatInterval are in milliseconds
+	RetryBackoff      int
+	SessionTimeout    int
+	HeartbeatInterval int
+	Logger            logger.Logger
+
+	// DeadLetterEnabled publishes messages that exhaust MaxRetries to
+	// <topic><DeadLetterSuffix> and commits them so the partition advances
+	DeadLetterEnabled bool
+	DeadLetterSuffix  string
+	// DeadLetterProducer overrides the producer the consumer would otherwise
+	// create for the dead-letter topics; it is not closed by the consumer
+	DeadLetterProducer Producer
+	// DeadLetterCounter counts dead-lettered messages by original topic
+	DeadLetterCounter metrics.Counter
+}
@@ -16,14 +16,34 @@ type Broker interface {
 type Producer interface {
 	Send(ctx context.Context, topic string, message *Message) pkgErrors.AppError
 	SendBatch(ctx context.Context, topic string, messages []*Message) pkgErrors.AppError
+	// ProduceWithKey sends value keyed by key, so all events sharing a key
+	// land on the same partition and keep their order
+	ProduceWithKey(ctx context.Context, topic, key string, value []byte, opts ...ProduceOption) pkgErrors.AppError
+	// Flush blocks until every in-flight send has completed or ctx is done
+	Flush(ctx context.Context) error
 	Close() error
 }
 
+// Consumer delivers messages at least once: an offset is committed only after
+// the handler returns nil for it.
 type Consumer interface {
-	Consume(ctx context.Context, topics []string, handler Handler) pkgErrors.AppError
+	Subscribe(ctx context.Context, topics []string, handler HandlerFunc) pkgErrors.AppError
 	Close() error
 }
 
+// BrokerStatus is the cluster view returned by a health ping
+type BrokerStatus struct {
+	Brokers   int
+	Reachable int
+	Topics    int
+}
+
+// Pinger is implemented by producers and consumers that can verify they
+// still reach the brokers, for health checks
+type Pinger interface {
+	Ping(ctx context.Context) (BrokerStatus, error)
+}
+
 type Handler interface {
 	Handle(ctx context.Context, message *Message) error
 }
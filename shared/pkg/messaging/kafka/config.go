@@ -15,3 +15,16 @@ func DefaultConfig() messaging.Config {
 		HeartbeatInterval: 3000,
 	}
 }
+
+func DefaultConsumerConfig() messaging.ConsumerConfig {
+	return messaging.ConsumerConfig{
+		Brokers:           []string{"localhost:9092"},
+		ClientID:          "default-client",
+		GroupID:           "default-group",
+		InitialOffset:     messaging.OffsetNewest,
+		MaxRetries:        3,
+		RetryBackoff:      100,
+		SessionTimeout:    10000,
+		HeartbeatInterval: 3000,
+	}
+}
@@ -2,71 +2,224 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/IBM/sarama"
 
 	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
 	"shared/pkg/messaging"
+	"shared/pkg/monitoring/metrics"
+	"shared/pkg/monitoring/metrics/prometheus"
 )
 
+var (
+	deadLetterCounterOnce sync.Once
+	deadLetterCounter     metrics.Counter
+)
+
+func defaultDeadLetterCounter() metrics.Counter {
+	deadLetterCounterOnce.Do(func() {
+		deadLetterCounter = prometheus.NewCounter("echo", "kafka", "consumer_dead_lettered_total",
+			"Messages moved to a dead-letter topic after exhausting retries.", []string{"topic"})
+	})
+	return deadLetterCounter
+}
+
+// consumer is a consumer-group member with at-least-once delivery. Offsets
+// are committed manually after the handler succeeds. A message that keeps
+// failing is moved to the dead-letter topic when one is configured, otherwise
+// it is never committed and is redelivered after the next rebalance.
 type consumer struct {
 	group   sarama.ConsumerGroup
-	handler messaging.Handler
-	wg      sync.WaitGroup
+	client  sarama.Client
+	cfg     messaging.ConsumerConfig
+	log     logger.Logger
+	handler messaging.HandlerFunc
+
+	dlq        messaging.Producer
+	ownsDLQ    bool
+	dlqCounter metrics.Counter
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+	errLoop sync.WaitGroup
 }
 
-func NewConsumer(cfg messaging.Config) (messaging.Consumer, error) {
+func NewConsumer(cfg messaging.ConsumerConfig) (messaging.Consumer, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("kafka consumer requires at least one broker")
+	}
+	if cfg.GroupID == "" {
+		return nil, errors.New("kafka consumer requires a group id")
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logger.NewNoop()
+	}
+
 	config := sarama.NewConfig()
 	config.Version = sarama.V3_0_0_0
 	config.ClientID = cfg.ClientID
 	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
 	config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	if cfg.InitialOffset == messaging.OffsetOldest {
+		config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	}
+	config.Consumer.Offsets.AutoCommit.Enable = false
 	config.Consumer.Return.Errors = true
+	if cfg.SessionTimeout > 0 {
+		config.Consumer.Group.Session.Timeout = time.Duration(cfg.SessionTimeout) * time.Millisecond
+	}
+	if cfg.HeartbeatInterval > 0 {
+		config.Consumer.Group.Heartbeat.Interval = time.Duration(cfg.HeartbeatInterval) * time.Millisecond
+	}
+
+	c := &consumer{cfg: cfg, log: cfg.Logger}
+	if cfg.DeadLetterEnabled {
+		if err := c.setupDeadLetter(); err != nil {
+			return nil, err
+		}
+	}
 
-	group, err := sarama.NewConsumerGroup(cfg.Brokers, cfg.GroupID, config)
+	client, err := sarama.NewClient(cfg.Brokers, config)
 	if err != nil {
+		if c.ownsDLQ {
+			_ = c.dlq.Close()
+		}
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+	group, err := sarama.NewConsumerGroupFromClient(cfg.GroupID, client)
+	if err != nil {
+		_ = client.Close()
+		if c.ownsDLQ {
+			_ = c.dlq.Close()
+		}
 		return nil, fmt.Errorf("failed to create kafka consumer group: %w", err)
 	}
+	c.client = client
+	c.group = group
+	return c, nil
+}
 
-	return &consumer{
-		group: group,
-	}, nil
+func (c *consumer) setupDeadLetter() error {
+	if c.cfg.DeadLetterSuffix == "" {
+		c.cfg.DeadLetterSuffix = messaging.DefaultDeadLetterSuffix
+	}
+	c.dlqCounter = c.cfg.DeadLetterCounter
+	if c.dlqCounter == nil {
+		c.dlqCounter = defaultDeadLetterCounter()
+	}
+	c.dlq = c.cfg.DeadLetterProducer
+	if c.dlq != nil {
+		return nil
+	}
+	producer, err := NewProducer(messaging.Config{
+		Brokers:    c.cfg.Brokers,
+		ClientID:   c.cfg.ClientID,
+		MaxRetries: 3,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create dead-letter producer: %w", err)
+	}
+	c.dlq = producer
+	c.ownsDLQ = true
+	return nil
 }
 
-func (c *consumer) Consume(ctx context.Context, topics []string, handler messaging.Handler) pkgErrors.AppError {
+// Subscribe joins the group for topics and processes messages in the
+// background until ctx is cancelled or Close is called.
+func (c *consumer) Subscribe(ctx context.Context, topics []string, handler messaging.HandlerFunc) pkgErrors.AppError {
+	if len(topics) == 0 || handler == nil {
+		return pkgErrors.New(pkgErrors.CodeInvalidArgument, "topics and handler are required").
+			WithService("kafka-consumer")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		return pkgErrors.New(pkgErrors.CodeConflict, "consumer is already subscribed").
+			WithService("kafka-consumer")
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
 	c.handler = handler
 
-	c.wg.Add(1)
+	c.running.Add(1)
 	go func() {
-		defer c.wg.Done()
+		defer c.running.Done()
 		for {
-			if err := c.group.Consume(ctx, topics, c); err != nil {
-				fmt.Printf("Consumer error: %v\n", err)
+			if err := c.group.Consume(runCtx, topics, c); err != nil {
+				if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+					return
+				}
+				c.log.Warn("Kafka consumer session ended with error",
+					logger.String("group_id", c.cfg.GroupID),
+					logger.Error(err),
+				)
+				if !c.wait(runCtx, c.retryBackoff()) {
+					return
+				}
 			}
-
-			if ctx.Err() != nil {
+			if runCtx.Err() != nil {
 				return
 			}
 		}
 	}()
 
-	c.wg.Add(1)
+	c.errLoop.Add(1)
 	go func() {
-		defer c.wg.Done()
+		defer c.errLoop.Done()
 		for err := range c.group.Errors() {
-			fmt.Printf("Consumer group error: %v\n", err)
+			c.log.Error("Kafka consumer group error",
+				logger.String("group_id", c.cfg.GroupID),
+				logger.Error(err),
+			)
 		}
 	}()
 
+	c.log.Info("Kafka consumer subscribed",
+		logger.String("group_id", c.cfg.GroupID),
+		logger.Any("topics", topics),
+	)
 	return nil
 }
 
+// Close stops fetching, waits for in-flight handlers to finish and commit,
+// then leaves the group.
 func (c *consumer) Close() error {
-	c.wg.Wait()
-	return c.group.Close()
+	c.mu.Lock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.mu.Unlock()
+
+	c.running.Wait()
+	err := c.group.Close()
+	c.errLoop.Wait()
+	if c.client != nil {
+		if clientErr := c.client.Close(); err == nil && !errors.Is(clientErr, sarama.ErrClosedClient) {
+			err = clientErr
+		}
+	}
+	if c.ownsDLQ {
+		if dlqErr := c.dlq.Close(); err == nil {
+			err = dlqErr
+		}
+	}
+	return err
+}
+
+// Ping refreshes cluster metadata and checks every known broker
+func (c *consumer) Ping(ctx context.Context) (messaging.BrokerStatus, error) {
+	return pingCluster(ctx, c.client)
 }
+
 func (c *consumer) Setup(sarama.ConsumerGroupSession) error {
 	return nil
 }
@@ -76,29 +229,123 @@ func (c *consumer) Cleanup(sarama.ConsumerGroupSession) error {
 }
 
 func (c *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for message := range claim.Messages() {
-		msg := &messaging.Message{
-			Key:       message.Key,
-			Value:     message.Value,
-			Topic:     message.Topic,
-			Partition: message.Partition,
-			Offset:    message.Offset,
-			Timestamp: message.Timestamp,
-			Headers:   make(map[string]string),
-			Metadata:  make(map[string]interface{}),
+	for {
+		select {
+		case <-session.Context().Done():
+			return nil
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if err := c.process(session, message); err != nil {
+				return err
+			}
 		}
+	}
+}
 
-		for _, header := range message.Headers {
-			msg.Headers[string(header.Key)] = string(header.Value)
-		}
+// process runs the handler with retries and commits the offset once it
+// succeeds. Handlers get a context that survives session shutdown so a
+// message being handled when Close is called still completes.
+func (c *consumer) process(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage) error {
+	msg := toMessage(message)
+	ctx := context.WithoutCancel(session.Context())
 
-		if err := c.handler.Handle(session.Context(), msg); err != nil {
-			fmt.Printf("Handler error: %v\n", err)
-			continue
+	var err error
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 && !c.wait(session.Context(), c.retryBackoff()) {
+			return nil
 		}
+		if err = c.handler(ctx, msg); err == nil {
+			session.MarkMessage(message, "")
+			session.Commit()
+			return nil
+		}
+		c.log.Warn("Kafka message handler failed",
+			logger.String("topic", message.Topic),
+			logger.Int("partition", int(message.Partition)),
+			logger.Int64("offset", message.Offset),
+			logger.Int("attempt", attempt+1),
+			logger.Error(err),
+		)
+	}
 
+	attempts := c.cfg.MaxRetries + 1
+	if c.dlq != nil {
+		if dlqErr := c.deadLetter(ctx, message, msg, err, attempts); dlqErr != nil {
+			return fmt.Errorf("dead-letter %s/%d at offset %d: %w", message.Topic, message.Partition, message.Offset, dlqErr)
+		}
 		session.MarkMessage(message, "")
+		session.Commit()
+		return nil
+	}
+
+	return fmt.Errorf("handler failed for %s/%d at offset %d after %d attempts: %w",
+		message.Topic, message.Partition, message.Offset, attempts, err)
+}
+
+// deadLetter publishes the original key, value and headers to the topic's
+// dead-letter topic, annotated with where the message came from and why it
+// failed.
+func (c *consumer) deadLetter(ctx context.Context, message *sarama.ConsumerMessage, msg *messaging.Message, cause error, attempts int) error {
+	topic := message.Topic + c.cfg.DeadLetterSuffix
+	dead := messaging.NewMessage(message.Value).WithKey(message.Key)
+	for k, v := range msg.Headers {
+		dead.WithHeader(k, v)
 	}
+	dead.WithHeader(messaging.HeaderDLQOriginalTopic, message.Topic).
+		WithHeader(messaging.HeaderDLQPartition, strconv.FormatInt(int64(message.Partition), 10)).
+		WithHeader(messaging.HeaderDLQOffset, strconv.FormatInt(message.Offset, 10)).
+		WithHeader(messaging.HeaderDLQError, cause.Error()).
+		WithHeader(messaging.HeaderDLQAttempts, strconv.Itoa(attempts)).
+		WithHeader(messaging.HeaderDLQFailedAt, time.Now().UTC().Format(time.RFC3339Nano))
 
+	if err := c.dlq.Send(ctx, topic, dead); err != nil {
+		return err
+	}
+	c.dlqCounter.Inc(map[string]string{"topic": message.Topic})
+	c.log.Error("Kafka message moved to dead-letter topic",
+		logger.String("topic", message.Topic),
+		logger.String("dlq_topic", topic),
+		logger.Int("partition", int(message.Partition)),
+		logger.Int64("offset", message.Offset),
+		logger.Int("attempts", attempts),
+		logger.Error(cause),
+	)
 	return nil
 }
+
+func (c *consumer) retryBackoff() time.Duration {
+	if c.cfg.RetryBackoff <= 0 {
+		return 100 * time.Millisecond
+	}
+	return time.Duration(c.cfg.RetryBackoff) * time.Millisecond
+}
+
+func (c *consumer) wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func toMessage(message *sarama.ConsumerMessage) *messaging.Message {
+	msg := &messaging.Message{
+		Key:       message.Key,
+		Value:     message.Value,
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+		Timestamp: message.Timestamp,
+		Headers:   make(map[string]string, len(message.Headers)),
+		Metadata:  make(map[string]interface{}),
+	}
+	for _, header := range message.Headers {
+		msg.Headers[string(header.Key)] = string(header.Value)
+	}
+	return msg
+}
@@ -0,0 +1,194 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+
+	pkgErrors "shared/pkg/errors"
+	"shared/pkg/logger"
+	"shared/pkg/messaging"
+)
+
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	ctx     context.Context
+	marked  []int64
+	commits int
+}
+
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+func (s *fakeSession) Commit() { s.commits++ }
+
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func newFakeClaim(offsets ...int64) *fakeClaim {
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, len(offsets))}
+	for _, offset := range offsets {
+		claim.messages <- &sarama.ConsumerMessage{
+			Topic:   "notifications",
+			Offset:  offset,
+			Value:   []byte("payload"),
+			Headers: []*sarama.RecordHeader{{Key: []byte("type"), Value: []byte("notification")}},
+		}
+	}
+	close(claim.messages)
+	return claim
+}
+
+type fakeProducer struct {
+	messaging.Producer
+	topics []string
+	sent   []*messaging.Message
+}
+
+func (p *fakeProducer) Send(ctx context.Context, topic string, message *messaging.Message) pkgErrors.AppError {
+	p.topics = append(p.topics, topic)
+	p.sent = append(p.sent, message)
+	return nil
+}
+
+type fakeCounter struct {
+	counts map[string]int
+}
+
+func (c *fakeCounter) Inc(labels map[string]string) { c.counts[labels["topic"]]++ }
+
+func (c *fakeCounter) Add(value float64, labels map[string]string) {
+	c.counts[labels["topic"]] += int(value)
+}
+
+func newTestConsumer(maxRetries int, handler messaging.HandlerFunc) *consumer {
+	return &consumer{
+		cfg:     messaging.ConsumerConfig{MaxRetries: maxRetries, RetryBackoff: 1},
+		log:     logger.NewNoop(),
+		handler: handler,
+	}
+}
+
+func TestConsumeClaim_CommitsAfterSuccessfulRetry(t *testing.T) {
+	calls := 0
+	c := newTestConsumer(2, func(ctx context.Context, msg *messaging.Message) error {
+		calls++
+		if msg.Headers["type"] != "notification" {
+			t.Fatalf("headers not copied: %v", msg.Headers)
+		}
+		if msg.Offset == 1 && calls == 1 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	session := &fakeSession{ctx: context.Background()}
+
+	if err := c.ConsumeClaim(session, newFakeClaim(1, 2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 handler calls, got %d", calls)
+	}
+	if len(session.marked) != 2 || session.marked[0] != 1 || session.marked[1] != 2 {
+		t.Fatalf("expected offsets 1 and 2 marked in order, got %v", session.marked)
+	}
+	if session.commits != 2 {
+		t.Fatalf("expected a commit per message, got %d", session.commits)
+	}
+}
+
+func TestConsumeClaim_FailingMessageIsNotCommitted(t *testing.T) {
+	calls := 0
+	c := newTestConsumer(1, func(ctx context.Context, msg *messaging.Message) error {
+		calls++
+		return errors.New("permanent")
+	})
+	session := &fakeSession{ctx: context.Background()}
+
+	if err := c.ConsumeClaim(session, newFakeClaim(7, 8)); err == nil {
+		t.Fatalf("expected claim to stop with an error")
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 attempts on the failing message only, got %d", calls)
+	}
+	if len(session.marked) != 0 || session.commits != 0 {
+		t.Fatalf("failing message must not be committed: marked=%v commits=%d", session.marked, session.commits)
+	}
+}
+
+func TestConsumeClaim_StopsFetchingWhenSessionEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	c := newTestConsumer(0, func(ctx context.Context, msg *messaging.Message) error {
+		calls++
+		return nil
+	})
+
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage)}
+	if err := c.ConsumeClaim(&fakeSession{ctx: ctx}, claim); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("expected no messages handled after shutdown, got %d", calls)
+	}
+}
+
+func TestConsumeClaim_DeadLettersAfterRetryBudget(t *testing.T) {
+	calls := 0
+	c := newTestConsumer(2, func(ctx context.Context, msg *messaging.Message) error {
+		calls++
+		return errors.New("poison payload")
+	})
+	producer := &fakeProducer{}
+	counter := &fakeCounter{counts: make(map[string]int)}
+	c.cfg.DeadLetterEnabled = true
+	c.cfg.DeadLetterProducer = producer
+	c.cfg.DeadLetterCounter = counter
+	if err := c.setupDeadLetter(); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	session := &fakeSession{ctx: context.Background()}
+
+	if err := c.ConsumeClaim(session, newFakeClaim(42)); err != nil {
+		t.Fatalf("dead-lettered message should not stop the claim: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts before dead-lettering, got %d", calls)
+	}
+	if len(producer.sent) != 1 || producer.topics[0] != "notifications.dlq" {
+		t.Fatalf("expected one message on notifications.dlq, got %v", producer.topics)
+	}
+	headers := producer.sent[0].Headers
+	expected := map[string]string{
+		messaging.HeaderDLQOriginalTopic: "notifications",
+		messaging.HeaderDLQPartition:     "0",
+		messaging.HeaderDLQOffset:        "42",
+		messaging.HeaderDLQError:         "poison payload",
+		messaging.HeaderDLQAttempts:      "3",
+		"type":                           "notification",
+	}
+	for k, v := range expected {
+		if headers[k] != v {
+			t.Fatalf("header %s = %q, want %q", k, headers[k], v)
+		}
+	}
+	if headers[messaging.HeaderDLQFailedAt] == "" {
+		t.Fatalf("expected failure timestamp header")
+	}
+	if len(session.marked) != 1 || session.marked[0] != 42 || session.commits != 1 {
+		t.Fatalf("dead-lettered offset should be committed: marked=%v commits=%d", session.marked, session.commits)
+	}
+	if counter.counts["notifications"] != 1 {
+		t.Fatalf("expected dead-letter metric to be incremented, got %v", counter.counts)
+	}
+}
@@ -0,0 +1,55 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+
+	"github.com/IBM/sarama"
+
+	"shared/pkg/messaging"
+)
+
+var errNoClient = errors.New("kafka client unavailable")
+
+// pingCluster refreshes metadata, which fails when no broker answers, then
+// dials every broker in it. sarama calls take no context, so the ping runs
+// aside and the caller gets ctx's error once it is done.
+func pingCluster(ctx context.Context, client sarama.Client) (messaging.BrokerStatus, error) {
+	if client == nil || client.Closed() {
+		return messaging.BrokerStatus{}, errNoClient
+	}
+
+	type result struct {
+		status messaging.BrokerStatus
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var status messaging.BrokerStatus
+		if err := client.RefreshMetadata(); err != nil {
+			done <- result{status, err}
+			return
+		}
+		brokers := client.Brokers()
+		status.Brokers = len(brokers)
+		for _, b := range brokers {
+			if connected, _ := b.Connected(); !connected {
+				_ = b.Open(client.Config())
+			}
+			if connected, _ := b.Connected(); connected {
+				status.Reachable++
+			}
+		}
+		if topics, err := client.Topics(); err == nil {
+			status.Topics = len(topics)
+		}
+		done <- result{status, nil}
+	}()
+
+	select {
+	case r := <-done:
+		return r.status, r.err
+	case <-ctx.Done():
+		return messaging.BrokerStatus{}, ctx.Err()
+	}
+}
@@ -2,7 +2,11 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"sync"
+	"time"
 
 	"github.com/IBM/sarama"
 
@@ -10,8 +14,16 @@ import (
 	"shared/pkg/messaging"
 )
 
+// producer is a synchronous, idempotent producer: broker-side sequence
+// numbers make sarama's internal retries safe, so a retried batch is not
+// written twice.
 type producer struct {
 	producer sarama.SyncProducer
+	client   sarama.Client
+
+	mu       sync.Mutex
+	inFlight int
+	idle     chan struct{}
 }
 
 func NewProducer(cfg messaging.Config) (messaging.Producer, error) {
@@ -22,67 +34,195 @@ func NewProducer(cfg messaging.Config) (messaging.Producer, error) {
 	config.Producer.Return.Errors = true
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Retry.Max = cfg.MaxRetries
+	if config.Producer.Retry.Max < 1 {
+		config.Producer.Retry.Max = 1
+	}
+	if cfg.RetryBackoff > 0 {
+		config.Producer.Retry.Backoff = time.Duration(cfg.RetryBackoff) * time.Millisecond
+	}
 	config.Producer.Compression = sarama.CompressionSnappy
+	config.Producer.Idempotent = true
+	config.Net.MaxOpenRequests = 1
 
-	prod, err := sarama.NewSyncProducer(cfg.Brokers, config)
+	client, err := sarama.NewClient(cfg.Brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+	prod, err := sarama.NewSyncProducerFromClient(client)
 	if err != nil {
+		_ = client.Close()
 		return nil, fmt.Errorf("failed to create kafka producer: %w", err)
 	}
 
-	return &producer{producer: prod}, nil
+	p := newProducer(prod)
+	p.client = client
+	return p, nil
+}
+
+func newProducer(prod sarama.SyncProducer) *producer {
+	idle := make(chan struct{})
+	close(idle)
+	return &producer{producer: prod, idle: idle}
 }
 
 func (p *producer) Send(ctx context.Context, topic string, message *messaging.Message) pkgErrors.AppError {
+	if err := ctx.Err(); err != nil {
+		return produceError(err, "context cancelled before sending message", topic)
+	}
+	p.begin()
+	defer p.end()
+
+	if _, _, err := p.producer.SendMessage(toProducerMessage(topic, message)); err != nil {
+		return produceError(err, "failed to send message", topic)
+	}
+	return nil
+}
+
+func (p *producer) ProduceWithKey(ctx context.Context, topic, key string, value []byte, opts ...messaging.ProduceOption) pkgErrors.AppError {
+	message := messaging.NewMessage(value).WithKey([]byte(key))
+	for _, opt := range opts {
+		opt(message)
+	}
+	return p.Send(ctx, topic, message)
+}
+
+func (p *producer) SendBatch(ctx context.Context, topic string, messages []*messaging.Message) pkgErrors.AppError {
+	if err := ctx.Err(); err != nil {
+		return produceError(err, "context cancelled before sending batch messages", topic)
+	}
+	msgs := make([]*sarama.ProducerMessage, 0, len(messages))
+	for _, message := range messages {
+		msgs = append(msgs, toProducerMessage(topic, message))
+	}
+
+	p.begin()
+	defer p.end()
+
+	if err := p.producer.SendMessages(msgs); err != nil {
+		return produceError(err, "failed to send batch messages", topic).
+			WithDetail("count", len(messages))
+	}
+	return nil
+}
+
+func (p *producer) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	idle := p.idle
+	p.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ping refreshes cluster metadata and checks every known broker
+func (p *producer) Ping(ctx context.Context) (messaging.BrokerStatus, error) {
+	return pingCluster(ctx, p.client)
+}
+
+func (p *producer) Close() error {
+	err := p.producer.Close()
+	if p.client != nil {
+		if clientErr := p.client.Close(); err == nil && !errors.Is(clientErr, sarama.ErrClosedClient) {
+			err = clientErr
+		}
+	}
+	return err
+}
+
+func (p *producer) begin() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inFlight == 0 {
+		p.idle = make(chan struct{})
+	}
+	p.inFlight++
+}
+
+func (p *producer) end() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inFlight--
+	if p.inFlight == 0 {
+		close(p.idle)
+	}
+}
+
+func toProducerMessage(topic string, message *messaging.Message) *sarama.ProducerMessage {
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
-		Key:   sarama.ByteEncoder(message.Key),
 		Value: sarama.ByteEncoder(message.Value),
 	}
-
+	// A nil key lets the partitioner spread messages; an empty encoder would
+	// hash every unkeyed message to the same partition
+	if len(message.Key) > 0 {
+		msg.Key = sarama.ByteEncoder(message.Key)
+	}
 	for k, v := range message.Headers {
 		msg.Headers = append(msg.Headers, sarama.RecordHeader{
 			Key:   []byte(k),
 			Value: []byte(v),
 		})
 	}
+	return msg
+}
 
-	if _, _, err := p.producer.SendMessage(msg); err != nil {
-		return pkgErrors.FromError(err, pkgErrors.CodeInternal, "failed to send message").
+func produceError(err error, message, topic string) pkgErrors.AppError {
+	if isRetryableProduceError(err) {
+		return pkgErrors.FromError(fmt.Errorf("%w: %w", messaging.ErrProduceRetryable, err), pkgErrors.CodeUnavailable, message).
 			WithService("kafka-producer").
-			WithDetail("topic", topic)
+			WithDetail("topic", topic).
+			WithDetail("retryable", true)
 	}
-	return nil
+	return pkgErrors.FromError(fmt.Errorf("%w: %w", messaging.ErrProduceFatal, err), pkgErrors.CodeInternal, message).
+		WithService("kafka-producer").
+		WithDetail("topic", topic).
+		WithDetail("retryable", false)
 }
 
-func (p *producer) SendBatch(ctx context.Context, topic string, messages []*messaging.Message) pkgErrors.AppError {
-	msgs := make([]*sarama.ProducerMessage, 0, len(messages))
-
-	for _, message := range messages {
-		msg := &sarama.ProducerMessage{
-			Topic: topic,
-			Key:   sarama.ByteEncoder(message.Key),
-			Value: sarama.ByteEncoder(message.Value),
+// isRetryableProduceError reports whether err is a transient broker or network
+// condition. A batch is only retryable when every failed message is.
+func isRetryableProduceError(err error) bool {
+	var batch sarama.ProducerErrors
+	if errors.As(err, &batch) {
+		if len(batch) == 0 {
+			return false
 		}
-
-		for k, v := range message.Headers {
-			msg.Headers = append(msg.Headers, sarama.RecordHeader{
-				Key:   []byte(k),
-				Value: []byte(v),
-			})
+		for _, pe := range batch {
+			if !isRetryableProduceError(pe.Err) {
+				return false
+			}
 		}
+		return true
+	}
 
-		msgs = append(msgs, msg)
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, sarama.ErrOutOfBrokers) ||
+		errors.Is(err, sarama.ErrNotConnected) {
+		return true
 	}
 
-	if err := p.producer.SendMessages(msgs); err != nil {
-		return pkgErrors.FromError(err, pkgErrors.CodeInternal, "failed to send batch messages").
-			WithService("kafka-producer").
-			WithDetail("topic", topic).
-			WithDetail("count", len(messages))
+	var kerr sarama.KError
+	if errors.As(err, &kerr) {
+		switch kerr {
+		case sarama.ErrNotLeaderForPartition,
+			sarama.ErrLeaderNotAvailable,
+			sarama.ErrUnknownTopicOrPartition,
+			sarama.ErrRequestTimedOut,
+			sarama.ErrBrokerNotAvailable,
+			sarama.ErrReplicaNotAvailable,
+			sarama.ErrNetworkException,
+			sarama.ErrNotEnoughReplicas,
+			sarama.ErrNotEnoughReplicasAfterAppend,
+			sarama.ErrKafkaStorageError:
+			return true
+		}
+		return false
 	}
-	return nil
-}
 
-func (p *producer) Close() error {
-	return p.producer.Close()
+	var netErr net.Error
+	return errors.As(err, &netErr)
 }
@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+
+	"shared/pkg/messaging"
+)
+
+func TestProduceWithKey_SetsKeyAndDedupHeader(t *testing.T) {
+	mock := mocks.NewSyncProducer(t, nil)
+	mock.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		key, _ := msg.Key.Encode()
+		if string(key) != "conversation-1" {
+			return fmt.Errorf("unexpected key %q", key)
+		}
+		for _, h := range msg.Headers {
+			if string(h.Key) == messaging.HeaderDedupKey && string(h.Value) == "event-1" {
+				return nil
+			}
+		}
+		return fmt.Errorf("dedup header missing: %v", msg.Headers)
+	})
+	p := newProducer(mock)
+	defer p.Close()
+
+	err := p.ProduceWithKey(context.Background(), "messages", "conversation-1", []byte("{}"), messaging.WithDedupKey("event-1"))
+	if err != nil {
+		t.Fatalf("produce failed: %v", err)
+	}
+}
+
+func TestSend_ClassifiesRetryableAndFatalErrors(t *testing.T) {
+	mock := mocks.NewSyncProducer(t, nil)
+	mock.ExpectSendMessageAndFail(sarama.ErrNotLeaderForPartition)
+	mock.ExpectSendMessageAndFail(sarama.ErrMessageSizeTooLarge)
+	p := newProducer(mock)
+	defer p.Close()
+	ctx := context.Background()
+
+	err := p.ProduceWithKey(ctx, "messages", "k", []byte("v"))
+	if err == nil || !messaging.IsRetryable(err) {
+		t.Fatalf("leader election should be retryable, got %v", err)
+	}
+	err = p.ProduceWithKey(ctx, "messages", "k", []byte("v"))
+	if err == nil || messaging.IsRetryable(err) {
+		t.Fatalf("oversized message should be fatal, got %v", err)
+	}
+}
+
+func TestFlush_WaitsForInFlightSends(t *testing.T) {
+	p := newProducer(mocks.NewSyncProducer(t, nil))
+	defer p.Close()
+
+	if err := p.Flush(context.Background()); err != nil {
+		t.Fatalf("flush with nothing in flight should return immediately: %v", err)
+	}
+
+	p.begin()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Flush(ctx); err == nil {
+		t.Fatalf("flush should wait for the in-flight send")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- p.Flush(context.Background()) }()
+	p.end()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("flush failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("flush did not return after the send completed")
+	}
+}
@@ -43,3 +43,14 @@ func (m *Message) WithMetadata(key string, value interface{}) *Message {
 	m.Metadata[key] = value
 	return m
 }
+
+func (m *Message) WithDedupKey(key string) *Message {
+	if key == "" {
+		return m
+	}
+	return m.WithHeader(HeaderDedupKey, key)
+}
+
+func (m *Message) DedupKey() string {
+	return m.Headers[HeaderDedupKey]
+}
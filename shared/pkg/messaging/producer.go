@@ -1,5 +1,7 @@
 package messaging
 
+import "errors"
+
 type ProducerConfig struct {
 	Acks              int
 	CompressionType   string
@@ -19,3 +21,27 @@ type SendResult struct {
 	Timestamp int64
 	Error     error
 }
+
+// HeaderDedupKey carries an application-level idempotency key. Producer
+// idempotence only covers broker retries; consumers use this header to drop
+// duplicates caused by the application publishing the same event twice.
+const HeaderDedupKey = "x-dedup-key"
+
+// Produce errors wrap one of these so callers can tell a transient broker
+// problem, worth retrying, from a message the broker will never accept.
+var (
+	ErrProduceRetryable = errors.New("retryable produce error")
+	ErrProduceFatal     = errors.New("fatal produce error")
+)
+
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrProduceRetryable)
+}
+
+type ProduceOption func(*Message)
+
+func WithDedupKey(key string) ProduceOption {
+	return func(m *Message) {
+		m.WithDedupKey(key)
+	}
+}
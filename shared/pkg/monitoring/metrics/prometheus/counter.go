@@ -9,9 +9,14 @@ type counterVec struct {
 	vec *prometheus.CounterVec
 }
 
-// NewCounter creates a new Prometheus counter
+// NewCounter creates a new Prometheus counter registered with the default registry
 func NewCounter(namespace, subsystem, name, help string, labelNames []string) *counterVec {
-	vec := promauto.NewCounterVec(
+	return NewCounterWith(prometheus.DefaultRegisterer, namespace, subsystem, name, help, labelNames)
+}
+
+// NewCounterWith creates a new Prometheus counter registered with reg
+func NewCounterWith(reg prometheus.Registerer, namespace, subsystem, name, help string, labelNames []string) *counterVec {
+	vec := promauto.With(reg).NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
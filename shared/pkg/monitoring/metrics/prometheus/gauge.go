@@ -9,9 +9,14 @@ type gaugeVec struct {
 	vec *prometheus.GaugeVec
 }
 
-// NewGauge creates a new Prometheus gauge
+// NewGauge creates a new Prometheus gauge registered with the default registry
 func NewGauge(namespace, subsystem, name, help string, labelNames []string) *gaugeVec {
-	vec := promauto.NewGaugeVec(
+	return NewGaugeWith(prometheus.DefaultRegisterer, namespace, subsystem, name, help, labelNames)
+}
+
+// NewGaugeWith creates a new Prometheus gauge registered with reg
+func NewGaugeWith(reg prometheus.Registerer, namespace, subsystem, name, help string, labelNames []string) *gaugeVec {
+	vec := promauto.With(reg).NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
@@ -9,9 +9,14 @@ type histogramVec struct {
 	vec *prometheus.HistogramVec
 }
 
-// NewHistogram creates a new Prometheus histogram
+// NewHistogram creates a new Prometheus histogram registered with the default registry
 func NewHistogram(namespace, subsystem, name, help string, labelNames []string, buckets []float64) *histogramVec {
-	vec := promauto.NewHistogramVec(
+	return NewHistogramWith(prometheus.DefaultRegisterer, namespace, subsystem, name, help, labelNames, buckets)
+}
+
+// NewHistogramWith creates a new Prometheus histogram registered with reg
+func NewHistogramWith(reg prometheus.Registerer, namespace, subsystem, name, help string, labelNames []string, buckets []float64) *histogramVec {
+	vec := promauto.With(reg).NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
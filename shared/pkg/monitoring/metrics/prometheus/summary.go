@@ -9,9 +9,14 @@ type summaryVec struct {
 	vec *prometheus.SummaryVec
 }
 
-// NewSummary creates a new Prometheus summary
+// NewSummary creates a new Prometheus summary registered with the default registry
 func NewSummary(namespace, subsystem, name, help string, labelNames []string, objectives map[float64]float64) *summaryVec {
-	vec := promauto.NewSummaryVec(
+	return NewSummaryWith(prometheus.DefaultRegisterer, namespace, subsystem, name, help, labelNames, objectives)
+}
+
+// NewSummaryWith creates a new Prometheus summary registered with reg
+func NewSummaryWith(reg prometheus.Registerer, namespace, subsystem, name, help string, labelNames []string, objectives map[float64]float64) *summaryVec {
+	vec := promauto.With(reg).NewSummaryVec(
 		prometheus.SummaryOpts{
 			Namespace:  namespace,
 			Subsystem:  subsystem,
@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Transport is an http.RoundTripper that starts a client span for each
+// outgoing request made under a recorded trace and forwards the trace to the
+// server in a W3C traceparent header
+type Transport struct {
+	base       http.RoundTripper
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewTransport wraps base, or http.DefaultTransport when base is nil. Spans
+// come from the global tracer provider, so nothing is recorded until New
+// installs an enabled one.
+func NewTransport(base http.RoundTripper, name string) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		base:       base,
+		tracer:     otel.Tracer(name),
+		propagator: propagation.TraceContext{},
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, span := t.tracer.Start(ctx, "HTTP "+req.Method+" "+req.URL.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String(AttrHTTPMethod, req.Method),
+			attribute.String(AttrHTTPURL, req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+		),
+	)
+	defer span.End()
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(ctx)
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int(AttrHTTPStatusCode, resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", resp.StatusCode))
+	}
+	return resp, nil
+}
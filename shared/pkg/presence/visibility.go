@@ -0,0 +1,24 @@
+// Package presence holds the presence privacy rules shared by the services
+// that read users.settings, so presence-service lookups and ws-service
+// broadcasts hide the same things.
+package presence
+
+// Presence visibility levels for last-seen and online status
+const (
+	VisibilityEveryone = "everyone"
+	VisibilityContacts = "contacts"
+	VisibilityNobody   = "nobody"
+)
+
+// VisibleTo reports whether a viewer may see a presence field saved with the
+// given visibility. Unknown or empty levels are treated as everyone.
+func VisibleTo(visibility string, isContact bool) bool {
+	switch visibility {
+	case VisibilityNobody:
+		return false
+	case VisibilityContacts:
+		return isContact
+	default:
+		return true
+	}
+}
@@ -0,0 +1,23 @@
+package presence
+
+import "testing"
+
+func TestVisibleTo(t *testing.T) {
+	cases := []struct {
+		visibility string
+		isContact  bool
+		want       bool
+	}{
+		{VisibilityEveryone, false, true},
+		{VisibilityContacts, true, true},
+		{VisibilityContacts, false, false},
+		{VisibilityNobody, true, false},
+		{"", false, true},
+	}
+
+	for _, tc := range cases {
+		if got := VisibleTo(tc.visibility, tc.isContact); got != tc.want {
+			t.Fatalf("VisibleTo(%q, %v) = %v, want %v", tc.visibility, tc.isContact, got, tc.want)
+		}
+	}
+}
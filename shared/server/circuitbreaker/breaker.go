@@ -0,0 +1,217 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"shared/server/health"
+)
+
+var ErrCircuitOpen = errors.New("circuitbreaker: circuit is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+	DefaultHalfOpenMaxCalls = 1
+)
+
+type Config struct {
+	// FailureThreshold is the number of consecutive failures that trips the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before allowing probes.
+	OpenTimeout time.Duration
+	// HalfOpenMaxCalls limits concurrent probe calls while half-open.
+	HalfOpenMaxCalls int
+	// OnStateChange is called, outside the breaker lock, after every transition.
+	OnStateChange func(from, to State)
+}
+
+type Breaker struct {
+	config Config
+	now    func() time.Time
+
+	mu         sync.Mutex
+	state      State
+	failures   int
+	openedAt   time.Time
+	probes     int
+	generation uint64
+}
+
+func New(config Config) *Breaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = DefaultFailureThreshold
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = DefaultOpenTimeout
+	}
+	if config.HalfOpenMaxCalls <= 0 {
+		config.HalfOpenMaxCalls = DefaultHalfOpenMaxCalls
+	}
+	return &Breaker{
+		config: config,
+		now:    time.Now,
+		state:  StateClosed,
+	}
+}
+
+// Execute runs fn if the breaker allows it and records the outcome. While the
+// breaker is open it returns ErrCircuitOpen without calling fn. Errors caused
+// by the caller's own context being done are not counted as failures.
+func (b *Breaker) Execute(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	generation, err := b.beforeCall()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	if err != nil && ctx.Err() != nil {
+		b.release(generation)
+		return err
+	}
+	b.afterCall(generation, err == nil)
+	return err
+}
+
+// State reports the current state, moving an expired open breaker to half-open.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	state, transition := b.currentState()
+	b.mu.Unlock()
+	b.notify(transition)
+	return state
+}
+
+// HealthCheck reports the breaker as down while open and degraded while half-open.
+func (b *Breaker) HealthCheck(name string) health.CheckFunc {
+	return func(ctx context.Context) health.CheckResult {
+		state := b.State()
+		result := health.CheckResult{
+			Status:    health.StatusUp,
+			Message:   fmt.Sprintf("%s circuit is %s", name, state),
+			Timestamp: time.Now(),
+			Metadata:  map[string]interface{}{"state": state.String()},
+		}
+		switch state {
+		case StateOpen:
+			result.Status = health.StatusDown
+		case StateHalfOpen:
+			result.Status = health.StatusDegraded
+		}
+		return result
+	}
+}
+
+type transition struct {
+	from, to State
+}
+
+func (b *Breaker) beforeCall() (uint64, error) {
+	b.mu.Lock()
+	state, t := b.currentState()
+	var err error
+	switch state {
+	case StateOpen:
+		err = ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probes >= b.config.HalfOpenMaxCalls {
+			err = ErrCircuitOpen
+		} else {
+			b.probes++
+		}
+	}
+	generation := b.generation
+	b.mu.Unlock()
+	b.notify(t)
+	return generation, err
+}
+
+func (b *Breaker) afterCall(generation uint64, success bool) {
+	b.mu.Lock()
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+
+	var t *transition
+	switch b.state {
+	case StateClosed:
+		if success {
+			b.failures = 0
+		} else {
+			b.failures++
+			if b.failures >= b.config.FailureThreshold {
+				t = b.setState(StateOpen)
+			}
+		}
+	case StateHalfOpen:
+		if success {
+			t = b.setState(StateClosed)
+		} else {
+			t = b.setState(StateOpen)
+		}
+	}
+	b.mu.Unlock()
+	b.notify(t)
+}
+
+func (b *Breaker) release(generation uint64) {
+	b.mu.Lock()
+	if generation == b.generation && b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+	b.mu.Unlock()
+}
+
+func (b *Breaker) currentState() (State, *transition) {
+	if b.state == StateOpen && !b.now().Before(b.openedAt.Add(b.config.OpenTimeout)) {
+		return StateHalfOpen, b.setState(StateHalfOpen)
+	}
+	return b.state, nil
+}
+
+func (b *Breaker) setState(state State) *transition {
+	t := &transition{from: b.state, to: state}
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.probes = 0
+	if state == StateOpen {
+		b.openedAt = b.now()
+	}
+	return t
+}
+
+func (b *Breaker) notify(t *transition) {
+	if t != nil && b.config.OnStateChange != nil {
+		b.config.OnStateChange(t.from, t.to)
+	}
+}
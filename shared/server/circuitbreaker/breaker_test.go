@@ -0,0 +1,114 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errDownstream = errors.New("downstream failed")
+
+func TestBreakerTransitions(t *testing.T) {
+	now := time.Now()
+	var transitions []State
+	b := New(Config{
+		FailureThreshold: 3,
+		OpenTimeout:      time.Minute,
+		OnStateChange: func(from, to State) {
+			transitions = append(transitions, to)
+		},
+	})
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+	fail := func() error { return errDownstream }
+	succeed := func() error { return nil }
+
+	for i := 0; i < 2; i++ {
+		if err := b.Execute(ctx, fail); !errors.Is(err, errDownstream) {
+			t.Fatalf("expected downstream error, got %v", err)
+		}
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("expected closed below threshold, got %s", b.State())
+	}
+
+	b.Execute(ctx, fail)
+	if b.State() != StateOpen {
+		t.Fatalf("expected open after threshold, got %s", b.State())
+	}
+
+	called := false
+	err := b.Execute(ctx, func() error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrCircuitOpen) || called {
+		t.Fatalf("expected ErrCircuitOpen without calling fn, got %v (called=%v)", err, called)
+	}
+
+	now = now.Add(time.Minute)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("expected half-open after timeout, got %s", b.State())
+	}
+
+	// A failed probe re-opens the breaker.
+	b.Execute(ctx, fail)
+	if b.State() != StateOpen {
+		t.Fatalf("expected open after failed probe, got %s", b.State())
+	}
+
+	now = now.Add(time.Minute)
+	if err := b.Execute(ctx, succeed); err != nil {
+		t.Fatalf("expected probe to succeed, got %v", err)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("expected closed after successful probe, got %s", b.State())
+	}
+
+	want := []State{StateOpen, StateHalfOpen, StateOpen, StateHalfOpen, StateClosed}
+	if len(transitions) != len(want) {
+		t.Fatalf("expected transitions %v, got %v", want, transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("expected transitions %v, got %v", want, transitions)
+		}
+	}
+}
+
+func TestBreakerLimitsHalfOpenProbes(t *testing.T) {
+	now := time.Now()
+	b := New(Config{FailureThreshold: 1, OpenTimeout: time.Second, HalfOpenMaxCalls: 1})
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	b.Execute(ctx, func() error { return errDownstream })
+	now = now.Add(time.Second)
+
+	err := b.Execute(ctx, func() error {
+		if err := b.Execute(ctx, func() error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected concurrent probe to be rejected, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected probe to succeed, got %v", err)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("expected closed, got %s", b.State())
+	}
+}
+
+func TestBreakerIgnoresCallerCancellation(t *testing.T) {
+	b := New(Config{FailureThreshold: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b.Execute(ctx, func() error {
+		cancel()
+		return ctx.Err()
+	})
+	if b.State() != StateClosed {
+		t.Fatalf("expected caller cancellation not to trip the breaker, got %s", b.State())
+	}
+}
@@ -16,6 +16,9 @@ type Claims struct {
 	Metadata  map[string]any `json:"metadata,omitempty"`
 	Raw       map[string]any `json:"-"`
 	IssuedKey string         `json:"-"`
+
+	// IssuedAtMs repeats iat in milliseconds; iat itself has whole seconds
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
 }
 
 const (
@@ -23,6 +26,18 @@ const (
 	TokenTypeRefresh TokenType = "refresh"
 )
 
+// IssuedAtTime returns when the token was issued, to the millisecond when the
+// token carries iat_ms
+func (c *Claims) IssuedAtTime() (time.Time, bool) {
+	if c.IssuedAtMs > 0 {
+		return time.UnixMilli(c.IssuedAtMs), true
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time, true
+	}
+	return time.Time{}, false
+}
+
 func (c *Claims) Capture(raw jwt.Claims) {
 	if raw == nil {
 		return
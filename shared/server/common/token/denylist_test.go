@@ -0,0 +1,109 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"shared/pkg/cache/memory"
+)
+
+func TestDenylist_RevokeTokenAndUserCutoff(t *testing.T) {
+	ks, err := NewStaticKeySet([]byte("test-secret-key-which-is-long-enough"))
+	if err != nil {
+		t.Fatalf("failed to create static key set: %v", err)
+	}
+	issuedAt := time.Now().Add(-time.Minute)
+	mgr, err := NewManager(Config{
+		KeySet:          ks,
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour * 24,
+		Clock:           func() time.Time { return issuedAt },
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	ctx := context.Background()
+	first, err := mgr.IssueAccessToken(ctx, "user-1", IssueOptions{})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+	second, err := mgr.IssueAccessToken(ctx, "user-1", IssueOptions{})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+
+	denylist := NewDenylist(memory.New())
+	if revoked, err := denylist.IsRevoked(ctx, first.Claims); err != nil || revoked {
+		t.Fatalf("expected fresh token to be allowed, got revoked=%v err=%v", revoked, err)
+	}
+
+	if err := denylist.RevokeToken(ctx, first.Claims.ID, time.Minute); err != nil {
+		t.Fatalf("revoke token failed: %v", err)
+	}
+	if revoked, _ := denylist.IsRevoked(ctx, first.Claims); !revoked {
+		t.Fatalf("expected revoked jti to be rejected")
+	}
+	if revoked, _ := denylist.IsRevoked(ctx, second.Claims); revoked {
+		t.Fatalf("revoking one jti must not affect others")
+	}
+
+	if err := denylist.RevokeUserTokensBefore(ctx, "user-1", time.Now(), time.Hour); err != nil {
+		t.Fatalf("revoke user tokens failed: %v", err)
+	}
+	if revoked, _ := denylist.IsRevoked(ctx, second.Claims); !revoked {
+		t.Fatalf("expected tokens issued before cutoff to be rejected")
+	}
+
+	later, err := NewManager(Config{KeySet: ks, AccessTokenTTL: time.Hour, RefreshTokenTTL: time.Hour,
+		Clock: func() time.Time { return time.Now().Add(time.Minute) }})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	fresh, err := later.IssueAccessToken(ctx, "user-1", IssueOptions{})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+	if revoked, _ := denylist.IsRevoked(ctx, fresh.Claims); revoked {
+		t.Fatalf("expected tokens issued after cutoff to be allowed")
+	}
+}
+
+func TestDenylist_UserCutoffWithinTheSameSecond(t *testing.T) {
+	ks, err := NewStaticKeySet([]byte("test-secret-key-which-is-long-enough"))
+	if err != nil {
+		t.Fatalf("failed to create static key set: %v", err)
+	}
+	second := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	issueAt := func(offset time.Duration) *Claims {
+		mgr, err := NewManager(Config{KeySet: ks, AccessTokenTTL: time.Hour, RefreshTokenTTL: time.Hour,
+			Clock: func() time.Time { return second.Add(offset) }})
+		if err != nil {
+			t.Fatalf("failed to create manager: %v", err)
+		}
+		signed, err := mgr.IssueAccessToken(context.Background(), "user-1", IssueOptions{})
+		if err != nil {
+			t.Fatalf("issue failed: %v", err)
+		}
+		// Round-trip so the check sees what a verifier would parse
+		claims, err := mgr.Parse(context.Background(), signed.Token)
+		if err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+		return claims
+	}
+
+	ctx := context.Background()
+	denylist := NewDenylist(memory.New())
+	if err := denylist.RevokeUserTokensBefore(ctx, "user-1", second.Add(500*time.Millisecond), time.Hour); err != nil {
+		t.Fatalf("revoke user tokens failed: %v", err)
+	}
+
+	if revoked, _ := denylist.IsRevoked(ctx, issueAt(200*time.Millisecond)); !revoked {
+		t.Fatalf("expected a token issued earlier in the cutoff's second to be rejected")
+	}
+	if revoked, _ := denylist.IsRevoked(ctx, issueAt(800*time.Millisecond)); revoked {
+		t.Fatalf("expected a token issued later in the cutoff's second to be allowed")
+	}
+}
@@ -0,0 +1,180 @@
+package token
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrSigningUnsupported = errors.New("token: key set is verify-only")
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func newRSAJWK(kid string, public *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Kid: kid,
+		Alg: AlgorithmRS256,
+		N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+		E:   encodeExponent(public.E),
+	}
+}
+
+func (j JWK) rsaPublicKey() (*rsa.PublicKey, error) {
+	if j.Kty != "RSA" {
+		return nil, fmt.Errorf("token: unsupported jwk type %q", j.Kty)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(j.N)
+	if err != nil {
+		return nil, fmt.Errorf("token: decode jwk modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(j.E)
+	if err != nil {
+		return nil, fmt.Errorf("token: decode jwk exponent: %w", err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// JWKSKeySet validates tokens against public keys fetched from an issuer's
+// JWKS endpoint. Unknown kids trigger a refetch, throttled by minRefresh, so
+// a rotation on the issuer is picked up without a restart.
+type JWKSKeySet struct {
+	url        string
+	client     *http.Client
+	cacheTTL   time.Duration
+	minRefresh time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]Key
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+type JWKSOption func(*JWKSKeySet)
+
+func WithJWKSHTTPClient(client *http.Client) JWKSOption {
+	return func(s *JWKSKeySet) {
+		s.client = client
+	}
+}
+
+func WithJWKSCacheTTL(ttl time.Duration) JWKSOption {
+	return func(s *JWKSKeySet) {
+		s.cacheTTL = ttl
+	}
+}
+
+func NewJWKSKeySet(url string, opts ...JWKSOption) *JWKSKeySet {
+	set := &JWKSKeySet{
+		url:        url,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		cacheTTL:   10 * time.Minute,
+		minRefresh: 30 * time.Second,
+		keys:       make(map[string]Key),
+	}
+	for _, opt := range opts {
+		opt(set)
+	}
+	return set
+}
+
+func (s *JWKSKeySet) Current(ctx context.Context) (Key, error) {
+	return Key{}, ErrSigningUnsupported
+}
+
+func (s *JWKSKeySet) Lookup(ctx context.Context, keyID string) (Key, error) {
+	s.mu.RLock()
+	key, ok := s.keys[keyID]
+	stale := time.Since(s.fetchedAt) > s.cacheTTL
+	s.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+	if s.claimRefresh() {
+		if err := s.Refresh(ctx); err != nil && !ok {
+			return Key{}, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if key, ok := s.keys[keyID]; ok {
+		return key, nil
+	}
+	return Key{}, ErrKeyNotFound
+}
+
+// claimRefresh records a fetch attempt and reports whether minRefresh has
+// passed since the previous one. Failed fetches count too, so an issuer that
+// is down is not hit again for every unknown kid.
+func (s *JWKSKeySet) claimRefresh() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.attemptedAt.IsZero() && time.Since(s.attemptedAt) < s.minRefresh {
+		return false
+	}
+	s.attemptedAt = time.Now()
+	return true
+}
+
+// Refresh fetches the JWKS document and replaces the cached keys.
+func (s *JWKSKeySet) Refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("token: build jwks request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("token: fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token: fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var doc JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("token: decode jwks: %w", err)
+	}
+
+	keys := make(map[string]Key, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Alg != "" && jwk.Alg != AlgorithmRS256 {
+			continue
+		}
+		public, err := jwk.rsaPublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = Key{ID: jwk.Kid, Algorithm: AlgorithmRS256, PublicKey: public}
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
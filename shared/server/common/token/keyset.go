@@ -3,15 +3,44 @@ package token
 import (
 	"context"
 	"crypto/hmac"
+	"crypto/rsa"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"time"
 )
 
+// Key is either a symmetric HMAC key (Secret) or an RSA key pair. Verify-only
+// keys carry just the PublicKey. A zero ExpiresAt never expires.
 type Key struct {
-	ID        string
-	Secret    []byte
-	Algorithm string
+	ID         string
+	Secret     []byte
+	Algorithm  string
+	PrivateKey *rsa.PrivateKey
+	PublicKey  *rsa.PublicKey
+	ExpiresAt  time.Time
+}
+
+func (k Key) signingKey() (interface{}, error) {
+	if k.PrivateKey != nil {
+		return k.PrivateKey, nil
+	}
+	if len(k.Secret) > 0 {
+		return k.Secret, nil
+	}
+	return nil, fmt.Errorf("token: key %s cannot sign", k.ID)
+}
+
+func (k Key) verificationKey() (interface{}, error) {
+	switch {
+	case k.PublicKey != nil:
+		return k.PublicKey, nil
+	case k.PrivateKey != nil:
+		return &k.PrivateKey.PublicKey, nil
+	case len(k.Secret) > 0:
+		return k.Secret, nil
+	}
+	return nil, fmt.Errorf("token: key %s has no verification material", k.ID)
 }
 
 type KeySet interface {
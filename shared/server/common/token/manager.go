@@ -63,20 +63,22 @@ func NewManager(cfg Config) (*Manager, error) {
 	if clock == nil {
 		clock = time.Now
 	}
-	parser := jwt.NewParser(cfg.ParserOptions...)
+	leeway := cfg.Leeway
+	if leeway < 0 {
+		leeway = 0
+	}
+	parserOptions := append([]jwt.ParserOption{jwt.WithLeeway(leeway), jwt.WithTimeFunc(clock)}, cfg.ParserOptions...)
+	parser := jwt.NewParser(parserOptions...)
 	manager := &Manager{
 		keySet:     cfg.KeySet,
 		issuer:     cfg.Issuer,
 		audience:   cfg.Audience,
 		accessTTL:  cfg.AccessTokenTTL,
 		refreshTTL: cfg.RefreshTokenTTL,
-		leeway:     cfg.Leeway,
+		leeway:     leeway,
 		clock:      clock,
 		parser:     parser,
 	}
-	if manager.leeway < 0 {
-		manager.leeway = 0
-	}
 	return manager, nil
 }
 
@@ -118,7 +120,7 @@ func (m *Manager) Parse(ctx context.Context, tokenString string) (*Claims, error
 		if t.Method != method {
 			return nil, errors.New("token: signing method mismatch")
 		}
-		return key.Secret, nil
+		return key.verificationKey()
 	})
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -144,6 +146,9 @@ func (m *Manager) Validate(ctx context.Context, tokenString string, expected Tok
 	if err := claims.Validate(m.clock(), m.leeway, expected, m.audience); err != nil {
 		return nil, err
 	}
+	if m.issuer != "" && claims.Issuer != m.issuer {
+		return nil, errors.New("token: issuer mismatch")
+	}
 	return claims, nil
 }
 
@@ -168,8 +173,9 @@ func (m *Manager) buildClaims(subject string, tokenType TokenType, opts IssueOpt
 			NotBefore: jwt.NewNumericDate(nonZero(opts.NotBefore, now)),
 			ExpiresAt: jwt.NewNumericDate(expires),
 		},
-		TokenType: tokenType,
-		Metadata:  metadata,
+		TokenType:  tokenType,
+		Metadata:   metadata,
+		IssuedAtMs: now.UnixMilli(),
 	}
 }
 
@@ -182,9 +188,13 @@ func (m *Manager) sign(ctx context.Context, claims *Claims) (SignedToken, error)
 	if method == nil {
 		return SignedToken{}, fmt.Errorf("token: unsupported signing method %s", key.Algorithm)
 	}
+	signingKey, err := key.signingKey()
+	if err != nil {
+		return SignedToken{}, err
+	}
 	token := jwt.NewWithClaims(method, claims)
 	token.Header["kid"] = key.ID
-	signed, err := token.SignedString(key.Secret)
+	signed, err := token.SignedString(signingKey)
 	if err != nil {
 		return SignedToken{}, fmt.Errorf("token: sign failed: %w", err)
 	}
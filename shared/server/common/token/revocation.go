@@ -0,0 +1,17 @@
+package token
+
+import "time"
+
+// DeviceRevokedChannel is the pub/sub channel auth-service announces device
+// revocations on. Services holding long-lived connections subscribe to it to
+// drop a revoked device at once rather than when its token is next checked.
+const DeviceRevokedChannel = "auth:device:revoked"
+
+// DeviceRevocation is the payload published on DeviceRevokedChannel
+type DeviceRevocation struct {
+	UserID     string    `json:"user_id"`
+	DeviceID   string    `json:"device_id"`
+	SessionIDs []string  `json:"session_ids"`
+	Reason     string    `json:"reason"`
+	RevokedAt  time.Time `json:"revoked_at"`
+}
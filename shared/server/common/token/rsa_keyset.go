@@ -0,0 +1,212 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const AlgorithmRS256 = "RS256"
+
+// RSAKeySet signs with the current RSA key and keeps previous keys around for
+// verification until they expire, so tokens issued before a rotation stay
+// valid for the rest of their lifetime.
+type RSAKeySet struct {
+	mu       sync.RWMutex
+	current  Key
+	previous map[string]Key
+	clock    func() time.Time
+}
+
+func NewRSAKeySet(current Key, previous ...Key) (*RSAKeySet, error) {
+	if current.PrivateKey == nil {
+		return nil, errors.New("token: current RSA key requires a private key")
+	}
+	set := &RSAKeySet{
+		current:  current,
+		previous: make(map[string]Key),
+		clock:    time.Now,
+	}
+	for _, k := range previous {
+		if k.PublicKey == nil && k.PrivateKey == nil {
+			return nil, fmt.Errorf("token: previous key %s has no public key", k.ID)
+		}
+		set.previous[k.ID] = k
+	}
+	return set, nil
+}
+
+// NewRSAKey wraps a private key, deriving the kid from the public key's
+// RFC 7638 thumbprint so every instance loading the same PEM agrees on it.
+func NewRSAKey(private *rsa.PrivateKey) Key {
+	return Key{
+		ID:         rsaThumbprint(&private.PublicKey),
+		Algorithm:  AlgorithmRS256,
+		PrivateKey: private,
+		PublicKey:  &private.PublicKey,
+	}
+}
+
+// NewRSAPublicKey wraps a verify-only key that stops validating at expiresAt.
+func NewRSAPublicKey(public *rsa.PublicKey, expiresAt time.Time) Key {
+	return Key{
+		ID:        rsaThumbprint(public),
+		Algorithm: AlgorithmRS256,
+		PublicKey: public,
+		ExpiresAt: expiresAt,
+	}
+}
+
+func GenerateRSAKey(bits int) (Key, error) {
+	private, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return Key{}, fmt.Errorf("token: generate RSA key: %w", err)
+	}
+	return NewRSAKey(private), nil
+}
+
+func LoadRSAPrivateKey(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("token: read private key: %w", err)
+	}
+	private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return Key{}, fmt.Errorf("token: parse private key: %w", err)
+	}
+	return NewRSAKey(private), nil
+}
+
+// LoadRSAPublicKey reads a PEM public key, or the public half of a PEM
+// private key, for verification only.
+func LoadRSAPublicKey(path string, expiresAt time.Time) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("token: read public key: %w", err)
+	}
+	if public, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return NewRSAPublicKey(public, expiresAt), nil
+	}
+	private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return Key{}, fmt.Errorf("token: parse public key: %w", err)
+	}
+	return NewRSAPublicKey(&private.PublicKey, expiresAt), nil
+}
+
+func (s *RSAKeySet) Current(ctx context.Context) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current, nil
+}
+
+func (s *RSAKeySet) Lookup(ctx context.Context, keyID string) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if keyID == "" || keyID == s.current.ID {
+		return s.current, nil
+	}
+	if key, ok := s.previous[keyID]; ok && !s.expired(key) {
+		return key, nil
+	}
+	return Key{}, ErrKeyNotFound
+}
+
+// Rotate makes next the signing key. The old current key keeps verifying for
+// retain, which should be at least the longest token TTL.
+func (s *RSAKeySet) Rotate(next Key, retain time.Duration) error {
+	if next.PrivateKey == nil {
+		return errors.New("token: rotated RSA key requires a private key")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.current
+	old.ExpiresAt = s.clock().Add(retain)
+	s.previous[old.ID] = old
+	s.current = next
+	for id, key := range s.previous {
+		if s.expired(key) {
+			delete(s.previous, id)
+		}
+	}
+	return nil
+}
+
+// ReloadPrivateKey loads the signing key at path and rotates to it when it
+// differs from the current one, so operators rotate by replacing the file.
+func (s *RSAKeySet) ReloadPrivateKey(path string, retain time.Duration) (bool, error) {
+	next, err := LoadRSAPrivateKey(path)
+	if err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	unchanged := next.ID == s.current.ID
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	return true, s.Rotate(next, retain)
+}
+
+// PublicKeys returns the current key and every unexpired previous key.
+func (s *RSAKeySet) PublicKeys() []Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := []Key{s.current}
+	for _, key := range s.previous {
+		if !s.expired(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.SliceStable(keys[1:], func(i, j int) bool { return keys[i+1].ID < keys[j+1].ID })
+	return keys
+}
+
+// JWKS renders the verification keys as a JSON Web Key Set.
+func (s *RSAKeySet) JWKS() JWKS {
+	keys := s.PublicKeys()
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		public := key.PublicKey
+		if public == nil {
+			public = &key.PrivateKey.PublicKey
+		}
+		set.Keys = append(set.Keys, newRSAJWK(key.ID, public))
+	}
+	return set
+}
+
+func (s *RSAKeySet) expired(key Key) bool {
+	return !key.ExpiresAt.IsZero() && !s.clock().Before(key.ExpiresAt)
+}
+
+func rsaThumbprint(public *rsa.PublicKey) string {
+	// RFC 7638: members in lexicographic order, no whitespace.
+	canonical, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{
+		E:   encodeExponent(public.E),
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+	})
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func encodeExponent(e int) string {
+	return base64.RawURLEncoding.EncodeToString(big.NewInt(int64(e)).Bytes())
+}
@@ -0,0 +1,180 @@
+package token
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRSAManager(t *testing.T, ks KeySet) *Manager {
+	t.Helper()
+	mgr, err := NewManager(Config{
+		KeySet:          ks,
+		Issuer:          "test-issuer",
+		Audience:        []string{"test_users"},
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour * 24,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	return mgr
+}
+
+func generateTestRSAKey(t *testing.T) Key {
+	t.Helper()
+	key, err := GenerateRSAKey(2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key
+}
+
+func TestRSAKeySet_RotationKeepsOldTokensValid(t *testing.T) {
+	ks, err := NewRSAKeySet(generateTestRSAKey(t))
+	if err != nil {
+		t.Fatalf("failed to create key set: %v", err)
+	}
+	now := time.Now()
+	ks.clock = func() time.Time { return now }
+	mgr := newRSAManager(t, ks)
+	ctx := context.Background()
+
+	old, err := mgr.IssueAccessToken(ctx, "user-123", IssueOptions{})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+
+	if err := ks.Rotate(generateTestRSAKey(t), time.Hour); err != nil {
+		t.Fatalf("rotate failed: %v", err)
+	}
+	fresh, err := mgr.IssueAccessToken(ctx, "user-123", IssueOptions{})
+	if err != nil {
+		t.Fatalf("issue after rotation failed: %v", err)
+	}
+
+	if _, err := mgr.Validate(ctx, old.Token, TokenTypeAccess); err != nil {
+		t.Fatalf("token signed with previous key should validate: %v", err)
+	}
+	if _, err := mgr.Validate(ctx, fresh.Token, TokenTypeAccess); err != nil {
+		t.Fatalf("token signed with current key should validate: %v", err)
+	}
+	if got := len(ks.JWKS().Keys); got != 2 {
+		t.Fatalf("expected 2 published keys, got %d", got)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := mgr.Parse(ctx, old.Token); err == nil {
+		t.Fatalf("expected previous key to stop validating after retention")
+	}
+	if got := len(ks.JWKS().Keys); got != 1 {
+		t.Fatalf("expected expired key to be unpublished, got %d keys", got)
+	}
+}
+
+func writeTestPrivateKey(t *testing.T, path string, key Key) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key.PrivateKey)})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+}
+
+func TestRSAKeySet_ReloadPrivateKeyRotatesOnNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	first := generateTestRSAKey(t)
+	writeTestPrivateKey(t, path, first)
+
+	ks, err := NewRSAKeySet(first)
+	if err != nil {
+		t.Fatalf("failed to create key set: %v", err)
+	}
+	ctx := context.Background()
+
+	if rotated, err := ks.ReloadPrivateKey(path, time.Hour); err != nil || rotated {
+		t.Fatalf("unchanged key file rotated=%v err=%v, want no rotation", rotated, err)
+	}
+
+	second := generateTestRSAKey(t)
+	writeTestPrivateKey(t, path, second)
+	if rotated, err := ks.ReloadPrivateKey(path, time.Hour); err != nil || !rotated {
+		t.Fatalf("expected rotation, got rotated=%v err=%v", rotated, err)
+	}
+	if current, _ := ks.Current(ctx); current.ID != second.ID {
+		t.Fatalf("current kid = %s, want %s", current.ID, second.ID)
+	}
+	if _, err := ks.Lookup(ctx, first.ID); err != nil {
+		t.Fatalf("previous key should keep verifying: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ReloadPrivateKey(path, time.Hour); err == nil {
+		t.Fatalf("expected an unreadable key file to fail")
+	}
+	if current, _ := ks.Current(ctx); current.ID != second.ID {
+		t.Fatalf("a failed reload replaced the signing key")
+	}
+}
+
+func TestJWKSKeySet_ValidatesIssuerTokens(t *testing.T) {
+	ks, err := NewRSAKeySet(generateTestRSAKey(t))
+	if err != nil {
+		t.Fatalf("failed to create key set: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ks.JWKS())
+	}))
+	defer server.Close()
+
+	issuer := newRSAManager(t, ks)
+	verifier := newRSAManager(t, NewJWKSKeySet(server.URL))
+	ctx := context.Background()
+
+	signed, err := issuer.IssueAccessToken(ctx, "user-123", IssueOptions{})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+	claims, err := verifier.Validate(ctx, signed.Token, TokenTypeAccess)
+	if err != nil {
+		t.Fatalf("verifier rejected issuer token: %v", err)
+	}
+	if claims.Subject != "user-123" {
+		t.Fatalf("unexpected subject: %s", claims.Subject)
+	}
+
+	if _, err := verifier.IssueAccessToken(ctx, "user-123", IssueOptions{}); !errors.Is(err, ErrSigningUnsupported) {
+		t.Fatalf("expected verify-only key set to refuse signing, got %v", err)
+	}
+}
+
+func TestJWKSKeySet_ThrottlesFailedFetches(t *testing.T) {
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ks := NewJWKSKeySet(server.URL)
+	ctx := context.Background()
+	for _, kid := range []string{"a", "b", "c"} {
+		if _, err := ks.Lookup(ctx, kid); err == nil {
+			t.Fatalf("expected lookup of %q to fail while the issuer is down", kid)
+		}
+	}
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Fatalf("expected a single fetch within minRefresh, got %d", got)
+	}
+}
@@ -18,4 +18,7 @@ const (
 	EnvKey           ContextKey = "env"
 	APIVersionKey    ContextKey = "api_version"
 	ResponseKey      ContextKey = "response"
+	GeoLocationKey   ContextKey = "geo_location"
+	TokenClaimsKey   ContextKey = "token_claims"
+	CSRFTokenKey     ContextKey = "csrf_token"
 )
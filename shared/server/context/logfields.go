@@ -0,0 +1,42 @@
+package contextx
+
+import (
+	"context"
+
+	"shared/pkg/logger"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// logFieldKeys are the request scoped values every log line should carry
+var logFieldKeys = []ContextKey{
+	RequestIDKey,
+	CorrelationIDKey,
+	UserIDKey,
+	SessionIDKey,
+	APIVersionKey,
+}
+
+func init() {
+	logger.RegisterContextExtractor(LogFields)
+}
+
+// LogFields returns the request, correlation, user, session and API version
+// values set on ctx as log fields, skipping the ones that are missing, plus
+// the trace and span IDs when ctx carries a span
+func LogFields(ctx context.Context) []logger.Field {
+	fields := make([]logger.Field, 0, len(logFieldKeys)+2)
+	for _, key := range logFieldKeys {
+		if v := GetString(ctx, key); v != "" {
+			fields = append(fields, logger.String(string(key), v))
+		}
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			logger.String(string(TraceIDKey), sc.TraceID().String()),
+			logger.String(string(SpanIDKey), sc.SpanID().String()),
+		)
+	}
+	return fields
+}
@@ -0,0 +1,57 @@
+package env
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Commit and BuildTime are stamped at link time, e.g.
+//
+//	go build -ldflags "-X shared/server/env.Commit=$(git rev-parse HEAD) -X shared/server/env.BuildTime=$(date -u +%FT%TZ)"
+//
+// When left empty, Build falls back to the VCS stamp Go embeds in binaries
+// built from a checkout.
+var (
+	Commit    string
+	BuildTime string
+)
+
+// BuildInfo identifies the running binary
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Build describes the running binary; version is the service's configured
+// release version
+func Build(version string) BuildInfo {
+	info := BuildInfo{
+		Version:   version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	if info.Commit != "" && info.BuildTime != "" {
+		return info
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+	return info
+}
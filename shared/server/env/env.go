@@ -86,6 +86,18 @@ func IsTest() bool {
 	return os.Getenv("APP_ENV") == EnvTest
 }
 
+// LogLevelEndpointEnabled reports whether services should mount the runtime
+// log level endpoint
+func LogLevelEndpointEnabled() bool {
+	return os.Getenv("LOG_LEVEL_ENDPOINT_ENABLED") == "true"
+}
+
+// BodyLoggingEnabled reports whether services should log request and response
+// bodies at debug level
+func BodyLoggingEnabled() bool {
+	return os.Getenv("LOG_BODIES_ENABLED") == "true"
+}
+
 func LogLevel() string {
 	level := os.Getenv("LOG_LEVEL")
 	if level == "" {
@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"shared/pkg/logger"
+)
+
+const defaultBodyLogSize = 4096
+
+// BodyLoggerConfig controls which request and response bodies BodyLogger
+// writes to the debug log
+type BodyLoggerConfig struct {
+	Enabled bool
+	Logger  logger.Logger
+	// MaxBodySize caps the logged bytes of each body; 4096 when unset
+	MaxBodySize int
+	// SensitiveFields are JSON and form keys masked on top of the logger's
+	// default sensitive fields
+	SensitiveFields []string
+	// SkipPaths are path suffixes whose bodies are never logged; /login and
+	// /register when nil
+	SkipPaths []string
+}
+
+// BodyLogger logs request and response bodies at debug level with sensitive
+// keys masked. Only text bodies are logged, and JSON that has to be cut short
+// is left out entirely since it can't be redacted. Websocket upgrades pass
+// through untouched.
+func BodyLogger(config BodyLoggerConfig) Handler {
+	if !config.Enabled || config.Logger == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultBodyLogSize
+	}
+	if config.SkipPaths == nil {
+		config.SkipPaths = []string{"/login", "/register"}
+	}
+	sanitizer := logger.NewSanitizer(logger.Config{SensitiveFields: config.SensitiveFields})
+	log := config.Logger
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if log.Level() != logger.DebugLevel || skipBodyLogging(r, config.SkipPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var requestBody []byte
+			requestTruncated := false
+			requestType := r.Header.Get("Content-Type")
+			if r.Body != nil && r.Body != http.NoBody && isTextContent(requestType) {
+				body, err := io.ReadAll(io.LimitReader(r.Body, int64(config.MaxBodySize)+1))
+				rest := io.Reader(r.Body)
+				if err != nil {
+					rest = errReader{err}
+				}
+				// The handler reads what was captured, then the rest of the
+				// original body
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(body), rest), r.Body}
+				if len(body) > config.MaxBodySize {
+					body = body[:config.MaxBodySize]
+					requestTruncated = true
+				}
+				requestBody = body
+			}
+
+			cw := &bodyCapturingWriter{ResponseWriter: w, limit: config.MaxBodySize}
+			next.ServeHTTP(cw, r)
+
+			fields := []logger.Field{
+				logger.String("method", r.Method),
+				logger.String("path", r.URL.Path),
+				logger.Int("status", cw.status()),
+			}
+			if body, ok := formatLoggedBody(requestType, requestBody, requestTruncated, sanitizer); ok {
+				fields = append(fields, logger.String("request_body", body))
+			}
+			if !cw.hijacked {
+				responseType := cw.Header().Get("Content-Type")
+				if responseType == "" && cw.body.Len() > 0 {
+					responseType = http.DetectContentType(cw.body.Bytes())
+				}
+				if body, ok := formatLoggedBody(responseType, cw.body.Bytes(), cw.size > cw.body.Len(), sanitizer); ok {
+					fields = append(fields, logger.String("response_body", body))
+				}
+				fields = append(fields, logger.Int("response_size", cw.size))
+			}
+			log.Debug("HTTP bodies", fields...)
+		})
+	}
+}
+
+func skipBodyLogging(r *http.Request, skipPaths []string) bool {
+	if strings.ToLower(r.Header.Get("Upgrade")) == "websocket" &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") {
+		return true
+	}
+	for _, suffix := range skipPaths {
+		if strings.HasSuffix(r.URL.Path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTextContent reports whether bodies of contentType are readable enough to log
+func isTextContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
+
+// formatLoggedBody renders body for the log with sensitive keys masked; ok is
+// false when nothing should be logged
+func formatLoggedBody(contentType string, body []byte, truncated bool, sanitizer *logger.Sanitizer) (string, bool) {
+	if len(body) == 0 || !isTextContent(contentType) {
+		return "", false
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if truncated {
+			return fmt.Sprintf("[JSON over %d bytes not logged]", len(body)), true
+		}
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return "[invalid JSON not logged]", true
+		}
+		redacted, err := json.Marshal(redactJSON(value, sanitizer))
+		if err != nil {
+			return "", false
+		}
+		return string(redacted), true
+
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "[invalid form not logged]", true
+		}
+		for key := range values {
+			if sanitizer.IsSensitive(key) {
+				for i, v := range values[key] {
+					values[key][i] = sanitizer.Mask(v)
+				}
+			}
+		}
+		return withTruncation(values.Encode(), truncated), true
+	}
+
+	return withTruncation(string(body), truncated), true
+}
+
+func redactJSON(value any, sanitizer *logger.Sanitizer) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if sanitizer.IsSensitive(key) {
+				v[key] = sanitizer.Mask(item)
+				continue
+			}
+			v[key] = redactJSON(item, sanitizer)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactJSON(item, sanitizer)
+		}
+	}
+	return value
+}
+
+func withTruncation(body string, truncated bool) string {
+	if truncated {
+		return body + "...(truncated)"
+	}
+	return body
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// bodyCapturingWriter forwards the response and keeps its first limit bytes
+type bodyCapturingWriter struct {
+	http.ResponseWriter
+	limit      int
+	body       bytes.Buffer
+	size       int
+	statusCode int
+	hijacked   bool
+}
+
+func (cw *bodyCapturingWriter) WriteHeader(code int) {
+	if cw.statusCode == 0 {
+		cw.statusCode = code
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *bodyCapturingWriter) Write(b []byte) (int, error) {
+	if cw.statusCode == 0 {
+		cw.statusCode = http.StatusOK
+	}
+	if room := cw.limit - cw.body.Len(); room > 0 {
+		cw.body.Write(b[:min(room, len(b))])
+	}
+	n, err := cw.ResponseWriter.Write(b)
+	cw.size += n
+	return n, err
+}
+
+func (cw *bodyCapturingWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *bodyCapturingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("body logger: response writer does not support hijacking")
+	}
+	cw.hijacked = true
+	return hj.Hijack()
+}
+
+func (cw *bodyCapturingWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *bodyCapturingWriter) status() int {
+	if cw.statusCode == 0 {
+		return http.StatusOK
+	}
+	return cw.statusCode
+}
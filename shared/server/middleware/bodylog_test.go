@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shared/pkg/logger"
+)
+
+// debugRecorder keeps the fields of every Debug call
+type debugRecorder struct {
+	logger.Logger
+	entries [][]logger.Field
+}
+
+func (d *debugRecorder) Debug(msg string, fields ...logger.Field) {
+	d.entries = append(d.entries, fields)
+}
+
+func (d *debugRecorder) Level() logger.Level {
+	return logger.DebugLevel
+}
+
+func (d *debugRecorder) field(t *testing.T, key string) string {
+	t.Helper()
+	if len(d.entries) != 1 {
+		t.Fatalf("got %d debug entries, want 1", len(d.entries))
+	}
+	for _, f := range d.entries[0] {
+		if f.Key() == key {
+			return f.Value().(string)
+		}
+	}
+	return ""
+}
+
+func TestBodyLoggerHandlerSeesFullBody(t *testing.T) {
+	log := &debugRecorder{Logger: logger.NewNoop()}
+	body := `{"name":"echo","password":"hunter22","notes":"` + strings.Repeat("x", 100) + `"}`
+
+	var seen string
+	handler := BodyLogger(BodyLoggerConfig{Enabled: true, Logger: log, MaxBodySize: 32})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("read body: %v", err)
+		}
+		seen = string(data)
+		w.Write([]byte("ok"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/profile", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seen != body {
+		t.Fatalf("handler read %d bytes, want the full %d", len(seen), len(body))
+	}
+	if got := log.field(t, "request_body"); strings.Contains(got, "hunter22") || strings.Contains(got, "echo") {
+		t.Fatalf("request_body = %q, want oversized JSON left out", got)
+	}
+	if got := log.field(t, "response_body"); got != "ok" {
+		t.Fatalf("response_body = %q, want ok", got)
+	}
+}
+
+func TestBodyLoggerRedactsJSONKeys(t *testing.T) {
+	log := &debugRecorder{Logger: logger.NewNoop()}
+	handler := BodyLogger(BodyLoggerConfig{
+		Enabled:         true,
+		Logger:          log,
+		SensitiveFields: []string{"otp"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"user":{"access_token":"abcdefgh"}}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(`{"phone":"555","otp":"123456"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := log.field(t, "request_body"); strings.Contains(got, "123456") || !strings.Contains(got, "555") {
+		t.Fatalf("request_body = %q, want otp masked and phone kept", got)
+	}
+	if got := log.field(t, "response_body"); strings.Contains(got, "abcdefgh") {
+		t.Fatalf("response_body = %q, want the nested token masked", got)
+	}
+}
+
+func TestBodyLoggerSkipsPathsAndBinaryBodies(t *testing.T) {
+	log := &debugRecorder{Logger: logger.NewNoop()}
+	handler := BodyLogger(BodyLoggerConfig{Enabled: true, Logger: log})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	}))
+
+	login := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"password":"x"}`))
+	login.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), login)
+	if len(log.entries) != 0 {
+		t.Fatalf("got %d entries for a skipped path, want 0", len(log.entries))
+	}
+
+	upload := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("binary"))
+	upload.Header.Set("Content-Type", "application/octet-stream")
+	handler.ServeHTTP(httptest.NewRecorder(), upload)
+	if got := log.field(t, "request_body"); got != "" {
+		t.Fatalf("request_body = %q, want binary bodies left out", got)
+	}
+	if got := log.field(t, "response_body"); got != "" {
+		t.Fatalf("response_body = %q, want binary bodies left out", got)
+	}
+}
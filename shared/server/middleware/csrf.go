@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	sContext "shared/server/context"
+	"shared/server/response"
+)
+
+const csrfTokenBytes = 32
+
+type CSRFConfig struct {
+	// CookieName defaults to csrf_token
+	CookieName string
+	// HeaderName defaults to X-CSRF-Token
+	HeaderName   string
+	CookiePath   string
+	CookieDomain string
+	Secure       bool
+	// SameSite defaults to Lax
+	SameSite http.SameSite
+	// MaxAge is how long the cookie lives; 12 hours when unset
+	MaxAge time.Duration
+	// ExemptPaths are exact paths or path.Match patterns that skip the check
+	ExemptPaths []string
+}
+
+// CSRF implements the double-submit cookie pattern: every client gets a
+// random token in a cookie and unsafe requests must echo it in the header.
+// Requests authenticated only by a bearer token carry no cookies a browser
+// could attach on its own, so they are let through.
+func CSRF(config CSRFConfig) Handler {
+	if config.CookieName == "" {
+		config.CookieName = "csrf_token"
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = "/"
+	}
+	if config.SameSite == 0 {
+		config.SameSite = http.SameSiteLaxMode
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = 12 * time.Hour
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, pattern := range config.ExemptPaths {
+				if matchPath(r.URL.Path, pattern) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			if isBearerOnly(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cookieToken := ""
+			if cookie, err := r.Cookie(config.CookieName); err == nil {
+				cookieToken = cookie.Value
+			}
+
+			token := cookieToken
+			if token == "" {
+				var err error
+				if token, err = newCSRFToken(); err != nil {
+					response.InternalServerError(r.Context(), r, w, "Failed to issue CSRF token", err)
+					return
+				}
+				http.SetCookie(w, &http.Cookie{
+					Name:     config.CookieName,
+					Value:    token,
+					Path:     config.CookiePath,
+					Domain:   config.CookieDomain,
+					MaxAge:   int(config.MaxAge.Seconds()),
+					Secure:   config.Secure,
+					SameSite: config.SameSite,
+					// Scripts read the cookie to send it back in the header
+					HttpOnly: false,
+				})
+			}
+			ctx := context.WithValue(r.Context(), sContext.CSRFTokenKey, token)
+
+			if isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			headerToken := r.Header.Get(config.HeaderName)
+			switch {
+			case cookieToken == "":
+				response.ForbiddenError(ctx, r, w, "CSRF token missing", errors.New("csrf cookie not set"))
+				return
+			case headerToken == "":
+				response.ForbiddenError(ctx, r, w, "CSRF token missing", errors.New(config.HeaderName+" header not set"))
+				return
+			case subtle.ConstantTimeCompare([]byte(headerToken), []byte(cookieToken)) != 1:
+				response.ForbiddenError(ctx, r, w, "CSRF token mismatch", nil)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetCSRFToken returns the CSRF token for this request, for handlers that
+// render it into a page or a response body
+func GetCSRFToken(ctx context.Context) string {
+	if token, ok := ctx.Value(sContext.CSRFTokenKey).(string); ok {
+		return token
+	}
+	return ""
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// isBearerOnly reports whether r authenticates with a bearer token and sends
+// no cookies
+func isBearerOnly(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	return strings.HasPrefix(auth, "Bearer ") && r.Header.Get("Cookie") == ""
+}
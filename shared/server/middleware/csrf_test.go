@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func csrfTestHandler(config CSRFConfig) (http.Handler, *string) {
+	var seen string
+	handler := CSRF(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetCSRFToken(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+	return handler, &seen
+}
+
+func issueCSRFCookie(t *testing.T, handler http.Handler) *http.Cookie {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/profile", nil))
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "csrf_token" {
+			return cookie
+		}
+	}
+	t.Fatal("GET did not set the csrf_token cookie")
+	return nil
+}
+
+func TestCSRFAcceptsMatchingToken(t *testing.T) {
+	handler, seen := csrfTestHandler(CSRFConfig{})
+	cookie := issueCSRFCookie(t, handler)
+
+	req := httptest.NewRequest(http.MethodPost, "/profile", nil)
+	req.AddCookie(cookie)
+	req.Header.Set("X-CSRF-Token", cookie.Value)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if *seen != cookie.Value {
+		t.Fatalf("GetCSRFToken() = %q, want the cookie token", *seen)
+	}
+}
+
+func TestCSRFRejectsMissingAndMismatchedHeader(t *testing.T) {
+	handler, _ := csrfTestHandler(CSRFConfig{})
+	cookie := issueCSRFCookie(t, handler)
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{name: "missing header", header: ""},
+		{name: "mismatch", header: "not-the-token"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodDelete, "/profile", nil)
+		req.AddCookie(cookie)
+		if tt.header != "" {
+			req.Header.Set("X-CSRF-Token", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("%s: status = %d, want 403", tt.name, rec.Code)
+		}
+	}
+}
+
+func TestCSRFRejectsUnsafeRequestWithoutCookie(t *testing.T) {
+	handler, _ := csrfTestHandler(CSRFConfig{})
+
+	req := httptest.NewRequest(http.MethodPost, "/profile", nil)
+	req.Header.Set("X-CSRF-Token", "forged")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", rec.Code)
+	}
+}
+
+func TestCSRFSkipsBearerOnlyAndExemptPaths(t *testing.T) {
+	handler, _ := csrfTestHandler(CSRFConfig{ExemptPaths: []string{"/webhooks/*"}})
+
+	bearer := httptest.NewRequest(http.MethodPost, "/profile", nil)
+	bearer.Header.Set("Authorization", "Bearer abc")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, bearer)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("bearer only: status = %d, want 200", rec.Code)
+	}
+
+	withCookie := httptest.NewRequest(http.MethodPost, "/profile", nil)
+	withCookie.Header.Set("Authorization", "Bearer abc")
+	withCookie.AddCookie(&http.Cookie{Name: "session", Value: "s"})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, withCookie)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("bearer with cookie: status = %d, want 403", rec.Code)
+	}
+
+	exempt := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, exempt)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("exempt path: status = %d, want 200", rec.Code)
+	}
+}
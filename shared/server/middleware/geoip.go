@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"shared/pkg/logger"
+	sContext "shared/server/context"
+)
+
+const (
+	DefaultGeoIPTimeout  = 200 * time.Millisecond
+	DefaultGeoIPCacheTTL = 10 * time.Minute
+
+	geoIPFailureTTL      = 30 * time.Second
+	maxGeoIPCacheEntries = 10000
+)
+
+// GeoLocation mirrors the location-service lookup response.
+type GeoLocation struct {
+	Latitude      float64 `json:"latitude,omitempty"`
+	Longitude     float64 `json:"longitude,omitempty"`
+	City          string  `json:"city,omitempty"`
+	Continent     string  `json:"continent,omitempty"`
+	ContinentCode string  `json:"continent_code,omitempty"`
+	State         string  `json:"state,omitempty"`
+	StateCode     string  `json:"state_code,omitempty"`
+	PostalCode    string  `json:"postal_code,omitempty"`
+	Country       string  `json:"country,omitempty"`
+	CountryCode   string  `json:"country_code,omitempty"`
+	Timezone      string  `json:"timezone,omitempty"`
+	ISP           string  `json:"isp,omitempty"`
+	IP            string  `json:"ip,omitempty"`
+}
+
+type geoIPOptions struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	client   *http.Client
+}
+
+type GeoIPOption func(*geoIPOptions)
+
+func WithGeoIPTimeout(timeout time.Duration) GeoIPOption {
+	return func(o *geoIPOptions) {
+		o.timeout = timeout
+	}
+}
+
+func WithGeoIPCacheTTL(ttl time.Duration) GeoIPOption {
+	return func(o *geoIPOptions) {
+		o.cacheTTL = ttl
+	}
+}
+
+func WithGeoIPClient(client *http.Client) GeoIPOption {
+	return func(o *geoIPOptions) {
+		o.client = client
+	}
+}
+
+type geoIPCacheEntry struct {
+	location  *GeoLocation
+	expiresAt time.Time
+}
+
+type geoIPCache struct {
+	mu      sync.Mutex
+	entries map[string]geoIPCacheEntry
+}
+
+func (c *geoIPCache) get(ip string) (*GeoLocation, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[ip]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.location, true
+}
+
+func (c *geoIPCache) set(ip string, location *GeoLocation, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.entries) >= maxGeoIPCacheEntries {
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= maxGeoIPCacheEntries {
+			c.entries = make(map[string]geoIPCacheEntry)
+		}
+	}
+	c.entries[ip] = geoIPCacheEntry{location: location, expiresAt: now.Add(ttl)}
+}
+
+// GeoIP resolves the client IP (as left in RemoteAddr by RealIP) against the
+// location-service lookup endpoint and stores the result in the request
+// context. Lookups that fail or time out attach nil and never fail the request.
+// Results are cached per IP for the cache TTL, failures for a shorter period.
+func GeoIP(endpoint string, log logger.Logger, opts ...GeoIPOption) Handler {
+	options := geoIPOptions{
+		timeout:  DefaultGeoIPTimeout,
+		cacheTTL: DefaultGeoIPCacheTTL,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.client == nil {
+		options.client = &http.Client{}
+	}
+
+	failureTTL := geoIPFailureTTL
+	if options.cacheTTL < failureTTL {
+		failureTTL = options.cacheTTL
+	}
+	cache := &geoIPCache{entries: make(map[string]geoIPCacheEntry)}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r.RemoteAddr)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := ip.String()
+			location, ok := cache.get(key)
+			if !ok {
+				var err error
+				location, err = lookupGeoLocation(r.Context(), options.client, endpoint, key, options.timeout)
+				switch {
+				case err == nil:
+					cache.set(key, location, options.cacheTTL)
+				case r.Context().Err() == nil:
+					log.Warn("GeoIP lookup failed",
+						logger.String("ip", key),
+						logger.Error(err),
+					)
+					cache.set(key, nil, failureTTL)
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), sContext.GeoLocationKey, location)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func GetGeoLocation(ctx context.Context) *GeoLocation {
+	if location, ok := ctx.Value(sContext.GeoLocationKey).(*GeoLocation); ok {
+		return location
+	}
+	return nil
+}
+
+func clientIP(remoteAddr string) net.IP {
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+	return net.ParseIP(host)
+}
+
+func lookupGeoLocation(ctx context.Context, client *http.Client, endpoint, ip string, timeout time.Duration) (*GeoLocation, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?ip=%s", endpoint, url.QueryEscape(ip)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("location service returned status %d", resp.StatusCode)
+	}
+
+	var location GeoLocation
+	if err := json.NewDecoder(resp.Body).Decode(&location); err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
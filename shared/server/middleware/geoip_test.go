@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"shared/pkg/logger"
+)
+
+func TestGeoIPAttachesAndCachesLocation(t *testing.T) {
+	var lookups int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&lookups, 1)
+		json.NewEncoder(w).Encode(GeoLocation{IP: r.URL.Query().Get("ip"), Country: "Germany", City: "Berlin"})
+	}))
+	defer upstream.Close()
+
+	var got *GeoLocation
+	handler := GeoIP(upstream.URL+"/lookup", logger.NewNoop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = GetGeoLocation(r.Context())
+	}))
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "8.8.8.8:1234"
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if got == nil || got.City != "Berlin" || got.IP != "8.8.8.8" {
+		t.Fatalf("unexpected location: %+v", got)
+	}
+	if n := atomic.LoadInt32(&lookups); n != 1 {
+		t.Fatalf("expected one upstream lookup, got %d", n)
+	}
+}
+
+func TestGeoIPAttachesNilOnTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer upstream.Close()
+
+	called := false
+	handler := GeoIP(upstream.URL, logger.NewNoop(), WithGeoIPTimeout(20*time.Millisecond))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if loc := GetGeoLocation(r.Context()); loc != nil {
+			t.Fatalf("expected nil location, got %+v", loc)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "1.1.1.1:443"
+	start := time.Now()
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !called {
+		t.Fatalf("expected request to reach the handler")
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Fatalf("lookup blocked the request for %s", elapsed)
+	}
+}
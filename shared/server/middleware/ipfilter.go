@@ -0,0 +1,221 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"shared/pkg/logger"
+	"shared/server/response"
+)
+
+type IPFilterConfig struct {
+	// Allow and Deny hold single IPs or CIDR ranges, IPv4 or IPv6
+	Allow []string
+	Deny  []string
+	// File adds rules read from disk, one per line as "allow <ip|cidr>" or
+	// "deny <ip|cidr>"; blank lines and lines starting with # are skipped.
+	// Changes are picked up without a restart.
+	File string
+	// ReloadInterval is how often File is checked for changes; 30s when unset
+	ReloadInterval time.Duration
+	// Logger reports rule files that fail to reload
+	Logger logger.Logger
+}
+
+type ipRules struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+type ipFilter struct {
+	config IPFilterConfig
+	static ipRules
+	rules  atomic.Pointer[ipRules]
+
+	reloadMu  sync.Mutex
+	checkedAt time.Time
+	modTime   time.Time
+}
+
+// IPFilter blocks requests by client IP as left in RemoteAddr by RealIP, so
+// it belongs after RealIP in the chain. Deny wins over allow, and an empty
+// allow list lets every address through that isn't denied. Blocked requests
+// get 403. Invalid rules panic at startup.
+func IPFilter(config IPFilterConfig) Handler {
+	if config.ReloadInterval <= 0 {
+		config.ReloadInterval = 30 * time.Second
+	}
+	if config.Logger == nil {
+		config.Logger = logger.NewNoop()
+	}
+
+	f := &ipFilter{config: config}
+	var err error
+	if f.static.allow, err = parseIPNets(config.Allow); err != nil {
+		panic(fmt.Sprintf("IPFilterConfig.Allow: %v", err))
+	}
+	if f.static.deny, err = parseIPNets(config.Deny); err != nil {
+		panic(fmt.Sprintf("IPFilterConfig.Deny: %v", err))
+	}
+	f.rules.Store(&f.static)
+	if config.File != "" {
+		if err := f.reload(time.Now()); err != nil {
+			panic(fmt.Sprintf("IPFilterConfig.File: %v", err))
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			f.maybeReload(time.Now())
+
+			ip := clientIP(r.RemoteAddr)
+			if ip == nil || !f.rules.Load().allows(ip) {
+				response.ForbiddenError(r.Context(), r, w, "Access denied", errors.New("client IP is not allowed"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (rules *ipRules) allows(ip net.IP) bool {
+	for _, n := range rules.deny {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	if len(rules.allow) == 0 {
+		return true
+	}
+	for _, n := range rules.allow {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// maybeReload rereads the rule file when it changed, at most once per
+// ReloadInterval. Requests that arrive during a reload use the old rules.
+func (f *ipFilter) maybeReload(now time.Time) {
+	if f.config.File == "" || !f.reloadMu.TryLock() {
+		return
+	}
+	defer f.reloadMu.Unlock()
+
+	if now.Sub(f.checkedAt) < f.config.ReloadInterval {
+		return
+	}
+	if err := f.reloadLocked(now); err != nil {
+		f.config.Logger.Error("Failed to reload IP filter rules, keeping the previous ones",
+			logger.String("file", f.config.File),
+			logger.Error(err),
+		)
+	}
+}
+
+func (f *ipFilter) reload(now time.Time) error {
+	f.reloadMu.Lock()
+	defer f.reloadMu.Unlock()
+	return f.reloadLocked(now)
+}
+
+func (f *ipFilter) reloadLocked(now time.Time) error {
+	f.checkedAt = now
+
+	info, err := os.Stat(f.config.File)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+
+	fileRules, err := readIPRules(f.config.File)
+	if err != nil {
+		return err
+	}
+	f.rules.Store(&ipRules{
+		allow: append(append([]*net.IPNet{}, f.static.allow...), fileRules.allow...),
+		deny:  append(append([]*net.IPNet{}, f.static.deny...), fileRules.deny...),
+	})
+	f.modTime = info.ModTime()
+	return nil
+}
+
+func readIPRules(path string) (*ipRules, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rules := &ipRules{}
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Fields(line)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: want \"allow|deny <ip|cidr>\", got %q", lineNo, line)
+		}
+		n, err := parseIPNet(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		switch strings.ToLower(parts[0]) {
+		case "allow":
+			rules.allow = append(rules.allow, n)
+		case "deny":
+			rules.deny = append(rules.deny, n)
+		default:
+			return nil, fmt.Errorf("line %d: unknown action %q", lineNo, parts[0])
+		}
+	}
+	return rules, scanner.Err()
+}
+
+func parseIPNets(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		n, err := parseIPNet(entry)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// parseIPNet accepts a CIDR range or a single address, which becomes a range
+// of one
+func parseIPNet(entry string) (*net.IPNet, error) {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", entry)
+		}
+		return n, nil
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP %q", entry)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func ipFilterStatus(handler http.Handler, remoteAddr string) int {
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestIPFilterRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     IPFilterConfig
+		remoteAddr string
+		want       int
+	}{
+		{name: "no rules", config: IPFilterConfig{}, remoteAddr: "203.0.113.9:5000", want: http.StatusOK},
+		{name: "denied ip", config: IPFilterConfig{Deny: []string{"203.0.113.9"}}, remoteAddr: "203.0.113.9:5000", want: http.StatusForbidden},
+		{name: "other ip with deny list", config: IPFilterConfig{Deny: []string{"203.0.113.9"}}, remoteAddr: "203.0.113.10:5000", want: http.StatusOK},
+		{name: "inside allowed cidr", config: IPFilterConfig{Allow: []string{"10.0.0.0/8"}}, remoteAddr: "10.20.30.40:80", want: http.StatusOK},
+		{name: "outside allowed cidr", config: IPFilterConfig{Allow: []string{"10.0.0.0/8"}}, remoteAddr: "11.0.0.1:80", want: http.StatusForbidden},
+		{name: "deny wins over allow", config: IPFilterConfig{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.1.0.0/16"}}, remoteAddr: "10.1.2.3:80", want: http.StatusForbidden},
+		{name: "address without port from RealIP", config: IPFilterConfig{Allow: []string{"192.168.1.1"}}, remoteAddr: "192.168.1.1", want: http.StatusOK},
+		{name: "ipv6 in allowed range", config: IPFilterConfig{Allow: []string{"2001:db8::/32"}}, remoteAddr: "[2001:db8::1]:443", want: http.StatusOK},
+		{name: "ipv6 outside allowed range", config: IPFilterConfig{Allow: []string{"2001:db8::/32"}}, remoteAddr: "[2001:db9::1]:443", want: http.StatusForbidden},
+		{name: "denied ipv6 address", config: IPFilterConfig{Deny: []string{"::1"}}, remoteAddr: "[::1]:443", want: http.StatusForbidden},
+		{name: "unparseable address", config: IPFilterConfig{}, remoteAddr: "not-an-ip", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if got := ipFilterStatus(IPFilter(tt.config)(okHandler()), tt.remoteAddr); got != tt.want {
+			t.Fatalf("%s: status = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIPFilterReloadsRuleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ip-rules")
+	if err := os.WriteFile(path, []byte("# blocked ranges\ndeny 198.51.100.0/24\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := IPFilter(IPFilterConfig{File: path, ReloadInterval: time.Nanosecond})(okHandler())
+	if got := ipFilterStatus(handler, "198.51.100.7:1"); got != http.StatusForbidden {
+		t.Fatalf("before reload: status = %d, want 403", got)
+	}
+	if got := ipFilterStatus(handler, "192.0.2.1:1"); got != http.StatusOK {
+		t.Fatalf("before reload: status = %d, want 200", got)
+	}
+
+	if err := os.WriteFile(path, []byte("deny 192.0.2.0/24\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ipFilterStatus(handler, "198.51.100.7:1"); got != http.StatusOK {
+		t.Fatalf("after reload: status = %d, want 200", got)
+	}
+	if got := ipFilterStatus(handler, "192.0.2.1:1"); got != http.StatusForbidden {
+		t.Fatalf("after reload: status = %d, want 403", got)
+	}
+}
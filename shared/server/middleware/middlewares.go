@@ -1,20 +1,31 @@
 package middleware
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"path"
 	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 
 	cache "shared/pkg/cache"
 	"shared/pkg/logger"
+	"shared/server/common/token"
 	sContext "shared/server/context"
 	"shared/server/response"
 )
@@ -63,9 +74,10 @@ func Timeout(timeout time.Duration) Handler {
 			ctx, cancel := context.WithTimeout(r.Context(), timeout)
 			defer cancel()
 
+			tw := &timeoutWriter{w: w, h: make(http.Header)}
 			done := make(chan struct{})
 			go func() {
-				next.ServeHTTP(w, r.WithContext(ctx))
+				next.ServeHTTP(tw, r.WithContext(ctx))
 				close(done)
 			}()
 
@@ -73,17 +85,102 @@ func Timeout(timeout time.Duration) Handler {
 			case <-done:
 				return
 			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if tw.wroteHeader || tw.hijacked {
+					// The handler already started its response; the
+					// status line is gone, so all that is left is to stop it
+					return
+				}
 				serverName := ""
 				if r.TLS != nil {
 					serverName = r.TLS.ServerName
 				}
-				w.WriteHeader(http.StatusRequestTimeout)
 				response.GatewayTimeoutError(r.Context(), r, w, serverName)
 			}
 		})
 	}
 }
 
+// timeoutWriter stands between a handler running under Timeout and the real
+// ResponseWriter. Once the deadline passes it discards whatever the handler
+// still writes, so the timeout response is the only one sent. The handler
+// gets its own header map, copied over when it writes its status.
+type timeoutWriter struct {
+	w http.ResponseWriter
+	h http.Header
+
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+	hijacked    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	dst := tw.w.Header()
+	for k, v := range tw.h {
+		dst[k] = v
+	}
+	tw.wroteHeader = true
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.w.Write(b)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the raw connection to the handler, e.g. for a websocket
+// upgrade; the deadline no longer produces a response after that
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	hj, ok := tw.w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("timeout: response writer does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		tw.hijacked = true
+	}
+	return conn, rw, err
+}
+
 func Cache(duration time.Duration, client cache.Cache) Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -101,6 +198,113 @@ func Cache(duration time.Duration, client cache.Cache) Handler {
 	}
 }
 
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type idempotentResponse struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body"`
+}
+
+// Idempotency stores the first response to a mutating request carrying an
+// Idempotency-Key header and replays it for repeats of the same key, method
+// and path within ttl. A repeat that arrives while the first request is still
+// in flight gets 409 Conflict.
+func Idempotency(client cache.Cache, ttl time.Duration) Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !isMutatingMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sum := sha256.Sum256([]byte(key + "|" + r.Method + "|" + r.URL.Path))
+			responseKey := "idempotency:" + hex.EncodeToString(sum[:])
+			lockKey := responseKey + ":lock"
+			ctx := r.Context()
+
+			if stored, err := client.Get(ctx, responseKey); err == nil && stored != nil {
+				var cached idempotentResponse
+				if err := json.Unmarshal(stored, &cached); err == nil {
+					if cached.ContentType != "" {
+						w.Header().Set("Content-Type", cached.ContentType)
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(cached.StatusCode)
+					w.Write(cached.Body)
+					return
+				}
+			}
+
+			// The lock is taken with its TTL in one command so a crash before
+			// the deferred Delete cannot leave it held forever
+			acquired, err := client.SetNX(ctx, lockKey, []byte("1"), ttl)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !acquired {
+				response.ConflictError(ctx, r, w, "A request with this idempotency key is already in progress", errors.New("idempotency key in use"))
+				return
+			}
+			defer client.Delete(context.WithoutCancel(ctx), lockKey)
+
+			captured := &capturingResponseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+			next.ServeHTTP(captured, r)
+
+			// Server errors are not stored so the client can retry them.
+			if captured.statusCode >= http.StatusInternalServerError {
+				return
+			}
+			payload, err := json.Marshal(idempotentResponse{
+				StatusCode:  captured.statusCode,
+				ContentType: captured.Header().Get("Content-Type"),
+				Body:        captured.body.Bytes(),
+			})
+			if err != nil {
+				return
+			}
+			client.Set(context.WithoutCancel(ctx), responseKey, payload, ttl)
+		})
+	}
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// capturingResponseWriter forwards the response while keeping a copy of the body.
+type capturingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+	written    bool
+}
+
+func (rw *capturingResponseWriter) WriteHeader(code int) {
+	if !rw.written {
+		rw.statusCode = code
+		rw.written = true
+		rw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (rw *capturingResponseWriter) Write(b []byte) (int, error) {
+	if !rw.written {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
 type RecoveryConfig struct {
 	PrintStack bool
 	StackSize  int
@@ -246,28 +450,75 @@ func (rw *responseWriterWithSize) Write(b []byte) (int, error) {
 	return size, err
 }
 
-func CORS(allowedOrigins []string, allowedMethods []string, allowedHeaders []string) Handler {
+type corsOptions struct {
+	maxAge      time.Duration
+	credentials bool
+}
+
+type CORSOption func(*corsOptions)
+
+// WithCORSMaxAge sets how long browsers may cache a preflight result; zero
+// leaves Access-Control-Max-Age unset
+func WithCORSMaxAge(maxAge time.Duration) CORSOption {
+	return func(o *corsOptions) {
+		o.maxAge = maxAge
+	}
+}
+
+// WithCORSCredentials sets whether browsers may send cookies and
+// authorization headers on cross-origin requests
+func WithCORSCredentials(allow bool) CORSOption {
+	return func(o *corsOptions) {
+		o.credentials = allow
+	}
+}
+
+// CORS answers cross-origin requests from allowedOrigins. A "*" in
+// allowedOrigins admits any origin and one in allowedHeaders admits whatever
+// headers a preflight asks for. Credentialed responses always name the
+// concrete origin, since browsers reject credentials with a wildcard.
+func CORS(allowedOrigins []string, allowedMethods []string, allowedHeaders []string, opts ...CORSOption) Handler {
+	o := corsOptions{
+		maxAge:      24 * time.Hour,
+		credentials: true,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	anyOrigin := slices.Contains(allowedOrigins, "*")
+	anyHeader := slices.Contains(allowedHeaders, "*")
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
-			allowed := false
-			for _, allowedOrigin := range allowedOrigins {
-				if allowedOrigin == "*" || allowedOrigin == origin {
-					allowed = true
-					break
-				}
-			}
+			allowed := anyOrigin || (origin != "" && slices.Contains(allowedOrigins, origin))
 
 			if allowed {
-				if origin != "" {
-					w.Header().Set("Access-Control-Allow-Origin", origin)
+				h := w.Header()
+				switch {
+				case origin == "":
+					h.Set("Access-Control-Allow-Origin", "*")
+				case anyOrigin && !o.credentials:
+					h.Set("Access-Control-Allow-Origin", "*")
+				default:
+					h.Set("Access-Control-Allow-Origin", origin)
+					h.Add("Vary", "Origin")
+					if o.credentials {
+						h.Set("Access-Control-Allow-Credentials", "true")
+					}
+				}
+
+				h.Set("Access-Control-Allow-Methods", joinStrings(allowedMethods))
+				if requested := r.Header.Get("Access-Control-Request-Headers"); anyHeader && requested != "" {
+					h.Set("Access-Control-Allow-Headers", requested)
+					h.Add("Vary", "Access-Control-Request-Headers")
 				} else {
-					w.Header().Set("Access-Control-Allow-Origin", "*")
+					h.Set("Access-Control-Allow-Headers", joinStrings(allowedHeaders))
+				}
+				if o.maxAge > 0 {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(int(o.maxAge.Seconds())))
 				}
-				w.Header().Set("Access-Control-Allow-Methods", joinStrings(allowedMethods))
-				w.Header().Set("Access-Control-Allow-Headers", joinStrings(allowedHeaders))
-				w.Header().Set("Access-Control-Allow-Credentials", "true")
-				w.Header().Set("Access-Control-Max-Age", "86400")
 			}
 
 			if r.Method == http.MethodOptions {
@@ -396,6 +647,14 @@ func GetSessionID(ctx context.Context) string {
 	return ""
 }
 
+// GetTokenClaims returns the claims JWTAuth validated for this request.
+func GetTokenClaims(ctx context.Context) *token.Claims {
+	if claims, ok := ctx.Value(sContext.TokenClaimsKey).(*token.Claims); ok {
+		return claims
+	}
+	return nil
+}
+
 func GetStartTime(ctx context.Context) time.Time {
 	if t, ok := ctx.Value(sContext.StartTimeKey).(time.Time); ok {
 		return t
@@ -411,10 +670,30 @@ type MetricsRecorder interface {
 	RecordRequest(method, path string, statusCode int, duration time.Duration)
 }
 
+// InFlightRecorder is optionally implemented by a MetricsRecorder to track
+// requests that are currently being served.
+type InFlightRecorder interface {
+	RequestStarted(method, path string)
+	RequestFinished(method, path string)
+}
+
+// UnmatchedRoute is the path label used for requests that matched no route.
+const UnmatchedRoute = "unmatched"
+
+// Metrics records every request against the matched route template (e.g.
+// /profile/{user_id}) instead of the raw URL to keep label cardinality bounded.
 func Metrics(recorder MetricsRecorder) Handler {
+	inFlight, _ := recorder.(InFlightRecorder)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			route := RoutePattern(r)
+
+			if inFlight != nil {
+				inFlight.RequestStarted(r.Method, route)
+				defer inFlight.RequestFinished(r.Method, route)
+			}
 
 			wrapped := &responseWriter{
 				ResponseWriter: w,
@@ -424,11 +703,22 @@ func Metrics(recorder MetricsRecorder) Handler {
 			next.ServeHTTP(wrapped, r)
 
 			duration := time.Since(start)
-			recorder.RecordRequest(r.Method, r.URL.Path, wrapped.statusCode, duration)
+			recorder.RecordRequest(r.Method, route, wrapped.statusCode, duration)
 		})
 	}
 }
 
+// RoutePattern returns the mux path template of the route matched for r, or
+// UnmatchedRoute when the request was not routed.
+func RoutePattern(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil && tmpl != "" {
+			return tmpl
+		}
+	}
+	return UnmatchedRoute
+}
+
 type KeyFuncHandler func(remoteAddr string, path string) string
 
 type RateLimitConfig struct {
@@ -436,6 +726,25 @@ type RateLimitConfig struct {
 	Window            time.Duration
 	KeyFunc           KeyFuncHandler
 	OnLimitExceeded   func(w http.ResponseWriter, r *http.Request)
+
+	// KeyByUser limits signed-in users individually rather than by address,
+	// as WithRateLimitKeyByUser does; KeyFunc then receives UserOrIPKey in
+	// place of the remote address. Requires the auth middleware to run first.
+	KeyByUser bool
+}
+
+// UserOrIPKey identifies r's client as "user:<id>" when an auth middleware
+// has put a user ID in the request context, and as "ip:<addr>" otherwise, so
+// users behind one NAT or carrier gateway are told apart.
+func UserOrIPKey(r *http.Request) string {
+	if userID := GetUserID(r.Context()); userID != "" {
+		return "user:" + userID
+	}
+	addr := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return "ip:" + addr
 }
 
 type rateLimitEntry struct {
@@ -444,8 +753,21 @@ type rateLimitEntry struct {
 	mu        sync.Mutex
 }
 
-func RateLimit(config RateLimitConfig) Handler {
-	store := &sync.Map{}
+func RateLimit(config RateLimitConfig, opts ...RateLimitOption) Handler {
+	options := newRateLimitOptions(opts)
+	if config.KeyByUser {
+		options.keyByUser = true
+	}
+	limiter := newLimiterHandle(options,
+		func(now time.Time) *rateLimitEntry {
+			return &rateLimitEntry{resetTime: now.Add(config.Window)}
+		},
+		func(entry *rateLimitEntry, now time.Time) bool {
+			entry.mu.Lock()
+			defer entry.mu.Unlock()
+			return now.After(entry.resetTime)
+		},
+	)
 
 	if config.KeyFunc == nil {
 		config.KeyFunc = func(remoteAddr string, path string) string {
@@ -464,14 +786,10 @@ func RateLimit(config RateLimitConfig) Handler {
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			key := config.KeyFunc(r.RemoteAddr, r.URL.Path)
+			key := config.KeyFunc(options.clientKey(r), r.URL.Path)
 			now := time.Now()
 
-			val, _ := store.LoadOrStore(key, &rateLimitEntry{
-				count:     0,
-				resetTime: now.Add(config.Window),
-			})
-			entry := val.(*rateLimitEntry)
+			entry := limiter.store.get(key, now)
 
 			entry.mu.Lock()
 			if now.After(entry.resetTime) {
@@ -503,30 +821,32 @@ func RateLimit(config RateLimitConfig) Handler {
 	}
 }
 
-func TokenBucketRateLimit(requests int, window time.Duration) Handler {
+func TokenBucketRateLimit(requests int, window time.Duration, opts ...RateLimitOption) Handler {
 	type bucket struct {
 		tokens        int
 		lastTokenTime time.Time
 		mu            sync.Mutex
 	}
 
-	buckets := make(map[string]*bucket)
-	var mu sync.Mutex
+	// A bucket left alone for a whole window has refilled, so forgetting it
+	// loses nothing
+	options := newRateLimitOptions(opts)
+	buckets := newLimiterHandle(options,
+		func(now time.Time) *bucket {
+			return &bucket{tokens: requests, lastTokenTime: now}
+		},
+		func(b *bucket, now time.Time) bool {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			return now.Sub(b.lastTokenTime) >= window
+		},
+	)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			key := r.RemoteAddr
+			key := options.clientKey(r)
 			now := time.Now()
 
-			mu.Lock()
-			b, exists := buckets[key]
-			if !exists {
-				b = &bucket{
-					tokens:        requests,
-					lastTokenTime: now,
-				}
-				buckets[key] = b
-			}
-			mu.Unlock()
+			b := buckets.store.get(key, now)
 
 			b.mu.Lock()
 			elapsed := now.Sub(b.lastTokenTime)
@@ -563,28 +883,28 @@ func TokenBucketRateLimit(requests int, window time.Duration) Handler {
 	}
 }
 
-func FixedWindowRateLimit(requests int, window time.Duration) Handler {
+func FixedWindowRateLimit(requests int, window time.Duration, opts ...RateLimitOption) Handler {
 	type windowData struct {
 		count     int
 		resetTime time.Time
 		mu        sync.Mutex
 	}
-	var clients = make(map[string]*windowData)
-	var mu sync.Mutex
+	options := newRateLimitOptions(opts)
+	clients := newLimiterHandle(options,
+		func(now time.Time) *windowData {
+			return &windowData{resetTime: now.Add(window)}
+		},
+		func(data *windowData, now time.Time) bool {
+			data.mu.Lock()
+			defer data.mu.Unlock()
+			return now.After(data.resetTime)
+		},
+	)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			key := r.RemoteAddr
+			key := options.clientKey(r)
 			now := time.Now()
-			mu.Lock()
-			data, exists := clients[key]
-			if !exists {
-				data = &windowData{
-					count:     0,
-					resetTime: now.Add(window),
-				}
-				clients[key] = data
-			}
-			mu.Unlock()
+			data := clients.store.get(key, now)
 			data.mu.Lock()
 			if now.After(data.resetTime) {
 				data.count = 0
@@ -613,42 +933,88 @@ func FixedWindowRateLimit(requests int, window time.Duration) Handler {
 	}
 }
 
-func SlidingWindowRateLimit(requests int, window time.Duration) Handler {
+// timestampRing holds the times of a client's most recent requests, oldest
+// first, in a buffer sized to the request limit
+type timestampRing struct {
+	buf   []time.Time
+	head  int
+	count int
+}
+
+func newTimestampRing(capacity int) *timestampRing {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &timestampRing{buf: make([]time.Time, capacity)}
+}
+
+// expire drops timestamps at least window older than now
+func (tr *timestampRing) expire(now time.Time, window time.Duration) {
+	for tr.count > 0 && now.Sub(tr.buf[tr.head]) >= window {
+		tr.head = (tr.head + 1) % len(tr.buf)
+		tr.count--
+	}
+}
+
+func (tr *timestampRing) full() bool {
+	return tr.count >= len(tr.buf)
+}
+
+// push records t; the caller checks full first
+func (tr *timestampRing) push(t time.Time) {
+	tr.buf[(tr.head+tr.count)%len(tr.buf)] = t
+	tr.count++
+}
+
+func (tr *timestampRing) oldest() (time.Time, bool) {
+	if tr.count == 0 {
+		return time.Time{}, false
+	}
+	return tr.buf[tr.head], true
+}
+
+func (tr *timestampRing) newest() (time.Time, bool) {
+	if tr.count == 0 {
+		return time.Time{}, false
+	}
+	return tr.buf[(tr.head+tr.count-1)%len(tr.buf)], true
+}
+
+func SlidingWindowRateLimit(requests int, window time.Duration, opts ...RateLimitOption) Handler {
 	type clientData struct {
-		timestamps []time.Time
+		timestamps *timestampRing
 		mu         sync.Mutex
 	}
 
-	var clients = make(map[string]*clientData)
-	var mu sync.Mutex
+	options := newRateLimitOptions(opts)
+	clients := newLimiterHandle(options,
+		func(now time.Time) *clientData {
+			return &clientData{timestamps: newTimestampRing(requests)}
+		},
+		func(data *clientData, now time.Time) bool {
+			data.mu.Lock()
+			defer data.mu.Unlock()
+			newest, ok := data.timestamps.newest()
+			return !ok || now.Sub(newest) >= window
+		},
+	)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			key := r.RemoteAddr
+			key := options.clientKey(r)
 			now := time.Now()
-			mu.Lock()
-			data, exists := clients[key]
-			if !exists {
-				data = &clientData{
-					timestamps: make([]time.Time, 0),
-				}
-				clients[key] = data
-			}
-			mu.Unlock()
+			data := clients.store.get(key, now)
+
 			data.mu.Lock()
-			validTimestamps := make([]time.Time, 0)
-			for _, t := range data.timestamps {
-				if now.Sub(t) < window {
-					validTimestamps = append(validTimestamps, t)
-				}
+			data.timestamps.expire(now, window)
+			remaining := requests - data.timestamps.count
+			resetTime := now.Add(window)
+			if oldest, ok := data.timestamps.oldest(); ok {
+				resetTime = oldest.Add(window)
 			}
-			data.timestamps = validTimestamps
-			remaining := requests - len(data.timestamps)
-			var resetTime time.Time
-			if len(data.timestamps) > 0 {
-				resetTime = data.timestamps[0].Add(window)
-			} else {
-				resetTime = now.Add(window)
+			limited := data.timestamps.full()
+			if !limited {
+				data.timestamps.push(now)
 			}
 			data.mu.Unlock()
 
@@ -656,16 +1022,12 @@ func SlidingWindowRateLimit(requests int, window time.Duration) Handler {
 			w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
 			w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", resetTime.Unix()))
 
-			data.mu.Lock()
-			if len(data.timestamps) >= requests {
-				data.mu.Unlock()
+			if limited {
 				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(resetTime.Sub(now).Seconds())))
 				w.WriteHeader(http.StatusTooManyRequests)
 				response.TooManyRequestsError(r.Context(), r, w, "rate limit exceeded", int(window.Seconds()))
 				return
 			}
-			data.timestamps = append(data.timestamps, now)
-			data.mu.Unlock()
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -738,9 +1100,20 @@ func Compression(config CompressionConfig) Handler {
 		}
 	}
 
+	if config.Level < gzip.HuffmanOnly || config.Level > gzip.BestCompression {
+		config.Level = gzip.DefaultCompression
+	}
+
 	contentTypeMap := make(map[string]bool)
 	for _, ct := range config.ContentTypes {
-		contentTypeMap[ct] = true
+		contentTypeMap[strings.ToLower(ct)] = true
+	}
+
+	pool := &sync.Pool{
+		New: func() any {
+			gz, _ := gzip.NewWriterLevel(nil, config.Level)
+			return gz
+		},
 	}
 
 	return func(next http.Handler) http.Handler {
@@ -752,16 +1125,162 @@ func Compression(config CompressionConfig) Handler {
 				return
 			}
 
+			// The body depends on Accept-Encoding whether or not this
+			// particular response ends up compressed
+			w.Header().Add("Vary", "Accept-Encoding")
+
 			if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			gw := &gzipResponseWriter{
+				ResponseWriter: w,
+				pool:           pool,
+				minSize:        config.MinSize,
+				contentTypes:   contentTypeMap,
+			}
+			defer gw.Close()
+
+			next.ServeHTTP(gw, r)
 		})
 	}
 }
 
+// gzipResponseWriter buffers the response until MinSize bytes have been
+// written, then decides whether to compress based on size and Content-Type.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	pool         *sync.Pool
+	minSize      int
+	contentTypes map[string]bool
+
+	gz         *gzip.Writer
+	buf        []byte
+	statusCode int
+	decided    bool
+}
+
+func (gw *gzipResponseWriter) WriteHeader(code int) {
+	if gw.statusCode == 0 {
+		gw.statusCode = code
+	}
+}
+
+func (gw *gzipResponseWriter) Write(b []byte) (int, error) {
+	if gw.statusCode == 0 {
+		gw.statusCode = http.StatusOK
+	}
+	if gw.decided {
+		if gw.gz != nil {
+			return gw.gz.Write(b)
+		}
+		return gw.ResponseWriter.Write(b)
+	}
+
+	gw.buf = append(gw.buf, b...)
+	if !gw.compressible() {
+		if err := gw.decide(false); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if len(gw.buf) >= gw.minSize {
+		if err := gw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (gw *gzipResponseWriter) Flush() {
+	if !gw.decided && gw.statusCode != 0 {
+		gw.decide(gw.compressible() && len(gw.buf) > 0)
+	}
+	if gw.gz != nil {
+		gw.gz.Flush()
+	}
+	if f, ok := gw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the raw connection to the handler, e.g. for a websocket
+// upgrade; nothing buffered so far is written and nothing is compressed.
+func (gw *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := gw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("compression: response writer does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		gw.decided = true
+		gw.buf = nil
+	}
+	return conn, rw, err
+}
+
+func (gw *gzipResponseWriter) Close() error {
+	if !gw.decided && gw.statusCode != 0 {
+		if err := gw.decide(false); err != nil {
+			return err
+		}
+	}
+	if gw.gz == nil {
+		return nil
+	}
+	err := gw.gz.Close()
+	gw.gz.Reset(nil)
+	gw.pool.Put(gw.gz)
+	gw.gz = nil
+	return err
+}
+
+func (gw *gzipResponseWriter) compressible() bool {
+	h := gw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	switch gw.statusCode {
+	case http.StatusNoContent, http.StatusNotModified:
+		return false
+	}
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(gw.buf)
+		h.Set("Content-Type", contentType)
+	}
+	if idx := strings.Index(contentType, ";"); idx != -1 {
+		contentType = contentType[:idx]
+	}
+	return gw.contentTypes[strings.ToLower(strings.TrimSpace(contentType))]
+}
+
+func (gw *gzipResponseWriter) decide(compress bool) error {
+	gw.decided = true
+	if compress {
+		h := gw.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		gw.gz = gw.pool.Get().(*gzip.Writer)
+		gw.gz.Reset(gw.ResponseWriter)
+	}
+	gw.ResponseWriter.WriteHeader(gw.statusCode)
+
+	buf := gw.buf
+	gw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if gw.gz != nil {
+		_, err = gw.gz.Write(buf)
+	} else {
+		_, err = gw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
 func ContentTypeValidator(allowedTypes []string) Handler {
 	allowedMap := make(map[string]bool)
 	for _, ct := range allowedTypes {
@@ -882,6 +1401,117 @@ func Auth(config AuthConfig) Handler {
 	}
 }
 
+type jwtAuthOptions struct {
+	skipPaths []string
+	tokenType token.TokenType
+	denylist  *token.Denylist
+}
+
+type AuthOption func(*jwtAuthOptions)
+
+// WithSkipPaths exempts matching paths (exact or path.Match patterns) from JWT validation.
+func WithSkipPaths(paths ...string) AuthOption {
+	return func(o *jwtAuthOptions) {
+		o.skipPaths = append(o.skipPaths, paths...)
+	}
+}
+
+// WithTokenType sets the token type JWTAuth accepts. Defaults to access tokens.
+func WithTokenType(tokenType token.TokenType) AuthOption {
+	return func(o *jwtAuthOptions) {
+		o.tokenType = tokenType
+	}
+}
+
+// WithDenylist rejects tokens whose jti, session or user has been revoked.
+// Lookup failures are let through so a cache outage does not lock out every
+// caller; tokens still expire on their own TTL.
+func WithDenylist(denylist *token.Denylist) AuthOption {
+	return func(o *jwtAuthOptions) {
+		o.denylist = denylist
+	}
+}
+
+// JWTAuth validates bearer tokens with the shared token service and forwards
+// the user and session IDs in the request context and the X-User-ID and
+// X-Session-ID headers read by InterceptUserId and InterceptSessionId.
+func JWTAuth(ts *token.JWTTokenService, opts ...AuthOption) Handler {
+	if ts == nil {
+		panic("JWTAuth token service cannot be nil")
+	}
+
+	options := jwtAuthOptions{tokenType: token.TokenTypeAccess}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Identity headers only ever come from a validated token
+			r.Header.Del("X-User-ID")
+			r.Header.Del("X-Session-ID")
+
+			for _, skipPattern := range options.skipPaths {
+				if matchPath(r.URL.Path, skipPattern) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				response.UnauthorizedError(r.Context(), r, w, "Missing authorization header", errors.New("missing authorization header"))
+				return
+			}
+
+			parts := strings.SplitN(authHeader, " ", 2)
+			if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || strings.TrimSpace(parts[1]) == "" {
+				response.UnauthorizedError(r.Context(), r, w, "Invalid authorization format", errors.New("invalid authorization format"))
+				return
+			}
+
+			claims, err := ts.Validate(r.Context(), strings.TrimSpace(parts[1]), options.tokenType)
+			if err != nil {
+				switch {
+				case errors.Is(err, token.ErrExpiredToken):
+					response.UnauthorizedError(r.Context(), r, w, "Token has expired", err)
+				case errors.Is(err, token.ErrInvalidToken):
+					response.UnauthorizedError(r.Context(), r, w, "Invalid token signature", err)
+				default:
+					response.UnauthorizedError(r.Context(), r, w, "Invalid token", err)
+				}
+				return
+			}
+
+			if options.denylist != nil {
+				if revoked, _ := options.denylist.IsRevoked(r.Context(), claims); revoked {
+					response.UnauthorizedError(r.Context(), r, w, "Token has been revoked", errors.New("token revoked"))
+					return
+				}
+			}
+
+			userID := claims.Subject
+			if userID == "" {
+				userID, _ = claims.Metadata["user_id"].(string)
+			}
+			if userID == "" {
+				response.UnauthorizedError(r.Context(), r, w, "Invalid token", errors.New("token has no subject"))
+				return
+			}
+
+			ctx := SetUserID(r.Context(), userID)
+			ctx = context.WithValue(ctx, sContext.TokenClaimsKey, claims)
+			r.Header.Set("X-User-ID", userID)
+			if sessionID, _ := claims.Metadata["session_id"].(string); sessionID != "" {
+				ctx = context.WithValue(ctx, sContext.SessionIDKey, sessionID)
+				r.Header.Set("X-Session-ID", sessionID)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 func matchPath(requestPath, pattern string) bool {
 	if pattern == "" {
 		return false
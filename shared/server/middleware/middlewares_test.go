@@ -0,0 +1,668 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"shared/pkg/cache"
+	"shared/pkg/cache/memory"
+	pkgErrors "shared/pkg/errors"
+	"shared/server/common/token"
+)
+
+func serveCompressed(t *testing.T, config CompressionConfig, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := Compression(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", "999")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, body)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCompressionLargeJSON(t *testing.T) {
+	body := `{"data":"` + strings.Repeat("a", 4096) + `"}`
+	rec := serveCompressed(t, CompressionConfig{}, "application/json; charset=utf-8", body)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, rec.Code)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected gzip encoding, got %q", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Fatalf("expected Vary: Accept-Encoding, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Length"); got != "" {
+		t.Fatalf("expected Content-Length to be removed, got %q", got)
+	}
+	if rec.Body.Len() >= len(body) {
+		t.Fatalf("expected compressed body smaller than %d, got %d", len(body), rec.Body.Len())
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	decoded, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	if string(decoded) != body {
+		t.Fatalf("decompressed body mismatch")
+	}
+}
+
+func TestCompressionSmallBodyPassesThrough(t *testing.T) {
+	body := `{"ok":true}`
+	rec := serveCompressed(t, CompressionConfig{}, "application/json", body)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, rec.Code)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("expected no encoding, got %q", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Fatalf("expected Vary: Accept-Encoding on uncompressed response, got %q", got)
+	}
+	if rec.Body.String() != body {
+		t.Fatalf("expected body %q, got %q", body, rec.Body.String())
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.conn, bufio.NewReadWriter(bufio.NewReader(h.conn), bufio.NewWriter(h.conn)), nil
+}
+
+func TestCompressionForwardsHijack(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	handler := Compression(CompressionConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Fatalf("compressed writer does not implement http.Hijacker")
+		}
+		conn, _, err := hj.Hijack()
+		if err != nil {
+			t.Fatalf("Hijack: %v", err)
+		}
+		conn.Close()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := &hijackRecorder{ResponseRecorder: httptest.NewRecorder(), conn: server}
+	handler.ServeHTTP(rec, req)
+
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("expected nothing written after hijack")
+	}
+}
+
+func TestCompressionSkipsDisallowedContentType(t *testing.T) {
+	body := strings.Repeat("x", 4096)
+	rec := serveCompressed(t, CompressionConfig{}, "image/png", body)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("expected no encoding, got %q", got)
+	}
+	if rec.Body.String() != body {
+		t.Fatalf("expected body to pass through untouched")
+	}
+}
+
+func newTestTokenService(t *testing.T, clock func() time.Time) *token.JWTTokenService {
+	t.Helper()
+	ks, err := token.NewStaticKeySet([]byte("middleware-test-secret-key"))
+	if err != nil {
+		t.Fatalf("NewStaticKeySet: %v", err)
+	}
+	ts, err := token.NewJWTTokenService(token.Config{
+		KeySet:          ks,
+		Issuer:          "test-issuer",
+		Audience:        []string{"test-audience"},
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+		Clock:           clock,
+	})
+	if err != nil {
+		t.Fatalf("NewJWTTokenService: %v", err)
+	}
+	return ts
+}
+
+func serveJWTAuth(ts *token.JWTTokenService, path, authHeader string, next http.HandlerFunc) *httptest.ResponseRecorder {
+	handler := JWTAuth(ts, WithSkipPaths("/health"))(next)
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestJWTAuthInjectsUserAndSession(t *testing.T) {
+	ts := newTestTokenService(t, nil)
+	signed, err := ts.IssueAccessToken(context.Background(), "user-1", token.IssueOptions{
+		Metadata: map[string]any{"session_id": "session-1"},
+	})
+	if err != nil {
+		t.Fatalf("IssueAccessToken: %v", err)
+	}
+
+	var userID, sessionID, userHeader, sessionHeader string
+	rec := serveJWTAuth(ts, "/profile", "Bearer "+signed.Token, func(w http.ResponseWriter, r *http.Request) {
+		userID = GetUserID(r.Context())
+		sessionID = GetSessionID(r.Context())
+		userHeader = r.Header.Get("X-User-ID")
+		sessionHeader = r.Header.Get("X-Session-ID")
+	})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if userID != "user-1" || userHeader != "user-1" {
+		t.Fatalf("expected user-1 in context and header, got %q / %q", userID, userHeader)
+	}
+	if sessionID != "session-1" || sessionHeader != "session-1" {
+		t.Fatalf("expected session-1 in context and header, got %q / %q", sessionID, sessionHeader)
+	}
+}
+
+func TestJWTAuthStripsForgedIdentityHeaders(t *testing.T) {
+	ts := newTestTokenService(t, nil)
+	signed, err := ts.IssueAccessToken(context.Background(), "user-1", token.IssueOptions{})
+	if err != nil {
+		t.Fatalf("IssueAccessToken: %v", err)
+	}
+
+	handler := JWTAuth(ts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-User-ID"); got != "user-1" {
+			t.Fatalf("expected X-User-ID from the token, got %q", got)
+		}
+		if got := r.Header.Get("X-Session-ID"); got != "" {
+			t.Fatalf("expected forged X-Session-ID to be dropped, got %q", got)
+		}
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+signed.Token)
+	req.Header.Set("X-User-ID", "someone-else")
+	req.Header.Set("X-Session-ID", "forged-session")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestJWTAuthRejectsExpiredAndInvalidTokens(t *testing.T) {
+	issuedAt := time.Now().Add(-time.Hour)
+	expired, err := newTestTokenService(t, func() time.Time { return issuedAt }).
+		IssueAccessToken(context.Background(), "user-1", token.IssueOptions{})
+	if err != nil {
+		t.Fatalf("IssueAccessToken: %v", err)
+	}
+	ts := newTestTokenService(t, nil)
+	valid, err := ts.IssueAccessToken(context.Background(), "user-1", token.IssueOptions{})
+	if err != nil {
+		t.Fatalf("IssueAccessToken: %v", err)
+	}
+
+	next := func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("handler should not be called")
+	}
+	tests := []struct {
+		name    string
+		header  string
+		message string
+	}{
+		{"missing", "", "Missing authorization header"},
+		{"expired", "Bearer " + expired.Token, "Token has expired"},
+		{"bad signature", "Bearer " + valid.Token[:len(valid.Token)-4] + "abcd", "Invalid token signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJWTAuth(ts, "/profile", tt.header, next)
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("expected status 401, got %d", rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.message) {
+				t.Fatalf("expected body to contain %q, got %s", tt.message, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestJWTAuthSkipPaths(t *testing.T) {
+	called := false
+	rec := serveJWTAuth(newTestTokenService(t, nil), "/health", "", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	if !called || rec.Code != http.StatusOK {
+		t.Fatalf("expected skipped path to reach handler, got status %d", rec.Code)
+	}
+}
+
+func TestJWTAuthRejectsRevokedSession(t *testing.T) {
+	ts := newTestTokenService(t, nil)
+	signed, err := ts.IssueAccessToken(context.Background(), "user-1", token.IssueOptions{
+		Metadata: map[string]any{"session_id": "session-1"},
+	})
+	if err != nil {
+		t.Fatalf("IssueAccessToken: %v", err)
+	}
+
+	denylist := token.NewDenylist(memory.New())
+	handler := JWTAuth(ts, WithDenylist(denylist))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetTokenClaims(r.Context()) == nil {
+			t.Fatalf("expected claims in context")
+		}
+	}))
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+		req.Header.Set("Authorization", "Bearer "+signed.Token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 before revocation, got %d", rec.Code)
+	}
+	if err := denylist.RevokeSession(context.Background(), "session-1", time.Minute); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	rec := serve()
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "Token has been revoked") {
+		t.Fatalf("expected revoked 401, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+type fakeCache struct {
+	cache.Cache
+	mu    sync.Mutex
+	items map[string][]byte
+	ttls  map[string]time.Duration
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{items: map[string][]byte{}, ttls: map[string]time.Duration{}}
+}
+
+func (c *fakeCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.items[key]; ok {
+		return v, nil
+	}
+	return nil, cache.ErrNotFound
+}
+
+func (c *fakeCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) pkgErrors.AppError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = value
+	return nil
+}
+
+func (c *fakeCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; ok {
+		return false, nil
+	}
+	c.items[key] = value
+	c.ttls[key] = ttl
+	return true, nil
+}
+
+func (c *fakeCache) Delete(ctx context.Context, key string) pkgErrors.AppError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	return nil
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	handler := Idempotency(newFakeCache(), time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id":"1"}`)
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/register", nil)
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusCreated || rec.Body.String() != `{"id":"1"}` {
+			t.Fatalf("request %d: unexpected response %d %q", i, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/json" {
+			t.Fatalf("request %d: expected JSON content type, got %q", i, got)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+}
+
+func TestIdempotencyRejectsConcurrentDuplicate(t *testing.T) {
+	store := newFakeCache()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := Idempotency(store, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/messages", nil)
+		req.Header.Set(IdempotencyKeyHeader, "dup")
+		return req
+	}
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+		close(done)
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest())
+
+	store.mu.Lock()
+	var lockTTLs []time.Duration
+	for key, ttl := range store.ttls {
+		if strings.HasSuffix(key, ":lock") {
+			lockTTLs = append(lockTTLs, ttl)
+		}
+	}
+	store.mu.Unlock()
+	close(release)
+	<-done
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for in-flight duplicate, got %d", rec.Code)
+	}
+	if len(lockTTLs) != 1 || lockTTLs[0] != time.Minute {
+		t.Fatalf("expected the lock to be taken with its TTL, got %v", lockTTLs)
+	}
+}
+
+func TestTimeoutDiscardsWritesAfterDeadline(t *testing.T) {
+	handlerDone := make(chan error, 1)
+	handler := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("X-Late", "1")
+		w.WriteHeader(http.StatusCreated)
+		_, err := io.WriteString(w, "late body")
+		handlerDone <- err
+	}))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusGatewayTimeout)
+	}
+	if strings.Contains(string(body), "late body") || resp.Header.Get("X-Late") != "" {
+		t.Fatalf("response carries the late handler's output: %q", body)
+	}
+	if err := <-handlerDone; err != http.ErrHandlerTimeout {
+		t.Fatalf("late write error = %v, want ErrHandlerTimeout", err)
+	}
+}
+
+func TestTimeoutPassesThroughFastHandler(t *testing.T) {
+	handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Handler", "1")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "ok")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "ok" || rec.Header().Get("X-Handler") != "1" {
+		t.Fatalf("got %d %q headers %v, want 201 \"ok\" with X-Handler", rec.Code, rec.Body.String(), rec.Header())
+	}
+}
+
+func TestTimestampRingStaysWithinLimitUnderLoad(t *testing.T) {
+	const limit = 5
+	window := time.Second
+	ring := newTimestampRing(limit)
+	now := time.Now()
+
+	admitted := 0
+	for i := 0; i < 10000; i++ {
+		now = now.Add(50 * time.Millisecond)
+		ring.expire(now, window)
+		if !ring.full() {
+			ring.push(now)
+			admitted++
+		}
+		if len(ring.buf) != limit || ring.count > limit {
+			t.Fatalf("ring holds %d of capacity %d, want at most %d", ring.count, len(ring.buf), limit)
+		}
+	}
+	// One request every 50ms against 5 per second admits 5 of every 20
+	if want := 10000 / 20 * limit; admitted != want {
+		t.Fatalf("admitted %d requests, want %d", admitted, want)
+	}
+}
+
+func TestSlidingWindowRateLimitHeaders(t *testing.T) {
+	handler := SlidingWindowRateLimit(2, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i, want := range []struct {
+		code      int
+		remaining string
+	}{
+		{http.StatusOK, "2"},
+		{http.StatusOK, "1"},
+		{http.StatusTooManyRequests, "0"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != want.code {
+			t.Fatalf("request %d: status = %d, want %d", i, rec.Code, want.code)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != want.remaining {
+			t.Fatalf("request %d: X-RateLimit-Remaining = %s, want %s", i, got, want.remaining)
+		}
+		if rec.Header().Get("X-RateLimit-Limit") != "2" || rec.Header().Get("X-RateLimit-Reset") == "" {
+			t.Fatalf("request %d: missing rate limit headers: %v", i, rec.Header())
+		}
+	}
+}
+
+func TestUserOrIPKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.7:5555"
+	if got := UserOrIPKey(req); got != "ip:203.0.113.7" {
+		t.Fatalf("anonymous key = %q, want ip:203.0.113.7", got)
+	}
+
+	req = req.WithContext(SetUserID(req.Context(), "u-1"))
+	if got := UserOrIPKey(req); got != "user:u-1" {
+		t.Fatalf("signed-in key = %q, want user:u-1", got)
+	}
+}
+
+func TestRateLimitKeyByUserSeparatesUsersBehindOneIP(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	limiters := map[string]Handler{
+		"RateLimit":              RateLimit(RateLimitConfig{RequestsPerWindow: 1, Window: time.Minute, KeyByUser: true}),
+		"TokenBucketRateLimit":   TokenBucketRateLimit(1, time.Minute, WithRateLimitKeyByUser()),
+		"FixedWindowRateLimit":   FixedWindowRateLimit(1, time.Minute, WithRateLimitKeyByUser()),
+		"SlidingWindowRateLimit": SlidingWindowRateLimit(1, time.Minute, WithRateLimitKeyByUser()),
+	}
+
+	for name, limiter := range limiters {
+		// Stands in for the auth middleware that must run before the limiter
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if userID := r.Header.Get("X-User-ID"); userID != "" {
+				r = r.WithContext(SetUserID(r.Context(), userID))
+			}
+			limiter(ok).ServeHTTP(w, r)
+		})
+		serve := func(userID string, port int) int {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = fmt.Sprintf("198.51.100.1:%d", port)
+			if userID != "" {
+				req.Header.Set("X-User-ID", userID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		if code := serve("alice", 1000); code != http.StatusOK {
+			t.Fatalf("%s: alice's first request = %d, want 200", name, code)
+		}
+		if code := serve("bob", 1001); code != http.StatusOK {
+			t.Fatalf("%s: bob shares alice's address but was limited: %d", name, code)
+		}
+		if code := serve("alice", 1002); code != http.StatusTooManyRequests {
+			t.Fatalf("%s: alice's second request = %d, want 429", name, code)
+		}
+
+		// Anonymous requests from one address share a bucket whatever the port
+		if code := serve("", 1003); code != http.StatusOK {
+			t.Fatalf("%s: first anonymous request = %d, want 200", name, code)
+		}
+		if code := serve("", 1004); code != http.StatusTooManyRequests {
+			t.Fatalf("%s: second anonymous request = %d, want 429", name, code)
+		}
+	}
+}
+
+func serveCORS(handler http.Handler, method, origin string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSCredentialedRequest(t *testing.T) {
+	handler := CORS([]string{"https://app.example.com"}, []string{"GET", "POST"}, []string{"Content-Type"})(okHandler())
+
+	rec := serveCORS(handler, http.MethodGet, "https://app.example.com", nil)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("Allow-Origin = %q, want the request origin", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("Allow-Credentials = %q, want true", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Origin" {
+		t.Fatalf("Vary = %q, want Origin", got)
+	}
+
+	rec = serveCORS(handler, http.MethodGet, "https://evil.example.com", nil)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("disallowed origin got Allow-Origin %q", got)
+	}
+}
+
+func TestCORSPreflightReflectsRequestedHeaders(t *testing.T) {
+	handler := CORS([]string{"https://app.example.com"}, []string{"GET", "PUT"}, []string{"*"}, WithCORSMaxAge(10*time.Minute))(okHandler())
+
+	rec := serveCORS(handler, http.MethodOptions, "https://app.example.com", http.Header{
+		"Access-Control-Request-Method":  {"PUT"},
+		"Access-Control-Request-Headers": {"X-Request-Id, Authorization"},
+	})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight status = %d, want 204", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "X-Request-Id, Authorization" {
+		t.Fatalf("Allow-Headers = %q, want the requested headers", got)
+	}
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Fatalf("Max-Age = %q, want 600", got)
+	}
+
+	fixed := CORS([]string{"https://app.example.com"}, []string{"GET"}, []string{"Content-Type"})(okHandler())
+	rec = serveCORS(fixed, http.MethodOptions, "https://app.example.com", http.Header{
+		"Access-Control-Request-Headers": {"X-Custom"},
+	})
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type" {
+		t.Fatalf("Allow-Headers = %q, want only the configured headers", got)
+	}
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "86400" {
+		t.Fatalf("default Max-Age = %q, want 86400", got)
+	}
+}
+
+func TestCORSNeverPairsCredentialsWithWildcard(t *testing.T) {
+	credentialed := CORS([]string{"*"}, []string{"GET"}, []string{"Content-Type"})(okHandler())
+
+	rec := serveCORS(credentialed, http.MethodGet, "https://app.example.com", nil)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("Allow-Origin = %q, want the concrete origin alongside credentials", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("Allow-Credentials = %q, want true", got)
+	}
+
+	rec = serveCORS(credentialed, http.MethodGet, "", nil)
+	if rec.Header().Get("Access-Control-Allow-Origin") == "*" && rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatalf("wildcard origin sent with credentials")
+	}
+
+	anonymous := CORS([]string{"*"}, []string{"GET"}, []string{"Content-Type"}, WithCORSCredentials(false))(okHandler())
+	rec = serveCORS(anonymous, http.MethodGet, "https://app.example.com", nil)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("Allow-Origin = %q, want * without credentials", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("Allow-Credentials = %q, want unset", got)
+	}
+}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRateLimitCleanupInterval = time.Minute
+	defaultRateLimitMaxKeys         = 100_000
+)
+
+type rateLimitOptions struct {
+	cleanupInterval time.Duration
+	maxKeys         int
+	ctx             context.Context
+	keyByUser       bool
+}
+
+type RateLimitOption func(*rateLimitOptions)
+
+// WithRateLimitCleanupInterval sets how often a limiter drops the state of
+// clients whose window has passed
+func WithRateLimitCleanupInterval(interval time.Duration) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.cleanupInterval = interval
+	}
+}
+
+// WithRateLimitMaxKeys caps how many clients a limiter tracks; past the cap
+// the least recently seen client is forgotten
+func WithRateLimitMaxKeys(n int) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.maxKeys = n
+	}
+}
+
+// WithRateLimitContext stops the limiter's cleanup when ctx is done. Without
+// it cleanup stops once the middleware is garbage collected.
+func WithRateLimitContext(ctx context.Context) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithRateLimitKeyByUser gives each signed-in user their own limit, keyed by
+// UserOrIPKey, instead of sharing one with everyone behind the same address.
+// The user ID comes from the request context, so JWTAuth, Auth or
+// InterceptUserId must run before the limiter; otherwise every request falls
+// back to being keyed by IP.
+func WithRateLimitKeyByUser() RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.keyByUser = true
+	}
+}
+
+// clientKey is the key r's client is limited under
+func (o rateLimitOptions) clientKey(r *http.Request) string {
+	if o.keyByUser {
+		return UserOrIPKey(r)
+	}
+	return r.RemoteAddr
+}
+
+func newRateLimitOptions(opts []RateLimitOption) rateLimitOptions {
+	o := rateLimitOptions{
+		cleanupInterval: defaultRateLimitCleanupInterval,
+		maxKeys:         defaultRateLimitMaxKeys,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// limiterStore holds per-client limiter state, least recently seen last. A
+// janitor sweeps out entries stale reports as expired, and the LRU cap
+// bounds memory between sweeps.
+type limiterStore[V any] struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	maxKeys int
+	create  func(now time.Time) V
+	stale   func(v V, now time.Time) bool
+}
+
+type limiterStoreEntry[V any] struct {
+	key   string
+	value V
+}
+
+// limiterHandle is what a limiter's middleware holds on to. When it becomes
+// unreachable its cleanup stops the janitor, which only references the store.
+type limiterHandle[V any] struct {
+	store *limiterStore[V]
+}
+
+func newLimiterHandle[V any](opts rateLimitOptions, create func(now time.Time) V, stale func(v V, now time.Time) bool) *limiterHandle[V] {
+	store := &limiterStore[V]{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		maxKeys: opts.maxKeys,
+		create:  create,
+		stale:   stale,
+	}
+	handle := &limiterHandle[V]{store: store}
+
+	if opts.cleanupInterval > 0 {
+		stop := make(chan struct{})
+		var once sync.Once
+		closeStop := func() { once.Do(func() { close(stop) }) }
+		go store.janitor(opts.cleanupInterval, stop)
+		runtime.AddCleanup(handle, func(stop func()) { stop() }, closeStop)
+		if opts.ctx != nil {
+			go func() {
+				select {
+				case <-opts.ctx.Done():
+					closeStop()
+				case <-stop:
+				}
+			}()
+		}
+	}
+	return handle
+}
+
+// get returns key's state, creating it if the client is new
+func (s *limiterStore[V]) get(key string, now time.Time) V {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[key]; ok {
+		s.order.MoveToFront(el)
+		return el.Value.(*limiterStoreEntry[V]).value
+	}
+
+	entry := &limiterStoreEntry[V]{key: key, value: s.create(now)}
+	s.entries[key] = s.order.PushFront(entry)
+
+	if s.maxKeys > 0 {
+		for s.order.Len() > s.maxKeys {
+			s.removeLocked(s.order.Back())
+		}
+	}
+	return entry.value
+}
+
+// sweep drops every entry that is stale at now and reports how many went
+func (s *limiterStore[V]) sweep(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for el := s.order.Front(); el != nil; {
+		next := el.Next()
+		if s.stale(el.Value.(*limiterStoreEntry[V]).value, now) {
+			s.removeLocked(el)
+			removed++
+		}
+		el = next
+	}
+	return removed
+}
+
+func (s *limiterStore[V]) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *limiterStore[V]) removeLocked(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*limiterStoreEntry[V]).key)
+}
+
+func (s *limiterStore[V]) janitor(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.sweep(time.Now())
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type testWindow struct {
+	resetTime time.Time
+}
+
+func newTestStore(window time.Duration, opts ...RateLimitOption) *limiterHandle[*testWindow] {
+	return newLimiterHandle(newRateLimitOptions(opts),
+		func(now time.Time) *testWindow { return &testWindow{resetTime: now.Add(window)} },
+		func(w *testWindow, now time.Time) bool { return now.After(w.resetTime) },
+	)
+}
+
+func TestLimiterStoreSweepsStaleEntries(t *testing.T) {
+	limiter := newTestStore(time.Minute, WithRateLimitCleanupInterval(0))
+	start := time.Now()
+
+	for i := 0; i < 1000; i++ {
+		limiter.store.get(fmt.Sprintf("10.0.%d.%d", i/256, i%256), start)
+	}
+	limiter.store.get("fresh", start.Add(time.Minute))
+
+	if removed := limiter.store.sweep(start.Add(30 * time.Second)); removed != 0 {
+		t.Fatalf("sweep inside the window removed %d entries, want 0", removed)
+	}
+	if removed := limiter.store.sweep(start.Add(time.Minute + time.Second)); removed != 1000 {
+		t.Fatalf("sweep after the window removed %d entries, want 1000", removed)
+	}
+	if n := limiter.store.len(); n != 1 {
+		t.Fatalf("store holds %d entries, want only the fresh one", n)
+	}
+}
+
+func TestLimiterStoreEvictsLeastRecentlySeen(t *testing.T) {
+	limiter := newTestStore(time.Minute, WithRateLimitCleanupInterval(0), WithRateLimitMaxKeys(3))
+	now := time.Now()
+
+	a := limiter.store.get("a", now)
+	limiter.store.get("b", now)
+	limiter.store.get("c", now)
+	limiter.store.get("a", now)
+	limiter.store.get("d", now)
+
+	if n := limiter.store.len(); n != 3 {
+		t.Fatalf("store holds %d entries, want 3", n)
+	}
+	if _, ok := limiter.store.entries["b"]; ok {
+		t.Fatalf("least recently seen key b was kept")
+	}
+	if got := limiter.store.get("a", now); got != a {
+		t.Fatalf("recently seen key a was evicted")
+	}
+}
+
+func TestLimiterJanitorRunsUntilContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	limiter := newTestStore(time.Millisecond, WithRateLimitCleanupInterval(5*time.Millisecond), WithRateLimitContext(ctx))
+	limiter.store.get("client", time.Now())
+
+	deadline := time.Now().Add(time.Second)
+	for limiter.store.len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor did not purge the stale entry")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	limiter.store.get("client", time.Now().Add(-time.Hour))
+	time.Sleep(30 * time.Millisecond)
+	if n := limiter.store.len(); n != 1 {
+		t.Fatalf("janitor kept sweeping after its context ended")
+	}
+}
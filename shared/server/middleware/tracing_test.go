@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"shared/pkg/logger"
+	"shared/pkg/monitoring/tracing"
+	sContext "shared/server/context"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestTracing_ContinuesTraceAndTagsLogs(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	var fields map[string]string
+	var outgoing string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outgoing = r.Header.Get("traceparent")
+	}))
+	defer downstream.Close()
+	client := &http.Client{Transport: tracing.NewTransport(nil, "test")}
+
+	handler := Tracing("test")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields = make(map[string]string)
+		for _, f := range sContext.LogFields(r.Context()) {
+			fields[f.Key()] = f.Value().(string)
+		}
+
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, downstream.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("downstream request: %v", err)
+			return
+		}
+		resp.Body.Close()
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("traceparent", testTraceParent)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if fields["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("trace_id = %q, want the one from traceparent", fields["trace_id"])
+	}
+	if fields["span_id"] == "" || fields["span_id"] == "00f067aa0ba902b7" {
+		t.Fatalf("span_id = %q, want the server span's own ID", fields["span_id"])
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want a server and a client span", len(spans))
+	}
+	client0, server := spans[0], spans[1]
+	if server.SpanKind() != trace.SpanKindServer || server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("server span kind %v parent %s, want a server span under the remote parent", server.SpanKind(), server.Parent().SpanID())
+	}
+	if client0.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Fatal("client span is not a child of the server span")
+	}
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + client0.SpanContext().SpanID().String() + "-01"
+	if outgoing != want {
+		t.Fatalf("outgoing traceparent = %q, want %q", outgoing, want)
+	}
+}
+
+func TestTracing_NoopProviderRecordsNothing(t *testing.T) {
+	var fields []logger.Field
+	handler := Tracing("test")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields = sContext.LogFields(r.Context())
+		if trace.SpanFromContext(r.Context()).IsRecording() {
+			t.Error("span is recording under the default provider")
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+	if len(fields) != 0 {
+		t.Fatalf("fields = %v, want none without an incoming trace", fields)
+	}
+}
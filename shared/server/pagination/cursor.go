@@ -0,0 +1,37 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a cursor was not produced by EncodeTimeCursor
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeTimeCursor returns an opaque cursor for keyset pagination ordered by
+// (created_at, id). The id breaks ties between rows created in the same
+// instant so no row is skipped or repeated across pages.
+func EncodeTimeCursor(createdAt time.Time, id string) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeTimeCursor reverses EncodeTimeCursor
+func DecodeTimeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return createdAt, id, nil
+}
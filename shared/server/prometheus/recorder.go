@@ -0,0 +1,96 @@
+package prometheus
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const DefaultNamespace = "echo"
+
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type Config struct {
+	Namespace string
+	Subsystem string
+	Buckets   []float64
+	Registry  *prometheus.Registry
+}
+
+// PrometheusRecorder implements middleware.MetricsRecorder and
+// middleware.InFlightRecorder on top of a Prometheus registry.
+type PrometheusRecorder struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	inFlight *prometheus.GaugeVec
+	duration *prometheus.HistogramVec
+}
+
+func NewPrometheusRecorder(cfg Config) *PrometheusRecorder {
+	if cfg.Namespace == "" {
+		cfg.Namespace = DefaultNamespace
+	}
+	if len(cfg.Buckets) == 0 {
+		cfg.Buckets = DefaultBuckets
+	}
+	registry := cfg.Registry
+	if registry == nil {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
+
+	r := &PrometheusRecorder{
+		registry: registry,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: cfg.Subsystem,
+			Name:      "http_requests_total",
+			Help:      "Total number of HTTP requests.",
+		}, []string{"method", "path", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: cfg.Subsystem,
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests currently being served.",
+		}, []string{"method", "path"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: cfg.Subsystem,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency in seconds.",
+			Buckets:   cfg.Buckets,
+		}, []string{"method", "path", "status"}),
+	}
+	registry.MustRegister(r.requests, r.inFlight, r.duration)
+	return r
+}
+
+func (r *PrometheusRecorder) RecordRequest(method, path string, statusCode int, duration time.Duration) {
+	status := strconv.Itoa(statusCode)
+	r.requests.WithLabelValues(method, path, status).Inc()
+	r.duration.WithLabelValues(method, path, status).Observe(duration.Seconds())
+}
+
+func (r *PrometheusRecorder) RequestStarted(method, path string) {
+	r.inFlight.WithLabelValues(method, path).Inc()
+}
+
+func (r *PrometheusRecorder) RequestFinished(method, path string) {
+	r.inFlight.WithLabelValues(method, path).Dec()
+}
+
+// Handler serves the recorder's registry in the Prometheus exposition format.
+func (r *PrometheusRecorder) Handler() http.Handler {
+	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{Registry: r.registry})
+}
+
+func (r *PrometheusRecorder) Registry() *prometheus.Registry {
+	return r.registry
+}
@@ -0,0 +1,46 @@
+package prometheus
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"shared/server/middleware"
+)
+
+func TestRecorderUsesRouteTemplate(t *testing.T) {
+	recorder := NewPrometheusRecorder(Config{Namespace: "test"})
+
+	router := mux.NewRouter()
+	router.Use(mux.MiddlewareFunc(middleware.Metrics(recorder)))
+	router.HandleFunc("/profile/{user_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}).Methods(http.MethodGet)
+
+	for _, id := range []string{"a", "b", "c"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/profile/"+id, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	recorder.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+
+	want := `test_http_requests_total{method="GET",path="/profile/{user_id}",status="202"} 3`
+	if !strings.Contains(out, want) {
+		t.Fatalf("expected %q in metrics output:\n%s", want, out)
+	}
+	if strings.Contains(out, `path="/profile/a"`) {
+		t.Fatalf("raw path leaked into labels:\n%s", out)
+	}
+	if !strings.Contains(out, `test_http_requests_in_flight{method="GET",path="/profile/{user_id}"} 0`) {
+		t.Fatalf("expected in-flight gauge back at zero:\n%s", out)
+	}
+	if !strings.Contains(out, "test_http_request_duration_seconds_count") {
+		t.Fatalf("expected latency histogram in metrics output")
+	}
+}
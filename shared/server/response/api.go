@@ -101,6 +101,23 @@ func BadRequestError(ctx context.Context, r *http.Request, w http.ResponseWriter
 		BadRequest(w)
 }
 
+// BadRequestFieldsError creates a 400 Bad Request error response listing the
+// individual field problems
+func BadRequestFieldsError(ctx context.Context, r *http.Request, w http.ResponseWriter, message string, fieldErrors []FieldError) error {
+	err := errors.New(errors.CodeInvalidArgument, message)
+	errorDetails := ErrorDetailsFromError(err, false)
+	errorDetails.Type = ErrorTypeBadRequest
+	errorDetails.Description = "One or more fields did not meet the requirements."
+	errorDetails.Fields = fieldErrors
+
+	return Error().
+		WithContext(ctx).
+		WithRequest(r).
+		WithError(errorDetails).
+		WithMessage(message).
+		BadRequest(w)
+}
+
 // UnauthorizedError creates a 401 Unauthorized error response
 func UnauthorizedError(ctx context.Context, r *http.Request, w http.ResponseWriter, message string, reqError error) error {
 	var err errors.AppError
@@ -228,6 +245,34 @@ func ValidationError(ctx context.Context, r *http.Request, w http.ResponseWriter
 		UnprocessableEntity(w)
 }
 
+// UnprocessableEntityError creates a 422 Unprocessable Entity error response
+// for requests that are well formed but can't be applied
+func UnprocessableEntityError(ctx context.Context, r *http.Request, w http.ResponseWriter, message string, reqError error) error {
+	var err errors.AppError
+
+	if reqError != nil {
+		if appErr, ok := reqError.(errors.AppError); ok {
+			err = appErr
+		} else {
+			err = errors.New(errors.CodeUnprocessableEntity, message)
+			err = err.WithDetail("original_error", reqError.Error())
+		}
+	} else {
+		err = errors.New(errors.CodeUnprocessableEntity, message)
+	}
+
+	errorDetails := ErrorDetailsFromError(err, false)
+	errorDetails.Description = "The request was well formed but could not be processed."
+	errorDetails.Type = ErrorTypeValidation
+
+	return Error().
+		WithContext(ctx).
+		WithRequest(r).
+		WithError(errorDetails).
+		WithMessage(message).
+		UnprocessableEntity(w)
+}
+
 // RateLimitError creates a 429 Too Many Requests error response
 func RateLimitError(ctx context.Context, r *http.Request, w http.ResponseWriter, retryAfter int) error {
 	err := errors.New(errors.CodeRateLimitExceeded, "Rate limit exceeded")
@@ -0,0 +1,76 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	contextx "shared/server/context"
+	"shared/server/headers"
+)
+
+func TestBadRequestError_EchoesRequestIDs(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+	ctx := context.WithValue(req.Context(), contextx.RequestIDKey, "req-123")
+	ctx = context.WithValue(ctx, contextx.CorrelationIDKey, "corr-456")
+	req = req.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	BadRequestError(ctx, req, w, "Invalid JSON body", errors.New("unexpected EOF"))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	if got := w.Header().Get(headers.XRequestID); got != "req-123" {
+		t.Fatalf("%s header = %q, want req-123", headers.XRequestID, got)
+	}
+
+	var body Response
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Error == nil || body.Error.RequestID != "req-123" || body.Error.CorrelationID != "corr-456" {
+		t.Fatalf("error = %+v, want request and correlation IDs", body.Error)
+	}
+	if body.Error.Type != ErrorTypeBadRequest || body.Error.Message != "Invalid JSON body" {
+		t.Fatalf("error shape changed: %+v", body.Error)
+	}
+	if body.Metadata.RequestID != "req-123" {
+		t.Fatalf("metadata request_id = %q", body.Metadata.RequestID)
+	}
+}
+
+func TestRespondWithError_FallsBackToRequestHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(headers.XRequestID, "from-header")
+
+	w := httptest.NewRecorder()
+	RespondWithError(req.Context(), req, w, http.StatusInternalServerError, errors.New("boom"))
+
+	var body Response
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Error.RequestID != "from-header" || w.Header().Get(headers.XRequestID) != "from-header" {
+		t.Fatalf("request id = %q, header %q", body.Error.RequestID, w.Header().Get(headers.XRequestID))
+	}
+}
+
+func TestSuccessResponse_HasNoErrorIDs(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextx.RequestIDKey, "req-789"))
+
+	w := httptest.NewRecorder()
+	JSONWithContext(req.Context(), req, w, http.StatusOK, map[string]string{"id": "1"})
+
+	var body Response
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Error != nil || body.Metadata.RequestID != "req-789" {
+		t.Fatalf("error %+v, metadata request_id %q", body.Error, body.Metadata.RequestID)
+	}
+}
@@ -150,7 +150,19 @@ func (b *Builder) Send(w http.ResponseWriter, statusCode int) error {
 		b.response.Debug = b.debug.GetDebugInfo()
 	}
 
+	requestID, correlationID := b.requestIDs()
+	if b.response.Error != nil {
+		b.response.Error.RequestID = requestID
+		b.response.Error.CorrelationID = correlationID
+	}
+
 	// Set headers
+	if requestID != "" {
+		w.Header().Set(headers.XRequestID, requestID)
+	}
+	if correlationID != "" {
+		w.Header().Set(headers.XCorrelationID, correlationID)
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 
@@ -286,6 +298,8 @@ func (b *Builder) buildMetadata(statusCode int) *Metadata {
 		StatusCode:    statusCode,
 	}
 
+	metadata.RequestID, metadata.CorrelationID = b.requestIDs()
+
 	// Extract from context
 	if b.ctx != nil {
 		metadata.TraceID = contextx.GetString(b.ctx, contextx.TraceIDKey)
@@ -299,8 +313,6 @@ func (b *Builder) buildMetadata(statusCode int) *Metadata {
 		metadata.Path = b.request.URL.Path
 		metadata.ClientIP = getClientIP(b.request)
 		metadata.UserAgent = b.request.UserAgent()
-		metadata.RequestID = b.request.Header.Get(headers.XRequestID)
-		metadata.CorrelationID = b.request.Header.Get(headers.XCorrelationID)
 
 		// Add request debug info if enabled
 		if b.config.EnableRequestDebug && b.debug != nil {
@@ -311,6 +323,24 @@ func (b *Builder) buildMetadata(statusCode int) *Metadata {
 	return metadata
 }
 
+// requestIDs returns the request and correlation IDs the middleware stored in
+// the context, falling back to the request headers
+func (b *Builder) requestIDs() (requestID, correlationID string) {
+	if b.ctx != nil {
+		requestID = contextx.GetString(b.ctx, contextx.RequestIDKey)
+		correlationID = contextx.GetString(b.ctx, contextx.CorrelationIDKey)
+	}
+	if b.request != nil {
+		if requestID == "" {
+			requestID = b.request.Header.Get(headers.XRequestID)
+		}
+		if correlationID == "" {
+			correlationID = b.request.Header.Get(headers.XCorrelationID)
+		}
+	}
+	return requestID, correlationID
+}
+
 func (b *Builder) addRequestDebugInfo(r *http.Request) {
 	if b.debug == nil || b.debug.info == nil {
 		return
@@ -0,0 +1,104 @@
+package response
+
+import (
+	"context"
+	"net/http"
+
+	"shared/pkg/database"
+	"shared/pkg/errors"
+	"shared/pkg/logger"
+	contextx "shared/server/context"
+)
+
+// FromDBError answers a database failure with the status its code maps to,
+// so the same condition gets the same status in every service:
+//
+//	CodeDBNoRows                       404 Not Found
+//	CodeDBDuplicateKey, CodeDBConflict 409 Conflict
+//	CodeDBForeignKey                   422 Unprocessable Entity
+//	CodeDBTimeout                      504 Gateway Timeout
+//	anything else                      500 Internal Server Error
+//
+// Only the constraint and column reach the client; the query, table and
+// driver error are logged through the configured logger instead.
+func FromDBError(ctx context.Context, r *http.Request, w http.ResponseWriter, err *database.DBError) error {
+	status := DBErrorStatus(err)
+	logDBError(ctx, r, err, status)
+
+	switch status {
+	case http.StatusNotFound:
+		return NotFoundError(ctx, r, w, "Resource")
+	case http.StatusConflict:
+		message := "Request conflicts with an existing record"
+		if err.Code() == database.CodeDBConflict {
+			message = "Record was modified concurrently"
+		}
+		return ConflictError(ctx, r, w, message, dbAppError(errors.CodeConflict, message, err))
+	case http.StatusUnprocessableEntity:
+		message := "Request refers to a record that does not exist"
+		return UnprocessableEntityError(ctx, r, w, message, dbAppError(errors.CodeUnprocessableEntity, message, err))
+	case http.StatusGatewayTimeout:
+		return GatewayTimeoutError(ctx, r, w, "database")
+	default:
+		return InternalServerError(ctx, r, w, "Database operation failed", errors.New(errors.CodeDatabaseError, "Database operation failed"))
+	}
+}
+
+// DBErrorStatus returns the HTTP status FromDBError answers err with
+func DBErrorStatus(err *database.DBError) int {
+	if err == nil {
+		return http.StatusInternalServerError
+	}
+
+	switch err.Code() {
+	case database.CodeDBNoRows:
+		return http.StatusNotFound
+	case database.CodeDBDuplicateKey, database.CodeDBConflict:
+		return http.StatusConflict
+	case database.CodeDBForeignKey:
+		return http.StatusUnprocessableEntity
+	case database.CodeDBTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// dbAppError carries only the constraint and column of a database error to
+// the client
+func dbAppError(code, message string, err *database.DBError) errors.AppError {
+	appErr := errors.New(code, message)
+	if err.Constraint() != "" {
+		appErr = appErr.WithDetail("constraint", err.Constraint())
+	}
+	if err.Column() != "" {
+		appErr = appErr.WithDetail("column", err.Column())
+	}
+	return appErr
+}
+
+func logDBError(ctx context.Context, r *http.Request, err *database.DBError, status int) {
+	log := GetGlobalConfig().Logger
+	if log == nil || err == nil {
+		return
+	}
+
+	fields := []logger.Field{
+		logger.String("code", err.Code()),
+		logger.String("operation", err.Operation()),
+		logger.String("table", err.Table()),
+		logger.String("constraint", err.Constraint()),
+		logger.String("sql_state", err.SQLState()),
+		logger.String("query", err.Query()),
+		logger.Int("status", status),
+		logger.String("request_id", contextx.GetString(ctx, contextx.RequestIDKey)),
+		logger.String("method", r.Method),
+		logger.String("path", r.URL.Path),
+		logger.Error(err),
+	}
+	if status >= http.StatusInternalServerError {
+		log.Error("Database error", fields...)
+		return
+	}
+	log.Warn("Database error", fields...)
+}
@@ -0,0 +1,74 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shared/pkg/database"
+)
+
+func TestFromDBError_MapsCodesToStatuses(t *testing.T) {
+	const query = "INSERT INTO users.profiles (username) VALUES ($1)"
+
+	tests := []struct {
+		code       string
+		wantStatus int
+	}{
+		{database.CodeDBNoRows, http.StatusNotFound},
+		{database.CodeDBDuplicateKey, http.StatusConflict},
+		{database.CodeDBConflict, http.StatusConflict},
+		{database.CodeDBForeignKey, http.StatusUnprocessableEntity},
+		{database.CodeDBTimeout, http.StatusGatewayTimeout},
+		{database.CodeDBConnection, http.StatusInternalServerError},
+		{database.CodeDBNotNull, http.StatusInternalServerError},
+		{database.CodeDBInternal, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			dbErr := database.NewDBError(tt.code, "database failure").
+				WithTable("users.profiles").
+				WithQuery(query).
+				WithWrapped(errors.New(`pq: relation "users.profiles" does not exist`))
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/profile", nil)
+			FromDBError(req.Context(), req, rec, dbErr)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := DBErrorStatus(dbErr); got != tt.wantStatus {
+				t.Fatalf("DBErrorStatus = %d, want %d", got, tt.wantStatus)
+			}
+			body := rec.Body.String()
+			if strings.Contains(body, "INSERT INTO") || strings.Contains(body, "pq:") {
+				t.Fatalf("body leaks database internals: %s", body)
+			}
+		})
+	}
+}
+
+func TestFromDBError_NamesConstraint(t *testing.T) {
+	dbErr := database.DuplicateError("users.profiles", "username", "taken").
+		WithConstraint("profiles_username_key")
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/profile", nil)
+	FromDBError(req.Context(), req, rec, dbErr)
+
+	var body Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Error.Context["constraint"] != "profiles_username_key" || body.Error.Context["column"] != "username" {
+		t.Fatalf("error context %v does not name the constraint and column", body.Error.Context)
+	}
+	if strings.Contains(rec.Body.String(), "taken") {
+		t.Fatalf("body %s leaks the conflicting value", rec.Body.String())
+	}
+}
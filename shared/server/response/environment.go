@@ -2,6 +2,7 @@ package response
 
 import (
 	"os"
+	"shared/pkg/logger"
 	"shared/server/env"
 )
 
@@ -36,6 +37,10 @@ type Config struct {
 	GitCommit string
 	GitBranch string
 	BuildTime string
+
+	// Logger records the details helpers such as FromDBError keep out of the
+	// response body. Nothing is logged when it is nil.
+	Logger logger.Logger
 }
 
 // DefaultConfig returns a default configuration based on environment
@@ -136,6 +141,11 @@ func SetGlobalConfig(cfg *Config) {
 	globalConfig = cfg
 }
 
+// SetLogger sets the logger on the global configuration
+func SetLogger(log logger.Logger) {
+	GetGlobalConfig().Logger = log
+}
+
 // GetGlobalConfig returns the global configuration
 func GetGlobalConfig() *Config {
 	if globalConfig == nil {
@@ -0,0 +1,245 @@
+package response
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"shared/server/headers"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Media types Respond can encode a payload as
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeCSV     = "text/csv"
+	MediaTypeMsgpack = "application/msgpack"
+)
+
+// Respond sends payload in the format the request's Accept header prefers.
+// JSON is the default and uses the standard response envelope; CSV writes a
+// slice of structs as rows headed by their db or json tags; MessagePack is a
+// compact binary encoding with libraries for most languages, keyed by the
+// same json tags as the JSON body. Both CSV and MessagePack carry the bare
+// payload.
+// A format that can't represent payload, or an Accept header naming none of
+// these, gets JSON.
+func Respond(ctx context.Context, r *http.Request, w http.ResponseWriter, statusCode int, payload any) error {
+	w.Header().Add("Vary", "Accept")
+
+	var (
+		body        []byte
+		contentType string
+		err         error
+	)
+	switch negotiate(r.Header.Get("Accept")) {
+	case MediaTypeCSV:
+		body, err = encodeCSV(payload)
+		contentType = MediaTypeCSV + "; charset=utf-8"
+	case MediaTypeMsgpack:
+		body, err = encodeMsgpack(payload)
+		contentType = MediaTypeMsgpack
+	}
+	if body == nil || err != nil {
+		return JSONWithContext(ctx, r, w, statusCode, payload)
+	}
+
+	requestID, correlationID := Success().WithContext(ctx).WithRequest(r).requestIDs()
+	if requestID != "" {
+		w.Header().Set(headers.XRequestID, requestID)
+	}
+	if correlationID != "" {
+		w.Header().Set(headers.XCorrelationID, correlationID)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(statusCode)
+	_, err = w.Write(body)
+	return err
+}
+
+// negotiate picks the supported media type with the highest quality in
+// accept, preferring the earliest on ties. Wildcards and anything
+// unsupported resolve to JSON.
+func negotiate(accept string) string {
+	best, bestQ := MediaTypeJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		switch mediaType {
+		case MediaTypeJSON, MediaTypeCSV, MediaTypeMsgpack:
+			best, bestQ = mediaType, q
+		case "application/x-msgpack":
+			best, bestQ = MediaTypeMsgpack, q
+		case "*/*", "application/*":
+			best, bestQ = MediaTypeJSON, q
+		}
+	}
+	return best
+}
+
+// encodeMsgpack names struct fields by their json tags, so MessagePack and
+// JSON clients see the same keys
+func encodeMsgpack(payload any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeCSV writes a slice or array of structs, or pointers to them, as CSV
+// with a header row; any other payload is an error
+func encodeCSV(payload any) ([]byte, error) {
+	v := reflect.ValueOf(payload)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("csv: cannot encode %T", payload)
+	}
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("csv: cannot encode %T", payload)
+	}
+
+	columns := csvColumns(elem, nil)
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.name
+	}
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+
+	record := make([]string, len(columns))
+	for i := 0; i < v.Len(); i++ {
+		row := reflect.Indirect(v.Index(i))
+		for j, col := range columns {
+			if !row.IsValid() {
+				record[j] = ""
+				continue
+			}
+			field, err := row.FieldByIndexErr(col.index)
+			if err != nil {
+				// A nil embedded pointer leaves the column empty
+				record[j] = ""
+				continue
+			}
+			if record[j], err = csvCell(field); err != nil {
+				return nil, err
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// csvColumns lists the exported fields of t named by their db tag, else their
+// json tag, else the field name. Untagged embedded structs are flattened and
+// fields tagged "-" are skipped.
+func csvColumns(t reflect.Type, index []int) []csvColumn {
+	var columns []csvColumn
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		name := field.Tag.Get("db")
+		if name == "" {
+			name, _, _ = strings.Cut(field.Tag.Get("json"), ",")
+		}
+
+		if name == "" && field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				columns = append(columns, csvColumns(embedded, fieldIndex)...)
+				continue
+			}
+		}
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: fieldIndex})
+	}
+	return columns
+}
+
+// csvCell formats one value: nil is empty, times are RFC 3339, scalars are
+// printed as is and anything else is written as JSON
+func csvCell(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return "", nil
+	}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
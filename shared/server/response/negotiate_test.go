@@ -0,0 +1,148 @@
+package response
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"shared/pkg/database/postgres/models"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func testEvents() []models.Event {
+	value := 4.5
+	platform := "ios"
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []models.Event{
+		{
+			ID:             "e1",
+			EventName:      "signup",
+			EventValue:     &value,
+			Platform:       &platform,
+			Properties:     json.RawMessage(`{"plan":"pro"}`),
+			CreatedAt:      created,
+			EventTimestamp: created,
+		},
+		{ID: "e2", EventName: "login, web", CreatedAt: created, EventTimestamp: created},
+	}
+}
+
+func respond(t *testing.T, accept string, payload any) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	if err := Respond(req.Context(), req, w, http.StatusOK, payload); err != nil {
+		t.Fatalf("respond: %v", err)
+	}
+	return w
+}
+
+func TestRespond_DefaultsToJSONEnvelope(t *testing.T) {
+	for _, accept := range []string{"", "application/json", "*/*", "application/xml"} {
+		w := respond(t, accept, testEvents())
+
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Fatalf("Accept %q: Content-Type = %q, want JSON", accept, ct)
+		}
+		var body struct {
+			Data []models.Event `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Accept %q: decode: %v", accept, err)
+		}
+		if len(body.Data) != 2 || body.Data[1].EventName != "login, web" {
+			t.Fatalf("Accept %q: data = %+v", accept, body.Data)
+		}
+	}
+}
+
+func TestRespond_NegotiatesCSV(t *testing.T) {
+	w := respond(t, "application/json;q=0.5, text/csv", testEvents())
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Fatalf("Content-Type = %q, want text/csv", ct)
+	}
+	if vary := w.Header().Get("Vary"); vary != "Accept" {
+		t.Fatalf("Vary = %q, want Accept", vary)
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want a header and 2 rows: %v", len(records), records)
+	}
+
+	column := make(map[string]int)
+	for i, name := range records[0] {
+		column[name] = i
+	}
+	want := []map[string]string{
+		{"id": "e1", "event_name": "signup", "event_value": "4.5", "platform": "ios", "properties": `{"plan":"pro"}`, "created_at": "2026-01-02T03:04:05Z"},
+		{"id": "e2", "event_name": "login, web", "event_value": "", "platform": "", "properties": "", "created_at": "2026-01-02T03:04:05Z"},
+	}
+	for i, row := range want {
+		for name, value := range row {
+			idx, ok := column[name]
+			if !ok {
+				t.Fatalf("header %v has no %q column", records[0], name)
+			}
+			if got := records[i+1][idx]; got != value {
+				t.Fatalf("row %d %s = %q, want %q", i, name, got, value)
+			}
+		}
+	}
+}
+
+func TestRespond_NegotiatesMsgpack(t *testing.T) {
+	for _, accept := range []string{"application/msgpack", "application/x-msgpack, application/json;q=0.9"} {
+		w := respond(t, accept, testEvents())
+
+		if ct := w.Header().Get("Content-Type"); ct != MediaTypeMsgpack {
+			t.Fatalf("Accept %q: Content-Type = %q, want %s", accept, ct, MediaTypeMsgpack)
+		}
+
+		// A client with no Go types reads the same keys the JSON body uses
+		var generic []map[string]interface{}
+		if err := msgpack.Unmarshal(w.Body.Bytes(), &generic); err != nil {
+			t.Fatalf("Accept %q: decode: %v", accept, err)
+		}
+		if len(generic) != 2 || generic[0]["event_name"] != "signup" || generic[0]["event_value"] != 4.5 {
+			t.Fatalf("Accept %q: decoded %v, want json tag keys", accept, generic)
+		}
+		if _, ok := generic[1]["event_value"]; ok {
+			t.Fatalf("Accept %q: omitempty field encoded: %v", accept, generic[1])
+		}
+
+		dec := msgpack.NewDecoder(w.Body)
+		dec.SetCustomStructTag("json")
+		var events []models.Event
+		if err := dec.Decode(&events); err != nil {
+			t.Fatalf("Accept %q: decode events: %v", accept, err)
+		}
+		got := events[0]
+		if got.ID != "e1" || *got.EventValue != 4.5 || *got.Platform != "ios" || string(got.Properties) != `{"plan":"pro"}` || !got.CreatedAt.Equal(testEvents()[0].CreatedAt) {
+			t.Fatalf("Accept %q: round trip = %+v", accept, got)
+		}
+		if events[1].EventName != "login, web" || events[1].EventValue != nil {
+			t.Fatalf("Accept %q: round trip = %+v", accept, events[1])
+		}
+	}
+}
+
+func TestRespond_CSVFallsBackToJSONForNonTabularPayload(t *testing.T) {
+	w := respond(t, "text/csv", map[string]int{"count": 2})
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Fatalf("Content-Type = %q, want JSON fallback", ct)
+	}
+}
@@ -51,6 +51,11 @@ type ErrorDetails struct {
 	InnerError  string                 `json:"inner_error,omitempty"`
 	Timestamp   time.Time              `json:"timestamp"`
 	Context     map[string]interface{} `json:"context,omitempty"`
+
+	// RequestID and CorrelationID tie an error a client reports back to the
+	// server's logs for the request
+	RequestID     string `json:"request_id,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
 // ErrorType categorizes errors for client handling
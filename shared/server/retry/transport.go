@@ -0,0 +1,187 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseDelay   = 100 * time.Millisecond
+	DefaultMaxDelay    = 2 * time.Second
+
+	// drainLimit bounds how much of a discarded response is read so its
+	// connection can be reused
+	drainLimit = 64 << 10
+)
+
+// DefaultRetryableStatuses are the responses worth another attempt when
+// Config.RetryableStatuses is empty.
+var DefaultRetryableStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+type Config struct {
+	// MaxAttempts caps the attempts per request, the first one included.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles each attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts. A Retry-After asking for
+	// longer ends the retries and returns that response.
+	MaxDelay time.Duration
+	// RetryableStatuses are the response codes that are retried.
+	RetryableStatuses []int
+	// RetryNonIdempotent allows retrying POST and PATCH requests without an
+	// Idempotency-Key header. Only set it when the server tolerates repeats.
+	RetryNonIdempotent bool
+}
+
+// Transport retries idempotent requests that fail with a connection error or
+// a retryable status, backing off exponentially with jitter between attempts.
+// Retries stop as soon as the request's context is done.
+type Transport struct {
+	base      http.RoundTripper
+	config    Config
+	retryable map[int]bool
+	sleep     func(ctx context.Context, d time.Duration) error
+}
+
+// NewTransport wraps base, or http.DefaultTransport when base is nil.
+func NewTransport(base http.RoundTripper, config Config) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = DefaultBaseDelay
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = DefaultMaxDelay
+	}
+	if len(config.RetryableStatuses) == 0 {
+		config.RetryableStatuses = DefaultRetryableStatuses
+	}
+
+	retryable := make(map[int]bool, len(config.RetryableStatuses))
+	for _, status := range config.RetryableStatuses {
+		retryable[status] = true
+	}
+	return &Transport{
+		base:      base,
+		config:    config,
+		retryable: retryable,
+		sleep:     sleep,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.canRetry(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		attemptReq, err := t.attemptRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if ctx.Err() != nil || attempt >= t.config.MaxAttempts {
+			return resp, err
+		}
+		if err == nil && !t.retryable[resp.StatusCode] {
+			return resp, nil
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				if wait > t.config.MaxDelay {
+					return resp, nil
+				}
+				delay = wait
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, drainLimit))
+			resp.Body.Close()
+		}
+
+		if err := t.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// canRetry reports whether req may be sent more than once
+func (t *Transport) canRetry(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return t.config.RetryNonIdempotent || req.Header.Get("Idempotency-Key") != ""
+}
+
+// attemptRequest returns req for the first attempt and a copy with a fresh
+// body for the ones after
+func (t *Transport) attemptRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 {
+		return req, nil
+	}
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// backoff returns a random wait between half and all of the exponential
+// delay for attempt
+func (t *Transport) backoff(attempt int) time.Duration {
+	delay := t.config.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > t.config.MaxDelay {
+		delay = t.config.MaxDelay
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status, or by dropping
+// the connection when status is 0
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= failures {
+			if status == 0 {
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestClient(config Config) (*http.Client, *[]time.Duration) {
+	var waits []time.Duration
+	transport := NewTransport(nil, config)
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	return &http.Client{Transport: transport}, &waits
+}
+
+func TestTransportRetriesStatusAndConnectionErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{name: "bad gateway", status: http.StatusBadGateway},
+		{name: "connection reset", status: 0},
+	}
+	for _, tt := range tests {
+		srv, calls := flakyServer(t, 2, tt.status, nil)
+		client, waits := newTestClient(Config{BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second})
+
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("%s: Get() error = %v", tt.name, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+			t.Fatalf("%s: status = %d after %d calls, want 200 after 3", tt.name, resp.StatusCode, calls.Load())
+		}
+		if len(*waits) != 2 || (*waits)[0] < 5*time.Millisecond || (*waits)[0] > 10*time.Millisecond || (*waits)[1] < 10*time.Millisecond || (*waits)[1] > 20*time.Millisecond {
+			t.Fatalf("%s: waits = %v, want jittered 10ms then 20ms", tt.name, *waits)
+		}
+	}
+}
+
+func TestTransportStopsAtMaxAttempts(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusServiceUnavailable, nil)
+	client, _ := newTestClient(Config{MaxAttempts: 4})
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 4 {
+		t.Fatalf("status = %d after %d calls, want 503 after 4", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestTransportNeverRetriesPostUnlessAllowed(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusBadGateway, nil)
+	client, _ := newTestClient(Config{})
+
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != 1 {
+		t.Fatalf("status = %d after %d calls, want 502 after 1", resp.StatusCode, calls.Load())
+	}
+
+	srv, calls = flakyServer(t, 1, http.StatusBadGateway, nil)
+	client, _ = newTestClient(Config{RetryNonIdempotent: true})
+	resp, err = client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if calls.Load() != 2 || string(body) != "payload" {
+		t.Fatalf("got %q after %d calls, want the body replayed on the second call", body, calls.Load())
+	}
+}
+
+func TestTransportHonorsRetryAfter(t *testing.T) {
+	srv, _ := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+	client, waits := newTestClient(Config{MaxDelay: 5 * time.Second})
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if len(*waits) != 1 || (*waits)[0] != time.Second {
+		t.Fatalf("waits = %v, want [1s] from Retry-After", *waits)
+	}
+
+	srv, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"60"}})
+	client, _ = newTestClient(Config{MaxDelay: 5 * time.Second})
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Fatalf("status = %d after %d calls, want 429 returned when Retry-After exceeds MaxDelay", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestTransportStopsWhenContextIsDone(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusBadGateway, nil)
+	client := &http.Client{Transport: NewTransport(nil, Config{BaseDelay: time.Minute, MaxDelay: time.Minute})}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+
+	start := time.Now()
+	_, err := client.Do(req)
+	if err == nil {
+		t.Fatal("Do() error = nil, want the context error")
+	}
+	if time.Since(start) > 5*time.Second || calls.Load() != 1 {
+		t.Fatalf("took %v for %d calls, want to stop while backing off", time.Since(start), calls.Load())
+	}
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readAll answers 413 when the body is over the route's limit
+func readAll(w http.ResponseWriter, r *http.Request) {
+	if _, err := io.ReadAll(r.Body); err != nil {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestBodyLimits(t *testing.T) {
+	r := NewBuilder().
+		WithBodyLimit(10).
+		WithRoutes(func(r *Router) {
+			r.Post("/messages", readAll)
+			r.Put("/messages/{id}", readAll).WithBodyLimit(100)
+		}).
+		WithRoutesGroup("/media", func(g *RouteGroup) {
+			g.Post("/{bucket}/upload", readAll).WithBodyLimit(1 * KB)
+		}).
+		Build()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		size   int
+		want   int
+	}{
+		{name: "default within limit", method: http.MethodPost, path: "/messages", size: 10, want: http.StatusOK},
+		{name: "default exceeded", method: http.MethodPost, path: "/messages", size: 11, want: http.StatusRequestEntityTooLarge},
+		{name: "parameterized route uses its own limit", method: http.MethodPut, path: "/messages/42", size: 100, want: http.StatusOK},
+		{name: "parameterized route limit exceeded", method: http.MethodPut, path: "/messages/42", size: 101, want: http.StatusRequestEntityTooLarge},
+		{name: "group route uses its own limit", method: http.MethodPost, path: "/media/avatars/upload", size: 1000, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(strings.Repeat("x", tt.size)))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Fatalf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
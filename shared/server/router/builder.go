@@ -9,10 +9,17 @@ import (
 	"shared/pkg/logger"
 	"shared/pkg/logger/adapter"
 	"shared/server/middleware"
+	"shared/server/prometheus"
 
 	"github.com/gorilla/mux"
 )
 
+// Byte sizes for body limits, e.g. 50*MB
+const (
+	KB int64 = 1 << 10
+	MB int64 = 1 << 20
+)
+
 type Middleware func(http.Handler) http.Handler
 
 type Handler func(http.ResponseWriter, *http.Request)
@@ -27,6 +34,7 @@ type Builder struct {
 	notFoundHandler    Handler
 	notAllowedHandler  Handler
 	enableSystemRoutes bool
+	bodyLimit          int64
 	logger             logger.Logger
 }
 
@@ -80,6 +88,13 @@ func (b *Builder) WithMiddlewareChain(chain *middleware.Chain) *Builder {
 	return b
 }
 
+// WithBodyLimit caps request bodies on app routes that don't set their own
+// limit with Route.WithBodyLimit
+func (b *Builder) WithBodyLimit(maxBytes int64) *Builder {
+	b.bodyLimit = maxBytes
+	return b
+}
+
 func getFunctionName(i interface{}) string {
 	return runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name()
 }
@@ -104,6 +119,20 @@ func (b *Builder) WithMetricsEndpoint(path string, handler Handler) *Builder {
 	return b
 }
 
+func (b *Builder) WithPrometheusMetrics(path string, recorder *prometheus.PrometheusRecorder) *Builder {
+	if path == "" {
+		path = DefaultSystemEndpointsConfig().MetricsPath
+	}
+	b.systemEndpoints = append(b.systemEndpoints, Endpoint{
+		Path:    path,
+		Handler: recorder.Handler(),
+		Method:  http.MethodGet,
+	})
+	b.earlyMiddleware = append(b.earlyMiddleware, Middleware(middleware.Metrics(recorder)))
+	b.logger.Debug("Prometheus metrics endpoint queued", logger.String("path", path))
+	return b
+}
+
 func (b *Builder) WithVersionEndpoint(path string, handler Handler) *Builder {
 	b.systemEndpoints = append(b.systemEndpoints, Endpoint{
 		Path:    path,
@@ -124,6 +153,24 @@ func (b *Builder) WithStatusEndpoint(path string, handler Handler) *Builder {
 	return b
 }
 
+// WithLogLevelEndpoint mounts GET and PUT on path to read and change log's
+// level at runtime. The endpoint has no auth of its own, so nothing is mounted
+// unless enabled is true; services keep it off outside trusted networks.
+func (b *Builder) WithLogLevelEndpoint(path string, log logger.Logger, enabled bool) *Builder {
+	if !enabled {
+		b.logger.Debug("Log level endpoint disabled", logger.String("path", path))
+		return b
+	}
+
+	handler := LogLevelHandler(log)
+	b.systemEndpoints = append(b.systemEndpoints,
+		Endpoint{Path: path, Handler: handler, Method: http.MethodGet},
+		Endpoint{Path: path, Handler: handler, Method: http.MethodPut},
+	)
+	b.logger.Debug("Log level endpoint queued", logger.String("path", path))
+	return b
+}
+
 func (b *Builder) WithNotFoundHandler(handler Handler) *Builder {
 	b.notFoundHandler = handler
 	b.logger.Debug("Not Found handler queued")
@@ -197,6 +244,14 @@ func (b *Builder) Build() *Router {
 		b.logger.Debug("Applied late middleware to app router", logger.String("name", getFunctionName(mw)))
 	}
 
+	if b.bodyLimit > 0 || len(appRouter.bodyLimits) > 0 {
+		appRouter.Use(bodyLimitMiddleware(appRouter, b.bodyLimit))
+		b.logger.Debug("Applied body limits to app router",
+			logger.Int64("default_limit", b.bodyLimit),
+			logger.Int("route_limits", len(appRouter.bodyLimits)),
+		)
+	}
+
 	b.router.Mux().PathPrefix("/").Handler(appMux)
 
 	if b.notFoundHandler != nil {
@@ -219,6 +274,23 @@ func (b *Builder) Build() *Router {
 	return b.router
 }
 
+// bodyLimitMiddleware applies the limit of the matched route, falling back to
+// defaultLimit; zero means no limit
+func bodyLimitMiddleware(r *Router, defaultLimit int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			limit, ok := r.bodyLimit(req)
+			if !ok {
+				limit = defaultLimit
+			}
+			if limit > 0 && req.Body != nil {
+				req.Body = http.MaxBytesReader(w, req.Body, limit)
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
 type SystemEndpointsConfig struct {
 	HealthPath       string
 	LivenessPath     string
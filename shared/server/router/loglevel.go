@@ -0,0 +1,48 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"shared/pkg/logger"
+	"shared/server/response"
+)
+
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// LogLevelHandler reports log's level on GET and changes it on PUT with a
+// body like {"level":"debug"}. The change applies to every logger derived
+// from log and lasts until the next change or restart.
+func LogLevelHandler(log logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if r.Method == http.MethodPut {
+			var req logLevelRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&req); err != nil {
+				response.BadRequestError(ctx, r, w, "Invalid request body", err)
+				return
+			}
+
+			level, ok := logger.LookupLevel(req.Level)
+			if !ok {
+				response.BadRequestError(ctx, r, w, fmt.Sprintf("Unknown log level %q", req.Level), nil)
+				return
+			}
+
+			previous := log.Level()
+			log.SetLevel(level)
+			log.Warn("Log level changed",
+				logger.String("from", previous.String()),
+				logger.String("to", level.String()),
+			)
+		}
+
+		response.JSONWithMessage(ctx, r, w, http.StatusOK, "Log level", map[string]string{
+			"level": log.Level().String(),
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shared/pkg/logger"
+)
+
+type levelLogger struct {
+	logger.Logger
+	level logger.Level
+}
+
+func (l *levelLogger) SetLevel(level logger.Level) { l.level = level }
+func (l *levelLogger) Level() logger.Level         { return l.level }
+
+func serveLogLevel(r *Router, method, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/admin/loglevel", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLogLevelEndpoint(t *testing.T) {
+	log := &levelLogger{Logger: logger.NewNoop(), level: logger.InfoLevel}
+	r := NewBuilder().WithLogLevelEndpoint("/admin/loglevel", log, true).Build()
+
+	if rec := serveLogLevel(r, http.MethodGet, ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"info"`) {
+		t.Fatalf("GET = %d %s, want 200 with the current level", rec.Code, rec.Body.String())
+	}
+
+	if rec := serveLogLevel(r, http.MethodPut, `{"level":"debug"}`); rec.Code != http.StatusOK {
+		t.Fatalf("PUT debug = %d, want 200", rec.Code)
+	}
+	if log.level != logger.DebugLevel {
+		t.Fatalf("level = %s, want debug", log.level)
+	}
+
+	if rec := serveLogLevel(r, http.MethodPut, `{"level":"loud"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("PUT unknown level = %d, want 400", rec.Code)
+	}
+	if rec := serveLogLevel(r, http.MethodPut, `not json`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("PUT malformed body = %d, want 400", rec.Code)
+	}
+	if log.level != logger.DebugLevel {
+		t.Fatalf("level = %s after rejected updates, want debug", log.level)
+	}
+}
+
+func TestLogLevelEndpoint_NotMountedUnlessEnabled(t *testing.T) {
+	log := &levelLogger{Logger: logger.NewNoop(), level: logger.InfoLevel}
+	r := NewBuilder().WithLogLevelEndpoint("/admin/loglevel", log, false).Build()
+
+	if rec := serveLogLevel(r, http.MethodPut, `{"level":"debug"}`); rec.Code == http.StatusOK {
+		t.Fatalf("PUT on a disabled endpoint = %d, want it unrouted", rec.Code)
+	}
+	if log.level != logger.InfoLevel {
+		t.Fatalf("level = %s, want info", log.level)
+	}
+}
@@ -12,6 +12,25 @@ type Router struct {
 	mux            *mux.Router
 	routes         []RouteInfo
 	strictPriority bool
+	// bodyLimits holds the limits set with Route.WithBodyLimit
+	bodyLimits map[*mux.Route]int64
+}
+
+// Route is a registered route; it embeds the mux route so its methods stay
+// available
+type Route struct {
+	*mux.Route
+	router *Router
+}
+
+// WithBodyLimit caps request bodies on this route at maxBytes, overriding the
+// builder's default
+func (r *Route) WithBodyLimit(maxBytes int64) *Route {
+	if r.router.bodyLimits == nil {
+		r.router.bodyLimits = make(map[*mux.Route]int64)
+	}
+	r.router.bodyLimits[r.Route] = maxBytes
+	return r
 }
 
 type RouteInfo struct {
@@ -56,24 +75,24 @@ func (r *Router) StrictPriority(enabled bool) {
 	r.strictPriority = enabled
 }
 
-func (r *Router) RegisterExact(method, path string, handler http.Handler) *mux.Route {
+func (r *Router) RegisterExact(method, path string, handler http.Handler) *Route {
 	route := r.mux.NewRoute().Path(path).Methods(method).Handler(handler)
 	r.routes = append(r.routes, RouteInfo{
 		Method:  method,
 		Pattern: path,
 		Type:    RouteTypeExact,
 	})
-	return route
+	return &Route{Route: route, router: r}
 }
 
-func (r *Router) RegisterFunc(method, path string, handler http.HandlerFunc) *mux.Route {
+func (r *Router) RegisterFunc(method, path string, handler http.HandlerFunc) *Route {
 	route := r.mux.NewRoute().Path(path).Methods(method).HandlerFunc(handler)
 	r.routes = append(r.routes, RouteInfo{
 		Method:  method,
 		Pattern: path,
 		Type:    RouteTypePrefix,
 	})
-	return route
+	return &Route{Route: route, router: r}
 }
 
 func (r *Router) With(middlewares ...middleware.Handler) *Router {
@@ -85,35 +104,35 @@ func (r *Router) With(middlewares ...middleware.Handler) *Router {
 	return r
 }
 
-func (r *Router) Handle(path string, method string, handler http.Handler) *mux.Route {
+func (r *Router) Handle(path string, method string, handler http.Handler) *Route {
 	return r.RegisterExact(method, path, handler)
 }
 
-func (r *Router) HandleFunc(path string, method string, handler http.HandlerFunc) *mux.Route {
+func (r *Router) HandleFunc(path string, method string, handler http.HandlerFunc) *Route {
 	return r.RegisterFunc(method, path, handler)
 }
 
-func (r *Router) Get(path string, handler http.HandlerFunc) *mux.Route {
+func (r *Router) Get(path string, handler http.HandlerFunc) *Route {
 	return r.RegisterExact(http.MethodGet, path, handler)
 }
 
-func (r *Router) Post(path string, handler http.HandlerFunc) *mux.Route {
+func (r *Router) Post(path string, handler http.HandlerFunc) *Route {
 	return r.RegisterExact(http.MethodPost, path, handler)
 }
 
-func (r *Router) Put(path string, handler http.HandlerFunc) *mux.Route {
+func (r *Router) Put(path string, handler http.HandlerFunc) *Route {
 	return r.RegisterExact(http.MethodPut, path, handler)
 }
 
-func (r *Router) Delete(path string, handler http.HandlerFunc) *mux.Route {
+func (r *Router) Delete(path string, handler http.HandlerFunc) *Route {
 	return r.RegisterExact(http.MethodDelete, path, handler)
 }
 
-func (r *Router) Patch(path string, handler http.HandlerFunc) *mux.Route {
+func (r *Router) Patch(path string, handler http.HandlerFunc) *Route {
 	return r.RegisterExact(http.MethodPatch, path, handler)
 }
 
-func (r *Router) Options(path string, handler http.HandlerFunc) *mux.Route {
+func (r *Router) Options(path string, handler http.HandlerFunc) *Route {
 	return r.RegisterExact(http.MethodOptions, path, handler)
 }
 
@@ -135,6 +154,16 @@ func (r *Router) Group(prefix string, middlewares ...mux.MiddlewareFunc) *RouteG
 	}
 }
 
+// bodyLimit returns the limit set on the route req matched, if any
+func (r *Router) bodyLimit(req *http.Request) (int64, bool) {
+	route := mux.CurrentRoute(req)
+	if route == nil {
+		return 0, false
+	}
+	limit, ok := r.bodyLimits[route]
+	return limit, ok
+}
+
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.mux.ServeHTTP(w, req)
 }
@@ -145,7 +174,7 @@ type RouteGroup struct {
 	parent *Router
 }
 
-func (g *RouteGroup) HandleProxy(handler http.HandlerFunc, methods ...string) *mux.Route {
+func (g *RouteGroup) HandleProxy(handler http.HandlerFunc, methods ...string) *Route {
 	route := g.router.PathPrefix("/").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := strings.TrimPrefix(r.URL.Path, g.prefix)
 		if path == "" {
@@ -173,36 +202,36 @@ func (g *RouteGroup) HandleProxy(handler http.HandlerFunc, methods ...string) *m
 		Pattern: g.prefix + "/*",
 		Type:    RouteTypeCatch,
 	})
-	return route
+	return &Route{Route: route, router: g.parent}
 }
 
-func (g *RouteGroup) Handle(path string, method string, handler http.HandlerFunc) *mux.Route {
+func (g *RouteGroup) Handle(path string, method string, handler http.HandlerFunc) *Route {
 	route := g.router.Path(path).Methods(method).HandlerFunc(handler)
 	g.parent.routes = append(g.parent.routes, RouteInfo{
 		Method:  method,
 		Pattern: g.prefix + path,
 		Type:    RouteTypeExact,
 	})
-	return route
+	return &Route{Route: route, router: g.parent}
 }
 
-func (g *RouteGroup) Get(path string, handler http.HandlerFunc) *mux.Route {
+func (g *RouteGroup) Get(path string, handler http.HandlerFunc) *Route {
 	return g.Handle(path, http.MethodGet, handler)
 }
 
-func (g *RouteGroup) Post(path string, handler http.HandlerFunc) *mux.Route {
+func (g *RouteGroup) Post(path string, handler http.HandlerFunc) *Route {
 	return g.Handle(path, http.MethodPost, handler)
 }
 
-func (g *RouteGroup) Put(path string, handler http.HandlerFunc) *mux.Route {
+func (g *RouteGroup) Put(path string, handler http.HandlerFunc) *Route {
 	return g.Handle(path, http.MethodPut, handler)
 }
 
-func (g *RouteGroup) Delete(path string, handler http.HandlerFunc) *mux.Route {
+func (g *RouteGroup) Delete(path string, handler http.HandlerFunc) *Route {
 	return g.Handle(path, http.MethodDelete, handler)
 }
 
-func (g *RouteGroup) Patch(path string, handler http.HandlerFunc) *mux.Route {
+func (g *RouteGroup) Patch(path string, handler http.HandlerFunc) *Route {
 	return g.Handle(path, http.MethodPatch, handler)
 }
 
@@ -0,0 +1,95 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"shared/pkg/logger"
+	"sync/atomic"
+	"time"
+)
+
+// drainLogInterval is how often DrainHook reports the requests it still waits for
+const drainLogInterval = time.Second
+
+// InFlight counts the requests a server is currently handling so shutdown can
+// wait for them rather than sleeping a fixed time
+type InFlight struct {
+	count atomic.Int64
+}
+
+func NewInFlight() *InFlight {
+	return &InFlight{}
+}
+
+// Middleware counts each request from entry until its handler returns
+func (f *InFlight) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.count.Add(1)
+		defer f.count.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Count returns the number of requests being handled
+func (f *InFlight) Count() int64 {
+	return f.count.Load()
+}
+
+// DrainHook creates a hook that waits until no request is in flight. It gives
+// up when ctx ends, so register it with the drain timeout.
+func DrainHook(inFlight *InFlight, log logger.Logger) Hook {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+
+		var lastLogged time.Time
+		for {
+			remaining := inFlight.Count()
+			if remaining == 0 {
+				return nil
+			}
+
+			if time.Since(lastLogged) >= drainLogInterval {
+				log.Info("Waiting for in-flight requests to finish",
+					logger.Int64("in_flight", remaining),
+				)
+				lastLogged = time.Now()
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return fmt.Errorf("%d requests still in flight: %w", inFlight.Count(), ctx.Err())
+			}
+		}
+	}
+}
+
+// ServerDrainHook shuts server down and waits for the requests inFlight counts
+// to finish, giving up on them after drainTimeout. Draining has to happen
+// inside the server hook: as a separate hook at the same priority it would
+// run after Shutdown had already waited, and always find nothing in flight.
+func ServerDrainHook(server interface{ Shutdown(context.Context) error }, inFlight *InFlight, drainTimeout time.Duration, log logger.Logger) Hook {
+	drain := DrainHook(inFlight, log)
+	return func(ctx context.Context) error {
+		shutdownCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// Shutdown closes the listeners straight away, so no request starts
+		// while the drain waits
+		shutdownErr := make(chan error, 1)
+		go func() {
+			shutdownErr <- server.Shutdown(shutdownCtx)
+		}()
+
+		drainCtx, drainCancel := context.WithTimeout(ctx, drainTimeout)
+		defer drainCancel()
+		if err := drain(drainCtx); err != nil {
+			cancel()
+			<-shutdownErr
+			return err
+		}
+		return <-shutdownErr
+	}
+}
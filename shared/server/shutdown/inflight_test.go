@@ -0,0 +1,139 @@
+package shutdown
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"shared/pkg/logger"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDrainHook_WaitsForSlowRequest(t *testing.T) {
+	inFlight := NewInFlight()
+	started := make(chan struct{})
+	var finished atomic.Bool
+
+	srv := httptest.NewServer(inFlight.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		finished.Store(true)
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer srv.Close()
+
+	go http.Get(srv.URL)
+	<-started
+
+	m := New(WithTimeout(5 * time.Second))
+	m.RegisterWithOptions("drain-connections", DrainHook(inFlight, logger.NewNoop()), PriorityHigh, 2*time.Second)
+
+	start := time.Now()
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if !finished.Load() {
+		t.Fatalf("shutdown returned before the in-flight request finished")
+	}
+	if elapsed := time.Since(start); elapsed >= 2*time.Second {
+		t.Fatalf("shutdown took %v, want it to end once the request finished", elapsed)
+	}
+	if n := inFlight.Count(); n != 0 {
+		t.Fatalf("in-flight count = %d, want 0", n)
+	}
+}
+
+func TestDrainHook_GivesUpAtTimeout(t *testing.T) {
+	inFlight := NewInFlight()
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+
+	handler := inFlight.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := DrainHook(inFlight, logger.NewNoop())(ctx); err == nil {
+		t.Fatalf("drain succeeded with a request still in flight")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("drain took %v past its timeout", elapsed)
+	}
+}
+
+func TestServerDrainHook_StopsAcceptingThenWaits(t *testing.T) {
+	inFlight := NewInFlight()
+	started := make(chan struct{})
+	var finished atomic.Bool
+
+	srv := httptest.NewServer(inFlight.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		finished.Store(true)
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer srv.Close()
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	m := New(WithTimeout(5 * time.Second))
+	m.RegisterWithPriority("http-server", ServerDrainHook(srv.Config, inFlight, 2*time.Second, logger.NewNoop()), PriorityHigh)
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if !finished.Load() {
+		t.Fatalf("shutdown returned before the in-flight request finished")
+	}
+	if got := <-status; got != http.StatusOK {
+		t.Fatalf("in-flight request status = %d, want 200", got)
+	}
+	if _, err := http.Get(srv.URL); err == nil {
+		t.Fatalf("server still accepted requests after shutdown")
+	}
+}
+
+func TestServerDrainHook_GivesUpAtDrainTimeout(t *testing.T) {
+	inFlight := NewInFlight()
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	srv := httptest.NewServer(inFlight.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})))
+	defer srv.Close()
+	// Close waits for the handler, so release it first
+	defer close(release)
+
+	go http.Get(srv.URL)
+	<-started
+
+	m := New(WithTimeout(5 * time.Second))
+	m.RegisterWithPriority("http-server", ServerDrainHook(srv.Config, inFlight, 50*time.Millisecond, logger.NewNoop()), PriorityHigh)
+
+	start := time.Now()
+	if err := m.Shutdown(context.Background()); err == nil {
+		t.Fatalf("shutdown succeeded with a request still in flight")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown took %v, want it to give up at the drain timeout", elapsed)
+	}
+}
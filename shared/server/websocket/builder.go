@@ -13,9 +13,10 @@ import (
 
 // EngineBuilder builds a WebSocket engine
 type EngineBuilder struct {
-	engine *Engine
-	config *EngineConfig
-	log    logger.Logger
+	engine    *Engine
+	config    *EngineConfig
+	reconnect *reconnect.Config
+	log       logger.Logger
 }
 
 // NewEngineBuilder creates a new engine builder
@@ -70,7 +71,7 @@ func (b *EngineBuilder) WithHealthCheck(interval time.Duration) *EngineBuilder {
 
 // WithReconnect enables reconnection handling
 func (b *EngineBuilder) WithReconnect(config *reconnect.Config) *EngineBuilder {
-	// Store reconnect config
+	b.reconnect = config
 	return b
 }
 
@@ -110,7 +111,11 @@ func (b *EngineBuilder) Build() *Engine {
 	}
 
 	// Enable reconnection
-	engine.reconnect = reconnect.NewHandler(reconnect.DefaultConfig(), b.log)
+	reconnectConfig := b.reconnect
+	if reconnectConfig == nil {
+		reconnectConfig = reconnect.DefaultConfig()
+	}
+	engine.reconnect = reconnect.NewHandler(reconnectConfig, b.log)
 
 	return engine
 }
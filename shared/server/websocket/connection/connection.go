@@ -44,6 +44,7 @@ type Connection struct {
 	messagesReceived atomic.Int64
 	bytesSent        atomic.Int64
 	bytesReceived    atomic.Int64
+	rateLimited      atomic.Int64
 
 	// Configuration
 	config *Config
@@ -167,6 +168,7 @@ func (c *Connection) Stats() Stats {
 		MessagesReceived: c.messagesReceived.Load(),
 		BytesSent:        c.bytesSent.Load(),
 		BytesReceived:    c.bytesReceived.Load(),
+		RateLimited:      c.rateLimited.Load(),
 		Uptime:           time.Since(c.createdAt),
 	}
 }
@@ -229,6 +231,11 @@ func (c *Connection) IncrementMessagesReceived() {
 	c.messagesReceived.Add(1)
 }
 
+// IncrementRateLimited counts a received message rejected by a rate limit
+func (c *Connection) IncrementRateLimited() {
+	c.rateLimited.Add(1)
+}
+
 // AddBytesSent adds to bytes sent counter
 func (c *Connection) AddBytesSent(n int64) {
 	c.bytesSent.Add(n)
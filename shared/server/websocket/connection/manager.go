@@ -45,11 +45,11 @@ func (m *Manager) Add(conn *Connection) error {
 	}
 
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	m.connections[conn.ID()] = conn
 	m.currentCount.Add(1)
+	m.mu.Unlock()
 
+	// Hooks run without the lock so they can look up other connections
 	if m.onConnect != nil {
 		m.onConnect(conn)
 	}
@@ -61,18 +61,22 @@ func (m *Manager) Add(conn *Connection) error {
 // Remove removes a connection from the manager
 func (m *Manager) Remove(connID string) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if conn, exists := m.connections[connID]; exists {
+	conn, exists := m.connections[connID]
+	if exists {
 		delete(m.connections, connID)
 		m.currentCount.Add(-1)
+	}
+	m.mu.Unlock()
 
-		if m.onDisconnect != nil {
-			m.onDisconnect(conn)
-		}
+	if !exists {
+		return
+	}
 
-		m.log.Info("Connection removed", logger.String("conn_id", connID))
+	if m.onDisconnect != nil {
+		m.onDisconnect(conn)
 	}
+
+	m.log.Info("Connection removed", logger.String("conn_id", connID))
 }
 
 // Get retrieves a connection by ID
@@ -12,5 +12,6 @@ type Stats struct {
 	MessagesReceived int64         `json:"messages_received"`
 	BytesSent        int64         `json:"bytes_sent"`
 	BytesReceived    int64         `json:"bytes_received"`
+	RateLimited      int64         `json:"rate_limited"`
 	Uptime           time.Duration `json:"uptime"`
 }
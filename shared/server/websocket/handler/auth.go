@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"shared/server/common/token"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// AccessTokenSubprotocol lets browsers, which cannot set headers on a
+// WebSocket handshake, send their access token as the subprotocol after it:
+// new WebSocket(url, ["access_token", token]). The server echoes back only
+// this name, never the token.
+const AccessTokenSubprotocol = "access_token"
+
+// Identity is who an upgrade request authenticated as
+type Identity struct {
+	UserID uuid.UUID
+	// Metadata holds verified values such as session_id. They are set on the
+	// connection after ExtractMetadata runs, so the client cannot override them.
+	Metadata map[string]any
+}
+
+// Authenticator verifies an upgrade request before the HTTP connection is
+// upgraded. Errors wrapping ErrInvalidCredentials are answered with 401.
+type Authenticator func(r *http.Request) (Identity, error)
+
+// TokenAuthenticator authenticates the upgrade with an access token taken from
+// the Authorization header, the AccessTokenSubprotocol subprotocol or the
+// access_token query param, in that order. The user comes from the token's
+// subject, and device_id and session_id from its metadata when present.
+//
+// Tokens the denylist reports revoked are rejected, so a revoked device
+// cannot reconnect with a token it still holds. As with middleware.JWTAuth,
+// lookup failures are let through and a nil denylist checks nothing.
+func TokenAuthenticator(ts *token.JWTTokenService, denylist *token.Denylist) Authenticator {
+	return func(r *http.Request) (Identity, error) {
+		raw, err := accessTokenFromRequest(r)
+		if err != nil {
+			return Identity{}, err
+		}
+
+		claims, err := ts.Validate(r.Context(), raw, token.TokenTypeAccess)
+		if err != nil {
+			return Identity{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+		}
+		if denylist != nil {
+			if revoked, _ := denylist.IsRevoked(r.Context(), claims); revoked {
+				return Identity{}, fmt.Errorf("%w: token has been revoked", ErrInvalidCredentials)
+			}
+		}
+		userID, err := uuid.Parse(claims.Subject)
+		if err != nil {
+			return Identity{}, fmt.Errorf("%w: invalid token subject", ErrInvalidCredentials)
+		}
+
+		identity := Identity{UserID: userID, Metadata: make(map[string]any)}
+		for _, key := range []string{"device_id", "session_id"} {
+			if value, ok := claims.Metadata[key].(string); ok && value != "" {
+				identity.Metadata[key] = value
+			}
+		}
+		return identity, nil
+	}
+}
+
+// accessTokenFromRequest returns the raw access token the client sent
+func accessTokenFromRequest(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			return "", fmt.Errorf("%w: invalid authorization format", ErrInvalidCredentials)
+		}
+		return nonEmptyToken(parts[1])
+	}
+
+	protocols := websocket.Subprotocols(r)
+	for i, protocol := range protocols {
+		if protocol != AccessTokenSubprotocol {
+			continue
+		}
+		if i+1 == len(protocols) {
+			return "", fmt.Errorf("%w: %s subprotocol without a token", ErrInvalidCredentials, AccessTokenSubprotocol)
+		}
+		return nonEmptyToken(protocols[i+1])
+	}
+
+	return nonEmptyToken(r.URL.Query().Get("access_token"))
+}
+
+func nonEmptyToken(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("%w: access token is required", ErrInvalidCredentials)
+	}
+	return raw, nil
+}
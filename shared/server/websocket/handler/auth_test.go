@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"shared/pkg/cache/memory"
+	"shared/pkg/logger"
+	"shared/server/common/token"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+func newTestTokenService(t *testing.T) *token.JWTTokenService {
+	t.Helper()
+	ks, err := token.NewStaticKeySet([]byte("handler-test-secret-key"))
+	if err != nil {
+		t.Fatalf("NewStaticKeySet: %v", err)
+	}
+	ts, err := token.NewJWTTokenService(token.Config{
+		KeySet:          ks,
+		Issuer:          "test-issuer",
+		Audience:        []string{"test-audience"},
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewJWTTokenService: %v", err)
+	}
+	return ts
+}
+
+// newAuthServer serves upgrades authenticated by ts and denylist and reports
+// each connection it accepts on the returned channel
+func newAuthServer(t *testing.T, ts *token.JWTTokenService, denylist *token.Denylist) (string, <-chan *Connection) {
+	t.Helper()
+	log := logger.NewNoop()
+	connected := make(chan *Connection, 1)
+	cfg := DefaultConfig()
+	cfg.Authenticate = TokenAuthenticator(ts, denylist)
+	cfg.ExtractMetadata = DefaultMetadataExtractor
+	cfg.OnConnected = func(conn *Connection) { connected <- conn }
+	h := New(newTestEngine(log), cfg, log)
+
+	server := httptest.NewServer(http.HandlerFunc(h.HandleUpgrade))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), connected
+}
+
+func TestHandleUpgrade_RejectsMissingTokenWith401(t *testing.T) {
+	url, connected := newAuthServer(t, newTestTokenService(t), nil)
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?user_id="+uuid.New().String(), nil)
+	if err == nil {
+		t.Fatalf("dial without a token succeeded")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("response = %v, want 401", resp)
+	}
+	if len(connected) != 0 {
+		t.Fatalf("a connection was created for an unauthenticated request")
+	}
+}
+
+func TestHandleUpgrade_RejectsInvalidTokenWith401(t *testing.T) {
+	ts := newTestTokenService(t)
+	url, _ := newAuthServer(t, ts, nil)
+	pair, err := ts.IssuePair(context.Background(), uuid.New().String(), token.IssueOptions{})
+	if err != nil {
+		t.Fatalf("IssuePair: %v", err)
+	}
+
+	for name, header := range map[string]http.Header{
+		"garbage":       {"Sec-Websocket-Protocol": {AccessTokenSubprotocol + ", not-a-jwt"}},
+		"refresh token": {"Authorization": {"Bearer " + pair.RefreshToken.Token}},
+		"no token":      {"Sec-Websocket-Protocol": {AccessTokenSubprotocol}},
+	} {
+		_, resp, err := websocket.DefaultDialer.Dial(url, header)
+		if err == nil {
+			t.Fatalf("%s: dial succeeded", name)
+		}
+		if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("%s: response = %v, want 401", name, resp)
+		}
+	}
+}
+
+func TestHandleUpgrade_SubprotocolTokenSetsVerifiedIdentity(t *testing.T) {
+	ts := newTestTokenService(t)
+	url, connected := newAuthServer(t, ts, nil)
+	userID := uuid.New()
+	access, err := ts.IssueAccessToken(context.Background(), userID.String(), token.IssueOptions{
+		Metadata: map[string]any{"device_id": "phone-1", "session_id": "session-1"},
+	})
+	if err != nil {
+		t.Fatalf("IssueAccessToken: %v", err)
+	}
+
+	// Client-supplied IDs must not override the token's
+	header := http.Header{"X-Device-Id": {"spoofed"}}
+	dialer := websocket.Dialer{Subprotocols: []string{AccessTokenSubprotocol, access.Token}}
+	c, resp, err := dialer.Dial(url+"?user_id="+uuid.New().String(), header)
+	if err != nil {
+		t.Fatalf("dial with a valid token failed: %v", err)
+	}
+	defer c.Close()
+	if got := resp.Header.Get("Sec-Websocket-Protocol"); got != AccessTokenSubprotocol {
+		t.Fatalf("selected subprotocol = %q, want %q", got, AccessTokenSubprotocol)
+	}
+
+	var conn *Connection
+	select {
+	case conn = <-connected:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("connection was not registered")
+	}
+	if got, _ := conn.GetMetadata("user_id"); got != userID {
+		t.Fatalf("user_id = %v, want token subject %s", got, userID)
+	}
+	if got, _ := conn.GetMetadata("device_id"); got != "phone-1" {
+		t.Fatalf("device_id = %v, want the token's phone-1", got)
+	}
+}
+
+func TestHandleUpgrade_RejectsRevokedTokenOnReconnect(t *testing.T) {
+	ts := newTestTokenService(t)
+	denylist := token.NewDenylist(memory.New())
+	url, connected := newAuthServer(t, ts, denylist)
+	access, err := ts.IssueAccessToken(context.Background(), uuid.New().String(), token.IssueOptions{
+		Metadata: map[string]any{"device_id": "phone-1", "session_id": "session-1"},
+	})
+	if err != nil {
+		t.Fatalf("IssueAccessToken: %v", err)
+	}
+	header := http.Header{"Authorization": {"Bearer " + access.Token}}
+
+	c, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("dial before revocation failed: %v", err)
+	}
+	c.Close()
+	<-connected
+
+	if err := denylist.RevokeSession(context.Background(), "session-1", time.Minute); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if err == nil {
+		t.Fatalf("reconnect with a revoked token succeeded")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("response = %v, want 401", resp)
+	}
+	if len(connected) != 0 {
+		t.Fatalf("a connection was created for a revoked token")
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"sync"
 	"time"
 
 	"shared/pkg/logger"
+	"shared/server/common/token"
 	"shared/server/websocket/connection"
+	"shared/server/websocket/metrics"
 	"shared/server/websocket/state"
 
 	"github.com/google/uuid"
@@ -32,6 +35,8 @@ type ConnectionMetadataFunc func(r *http.Request) map[string]any
 // Engine interface to avoid circular dependency
 type Engine interface {
 	ConnectionManager() *connection.Manager
+	// Metrics collects the connections and frames the handler serves
+	Metrics() *metrics.Collector
 }
 
 // Config holds handler configuration
@@ -44,14 +49,20 @@ type Config struct {
 	ReadTimeout    time.Duration
 	StaleTimeout   time.Duration
 
-	// Upgrader configuration
+	// MaxConnectionsPerUser caps concurrent sockets per user; 0 means unlimited.
+	// Connections over the cap are closed with CloseTryAgainLater (1013).
+	MaxConnectionsPerUser int
+
+	// Upgrader configuration. Handshakes CheckOrigin rejects get 403 before
+	// any other check; see AllowedOriginChecker.
 	CheckOrigin       func(r *http.Request) bool
 	ReadBufferSize    int
 	WriteBufferSize   int
 	EnableCompression bool
 
-	// Callbacks
+	// Callbacks. Authenticate, when set, replaces ExtractUserID.
 	ValidateUser    UserValidator
+	Authenticate    Authenticator
 	ExtractUserID   UserIDExtractor
 	HandleMessage   MessageHandler
 	ExtractMetadata ConnectionMetadataFunc
@@ -87,6 +98,9 @@ type Handler struct {
 	upgrader websocket.Upgrader
 	config   *Config
 	log      logger.Logger
+
+	userConns   map[uuid.UUID]int
+	userConnsMu sync.Mutex
 }
 
 // New creates a new WebSocket handler
@@ -99,13 +113,45 @@ func New(engine Engine, config *Config, log logger.Logger) *Handler {
 		engine: engine,
 		upgrader: websocket.Upgrader{
 			CheckOrigin:       config.CheckOrigin,
+			Subprotocols:      []string{AccessTokenSubprotocol},
 			ReadBufferSize:    config.ReadBufferSize,
 			WriteBufferSize:   config.WriteBufferSize,
 			EnableCompression: config.EnableCompression,
 		},
-		config: config,
-		log:    log,
+		config:    config,
+		log:       log,
+		userConns: make(map[uuid.UUID]int),
+	}
+}
+
+// acquireUserSlot reserves a connection slot for the user, reporting false
+// when the user is already at MaxConnectionsPerUser
+func (h *Handler) acquireUserSlot(userID uuid.UUID) bool {
+	h.userConnsMu.Lock()
+	defer h.userConnsMu.Unlock()
+	if h.config.MaxConnectionsPerUser > 0 && h.userConns[userID] >= h.config.MaxConnectionsPerUser {
+		return false
 	}
+	h.userConns[userID]++
+	return true
+}
+
+// releaseUserSlot frees a slot taken by acquireUserSlot
+func (h *Handler) releaseUserSlot(userID uuid.UUID) {
+	h.userConnsMu.Lock()
+	defer h.userConnsMu.Unlock()
+	if h.userConns[userID] <= 1 {
+		delete(h.userConns, userID)
+		return
+	}
+	h.userConns[userID]--
+}
+
+// UserConnectionCount returns the number of open sockets for a user
+func (h *Handler) UserConnectionCount(userID uuid.UUID) int {
+	h.userConnsMu.Lock()
+	defer h.userConnsMu.Unlock()
+	return h.userConns[userID]
 }
 
 // HandleUpgrade handles the WebSocket upgrade request
@@ -115,13 +161,27 @@ func (h *Handler) HandleUpgrade(w http.ResponseWriter, r *http.Request) {
 		logger.String("user_agent", r.UserAgent()),
 	)
 
-	// Extract user ID
-	userID, err := h.config.ExtractUserID(r)
+	if h.config.CheckOrigin != nil && !h.config.CheckOrigin(r) {
+		h.log.Warn("Rejected WebSocket upgrade from disallowed origin",
+			logger.String("origin", r.Header.Get("Origin")),
+			logger.String("remote_addr", r.RemoteAddr),
+		)
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	// Authenticate before upgrading so no socket exists for an unknown caller
+	identity, err := h.identify(r)
 	if err != nil {
-		h.log.Warn("Failed to extract user ID", logger.Error(err))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.log.Warn("Failed to authenticate WebSocket upgrade", logger.Error(err))
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrInvalidCredentials) {
+			status = http.StatusUnauthorized
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
+	userID := identity.UserID
 
 	// Validate user if validator is provided
 	if h.config.ValidateUser != nil {
@@ -138,10 +198,30 @@ func (h *Handler) HandleUpgrade(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Reserve the slot before upgrading so concurrent handshakes for the same
+	// user cannot both slip under the limit
+	slotAcquired := h.acquireUserSlot(userID)
+
 	// Upgrade connection
 	wsConn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		h.log.Error("Failed to upgrade connection", logger.Error(err))
+		if slotAcquired {
+			h.releaseUserSlot(userID)
+		}
+		return
+	}
+
+	// Reject over-limit users after the upgrade so the client gets a close
+	// code it can back off on instead of an opaque HTTP error
+	if !slotAcquired {
+		h.log.Warn("Per-user connection limit reached",
+			logger.String("user_id", userID.String()),
+			logger.Int("limit", h.config.MaxConnectionsPerUser),
+		)
+		closeMsg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too many connections for user")
+		wsConn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(h.config.WriteTimeout))
+		wsConn.Close()
 		return
 	}
 
@@ -159,9 +239,6 @@ func (h *Handler) HandleUpgrade(w http.ResponseWriter, r *http.Request) {
 	connID := uuid.New().String()
 	conn := connection.New(connID, wsConn, connConfig, h.log)
 
-	// Set user ID metadata
-	conn.SetMetadata("user_id", userID)
-
 	// Extract additional metadata if provided
 	if h.config.ExtractMetadata != nil {
 		metadata := h.config.ExtractMetadata(r)
@@ -170,9 +247,17 @@ func (h *Handler) HandleUpgrade(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Verified identity wins over anything the client sent
+	for key, value := range identity.Metadata {
+		conn.SetMetadata(key, value)
+	}
+	conn.SetMetadata("user_id", userID)
+
 	// Add to connection manager
 	if err := h.engine.ConnectionManager().Add(conn); err != nil {
 		h.log.Error("Failed to add connection", logger.Error(err))
+		h.engine.Metrics().IncrementFailedConnections()
+		h.releaseUserSlot(userID)
 		conn.Close()
 		return
 	}
@@ -180,10 +265,13 @@ func (h *Handler) HandleUpgrade(w http.ResponseWriter, r *http.Request) {
 	// Transition to connected state
 	if err := conn.TransitionTo(state.StateConnected); err != nil {
 		h.log.Error("Failed to transition to connected state", logger.Error(err))
+		h.engine.Metrics().IncrementFailedConnections()
 		h.engine.ConnectionManager().Remove(conn.ID())
+		h.releaseUserSlot(userID)
 		conn.Close()
 		return
 	}
+	h.engine.Metrics().IncrementConnections()
 
 	h.log.Info("WebSocket connection established",
 		logger.String("conn_id", conn.ID()),
@@ -196,17 +284,32 @@ func (h *Handler) HandleUpgrade(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Start connection pumps
-	go h.startReadPump(conn, wsConn, r)
+	go h.startReadPump(conn, wsConn, r, userID)
 	go h.startWritePump(conn, wsConn, connConfig)
 }
 
-func (h *Handler) startReadPump(conn *Connection, wsConn *websocket.Conn, r *http.Request) {
+// identify returns who the upgrade request is from, using Authenticate when
+// configured and ExtractUserID otherwise
+func (h *Handler) identify(r *http.Request) (Identity, error) {
+	if h.config.Authenticate != nil {
+		return h.config.Authenticate(r)
+	}
+	userID, err := h.config.ExtractUserID(r)
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{UserID: userID}, nil
+}
+
+func (h *Handler) startReadPump(conn *Connection, wsConn *websocket.Conn, r *http.Request, userID uuid.UUID) {
 	defer func() {
 		if h.config.OnDisconnected != nil {
 			h.config.OnDisconnected(conn)
 		}
 		conn.Close()
 		h.engine.ConnectionManager().Remove(conn.ID())
+		h.engine.Metrics().DecrementConnections()
+		h.releaseUserSlot(userID)
 	}()
 
 	for {
@@ -239,6 +342,8 @@ func (h *Handler) startReadPump(conn *Connection, wsConn *websocket.Conn, r *htt
 		conn.UpdateActivity()
 		conn.IncrementMessagesReceived()
 		conn.AddBytesReceived(int64(len(message)))
+		h.engine.Metrics().IncrementMessagesReceived()
+		h.engine.Metrics().AddBytesReceived(int64(len(message)))
 
 		// Validate message if validator is configured
 		if h.config.MessageValidator != nil {
@@ -320,10 +425,13 @@ func (h *Handler) startWritePump(conn *Connection, wsConn *websocket.Conn, cfg *
 					logger.String("conn_id", conn.ID()),
 					logger.Error(err),
 				)
+				h.engine.Metrics().IncrementMessagesSentFailed()
 				return
 			}
 			conn.IncrementMessagesSent()
 			conn.AddBytesSent(int64(len(message)))
+			h.engine.Metrics().IncrementMessagesSent()
+			h.engine.Metrics().AddBytesSent(int64(len(message)))
 		case <-ticker.C:
 			deadline := time.Now().Add(cfg.WriteTimeout)
 			if err := wsConn.WriteControl(websocket.PingMessage, []byte{}, deadline); err != nil {
@@ -356,6 +464,20 @@ func DefaultUserIDExtractor(r *http.Request) (uuid.UUID, error) {
 	return userID, nil
 }
 
+// ErrInvalidCredentials is returned by extractors when the caller's credentials
+// are missing or invalid; HandleUpgrade answers it with 401
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// TokenUserIDExtractor authenticates the upgrade like TokenAuthenticator but
+// keeps only the user ID
+func TokenUserIDExtractor(ts *token.JWTTokenService, denylist *token.Denylist) UserIDExtractor {
+	authenticate := TokenAuthenticator(ts, denylist)
+	return func(r *http.Request) (uuid.UUID, error) {
+		identity, err := authenticate(r)
+		return identity.UserID, err
+	}
+}
+
 // DefaultMetadataExtractor extracts common metadata from request
 func DefaultMetadataExtractor(r *http.Request) map[string]any {
 	return map[string]any{
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"shared/pkg/logger"
+	"shared/server/common/token"
+	"shared/server/websocket/connection"
+	"shared/server/websocket/metrics"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+type testEngine struct {
+	connections *connection.Manager
+	metrics     *metrics.Collector
+}
+
+func (e *testEngine) ConnectionManager() *connection.Manager {
+	return e.connections
+}
+
+func (e *testEngine) Metrics() *metrics.Collector {
+	return e.metrics
+}
+
+func newTestEngine(log logger.Logger) *testEngine {
+	return &testEngine{
+		connections: connection.NewManager(100, time.Minute, log),
+		metrics:     metrics.NewCollector(0),
+	}
+}
+
+func TestHandleUpgrade_EnforcesPerUserLimit(t *testing.T) {
+	const limit = 2
+	log := logger.NewNoop()
+	cfg := DefaultConfig()
+	cfg.MaxConnectionsPerUser = limit
+	h := New(newTestEngine(log), cfg, log)
+
+	server := httptest.NewServer(http.HandlerFunc(h.HandleUpgrade))
+	defer server.Close()
+
+	userID := uuid.New()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=" + userID.String()
+
+	var open []*websocket.Conn
+	defer func() {
+		for _, c := range open {
+			c.Close()
+		}
+	}()
+	for i := 0; i < limit; i++ {
+		c, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("connection %d failed: %v", i+1, err)
+		}
+		open = append(open, c)
+	}
+
+	extra, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial over limit failed before upgrade: %v", err)
+	}
+	defer extra.Close()
+	extra.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = extra.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseTryAgainLater {
+		t.Fatalf("expected close code %d, got %v", websocket.CloseTryAgainLater, err)
+	}
+	if got := h.UserConnectionCount(userID); got != limit {
+		t.Fatalf("expected %d tracked connections, got %d", limit, got)
+	}
+
+	// Another user is unaffected by the first user's limit.
+	other, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?user_id="+uuid.New().String(), nil)
+	if err != nil {
+		t.Fatalf("other user's connection failed: %v", err)
+	}
+	open = append(open, other)
+}
+
+func TestHandleUpgrade_FeedsEngineMetrics(t *testing.T) {
+	log := logger.NewNoop()
+	engine := newTestEngine(log)
+	cfg := DefaultConfig()
+	cfg.HandleMessage = func(ctx context.Context, conn *Connection, message []byte) error {
+		return conn.Send(message)
+	}
+	h := New(engine, cfg, log)
+
+	server := httptest.NewServer(http.HandlerFunc(h.HandleUpgrade))
+	defer server.Close()
+
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?user_id="+uuid.New().String(), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	if err := c.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := c.ReadMessage(); err != nil {
+		t.Fatalf("echo not received: %v", err)
+	}
+
+	snapshot := engine.Metrics().GetSnapshot()
+	if snapshot.ActiveConnections != 1 || snapshot.MessagesReceived != 1 || snapshot.BytesReceived != 5 {
+		t.Fatalf("snapshot = %+v, want 1 connection and one 5-byte frame received", snapshot)
+	}
+	if snapshot.MessagesSent != 1 {
+		t.Fatalf("messages sent = %d, want the echo", snapshot.MessagesSent)
+	}
+
+	c.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for engine.Metrics().GetSnapshot().ActiveConnections != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("active connections stayed at %d after the client left", engine.Metrics().GetSnapshot().ActiveConnections)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := engine.Metrics().GetSnapshot().TotalDisconnections; got != 1 {
+		t.Fatalf("disconnections = %d, want 1", got)
+	}
+}
+
+func TestTokenUserIDExtractor(t *testing.T) {
+	ks, err := token.NewStaticKeySet([]byte("handler-test-secret-key"))
+	if err != nil {
+		t.Fatalf("NewStaticKeySet: %v", err)
+	}
+	ts, err := token.NewJWTTokenService(token.Config{
+		KeySet:          ks,
+		Issuer:          "test-issuer",
+		Audience:        []string{"test-audience"},
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewJWTTokenService: %v", err)
+	}
+	userID := uuid.New()
+	pair, err := ts.IssuePair(context.Background(), userID.String(), token.IssueOptions{})
+	if err != nil {
+		t.Fatalf("IssuePair: %v", err)
+	}
+	extract := TokenUserIDExtractor(ts, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken.Token)
+	if got, err := extract(req); err != nil || got != userID {
+		t.Fatalf("header token: got %s, %v", got, err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ws?access_token="+pair.AccessToken.Token, nil)
+	if got, err := extract(req); err != nil || got != userID {
+		t.Fatalf("query token: got %s, %v", got, err)
+	}
+
+	for name, req := range map[string]*http.Request{
+		"missing":       httptest.NewRequest(http.MethodGet, "/ws?user_id="+userID.String(), nil),
+		"refresh token": httptest.NewRequest(http.MethodGet, "/ws?access_token="+pair.RefreshToken.Token, nil),
+	} {
+		if _, err := extract(req); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("%s: expected ErrInvalidCredentials, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AllowedOriginChecker returns a CheckOrigin that accepts a browser's Origin
+// header only when it matches one of allowed. An entry is "*" for any
+// origin, a full origin like "https://app.example.com", or a host like
+// "app.example.com" that matches under any scheme. A leading "*." matches
+// subdomains only, so "https://*.example.com" accepts
+// "https://chat.example.com" but not "https://example.com". Requests without
+// an Origin header come from native clients rather than browsers and pass.
+func AllowedOriginChecker(allowed []string) func(r *http.Request) bool {
+	patterns := make([]originPattern, 0, len(allowed))
+	for _, entry := range allowed {
+		if p, ok := parseOriginPattern(entry); ok {
+			patterns = append(patterns, p)
+		}
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" {
+			return false
+		}
+		for _, p := range patterns {
+			if p.matches(strings.ToLower(u.Scheme), strings.ToLower(u.Host)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type originPattern struct {
+	any    bool
+	scheme string // empty matches any scheme
+	host   string // host[:port], without the "*." of a wildcard
+	suffix bool   // host is a parent domain and only subdomains match
+}
+
+func parseOriginPattern(entry string) (originPattern, bool) {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+	if entry == "" {
+		return originPattern{}, false
+	}
+	if entry == "*" {
+		return originPattern{any: true}, true
+	}
+
+	var p originPattern
+	if scheme, host, ok := strings.Cut(entry, "://"); ok {
+		p.scheme, entry = scheme, host
+	}
+	entry = strings.TrimSuffix(entry, "/")
+	if host, ok := strings.CutPrefix(entry, "*."); ok {
+		p.suffix, entry = true, host
+	}
+	p.host = entry
+	return p, p.host != ""
+}
+
+func (p originPattern) matches(scheme, host string) bool {
+	if p.any {
+		return true
+	}
+	if p.scheme != "" && p.scheme != scheme {
+		return false
+	}
+	if p.suffix {
+		return strings.HasSuffix(host, "."+p.host)
+	}
+	return host == p.host
+}
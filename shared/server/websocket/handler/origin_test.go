@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shared/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+func TestAllowedOriginChecker(t *testing.T) {
+	check := AllowedOriginChecker([]string{"https://app.example.com", "https://*.echo.chat", "localhost:3000"})
+	cases := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"http://app.example.com", false},
+		{"https://evil.example.com", false},
+		{"https://web.echo.chat", true},
+		{"https://a.b.echo.chat", true},
+		{"https://echo.chat", false},
+		{"https://notecho.chat", false},
+		{"http://localhost:3000", true},
+		{"http://localhost:4000", false},
+		{"null", false},
+		{"", true},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if c.origin != "" {
+			r.Header.Set("Origin", c.origin)
+		}
+		if got := check(r); got != c.want {
+			t.Fatalf("origin %q allowed = %v, want %v", c.origin, got, c.want)
+		}
+	}
+}
+
+func newOriginServer(t *testing.T, allowed ...string) (*httptest.Server, string) {
+	t.Helper()
+	log := logger.NewNoop()
+	cfg := DefaultConfig()
+	cfg.CheckOrigin = AllowedOriginChecker(allowed)
+	h := New(newTestEngine(log), cfg, log)
+
+	server := httptest.NewServer(http.HandlerFunc(h.HandleUpgrade))
+	t.Cleanup(server.Close)
+	return server, "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=" + uuid.New().String()
+}
+
+func TestHandleUpgrade_AllowsListedOrigin(t *testing.T) {
+	_, url := newOriginServer(t, "https://*.echo.chat")
+
+	c, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://web.echo.chat"}})
+	if err != nil {
+		t.Fatalf("dial from allowed origin failed: %v", err)
+	}
+	c.Close()
+}
+
+func TestHandleUpgrade_RejectsOtherOriginWith403(t *testing.T) {
+	_, url := newOriginServer(t, "https://*.echo.chat")
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://evil.example.com"}})
+	if err == nil {
+		t.Fatalf("dial from disallowed origin succeeded")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("response = %v, want 403 before upgrading", resp)
+	}
+}
+
+func TestHandleUpgrade_AllowsMissingOrigin(t *testing.T) {
+	_, url := newOriginServer(t, "https://app.example.com")
+
+	c, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial without an Origin header failed: %v", err)
+	}
+	c.Close()
+}
@@ -14,8 +14,16 @@ type Message struct {
 	Type    string
 	Payload []byte
 	Metadata map[string]interface{}
+
+	// AckID is set when the sender asked to be told whether the message was
+	// handled. Empty means no acknowledgement is sent.
+	AckID string
 }
 
+// AckFunc reports the routing outcome of a message that carries an AckID.
+// err is nil on success.
+type AckFunc func(ctx context.Context, msg *Message, err error)
+
 // Router routes messages to handlers based on message type
 type Router struct {
 	handlers map[string]Handler
@@ -26,6 +34,9 @@ type Router struct {
 
 	// Middleware chain
 	middleware []Middleware
+
+	// Called after routing for messages that request an ack
+	ack AckFunc
 }
 
 // New creates a new router
@@ -60,20 +71,34 @@ func (r *Router) SetFallback(handler Handler) {
 	r.fallback = handler
 }
 
+// SetAckHandler sets the function that acknowledges messages carrying an AckID
+func (r *Router) SetAckHandler(ack AckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ack = ack
+}
+
 // Route routes a message to its handler
 func (r *Router) Route(ctx context.Context, msg *Message) error {
 	r.mu.RLock()
 	handler, exists := r.handlers[msg.Type]
+	ack := r.ack
 	r.mu.RUnlock()
 
-	if !exists {
-		if r.fallback != nil {
-			return r.applyMiddleware(ctx, msg, r.fallback)
-		}
-		return fmt.Errorf("no handler for message type: %s", msg.Type)
+	var err error
+	switch {
+	case exists:
+		err = r.applyMiddleware(ctx, msg, handler)
+	case r.fallback != nil:
+		err = r.applyMiddleware(ctx, msg, r.fallback)
+	default:
+		err = fmt.Errorf("no handler for message type: %s", msg.Type)
 	}
 
-	return r.applyMiddleware(ctx, msg, handler)
+	if msg.AckID != "" && ack != nil {
+		ack(ctx, msg, err)
+	}
+	return err
 }
 
 // applyMiddleware applies middleware chain
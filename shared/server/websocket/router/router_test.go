@@ -0,0 +1,40 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRoute_AcksOnlyWhenRequested(t *testing.T) {
+	r := New()
+	r.Register("ok", func(ctx context.Context, msg *Message) error { return nil })
+	r.Register("fail", func(ctx context.Context, msg *Message) error { return errors.New("boom") })
+
+	acks := map[string]error{}
+	r.SetAckHandler(func(ctx context.Context, msg *Message, err error) {
+		acks[msg.AckID] = err
+	})
+
+	ctx := context.Background()
+	if err := r.Route(ctx, &Message{Type: "ok", AckID: "a1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Route(ctx, &Message{Type: "fail", AckID: "a2"}); err == nil {
+		t.Fatalf("expected handler error")
+	}
+	if err := r.Route(ctx, &Message{Type: "unknown", AckID: "a3"}); err == nil {
+		t.Fatalf("expected unknown type error")
+	}
+	_ = r.Route(ctx, &Message{Type: "ok"})
+
+	if len(acks) != 3 {
+		t.Fatalf("expected 3 acks, got %d", len(acks))
+	}
+	if acks["a1"] != nil {
+		t.Fatalf("expected success ack for a1, got %v", acks["a1"])
+	}
+	if acks["a2"] == nil || acks["a3"] == nil {
+		t.Fatalf("expected nacks for a2 and a3")
+	}
+}